@@ -78,6 +78,7 @@ export default function IncidentsPage() {
         // ReBAC: org_id is MANDATORY, project_id is OPTIONAL
         const filterParams = {
           org_id: currentOrg.id,
+          include_resolved: true,
           ...(currentProject?.id && { project_id: currentProject.id })
         };
         const data = await apiClient.getIncidents('', filterParams);
@@ -128,6 +129,7 @@ export default function IncidentsPage() {
         const filterParams = {
           ...filters,
           status: activeTab === 'any_status' ? '' : activeTab,
+          include_resolved: activeTab === 'any_status',
           org_id: currentOrg.id,
           ...(currentProject?.id && { project_id: currentProject.id })
         };
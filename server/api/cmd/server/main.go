@@ -7,11 +7,13 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	_ "github.com/lib/pq"
 
+	"github.com/phonginreallife/inres/handlers"
 	"github.com/phonginreallife/inres/internal/background"
 	"github.com/phonginreallife/inres/internal/config"
 	"github.com/phonginreallife/inres/router"
@@ -96,6 +98,10 @@ func main() {
 	// Initialize services for workers
 	fcmService, _ := services.NewFCMService(db)
 	incidentService := services.NewIncidentService(db, redisClient, fcmService)
+	incidentService.AssignmentNotificationDelay = time.Duration(config.App.AssignmentNotificationDelaySeconds) * time.Second
+	incidentService.PublicURL = config.App.PublicURL
+	incidentService.DailyIncidentQuota = config.App.IncidentDailyQuota
+	incidentService.ClusterWindow = time.Duration(config.App.IncidentClusterWindowMinutes) * time.Minute
 
 	// Initialize workers
 	notificationWorker := background.NewNotificationWorker(db, fcmService)
@@ -107,6 +113,23 @@ func main() {
 	log.Println("  Realtime broadcast service initialized")
 
 	incidentWorker := background.NewIncidentWorker(db, incidentService, notificationWorker)
+	incidentWorker.ArchiveResolvedAfterDays = config.App.ArchiveResolvedAfterDays
+	incidentWorker.TicketChecker = services.NewHTTPTicketChecker()
+	incidentWorker.EscalationService = services.NewEscalationService(db, nil, nil, fcmService)
+	incidentWorker.EscalationService.WebhookSecret = config.App.EscalationWebhookSecret
+	if rules, err := services.ParseSeverityUpgradeRules(config.App.SeverityAutoUpgrade); err != nil {
+		log.Printf("WARNING: Ignoring severity_auto_upgrade: %v", err)
+	} else {
+		incidentWorker.SeverityUpgradeRules = rules
+	}
+
+	// Webhook worker routes the payloads the webhook endpoint queues
+	webhookHandler := handlers.NewWebhookHandler(services.NewIntegrationService(db), services.NewAlertService(db, redisClient, fcmService), incidentService, services.NewServiceService(db))
+	webhookWorker := background.NewWebhookWorker(db, webhookHandler)
+	webhookWorker.EventRetentionDays = config.App.WebhookEventRetentionDays
+
+	// Enrichment worker completes incidents the async webhook fast path inserted
+	enrichmentWorker := background.NewEnrichmentWorker(db, webhookHandler)
 
 	// Start workers in background goroutines
 	var wg sync.WaitGroup
@@ -127,6 +150,22 @@ func main() {
 		incidentWorker.StartIncidentWorker()
 	}()
 
+	// Start webhook worker
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		log.Println("Starting webhook worker...")
+		webhookWorker.StartWebhookWorker()
+	}()
+
+	// Start incident enrichment worker
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		log.Println("Starting incident enrichment worker...")
+		enrichmentWorker.StartEnrichmentWorker()
+	}()
+
 	log.Println("Workers started successfully")
 
 	// Start server in a goroutine
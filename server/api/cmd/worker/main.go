@@ -9,6 +9,7 @@ import (
 	"syscall"
 
 	_ "github.com/lib/pq"
+	"github.com/phonginreallife/inres/handlers"
 	"github.com/phonginreallife/inres/internal/background"
 	"github.com/phonginreallife/inres/internal/config"
 	"github.com/phonginreallife/inres/services"
@@ -61,8 +62,25 @@ func main() {
 	incidentService.SetNotificationWorker(notificationWorker)
 
 	incidentWorker := background.NewIncidentWorker(pg, incidentService, notificationWorker)
+	incidentWorker.ArchiveResolvedAfterDays = config.App.ArchiveResolvedAfterDays
+	incidentWorker.TicketChecker = services.NewHTTPTicketChecker()
+	incidentWorker.EscalationService = services.NewEscalationService(pg, nil, nil, fcmService)
+	incidentWorker.EscalationService.WebhookSecret = config.App.EscalationWebhookSecret
+	if rules, err := services.ParseSeverityUpgradeRules(config.App.SeverityAutoUpgrade); err != nil {
+		log.Printf("WARNING: Ignoring severity_auto_upgrade: %v", err)
+	} else {
+		incidentWorker.SeverityUpgradeRules = rules
+	}
 	// uptimeWorker := workers.NewUptimeWorker(pg, incidentService) // Disabled for now
 
+	// Webhook worker routes the payloads the webhook endpoint queues
+	webhookHandler := handlers.NewWebhookHandler(services.NewIntegrationService(pg), services.NewAlertService(pg, nil, fcmService), incidentService, services.NewServiceService(pg))
+	webhookWorker := background.NewWebhookWorker(pg, webhookHandler)
+	webhookWorker.EventRetentionDays = config.App.WebhookEventRetentionDays
+
+	// Enrichment worker completes incidents the async webhook fast path inserted
+	enrichmentWorker := background.NewEnrichmentWorker(pg, webhookHandler)
+
 	// Start workers in separate goroutines
 	var wg sync.WaitGroup
 
@@ -82,6 +100,22 @@ func main() {
 		incidentWorker.StartIncidentWorker()
 	}()
 
+	// Start webhook worker
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		log.Println("Starting webhook worker...")
+		webhookWorker.StartWebhookWorker()
+	}()
+
+	// Start incident enrichment worker
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		log.Println("Starting incident enrichment worker...")
+		enrichmentWorker.StartEnrichmentWorker()
+	}()
+
 	// Start uptime monitoring worker - DISABLED
 	// wg.Add(1)
 	// go func() {
@@ -14,12 +14,14 @@ type Incident struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 
 	// Assignment & Acknowledgment
-	AssignedTo     string     `json:"assigned_to,omitempty"`
-	AssignedAt     *time.Time `json:"assigned_at,omitempty"`
-	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
-	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
-	ResolvedBy     string     `json:"resolved_by,omitempty"`
-	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	AssignedTo       string     `json:"assigned_to,omitempty"`
+	AssignedAt       *time.Time `json:"assigned_at,omitempty"`
+	AssignmentMethod string     `json:"assignment_method,omitempty"` // How AssignedTo was picked at creation: explicit, escalation_policy, on_call
+	AcknowledgedBy   string     `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt   *time.Time `json:"acknowledged_at,omitempty"`
+	ResolvedBy       string     `json:"resolved_by,omitempty"`
+	ResolvedAt       *time.Time `json:"resolved_at,omitempty"`
+	ArchivedAt       *time.Time `json:"archived_at,omitempty"` // Hidden from default lists, kept for reporting
 
 	// Source & Integration
 	Source        string `json:"source"`
@@ -35,6 +37,7 @@ type Incident struct {
 	CurrentEscalationLevel int        `json:"current_escalation_level"`
 	LastEscalatedAt        *time.Time `json:"last_escalated_at,omitempty"`
 	EscalationStatus       string     `json:"escalation_status"`
+	SnoozedUntil           *time.Time `json:"snoozed_until,omitempty"` // Escalation paused until this time
 
 	// Grouping & Organization
 	GroupID        string `json:"group_id,omitempty"`
@@ -48,6 +51,30 @@ type Incident struct {
 	AlertCount   int                    `json:"alert_count"`
 	Labels       map[string]interface{} `json:"labels,omitempty"`
 	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+
+	// SLA targets in minutes; fall back to the service's defaults when unset
+	ResponseSLAMinutes   *int `json:"response_sla_minutes,omitempty"`
+	ResolutionSLAMinutes *int `json:"resolution_sla_minutes,omitempty"`
+
+	// Deploy this incident is correlated to (created shortly after it)
+	RelatedDeployID string `json:"related_deploy_id,omitempty"`
+
+	// Metric/graph snapshot image from the alert, embedded in the timeline
+	SnapshotURL string `json:"snapshot_url,omitempty"`
+
+	// Customer and revenue impact, set by responders
+	BusinessImpact *BusinessImpact `json:"business_impact,omitempty"`
+
+	// Cluster incident this incident was grouped under for having labels similar to other
+	// incidents opened around the same time
+	ClusterID string `json:"cluster_id,omitempty"`
+}
+
+// BusinessImpact is what an incident costs the business while it lasts
+type BusinessImpact struct {
+	CustomersAffected int      `json:"customers_affected"`
+	RevenuePerMinute  float64  `json:"revenue_per_minute"` // Revenue lost per minute of the incident
+	Services          []string `json:"services,omitempty"` // Impacted business services, e.g. "checkout"
 }
 
 // IncidentResponse includes additional information for API responses
@@ -71,19 +98,132 @@ type IncidentResponse struct {
 	// Escalation information
 	EscalationPolicyName string `json:"escalation_policy_name,omitempty"`
 
+	// Organization and project information
+	OrganizationName string `json:"organization_name,omitempty"`
+	ProjectName      string `json:"project_name,omitempty"`
+
+	// Deploy correlation
+	RelatedDeploy *DeployEvent `json:"related_deploy,omitempty"`
+	LikelyCause   string       `json:"likely_cause,omitempty"` // e.g. "Likely caused by deploy v1.4.2"
+
+	// Countdown of the running SLA (response until acknowledged, then resolution);
+	// negative once breached, unset when no SLA applies
+	SLAType             string `json:"sla_type,omitempty"`
+	SLARemainingSeconds *int64 `json:"sla_remaining_seconds,omitempty"`
+
 	// Recent events
 	RecentEvents []IncidentEvent `json:"recent_events,omitempty"`
+
+	// Users subscribed to changes of this incident
+	Watchers []IncidentWatcher `json:"watchers,omitempty"`
+
+	// Linked postmortems, runbooks, screenshots and logs
+	Attachments []IncidentAttachment `json:"attachments,omitempty"`
+}
+
+// AddIncidentWatcherRequest subscribes a user to an incident; defaults to the current user
+type AddIncidentWatcherRequest struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+// IncidentWatcher is a user subscribed to every change of an incident
+type IncidentWatcher struct {
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Incident attachment kinds
+const (
+	AttachmentKindPostmortem = "postmortem"
+	AttachmentKindRunbook    = "runbook"
+	AttachmentKindScreenshot = "screenshot"
+	AttachmentKindLog        = "log"
+)
+
+// AddIncidentAttachmentRequest links a URL to an incident
+type AddIncidentAttachmentRequest struct {
+	URL   string `json:"url" binding:"required"`
+	Title string `json:"title,omitempty"`
+	Kind  string `json:"kind" binding:"required"`
+}
+
+// IncidentAttachment is a link (postmortem, runbook, screenshot, log) kept on an incident
+type IncidentAttachment struct {
+	ID            string    `json:"id"`
+	IncidentID    string    `json:"incident_id"`
+	URL           string    `json:"url"`
+	Title         string    `json:"title,omitempty"`
+	Kind          string    `json:"kind"`
+	CreatedBy     string    `json:"created_by,omitempty"`
+	CreatedByName string    `json:"created_by_name,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// External ticket providers an incident can be put on hold against
+const (
+	ExternalTicketProviderGitHub  = "github"
+	ExternalTicketProviderJira    = "jira"
+	ExternalTicketProviderGeneric = "generic" // Any URL returning JSON with a status field
+)
+
+// IncidentExternalTicket is the vendor ticket an external_pending incident is waiting on
+type IncidentExternalTicket struct {
+	IncidentID string     `json:"incident_id"`
+	Provider   string     `json:"provider"`
+	URL        string     `json:"url"`
+	Status     string     `json:"status,omitempty"`     // Last status seen on the ticket
+	CheckedAt  *time.Time `json:"checked_at,omitempty"` // Last time the ticket was polled
+	CreatedBy  string     `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HoldIncidentRequest puts an incident on hold pending an external ticket
+type HoldIncidentRequest struct {
+	Provider  string `json:"provider" binding:"required,oneof=github jira generic"`
+	TicketURL string `json:"ticket_url" binding:"required"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// IncidentAssignment is one hand-over in an incident's assignment history.
+// From is empty for the first assignment.
+type IncidentAssignment struct {
+	From     string    `json:"from,omitempty"`
+	FromName string    `json:"from_name,omitempty"`
+	To       string    `json:"to"`
+	ToName   string    `json:"to_name,omitempty"`
+	At       time.Time `json:"at"`
+	Method   string    `json:"method"` // "manual", "auto_assignment", "escalation", "rebalance"
+	By       string    `json:"by,omitempty"`
+}
+
+// IncidentReassignment is one incident moved by RebalanceAssignments
+type IncidentReassignment struct {
+	IncidentID string `json:"incident_id"`
+	From       string `json:"from,omitempty"` // empty when the incident was unassigned
+	To         string `json:"to"`
+}
+
+// RebalanceResult summarises a group's assignment rebalance
+type RebalanceResult struct {
+	GroupID       string                 `json:"group_id"`
+	OnCallUsers   []string               `json:"on_call_users"`
+	Reassignments []IncidentReassignment `json:"reassignments"`
+	Load          map[string]int         `json:"load"` // open, unacknowledged incidents per on-call user afterwards
 }
 
 // IncidentEvent represents an event in the incident timeline
 type IncidentEvent struct {
-	ID            string                 `json:"id"`
-	IncidentID    string                 `json:"incident_id"`
-	EventType     string                 `json:"event_type"`
-	EventData     map[string]interface{} `json:"event_data,omitempty"`
-	CreatedAt     time.Time              `json:"created_at"`
-	CreatedBy     string                 `json:"created_by,omitempty"`
-	CreatedByName string                 `json:"created_by_name,omitempty"`
+	ID             string                 `json:"id"`
+	IncidentID     string                 `json:"incident_id"`
+	EventType      string                 `json:"event_type"`
+	EventData      map[string]interface{} `json:"event_data,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+	CreatedBy      string                 `json:"created_by,omitempty"`
+	CreatedByName  string                 `json:"created_by_name,omitempty"`
+	CreatedByEmail string                 `json:"created_by_email,omitempty"`
+	CreatedByRole  string                 `json:"created_by_role,omitempty"` // "system" for events without a user
 }
 
 // RawAlert represents raw alert data before processing into incidents
@@ -110,12 +250,16 @@ type CreateIncidentRequest struct {
 	ServiceID          string                 `json:"service_id,omitempty"`
 	GroupID            string                 `json:"group_id,omitempty"`
 	EscalationPolicyID string                 `json:"escalation_policy_id,omitempty"`
+	AssignedTo         string                 `json:"assigned_to,omitempty"`  // Overrides escalation policy and on-call assignment
 	IncidentKey        string                 `json:"incident_key,omitempty"` // For deduplication
 	Severity           string                 `json:"severity,omitempty"`
 	Labels             map[string]interface{} `json:"labels,omitempty"`
 	CustomFields       map[string]interface{} `json:"custom_fields,omitempty"`
 	ProjectID          string                 `json:"project_id,omitempty"`      // Project scoping
 	OrganizationID     string                 `json:"organization_id,omitempty"` // Tenant isolation - MANDATORY
+
+	ResponseSLAMinutes   *int `json:"response_sla_minutes,omitempty" binding:"omitempty,min=1"`
+	ResolutionSLAMinutes *int `json:"resolution_sla_minutes,omitempty" binding:"omitempty,min=1"`
 }
 
 // UpdateIncidentRequest for updating an incident
@@ -128,11 +272,14 @@ type UpdateIncidentRequest struct {
 	Severity     *string                `json:"severity,omitempty"`
 	Labels       map[string]interface{} `json:"labels,omitempty"`
 	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+
+	BusinessImpact *BusinessImpact `json:"business_impact,omitempty"`
 }
 
 // AcknowledgeIncidentRequest for acknowledging an incident
 type AcknowledgeIncidentRequest struct {
-	Note string `json:"note,omitempty"`
+	Note string     `json:"note,omitempty"`
+	ETA  *time.Time `json:"eta,omitempty"` // Estimated resolution time
 }
 
 // ResolveIncidentRequest for resolving an incident
@@ -141,6 +288,60 @@ type ResolveIncidentRequest struct {
 	Resolution string `json:"resolution,omitempty"`
 }
 
+// ReopenIncidentRequest for reopening a resolved incident
+type ReopenIncidentRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// SetIncidentEscalationPolicyRequest for escalating an incident through a different policy
+type SetIncidentEscalationPolicyRequest struct {
+	EscalationPolicyID string `json:"escalation_policy_id" binding:"required"`
+}
+
+// BulkUpdateIncidentsRequest for acknowledging or resolving several incidents at once
+type BulkUpdateIncidentsRequest struct {
+	IncidentIDs []string `json:"incident_ids" binding:"required,min=1,max=100"`
+	Action      string   `json:"action" binding:"required,oneof=acknowledge resolve"`
+	Note        string   `json:"note,omitempty"`
+}
+
+// BulkUpdateResult reports the per-incident outcome of a bulk status update
+type BulkUpdateResult struct {
+	Succeeded    int               `json:"succeeded"`
+	Failed       int               `json:"failed"`
+	SucceededIDs []string          `json:"succeeded_ids"`
+	Errors       map[string]string `json:"errors"` // incident ID -> error message
+}
+
+// IncidentLabelDiff describes how the labels of a resolving alert differ from the firing alert
+type IncidentLabelDiff struct {
+	Added   map[string]interface{}         `json:"added,omitempty"`
+	Removed map[string]interface{}         `json:"removed,omitempty"`
+	Changed map[string]IncidentLabelChange `json:"changed,omitempty"`
+}
+
+// IncidentLabelChange is a label whose value changed between fire and resolve
+type IncidentLabelChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// IsEmpty reports whether the labels were identical
+func (d *IncidentLabelDiff) IsEmpty() bool {
+	return d == nil || (len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0)
+}
+
+// SnoozeIncidentRequest for pausing escalation of an incident
+type SnoozeIncidentRequest struct {
+	DurationMinutes int    `json:"duration_minutes" binding:"required,min=1,max=10080"` // Up to 7 days
+	Reason          string `json:"reason,omitempty"`
+}
+
+// MergeIncidentsRequest for merging duplicate incidents into the incident in the URL
+type MergeIncidentsRequest struct {
+	IncidentIDs []string `json:"incident_ids" binding:"required,min=1,max=100"`
+}
+
 // AssignIncidentRequest for assigning an incident
 type AssignIncidentRequest struct {
 	AssignedTo string `json:"assigned_to" binding:"required"`
@@ -188,14 +389,29 @@ const (
 	IncidentStatusTriggered    = "triggered"
 	IncidentStatusAcknowledged = "acknowledged"
 	IncidentStatusResolved     = "resolved"
+
+	// IncidentStatusExternalPending is an incident on hold, waiting on an external vendor ticket.
+	// It is still open but doesn't escalate.
+	IncidentStatusExternalPending = "external_pending"
 )
 
+// OpenIncidentStatuses are the statuses of incidents that aren't resolved yet
+var OpenIncidentStatuses = []string{IncidentStatusTriggered, IncidentStatusAcknowledged, IncidentStatusExternalPending}
+
 // Incident urgency levels
 const (
 	IncidentUrgencyLow  = "low"
 	IncidentUrgencyHigh = "high"
 )
 
+// Assignment methods of a new incident, in precedence order.
+// An assignee set without a method is explicit.
+const (
+	AssignmentMethodExplicit         = "explicit"
+	AssignmentMethodEscalationPolicy = "escalation_policy"
+	AssignmentMethodOnCall           = "on_call"
+)
+
 // Incident event types
 const (
 	IncidentEventTriggered    = "triggered"
@@ -205,6 +421,33 @@ const (
 	IncidentEventEscalated    = "escalated"
 	IncidentEventNoteAdded    = "note_added"
 	IncidentEventUpdated      = "updated"
+	IncidentEventMerged       = "merged"
+	IncidentEventSnoozed      = "snoozed"
+	IncidentEventUnsnoozed    = "unsnoozed"
+	IncidentEventReopened     = "reopened"
+	IncidentEventSLABreached  = "sla_breached"
+	IncidentEventArchived     = "archived"
+
+	IncidentEventAttachmentAdded = "attachment_added"
+
+	IncidentEventOnHold                = "on_hold"
+	IncidentEventHoldReleased          = "hold_released"
+	IncidentEventExternalTicketUpdated = "external_ticket_updated"
+
+	IncidentEventNotificationSuppressed = "notification_suppressed"
+	IncidentEventNotificationDelivered  = "notification_delivered"
+	IncidentEventNotificationRead       = "notification_read"
+	IncidentEventAssigneeUnreachable    = "assignee_unreachable"
+
+	IncidentEventEscalationPolicyChanged = "escalation_policy_changed"
+	IncidentEventETAPassed               = "eta_passed"
+	IncidentEventETAUpdated              = "eta_updated"
+)
+
+// Bulk update actions
+const (
+	IncidentBulkActionAcknowledge = "acknowledge"
+	IncidentBulkActionResolve     = "resolve"
 )
 
 // Webhook event actions
@@ -214,6 +457,64 @@ const (
 	WebhookActionResolve     = "resolve"
 )
 
+// DeployEvent is a deployment (change event) that incidents can be correlated to
+type DeployEvent struct {
+	ID             string                 `json:"id"`
+	OrganizationID string                 `json:"organization_id,omitempty"`
+	ProjectID      string                 `json:"project_id,omitempty"`
+	ServiceID      string                 `json:"service_id"`
+	Version        string                 `json:"version"`
+	Description    string                 `json:"description,omitempty"`
+	Environment    string                 `json:"environment,omitempty"`
+	Source         string                 `json:"source,omitempty"` // github, argocd, api, ...
+	DeployedBy     string                 `json:"deployed_by,omitempty"`
+	URL            string                 `json:"url,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	DeployedAt     time.Time              `json:"deployed_at"`
+	CreatedAt      time.Time              `json:"created_at"`
+	CreatedBy      string                 `json:"created_by,omitempty"`
+}
+
+// CreateDeployEventRequest represents a deploy event reported by CI/CD
+type CreateDeployEventRequest struct {
+	ServiceID      string                 `json:"service_id" binding:"required"`
+	Version        string                 `json:"version" binding:"required"`
+	Description    string                 `json:"description,omitempty"`
+	Environment    string                 `json:"environment,omitempty"`
+	Source         string                 `json:"source,omitempty"`
+	DeployedBy     string                 `json:"deployed_by,omitempty"`
+	URL            string                 `json:"url,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	DeployedAt     *time.Time             `json:"deployed_at,omitempty"` // Defaults to now
+	ProjectID      string                 `json:"project_id,omitempty"`
+	OrganizationID string                 `json:"organization_id,omitempty"`
+}
+
+// SLA breach types
+const (
+	SLATypeResponse   = "response"
+	SLATypeResolution = "resolution"
+)
+
+// SLABreach describes an incident whose acknowledgement or resolution exceeded its SLA target
+type SLABreach struct {
+	IncidentID     string    `json:"incident_id"`
+	Title          string    `json:"title"`
+	Status         string    `json:"status"`
+	SLAType        string    `json:"sla_type"` // response, resolution
+	SLAMinutes     int       `json:"sla_minutes"`
+	ElapsedMinutes int       `json:"elapsed_minutes"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// SeverityUpgrade records an incident whose severity was bumped for staying unresolved too long
+type SeverityUpgrade struct {
+	IncidentID       string `json:"incident_id"`
+	PreviousSeverity string `json:"previous_severity"`
+	Severity         string `json:"severity"`
+	Priority         string `json:"priority,omitempty"`
+}
+
 // EscalationResult represents the result of a manual escalation
 type EscalationResult struct {
 	NewLevel         int    `json:"new_level"`
@@ -1,6 +1,9 @@
 package db
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ===========================
 // INTEGRATION MODELS
@@ -33,8 +36,42 @@ type Integration struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	CreatedBy string    `json:"created_by,omitempty"`
 
+	// Webhooks rejected by the rate limiter
+	DroppedAlerts int64 `json:"dropped_alerts"`
+
 	// For API responses
-	ServicesCount int `json:"services_count,omitempty"` // Number of linked services
+	ServicesCount int                   `json:"services_count,omitempty"` // Number of linked services
+	RateLimit     *IntegrationRateLimit `json:"rate_limit,omitempty"`     // Webhook rate limit and current rate
+}
+
+// IntegrationRateLimit is an integration's webhook rate limit and how close it is to it
+type IntegrationRateLimit struct {
+	LimitPerMinute int     `json:"limit_per_minute"`
+	Burst          int     `json:"burst"`
+	CurrentRate    float64 `json:"current_rate"` // webhooks received over the last minute
+}
+
+// WebhookEvent is a raw webhook payload received from an integration and what came of it
+type WebhookEvent struct {
+	ID              string          `json:"id"`
+	IntegrationID   string          `json:"integration_id"`
+	IntegrationType string          `json:"integration_type"`
+	Payload         json.RawMessage `json:"payload"`
+	ReceivedAt      time.Time       `json:"received_at"`
+	ProcessedAt     *time.Time      `json:"processed_at,omitempty"` // nil until the webhook worker has routed it
+	IncidentIDs     []string        `json:"incident_ids"`           // Incidents opened, counted on or resolved
+	Error           string          `json:"error,omitempty"`
+}
+
+// WebhookDeadLetter is an alert from an integration's webhook that could not be routed
+type WebhookDeadLetter struct {
+	ID            string          `json:"id"`
+	IntegrationID string          `json:"integration_id"`
+	Payload       json.RawMessage `json:"payload"` // The processed alert
+	Error         string          `json:"error"`
+	Attempts      int             `json:"attempts"`
+	CreatedAt     time.Time       `json:"created_at"`
+	ReplayedAt    *time.Time      `json:"replayed_at,omitempty"`
 }
 
 // ServiceIntegration represents the many-to-many relationship between services and integrations
@@ -112,7 +149,7 @@ type User struct {
 	Name       string    `json:"name"`
 	Email      string    `json:"email"`
 	Phone      string    `json:"phone,omitempty"`
-	Role       string    `json:"role"` // admin, engineer, manager
+	Role       string    `json:"role"` // admin, engineer, manager, platform_admin
 	Team       string    `json:"team"` // Platform Team, Backend Team, etc.
 	FCMToken   string    `json:"fcm_token,omitempty"`
 	IsActive   bool      `json:"is_active"`
@@ -176,6 +213,28 @@ type AlertResponse struct {
 
 // SERVICE MANAGEMENT MODELS (PagerDuty-style)
 
+// ServiceNotificationSettings is the schema of Service.NotificationSettings:
+//
+//	{
+//	  "slack": true, "fcm": true, "email": true, "sms": false,  // channel toggles, missing = enabled
+//	  "suppress_below_severity": "error",                       // info < warning < error < critical
+//	  "quiet_hours": {"start": "22:00", "end": "07:00", "timezone": "Europe/Berlin"}
+//	}
+//
+// During quiet hours only high-urgency incidents notify.
+type ServiceNotificationSettings struct {
+	Channels              map[string]bool `json:"-"`
+	SuppressBelowSeverity string          `json:"suppress_below_severity,omitempty"`
+	QuietHours            *QuietHours     `json:"quiet_hours,omitempty"`
+}
+
+// QuietHours is a daily window (HH:MM, may wrap midnight) in the given IANA timezone
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone,omitempty"` // Defaults to UTC
+}
+
 // Service represents a service within a group (like PagerDuty services)
 type Service struct {
 	ID                 string                 `json:"id"`
@@ -195,8 +254,12 @@ type Service struct {
 	ProjectID      string `json:"project_id,omitempty"`      // Project scoping
 
 	// Integration settings
-	Integrations         map[string]interface{} `json:"integrations,omitempty"` // Datadog, Prometheus configs
-	NotificationSettings map[string]interface{} `json:"notification_settings,omitempty"`
+	Integrations         map[string]interface{} `json:"integrations,omitempty"`          // Datadog, Prometheus configs
+	NotificationSettings map[string]interface{} `json:"notification_settings,omitempty"` // See ServiceNotificationSettings
+
+	// Default SLA targets (minutes) for incidents on this service
+	ResponseSLAMinutes   *int `json:"response_sla_minutes,omitempty"`
+	ResolutionSLAMinutes *int `json:"resolution_sla_minutes,omitempty"`
 
 	// Display info (for API responses)
 	GroupName          string `json:"group_name,omitempty"`
@@ -233,6 +296,8 @@ type UpdateServiceRequest struct {
 	IsActive             *bool                  `json:"is_active,omitempty"`
 	Integrations         map[string]interface{} `json:"integrations,omitempty"`
 	NotificationSettings map[string]interface{} `json:"notification_settings,omitempty"`
+	ResponseSLAMinutes   *int                   `json:"response_sla_minutes,omitempty" binding:"omitempty,min=1"`
+	ResolutionSLAMinutes *int                   `json:"resolution_sla_minutes,omitempty" binding:"omitempty,min=1"`
 }
 
 // UptimeService represents uptime monitoring services (renamed from Service to avoid conflict)
@@ -433,6 +498,8 @@ const (
 	PermissionManageOnCall   Permission = "manage_oncall"
 	PermissionViewDashboard  Permission = "view_dashboard"
 	PermissionManageServices Permission = "manage_services"
+	PermissionAckIncidents   Permission = "acknowledge_incidents"
+	PermissionCreateDeploys  Permission = "create_deploys"
 )
 
 // Valid permissions list
@@ -442,6 +509,8 @@ var ValidPermissions = []Permission{
 	PermissionManageOnCall,
 	PermissionViewDashboard,
 	PermissionManageServices,
+	PermissionAckIncidents,
+	PermissionCreateDeploys,
 }
 
 // Environment constants
@@ -559,6 +628,15 @@ type EscalationPolicyWithLevels struct {
 	Levels []EscalationLevel `json:"levels"`
 }
 
+// EscalationPolicyVersion is an escalation policy as it was between two updates
+type EscalationPolicyVersion struct {
+	PolicyID  string                     `json:"policy_id"`
+	Version   int                        `json:"version"`
+	Policy    EscalationPolicyWithLevels `json:"policy"`
+	ValidFrom time.Time                  `json:"valid_from"`
+	ValidTo   *time.Time                 `json:"valid_to,omitempty"` // nil for the version in effect
+}
+
 // AlertEscalation tracks escalation history for an alert (Datadog-style)
 type AlertEscalation struct {
 	ID                  string     `json:"id"`
@@ -567,7 +645,7 @@ type AlertEscalation struct {
 	EscalationLevel     int        `json:"escalation_level"`
 	TargetType          string     `json:"target_type"`
 	TargetID            string     `json:"target_id"`
-	Status              string     `json:"status"` // executing, completed, failed, acknowledged, timeout
+	Status              string     `json:"status"` // executing, completed, failed, acknowledged, timeout, skipped
 	ErrorMessage        string     `json:"error_message,omitempty"`
 	CreatedAt           time.Time  `json:"created_at"`
 	UpdatedAt           time.Time  `json:"updated_at"`
@@ -575,11 +653,21 @@ type AlertEscalation struct {
 	AcknowledgedBy      string     `json:"acknowledged_by,omitempty"`
 	ResponseTimeSeconds int        `json:"response_time_seconds,omitempty"`
 	NotificationMethods []string   `json:"notification_methods"`
+	// Deliveries is what happened to each notification sent for the escalation
+	Deliveries []EscalationDelivery `json:"deliveries,omitempty"`
 	// Target info (for display)
 	TargetName string `json:"target_name,omitempty"`
 	RuleName   string `json:"rule_name,omitempty"`
 }
 
+// EscalationDelivery is the outcome of notifying one user over one channel for an alert escalation
+type EscalationDelivery struct {
+	UserID  string `json:"user_id"`
+	Channel string `json:"channel,omitempty"` // push, slack, email, sms, phone; empty for a skipped user
+	Status  string `json:"status"`            // sent, queued, failed, skipped
+	Error   string `json:"error,omitempty"`
+}
+
 // RotationCycle represents automatic rotation configurations
 type RotationCycle struct {
 	ID           string    `json:"id"`
@@ -918,6 +1006,7 @@ const (
 	AlertEscalationStatusFailed       = "failed"
 	AlertEscalationStatusAcknowledged = "acknowledged"
 	AlertEscalationStatusTimeout      = "timeout"
+	AlertEscalationStatusStopped      = "stopped"
 )
 
 const (
@@ -927,6 +1016,51 @@ const (
 	NotificationMethodWebhook = "webhook"
 )
 
+// NOTIFICATION DELIVERY MODELS
+
+// Notification delivery statuses, as stored in notification_logs.status. Receipts only move
+// a notification forward: pending → sent → delivered → read.
+const (
+	NotificationStatusPending   = "pending"
+	NotificationStatusSent      = "sent"
+	NotificationStatusFailed    = "failed"
+	NotificationStatusDelivered = "delivered"
+	NotificationStatusRead      = "read"
+)
+
+// NotificationDeliveryReceipt is a delivery or read receipt reported by a channel (Slack, FCM, ...).
+// The notification is identified by its ID, or by the channel's message ID.
+type NotificationDeliveryReceipt struct {
+	NotificationID    string     `json:"notification_id,omitempty"`
+	ExternalMessageID string     `json:"external_message_id,omitempty"`
+	Channel           string     `json:"channel,omitempty"`
+	Status            string     `json:"status" binding:"required,oneof=delivered read"`
+	Timestamp         *time.Time `json:"timestamp,omitempty"` // When the channel delivered or the user read it; defaults to now
+}
+
+// NotificationDelivery is the delivery state of a notification after a receipt
+type NotificationDelivery struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	IncidentID  string     `json:"incident_id,omitempty"`
+	Channel     string     `json:"channel"`
+	Status      string     `json:"status"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+}
+
+// NotificationDeadLetter is a queued notification that ran out of delivery attempts
+type NotificationDeadLetter struct {
+	ID         string          `json:"id"`
+	QueueName  string          `json:"queue_name"`
+	MsgID      int64           `json:"msg_id"`
+	Message    json.RawMessage `json:"message"`
+	Error      string          `json:"error"`
+	Attempts   int             `json:"attempts"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ReplayedAt *time.Time      `json:"replayed_at,omitempty"`
+}
+
 // SHIFT SWAP MODELS
 
 // ShiftSwapRequest represents a request to swap two schedules
@@ -1109,6 +1243,7 @@ type AlertAttributes struct {
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 	CreatedAt   *time.Time             `json:"created_at,omitempty"`
 	Environment string                 `json:"environment,omitempty"`
+	Fingerprint string                 `json:"fingerprint,omitempty"` // Logged as the alert ID of webhook alerts
 }
 
 // RoutingTableWithRules includes routing table with its rules
@@ -22,6 +22,25 @@ const (
 	SystemUserAPI = "00000000-0000-0000-0000-000000000006"
 )
 
+// SystemActorRole is the role reported for events recorded by a system user
+const SystemActorRole = "system"
+
+// systemUserNames are the display names of system users in activity feeds
+var systemUserNames = map[string]string{
+	SystemUserPrometheus: "Prometheus (automated)",
+	SystemUserDatadog:    "Datadog (automated)",
+	SystemUserGrafana:    "Grafana (automated)",
+	SystemUserAWS:        "AWS CloudWatch (automated)",
+	SystemUserWebhook:    "Webhook (automated)",
+	SystemUserAPI:        "API (automated)",
+}
+
+// SystemUserName returns the display name of a system user, and whether id is one
+func SystemUserName(id string) (string, bool) {
+	name, ok := systemUserNames[id]
+	return name, ok
+}
+
 // GetSystemUserBySource returns the appropriate system user ID based on alert source
 func GetSystemUserBySource(source string) string {
 	switch source {
@@ -361,6 +361,9 @@ func (h *APIKeyHandler) hasRequiredPermission(apiKey *db.APIKey, endpoint string
 		"/api/oncall":            db.PermissionManageOnCall,
 		"/api/dashboard":         db.PermissionViewDashboard,
 		"/api/services":          db.PermissionManageServices,
+
+		"/webhooks/incidents/:id/acknowledge": db.PermissionAckIncidents,
+		"/webhooks/deploys":                   db.PermissionCreateDeploys,
 	}
 
 	requiredPermission, exists := endpointPermissions[endpoint]
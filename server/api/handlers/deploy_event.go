@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phonginreallife/inres/authz"
+	"github.com/phonginreallife/inres/db"
+	"github.com/phonginreallife/inres/services"
+)
+
+// DeployEventHandler handles deploy (change) event ingestion and listing
+type DeployEventHandler struct {
+	deployEventService *services.DeployEventService
+}
+
+// NewDeployEventHandler creates a new DeployEventHandler
+func NewDeployEventHandler(deployEventService *services.DeployEventService) *DeployEventHandler {
+	return &DeployEventHandler{deployEventService: deployEventService}
+}
+
+// CreateDeployEvent handles POST /deploys
+func (h *DeployEventHandler) CreateDeployEvent(c *gin.Context) {
+	var req db.CreateDeployEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// SECURITY: org_id is MANDATORY for tenant isolation
+	filters := authz.GetReBACFilters(c)
+	orgID, _ := filters["current_org_id"].(string)
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "organization_id is required",
+			"message": "Please provide org_id query param or X-Org-ID header for tenant isolation",
+		})
+		return
+	}
+	req.OrganizationID = orgID
+
+	h.createDeployEvent(c, req, c.GetString("user_id"))
+}
+
+// WebhookCreateDeployEvent handles POST /webhooks/deploys for CI/CD pipelines (API key auth)
+func (h *DeployEventHandler) WebhookCreateDeployEvent(c *gin.Context) {
+	apiKey, ok := c.MustGet("api_key").(*db.APIKey)
+	if !ok || apiKey == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "API key required"})
+		return
+	}
+	if apiKey.OrganizationID == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key is not scoped to an organization"})
+		return
+	}
+
+	var req db.CreateDeployEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.OrganizationID = apiKey.OrganizationID
+	if req.Source == "" {
+		req.Source = "webhook"
+	}
+
+	h.createDeployEvent(c, req, apiKey.UserID)
+}
+
+func (h *DeployEventHandler) createDeployEvent(c *gin.Context, req db.CreateDeployEventRequest, createdBy string) {
+	event, err := h.deployEventService.CreateDeployEvent(req, createdBy)
+	if err != nil {
+		if err.Error() == "service not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create deploy event",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"deploy": event})
+}
+
+// ListDeployEvents handles GET /deploys
+// Query params: service_id, project_id, limit (max 200)
+func (h *DeployEventHandler) ListDeployEvents(c *gin.Context) {
+	filters := authz.GetReBACFilters(c)
+	if filters["current_org_id"] == nil || filters["current_org_id"].(string) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "organization_id is required",
+			"message": "Please provide org_id query param or X-Org-ID header for tenant isolation",
+		})
+		return
+	}
+
+	if serviceID := c.Query("service_id"); serviceID != "" {
+		filters["service_id"] = serviceID
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		if limit > 200 {
+			limit = 200
+		}
+		filters["limit"] = limit
+	}
+
+	events, err := h.deployEventService.ListDeployEvents(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list deploy events",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deploys": events,
+		"total":   len(events),
+	})
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phonginreallife/inres/services"
+)
+
+// FeatureFlagHandler handles per-organization feature flag requests
+type FeatureFlagHandler struct {
+	featureFlagService *services.FeatureFlagService
+}
+
+// NewFeatureFlagHandler creates a new FeatureFlagHandler
+func NewFeatureFlagHandler(featureFlagService *services.FeatureFlagService) *FeatureFlagHandler {
+	return &FeatureFlagHandler{featureFlagService: featureFlagService}
+}
+
+// GetOrgFeatureFlags handles GET /orgs/:id/feature-flags
+func (h *FeatureFlagHandler) GetOrgFeatureFlags(c *gin.Context) {
+	flags, err := h.featureFlagService.GetOrgFeatureFlags(c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "organization not found" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"feature_flags": flags})
+}
+
+// UpdateOrgFeatureFlags handles PATCH /orgs/:id/feature-flags
+// Only the flags present in the body are changed
+func (h *FeatureFlagHandler) UpdateOrgFeatureFlags(c *gin.Context) {
+	var updates map[string]bool
+	if err := c.ShouldBindJSON(&updates); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	for name := range updates {
+		if !services.IsKnownFeatureFlag(name) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown feature flag", "details": name})
+			return
+		}
+	}
+
+	flags, err := h.featureFlagService.UpdateOrgFeatureFlags(c.Param("id"), updates)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "organization not found" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"feature_flags": flags})
+}
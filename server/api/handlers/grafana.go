@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phonginreallife/inres/services"
+)
+
+// Grafana SimpleJSON datasource metrics exposed by the trends endpoints
+const (
+	GrafanaMetricIncidentsTotal        = "incidents.total"
+	GrafanaMetricIncidentsTriggered    = "incidents.triggered"
+	GrafanaMetricIncidentsAcknowledged = "incidents.acknowledged"
+	GrafanaMetricIncidentsResolved     = "incidents.resolved"
+	GrafanaMetricMTTAMinutes           = "incidents.mtta_minutes"
+	GrafanaMetricMTTRMinutes           = "incidents.mttr_minutes"
+)
+
+var grafanaMetrics = []string{
+	GrafanaMetricIncidentsTotal,
+	GrafanaMetricIncidentsTriggered,
+	GrafanaMetricIncidentsAcknowledged,
+	GrafanaMetricIncidentsResolved,
+	GrafanaMetricMTTAMinutes,
+	GrafanaMetricMTTRMinutes,
+}
+
+// GrafanaQueryRequest is the body Grafana sends to /query
+type GrafanaQueryRequest struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	Targets []struct {
+		Target string `json:"target"`
+		RefID  string `json:"refId"`
+		Type   string `json:"type"`
+	} `json:"targets"`
+}
+
+// GrafanaTimeseries is a single series in the /query response.
+// Each datapoint is [value, unix_timestamp_ms].
+type GrafanaTimeseries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// GrafanaTestDatasource handles GET /incidents/grafana
+// Grafana calls this when the datasource is saved to check connectivity
+func (h *IncidentHandler) GrafanaTestDatasource(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GrafanaSearch handles POST /incidents/grafana/search
+// Returns the list of metrics that can be queried
+func (h *IncidentHandler) GrafanaSearch(c *gin.Context) {
+	c.JSON(http.StatusOK, grafanaMetrics)
+}
+
+// GrafanaQuery handles POST /incidents/grafana/query
+// Returns timeseries for the requested metrics, backed by GetIncidentTrends
+func (h *IncidentHandler) GrafanaQuery(c *gin.Context) {
+	var req GrafanaQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	orgID, projectID := trendsScope(c)
+
+	trends, err := h.incidentService.GetIncidentTrends(orgID, projectID, grafanaTimeRange(req.Range.From))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch incident trends",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	series := make([]GrafanaTimeseries, 0, len(req.Targets))
+	for _, target := range req.Targets {
+		if target.Target == "" {
+			continue
+		}
+		series = append(series, buildGrafanaTimeseries(target.Target, trends.DailyCounts, req.Range.From, req.Range.To))
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// grafanaTimeRange picks the smallest supported trends window covering from
+func grafanaTimeRange(from time.Time) string {
+	if from.IsZero() {
+		return "7d"
+	}
+
+	age := time.Since(from)
+	switch {
+	case age <= 7*24*time.Hour:
+		return "7d"
+	case age <= 30*24*time.Hour:
+		return "30d"
+	default:
+		return "90d"
+	}
+}
+
+// buildGrafanaTimeseries converts daily trend points into a Grafana series,
+// keeping only the points inside [from, to] when a range is given
+func buildGrafanaTimeseries(metric string, points []services.IncidentTrendDataPoint, from, to time.Time) GrafanaTimeseries {
+	series := GrafanaTimeseries{
+		Target:     metric,
+		Datapoints: make([][2]float64, 0, len(points)),
+	}
+
+	for _, dp := range points {
+		day, err := time.Parse("2006-01-02", dp.Date)
+		if err != nil {
+			continue
+		}
+		// Daily buckets cover the whole day, so compare against the day's end
+		if !from.IsZero() && day.Add(24*time.Hour).Before(from) {
+			continue
+		}
+		if !to.IsZero() && day.After(to) {
+			continue
+		}
+
+		var value float64
+		switch metric {
+		case GrafanaMetricIncidentsTotal:
+			value = float64(dp.Total)
+		case GrafanaMetricIncidentsTriggered:
+			value = float64(dp.Triggered)
+		case GrafanaMetricIncidentsAcknowledged:
+			value = float64(dp.Acknowledged)
+		case GrafanaMetricIncidentsResolved:
+			value = float64(dp.Resolved)
+		case GrafanaMetricMTTAMinutes:
+			if dp.AvgMTTAMinutes == nil {
+				continue
+			}
+			value = *dp.AvgMTTAMinutes
+		case GrafanaMetricMTTRMinutes:
+			if dp.AvgMTTRMinutes == nil {
+				continue
+			}
+			value = *dp.AvgMTTRMinutes
+		default:
+			continue
+		}
+
+		series.Datapoints = append(series.Datapoints, [2]float64{value, float64(day.UnixMilli())})
+	}
+
+	return series
+}
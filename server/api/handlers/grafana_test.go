@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/phonginreallife/inres/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncidentHandler_GrafanaQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	handler := NewIncidentHandler(services.NewIncidentService(db, nil, nil), nil, nil, nil, nil)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday := today.Add(-24 * time.Hour)
+
+	rows := sqlmock.NewRows([]string{
+		"date", "total", "triggered", "acknowledged", "resolved", "avg_mtta_minutes", "avg_mttr_minutes",
+	}).
+		AddRow(yesterday.Format("2006-01-02"), 4, 1, 1, 2, 3.5, 42.0).
+		AddRow(today.Format("2006-01-02"), 2, 2, 0, 0, nil, nil)
+	mockDB.ExpectQuery("SELECT .* FROM incidents .* GROUP BY DATE\\(created_at\\)").
+		WithArgs("7 days", "org-1").
+		WillReturnRows(rows)
+
+	body := map[string]interface{}{
+		"range": map[string]interface{}{
+			"from": yesterday.Add(-time.Hour).Format(time.RFC3339),
+			"to":   today.Add(time.Hour).Format(time.RFC3339),
+		},
+		"targets": []map[string]interface{}{
+			{"target": GrafanaMetricIncidentsTotal, "refId": "A", "type": "timeserie"},
+			{"target": GrafanaMetricMTTAMinutes, "refId": "B", "type": "timeserie"},
+		},
+	}
+	payload, _ := json.Marshal(body)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/incidents/grafana/query?org_id=org-1", bytes.NewReader(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.GrafanaQuery(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var series []GrafanaTimeseries
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &series))
+	assert.Len(t, series, 2)
+
+	assert.Equal(t, GrafanaMetricIncidentsTotal, series[0].Target)
+	assert.Equal(t, [][2]float64{
+		{4, float64(yesterday.UnixMilli())},
+		{2, float64(today.UnixMilli())},
+	}, series[0].Datapoints)
+
+	// Days without acknowledgements have no MTTA datapoint
+	assert.Equal(t, GrafanaMetricMTTAMinutes, series[1].Target)
+	assert.Equal(t, [][2]float64{
+		{3.5, float64(yesterday.UnixMilli())},
+	}, series[1].Datapoints)
+}
+
+func TestIncidentHandler_GrafanaSearch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewIncidentHandler(nil, nil, nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/incidents/grafana/search", bytes.NewReader([]byte(`{"target":""}`)))
+
+	handler.GrafanaSearch(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var metrics []string
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &metrics))
+	assert.Contains(t, metrics, GrafanaMetricIncidentsTotal)
+	assert.Contains(t, metrics, GrafanaMetricMTTRMinutes)
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/phonginreallife/inres/authz"
@@ -13,14 +16,18 @@ import (
 )
 
 type GroupHandler struct {
-	GroupService      *services.GroupService
-	EscalationService *services.EscalationService
+	GroupService        *services.GroupService
+	EscalationService   *services.EscalationService
+	IncidentService     *services.IncidentService
+	NotificationService *services.NotificationService
 }
 
-func NewGroupHandler(groupService *services.GroupService, escalationService *services.EscalationService) *GroupHandler {
+func NewGroupHandler(groupService *services.GroupService, escalationService *services.EscalationService, incidentService *services.IncidentService) *GroupHandler {
 	return &GroupHandler{
-		GroupService:      groupService,
-		EscalationService: escalationService,
+		GroupService:        groupService,
+		EscalationService:   escalationService,
+		IncidentService:     incidentService,
+		NotificationService: services.NewNotificationService(groupService.PG),
 	}
 }
 
@@ -208,6 +215,33 @@ func (h *GroupHandler) GetGroupMembers(c *gin.Context) {
 	})
 }
 
+// PreviewGroupNotification shows who a page to the group would notify and on which
+// channels, without sending anything
+// GET /groups/:id/notification-preview?methods=slack,push
+func (h *GroupHandler) PreviewGroupNotification(c *gin.Context) {
+	groupID := c.Param("id")
+
+	var methods []string
+	if raw := c.Query("methods"); raw != "" {
+		methods = strings.Split(raw, ",")
+	}
+
+	recipients, err := h.NotificationService.PreviewGroupNotification(groupID, methods)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidNotificationMethod) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to preview group notification"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recipients": recipients,
+		"total":      len(recipients),
+	})
+}
+
 // AddGroupMember adds a user to a group
 func (h *GroupHandler) AddGroupMember(c *gin.Context) {
 	groupID := c.Param("id")
@@ -347,6 +381,70 @@ func (h *GroupHandler) GetEscalationPolicyDetail(c *gin.Context) {
 	c.JSON(http.StatusOK, policyDetail)
 }
 
+// GetEscalationPolicyVersions lists an escalation policy's versions, or with ?at= (RFC3339)
+// returns the version that was in effect at that time
+func (h *GroupHandler) GetEscalationPolicyVersions(c *gin.Context) {
+	policyID := c.Param("policy_id")
+
+	if atParam := c.Query("at"); atParam != "" {
+		at, err := time.Parse(time.RFC3339, atParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid at, expected RFC3339"})
+			return
+		}
+		version, err := h.EscalationService.GetPolicyAtTime(policyID, at)
+		if err != nil {
+			if errors.Is(err, services.ErrPolicyVersionNotFound) || strings.Contains(err.Error(), "escalation policy not found") {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve escalation policy version"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, version)
+		return
+	}
+
+	versions, err := h.EscalationService.GetPolicyVersions(policyID)
+	if err != nil {
+		if strings.Contains(err.Error(), "escalation policy not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Escalation policy not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve escalation policy versions"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"versions": versions, "total": len(versions)})
+}
+
+// SimulateEscalationPolicy shows who each level of a policy would page, now or at ?at= (RFC3339),
+// without paging anyone
+func (h *GroupHandler) SimulateEscalationPolicy(c *gin.Context) {
+	groupID := c.Param("id")
+	policyID := c.Param("policy_id")
+
+	at := time.Now()
+	if atParam := c.Query("at"); atParam != "" {
+		parsed, err := time.Parse(time.RFC3339, atParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid at, expected RFC3339"})
+			return
+		}
+		at = parsed
+	}
+
+	steps, err := h.EscalationService.SimulatePolicy(policyID, groupID, at)
+	if err != nil {
+		if strings.Contains(err.Error(), "escalation policy not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Escalation policy not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to simulate escalation policy", "details": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"steps": steps, "at": at})
+}
+
 // CreateEscalationPolicy creates a new escalation policy
 func (h *GroupHandler) CreateEscalationPolicy(c *gin.Context) {
 	groupID := c.Param("id")
@@ -362,6 +460,11 @@ func (h *GroupHandler) CreateEscalationPolicy(c *gin.Context) {
 
 	policy, err := h.EscalationService.CreateEscalationPolicy(groupID, escalationPolicy)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidNotificationMethod) || errors.Is(err, services.ErrInvalidLevelNumbers) ||
+			errors.Is(err, services.ErrInvalidExternalTarget) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create escalation policy"})
 		return
 	}
@@ -373,6 +476,7 @@ func (h *GroupHandler) CreateEscalationPolicy(c *gin.Context) {
 }
 
 // GetGroupEscalationPolicies retrieves escalation policies for a specific group
+// Supports ?search= (policy name) and ?sort=name|usage|updated
 // ReBAC: Uses organization context for MANDATORY tenant isolation
 func (h *GroupHandler) GetGroupEscalationPolicies(c *gin.Context) {
 	groupID := c.Param("id")
@@ -402,6 +506,12 @@ func (h *GroupHandler) GetGroupEscalationPolicies(c *gin.Context) {
 	// Pass filters to service for ReBAC-aware query
 	filters["group_id"] = groupID
 	filters["active_only"] = activeOnly
+	if search := c.Query("search"); search != "" {
+		filters["search"] = search
+	}
+	if sort := c.Query("sort"); sort != "" {
+		filters["sort"] = sort // name, usage, updated
+	}
 
 	policiesWithUsage, err := h.EscalationService.GetGroupEscalationPoliciesWithFilters(filters)
 	if err != nil {
@@ -625,7 +735,25 @@ func (h *GroupHandler) GetEscalationGroups(c *gin.Context) {
 func (h *GroupHandler) GetAlertEscalations(c *gin.Context) {
 	alertID := c.Param("alert_id")
 
-	escalations, err := h.EscalationService.GetAlertEscalations(alertID)
+	filters := make(map[string]interface{})
+	if status := c.Query("status"); status != "" {
+		if !services.IsValidAlertEscalationStatus(status) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status filter"})
+			return
+		}
+		filters["status"] = status
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		if limit > 1000 {
+			limit = 1000
+		}
+		filters["limit"] = limit
+	}
+	if offset, err := strconv.Atoi(c.Query("offset")); err == nil && offset > 0 {
+		filters["offset"] = offset
+	}
+
+	escalations, err := h.EscalationService.GetAlertEscalations(alertID, filters)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve alert escalations"})
 		return
@@ -637,6 +765,30 @@ func (h *GroupHandler) GetAlertEscalations(c *gin.Context) {
 	})
 }
 
+// GetActiveEscalations lists the escalations paging someone right now across the organization
+func (h *GroupHandler) GetActiveEscalations(c *gin.Context) {
+	filters := authz.GetReBACFilters(c)
+	orgID, _ := filters["current_org_id"].(string)
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "organization_id is required",
+			"message": "Please provide org_id query param or X-Org-ID header for tenant isolation",
+		})
+		return
+	}
+
+	escalations, err := h.EscalationService.ListActiveEscalations(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve active escalations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"escalations": escalations,
+		"total":       len(escalations),
+	})
+}
+
 // BULK OPERATIONS
 
 // AddMultipleGroupMembers adds multiple users to a group
@@ -781,6 +933,11 @@ func (h *GroupHandler) UpdateEscalationPolicy(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Escalation policy not found"})
 			return
 		}
+		if errors.Is(err, services.ErrInvalidNotificationMethod) || errors.Is(err, services.ErrInvalidLevelNumbers) ||
+			errors.Is(err, services.ErrInvalidExternalTarget) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update escalation policy", "details": err.Error()})
 		return
 	}
@@ -791,6 +948,51 @@ func (h *GroupHandler) UpdateEscalationPolicy(c *gin.Context) {
 	})
 }
 
+// ReorderEscalationLevels renumbers an escalation policy's levels in the given order, keeping their IDs
+func (h *GroupHandler) ReorderEscalationLevels(c *gin.Context) {
+	groupID := c.Param("id")
+	policyID := c.Param("policy_id")
+
+	var req struct {
+		LevelIDs []string `json:"level_ids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	// Validate group access
+	userID := c.GetString("user_id")
+	ok, err := h.GroupService.IsUserInGroup(groupID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group membership"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	levels, err := h.EscalationService.ReorderLevels(policyID, req.LevelIDs)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Escalation policy not found"})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidLevelOrder) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder escalation levels", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"levels":  levels,
+		"message": "Escalation levels reordered successfully",
+	})
+}
+
 // DeleteEscalationPolicy deletes an escalation policy
 func (h *GroupHandler) DeleteEscalationPolicy(c *gin.Context) {
 	groupID := c.Param("id")
@@ -808,9 +1010,16 @@ func (h *GroupHandler) DeleteEscalationPolicy(c *gin.Context) {
 		return
 	}
 
+	// Policies still in use are only deleted with ?force=true, which unlinks them first
+	force, _ := strconv.ParseBool(c.Query("force"))
+
 	// Delete escalation policy
-	err = h.EscalationService.DeleteEscalationPolicy(policyID)
+	err = h.EscalationService.DeleteEscalationPolicy(groupID, policyID, force)
 	if err != nil {
+		if errors.Is(err, services.ErrEscalationPolicyInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Escalation policy not found"})
 			return
@@ -823,3 +1032,73 @@ func (h *GroupHandler) DeleteEscalationPolicy(c *gin.Context) {
 		"message": "Escalation policy deleted successfully",
 	})
 }
+
+// BulkDeleteEscalationPolicies deletes several escalation policies; in-use ones are skipped unless force is set
+func (h *GroupHandler) BulkDeleteEscalationPolicies(c *gin.Context) {
+	groupID := c.Param("id")
+
+	var req struct {
+		PolicyIDs []string `json:"policy_ids" binding:"required,min=1,max=100"`
+		Force     bool     `json:"force"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate group access
+	userID := c.GetString("user_id")
+	ok, err := h.GroupService.IsUserInGroup(groupID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group membership"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	results := h.EscalationService.BulkDeleteEscalationPolicies(groupID, req.PolicyIDs, req.Force)
+
+	deleted := 0
+	for _, result := range results {
+		if result.Deleted {
+			deleted++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results":       results,
+		"deleted_count": deleted,
+		"failed_count":  len(results) - deleted,
+	})
+}
+
+// RebalanceIncidents spreads the group's open, unacknowledged incidents evenly across whoever is on call
+func (h *GroupHandler) RebalanceIncidents(c *gin.Context) {
+	groupID := c.Param("id")
+
+	// Validate group access
+	userID := c.GetString("user_id")
+	ok, err := h.GroupService.IsUserInGroup(groupID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group membership"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	result, err := h.IncidentService.RebalanceAssignments(groupID)
+	if err != nil {
+		if errors.Is(err, services.ErrNoOnCallUsers) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rebalance incidents", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
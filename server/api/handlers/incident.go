@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -58,9 +59,62 @@ func (h *IncidentHandler) ListIncidents(c *gin.Context) {
 		filters["project_id"] = projectID
 	}
 
+	if err := parseIncidentListQuery(c, filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid cursor",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	incidents, total, err := h.incidentService.ListIncidentsWithCount(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch incidents",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Calculate pagination info
+	page := 1
+	if p, ok := filters["page"].(int); ok {
+		page = p
+	}
+	limit := 20
+	if l, ok := filters["limit"].(int); ok && l <= 100 {
+		limit = l
+	}
+	totalPages := (total + limit - 1) / limit
+
+	hasMore := page < totalPages
+	nextCursor := ""
+	if _, ok := filters["cursor"]; ok {
+		cursor := services.NextIncidentCursor(incidents, limit)
+		hasMore = cursor != ""
+		if incidentCursorSortable(filters) {
+			nextCursor = cursor
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"incidents":   incidents,
+		"page":        page,
+		"limit":       limit,
+		"total":       total,
+		"total_pages": totalPages,
+		"has_more":    hasMore,
+		"next_cursor": nextCursor,
+	})
+}
+
+// parseIncidentListQuery adds the list filters, sorting and pagination from the query string.
+// Returns an error for an invalid cursor.
+func parseIncidentListQuery(c *gin.Context, filters map[string]interface{}) error {
 	// Parse resource-specific query parameters
 	if search := c.Query("search"); search != "" {
 		filters["search"] = search
+		filters["search_notes"] = c.Query("search_notes") == "true"
 	}
 	if status := c.Query("status"); status != "" {
 		filters["status"] = status
@@ -77,9 +131,25 @@ func (h *IncidentHandler) ListIncidents(c *gin.Context) {
 	if assignedTo := c.Query("assigned_to"); assignedTo != "" {
 		filters["assigned_to"] = assignedTo
 	}
+	if assignedToEmail := c.Query("assigned_to_email"); assignedToEmail != "" {
+		filters["assigned_to_email"] = assignedToEmail
+	}
+	filters["include_archived"] = c.Query("include_archived") == "true"
+	filters["include_resolved"] = c.Query("include_resolved") == "true"
 	if serviceID := c.Query("service_id"); serviceID != "" {
 		filters["service_id"] = serviceID
 	}
+	if clusterID := c.Query("cluster_id"); clusterID != "" {
+		filters["cluster_id"] = clusterID
+	}
+	filters["hide_clustered"] = c.Query("hide_clustered") == "true"
+	// Label / custom field filters: ?labels[team]=payments&custom_fields[region]=eu
+	if labels := c.QueryMap("labels"); len(labels) > 0 {
+		filters["labels"] = labels
+	}
+	if customFields := c.QueryMap("custom_fields"); len(customFields) > 0 {
+		filters["custom_fields"] = customFields
+	}
 	if sort := c.Query("sort"); sort != "" {
 		filters["sort"] = sort
 	}
@@ -95,9 +165,57 @@ func (h *IncidentHandler) ListIncidents(c *gin.Context) {
 			filters["limit"] = limit
 		}
 	}
+	// Keyset pagination (preferred over page when present); an empty cursor starts from the newest
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		if cursor != "" {
+			if _, _, err := services.DecodeIncidentCursor(cursor); err != nil {
+				return err
+			}
+		}
+		filters["cursor"] = cursor
+	}
+
+	return nil
+}
+
+// incidentCursorSortable reports whether the list was requested in the default newest-first
+// order. A next_cursor is only handed out then, since it continues from the last incident's
+// created_at and would skip or repeat incidents under any other sort.
+func incidentCursorSortable(filters map[string]interface{}) bool {
+	if _, ok := filters["search"]; ok {
+		return false
+	}
+	sort, _ := filters["sort"].(string)
+	return sort == "" || sort == "created_at_desc"
+}
+
+// ListIncidentsAllOrgs handles GET /admin/incidents
+// Cross-organization incident list for platform admins; takes the same query params as
+// ListIncidents plus an optional org_id to narrow to one organization. Every call is audited.
+func (h *IncidentHandler) ListIncidentsAllOrgs(c *gin.Context) {
+	filters := map[string]interface{}{
+		"current_user_id": c.GetString("user_id"),
+	}
+	if orgID := c.Query("org_id"); orgID != "" {
+		filters["organization_id"] = orgID
+	}
+	if projectID := c.Query("project_id"); projectID != "" {
+		filters["project_id"] = projectID
+	}
+	if err := parseIncidentListQuery(c, filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid cursor",
+			"details": err.Error(),
+		})
+		return
+	}
 
-	incidents, err := h.incidentService.ListIncidents(filters)
+	incidents, err := h.incidentService.ListIncidentsAllOrgs(filters)
 	if err != nil {
+		if errors.Is(err, services.ErrPlatformAdminRequired) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to fetch incidents",
 			"details": err.Error(),
@@ -105,26 +223,71 @@ func (h *IncidentHandler) ListIncidents(c *gin.Context) {
 		return
 	}
 
-	// Calculate pagination info
-	total := len(incidents)
-	page := 1
-	if p, ok := filters["page"].(int); ok {
-		page = p
-	}
 	limit := 20
-	if l, ok := filters["limit"].(int); ok {
+	if l, ok := filters["limit"].(int); ok && l <= 100 {
 		limit = l
 	}
+	hasMore := len(incidents) == limit
+	nextCursor := ""
+	if _, ok := filters["cursor"]; ok {
+		cursor := services.NextIncidentCursor(incidents, limit)
+		hasMore = cursor != ""
+		if incidentCursorSortable(filters) {
+			nextCursor = cursor
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"incidents": incidents,
-		"page":      page,
-		"limit":     limit,
-		"total":     total,
-		"has_more":  len(incidents) == limit, // Simple check, could be improved
+		"incidents":   incidents,
+		"limit":       limit,
+		"has_more":    hasMore,
+		"next_cursor": nextCursor,
 	})
 }
 
+// RecordNotificationReceipt handles POST /notifications/receipts
+// Channels report delivered/read receipts for queued notifications. Users may only report
+// receipts for their own notifications; channel workers authenticate with an API key.
+func (h *IncidentHandler) RecordNotificationReceipt(c *gin.Context) {
+	var receipt db.NotificationDeliveryReceipt
+	if err := c.ShouldBindJSON(&receipt); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	// Channel workers report for any recipient, but only within their API key's organization
+	orgID := ""
+	if c.GetBool("is_api_key") {
+		userID = ""
+		orgID = authz.GetOrgIDFromContext(c)
+		if orgID == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API key is not scoped to an organization"})
+			return
+		}
+	}
+
+	delivery, err := h.incidentService.RecordDeliveryReceipt(receipt, userID, orgID)
+	if errors.Is(err, services.ErrNotificationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidReceipt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record receipt", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
 // GetIncident handles GET /incidents/:id
 func (h *IncidentHandler) GetIncident(c *gin.Context) {
 	id := c.Param("id")
@@ -137,11 +300,11 @@ func (h *IncidentHandler) GetIncident(c *gin.Context) {
 
 	incident, err := h.checkIncidentAccess(c, id, authz.ActionView)
 	if err != nil {
-		if err.Error() == "incident not found" {
+		if errors.Is(err, services.ErrIncidentNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
 			return
 		}
-		if err.Error() == "forbidden" {
+		if errors.Is(err, errIncidentForbidden) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to view this incident"})
 			return
 		}
@@ -152,6 +315,9 @@ func (h *IncidentHandler) GetIncident(c *gin.Context) {
 	c.JSON(http.StatusOK, incident)
 }
 
+// errIncidentForbidden is returned by checkIncidentAccess when the user lacks the requested permission
+var errIncidentForbidden = errors.New("forbidden")
+
 // checkIncidentAccess verifies if the user has permission to access the incident
 // ReBAC: project_id is MANDATORY - all incidents must belong to a project
 func (h *IncidentHandler) checkIncidentAccess(c *gin.Context, incidentID string, action authz.Action) (*db.IncidentResponse, error) {
@@ -168,7 +334,7 @@ func (h *IncidentHandler) checkIncidentAccess(c *gin.Context, incidentID string,
 	// ReBAC: project_id is MANDATORY
 	if incident.ProjectID == "" {
 		log.Printf("WARNING: Incident %s has no project_id - denying access", incidentID)
-		return nil, fmt.Errorf("forbidden")
+		return nil, errIncidentForbidden
 	}
 
 	// Check project membership
@@ -176,7 +342,7 @@ func (h *IncidentHandler) checkIncidentAccess(c *gin.Context, incidentID string,
 		return incident, nil
 	}
 
-	return nil, fmt.Errorf("forbidden")
+	return nil, errIncidentForbidden
 }
 
 // CreateIncident handles POST /incidents
@@ -213,6 +379,13 @@ func (h *IncidentHandler) CreateIncident(c *gin.Context) {
 		organizationID = req.OrganizationID
 	}
 
+	if req.AssignedTo != "" && !h.canBeAssigned(c, req.AssignedTo, projectID, organizationID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Assignee does not have access to this project",
+		})
+		return
+	}
+
 	// Convert request to incident
 	incident := &db.Incident{
 		Title:              req.Title,
@@ -222,6 +395,7 @@ func (h *IncidentHandler) CreateIncident(c *gin.Context) {
 		ServiceID:          req.ServiceID,
 		GroupID:            req.GroupID,
 		EscalationPolicyID: req.EscalationPolicyID,
+		AssignedTo:         req.AssignedTo,
 		IncidentKey:        req.IncidentKey,
 		Severity:           req.Severity,
 		Labels:             req.Labels,
@@ -229,6 +403,9 @@ func (h *IncidentHandler) CreateIncident(c *gin.Context) {
 		Source:             "manual", // Manual creation
 		ProjectID:          projectID,
 		OrganizationID:     organizationID,
+
+		ResponseSLAMinutes:   req.ResponseSLAMinutes,
+		ResolutionSLAMinutes: req.ResolutionSLAMinutes,
 	}
 
 	// Set default urgency if not provided
@@ -236,30 +413,6 @@ func (h *IncidentHandler) CreateIncident(c *gin.Context) {
 		incident.Urgency = db.IncidentUrgencyHigh
 	}
 
-	// Auto-assign incident based on escalation policy
-	log.Printf("DEBUG: Starting auto-assignment check - EscalationPolicyID: '%s', GroupID: '%s'", incident.EscalationPolicyID, incident.GroupID)
-
-	if incident.EscalationPolicyID != "" && incident.GroupID != "" {
-		log.Printf("DEBUG: Both EscalationPolicyID and GroupID are present, calling GetAssigneeFromEscalationPolicy")
-		assigneeID, err := h.incidentService.GetAssigneeFromEscalationPolicy(incident.EscalationPolicyID, incident.GroupID)
-		if err != nil {
-			log.Printf("DEBUG: Failed to get assignee from escalation policy: %v", err)
-			// Continue with incident creation even if assignment fails
-		} else if assigneeID != "" {
-			log.Printf("DEBUG: Found assignee: %s, setting assignment fields", assigneeID)
-			incident.AssignedTo = assigneeID
-			now := time.Now()
-			incident.AssignedAt = &now
-			log.Printf("DEBUG: Auto-assigned incident to user %s based on escalation policy %s", assigneeID, incident.EscalationPolicyID)
-		} else {
-			log.Printf("DEBUG: GetAssigneeFromEscalationPolicy returned empty assigneeID")
-		}
-	} else {
-		log.Printf("DEBUG: Skipping auto-assignment - missing EscalationPolicyID or GroupID")
-	}
-
-	log.Printf("DEBUG: Final incident state before creation - AssignedTo: '%s', AssignedAt: %v", incident.AssignedTo, incident.AssignedAt)
-
 	createdIncident, err := h.incidentService.CreateIncident(incident)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -277,6 +430,19 @@ func (h *IncidentHandler) CreateIncident(c *gin.Context) {
 	c.JSON(http.StatusCreated, createdIncident)
 }
 
+// canBeAssigned reports whether the assignee can view incidents of the project, or of the
+// organization when the incident has no project
+func (h *IncidentHandler) canBeAssigned(c *gin.Context, assigneeID, projectID, organizationID string) bool {
+	ctx := c.Request.Context()
+	if projectID != "" {
+		return h.authorizer.Check(ctx, assigneeID, authz.ActionView, authz.ResourceProject, projectID)
+	}
+	if organizationID != "" {
+		return h.authorizer.Check(ctx, assigneeID, authz.ActionView, authz.ResourceOrg, organizationID)
+	}
+	return false
+}
+
 // UpdateIncident handles PUT /incidents/:id
 func (h *IncidentHandler) UpdateIncident(c *gin.Context) {
 	id := c.Param("id")
@@ -299,11 +465,11 @@ func (h *IncidentHandler) UpdateIncident(c *gin.Context) {
 	// Check permission (ActionUpdate)
 	_, err := h.checkIncidentAccess(c, id, authz.ActionUpdate)
 	if err != nil {
-		if err.Error() == "incident not found" {
+		if errors.Is(err, services.ErrIncidentNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
 			return
 		}
-		if err.Error() == "forbidden" {
+		if errors.Is(err, errIncidentForbidden) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to update this incident"})
 			return
 		}
@@ -312,6 +478,10 @@ func (h *IncidentHandler) UpdateIncident(c *gin.Context) {
 	}
 
 	updatedIncident, err := h.incidentService.UpdateIncident(id, req)
+	if errors.Is(err, services.ErrInvalidBusinessImpact) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid business impact", "details": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to update incident",
@@ -345,11 +515,11 @@ func (h *IncidentHandler) AcknowledgeIncident(c *gin.Context) {
 	// Check permission (ActionUpdate)
 	_, err := h.checkIncidentAccess(c, id, authz.ActionUpdate)
 	if err != nil {
-		if err.Error() == "incident not found" {
+		if errors.Is(err, services.ErrIncidentNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
 			return
 		}
-		if err.Error() == "forbidden" {
+		if errors.Is(err, errIncidentForbidden) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to acknowledge this incident"})
 			return
 		}
@@ -359,11 +529,24 @@ func (h *IncidentHandler) AcknowledgeIncident(c *gin.Context) {
 
 	var req db.AcknowledgeIncidentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		// Note is optional, so we can proceed without it
-		req.Note = ""
+		// Note and ETA are optional, so we can proceed without them
+		req = db.AcknowledgeIncidentRequest{}
+	}
+
+	var eta time.Time
+	if req.ETA != nil {
+		eta = *req.ETA
 	}
 
-	err = h.incidentService.AcknowledgeIncident(id, userID.(string), req.Note)
+	err = h.incidentService.AcknowledgeIncident(id, userID.(string), req.Note, eta)
+	if errors.Is(err, services.ErrAckETAInPast) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrIncidentNotTriggered) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to acknowledge incident",
@@ -372,8 +555,63 @@ func (h *IncidentHandler) AcknowledgeIncident(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"message": "Incident acknowledged successfully",
+	}
+	if req.ETA != nil {
+		response["eta"] = req.ETA
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// APIKeyAcknowledgeIncident handles POST /webhooks/incidents/:id/acknowledge
+// Lets automation acknowledge the incident it is remediating using an API key.
+func (h *IncidentHandler) APIKeyAcknowledgeIncident(c *gin.Context) {
+	id := c.Param("id")
+
+	apiKey, ok := c.MustGet("api_key").(*db.APIKey)
+	if !ok || apiKey == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "API key required"})
+		return
+	}
+
+	// The key acts with its owner's access to the incident
+	_, err := h.checkIncidentAccess(c, id, authz.ActionUpdate)
+	if err != nil {
+		if errors.Is(err, services.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+			return
+		}
+		if errors.Is(err, errIncidentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API key does not have access to this incident"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
+		return
+	}
+
+	var req db.AcknowledgeIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		// Note is optional
+		req.Note = ""
+	}
+
+	if err := h.incidentService.AcknowledgeIncidentByAPIKey(id, apiKey, req.Note); err != nil {
+		if errors.Is(err, services.ErrIncidentNotTriggered) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to acknowledge incident",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Incident acknowledged successfully",
+		"acknowledged_by": db.SystemUserAPI,
+		"api_key_id":      apiKey.ID,
 	})
 }
 
@@ -399,11 +637,11 @@ func (h *IncidentHandler) ResolveIncident(c *gin.Context) {
 	// Check permission (ActionUpdate)
 	_, err := h.checkIncidentAccess(c, id, authz.ActionUpdate)
 	if err != nil {
-		if err.Error() == "incident not found" {
+		if errors.Is(err, services.ErrIncidentNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
 			return
 		}
-		if err.Error() == "forbidden" {
+		if errors.Is(err, errIncidentForbidden) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to resolve this incident"})
 			return
 		}
@@ -419,6 +657,10 @@ func (h *IncidentHandler) ResolveIncident(c *gin.Context) {
 	}
 
 	err = h.incidentService.ResolveIncident(id, userID.(string), req.Note, req.Resolution)
+	if errors.Is(err, services.ErrAlreadyResolved) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Incident is already resolved"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to resolve incident",
@@ -432,8 +674,8 @@ func (h *IncidentHandler) ResolveIncident(c *gin.Context) {
 	})
 }
 
-// AssignIncident handles POST /incidents/:id/assign
-func (h *IncidentHandler) AssignIncident(c *gin.Context) {
+// ReopenIncident handles POST /incidents/:id/reopen
+func (h *IncidentHandler) ReopenIncident(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -442,55 +684,58 @@ func (h *IncidentHandler) AssignIncident(c *gin.Context) {
 		return
 	}
 
-	// Get user ID from context (set by auth middleware)
-	assignedBy, exists := c.Get("user_id")
-	if !exists {
+	userID := c.GetString("user_id")
+	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "User not authenticated",
 		})
 		return
 	}
 
-	// Check permission (ActionUpdate)
 	_, err := h.checkIncidentAccess(c, id, authz.ActionUpdate)
 	if err != nil {
-		if err.Error() == "incident not found" {
+		if errors.Is(err, services.ErrIncidentNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
 			return
 		}
-		if err.Error() == "forbidden" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to assign this incident"})
+		if errors.Is(err, errIncidentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to reopen this incident"})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
 		return
 	}
 
-	var req db.AssignIncidentRequest
+	var req db.ReopenIncidentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
-		return
+		// Reason is optional
+		req.Reason = ""
 	}
 
-	err = h.incidentService.AssignIncident(id, req.AssignedTo, assignedBy.(string), req.Note)
+	err = h.incidentService.ReopenIncident(id, userID, req.Reason)
+	if errors.Is(err, services.ErrIncidentNotResolved) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only resolved incidents can be reopened"})
+		return
+	}
+	if errors.Is(err, services.ErrIncidentKeyInUse) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to assign incident",
+			"error":   "Failed to reopen incident",
 			"details": err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Incident assigned successfully",
+		"message": "Incident reopened successfully",
 	})
 }
 
-// EscalateIncident handles POST /incidents/:id/escalate
-func (h *IncidentHandler) EscalateIncident(c *gin.Context) {
+// SetIncidentEscalationPolicy handles PUT /incidents/:id/escalation-policy
+func (h *IncidentHandler) SetIncidentEscalationPolicy(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -499,64 +744,61 @@ func (h *IncidentHandler) EscalateIncident(c *gin.Context) {
 		return
 	}
 
-	// Get user ID from context (set by auth middleware)
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID := c.GetString("user_id")
+	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "User not authenticated",
 		})
 		return
 	}
 
-	// Check permission (ActionUpdate)
 	_, err := h.checkIncidentAccess(c, id, authz.ActionUpdate)
 	if err != nil {
-		if err.Error() == "incident not found" {
+		if errors.Is(err, services.ErrIncidentNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
 			return
 		}
-		if err.Error() == "forbidden" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to escalate this incident"})
+		if errors.Is(err, errIncidentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to change this incident's escalation policy"})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
 		return
 	}
 
-	// Call the escalation service
-	result, err := h.incidentService.ManualEscalateIncident(id, userID.(string))
-	if err != nil {
-		// Determine appropriate status code based on error
-		statusCode := http.StatusInternalServerError
-		if err.Error() == "incident not found" {
-			statusCode = http.StatusNotFound
-		} else if err.Error() == "cannot escalate resolved incident" ||
-			err.Error() == "incident has no escalation policy" ||
-			err.Error() == "escalation policy has no levels defined" ||
-			len(err.Error()) > 20 && err.Error()[:20] == "already at maximum" {
-			statusCode = http.StatusBadRequest
-		}
+	var req db.SetIncidentEscalationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
 
-		c.JSON(statusCode, gin.H{
-			"error":   "Failed to escalate incident",
+	err = h.incidentService.SetIncidentEscalationPolicy(id, req.EscalationPolicyID, userID)
+	if errors.Is(err, services.ErrEscalationPolicyNotInOrg) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrAlreadyResolved) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Incident is already resolved"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to set escalation policy",
 			"details": err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":           "Incident escalated successfully",
-		"new_level":         result.NewLevel,
-		"assigned_user_id":  result.AssignedUserID,
-		"assigned_to_name":  result.AssignedToName,
-		"escalation_status": result.EscalationStatus,
-		"target_type":       result.TargetType,
-		"has_more_levels":   result.HasMoreLevels,
+		"message": "Escalation policy updated successfully",
 	})
 }
 
-// AddIncidentNote handles POST /incidents/:id/notes
-func (h *IncidentHandler) AddIncidentNote(c *gin.Context) {
+// ArchiveIncident handles POST /incidents/:id/archive
+func (h *IncidentHandler) ArchiveIncident(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -565,79 +807,823 @@ func (h *IncidentHandler) AddIncidentNote(c *gin.Context) {
 		return
 	}
 
-	// Check permission (ActionUpdate - assuming notes require update perm)
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
 	_, err := h.checkIncidentAccess(c, id, authz.ActionUpdate)
 	if err != nil {
-		if err.Error() == "incident not found" {
+		if errors.Is(err, services.ErrIncidentNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
 			return
 		}
-		if err.Error() == "forbidden" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to add notes to this incident"})
+		if errors.Is(err, errIncidentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to archive this incident"})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
 		return
 	}
 
-	var req db.AddIncidentNoteRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+	err = h.incidentService.ArchiveIncident(id, userID)
+	if errors.Is(err, services.ErrIncidentNotResolved) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only resolved incidents can be archived"})
+		return
+	}
+	if errors.Is(err, services.ErrIncidentArchived) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Incident is already archived"})
 		return
 	}
-
-	userID := c.GetString("user_id")
-	err = h.incidentService.AddNote(id, userID, req.Note)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to add note",
+			"error":   "Failed to archive incident",
 			"details": err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Note added successfully",
+		"message": "Incident archived successfully",
 	})
 }
 
-// GetIncidentEvents handles GET /incidents/:id/events
-func (h *IncidentHandler) GetIncidentEvents(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Incident ID is required",
+// BulkUpdateIncidents handles POST /incidents/bulk
+// Acknowledges or resolves several incidents at once; per-incident ReBAC is enforced by the service
+func (h *IncidentHandler) BulkUpdateIncidents(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
 		})
 		return
 	}
 
-	limit := 50
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
-			limit = l
-		}
-	}
-
-	events, err := h.incidentService.GetIncidentEvents(id, limit)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch incident events",
+	var req db.BulkUpdateIncidentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
 			"details": err.Error(),
 		})
 		return
 	}
 
+	// Only incidents of the current organization are updated
+	orgID := authz.GetOrgIDFromContext(c)
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "organization_id is required",
+			"message": "Please provide org_id query param or X-Org-ID header for tenant isolation",
+		})
+		return
+	}
+
+	result, err := h.incidentService.BulkUpdateStatus(req.IncidentIDs, userID, orgID, req.Action, req.Note)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update incidents",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SnoozeIncident handles POST /incidents/:id/snooze
+// Pauses escalation for the requested duration
+func (h *IncidentHandler) SnoozeIncident(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Incident ID is required",
+		})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	// Check permission (ActionUpdate)
+	_, err := h.checkIncidentAccess(c, id, authz.ActionUpdate)
+	if err != nil {
+		if errors.Is(err, services.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+			return
+		}
+		if errors.Is(err, errIncidentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to snooze this incident"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
+		return
+	}
+
+	var req db.SnoozeIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	until := time.Now().Add(time.Duration(req.DurationMinutes) * time.Minute)
+	if err := h.incidentService.SnoozeIncident(id, userID, until, req.Reason); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to snooze incident",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Incident snoozed successfully",
+		"snoozed_until": until.UTC(),
+	})
+}
+
+// HoldIncident handles POST /incidents/:id/hold
+// Puts the incident on hold (external_pending) until the linked vendor ticket is closed
+func (h *IncidentHandler) HoldIncident(c *gin.Context) {
+	id := c.Param("id")
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if _, err := h.checkIncidentAccess(c, id, authz.ActionUpdate); err != nil {
+		if errors.Is(err, services.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+			return
+		}
+		if errors.Is(err, errIncidentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to put this incident on hold"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
+		return
+	}
+
+	var req db.HoldIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	ticket, err := h.incidentService.HoldIncidentForExternalTicket(id, userID, req.Provider, req.TicketURL, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to put incident on hold", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Incident on hold pending external ticket",
+		"status":          db.IncidentStatusExternalPending,
+		"external_ticket": ticket,
+	})
+}
+
+// GetIncidentHold handles GET /incidents/:id/hold, returning the ticket the incident is on hold for
+func (h *IncidentHandler) GetIncidentHold(c *gin.Context) {
+	id := c.Param("id")
+
+	if _, err := h.checkIncidentAccess(c, id, authz.ActionView); err != nil {
+		if errors.Is(err, services.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+			return
+		}
+		if errors.Is(err, errIncidentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to view this incident"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
+		return
+	}
+
+	ticket, err := h.incidentService.GetExternalTicket(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get external ticket", "details": err.Error()})
+		return
+	}
+	if ticket == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incident is not on hold"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// ReleaseIncidentHold handles DELETE /incidents/:id/hold, returning the incident to acknowledged
+func (h *IncidentHandler) ReleaseIncidentHold(c *gin.Context) {
+	id := c.Param("id")
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if _, err := h.checkIncidentAccess(c, id, authz.ActionUpdate); err != nil {
+		if errors.Is(err, services.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+			return
+		}
+		if errors.Is(err, errIncidentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to update this incident"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
+		return
+	}
+
+	if err := h.incidentService.ReleaseIncidentHold(id, userID); err != nil {
+		if errors.Is(err, services.ErrIncidentNotOnHold) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to release incident hold", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Incident hold released",
+		"status":  db.IncidentStatusAcknowledged,
+	})
+}
+
+// AddIncidentWatcher handles POST /incidents/:id/watchers
+// Subscribes the current user, or the user_id in the body (requires update access)
+func (h *IncidentHandler) AddIncidentWatcher(c *gin.Context) {
+	id := c.Param("id")
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req db.AddIncidentWatcherRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request body",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	watcherID := userID
+	if req.UserID != "" {
+		watcherID = req.UserID
+	}
+
+	incident, ok := h.checkWatcherAccess(c, id, watcherID != userID)
+	if !ok {
+		return
+	}
+
+	// Watchers receive the incident's updates, so they must be able to view it
+	if watcherID != userID && !h.authorizer.Check(c.Request.Context(), watcherID, authz.ActionView, authz.ResourceProject, incident.ProjectID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Watcher does not have access to this incident"})
+		return
+	}
+
+	if err := h.incidentService.AddWatcher(id, watcherID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to add watcher",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Watcher added successfully"})
+}
+
+// RemoveIncidentWatcher handles DELETE /incidents/:id/watchers/:user_id
+// Users can always unwatch themselves; removing others requires update access
+func (h *IncidentHandler) RemoveIncidentWatcher(c *gin.Context) {
+	id := c.Param("id")
+	watcherID := c.Param("user_id")
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if _, ok := h.checkWatcherAccess(c, id, watcherID != userID); !ok {
+		return
+	}
+
+	if err := h.incidentService.RemoveWatcher(id, watcherID); err != nil {
+		if errors.Is(err, services.ErrWatcherNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Watcher not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to remove watcher",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Watcher removed successfully"})
+}
+
+// AddIncidentAttachment handles POST /incidents/:id/attachments
+// Links a postmortem, runbook, screenshot or log URL to the incident
+func (h *IncidentHandler) AddIncidentAttachment(c *gin.Context) {
+	id := c.Param("id")
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req db.AddIncidentAttachmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	_, err := h.checkIncidentAccess(c, id, authz.ActionUpdate)
+	if err != nil {
+		if errors.Is(err, services.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+			return
+		}
+		if errors.Is(err, errIncidentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to add attachments to this incident"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
+		return
+	}
+
+	attachment, err := h.incidentService.AddAttachment(id, userID, req.URL, req.Title, req.Kind)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidAttachment) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, services.ErrAttachmentLimitReached) {
+			statusCode = http.StatusConflict
+		}
+		c.JSON(statusCode, gin.H{
+			"error":   "Failed to add attachment",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// checkWatcherAccess writes an error response and returns false unless the current user
+// can view the incident, or update it when managing someone else's subscription
+func (h *IncidentHandler) checkWatcherAccess(c *gin.Context, id string, otherUser bool) (*db.IncidentResponse, bool) {
+	action := authz.ActionView
+	if otherUser {
+		action = authz.ActionUpdate
+	}
+
+	incident, err := h.checkIncidentAccess(c, id, action)
+	if err == nil {
+		return incident, true
+	}
+
+	if errors.Is(err, services.ErrIncidentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+		return nil, false
+	}
+	if errors.Is(err, errIncidentForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to manage watchers of this incident"})
+		return nil, false
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
+	return nil, false
+}
+
+// MergeIncidents handles POST /incidents/:id/merge
+// Merges the incidents in the request body into the incident in the URL
+func (h *IncidentHandler) MergeIncidents(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Incident ID is required",
+		})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req db.MergeIncidentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Check permission (ActionUpdate) on the primary and every merged incident
+	for _, incidentID := range append([]string{id}, req.IncidentIDs...) {
+		if _, err := h.checkIncidentAccess(c, incidentID, authz.ActionUpdate); err != nil {
+			if errors.Is(err, services.ErrIncidentNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found", "incident_id": incidentID})
+				return
+			}
+			if errors.Is(err, errIncidentForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to merge this incident", "incident_id": incidentID})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
+			return
+		}
+	}
+
+	if err := h.incidentService.MergeIncidents(id, req.IncidentIDs, userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to merge incidents",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":             "Incidents merged successfully",
+		"merged_incident_ids": req.IncidentIDs,
+	})
+}
+
+// AssignIncident handles POST /incidents/:id/assign
+func (h *IncidentHandler) AssignIncident(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Incident ID is required",
+		})
+		return
+	}
+
+	// Get user ID from context (set by auth middleware)
+	assignedBy, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	// Check permission (ActionUpdate)
+	_, err := h.checkIncidentAccess(c, id, authz.ActionUpdate)
+	if err != nil {
+		if errors.Is(err, services.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+			return
+		}
+		if errors.Is(err, errIncidentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to assign this incident"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
+		return
+	}
+
+	var req db.AssignIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	err = h.incidentService.AssignIncident(id, req.AssignedTo, assignedBy.(string), req.Note)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to assign incident",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Incident assigned successfully",
+	})
+}
+
+// GetAssignmentHistory handles GET /incidents/:id/assignments
+func (h *IncidentHandler) GetAssignmentHistory(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Incident ID is required",
+		})
+		return
+	}
+
+	if _, err := h.checkIncidentAccess(c, id, authz.ActionView); err != nil {
+		if errors.Is(err, services.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+			return
+		}
+		if errors.Is(err, errIncidentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to view this incident"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
+		return
+	}
+
+	assignments, err := h.incidentService.GetAssignmentHistory(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch assignment history",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"assignments": assignments,
+		"total":       len(assignments),
+	})
+}
+
+// EscalateIncident handles POST /incidents/:id/escalate
+func (h *IncidentHandler) EscalateIncident(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Incident ID is required",
+		})
+		return
+	}
+
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	// Check permission (ActionUpdate)
+	_, err := h.checkIncidentAccess(c, id, authz.ActionUpdate)
+	if err != nil {
+		if errors.Is(err, services.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+			return
+		}
+		if errors.Is(err, errIncidentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to escalate this incident"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
+		return
+	}
+
+	// Call the escalation service
+	result, err := h.incidentService.ManualEscalateIncident(id, userID.(string))
+	if err != nil {
+		// Determine appropriate status code based on error
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, services.ErrIncidentNotFound) {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, services.ErrCannotEscalateResolved) ||
+			errors.Is(err, services.ErrNoEscalationPolicy) ||
+			errors.Is(err, services.ErrNoEscalationLevels) ||
+			errors.Is(err, services.ErrMaxEscalationLevel) {
+			statusCode = http.StatusBadRequest
+		}
+
+		c.JSON(statusCode, gin.H{
+			"error":   "Failed to escalate incident",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Incident escalated successfully",
+		"new_level":         result.NewLevel,
+		"assigned_user_id":  result.AssignedUserID,
+		"assigned_to_name":  result.AssignedToName,
+		"escalation_status": result.EscalationStatus,
+		"target_type":       result.TargetType,
+		"has_more_levels":   result.HasMoreLevels,
+	})
+}
+
+// PreviewEscalation handles GET /incidents/:id/escalate/preview
+// Returns who the next escalation would page without escalating the incident
+func (h *IncidentHandler) PreviewEscalation(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Incident ID is required",
+		})
+		return
+	}
+
+	_, err := h.checkIncidentAccess(c, id, authz.ActionView)
+	if err != nil {
+		if errors.Is(err, services.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+			return
+		}
+		if errors.Is(err, errIncidentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to view this incident"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
+		return
+	}
+
+	result, err := h.incidentService.PreviewNextEscalation(id)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, services.ErrIncidentNotFound) {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, services.ErrCannotEscalateResolved) ||
+			errors.Is(err, services.ErrNoEscalationPolicy) ||
+			errors.Is(err, services.ErrNoEscalationLevels) ||
+			errors.Is(err, services.ErrMaxEscalationLevel) {
+			statusCode = http.StatusBadRequest
+		}
+
+		c.JSON(statusCode, gin.H{
+			"error":   "Failed to preview escalation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// AddIncidentNote handles POST /incidents/:id/notes
+func (h *IncidentHandler) AddIncidentNote(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Incident ID is required",
+		})
+		return
+	}
+
+	// Check permission (ActionUpdate - assuming notes require update perm)
+	_, err := h.checkIncidentAccess(c, id, authz.ActionUpdate)
+	if err != nil {
+		if errors.Is(err, services.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+			return
+		}
+		if errors.Is(err, errIncidentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to add notes to this incident"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
+		return
+	}
+
+	var req db.AddIncidentNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	err = h.incidentService.AddNote(id, userID, req.Note)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to add note",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Note added successfully",
+	})
+}
+
+// GetIncidentEvents handles GET /incidents/:id/events
+// Filters: ?event_type=escalated,acknowledged&since=&until= (RFC3339); paginated with ?limit= and ?cursor=
+func (h *IncidentHandler) GetIncidentEvents(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Incident ID is required",
+		})
+		return
+	}
+
+	if _, err := h.checkIncidentAccess(c, id, authz.ActionView); err != nil {
+		if errors.Is(err, services.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+			return
+		}
+		if errors.Is(err, errIncidentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to view this incident"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permission", "details": err.Error()})
+		return
+	}
+
+	filters := map[string]interface{}{}
+
+	// ?event_type=escalated,acknowledged
+	if eventType := c.Query("event_type"); eventType != "" {
+		filters["event_type"] = eventType
+	}
+	for _, key := range []string{"since", "until"} {
+		if value := c.Query(key); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("Invalid %s, expected RFC3339 timestamp", key),
+				})
+				return
+			}
+			filters[key] = t
+		}
+	}
+
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+	filters["limit"] = limit
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		if _, _, err := services.DecodeIncidentCursor(cursor); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid cursor",
+				"details": err.Error(),
+			})
+			return
+		}
+		filters["cursor"] = cursor
+	}
+
+	events, err := h.incidentService.GetIncidentEventsPaged(id, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch incident events",
+			"details": err.Error(),
+		})
+		return
+	}
+	if events == nil {
+		events = []db.IncidentEvent{}
+	}
+
+	nextCursor := services.NextIncidentEventCursor(events, limit)
 	c.JSON(http.StatusOK, gin.H{
-		"events": events,
+		"events":      events,
+		"limit":       limit,
+		"has_more":    nextCursor != "",
+		"next_cursor": nextCursor,
 	})
 }
 
 // GetIncidentStats handles GET /incidents/stats
+// Scoped like trends: org_id/project_id from query params or the request context.
+// ?time_range= takes the same values as the incident list (default last_30_days).
 func (h *IncidentHandler) GetIncidentStats(c *gin.Context) {
-	stats, err := h.incidentService.GetIncidentStats()
+	orgID, projectID := trendsScope(c)
+
+	stats, err := h.incidentService.GetIncidentStats(orgID, projectID, c.Query("time_range"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to fetch incident stats",
@@ -665,7 +1651,151 @@ func (h *IncidentHandler) GetIncidentTrends(c *gin.Context) {
 		return
 	}
 
-	// Get org_id and project_id from context (injected by middleware) or query params
+	orgID, projectID := trendsScope(c)
+
+	trends, err := h.incidentService.GetIncidentTrends(orgID, projectID, timeRange)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch incident trends",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, trends)
+}
+
+// GetResponseMetrics handles GET /incidents/response-metrics
+// Returns MTTA/MTTR per group (?by=group, default) or per service (?by=service)
+func (h *IncidentHandler) GetResponseMetrics(c *gin.Context) {
+	timeRange := c.DefaultQuery("time_range", "7d")
+
+	validRanges := map[string]bool{"7d": true, "30d": true, "90d": true}
+	if !validRanges[timeRange] {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid time_range",
+			"details": "time_range must be one of: 7d, 30d, 90d",
+		})
+		return
+	}
+
+	orgID, ok := verifiedOrgID(c, h.authorizer)
+	if !ok {
+		return
+	}
+
+	var metrics []services.ResponseMetrics
+	var err error
+	by := c.DefaultQuery("by", "group")
+	switch by {
+	case "group":
+		metrics, err = h.incidentService.GetResponseMetricsByGroup(orgID, timeRange)
+	case "service":
+		metrics, err = h.incidentService.GetResponseMetricsByService(orgID, timeRange)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid by",
+			"details": "by must be one of: group, service",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch response metrics",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"by":         by,
+		"time_range": timeRange,
+		"metrics":    metrics,
+	})
+}
+
+// GetBusinessImpactReport handles GET /incidents/business-impact
+// Returns the customers affected and revenue lost by the organization's incidents
+func (h *IncidentHandler) GetBusinessImpactReport(c *gin.Context) {
+	timeRange := c.DefaultQuery("time_range", "30d")
+
+	validRanges := map[string]bool{"7d": true, "30d": true, "90d": true}
+	if !validRanges[timeRange] {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid time_range",
+			"details": "time_range must be one of: 7d, 30d, 90d",
+		})
+		return
+	}
+
+	orgID, ok := verifiedOrgID(c, h.authorizer)
+	if !ok {
+		return
+	}
+
+	report, err := h.incidentService.GetBusinessImpactReport(orgID, timeRange)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch business impact report",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetSLABreaches handles GET /incidents/sla-breaches
+// Returns incidents whose acknowledgement or resolution exceeded their SLA target
+func (h *IncidentHandler) GetSLABreaches(c *gin.Context) {
+	orgID, ok := verifiedOrgID(c, h.authorizer)
+	if !ok {
+		return
+	}
+
+	breaches, err := h.incidentService.GetSLABreaches(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch SLA breaches",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"breaches": breaches,
+		"total":    len(breaches),
+	})
+}
+
+// verifiedOrgID returns the caller's organization: the one verified by middleware (an API
+// key's org), or the org_id/X-Org-ID of an organization the user can view. Writes the error
+// response and returns false when there is none or the user isn't a member.
+func verifiedOrgID(c *gin.Context, authorizer authz.Authorizer) (string, bool) {
+	orgID := authz.GetOrgIDFromContext(c)
+	if orgID == "" && !c.GetBool("is_api_key") {
+		orgID = c.Query("org_id")
+		if orgID == "" {
+			orgID = c.GetHeader("X-Org-ID")
+		}
+		if orgID != "" && !authorizer.Check(c.Request.Context(), c.GetString("user_id"), authz.ActionView, authz.ResourceOrg, orgID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this organization"})
+			return "", false
+		}
+	}
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Missing organization",
+			"details": "org_id is required",
+		})
+		return "", false
+	}
+	return orgID, true
+}
+
+// trendsScope returns the org_id and project_id used to scope trend queries,
+// taken from query params or from the context injected by middleware
+func trendsScope(c *gin.Context) (string, string) {
 	orgID := c.Query("org_id")
 	if orgID == "" {
 		if ctxOrgID, exists := c.Get("org_id"); exists {
@@ -680,16 +1810,7 @@ func (h *IncidentHandler) GetIncidentTrends(c *gin.Context) {
 		}
 	}
 
-	trends, err := h.incidentService.GetIncidentTrends(orgID, projectID, timeRange)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch incident trends",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, trends)
+	return orgID, projectID
 }
 
 // WebhookCreateIncident handles webhook incident creation (PagerDuty Events API style)
@@ -752,7 +1873,7 @@ func (h *IncidentHandler) WebhookCreateIncident(c *gin.Context) {
 		// Check if incident with this dedup key already exists
 		existingIncidents, err := h.incidentService.ListIncidents(map[string]interface{}{
 			"incident_key": req.DedupKey,
-			"status":       []string{db.IncidentStatusTriggered, db.IncidentStatusAcknowledged},
+			"status":       db.OpenIncidentStatuses,
 		})
 		if err == nil && len(existingIncidents) > 0 {
 			// Update existing incident based on event action
@@ -817,6 +1938,18 @@ func (h *IncidentHandler) WebhookCreateIncident(c *gin.Context) {
 			return
 		}
 
+		// An open incident in the org already had this dedup key; the alert was counted on it
+		if createdIncident.ID != incident.ID {
+			c.JSON(http.StatusOK, db.WebhookIncidentResponse{
+				Status:      "success",
+				Message:     "Incident updated",
+				DedupKey:    req.DedupKey,
+				IncidentID:  createdIncident.ID,
+				IncidentKey: createdIncident.IncidentKey,
+			})
+			return
+		}
+
 		// Queue for AI analysis (non-blocking)
 		if h.analyticsService != nil {
 			h.analyticsService.QueueIncidentForAnalysisAsync(createdIncident)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"time"
@@ -110,11 +111,14 @@ func TestIncidentHandler_GetIncident_ReBAC(t *testing.T) {
 			"escalation_policy_id", "current_escalation_level", "last_escalated_at",
 			"escalation_status", "group_id", "api_key_id", "severity", "incident_key",
 			"alert_count", "labels", "custom_fields",
-			"organization_id", "project_id",
+			"organization_id", "project_id", "snoozed_until", "archived_at",
+			"response_sla_minutes", "resolution_sla_minutes", "snapshot_url", "business_impact", "cluster_id",
+			"deploy_id", "deploy_version", "deploy_environment", "deployed_at",
 			"assigned_to_name", "assigned_to_email",
 			"acknowledged_by_name", "acknowledged_by_email",
 			"resolved_by_name", "resolved_by_email",
 			"group_name", "service_name", "escalation_policy_name",
+			"organization_name", "project_name",
 		}).AddRow(
 			"inc-1", "Test Incident", "Desc", "triggered", "high", "P1",
 			time.Now(), time.Now(), nil, nil,
@@ -123,11 +127,17 @@ func TestIncidentHandler_GetIncident_ReBAC(t *testing.T) {
 			nil, 0, nil,
 			"pending", nil, nil, "critical", "key-1",
 			1, nil, nil,
-			"org-1", "proj-1",
+			"org-1", "proj-1", nil, nil,
+			nil, nil, "https://grafana.example.com/render/cpu.png", []byte(`{"customers_affected": 120, "revenue_per_minute": 50}`), "cluster-1",
+			nil, nil, nil, nil,
 			nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			"Acme", "Checkout",
 		)
 
 		mockDB.ExpectQuery("SELECT .* FROM incidents").WithArgs("inc-1").WillReturnRows(rows)
+		mockDB.ExpectQuery("FROM incident_attachments").WithArgs("inc-1").WillReturnRows(sqlmock.NewRows([]string{
+			"id", "incident_id", "url", "title", "kind", "created_by", "created_by_name", "created_at",
+		}).AddRow("att-1", "inc-1", "https://wiki.example.com/pm/42", "Postmortem", "postmortem", "user-1", "Alice", time.Now()))
 
 		// Mock Authorizer response
 		mockAuthorizer.On("Check", mock.Anything, "user-1", authz.ActionView, authz.ResourceProject, "proj-1").Return(true)
@@ -146,6 +156,12 @@ func TestIncidentHandler_GetIncident_ReBAC(t *testing.T) {
 			t.Logf("Response Body: %s", w.Body.String())
 		}
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"snapshot_url":"https://grafana.example.com/render/cpu.png"`)
+		assert.Contains(t, w.Body.String(), `"business_impact":{"customers_affected":120,"revenue_per_minute":50}`)
+		assert.Contains(t, w.Body.String(), `"cluster_id":"cluster-1"`)
+		assert.Contains(t, w.Body.String(), `"organization_name":"Acme"`)
+		assert.Contains(t, w.Body.String(), `"project_name":"Checkout"`)
+		assert.Contains(t, w.Body.String(), `"attachments":[{"id":"att-1"`)
 		mockAuthorizer.AssertExpectations(t)
 	})
 
@@ -160,11 +176,14 @@ func TestIncidentHandler_GetIncident_ReBAC(t *testing.T) {
 			"escalation_policy_id", "current_escalation_level", "last_escalated_at",
 			"escalation_status", "group_id", "api_key_id", "severity", "incident_key",
 			"alert_count", "labels", "custom_fields",
-			"organization_id", "project_id",
+			"organization_id", "project_id", "snoozed_until", "archived_at",
+			"response_sla_minutes", "resolution_sla_minutes", "snapshot_url", "business_impact", "cluster_id",
+			"deploy_id", "deploy_version", "deploy_environment", "deployed_at",
 			"assigned_to_name", "assigned_to_email",
 			"acknowledged_by_name", "acknowledged_by_email",
 			"resolved_by_name", "resolved_by_email",
 			"group_name", "service_name", "escalation_policy_name",
+			"organization_name", "project_name",
 		}).AddRow(
 			"inc-2", "Test Incident 2", "Desc", "triggered", "high", "P1",
 			time.Now(), time.Now(), nil, nil,
@@ -173,8 +192,11 @@ func TestIncidentHandler_GetIncident_ReBAC(t *testing.T) {
 			nil, 0, nil,
 			"pending", nil, nil, "critical", "key-2",
 			1, nil, nil,
-			"org-1", "proj-2",
+			"org-1", "proj-2", nil, nil,
+			nil, nil, "", nil, nil,
+			nil, nil, nil, nil,
 			nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			"Acme", "Payments",
 		)
 
 		mockDB.ExpectQuery("SELECT .* FROM incidents").WithArgs("inc-2").WillReturnRows(rows)
@@ -210,11 +232,14 @@ func TestIncidentHandler_GetIncident_ReBAC(t *testing.T) {
 			"escalation_policy_id", "current_escalation_level", "last_escalated_at",
 			"escalation_status", "group_id", "api_key_id", "severity", "incident_key",
 			"alert_count", "labels", "custom_fields",
-			"organization_id", "project_id",
+			"organization_id", "project_id", "snoozed_until", "archived_at",
+			"response_sla_minutes", "resolution_sla_minutes", "snapshot_url", "business_impact", "cluster_id",
+			"deploy_id", "deploy_version", "deploy_environment", "deployed_at",
 			"assigned_to_name", "assigned_to_email",
 			"acknowledged_by_name", "acknowledged_by_email",
 			"resolved_by_name", "resolved_by_email",
 			"group_name", "service_name", "escalation_policy_name",
+			"organization_name", "project_name",
 		}).AddRow(
 			"inc-3", "Test Incident 3", "Desc", "triggered", "high", "P1",
 			time.Now(), time.Now(), "user-1", time.Now(),
@@ -223,8 +248,11 @@ func TestIncidentHandler_GetIncident_ReBAC(t *testing.T) {
 			nil, 0, nil,
 			"pending", nil, nil, "critical", "key-3",
 			1, nil, nil,
-			"org-1", "proj-3",
+			"org-1", "proj-3", nil, nil,
+			nil, nil, "", nil, nil,
+			nil, nil, nil, nil,
 			"User One", "user1@example.com", nil, nil, nil, nil, nil, nil, nil,
+			nil, nil,
 		)
 
 		mockDB.ExpectQuery("SELECT .* FROM incidents").WithArgs("inc-3").WillReturnRows(rows)
@@ -243,6 +271,130 @@ func TestIncidentHandler_GetIncident_ReBAC(t *testing.T) {
 
 		// Assertions
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), `"organization_name"`, "names are omitted when the org row is missing")
+		assert.Contains(t, w.Body.String(), `"organization_id":"org-1"`)
 		mockAuthorizer.AssertExpectations(t)
 	})
 }
+
+func TestIncidentHandler_CreateIncident_RejectsAssigneeOutsideProject(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mockAuthorizer := new(MockAuthorizer)
+	mockAuthorizer.On("Check", mock.Anything, "outsider", authz.ActionView, authz.ResourceProject, "proj-1").Return(false)
+	handler := NewIncidentHandler(services.NewIncidentService(db, nil, nil), services.NewServiceService(db), &authz.ProjectService{}, mockAuthorizer, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user_id", "user-1")
+	c.Set(string(authz.ContextKeyProjectID), "proj-1")
+	c.Request, _ = http.NewRequest("POST", "/incidents", strings.NewReader(`{"title":"Database down","assigned_to":"outsider"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.CreateIncident(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockAuthorizer.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet(), "no incident should be created")
+}
+
+func TestIncidentCursorSortable(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+		want    bool
+	}{
+		{"DefaultSort", map[string]interface{}{"cursor": ""}, true},
+		{"CreatedAtDesc", map[string]interface{}{"cursor": "", "sort": "created_at_desc"}, true},
+		{"CustomSort", map[string]interface{}{"cursor": "", "sort": "urgency_desc"}, false},
+		{"Search", map[string]interface{}{"cursor": "", "search": "database"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, incidentCursorSortable(tt.filters))
+		})
+	}
+}
+
+func TestIncidentHandler_GetSLABreaches_RejectsOtherOrg(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mockAuthorizer := new(MockAuthorizer)
+	mockAuthorizer.On("Check", mock.Anything, "user-1", authz.ActionView, authz.ResourceOrg, "org-2").Return(false)
+	handler := NewIncidentHandler(services.NewIncidentService(db, nil, nil), services.NewServiceService(db), &authz.ProjectService{}, mockAuthorizer, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user_id", "user-1")
+	c.Request, _ = http.NewRequest("GET", "/incidents/sla-breaches?org_id=org-2", nil)
+
+	handler.GetSLABreaches(c)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockAuthorizer.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet(), "no breaches should be queried")
+}
+
+func TestIncidentHandler_GetResponseMetrics_RejectsOtherOrg(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mockAuthorizer := new(MockAuthorizer)
+	mockAuthorizer.On("Check", mock.Anything, "user-1", authz.ActionView, authz.ResourceOrg, "org-2").Return(false)
+	handler := NewIncidentHandler(services.NewIncidentService(db, nil, nil), services.NewServiceService(db), &authz.ProjectService{}, mockAuthorizer, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user_id", "user-1")
+	c.Request, _ = http.NewRequest("GET", "/incidents/response-metrics?org_id=org-2", nil)
+
+	handler.GetResponseMetrics(c)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockAuthorizer.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet(), "no metrics should be queried")
+}
+
+func TestIncidentHandler_GetBusinessImpactReport_RejectsOtherOrg(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mockAuthorizer := new(MockAuthorizer)
+	mockAuthorizer.On("Check", mock.Anything, "user-1", authz.ActionView, authz.ResourceOrg, "org-2").Return(false)
+	handler := NewIncidentHandler(services.NewIncidentService(db, nil, nil), services.NewServiceService(db), &authz.ProjectService{}, mockAuthorizer, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user_id", "user-1")
+	c.Request, _ = http.NewRequest("GET", "/incidents/business-impact", nil)
+	c.Request.Header.Set("X-Org-ID", "org-2")
+
+	handler.GetBusinessImpactReport(c)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockAuthorizer.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet(), "no report should be queried")
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/phonginreallife/inres/authz"
@@ -12,14 +14,37 @@ import (
 
 type IntegrationHandler struct {
 	IntegrationService *services.IntegrationService
+	authorizer         authz.Authorizer
 }
 
 func NewIntegrationHandler(integrationService *services.IntegrationService) *IntegrationHandler {
 	return &IntegrationHandler{
 		IntegrationService: integrationService,
+		authorizer:         authz.NewSimpleAuthorizer(integrationService.PG),
 	}
 }
 
+// callerIntegration loads an integration that belongs to the caller's organization; other
+// organizations' integrations are reported as not found. Writes the error response and
+// returns false otherwise.
+func callerIntegration(c *gin.Context, authorizer authz.Authorizer, integrationService *services.IntegrationService, integrationID string) (db.Integration, bool) {
+	orgID, ok := verifiedOrgID(c, authorizer)
+	if !ok {
+		return db.Integration{}, false
+	}
+
+	integration, err := integrationService.GetIntegration(integrationID)
+	if err != nil && err.Error() != "integration not found" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get integration", "details": err.Error()})
+		return db.Integration{}, false
+	}
+	if err != nil || integration.OrganizationID != orgID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Integration not found"})
+		return db.Integration{}, false
+	}
+	return integration, true
+}
+
 // ===========================
 // INTEGRATION ENDPOINTS
 // ===========================
@@ -61,7 +86,7 @@ func (h *IntegrationHandler) CreateIntegration(c *gin.Context) {
 	}
 
 	// Validate integration type
-	validTypes := []string{"prometheus", "datadog", "grafana", "webhook", "aws", "pagerduty", "coralogix", "custom"}
+	validTypes := []string{"prometheus", "datadog", "grafana", "webhook", "aws", "pagerduty", "coralogix", "sentry", "custom"}
 	isValidType := false
 	for _, validType := range validTypes {
 		if req.Type == validType {
@@ -159,6 +184,7 @@ func (h *IntegrationHandler) GetIntegration(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get integration", "details": err.Error()})
 		return
 	}
+	integration.RateLimit = h.IntegrationService.WebhookRateLimit(integration)
 
 	c.JSON(http.StatusOK, gin.H{"integration": integration})
 }
@@ -323,6 +349,53 @@ func (h *IntegrationHandler) GetIntegrationServices(c *gin.Context) {
 	})
 }
 
+// GetWebhookEvents returns an integration's recent raw webhook payloads and the incidents they produced
+// GET /api/integrations/:id/webhook-events
+func (h *IntegrationHandler) GetWebhookEvents(c *gin.Context) {
+	integration, ok := callerIntegration(c, h.authorizer, h.IntegrationService, c.Param("id"))
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	events, err := h.IntegrationService.GetWebhookEvents(integration.ID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhook events", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhook_events": events,
+		"count":          len(events),
+	})
+}
+
+// ReplayWebhookEvent queues a recorded webhook payload to be processed again
+// POST /api/integrations/webhook-events/:event_id/replay
+func (h *IntegrationHandler) ReplayWebhookEvent(c *gin.Context) {
+	orgID, ok := verifiedOrgID(c, h.authorizer)
+	if !ok {
+		return
+	}
+	eventID := c.Param("event_id")
+
+	msgID, err := h.IntegrationService.ReplayWebhookEvent(eventID, orgID)
+	if errors.Is(err, services.ErrWebhookEventNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to replay webhook event", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":    "Webhook event queued for replay",
+		"event_id":   eventID,
+		"message_id": msgID,
+	})
+}
+
 // UpdateServiceIntegration updates a service-integration mapping
 // PUT /api/service-integrations/:id
 func (h *IntegrationHandler) UpdateServiceIntegration(c *gin.Context) {
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/phonginreallife/inres/authz"
+	"github.com/phonginreallife/inres/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIntegrationHandler_GetWebhookEvents_OtherOrganization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// The caller belongs to org-2; int-1 belongs to org-1
+	mockAuthorizer := new(MockAuthorizer)
+	mockAuthorizer.On("Check", mock.Anything, "user-1", authz.ActionView, authz.ResourceOrg, "org-2").Return(true)
+	expectGetIntegration(mockDB, "int-1", "webhook", true, `{}`)
+
+	handler := NewIntegrationHandler(services.NewIntegrationService(pg))
+	handler.authorizer = mockAuthorizer
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user_id", "user-1")
+	c.Params = gin.Params{{Key: "id", Value: "int-1"}}
+	c.Request, _ = http.NewRequest("GET", "/integrations/int-1/webhook-events", nil)
+	c.Request.Header.Set("X-Org-ID", "org-2")
+
+	handler.GetWebhookEvents(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockAuthorizer.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet(), "no webhook events should be read")
+}
+
+func TestIntegrationHandler_ReplayWebhookEvent_RequiresMembership(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockAuthorizer := new(MockAuthorizer)
+	mockAuthorizer.On("Check", mock.Anything, "user-1", authz.ActionView, authz.ResourceOrg, "org-1").Return(false)
+
+	handler := NewIntegrationHandler(services.NewIntegrationService(pg))
+	handler.authorizer = mockAuthorizer
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user_id", "user-1")
+	c.Params = gin.Params{{Key: "event_id", Value: "evt-1"}}
+	c.Request, _ = http.NewRequest("POST", "/integrations/webhook-events/evt-1/replay?org_id=org-1", nil)
+
+	handler.ReplayWebhookEvent(c)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockAuthorizer.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet(), "nothing should be queued")
+}
@@ -8,12 +8,14 @@ import (
 )
 
 type NotificationHandler struct {
-	SlackService *services.SlackService
+	SlackService        *services.SlackService
+	NotificationService *services.NotificationService
 }
 
-func NewNotificationHandler(slackService *services.SlackService) *NotificationHandler {
+func NewNotificationHandler(slackService *services.SlackService, notificationService *services.NotificationService) *NotificationHandler {
 	return &NotificationHandler{
-		SlackService: slackService,
+		SlackService:        slackService,
+		NotificationService: notificationService,
 	}
 }
 
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phonginreallife/inres/services"
+)
+
+// RetryDeadLetteredRequest names the dead-lettered notifications to put back on their queues
+type RetryDeadLetteredRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// ListDeadLetteredNotifications lists queued notifications that ran out of delivery attempts.
+// Platform admins only, since dead letters span every organization.
+// GET /api/notifications/dead-letters?queue=incident_notifications&limit=50
+func (h *NotificationHandler) ListDeadLetteredNotifications(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	deadLetters, err := h.NotificationService.ListDeadLettered(c.GetString("user_id"), c.Query("queue"), limit)
+	if err != nil {
+		if errors.Is(err, services.ErrPlatformAdminRequired) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list dead-lettered notifications", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dead_letters": deadLetters,
+		"count":        len(deadLetters),
+	})
+}
+
+// RetryDeadLetteredNotifications puts dead-lettered notifications back on their queues.
+// Already replayed ones are skipped. Platform admins only.
+// POST /api/notifications/dead-letters/retry
+func (h *NotificationHandler) RetryDeadLetteredNotifications(c *gin.Context) {
+	var req RetryDeadLetteredRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	replayed, err := h.NotificationService.RetryDeadLettered(c.GetString("user_id"), req.IDs)
+	if err != nil {
+		if errors.Is(err, services.ErrNoDeadLetterIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrPlatformAdminRequired) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry dead-lettered notifications", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dead_letters": replayed,
+		"count":        len(replayed),
+	})
+}
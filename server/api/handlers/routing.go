@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -79,7 +80,14 @@ func (h *RoutingHandler) CreateRoutingTable(c *gin.Context) {
 		return
 	}
 
-	table, err := h.RoutingService.CreateRoutingTable(req, userID.(string))
+	// Tables only route alerts of the organization they're created in
+	orgID := c.GetString("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Organization ID is required"})
+		return
+	}
+
+	table, err := h.RoutingService.CreateRoutingTable(orgID, req, userID.(string))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create routing table"})
 		return
@@ -204,7 +212,9 @@ func (h *RoutingHandler) DeleteRoutingRule(c *gin.Context) {
 
 // TESTING AND DEBUGGING ENDPOINTS
 
-// TestRouting tests routing for given alert attributes
+// TestRouting handles POST /orgs/:id/routing/test
+// Shows which of the organization's routing rules alert attributes would match and why,
+// without logging the match or creating an incident
 func (h *RoutingHandler) TestRouting(c *gin.Context) {
 	var req db.TestRoutingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -212,8 +222,8 @@ func (h *RoutingHandler) TestRouting(c *gin.Context) {
 		return
 	}
 
-	result, err := h.RoutingService.TestRouting(req.Alert)
-	if err != nil {
+	result, err := h.RoutingService.TestRouting(c.Param("id"), req.Alert)
+	if errors.Is(err, services.ErrNoRouteMatched) {
 		c.JSON(http.StatusOK, gin.H{
 			"matched":          false,
 			"error":            err.Error(),
@@ -221,6 +231,10 @@ func (h *RoutingHandler) TestRouting(c *gin.Context) {
 		})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to test routing", "details": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"matched":          true,
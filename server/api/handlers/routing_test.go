@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/phonginreallife/inres/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutingHandler_TestRouting(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	handler := NewRoutingHandler(services.NewRoutingService(pg))
+	testRouting := func(body string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/orgs/org-1/routing/test", bytes.NewReader([]byte(body)))
+		c.Params = gin.Params{{Key: "id", Value: "org-1"}}
+		handler.TestRouting(c)
+
+		var resp map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	// Only the org from the path is evaluated, and nothing is logged
+	now := time.Now()
+	mockDB.ExpectQuery(`FROM alert_routing_tables\s+WHERE is_active = true AND organization_id = \$1`).
+		WithArgs("org-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "is_active", "priority", "created_at", "updated_at", "created_by"}).
+			AddRow("table-1", "Production", "", true, 100, now, now, nil))
+	mockDB.ExpectQuery(`FROM alert_routing_rules arr`).
+		WithArgs("table-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "routing_table_id", "name", "priority", "is_active",
+			"match_conditions", "target_group_id", "escalation_rule_id", "time_conditions",
+			"created_at", "updated_at", "created_by", "group_name", "escalation_rule_name",
+		}).AddRow("rule-1", "table-1", "Database alerts", 50, true, []byte(`{"labels.team": "db"}`), "group-dba", nil, nil, now, now, nil, "DBA", ""))
+
+	code, resp := testRouting(`{"alert": {"severity": "critical", "labels": {"team": "db"}}}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, resp["matched"])
+	result, _ := resp["routing_result"].(map[string]interface{})
+	assert.Equal(t, "group-dba", result["target_group_id"])
+	assert.Contains(t, result["matched_reason"], "rule 'Database alerts' in table 'Production'")
+
+	// No matching rule is a normal answer, not an error
+	mockDB.ExpectQuery(`FROM alert_routing_tables`).
+		WithArgs("org-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "is_active", "priority", "created_at", "updated_at", "created_by"}))
+
+	code, resp = testRouting(`{"alert": {"severity": "info"}}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, false, resp["matched"])
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
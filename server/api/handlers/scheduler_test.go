@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/phonginreallife/inres/services"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHandlersDoNotCallUnscopedSchedulerQueries keeps GetSchedulersByGroup, which skips
+// the membership check, out of every request path
+func TestHandlersDoNotCallUnscopedSchedulerQueries(t *testing.T) {
+	for _, dir := range []string{".", "../router"} {
+		pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+			return !strings.HasSuffix(info.Name(), "_test.go")
+		}, 0)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", dir, err)
+		}
+
+		for _, pkg := range pkgs {
+			for name, file := range pkg.Files {
+				ast.Inspect(file, func(n ast.Node) bool {
+					if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == "GetSchedulersByGroup" {
+						t.Errorf("%s uses GetSchedulersByGroup; use GetSchedulersByGroupWithFilters", filepath.Clean(name))
+					}
+					return true
+				})
+			}
+		}
+	}
+}
+
+func TestGetGroupSchedulers_Unauthenticated(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	handler := NewSchedulerHandler(services.NewSchedulerService(pg), nil, nil)
+	getSchedulers := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", url, nil)
+		c.Params = gin.Params{{Key: "id", Value: "group-1"}}
+		handler.GetGroupSchedulers(c)
+		return w
+	}
+
+	// No organization
+	assert.Equal(t, http.StatusBadRequest, getSchedulers("/groups/group-1/schedulers").Code)
+
+	// An organization but no authenticated user sees nothing, without a query
+	w := getSchedulers("/groups/group-1/schedulers?org_id=org-1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"schedulers": [], "total": 0}`, w.Body.String())
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/phonginreallife/inres/authz"
@@ -61,6 +62,10 @@ func (h *ServiceHandler) CreateService(c *gin.Context) {
 	// Create service
 	service, err := h.ServiceService.CreateService(groupID, req, userID.(string))
 	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid notification_settings") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create service: " + err.Error()})
 		return
 	}
@@ -127,6 +132,10 @@ func (h *ServiceHandler) UpdateService(c *gin.Context) {
 
 	service, err := h.ServiceService.UpdateService(serviceID, req)
 	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid notification_settings") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update service: " + err.Error()})
 		return
 	}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,7 +11,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/phonginreallife/inres/authz"
 	"github.com/phonginreallife/inres/db"
+	"github.com/phonginreallife/inres/internal/config"
 	"github.com/phonginreallife/inres/services"
 )
 
@@ -19,6 +22,8 @@ type WebhookHandler struct {
 	alertService       *services.AlertService
 	incidentService    *services.IncidentService
 	serviceService     *services.ServiceService
+	routingService     *services.RoutingService
+	authorizer         authz.Authorizer
 }
 
 func NewWebhookHandler(integrationService *services.IntegrationService, alertService *services.AlertService, incidentService *services.IncidentService, serviceService *services.ServiceService) *WebhookHandler {
@@ -27,6 +32,8 @@ func NewWebhookHandler(integrationService *services.IntegrationService, alertSer
 		alertService:       alertService,
 		incidentService:    incidentService,
 		serviceService:     serviceService,
+		routingService:     services.NewRoutingService(integrationService.PG),
+		authorizer:         authz.NewSimpleAuthorizer(integrationService.PG),
 	}
 }
 
@@ -42,7 +49,8 @@ type WebhookPayload struct {
 type ProcessedAlert struct {
 	AlertName   string                 `json:"alert_name"`
 	Severity    string                 `json:"severity"`
-	Status      string                 `json:"status"` // firing, resolved
+	RawSeverity string                 `json:"raw_severity,omitempty"` // Provider's severity before mapping, e.g. Datadog "P2"
+	Status      string                 `json:"status"`                 // firing, resolved
 	Summary     string                 `json:"summary"`
 	Description string                 `json:"description"`
 	Labels      map[string]interface{} `json:"labels"`
@@ -51,6 +59,13 @@ type ProcessedAlert struct {
 	EndsAt      *time.Time             `json:"ends_at,omitempty"`
 	Fingerprint string                 `json:"fingerprint"` // For deduplication
 	Priority    string                 `json:"priority"`
+	SnapshotURL string                 `json:"snapshot_url,omitempty"` // Metric/graph image from the monitoring tool
+
+	// Fingerprints lists every alert a single resolve covers (batch resolve)
+	Fingerprints []string `json:"fingerprints,omitempty"`
+
+	// ResolutionNote is the summary a provider sends with a resolve, if any
+	ResolutionNote string `json:"resolution_note,omitempty"`
 }
 
 // ResolvedServiceInfo holds service resolution results
@@ -58,6 +73,9 @@ type ResolvedServiceInfo struct {
 	Service            *db.Service
 	ServiceIntegration *db.ServiceIntegration
 	Found              bool
+
+	// Route is the routing table rule the alert matched, if any; its group wins over the service's
+	Route *db.RoutingResult
 }
 
 // ResolvedAssigneeInfo holds assignee resolution results
@@ -96,8 +114,32 @@ func (h *WebhookHandler) ReceiveWebhook(c *gin.Context) {
 	}
 
 	// Get raw body
+	body, err := c.GetRawData()
+	if err != nil {
+		log.Printf("Failed to read webhook body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	// Signatures are computed over the raw body, so verify before parsing
+	if err := verifyWebhookSignature(integration, c.Request.Header, body); err != nil {
+		log.Printf("Webhook signature verification failed for integration %s: %v", integrationID, err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid webhook signature"})
+		return
+	}
+
+	// Checked after the signature so unsigned requests can't use up a monitor's budget
+	if !h.integrationService.AllowWebhook(integration) {
+		log.Printf("WARNING: Rate limit exceeded for integration %s, dropping webhook", integrationID)
+		if err := h.integrationService.IncrementDroppedAlerts(integrationID); err != nil {
+			log.Printf("Failed to count dropped webhook for integration %s: %v", integrationID, err)
+		}
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded for integration"})
+		return
+	}
+
 	var rawPayload map[string]interface{}
-	if err := c.ShouldBindJSON(&rawPayload); err != nil {
+	if err := json.Unmarshal(body, &rawPayload); err != nil {
 		log.Printf("Invalid JSON payload: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON payload"})
 		return
@@ -109,57 +151,137 @@ func (h *WebhookHandler) ReceiveWebhook(c *gin.Context) {
 		// Don't fail the webhook for this
 	}
 
-	// Process webhook based on type
-	var processedAlerts []ProcessedAlert
-	switch integrationType {
-	case "prometheus":
-		processedAlerts = h.processPrometheusWebhook(rawPayload)
-	case "datadog":
-		processedAlerts = h.processDatadogWebhook(rawPayload)
-	case "grafana":
-		processedAlerts = h.processGrafanaWebhook(rawPayload)
-	case "pagerduty":
-		processedAlerts = h.processPagerDutyWebhook(rawPayload)
-	case "coralogix":
-		processedAlerts = h.processCoralogixWebhook(rawPayload)
-	case "webhook":
-		processedAlerts = h.processGenericWebhook(rawPayload)
-	case "aws":
-		processedAlerts = h.processAWSWebhook(rawPayload)
-	default:
-		processedAlerts = h.processGenericWebhook(rawPayload)
+	msg := services.WebhookQueueMessage{
+		IntegrationID:   integrationID,
+		IntegrationType: integrationType,
+		Payload:         json.RawMessage(body),
+		ReceivedAt:      time.Now().UTC(),
 	}
 
-	// Log webhook payload for debugging/audit
-	webhookPayload := WebhookPayload{
-		IntegrationType: integrationType,
-		IntegrationID:   integrationID,
-		Timestamp:       time.Now(),
-		RawPayload:      rawPayload,
-		ProcessedAlerts: processedAlerts,
+	// Keep the raw payload for audit and replay
+	if eventID, err := h.integrationService.RecordWebhookEvent(msg); err != nil {
+		log.Printf("WARNING: Failed to record webhook event for integration %s: %v", integrationID, err)
+		// Don't fail the webhook for this
+	} else {
+		msg.EventID = eventID
+	}
+
+	// Persist the payload and answer right away; the webhook worker routes it to incidents,
+	// so a slow database or notification doesn't make the provider time out and retry
+	msgID, err := h.integrationService.EnqueueWebhook(msg)
+	if err != nil {
+		log.Printf("Failed to queue webhook for integration %s: %v", integrationID, err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to queue webhook"})
+		return
 	}
-	log.Printf("Webhook payload processed: type=%s, integration=%s, alerts=%d",
-		webhookPayload.IntegrationType, webhookPayload.IntegrationID, len(webhookPayload.ProcessedAlerts))
 
-	log.Printf("processedAlerts: %v", processedAlerts)
+	log.Printf("Queued webhook: integration=%s, type=%s, msg_id=%d", integrationID, integrationType, msgID)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":        "Webhook accepted",
+		"message_id":     msgID,
+		"integration_id": integrationID,
+		"timestamp":      time.Now(),
+	})
+}
+
+// ProcessQueuedWebhook converts a queued webhook payload into alerts and routes each of them.
+// It is called by the webhook worker; an error leaves the message on the queue to be retried.
+func (h *WebhookHandler) ProcessQueuedWebhook(msg services.WebhookQueueMessage) error {
+	integration, err := h.integrationService.GetIntegration(msg.IntegrationID)
+	if err != nil {
+		return fmt.Errorf("failed to get integration %s: %w", msg.IntegrationID, err)
+	}
+	if !integration.IsActive {
+		log.Printf("Dropping queued webhook for inactive integration %s", msg.IntegrationID)
+		h.completeWebhookEvent(msg.EventID, nil, fmt.Errorf("integration %s is inactive", msg.IntegrationID))
+		return nil
+	}
+
+	var rawPayload map[string]interface{}
+	if err := json.Unmarshal(msg.Payload, &rawPayload); err != nil {
+		log.Printf("Dropping queued webhook with invalid JSON payload for integration %s: %v", msg.IntegrationID, err)
+		h.completeWebhookEvent(msg.EventID, nil, fmt.Errorf("invalid JSON payload: %w", err))
+		return nil
+	}
+
+	// Process webhook based on type, unless the integration maps its own payload shape
+	var processedAlerts []ProcessedAlert
+	if transform, ok := integrationPayloadTransform(integration); ok {
+		processedAlerts = h.processTransformedWebhook(rawPayload, transform)
+	} else {
+		processedAlerts = h.processWebhookByType(msg.IntegrationType, rawPayload)
+	}
+
+	log.Printf("Webhook payload processed: type=%s, integration=%s, alerts=%d",
+		msg.IntegrationType, msg.IntegrationID, len(processedAlerts))
 
 	// Process each alert: handle based on status (firing vs resolved)
+	severityMap := integrationSeverityMap(integration)
+	var incidentIDs []string
+	var failed int
+	var lastErr, lastRouteErr error
 	for _, alert := range processedAlerts {
-		if err := h.routeAlert(integration, alert); err != nil {
+		alert = applySeverityMap(alert, severityMap)
+		ids, err := h.routeAlertWithRetry(integration, alert)
+		if err != nil {
 			log.Printf("Failed to process alert %s: %v", alert.AlertName, err)
+			lastRouteErr = err
+			// A dead-lettered alert is handled: an operator replays it once the cause is fixed
+			if dlErr := h.deadLetterAlert(integration, alert, err); dlErr != nil {
+				log.Printf("Failed to dead-letter alert %s: %v", alert.AlertName, dlErr)
+				failed++
+				lastErr = err
+			}
 			// Continue processing other alerts
+			continue
 		}
+		incidentIDs = append(incidentIDs, ids...)
 	}
 
-	// Log webhook for debugging/audit
-	log.Printf("Processed webhook: integration=%s, alerts_count=%d", integrationID, len(processedAlerts))
+	log.Printf("Processed webhook: integration=%s, alerts_count=%d, failed=%d", msg.IntegrationID, len(processedAlerts), failed)
+	h.completeWebhookEvent(msg.EventID, incidentIDs, lastRouteErr)
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":        "Webhook processed successfully",
-		"alerts_count":   len(processedAlerts),
-		"integration_id": integrationID,
-		"timestamp":      time.Now(),
-	})
+	// Only a webhook none of whose alerts got through or into the dead letters is retried,
+	// so a retry never counts an alert twice on the incidents that were created
+	if failed > 0 && failed == len(processedAlerts) {
+		return fmt.Errorf("failed to route webhook alerts: %w", lastErr)
+	}
+	return nil
+}
+
+// completeWebhookEvent records the outcome of processing on the payload's webhook event, if it has one
+func (h *WebhookHandler) completeWebhookEvent(eventID string, incidentIDs []string, processErr error) {
+	if eventID == "" {
+		return
+	}
+	if err := h.integrationService.CompleteWebhookEvent(eventID, incidentIDs, processErr); err != nil {
+		log.Printf("WARNING: Failed to record outcome of webhook event %s: %v", eventID, err)
+	}
+}
+
+// processWebhookByType converts a payload with the integration type's built-in parser
+func (h *WebhookHandler) processWebhookByType(integrationType string, payload map[string]interface{}) []ProcessedAlert {
+	switch integrationType {
+	case "prometheus":
+		return h.processPrometheusWebhook(payload)
+	case "datadog":
+		return h.processDatadogWebhook(payload)
+	case "grafana":
+		return h.processGrafanaWebhook(payload)
+	case "pagerduty":
+		return h.processPagerDutyWebhook(payload)
+	case "coralogix":
+		return h.processCoralogixWebhook(payload)
+	case "sentry":
+		return h.processSentryWebhook(payload)
+	case "webhook":
+		return h.processGenericWebhook(payload)
+	case "aws":
+		return h.processAWSWebhook(payload)
+	default:
+		return h.processGenericWebhook(payload)
+	}
 }
 
 // Process Prometheus AlertManager webhook
@@ -252,8 +374,42 @@ func (h *WebhookHandler) processPrometheusWebhookLegacy(payload map[string]inter
 	return alerts
 }
 
-// Process Datadog webhook
+// Process Datadog webhook. Batched payloads carry their events in an "events" array
+// and yield one alert per event.
 func (h *WebhookHandler) processDatadogWebhook(payload map[string]interface{}) []ProcessedAlert {
+	if events, ok := payload["events"].([]interface{}); ok && len(events) > 0 {
+		return h.processDatadogBatch(events)
+	}
+	return h.processDatadogEvent(payload)
+}
+
+// processDatadogBatch processes each event of a batched Datadog payload. Events without
+// an aggregation or alert cycle key are fingerprinted by event ID so they stay distinct.
+func (h *WebhookHandler) processDatadogBatch(events []interface{}) []ProcessedAlert {
+	var alerts []ProcessedAlert
+	for i, raw := range events {
+		event, ok := raw.(map[string]interface{})
+		if !ok {
+			log.Printf("WARN: Skipping Datadog batch event %d: not an object", i)
+			continue
+		}
+
+		for _, alert := range h.processDatadogEvent(event) {
+			if alert.Fingerprint == "" {
+				if id := getStringFromMap(event, "id", ""); id != "" {
+					alert.Fingerprint = "datadog-" + id
+				}
+			}
+			alerts = append(alerts, alert)
+		}
+	}
+
+	log.Printf("INFO: Processed %d Datadog alerts from a batch of %d events", len(alerts), len(events))
+	return alerts
+}
+
+// processDatadogEvent processes a single Datadog event
+func (h *WebhookHandler) processDatadogEvent(payload map[string]interface{}) []ProcessedAlert {
 	var alerts []ProcessedAlert
 
 	// Try to unmarshal into typed struct first
@@ -303,6 +459,7 @@ func (h *WebhookHandler) processDatadogWebhookLegacy(payload map[string]interfac
 	alert := ProcessedAlert{
 		AlertName:   title,
 		Severity:    severity,
+		RawSeverity: alertPriority,
 		Status:      mapDatadogStatus(transition),
 		Summary:     title,
 		Description: getStringFromMap(payload, "body", ""),
@@ -317,16 +474,20 @@ func (h *WebhookHandler) processDatadogWebhookLegacy(payload map[string]interfac
 			"org_name":     getStringFromMap(payload, "org.name", ""),
 			"last_updated": getStringFromMap(payload, "last_updated", ""),
 		},
-		StartsAt: parseDatadogTimestamp(payload),
+		StartsAt:    parseDatadogTimestamp(payload),
+		SnapshotURL: getStringFromMap(payload, "snapshot", ""),
 	}
 
 	alerts = append(alerts, alert)
 	return alerts
 }
 
-// Process Grafana webhook
+// Process Grafana webhook. Grafana 9+ unified alerting sends Alertmanager-compatible
+// payloads, which go through the Prometheus conversion; older payloads use the legacy format.
 func (h *WebhookHandler) processGrafanaWebhook(payload map[string]interface{}) []ProcessedAlert {
-	var alerts []ProcessedAlert
+	if isAlertmanagerPayload(payload) {
+		return h.processGrafanaUnifiedWebhook(payload)
+	}
 
 	// Try to unmarshal into typed struct first
 	payloadBytes, err := json.Marshal(payload)
@@ -341,11 +502,64 @@ func (h *WebhookHandler) processGrafanaWebhook(payload map[string]interface{}) [
 		return h.processGrafanaWebhookLegacy(payload)
 	}
 
-	// Convert to ProcessedAlert
-	alert := webhook.ToProcessedAlert()
-	alerts = append(alerts, alert)
+	// Legacy alerts matching several series carry one evaluation match per series
+	alerts := webhook.ToProcessedAlerts()
+
+	log.Printf("INFO: Processed %d Grafana alerts: %s (State: %s)", len(alerts), webhook.RuleName, webhook.State)
+	return alerts
+}
+
+// isAlertmanagerPayload reports whether a payload has an Alertmanager-style alerts[] array
+func isAlertmanagerPayload(payload map[string]interface{}) bool {
+	alerts, ok := payload["alerts"].([]interface{})
+	if !ok || len(alerts) == 0 {
+		return false
+	}
+	first, ok := alerts[0].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, hasLabels := first["labels"]
+	_, hasStatus := first["status"]
+	return hasLabels || hasStatus
+}
+
+// processGrafanaUnifiedWebhook converts a Grafana unified alerting payload with the
+// Prometheus path, then adds what Grafana sends beyond Alertmanager: a "fingerprint"
+// label takes precedence over the alert fingerprint, and per-alert panel screenshots
+// become the snapshot URL.
+func (h *WebhookHandler) processGrafanaUnifiedWebhook(payload map[string]interface{}) []ProcessedAlert {
+	alerts := h.processPrometheusWebhook(payload)
+
+	rawAlerts, _ := payload["alerts"].([]interface{})
+	commonAnnotations := getMapFromMap(payload, "commonAnnotations")
+	for i := range alerts {
+		alert := &alerts[i]
+		if alert.Labels == nil {
+			alert.Labels = map[string]interface{}{}
+		}
+		if _, ok := alert.Labels["source"]; !ok {
+			alert.Labels["source"] = "grafana"
+		}
+		if fp, ok := alert.Labels["fingerprint"].(string); ok && fp != "" {
+			alert.Fingerprint = fp
+		}
+
+		// Both conversions keep the order of alerts[], skipping nothing when all are objects
+		if alert.SnapshotURL == "" && len(rawAlerts) == len(alerts) {
+			if raw, ok := rawAlerts[i].(map[string]interface{}); ok {
+				alert.SnapshotURL = getStringFromMap(raw, "imageURL", "")
+			}
+		}
+		for _, key := range snapshotAnnotationKeys {
+			if alert.SnapshotURL != "" {
+				break
+			}
+			alert.SnapshotURL, _ = commonAnnotations[key].(string)
+		}
+	}
 
-	log.Printf("INFO: Processed Grafana alert: %s (State: %s)", webhook.RuleName, webhook.State)
+	log.Printf("INFO: Processed %d Grafana unified alerts", len(alerts))
 	return alerts
 }
 
@@ -356,6 +570,7 @@ func (h *WebhookHandler) processGrafanaWebhookLegacy(payload map[string]interfac
 	alert := ProcessedAlert{
 		AlertName:   getStringFromMap(payload, "ruleName", "grafana-alert"),
 		Severity:    mapGrafanaSeverity(getStringFromMap(payload, "state", "alerting")),
+		RawSeverity: getStringFromMap(payload, "state", "alerting"),
 		Status:      mapGrafanaStatus(getStringFromMap(payload, "state", "alerting")),
 		Summary:     getStringFromMap(payload, "message", ""),
 		Description: getStringFromMap(payload, "title", ""),
@@ -368,7 +583,8 @@ func (h *WebhookHandler) processGrafanaWebhookLegacy(payload map[string]interfac
 			"grafana_url": getStringFromMap(payload, "ruleUrl", ""),
 			"image_url":   getStringFromMap(payload, "imageUrl", ""),
 		},
-		StartsAt: time.Now(),
+		StartsAt:    time.Now(),
+		SnapshotURL: getStringFromMap(payload, "imageUrl", ""),
 	}
 
 	alerts = append(alerts, alert)
@@ -424,6 +640,7 @@ func (h *WebhookHandler) processAWSWebhookLegacy(payload map[string]interface{})
 	alert := ProcessedAlert{
 		AlertName:   getStringFromMap(payload, "AlarmName", "aws-alarm"),
 		Severity:    mapAWSSeverity(getStringFromMap(payload, "NewStateValue", "ALARM")),
+		RawSeverity: getStringFromMap(payload, "NewStateValue", "ALARM"),
 		Status:      mapAWSStatus(getStringFromMap(payload, "NewStateValue", "ALARM")),
 		Summary:     getStringFromMap(payload, "AlarmDescription", ""),
 		Description: getStringFromMap(payload, "NewStateReason", ""),
@@ -519,6 +736,7 @@ func (h *WebhookHandler) processPagerDutyWebhookLegacy(payload map[string]interf
 	alert := ProcessedAlert{
 		AlertName:   title,
 		Severity:    severity,
+		RawSeverity: urgency,
 		Status:      alertStatus,
 		Summary:     title,
 		Description: description,
@@ -607,6 +825,7 @@ func (h *WebhookHandler) processCoralogixWebhookLegacy(payload map[string]interf
 	alert := ProcessedAlert{
 		AlertName:   alertName,
 		Severity:    severity,
+		RawSeverity: alertSeverity,
 		Status:      status,
 		Summary:     alertName,
 		Description: description,
@@ -627,6 +846,83 @@ func (h *WebhookHandler) processCoralogixWebhookLegacy(payload map[string]interf
 	return alerts
 }
 
+// Process Sentry issue alert webhook
+func (h *WebhookHandler) processSentryWebhook(payload map[string]interface{}) []ProcessedAlert {
+	var alerts []ProcessedAlert
+
+	// Try to unmarshal into typed struct first
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("ERROR: Failed to marshal Sentry payload: %v", err)
+		return h.processSentryWebhookLegacy(payload)
+	}
+
+	var webhook SentryWebhook
+	if err := json.Unmarshal(payloadBytes, &webhook); err != nil {
+		log.Printf("WARN: Failed to unmarshal Sentry webhook, falling back to legacy: %v", err)
+		return h.processSentryWebhookLegacy(payload)
+	}
+
+	// Convert to ProcessedAlert
+	alert := webhook.ToProcessedAlert()
+	alerts = append(alerts, alert)
+
+	log.Printf("INFO: Processed Sentry alert: name=%s, action=%s, status=%s, fingerprint=%s, severity=%s",
+		alert.AlertName, webhook.Action, alert.Status, alert.Fingerprint, alert.Severity)
+	return alerts
+}
+
+// Legacy fallback for Sentry webhook processing
+func (h *WebhookHandler) processSentryWebhookLegacy(payload map[string]interface{}) []ProcessedAlert {
+	var alerts []ProcessedAlert
+
+	// New payloads nest the event under data, legacy ones at the top level
+	event := getMapFromMap(getMapFromMap(payload, "data"), "event")
+	if len(event) == 0 {
+		event = getMapFromMap(payload, "event")
+	}
+
+	title := getStringFromMap(event, "title", getStringFromMap(payload, "message", "sentry-alert"))
+	level := getStringFromMap(event, "level", getStringFromMap(payload, "level", "error"))
+	issueID := getStringFromMap(event, "issue_id", getStringFromMap(payload, "id", ""))
+	culprit := getStringFromMap(event, "culprit", getStringFromMap(payload, "culprit", ""))
+
+	status := "firing"
+	if strings.EqualFold(getStringFromMap(payload, "action", ""), "resolved") {
+		status = "resolved"
+	}
+
+	fingerprint := "sentry-" + issueID
+	if issueID == "" {
+		fingerprint = fmt.Sprintf("sentry-%s-%s", getStringFromMap(payload, "project", ""), title)
+	}
+
+	severity := mapSentryLevel(level)
+	alert := ProcessedAlert{
+		AlertName:   title,
+		Severity:    severity,
+		RawSeverity: level,
+		Status:      status,
+		Summary:     title,
+		Description: culprit,
+		Fingerprint: fingerprint,
+		Priority:    mapSeverityToPriority(severity),
+		Labels: map[string]interface{}{
+			"source":      "sentry",
+			"issue_id":    issueID,
+			"culprit":     culprit,
+			"transaction": getStringFromMap(event, "transaction", ""),
+		},
+		Annotations: map[string]interface{}{
+			"url": getStringFromMap(event, "web_url", getStringFromMap(payload, "url", "")),
+		},
+		StartsAt: time.Now(),
+	}
+
+	alerts = append(alerts, alert)
+	return alerts
+}
+
 // Process generic webhook
 func (h *WebhookHandler) processGenericWebhook(payload map[string]interface{}) []ProcessedAlert {
 	var alerts []ProcessedAlert
@@ -672,10 +968,17 @@ func (h *WebhookHandler) processGenericWebhookLegacy(payload map[string]interfac
 	return alerts
 }
 
-// Route alert: handle based on status (firing vs resolved)
-func (h *WebhookHandler) routeAlert(integration db.Integration, alert ProcessedAlert) error {
+// Route alert: handle based on status (firing vs resolved). Returns the IDs of the incidents
+// the alert opened, re-opened, counted on or resolved.
+func (h *WebhookHandler) routeAlert(integration db.Integration, alert ProcessedAlert) ([]string, error) {
 	log.Printf("DEBUG: Routing alert %s with status %s", alert.AlertName, alert.Status)
 
+	// Filtered alerts never open incidents; resolves still pass so existing incidents can close
+	if alert.Status != "resolved" && !alertNameAllowed(integration, alert.AlertName) {
+		log.Printf("DEBUG: Dropping alert %s filtered by integration %s allow/deny lists", alert.AlertName, integration.ID)
+		return nil, nil
+	}
+
 	switch alert.Status {
 	case "firing":
 		return h.routeAlertToCreateIncident(integration, alert)
@@ -688,19 +991,59 @@ func (h *WebhookHandler) routeAlert(integration db.Integration, alert ProcessedA
 }
 
 // Route alert: atomic incident creation with full service resolution
-func (h *WebhookHandler) routeAlertToCreateIncident(integration db.Integration, alert ProcessedAlert) error {
+func (h *WebhookHandler) routeAlertToCreateIncident(integration db.Integration, alert ProcessedAlert) ([]string, error) {
 	log.Printf("DEBUG: Starting atomic incident creation for integration %s", integration.ID)
 
 	// Step 0: Check for duplicate incidents (deduplication)
 	if alert.Fingerprint != "" {
-		existingIncident, err := h.incidentService.FindIncidentByFingerprint(alert.Fingerprint)
+		scope := h.dedupScope(integration)
+		existingIncident, err := h.incidentService.FindIncidentByFingerprintForIntegration(alert.Fingerprint, scope)
 		if err == nil && existingIncident != nil {
 			log.Printf("DEBUG: Found existing incident %s with fingerprint %s, skipping duplicate creation",
 				existingIncident.ID, alert.Fingerprint)
 			// Optionally increment alert count on existing incident
 			_ = h.incidentService.IncrementAlertCount(existingIncident.ID)
-			return nil
+			return []string{existingIncident.ID}, nil
+		}
+
+		// Flapping alert: re-open an incident resolved within the dedup window
+		if h.incidentService.FeatureFlags.IsEnabled(integration.OrganizationID, services.FeatureFlapSuppression) {
+			reopenedID, err := h.incidentService.ReopenRecentlyResolvedIncident(alert.Fingerprint, scope,
+				time.Duration(config.App.DedupWindowMinutes)*time.Minute)
+			if err != nil {
+				log.Printf("WARNING: Failed to check recently resolved incidents for fingerprint %s: %v", alert.Fingerprint, err)
+			} else if reopenedID != "" {
+				log.Printf("DEBUG: Re-opened recently resolved incident %s with fingerprint %s", reopenedID, alert.Fingerprint)
+				return []string{reopenedID}, nil
+			}
+		}
+	}
+
+	// Fast path: insert a minimal incident now and resolve service/assignee from the enrichment queue
+	if config.App.WebhookAsyncIncidents {
+		alertJSON, err := json.Marshal(alert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal alert: %w", err)
+		}
+		incident, err := h.incidentService.CreateIncidentAsync(h.buildIncidentFromAlert(integration, alert), services.IncidentEnrichmentMessage{
+			IntegrationID: integration.ID,
+			Alert:         alertJSON,
+		})
+		if errors.Is(err, services.ErrEnrichmentNotQueued) {
+			// The incident exists, so a retry would only dedup onto it: enrich it now instead
+			log.Printf("WARNING: %v, enriching incident %s inline", err, incident.ID)
+			if err := h.enrichIncident(integration, alert, incident); err != nil {
+				log.Printf("ERROR: %v", err)
+			}
+			return []string{incident.ID}, nil
 		}
+		if err != nil {
+			log.Printf("ERROR: Failed to create incident: %v", err)
+			return nil, fmt.Errorf("failed to create incident: %w", err)
+		}
+
+		log.Printf("SUCCESS: Created incident %s, enrichment queued", incident.ID)
+		return []string{incident.ID}, nil
 	}
 
 	// Step 1: Resolve service and assignment BEFORE creating incident
@@ -714,33 +1057,64 @@ func (h *WebhookHandler) routeAlertToCreateIncident(integration db.Integration,
 	incident, err := h.createIncidentAtomic(integration, alert, serviceInfo, assigneeInfo)
 	if err != nil {
 		log.Printf("ERROR: Failed to create incident atomically: %v", err)
-		return fmt.Errorf("failed to create incident: %w", err)
+		return nil, fmt.Errorf("failed to create incident: %w", err)
 	}
 
 	log.Printf("SUCCESS: Created incident %s with ServiceID=%s, AssignedTo=%s",
 		incident.ID, incident.ServiceID, incident.AssignedTo)
 
-	return nil
+	return []string{incident.ID}, nil
+}
+
+// ProcessIncidentEnrichment resolves the service and assignee of an incident the async
+// fast path inserted, for the enrichment worker
+func (h *WebhookHandler) ProcessIncidentEnrichment(msg services.IncidentEnrichmentMessage) error {
+	var alert ProcessedAlert
+	if err := json.Unmarshal(msg.Alert, &alert); err != nil {
+		return fmt.Errorf("invalid alert in enrichment message for incident %s: %w", msg.Incident.ID, err)
+	}
+	integration, err := h.integrationService.GetIntegration(msg.IntegrationID)
+	if err != nil {
+		return fmt.Errorf("failed to get integration %s: %w", msg.IntegrationID, err)
+	}
+
+	incident := msg.Incident
+	return h.enrichIncident(integration, alert, &incident)
+}
+
+// enrichIncident completes an incident inserted by CreateIncidentAsync
+func (h *WebhookHandler) enrichIncident(integration db.Integration, alert ProcessedAlert, incident *db.Incident) error {
+	return h.incidentService.EnrichIncident(incident, func(incident *db.Incident) {
+		serviceInfo, assigneeInfo, err := h.resolveServiceAndAssignee(integration, alert)
+		if err != nil {
+			log.Printf("DEBUG: Failed to resolve service/assignee for incident %s: %v", incident.ID, err)
+		}
+		applyResolvedServiceInfo(incident, serviceInfo, assigneeInfo)
+	})
 }
 
 // Route alert: resolve existing incident based on alert fingerprint/labels
-func (h *WebhookHandler) routeAlertToResolveIncident(integration db.Integration, alert ProcessedAlert) error {
+func (h *WebhookHandler) routeAlertToResolveIncident(integration db.Integration, alert ProcessedAlert) ([]string, error) {
 	log.Printf("DEBUG: Attempting to resolve incident for alert %s", alert.AlertName)
 
+	if len(alert.Fingerprints) > 0 {
+		return h.resolveIncidentsByFingerprints(integration, alert)
+	}
+
 	// Find existing incident based on alert fingerprint or labels
 	incident, err := h.findIncidentByAlert(integration, alert)
 	if err != nil {
 		log.Printf("ERROR: Failed to find incident for resolved alert %s: %v", alert.AlertName, err)
-		return fmt.Errorf("failed to find incident: %w", err)
+		return nil, fmt.Errorf("failed to find incident: %w", err)
 	}
 
 	if incident == nil {
 		log.Printf("WARNING: No incident found for resolved alert %s, skipping resolution", alert.AlertName)
-		return nil
+		return nil, nil
 	}
 
 	// Resolve the incident using IncidentService (triggers notifications)
-	note := "Alert resolved automatically"
+	note := alertResolutionNote(alert)
 	resolution := fmt.Sprintf("Automatically resolved by %s alert resolution", alert.AlertName)
 	if alert.Description != "" {
 		resolution = fmt.Sprintf("%s: %s", resolution, alert.Description)
@@ -748,14 +1122,66 @@ func (h *WebhookHandler) routeAlertToResolveIncident(integration db.Integration,
 
 	// Use appropriate system user based on integration type
 	systemUserID := db.GetSystemUserBySource(integration.Type)
-	err = h.incidentService.ResolveIncident(incident.ID, systemUserID, note, resolution)
+
+	// Label changes between fire and resolve are useful diagnostics.
+	// The fingerprint label is added by us on creation, so don't report it as removed.
+	labelDiff := services.DiffLabels(incident.Labels, alert.Labels, "fingerprint")
+	if !labelDiff.IsEmpty() {
+		log.Printf("DEBUG: Resolved alert %s labels differ from firing alert: %+v", alert.AlertName, labelDiff)
+	}
+
+	err = h.incidentService.ResolveIncidentWithLabelDiff(incident.ID, systemUserID, note, resolution, labelDiff)
+	if errors.Is(err, services.ErrAlreadyResolved) {
+		// Resolve alerts are often re-sent; nothing to do
+		log.Printf("DEBUG: Incident %s already resolved, ignoring resolve for alert %s", incident.ID, alert.AlertName)
+		return nil, nil
+	}
 	if err != nil {
 		log.Printf("ERROR: Failed to resolve incident %s: %v", incident.ID, err)
-		return fmt.Errorf("failed to resolve incident: %w", err)
+		return nil, fmt.Errorf("failed to resolve incident: %w", err)
 	}
 
 	log.Printf("SUCCESS: Resolved incident %s for alert %s", incident.ID, alert.AlertName)
-	return nil
+	return []string{incident.ID}, nil
+}
+
+// resolveIncidentsByFingerprints resolves all open incidents matching any fingerprint of a batch resolve
+func (h *WebhookHandler) resolveIncidentsByFingerprints(integration db.Integration, alert ProcessedAlert) ([]string, error) {
+	fingerprints := alert.Fingerprints
+	if alert.Fingerprint != "" {
+		fingerprints = append([]string{alert.Fingerprint}, fingerprints...)
+	}
+
+	note := alertResolutionNote(alert)
+	resolution := fmt.Sprintf("Automatically resolved by %s batch resolution", alert.AlertName)
+	if alert.Description != "" {
+		resolution = fmt.Sprintf("%s: %s", resolution, alert.Description)
+	}
+
+	resolvedIDs, err := h.incidentService.ResolveIncidentsByFingerprints(fingerprints, h.dedupScope(integration),
+		db.GetSystemUserBySource(integration.Type), note, resolution)
+	if err != nil {
+		log.Printf("ERROR: Failed to batch resolve %d fingerprints: %v", len(fingerprints), err)
+		return nil, fmt.Errorf("failed to resolve incidents: %w", err)
+	}
+
+	log.Printf("SUCCESS: Batch resolve for alert %s closed %d incidents across %d fingerprints",
+		alert.AlertName, len(resolvedIDs), len(fingerprints))
+	return resolvedIDs, nil
+}
+
+// alertResolutionNote returns the resolution summary sent with a resolve, from the alert
+// itself or its resolution/resolution_note annotation, falling back to a generic note
+func alertResolutionNote(alert ProcessedAlert) string {
+	if note := strings.TrimSpace(alert.ResolutionNote); note != "" {
+		return note
+	}
+	for _, key := range []string{"resolution", "resolution_note"} {
+		if note, ok := alert.Annotations[key].(string); ok && strings.TrimSpace(note) != "" {
+			return strings.TrimSpace(note)
+		}
+	}
+	return "Alert resolved automatically"
 }
 
 // Find existing incident based on alert labels/fingerprint
@@ -764,7 +1190,7 @@ func (h *WebhookHandler) findIncidentByAlert(integration db.Integration, alert P
 
 	// Strategy 1: Find by alert fingerprint (if available)
 	if alert.Fingerprint != "" {
-		incident, err := h.findIncidentByFingerprint(alert.Fingerprint)
+		incident, err := h.findIncidentByFingerprint(integration, alert.Fingerprint)
 		if err == nil && incident != nil {
 			log.Printf("DEBUG: Found incident %s by fingerprint %s", incident.ID, alert.Fingerprint)
 			return incident, nil
@@ -799,11 +1225,11 @@ func (h *WebhookHandler) findIncidentByAlert(integration db.Integration, alert P
 }
 
 // Find incident by fingerprint
-func (h *WebhookHandler) findIncidentByFingerprint(fingerprint string) (*db.Incident, error) {
+func (h *WebhookHandler) findIncidentByFingerprint(integration db.Integration, fingerprint string) (*db.Incident, error) {
 	log.Printf("DEBUG: Searching for incident with fingerprint: %s", fingerprint)
 
 	// Use direct database query for fingerprint search (more efficient)
-	incident, err := h.findIncidentByFingerprintDirect(fingerprint)
+	incident, err := h.findIncidentByFingerprintDirect(fingerprint, h.dedupScope(integration))
 	if err != nil {
 		log.Printf("ERROR: Failed to search incident by fingerprint: %v", err)
 		return nil, err
@@ -819,10 +1245,19 @@ func (h *WebhookHandler) findIncidentByFingerprint(fingerprint string) (*db.Inci
 }
 
 // Direct database query for fingerprint search
-func (h *WebhookHandler) findIncidentByFingerprintDirect(fingerprint string) (*db.Incident, error) {
-	// Get database connection from incident service
-	// We'll need to add a method to access the database
-	return h.incidentService.FindIncidentByFingerprint(fingerprint)
+func (h *WebhookHandler) findIncidentByFingerprintDirect(fingerprint string, scope services.DedupScope) (*db.Incident, error) {
+	return h.incidentService.FindIncidentByFingerprintForIntegration(fingerprint, scope)
+}
+
+// dedupScope returns the incidents fingerprint matching is limited to: the integration's
+// organization, narrowed to the integration itself unless the org allows alerts to dedup
+// against incidents from any of its integrations
+func (h *WebhookHandler) dedupScope(integration db.Integration) services.DedupScope {
+	scope := services.DedupScope{OrganizationID: integration.OrganizationID}
+	if !h.incidentService.FeatureFlags.IsEnabled(integration.OrganizationID, services.FeatureCrossIntegrationDedup) {
+		scope.IntegrationID = integration.ID
+	}
+	return scope
 }
 
 // Find incident by alert labels
@@ -935,7 +1370,10 @@ func (h *WebhookHandler) resolveServiceAndAssignee(integration db.Integration, a
 	serviceInfo := &ResolvedServiceInfo{Found: false}
 	assigneeInfo := &ResolvedAssigneeInfo{Found: false}
 
-	// Step 1: Get services connected to this integration
+	// Step 1: Routing tables can send the alert to a group other than its service's
+	serviceInfo.Route = h.evaluateRoutingTables(integration, alert)
+
+	// Step 2: Get services connected to this integration
 	serviceIntegrations, err := h.integrationService.GetIntegrationServices(integration.ID)
 	if err != nil {
 		log.Printf("DEBUG: Error getting services for integration %s: %v", integration.ID, err)
@@ -949,75 +1387,146 @@ func (h *WebhookHandler) resolveServiceAndAssignee(integration db.Integration, a
 		return serviceInfo, assigneeInfo, nil
 	}
 
-	// Step 2: Find matching service based on routing conditions
-	for i, serviceIntegration := range serviceIntegrations {
-		log.Printf("DEBUG: Checking service integration %d: ServiceID=%s", i+1, serviceIntegration.ServiceID)
-
-		matches := h.matchesRoutingConditions(alert, serviceIntegration.RoutingConditions)
-		log.Printf("DEBUG: Routing conditions match result: %t", matches)
-
-		if matches {
-			log.Printf("DEBUG: Found matching service %s", serviceIntegration.ServiceID)
+	// Step 3: Find matching service - the alert's own service label first, then routing conditions.
+	// Each alert in a batch is routed on its own, so one webhook can open incidents on several services.
+	// When a routing rule matched, a service of its target group is preferred.
+	candidates := h.matchingServiceIntegrations(alert, serviceIntegrations)
+	for i, serviceIntegration := range candidates {
+		log.Printf("DEBUG: Checking matching service integration %d: ServiceID=%s", i+1, serviceIntegration.ServiceID)
 
-			// Get service details
-			service, err := h.serviceService.GetService(serviceIntegration.ServiceID)
-			if err != nil {
-				log.Printf("DEBUG: Failed to get service details for %s: %v", serviceIntegration.ServiceID, err)
-				continue
-			}
+		// Get service details
+		service, err := h.serviceService.GetService(serviceIntegration.ServiceID)
+		if err != nil {
+			log.Printf("DEBUG: Failed to get service details for %s: %v", serviceIntegration.ServiceID, err)
+			continue
+		}
 
+		if !serviceInfo.Found || (serviceInfo.Route != nil && service.GroupID == serviceInfo.Route.TargetGroupID) {
 			serviceInfo.Service = &service
 			serviceInfo.ServiceIntegration = &serviceIntegration
 			serviceInfo.Found = true
+		}
 
-			log.Printf("DEBUG: Service details - ID: %s, Name: %s, EscalationPolicyID: %s, GroupID: %s",
-				service.ID, service.Name, service.EscalationPolicyID, service.GroupID)
-
-			// Step 3: Resolve assignee if service has escalation policy
-			if service.EscalationPolicyID != "" && service.GroupID != "" {
-				log.Printf("DEBUG: Resolving assignee with escalation policy %s and group %s",
-					service.EscalationPolicyID, service.GroupID)
-
-				assigneeID, err := h.incidentService.GetAssigneeFromEscalationPolicy(service.EscalationPolicyID, service.GroupID)
-				if err != nil {
-					log.Printf("DEBUG: Failed to resolve assignee: %v", err)
-				} else if assigneeID != "" {
-					assigneeInfo.UserID = assigneeID
-					assigneeInfo.Found = true
-					assigneeInfo.Method = "escalation_policy"
-					log.Printf("DEBUG: Resolved assignee: %s via escalation policy", assigneeID)
-				} else {
-					log.Printf("DEBUG: No assignee found via escalation policy")
-				}
-			} else {
-				log.Printf("DEBUG: Cannot resolve assignee - missing escalation policy or group")
-			}
-
-			// Use first matching service
+		// Use first matching service, or keep looking for one in the routed group
+		if serviceInfo.Route == nil || serviceInfo.Service.GroupID == serviceInfo.Route.TargetGroupID {
 			break
 		}
 	}
 
 	if !serviceInfo.Found {
 		log.Printf("DEBUG: No matching service found for alert")
+		return serviceInfo, assigneeInfo, nil
+	}
+
+	service := serviceInfo.Service
+	groupID := service.GroupID
+	if serviceInfo.Route != nil {
+		groupID = serviceInfo.Route.TargetGroupID
+	}
+	log.Printf("DEBUG: Service details - ID: %s, Name: %s, EscalationPolicyID: %s, GroupID: %s",
+		service.ID, service.Name, service.EscalationPolicyID, groupID)
+
+	// Step 4: Resolve assignee if service has escalation policy
+	if service.EscalationPolicyID != "" && groupID != "" {
+		log.Printf("DEBUG: Resolving assignee with escalation policy %s and group %s",
+			service.EscalationPolicyID, groupID)
+
+		assigneeID, err := h.incidentService.GetAssigneeFromEscalationPolicy(service.EscalationPolicyID, groupID)
+		if err != nil {
+			log.Printf("DEBUG: Failed to resolve assignee: %v", err)
+		} else if assigneeID != "" {
+			assigneeInfo.UserID = assigneeID
+			assigneeInfo.Found = true
+			assigneeInfo.Method = "escalation_policy"
+			log.Printf("DEBUG: Resolved assignee: %s via escalation policy", assigneeID)
+		} else {
+			log.Printf("DEBUG: No assignee found via escalation policy")
+		}
+	} else {
+		log.Printf("DEBUG: Cannot resolve assignee - missing escalation policy or group")
 	}
 
 	return serviceInfo, assigneeInfo, nil
 }
 
+// evaluateRoutingTables returns the routing table rule an alert matches, or nil. Routing
+// errors only lose the override; the alert is still routed by its service.
+func (h *WebhookHandler) evaluateRoutingTables(integration db.Integration, alert ProcessedAlert) *db.RoutingResult {
+	if h.routingService == nil {
+		return nil
+	}
+
+	route, err := h.routingService.Evaluate(integration.OrganizationID, routingAttributes(integration, alert))
+	if err != nil {
+		log.Printf("WARNING: Failed to evaluate routing tables for alert %s: %v", alert.AlertName, err)
+		return nil
+	}
+	if route != nil {
+		log.Printf("DEBUG: %s, routing alert %s to group %s (%dms)",
+			route.MatchedReason, alert.AlertName, route.TargetGroupID, route.EvaluationTimeMs)
+	}
+	return route
+}
+
+// routingAttributes describes an alert for routing table rules
+func routingAttributes(integration db.Integration, alert ProcessedAlert) db.AlertAttributes {
+	startsAt := alert.StartsAt
+	attrs := db.AlertAttributes{
+		Severity: alert.Severity,
+		Source:   integration.Type,
+		Labels:   alert.Labels,
+		Metadata: map[string]interface{}{
+			"alertname":      alert.AlertName,
+			"status":         alert.Status,
+			"integration_id": integration.ID,
+		},
+		CreatedAt:   &startsAt,
+		Fingerprint: alert.Fingerprint,
+	}
+	for _, key := range []string{"env", "environment"} {
+		if env, ok := alert.Labels[key].(string); ok && env != "" {
+			attrs.Environment = env
+			break
+		}
+	}
+	return attrs
+}
+
 // createIncidentAtomic creates incident with all resolved information in a single transaction
 func (h *WebhookHandler) createIncidentAtomic(integration db.Integration, alert ProcessedAlert, serviceInfo *ResolvedServiceInfo, assigneeInfo *ResolvedAssigneeInfo) (*db.Incident, error) {
 	log.Printf("DEBUG: Creating incident atomically")
 
-	// Build incident with all resolved information
+	incident := h.buildIncidentFromAlert(integration, alert)
+	applyResolvedServiceInfo(incident, serviceInfo, assigneeInfo)
+
+	log.Printf("DEBUG: Final incident before creation - Title: %s, ServiceID: %s, AssignedTo: %s",
+		incident.Title, incident.ServiceID, incident.AssignedTo)
+
+	// Create incident atomically using the incident service
+	createdIncident, err := h.incidentService.CreateIncident(incident)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create incident: %w", err)
+	}
+
+	// Log success with all details
+	log.Printf("SUCCESS: Created incident %s - ServiceID: %s, EscalationPolicyID: %s, GroupID: %s, AssignedTo: %s",
+		createdIncident.ID, createdIncident.ServiceID, createdIncident.EscalationPolicyID,
+		createdIncident.GroupID, createdIncident.AssignedTo)
+
+	return createdIncident, nil
+}
+
+// buildIncidentFromAlert maps an alert to a new incident, without service or assignee
+func (h *WebhookHandler) buildIncidentFromAlert(integration db.Integration, alert ProcessedAlert) *db.Incident {
 	incident := &db.Incident{
-		Title:       alert.AlertName,
-		Description: alert.Description,
-		Severity:    alert.Severity,
-		Priority:    alert.Priority,
-		Status:      db.IncidentStatusTriggered,
-		Source:      "webhook",
-		Urgency:     db.IncidentUrgencyHigh, // Default to high for webhook incidents
+		Title:         alert.AlertName,
+		Description:   alert.Description,
+		Severity:      alert.Severity,
+		Priority:      alert.Priority,
+		Status:        db.IncidentStatusTriggered,
+		Source:        "webhook",
+		IntegrationID: integration.ID,
+		Urgency:       db.IncidentUrgencyHigh, // Default to high for webhook incidents
 	}
 
 	// Add alert metadata
@@ -1033,6 +1542,9 @@ func (h *WebhookHandler) createIncidentAtomic(integration db.Integration, alert
 		incident.Urgency = db.IncidentUrgencyLow
 	}
 
+	// Keep the metric snapshot so the timeline can embed it
+	incident.SnapshotURL = alert.SnapshotURL
+
 	// Add labels from alert
 	if alert.Labels != nil {
 		incident.Labels = alert.Labels
@@ -1058,8 +1570,13 @@ func (h *WebhookHandler) createIncidentAtomic(integration db.Integration, alert
 		log.Printf("DEBUG: Setting ProjectID from integration: %s", integration.ProjectID)
 	}
 
+	return incident
+}
+
+// applyResolvedServiceInfo sets the service, escalation policy, group and assignee resolved for an alert
+func applyResolvedServiceInfo(incident *db.Incident, serviceInfo *ResolvedServiceInfo, assigneeInfo *ResolvedAssigneeInfo) {
 	// Add service information if resolved
-	if serviceInfo.Found && serviceInfo.Service != nil {
+	if serviceInfo != nil && serviceInfo.Found && serviceInfo.Service != nil {
 		incident.ServiceID = serviceInfo.Service.ID
 		incident.EscalationPolicyID = serviceInfo.Service.EscalationPolicyID
 		incident.GroupID = serviceInfo.Service.GroupID
@@ -1067,80 +1584,50 @@ func (h *WebhookHandler) createIncidentAtomic(integration db.Integration, alert
 			incident.ServiceID, incident.EscalationPolicyID, incident.GroupID)
 	}
 
+	// A matched routing rule decides the group, with or without a service
+	if serviceInfo != nil && serviceInfo.Route != nil && serviceInfo.Route.TargetGroupID != "" {
+		incident.GroupID = serviceInfo.Route.TargetGroupID
+		log.Printf("DEBUG: Routing table sets GroupID: %s", incident.GroupID)
+	}
+
 	// Add assignment information if resolved
-	if assigneeInfo.Found && assigneeInfo.UserID != "" {
+	if assigneeInfo != nil && assigneeInfo.Found && assigneeInfo.UserID != "" {
 		incident.AssignedTo = assigneeInfo.UserID
+		incident.AssignmentMethod = db.AssignmentMethodEscalationPolicy
 		now := time.Now().UTC()
 		incident.AssignedAt = &now
 		log.Printf("DEBUG: Adding assignment - AssignedTo: %s, Method: %s",
 			incident.AssignedTo, assigneeInfo.Method)
 	}
-
-	log.Printf("DEBUG: Final incident before creation - Title: %s, ServiceID: %s, AssignedTo: %s",
-		incident.Title, incident.ServiceID, incident.AssignedTo)
-
-	// Create incident atomically using the incident service
-	createdIncident, err := h.incidentService.CreateIncident(incident)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create incident: %w", err)
-	}
-
-	// Log success with all details
-	log.Printf("SUCCESS: Created incident %s - ServiceID: %s, EscalationPolicyID: %s, GroupID: %s, AssignedTo: %s",
-		createdIncident.ID, createdIncident.ServiceID, createdIncident.EscalationPolicyID,
-		createdIncident.GroupID, createdIncident.AssignedTo)
-
-	return createdIncident, nil
 }
 
 // Legacy functions removed - replaced by atomic transaction approach
 
-// Check if alert matches routing conditions
-func (h *WebhookHandler) matchesRoutingConditions(alert ProcessedAlert, conditions map[string]interface{}) bool {
-	if len(conditions) == 0 {
-		return true // No conditions = match all
-	}
-
-	// Check severity condition
-	if severities, ok := conditions["severity"].([]interface{}); ok {
-		matched := false
-		for _, sev := range severities {
-			if sevStr, ok := sev.(string); ok && sevStr == alert.Severity {
-				matched = true
-				break
+// matchingServiceIntegrations returns the service integrations an alert should be routed to,
+// in preference order. An alert carrying a "service" label that names (or is the ID of) a
+// connected service goes to that service; otherwise routing conditions decide.
+func (h *WebhookHandler) matchingServiceIntegrations(alert ProcessedAlert, serviceIntegrations []db.ServiceIntegration) []db.ServiceIntegration {
+	if serviceLabel, ok := alert.Labels["service"].(string); ok && serviceLabel != "" {
+		var labeled []db.ServiceIntegration
+		for _, si := range serviceIntegrations {
+			if si.ServiceID == serviceLabel || strings.EqualFold(si.ServiceName, serviceLabel) {
+				labeled = append(labeled, si)
 			}
 		}
-		if !matched {
-			return false
+		if len(labeled) > 0 {
+			log.Printf("DEBUG: Routing alert %s by service label %q", alert.AlertName, serviceLabel)
+			return labeled
 		}
+		log.Printf("DEBUG: Service label %q does not match any connected service, using routing conditions", serviceLabel)
 	}
 
-	// Check alertname condition
-	if alertnames, ok := conditions["alertname"].([]interface{}); ok {
-		matched := false
-		for _, name := range alertnames {
-			if nameStr, ok := name.(string); ok {
-				if nameStr == "*" || nameStr == alert.AlertName {
-					matched = true
-					break
-				}
-			}
-		}
-		if !matched {
-			return false
+	var matched []db.ServiceIntegration
+	for _, si := range serviceIntegrations {
+		if h.matchesRoutingConditions(alert, si.RoutingConditions) {
+			matched = append(matched, si)
 		}
 	}
-
-	// Check label conditions
-	if labelConditions, ok := conditions["labels"].(map[string]interface{}); ok {
-		for key, expectedValue := range labelConditions {
-			if actualValue, exists := alert.Labels[key]; !exists || actualValue != expectedValue {
-				return false
-			}
-		}
-	}
-
-	return true
+	return matched
 }
 
 // Utility functions
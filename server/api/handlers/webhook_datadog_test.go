@@ -371,3 +371,67 @@ func TestGetStringFromMapNested(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessDatadogWebhookSnapshot(t *testing.T) {
+	handler := &WebhookHandler{}
+
+	payload := map[string]interface{}{
+		"id":             "8306077573749414142",
+		"title":          "[P1] [Triggered] High tracking",
+		"transition":     "Triggered",
+		"alert_priority": "P1",
+		"snapshot":       "https://p.datadoghq.com/snapshot/view/dd-snapshots-prod/org_1/2025-10-01/abc.png",
+	}
+
+	alerts := handler.processDatadogWebhook(payload)
+	if len(alerts) != 1 {
+		t.Fatalf("Expected 1 alert, got %d", len(alerts))
+	}
+	if alerts[0].SnapshotURL != payload["snapshot"] {
+		t.Errorf("SnapshotURL = %v, want %v", alerts[0].SnapshotURL, payload["snapshot"])
+	}
+}
+
+func TestProcessDatadogWebhookBatch(t *testing.T) {
+	handler := &WebhookHandler{}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+		"events": [
+			{"id": "1001", "title": "[P1] [Triggered] CPU on web-1", "transition": "Triggered", "alert_priority": "P1", "aggregate": "agg-web-1"},
+			{"id": "1002", "title": "[P2] [Triggered] CPU on web-2", "transition": "Triggered", "alert_priority": "P2", "aggregate": "agg-web-2"},
+			{"id": "1003", "title": "[P3] [Triggered] Disk on db-1", "transition": "Triggered", "alert_priority": "P3"},
+			"not an event"
+		]
+	}`), &payload); err != nil {
+		t.Fatalf("Failed to unmarshal payload: %v", err)
+	}
+
+	alerts := handler.processDatadogWebhook(payload)
+	if len(alerts) != 3 {
+		t.Fatalf("Expected 3 alerts, got %d", len(alerts))
+	}
+
+	expected := []struct {
+		name        string
+		fingerprint string
+	}{
+		{"[P1] [Triggered] CPU on web-1", "agg-web-1"},
+		{"[P2] [Triggered] CPU on web-2", "agg-web-2"},
+		{"[P3] [Triggered] Disk on db-1", "datadog-1003"},
+	}
+	for i, want := range expected {
+		if alerts[i].AlertName != want.name {
+			t.Errorf("alerts[%d].AlertName = %q, want %q", i, alerts[i].AlertName, want.name)
+		}
+		if alerts[i].Fingerprint != want.fingerprint {
+			t.Errorf("alerts[%d].Fingerprint = %q, want %q", i, alerts[i].Fingerprint, want.fingerprint)
+		}
+	}
+
+	// A single event still yields one alert
+	single := handler.processDatadogWebhook(map[string]interface{}{"id": "1004", "title": "Latency", "transition": "Triggered"})
+	if len(single) != 1 {
+		t.Fatalf("Expected 1 alert, got %d", len(single))
+	}
+}
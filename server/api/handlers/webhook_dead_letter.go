@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phonginreallife/inres/db"
+	"github.com/phonginreallife/inres/services"
+)
+
+// alertRouteAttempts is how many times an alert is routed before it is dead-lettered
+const alertRouteAttempts = 3
+
+// alertRouteBackoff is the wait before the second attempt, doubled for each one after
+var alertRouteBackoff = 500 * time.Millisecond
+
+// routeAlertWithRetry routes an alert, retrying with backoff so a database blip doesn't drop it
+func (h *WebhookHandler) routeAlertWithRetry(integration db.Integration, alert ProcessedAlert) ([]string, error) {
+	var err error
+	for attempt := 1; attempt <= alertRouteAttempts; attempt++ {
+		var incidentIDs []string
+		if incidentIDs, err = h.routeAlert(integration, alert); err == nil {
+			return incidentIDs, nil
+		}
+		if attempt < alertRouteAttempts {
+			backoff := alertRouteBackoff << (attempt - 1)
+			log.Printf("WARNING: Routing alert %s failed (attempt %d), retrying in %s: %v", alert.AlertName, attempt, backoff, err)
+			time.Sleep(backoff)
+		}
+	}
+	return nil, err
+}
+
+// deadLetterAlert keeps an alert that could not be routed for inspection and replay
+func (h *WebhookHandler) deadLetterAlert(integration db.Integration, alert ProcessedAlert, routeErr error) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+	id, err := h.integrationService.AddDeadLetter(integration.ID, payload, routeErr, alertRouteAttempts)
+	if err != nil {
+		return err
+	}
+	log.Printf("Dead-lettered alert %s for integration %s as %s", alert.AlertName, integration.ID, id)
+	return nil
+}
+
+// ListDeadLetters lists the alerts of an integration that could not be routed
+// GET /api/integrations/:id/dead-letters
+func (h *WebhookHandler) ListDeadLetters(c *gin.Context) {
+	integration, ok := callerIntegration(c, h.authorizer, h.integrationService, c.Param("id"))
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	deadLetters, err := h.integrationService.ListDeadLetters(integration.ID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list dead letters", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dead_letters": deadLetters,
+		"count":        len(deadLetters),
+	})
+}
+
+// ReplayDeadLetter routes a dead-lettered alert again. Fingerprint dedup applies as for a
+// new alert, so an incident opened since the failure only has its alert count bumped.
+// POST /api/integrations/dead-letters/:dead_letter_id/replay
+func (h *WebhookHandler) ReplayDeadLetter(c *gin.Context) {
+	orgID, ok := verifiedOrgID(c, h.authorizer)
+	if !ok {
+		return
+	}
+
+	deadLetter, err := h.integrationService.ReplayDeadLetter(c.Param("dead_letter_id"), orgID, h.replayDeadLetter)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDeadLetterNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrDeadLetterReplayed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to replay dead letter", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Dead letter replayed",
+		"dead_letter": deadLetter,
+	})
+}
+
+// replayDeadLetter routes the alert stored in a dead letter
+func (h *WebhookHandler) replayDeadLetter(deadLetter db.WebhookDeadLetter) error {
+	var alert ProcessedAlert
+	if err := json.Unmarshal(deadLetter.Payload, &alert); err != nil {
+		return fmt.Errorf("invalid dead letter payload: %w", err)
+	}
+
+	integration, err := h.integrationService.GetIntegration(deadLetter.IntegrationID)
+	if err != nil {
+		return fmt.Errorf("failed to get integration %s: %w", deadLetter.IntegrationID, err)
+	}
+	if !integration.IsActive {
+		return fmt.Errorf("integration %s is inactive", deadLetter.IntegrationID)
+	}
+
+	_, err = h.routeAlert(integration, alert)
+	return err
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/phonginreallife/inres/authz"
+	"github.com/phonginreallife/inres/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// deadLetterAlertArg matches a dead-lettered alert payload by alert name
+type deadLetterAlertArg string
+
+func (a deadLetterAlertArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
+	var alert ProcessedAlert
+	return json.Unmarshal([]byte(s), &alert) == nil && alert.AlertName == string(a)
+}
+
+func TestProcessQueuedWebhook_DeadLettersAfterRetries(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	defer func(backoff time.Duration) { alertRouteBackoff = backoff }(alertRouteBackoff)
+	alertRouteBackoff = 0
+
+	expectGetIntegration(mockDB, "int-1", "webhook", true, `{}`)
+	for i := 0; i < alertRouteAttempts; i++ {
+		mockDB.ExpectBegin().WillReturnError(errors.New("connection reset by peer"))
+	}
+	mockDB.ExpectQuery(`INSERT INTO webhook_dead_letters`).
+		WithArgs("int-1", deadLetterAlertArg("Database outage"), sqlmock.AnyArg(), alertRouteAttempts).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("dl-1"))
+
+	handler := NewWebhookHandler(services.NewIntegrationService(pg), nil, services.NewIncidentService(pg, nil, nil), nil)
+	err = handler.ProcessQueuedWebhook(services.WebhookQueueMessage{
+		IntegrationID:   "int-1",
+		IntegrationType: "webhook",
+		Payload:         json.RawMessage(`{"alert_name":"Database outage","status":"resolved","fingerprints":["fp-api"]}`),
+	})
+
+	// The alert is kept as a dead letter, so the queued webhook isn't retried
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestReplayDeadLetter_HonorsFingerprintDedup(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	now := time.Now()
+	payload := `{"alert_name":"Disk full","status":"firing","fingerprint":"fp-disk"}`
+	mockDB.ExpectQuery(`UPDATE webhook_dead_letters SET replayed_at = NOW\(\)`).
+		WithArgs("dl-1", "org-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "integration_id", "payload", "error", "attempts", "created_at", "replayed_at",
+		}).AddRow("dl-1", "int-1", []byte(payload), "connection reset by peer", 4, now, now))
+	expectGetIntegration(mockDB, "int-1", "webhook", true, `{}`)
+	// An incident opened for the fingerprint since the failure only counts the alert; the
+	// match never leaves the integration's organization
+	mockDB.ExpectQuery(`WHERE labels->>'fingerprint' = \$1\s+AND status IN .*\s+AND COALESCE\(organization_id::text, ''\) = \$2`).
+		WithArgs("fp-disk", "org-1", "").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "title", "description", "status", "urgency", "priority",
+			"created_at", "updated_at", "assigned_to", "assigned_at",
+			"acknowledged_by", "acknowledged_at", "resolved_by", "resolved_at",
+			"source", "integration_id", "service_id", "external_id", "external_url",
+			"escalation_policy_id", "current_escalation_level", "last_escalated_at",
+			"escalation_status", "group_id", "api_key_id", "severity", "incident_key",
+			"alert_count", "labels", "custom_fields",
+		}).AddRow(
+			"inc-1", "Disk full", "", "triggered", "high", "P2",
+			now, now, nil, nil,
+			nil, nil, nil, nil,
+			"webhook", "int-1", nil, nil, nil,
+			nil, 1, nil,
+			"none", nil, nil, "warning", nil,
+			1, `{"fingerprint":"fp-disk"}`, nil,
+		))
+	mockDB.ExpectExec(`SET alert_count = alert_count \+ 1`).
+		WithArgs("inc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mockAuthorizer := new(MockAuthorizer)
+	mockAuthorizer.On("Check", mock.Anything, "user-1", authz.ActionView, authz.ResourceOrg, "org-1").Return(true)
+	handler := NewWebhookHandler(services.NewIntegrationService(pg), nil, services.NewIncidentService(pg, nil, nil), nil)
+	handler.authorizer = mockAuthorizer
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user_id", "user-1")
+	c.Request, _ = http.NewRequest("POST", "/integrations/dead-letters/dl-1/replay?org_id=org-1", nil)
+	c.Params = gin.Params{{Key: "dead_letter_id", Value: "dl-1"}}
+
+	handler.ReplayDeadLetter(c)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestListDeadLetters_OtherOrganization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// The caller belongs to org-2; int-1 belongs to org-1
+	mockAuthorizer := new(MockAuthorizer)
+	mockAuthorizer.On("Check", mock.Anything, "user-1", authz.ActionView, authz.ResourceOrg, "org-2").Return(true)
+	expectGetIntegration(mockDB, "int-1", "webhook", true, `{}`)
+
+	handler := NewWebhookHandler(services.NewIntegrationService(pg), nil, nil, nil)
+	handler.authorizer = mockAuthorizer
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user_id", "user-1")
+	c.Params = gin.Params{{Key: "id", Value: "int-1"}}
+	c.Request, _ = http.NewRequest("GET", "/integrations/int-1/dead-letters?org_id=org-2", nil)
+
+	handler.ListDeadLetters(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockAuthorizer.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet(), "no dead letters should be read")
+}
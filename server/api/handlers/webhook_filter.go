@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"log"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// alertNameAllowed applies the integration's alert_allowlist and alert_denylist config to an alertname.
+// Patterns are globs ("disk-*") or regular expressions wrapped in slashes ("/^kube.*Restart/").
+// The denylist wins; a non-empty allowlist drops every alert it doesn't match.
+func alertNameAllowed(integration db.Integration, alertName string) bool {
+	if matchesAnyAlertPattern(configPatterns(integration.Config, "alert_denylist"), alertName) {
+		return false
+	}
+	allowlist := configPatterns(integration.Config, "alert_allowlist")
+	return len(allowlist) == 0 || matchesAnyAlertPattern(allowlist, alertName)
+}
+
+// configPatterns reads a list of patterns from integration config
+func configPatterns(config map[string]interface{}, key string) []string {
+	var patterns []string
+	switch v := config[key].(type) {
+	case []interface{}:
+		for _, item := range v {
+			if pattern, ok := item.(string); ok && strings.TrimSpace(pattern) != "" {
+				patterns = append(patterns, strings.TrimSpace(pattern))
+			}
+		}
+	case []string:
+		for _, pattern := range v {
+			if strings.TrimSpace(pattern) != "" {
+				patterns = append(patterns, strings.TrimSpace(pattern))
+			}
+		}
+	}
+	return patterns
+}
+
+func matchesAnyAlertPattern(patterns []string, alertName string) bool {
+	for _, pattern := range patterns {
+		if matchAlertPattern(pattern, alertName) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAlertPattern(pattern, alertName string) bool {
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			log.Printf("WARNING: Invalid alertname pattern %q: %v", pattern, err)
+			return false
+		}
+		return re.MatchString(alertName)
+	}
+
+	matched, err := path.Match(pattern, alertName)
+	if err != nil {
+		log.Printf("WARNING: Invalid alertname pattern %q: %v", pattern, err)
+		return false
+	}
+	return matched
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/phonginreallife/inres/internal/config"
+	"github.com/phonginreallife/inres/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteAlert_AlertNameFilters(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	previous := config.App.DedupWindowMinutes
+	config.App.DedupWindowMinutes = 10
+	defer func() { config.App.DedupWindowMinutes = previous }()
+
+	integration := db.Integration{ID: "int-1", Type: "prometheus", Config: map[string]interface{}{
+		"alert_allowlist": []interface{}{"Kube*", "/^Disk(Full|Slow)$/"},
+		"alert_denylist":  []interface{}{"KubeJobFailed"},
+	}}
+	handler := &WebhookHandler{incidentService: services.NewIncidentService(pg, nil, nil)}
+
+	// Denylisted and non-allowlisted alerts are dropped without touching the database
+	for _, name := range []string{"KubeJobFailed", "Watchdog", "DiskFullish"} {
+		_, err := handler.routeAlert(integration, ProcessedAlert{AlertName: name, Status: "firing", Fingerprint: "fp-" + name})
+		assert.NoError(t, err, name)
+	}
+
+	// An allowlisted alert proceeds to incident creation (here re-opening a flapping incident)
+	mockDB.ExpectQuery("WHERE labels->>'fingerprint' = \\$1").
+		WithArgs("fp-disk", "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mockDB.ExpectQuery("UPDATE incidents").
+		WithArgs(db.IncidentStatusTriggered, "fp-disk", db.IncidentStatusResolved, float64(600), "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "assigned_to", "alert_count"}).AddRow("inc-1", nil, 2))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "reopened", sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = handler.routeAlert(integration, ProcessedAlert{AlertName: "DiskFull", Status: "firing", Fingerprint: "fp-disk"})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestAlertNameAllowed(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		alert  string
+		want   bool
+	}{
+		{"NoLists", nil, "Anything", true},
+		{"DenyGlob", map[string]interface{}{"alert_denylist": []interface{}{"Watchdog*"}}, "WatchdogHeartbeat", false},
+		{"DenyRegex", map[string]interface{}{"alert_denylist": []interface{}{"/^test-/"}}, "test-alert", false},
+		{"NotDenied", map[string]interface{}{"alert_denylist": []interface{}{"Watchdog"}}, "HighLatency", true},
+		{"Allowed", map[string]interface{}{"alert_allowlist": []interface{}{"High*"}}, "HighLatency", true},
+		{"NotAllowed", map[string]interface{}{"alert_allowlist": []interface{}{"High*"}}, "LowLatency", false},
+		{"DenyWins", map[string]interface{}{"alert_allowlist": []interface{}{"*"}, "alert_denylist": []interface{}{"Noisy"}}, "Noisy", false},
+		{"InvalidPattern", map[string]interface{}{"alert_denylist": []interface{}{"/(/", "["}}, "(", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, alertNameAllowed(db.Integration{Config: tt.config}, tt.alert))
+		})
+	}
+}
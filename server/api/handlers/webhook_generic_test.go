@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/phonginreallife/inres/db"
+	"github.com/phonginreallife/inres/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenericWebhookBatchResolve(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	payload := `{
+		"alert_name": "Database outage",
+		"status": "resolved",
+		"fingerprints": ["fp-api", "fp-worker", "fp-cron"]
+	}`
+	var payloadMap map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &payloadMap); err != nil {
+		t.Fatalf("Failed to unmarshal payload: %v", err)
+	}
+
+	expectBatchResolve(mockDB, []string{"fp-api", "fp-worker", "fp-cron"}, "", db.SystemUserWebhook, "inc-api", "inc-worker")
+
+	handler := &WebhookHandler{incidentService: services.NewIncidentService(pg, nil, nil)}
+
+	alerts := handler.processGenericWebhook(payloadMap)
+	if len(alerts) != 1 {
+		t.Fatalf("Expected 1 alert, got %d", len(alerts))
+	}
+	assert.Equal(t, []string{"fp-api", "fp-worker", "fp-cron"}, alerts[0].Fingerprints)
+
+	_, err = handler.routeAlert(db.Integration{ID: "int-1", Type: "webhook"}, alerts[0])
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+// expectBatchResolve expects a multi-fingerprint resolve within orgID that resolves ids,
+// each the way a single resolve does (escalations stopped, event recorded)
+func expectBatchResolve(mockDB sqlmock.Sqlmock, fingerprints []string, orgID, userID string, ids ...string) {
+	fingerprintsArg, _ := pq.Array(fingerprints).Value()
+	rows := sqlmock.NewRows([]string{"id"})
+	for _, id := range ids {
+		rows.AddRow(id)
+	}
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery(`SELECT id FROM incidents\s+WHERE labels->>'fingerprint' = ANY\(\$1\).*AND COALESCE\(organization_id::text, ''\) = \$2.*FOR UPDATE`).
+		WithArgs(fingerprintsArg, orgID, "").
+		WillReturnRows(rows)
+	for _, id := range ids {
+		mockDB.ExpectExec(`UPDATE incidents\s+SET status = \$1, resolved_by = \$2::uuid`).
+			WithArgs(db.IncidentStatusResolved, userID, id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mockDB.ExpectExec(`UPDATE alert_escalations`).
+			WithArgs(id, db.AlertEscalationStatusStopped, userID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mockDB.ExpectExec(`UPDATE scheduled_escalations`).
+			WithArgs(id).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mockDB.ExpectExec(`UPDATE group_escalation_members`).
+			WithArgs(id).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mockDB.ExpectExec("INSERT INTO incident_events").
+			WithArgs(id, "resolved", sqlmock.AnyArg(), userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mockDB.ExpectCommit()
+}
+
+func TestGenericWebhookResolveWithNote(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	payload := `{
+		"alert_name": "Disk full",
+		"status": "resolved",
+		"fingerprint": "fp-disk",
+		"labels": {"fingerprint": "fp-disk", "host": "db-1"},
+		"resolution_note": "Rotated logs and grew the volume to 200GB"
+	}`
+	var payloadMap map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &payloadMap); err != nil {
+		t.Fatalf("Failed to unmarshal payload: %v", err)
+	}
+
+	now := time.Now()
+	mockDB.ExpectQuery(`WHERE labels->>'fingerprint' = \$1`).
+		WithArgs("fp-disk", "", "").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "title", "description", "status", "urgency", "priority",
+			"created_at", "updated_at", "assigned_to", "assigned_at",
+			"acknowledged_by", "acknowledged_at", "resolved_by", "resolved_at",
+			"source", "integration_id", "service_id", "external_id", "external_url",
+			"escalation_policy_id", "current_escalation_level", "last_escalated_at",
+			"escalation_status", "group_id", "api_key_id", "severity", "incident_key",
+			"alert_count", "labels", "custom_fields",
+		}).AddRow(
+			"inc-1", "Disk full", "", "triggered", "high", "P2",
+			now, now, nil, nil,
+			nil, nil, nil, nil,
+			"webhook", nil, nil, nil, nil,
+			nil, 1, nil,
+			"none", nil, nil, "warning", nil,
+			1, `{"fingerprint":"fp-disk","host":"db-1"}`, nil,
+		))
+	mockDB.ExpectExec("UPDATE incidents").
+		WithArgs(db.IncidentStatusResolved, db.SystemUserWebhook, "inc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "resolved",
+			`{"note":"Rotated logs and grew the volume to 200GB","resolution":"Automatically resolved by Disk full alert resolution"}`,
+			db.SystemUserWebhook).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	handler := &WebhookHandler{incidentService: services.NewIncidentService(pg, nil, nil)}
+
+	alerts := handler.processGenericWebhook(payloadMap)
+	_, err = handler.routeAlert(db.Integration{ID: "int-1", Type: "webhook"}, alerts[0])
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestAlertResolutionNote(t *testing.T) {
+	// Prometheus-style annotation
+	alert := ProcessedAlert{Annotations: map[string]interface{}{"resolution": " Restarted the pod "}}
+	assert.Equal(t, "Restarted the pod", alertResolutionNote(alert))
+
+	alert.ResolutionNote = "Rolled back deploy 4812"
+	assert.Equal(t, "Rolled back deploy 4812", alertResolutionNote(alert))
+
+	// Nothing provided: generic note
+	assert.Equal(t, "Alert resolved automatically", alertResolutionNote(ProcessedAlert{}))
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+func TestProcessGrafanaWebhookSnapshotURL(t *testing.T) {
+	handler := &WebhookHandler{}
+
+	tests := []struct {
+		name     string
+		payload  string
+		expected string
+	}{
+		{
+			name: "Legacy alert with top-level imageUrl",
+			payload: `{
+				"title": "[Alerting] CPU usage",
+				"ruleName": "CPU usage",
+				"ruleUrl": "https://grafana.example.com/d/abc/cpu",
+				"state": "alerting",
+				"message": "CPU above 90%",
+				"imageUrl": "https://grafana.example.com/render/cpu.png"
+			}`,
+			expected: "https://grafana.example.com/render/cpu.png",
+		},
+		{
+			name: "Unified alerting with per-alert imageURL",
+			payload: `{
+				"status": "firing",
+				"state": "alerting",
+				"ruleName": "Memory usage",
+				"alerts": [
+					{"status": "firing", "labels": {"alertname": "Memory usage"}, "imageURL": "https://grafana.example.com/render/mem.png"}
+				]
+			}`,
+			expected: "https://grafana.example.com/render/mem.png",
+		},
+		{
+			name: "Graph URL in common annotations",
+			payload: `{
+				"state": "alerting",
+				"ruleName": "Disk usage",
+				"commonAnnotations": {"graph_url": "https://grafana.example.com/render/disk.png"}
+			}`,
+			expected: "https://grafana.example.com/render/disk.png",
+		},
+		{
+			name:     "No image",
+			payload:  `{"state": "alerting", "ruleName": "Latency"}`,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.payload), &payload); err != nil {
+				t.Fatalf("Failed to unmarshal payload: %v", err)
+			}
+
+			alerts := handler.processGrafanaWebhook(payload)
+			if len(alerts) != 1 {
+				t.Fatalf("Expected 1 alert, got %d", len(alerts))
+			}
+			if alerts[0].SnapshotURL != tt.expected {
+				t.Errorf("SnapshotURL = %q, want %q", alerts[0].SnapshotURL, tt.expected)
+			}
+
+			incident := handler.buildIncidentFromAlert(db.Integration{ID: "int-1"}, alerts[0])
+			if incident.SnapshotURL != tt.expected {
+				t.Errorf("incident.SnapshotURL = %q, want %q", incident.SnapshotURL, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessGrafanaUnifiedWebhook(t *testing.T) {
+	handler := &WebhookHandler{}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+		"receiver": "inres",
+		"status": "firing",
+		"orgId": 1,
+		"commonLabels": {"team": "platform"},
+		"alerts": [
+			{
+				"status": "firing",
+				"labels": {"alertname": "HighCPU", "instance": "web-1", "severity": "critical", "team": "platform"},
+				"annotations": {"summary": "CPU above 90% on web-1"},
+				"fingerprint": "a1b2c3",
+				"startsAt": "2026-10-17T09:00:00Z",
+				"endsAt": "0001-01-01T00:00:00Z",
+				"imageURL": "https://grafana.example.com/render/cpu-web-1.png"
+			},
+			{
+				"status": "resolved",
+				"labels": {"alertname": "HighCPU", "instance": "web-2", "team": "platform"},
+				"annotations": {"summary": "CPU above 90% on web-2"},
+				"startsAt": "2026-10-17T08:00:00Z",
+				"endsAt": "2026-10-17T09:00:00Z"
+			},
+			{
+				"status": "firing",
+				"labels": {"alertname": "HighCPU", "instance": "web-3", "team": "platform", "fingerprint": "cpu-web-3"},
+				"fingerprint": "d4e5f6",
+				"startsAt": "2026-10-17T09:00:00Z"
+			}
+		]
+	}`), &payload); err != nil {
+		t.Fatalf("Failed to unmarshal payload: %v", err)
+	}
+
+	alerts := handler.processGrafanaWebhook(payload)
+	if len(alerts) != 3 {
+		t.Fatalf("Expected 3 alerts, got %d", len(alerts))
+	}
+
+	first, second, third := alerts[0], alerts[1], alerts[2]
+	if first.Fingerprint != "a1b2c3" {
+		t.Errorf("first.Fingerprint = %q, want %q", first.Fingerprint, "a1b2c3")
+	}
+	if first.AlertName != "HighCPU" || first.Severity != "critical" || first.Status != "firing" || first.Summary != "CPU above 90% on web-1" {
+		t.Errorf("first = %+v", first)
+	}
+	if first.Labels["source"] != "grafana" || first.Labels["instance"] != "web-1" {
+		t.Errorf("first.Labels = %v", first.Labels)
+	}
+	if first.SnapshotURL != "https://grafana.example.com/render/cpu-web-1.png" {
+		t.Errorf("first.SnapshotURL = %q", first.SnapshotURL)
+	}
+
+	// Without a fingerprint, the Prometheus path derives one from the labels
+	if second.Fingerprint != "HighCPU-web-2-" {
+		t.Errorf("second.Fingerprint = %q", second.Fingerprint)
+	}
+	if second.Status != "resolved" || second.EndsAt == nil {
+		t.Errorf("second = %+v", second)
+	}
+
+	// Grafana's fingerprint label wins over the alert fingerprint
+	if third.Fingerprint != "cpu-web-3" {
+		t.Errorf("third.Fingerprint = %q, want %q", third.Fingerprint, "cpu-web-3")
+	}
+}
+
+func TestIsAlertmanagerPayload(t *testing.T) {
+	tests := map[string]bool{
+		`{"alerts": [{"status": "firing", "labels": {"alertname": "HighCPU"}}]}`: true,
+		`{"ruleName": "CPU", "state": "alerting"}`:                               false,
+		`{"alerts": []}`:          false,
+		`{"alerts": ["HighCPU"]}`: false,
+	}
+	for raw, expected := range tests {
+		var payload map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+			t.Fatalf("Failed to unmarshal payload: %v", err)
+		}
+		if got := isAlertmanagerPayload(payload); got != expected {
+			t.Errorf("isAlertmanagerPayload(%s) = %v, want %v", raw, got, expected)
+		}
+	}
+}
+
+func TestProcessGrafanaWebhookEvalMatches(t *testing.T) {
+	handler := &WebhookHandler{}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+		"ruleId": 7,
+		"ruleName": "Disk usage",
+		"state": "alerting",
+		"evalMatches": [
+			{"value": 91, "metric": "disk.used", "tags": {"host": "db-1"}},
+			{"value": 95, "metric": "disk.used", "tags": {"host": "db-2"}}
+		]
+	}`), &payload); err != nil {
+		t.Fatalf("Failed to unmarshal payload: %v", err)
+	}
+
+	alerts := handler.processGrafanaWebhook(payload)
+	if len(alerts) != 2 {
+		t.Fatalf("Expected 2 alerts, got %d", len(alerts))
+	}
+	if alerts[0].Fingerprint == alerts[1].Fingerprint {
+		t.Errorf("Expected distinct fingerprints, both %q", alerts[0].Fingerprint)
+	}
+	if alerts[0].Fingerprint != "grafana-metric=disk.used,rule_id=7,tag_host=db-1" {
+		t.Errorf("alerts[0].Fingerprint = %q", alerts[0].Fingerprint)
+	}
+	if alerts[1].AlertName != "Disk usage" || alerts[1].Labels["host"] != "db-2" {
+		t.Errorf("alerts[1] = %+v", alerts[1])
+	}
+
+	// A single match keeps the one-alert behavior
+	payload["evalMatches"] = payload["evalMatches"].([]interface{})[:1]
+	if alerts := handler.processGrafanaWebhook(payload); len(alerts) != 1 {
+		t.Errorf("Expected 1 alert, got %d", len(alerts))
+	}
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"encoding/json"
 	"testing"
+
+	"github.com/phonginreallife/inres/db"
 )
 
 func TestProcessPrometheusWebhook(t *testing.T) {
@@ -275,3 +277,69 @@ func TestProcessPrometheusWebhook(t *testing.T) {
 		})
 	}
 }
+
+func TestPrometheusBatchRoutesAlertsPerService(t *testing.T) {
+	handler := &WebhookHandler{}
+
+	payload := `{
+		"receiver": "inres-webhook",
+		"status": "firing",
+		"alerts": [
+			{
+				"status": "firing",
+				"labels": {"alertname": "HighLatency", "instance": "api-1", "job": "api", "service": "checkout"},
+				"annotations": {"summary": "checkout latency"},
+				"startsAt": "2024-01-15T10:30:00Z",
+				"fingerprint": "fp-checkout"
+			},
+			{
+				"status": "firing",
+				"labels": {"alertname": "HighLatency", "instance": "db-1", "job": "db", "service": "svc-payments"},
+				"annotations": {"summary": "payments latency"},
+				"startsAt": "2024-01-15T10:30:00Z",
+				"fingerprint": "fp-payments"
+			},
+			{
+				"status": "firing",
+				"labels": {"alertname": "DiskFull", "instance": "misc-1", "job": "node", "service": "unknown"},
+				"annotations": {"summary": "disk full"},
+				"startsAt": "2024-01-15T10:30:00Z",
+				"fingerprint": "fp-unknown"
+			}
+		]
+	}`
+
+	var payloadMap map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &payloadMap); err != nil {
+		t.Fatalf("Failed to unmarshal payload: %v", err)
+	}
+
+	serviceIntegrations := []db.ServiceIntegration{
+		{ID: "si-1", ServiceID: "svc-catchall", ServiceName: "Catch-all", RoutingConditions: map[string]interface{}{}},
+		{ID: "si-2", ServiceID: "svc-checkout", ServiceName: "Checkout", RoutingConditions: map[string]interface{}{
+			"alertname": []interface{}{"NeverMatches"},
+		}},
+		{ID: "si-3", ServiceID: "svc-payments", ServiceName: "Payments", RoutingConditions: map[string]interface{}{}},
+	}
+
+	alerts := handler.processPrometheusWebhook(payloadMap)
+	if len(alerts) != 3 {
+		t.Fatalf("Expected 3 alerts, got %d", len(alerts))
+	}
+
+	expected := map[string]string{
+		"fp-checkout": "svc-checkout", // matched by service name, routing conditions ignored
+		"fp-payments": "svc-payments", // matched by service ID
+		"fp-unknown":  "svc-catchall", // unknown label falls back to routing conditions
+	}
+	for _, alert := range alerts {
+		candidates := handler.matchingServiceIntegrations(alert, serviceIntegrations)
+		if len(candidates) == 0 {
+			t.Errorf("Alert %s: expected a matching service, got none", alert.Fingerprint)
+			continue
+		}
+		if candidates[0].ServiceID != expected[alert.Fingerprint] {
+			t.Errorf("Alert %s: expected service %s, got %s", alert.Fingerprint, expected[alert.Fingerprint], candidates[0].ServiceID)
+		}
+	}
+}
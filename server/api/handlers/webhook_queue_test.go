@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/phonginreallife/inres/db"
+	"github.com/phonginreallife/inres/services"
+	"github.com/stretchr/testify/assert"
+)
+
+// queuedWebhookArg matches the JSON of a queued webhook message
+type queuedWebhookArg struct {
+	integrationID, integrationType, payload, eventID string
+}
+
+func (a queuedWebhookArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
+	var msg services.WebhookQueueMessage
+	if err := json.Unmarshal([]byte(s), &msg); err != nil {
+		return false
+	}
+	return msg.IntegrationID == a.integrationID && msg.IntegrationType == a.integrationType &&
+		string(msg.Payload) == a.payload && msg.EventID == a.eventID && !msg.ReceivedAt.IsZero()
+}
+
+func expectGetIntegration(mockDB sqlmock.Sqlmock, id, integrationType string, active bool, config string) {
+	now := time.Now()
+	mockDB.ExpectQuery(`FROM integrations i`).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "name", "type", "description", "config", "webhook_url", "webhook_secret",
+			"is_active", "last_heartbeat", "heartbeat_interval",
+			"created_at", "updated_at", "created_by",
+			"organization_id", "project_id", "health_status", "services_count",
+			"dropped_alerts",
+		}).AddRow(
+			id, "Alerts", integrationType, "", []byte(config), nil, "",
+			active, nil, 300,
+			now, now, "",
+			"org-1", nil, "healthy", 0,
+			0,
+		))
+}
+
+func TestReceiveWebhook_QueuesPayload(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	body := `{"alert_name":"DiskFull","status":"firing"}`
+	expectGetIntegration(mockDB, "int-1", "webhook", true, `{}`)
+	mockDB.ExpectExec(`SELECT update_integration_heartbeat\(\$1\)`).
+		WithArgs("int-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The raw payload is kept for audit, and the queued message points at it
+	mockDB.ExpectQuery(`INSERT INTO webhook_events`).
+		WithArgs("int-1", "webhook", body, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("evt-1"))
+	mockDB.ExpectQuery(`SELECT pgmq\.send\(\$1, \$2::jsonb\)`).
+		WithArgs(services.WebhookQueueName, queuedWebhookArg{"int-1", "webhook", body, "evt-1"}).
+		WillReturnRows(sqlmock.NewRows([]string{"send"}).AddRow(42))
+
+	handler := NewWebhookHandler(services.NewIntegrationService(pg), nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/webhook/webhook/int-1", bytes.NewReader([]byte(body)))
+	c.Params = gin.Params{{Key: "type", Value: "webhook"}, {Key: "integration_id", Value: "int-1"}}
+
+	handler.ReceiveWebhook(c)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, float64(42), resp["message_id"])
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestReceiveWebhook_RateLimited(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	integrationService := services.NewIntegrationService(pg)
+	integrationService.RateLimiter = services.NewWebhookRateLimiter(nil, 600)
+	handler := NewWebhookHandler(integrationService, nil, nil, nil)
+
+	receive := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/webhook/webhook/int-1", bytes.NewReader([]byte(`{}`)))
+		c.Params = gin.Params{{Key: "type", Value: "webhook"}, {Key: "integration_id", Value: "int-1"}}
+		handler.ReceiveWebhook(c)
+		return w
+	}
+
+	// The only token of the bucket is used by the first webhook
+	config := `{"rate_limit_per_minute": 1}`
+	expectGetIntegration(mockDB, "int-1", "webhook", true, config)
+	mockDB.ExpectExec(`SELECT update_integration_heartbeat\(\$1\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery(`INSERT INTO webhook_events`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("evt-1"))
+	mockDB.ExpectQuery(`SELECT pgmq\.send`).
+		WillReturnRows(sqlmock.NewRows([]string{"send"}).AddRow(1))
+	assert.Equal(t, http.StatusAccepted, receive().Code)
+
+	// The second is rejected without being queued, and counted on the integration
+	expectGetIntegration(mockDB, "int-1", "webhook", true, config)
+	mockDB.ExpectExec(`UPDATE integrations SET dropped_alerts = dropped_alerts \+ 1`).
+		WithArgs("int-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.Equal(t, http.StatusTooManyRequests, receive().Code)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestReceiveWebhook_QueueUnavailable(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectGetIntegration(mockDB, "int-1", "webhook", true, `{}`)
+	mockDB.ExpectExec(`SELECT update_integration_heartbeat\(\$1\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery(`INSERT INTO webhook_events`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("evt-1"))
+	mockDB.ExpectQuery(`SELECT pgmq\.send`).
+		WillReturnError(errors.New("connection refused"))
+
+	handler := NewWebhookHandler(services.NewIntegrationService(pg), nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/webhook/webhook/int-1", bytes.NewReader([]byte(`{}`)))
+	c.Params = gin.Params{{Key: "type", Value: "webhook"}, {Key: "integration_id", Value: "int-1"}}
+
+	handler.ReceiveWebhook(c)
+
+	// The provider retries a 503, so the payload isn't lost
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestProcessQueuedWebhook(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	handler := NewWebhookHandler(services.NewIntegrationService(pg), nil, services.NewIncidentService(pg, nil, nil), nil)
+	msg := services.WebhookQueueMessage{
+		IntegrationID:   "int-1",
+		IntegrationType: "webhook",
+		Payload:         json.RawMessage(`{"alert_name":"Watchdog","status":"firing"}`),
+	}
+
+	// The integration is loaded again when the message is processed; a denylisted alert stops there
+	expectGetIntegration(mockDB, "int-1", "webhook", true, `{"alert_denylist":["Watchdog"]}`)
+	assert.NoError(t, handler.ProcessQueuedWebhook(msg))
+
+	// Messages for an integration deactivated since are dropped
+	expectGetIntegration(mockDB, "int-1", "webhook", false, `{}`)
+	assert.NoError(t, handler.ProcessQueuedWebhook(msg))
+
+	// A failed lookup is returned so the worker retries the message
+	mockDB.ExpectQuery(`FROM integrations i`).WillReturnError(errors.New("connection refused"))
+	assert.Error(t, handler.ProcessQueuedWebhook(msg))
+
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestProcessQueuedWebhook_RecordsIncidentIDs(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectGetIntegration(mockDB, "int-1", "webhook", true, `{}`)
+	expectBatchResolve(mockDB, []string{"fp-api", "fp-worker"}, "org-1", db.SystemUserWebhook, "inc-api", "inc-worker")
+	incidentIDs, _ := pq.Array([]string{"inc-api", "inc-worker"}).Value()
+	mockDB.ExpectExec(`UPDATE webhook_events SET processed_at = NOW\(\)`).
+		WithArgs("evt-1", incidentIDs, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	handler := NewWebhookHandler(services.NewIntegrationService(pg), nil, services.NewIncidentService(pg, nil, nil), nil)
+	err = handler.ProcessQueuedWebhook(services.WebhookQueueMessage{
+		IntegrationID:   "int-1",
+		IntegrationType: "webhook",
+		Payload:         json.RawMessage(`{"alert_name":"Database outage","status":"resolved","fingerprints":["fp-api","fp-worker"]}`),
+		EventID:         "evt-1",
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
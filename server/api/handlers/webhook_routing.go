@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// Routing conditions of a service integration are a tree evaluated against an alert. Every key
+// of a condition map must match:
+//
+//	{"severity": ["critical", "high"], "labels.env": "prod"}
+//	{"or": [{"alertname": {"operator": "regex", "value": "^Disk"}}, {"not": {"labels.team": "db"}}]}
+//
+// A field (severity, alertname, status, priority, summary, description, fingerprint,
+// labels.<name>, annotations.<name>) maps to a value (equals), a list (in; "*" matches any
+// value) or {"operator": ..., "value": ...} with one of the db.RoutingOperator* operators.
+// "and" and "or" take a list of condition maps and "not" one map. The simple form, with
+// "labels" mapping label names to values, still works.
+
+// matchesRoutingConditions reports whether an alert matches routing conditions
+func (h *WebhookHandler) matchesRoutingConditions(alert ProcessedAlert, conditions map[string]interface{}) bool {
+	if len(conditions) == 0 {
+		return true // No conditions = match all
+	}
+	return evaluateRoutingConditions(alert, conditions)
+}
+
+// evaluateRoutingConditions reports whether an alert matches every condition of a map
+func evaluateRoutingConditions(alert ProcessedAlert, conditions map[string]interface{}) bool {
+	for key, condition := range conditions {
+		if !evaluateRoutingCondition(alert, key, condition) {
+			return false
+		}
+	}
+	return true
+}
+
+func evaluateRoutingCondition(alert ProcessedAlert, key string, condition interface{}) bool {
+	switch key {
+	case db.RoutingLogicalAnd:
+		for _, sub := range routingConditionList(condition) {
+			if !evaluateRoutingConditions(alert, sub) {
+				return false
+			}
+		}
+		return true
+	case db.RoutingLogicalOr:
+		for _, sub := range routingConditionList(condition) {
+			if evaluateRoutingConditions(alert, sub) {
+				return true
+			}
+		}
+		return false
+	case db.RoutingLogicalNot:
+		sub, ok := condition.(map[string]interface{})
+		if !ok {
+			log.Printf("WARNING: Ignoring routing condition: \"not\" needs a condition map, got %T", condition)
+			return false
+		}
+		return !evaluateRoutingConditions(alert, sub)
+	case db.RoutingOperatorDefault:
+		return true
+	case "labels", "annotations":
+		// Simple form: {"labels": {"env": "prod"}}
+		fields, ok := condition.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for name, sub := range fields {
+			if !evaluateRoutingCondition(alert, key+"."+name, sub) {
+				return false
+			}
+		}
+		return true
+	}
+
+	actual, exists := routingField(alert, key)
+	return matchRoutingValue(actual, exists, condition)
+}
+
+// routingConditionList reads the operands of "and" and "or"
+func routingConditionList(condition interface{}) []map[string]interface{} {
+	var list []map[string]interface{}
+	switch v := condition.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if sub, ok := item.(map[string]interface{}); ok {
+				list = append(list, sub)
+			}
+		}
+	case map[string]interface{}:
+		list = append(list, v)
+	}
+	return list
+}
+
+// routingField returns the value of an alert field named in a routing condition
+func routingField(alert ProcessedAlert, key string) (interface{}, bool) {
+	switch key {
+	case "severity":
+		return alert.Severity, true
+	case "raw_severity":
+		return alert.RawSeverity, alert.RawSeverity != ""
+	case "alertname", "alert_name":
+		return alert.AlertName, true
+	case "status":
+		return alert.Status, true
+	case "priority":
+		return alert.Priority, alert.Priority != ""
+	case "summary":
+		return alert.Summary, true
+	case "description":
+		return alert.Description, true
+	case "fingerprint":
+		return alert.Fingerprint, alert.Fingerprint != ""
+	}
+
+	if name, ok := strings.CutPrefix(key, "labels."); ok {
+		value, exists := alert.Labels[name]
+		return value, exists
+	}
+	if name, ok := strings.CutPrefix(key, "annotations."); ok {
+		value, exists := alert.Annotations[name]
+		return value, exists
+	}
+	return nil, false
+}
+
+// matchRoutingValue matches a field against a value, a list or an operator condition. A field
+// the alert doesn't have only matches the negative operators.
+func matchRoutingValue(actual interface{}, exists bool, condition interface{}) bool {
+	var operator string
+	var expected interface{}
+	switch v := condition.(type) {
+	case []interface{}:
+		operator, expected = db.RoutingOperatorIn, v
+	case map[string]interface{}:
+		operator, _ = v["operator"].(string)
+		expected = v["value"]
+	default:
+		operator, expected = db.RoutingOperatorEquals, v
+	}
+
+	if !exists {
+		switch operator {
+		case db.RoutingOperatorNotEquals, db.RoutingOperatorNotIn, db.RoutingOperatorNotContains:
+			return true
+		case db.RoutingOperatorDefault:
+			return true
+		}
+		return false
+	}
+
+	actualStr := routingString(actual)
+	switch operator {
+	case db.RoutingOperatorEquals:
+		return routingValueEquals(actualStr, expected)
+	case db.RoutingOperatorNotEquals:
+		return !routingValueEquals(actualStr, expected)
+	case db.RoutingOperatorIn:
+		return routingValueIn(actualStr, expected)
+	case db.RoutingOperatorNotIn:
+		return !routingValueIn(actualStr, expected)
+	case db.RoutingOperatorContains:
+		return strings.Contains(actualStr, routingString(expected))
+	case db.RoutingOperatorNotContains:
+		return !strings.Contains(actualStr, routingString(expected))
+	case db.RoutingOperatorRegex:
+		re, err := regexp.Compile(routingString(expected))
+		if err != nil {
+			log.Printf("WARNING: Ignoring routing condition with invalid regex %q: %v", routingString(expected), err)
+			return false
+		}
+		return re.MatchString(actualStr)
+	case db.RoutingOperatorGreaterThan, db.RoutingOperatorLessThan:
+		actualNum, err1 := strconv.ParseFloat(actualStr, 64)
+		expectedNum, err2 := strconv.ParseFloat(routingString(expected), 64)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		if operator == db.RoutingOperatorGreaterThan {
+			return actualNum > expectedNum
+		}
+		return actualNum < expectedNum
+	case db.RoutingOperatorDefault:
+		return true
+	}
+
+	log.Printf("WARNING: Ignoring routing condition with unknown operator %q", operator)
+	return false
+}
+
+func routingValueEquals(actual string, expected interface{}) bool {
+	expectedStr := routingString(expected)
+	return expectedStr == "*" || actual == expectedStr
+}
+
+func routingValueIn(actual string, expected interface{}) bool {
+	list, ok := expected.([]interface{})
+	if !ok {
+		return routingValueEquals(actual, expected)
+	}
+	for _, item := range list {
+		if routingValueEquals(actual, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// routingString formats a JSON value for comparison, so 3 and 3.0 both read "3"
+func routingString(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
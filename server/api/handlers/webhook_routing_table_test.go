@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/phonginreallife/inres/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func expectRoutedService(mockDB sqlmock.Sqlmock, id, groupID, policyID string) {
+	now := time.Now()
+	var policy interface{}
+	if policyID != "" {
+		policy = policyID
+	}
+	mockDB.ExpectQuery(`FROM services s\s+LEFT JOIN groups g ON s.group_id = g.id\s+WHERE s.id = \$1`).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "group_id", "name", "description", "routing_key", "escalation_policy_id",
+			"is_active", "created_at", "updated_at", "created_by",
+			"integrations", "notification_settings", "response_sla_minutes", "resolution_sla_minutes", "group_name",
+		}).AddRow(id, groupID, id, "", "", policy, true, now, now, "", []byte(`{}`), []byte(`{}`), nil, nil, groupID))
+}
+
+func TestResolveServiceAndAssignee_RoutingTablePicksGroup(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	now := time.Now()
+	mockDB.ExpectQuery(`FROM alert_routing_tables`).
+		WithArgs("org-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "is_active", "priority", "created_at", "updated_at", "created_by"}).
+			AddRow("table-1", "Production", "", true, 100, now, now, nil))
+	mockDB.ExpectQuery(`FROM alert_routing_rules arr`).
+		WithArgs("table-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "routing_table_id", "name", "priority", "is_active",
+			"match_conditions", "target_group_id", "escalation_rule_id", "time_conditions",
+			"created_at", "updated_at", "created_by", "group_name", "escalation_rule_name",
+		}).AddRow("rule-1", "table-1", "Database alerts", 50, true, []byte(`{"labels.team": "db"}`), "group-dba", nil, nil, now, now, nil, "DBA", ""))
+	mockDB.ExpectExec(`INSERT INTO alert_route_logs`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectQuery(`FROM service_integrations si`).
+		WithArgs("int-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "service_id", "integration_id", "routing_conditions",
+			"priority", "is_active", "created_at", "updated_at", "created_by",
+			"service_name", "integration_name", "integration_type",
+		}).
+			AddRow("si-1", "svc-web", "int-1", []byte(`{}`), 1, true, now, now, "", "Web", "Alerts", "prometheus").
+			AddRow("si-2", "svc-db", "int-1", []byte(`{}`), 2, true, now, now, "", "Database", "Alerts", "prometheus"))
+	// The first service belongs to another group, so the routed group's service wins
+	expectRoutedService(mockDB, "svc-web", "group-web", "policy-web")
+	expectRoutedService(mockDB, "svc-db", "group-dba", "policy-db")
+	mockDB.ExpectQuery(`FROM escalation_levels`).
+		WithArgs("policy-db").
+		WillReturnRows(sqlmock.NewRows([]string{"target_type", "target_id"}).AddRow("user", "user-dba"))
+
+	handler := NewWebhookHandler(services.NewIntegrationService(pg), nil, services.NewIncidentService(pg, nil, nil), services.NewServiceService(pg))
+	serviceInfo, assigneeInfo, err := handler.resolveServiceAndAssignee(
+		db.Integration{ID: "int-1", Type: "prometheus", OrganizationID: "org-1"},
+		ProcessedAlert{AlertName: "ReplicationLag", Severity: "critical", Fingerprint: "fp-1", Labels: map[string]interface{}{"team": "db"}},
+	)
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, serviceInfo.Route) {
+		assert.Equal(t, "group-dba", serviceInfo.Route.TargetGroupID)
+	}
+	assert.Equal(t, "svc-db", serviceInfo.Service.ID)
+	assert.Equal(t, "user-dba", assigneeInfo.UserID)
+
+	incident := &db.Incident{}
+	applyResolvedServiceInfo(incident, serviceInfo, assigneeInfo)
+	assert.Equal(t, "group-dba", incident.GroupID)
+	assert.Equal(t, "policy-db", incident.EscalationPolicyID)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestApplyResolvedServiceInfo_RouteWithoutService(t *testing.T) {
+	incident := &db.Incident{}
+	applyResolvedServiceInfo(incident, &ResolvedServiceInfo{Route: &db.RoutingResult{TargetGroupID: "group-noc"}}, &ResolvedAssigneeInfo{})
+
+	assert.Equal(t, "group-noc", incident.GroupID)
+	assert.Empty(t, incident.ServiceID)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func routingTestAlert() ProcessedAlert {
+	return ProcessedAlert{
+		AlertName:   "DiskFull",
+		Severity:    "critical",
+		Status:      "firing",
+		Summary:     "Disk /var is 97% full on db-1",
+		Fingerprint: "abc123",
+		Labels: map[string]interface{}{
+			"env":      "prod",
+			"team":     "storage",
+			"instance": "db-1:9100",
+			"usage":    "97",
+		},
+		Annotations: map[string]interface{}{"runbook": "https://runbooks/disk"},
+	}
+}
+
+// conditions parses routing conditions as they are stored (JSON)
+func conditions(t *testing.T, raw string) map[string]interface{} {
+	var c map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &c); err != nil {
+		t.Fatalf("invalid conditions %s: %v", raw, err)
+	}
+	return c
+}
+
+func TestMatchesRoutingConditions_Operators(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions string
+		want       bool
+	}{
+		{"equals", `{"labels.env": {"operator": "equals", "value": "prod"}}`, true},
+		{"equals mismatch", `{"labels.env": {"operator": "equals", "value": "staging"}}`, false},
+		{"not_equals", `{"labels.env": {"operator": "not_equals", "value": "staging"}}`, true},
+		{"not_equals mismatch", `{"severity": {"operator": "not_equals", "value": "critical"}}`, false},
+		{"in", `{"severity": {"operator": "in", "value": ["critical", "high"]}}`, true},
+		{"in mismatch", `{"severity": {"operator": "in", "value": ["low", "info"]}}`, false},
+		{"not_in", `{"labels.team": {"operator": "not_in", "value": ["web", "mobile"]}}`, true},
+		{"not_in mismatch", `{"labels.team": {"operator": "not_in", "value": ["storage"]}}`, false},
+		{"contains", `{"summary": {"operator": "contains", "value": "/var"}}`, true},
+		{"contains mismatch", `{"summary": {"operator": "contains", "value": "/home"}}`, false},
+		{"not_contains", `{"labels.instance": {"operator": "not_contains", "value": "web"}}`, true},
+		{"not_contains mismatch", `{"labels.instance": {"operator": "not_contains", "value": "db-"}}`, false},
+		{"regex", `{"alertname": {"operator": "regex", "value": "^Disk(Full|Slow)$"}}`, true},
+		{"regex mismatch", `{"alertname": {"operator": "regex", "value": "^CPU"}}`, false},
+		{"invalid regex", `{"alertname": {"operator": "regex", "value": "(unclosed"}}`, false},
+		{"greater_than", `{"labels.usage": {"operator": "greater_than", "value": 90}}`, true},
+		{"greater_than mismatch", `{"labels.usage": {"operator": "greater_than", "value": 99}}`, false},
+		{"less_than", `{"labels.usage": {"operator": "less_than", "value": "99.5"}}`, true},
+		{"less_than not a number", `{"labels.env": {"operator": "less_than", "value": 10}}`, false},
+		{"default", `{"default": true}`, true},
+		{"unknown operator", `{"severity": {"operator": "starts_with", "value": "crit"}}`, false},
+		{"annotation", `{"annotations.runbook": {"operator": "contains", "value": "runbooks"}}`, true},
+
+		// Fields the alert doesn't have only match negative operators
+		{"missing label equals", `{"labels.region": "eu"}`, false},
+		{"missing label not_equals", `{"labels.region": {"operator": "not_equals", "value": "eu"}}`, true},
+		{"missing label regex", `{"labels.region": {"operator": "regex", "value": ".*"}}`, false},
+	}
+
+	h := &WebhookHandler{}
+	alert := routingTestAlert()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, h.matchesRoutingConditions(alert, conditions(t, tt.conditions)))
+		})
+	}
+}
+
+func TestMatchesRoutingConditions_LogicalOperators(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions string
+		want       bool
+	}{
+		{"and", `{"and": [{"severity": {"operator": "in", "value": ["critical", "high"]}}, {"labels.env": "prod"}]}`, true},
+		{"and mismatch", `{"and": [{"severity": "critical"}, {"labels.env": "staging"}]}`, false},
+		{"or", `{"or": [{"labels.env": "staging"}, {"labels.team": "storage"}]}`, true},
+		{"or mismatch", `{"or": [{"labels.env": "staging"}, {"labels.team": "web"}]}`, false},
+		{"empty or", `{"or": []}`, false},
+		{"not", `{"not": {"labels.env": "staging"}}`, true},
+		{"not mismatch", `{"not": {"alertname": {"operator": "regex", "value": "Disk"}}}`, false},
+		{"nested", `{"or": [{"and": [{"severity": "critical"}, {"not": {"labels.env": "prod"}}]}, {"and": [{"labels.usage": {"operator": "greater_than", "value": 95}}, {"labels.team": "storage"}]}]}`, true},
+		{"keys are and-ed", `{"severity": "critical", "or": [{"labels.env": "staging"}]}`, false},
+	}
+
+	h := &WebhookHandler{}
+	alert := routingTestAlert()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, h.matchesRoutingConditions(alert, conditions(t, tt.conditions)))
+		})
+	}
+}
+
+func TestMatchesRoutingConditions_SimpleForm(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions string
+		want       bool
+	}{
+		{"no conditions", `{}`, true},
+		{"severity list", `{"severity": ["critical", "high"]}`, true},
+		{"severity list mismatch", `{"severity": ["low"]}`, false},
+		{"alertname wildcard", `{"alertname": ["*"]}`, true},
+		{"alertname list", `{"alertname": ["CPUHigh", "DiskFull"]}`, true},
+		{"labels", `{"labels": {"env": "prod", "team": "storage"}}`, true},
+		{"labels mismatch", `{"labels": {"env": "prod", "team": "web"}}`, false},
+		{"labels missing", `{"labels": {"region": "eu"}}`, false},
+		{"all together", `{"severity": ["critical"], "alertname": ["DiskFull"], "labels": {"env": "prod"}}`, true},
+	}
+
+	h := &WebhookHandler{}
+	alert := routingTestAlert()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, h.matchesRoutingConditions(alert, conditions(t, tt.conditions)))
+		})
+	}
+}
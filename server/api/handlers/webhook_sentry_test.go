@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessSentryWebhook(t *testing.T) {
+	handler := &WebhookHandler{}
+
+	tests := []struct {
+		name          string
+		payload       string
+		expectedAlert ProcessedAlert
+		labels        map[string]interface{}
+	}{
+		{
+			name: "Integration platform issue alert",
+			payload: `{
+				"action": "triggered",
+				"data": {
+					"event": {
+						"event_id": "ec7e3c4b9a0a4a3c8d0c4b5e6f708192",
+						"issue_id": "1117540176",
+						"title": "TypeError: Cannot read properties of undefined",
+						"level": "error",
+						"culprit": "app/components/cart.tsx in render",
+						"transaction": "/checkout",
+						"environment": "production",
+						"web_url": "https://sentry.io/organizations/acme/issues/1117540176/events/ec7e3c4b/",
+						"datetime": "2026-10-17T09:30:00.000Z",
+						"tags": [["browser", "Chrome 129"], ["release", "web@1.4.2"]]
+					},
+					"triggered_rule": "Checkout errors"
+				}
+			}`,
+			expectedAlert: ProcessedAlert{
+				AlertName:   "TypeError: Cannot read properties of undefined",
+				Severity:    "high",
+				Status:      "firing",
+				Description: "app/components/cart.tsx in render",
+				Fingerprint: "sentry-1117540176",
+				Priority:    "P2",
+			},
+			labels: map[string]interface{}{
+				"source":      "sentry",
+				"issue_id":    "1117540176",
+				"culprit":     "app/components/cart.tsx in render",
+				"transaction": "/checkout",
+				"environment": "production",
+				"tag_release": "web@1.4.2",
+			},
+		},
+		{
+			name: "Legacy plugin webhook",
+			payload: `{
+				"id": "1117540176",
+				"project": "web",
+				"project_name": "Web",
+				"culprit": "app/components/cart.tsx in render",
+				"message": "Cannot read properties of undefined",
+				"url": "https://sentry.io/acme/web/issues/1117540176/",
+				"level": "fatal",
+				"event": {
+					"event_id": "ec7e3c4b9a0a4a3c8d0c4b5e6f708192",
+					"title": "TypeError: Cannot read properties of undefined",
+					"transaction": "/checkout"
+				}
+			}`,
+			expectedAlert: ProcessedAlert{
+				AlertName:   "TypeError: Cannot read properties of undefined",
+				Severity:    "critical",
+				Status:      "firing",
+				Description: "app/components/cart.tsx in render",
+				Fingerprint: "sentry-1117540176",
+				Priority:    "P1",
+			},
+			labels: map[string]interface{}{
+				"project":     "web",
+				"culprit":     "app/components/cart.tsx in render",
+				"transaction": "/checkout",
+			},
+		},
+		{
+			name: "Resolved issue",
+			payload: `{
+				"action": "resolved",
+				"data": {
+					"issue": {
+						"id": "1117540176",
+						"shortId": "WEB-3F",
+						"title": "TypeError: Cannot read properties of undefined",
+						"level": "warning",
+						"status": "resolved",
+						"permalink": "https://sentry.io/organizations/acme/issues/1117540176/"
+					}
+				}
+			}`,
+			expectedAlert: ProcessedAlert{
+				AlertName:   "TypeError: Cannot read properties of undefined",
+				Severity:    "warning",
+				Status:      "resolved",
+				Fingerprint: "sentry-1117540176",
+				Priority:    "P3",
+			},
+		},
+		{
+			// Tags in an unexpected shape don't fit the typed struct, so the legacy fallback handles it
+			name: "Legacy fallback",
+			payload: `{
+				"action": "triggered",
+				"data": {
+					"event": {
+						"issue_id": "1117540176",
+						"title": "ZeroDivisionError",
+						"level": "error",
+						"culprit": "billing.invoice in total",
+						"tags": {"unexpected": "shape"}
+					}
+				}
+			}`,
+			expectedAlert: ProcessedAlert{
+				AlertName:   "ZeroDivisionError",
+				Severity:    "high",
+				Status:      "firing",
+				Description: "billing.invoice in total",
+				Fingerprint: "sentry-1117540176",
+				Priority:    "P2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.payload), &payload); err != nil {
+				t.Fatalf("Failed to unmarshal payload: %v", err)
+			}
+
+			alerts := handler.processSentryWebhook(payload)
+			if len(alerts) != 1 {
+				t.Fatalf("Expected 1 alert, got %d", len(alerts))
+			}
+			alert := alerts[0]
+
+			assert.Equal(t, tt.expectedAlert.AlertName, alert.AlertName)
+			assert.Equal(t, tt.expectedAlert.Severity, alert.Severity)
+			assert.Equal(t, tt.expectedAlert.Status, alert.Status)
+			assert.Equal(t, tt.expectedAlert.Description, alert.Description)
+			assert.Equal(t, tt.expectedAlert.Fingerprint, alert.Fingerprint)
+			assert.Equal(t, tt.expectedAlert.Priority, alert.Priority)
+			for key, value := range tt.labels {
+				assert.Equal(t, value, alert.Labels[key], key)
+			}
+		})
+	}
+}
+
+func TestProcessSentryWebhook_Dedup(t *testing.T) {
+	handler := &WebhookHandler{}
+
+	// Two notifications for the same issue, different events
+	var first, second map[string]interface{}
+	_ = json.Unmarshal([]byte(`{"data":{"event":{"event_id":"a1","issue_id":"42","title":"KeyError","datetime":"2026-10-17T09:30:00Z"}}}`), &first)
+	_ = json.Unmarshal([]byte(`{"data":{"event":{"event_id":"b2","issue_id":"42","title":"KeyError","datetime":"2026-10-17T09:45:00Z"}}}`), &second)
+
+	a := handler.processSentryWebhook(first)[0]
+	b := handler.processSentryWebhook(second)[0]
+
+	assert.Equal(t, a.Fingerprint, b.Fingerprint)
+	assert.Equal(t, time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC), a.StartsAt)
+}
+
+func TestMapSentryLevel(t *testing.T) {
+	tests := map[string]string{
+		"fatal":   "critical",
+		"error":   "high",
+		"ERROR":   "high",
+		"warning": "warning",
+		"info":    "info",
+		"debug":   "low",
+		"":        "high",
+	}
+	for level, expected := range tests {
+		assert.Equal(t, expected, mapSentryLevel(level), level)
+	}
+}
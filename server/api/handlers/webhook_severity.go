@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"log"
+	"strings"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// integrationSeverityMap reads the integration's severity_map config, which overrides the
+// built-in severity mapping: {"P2": "critical", "INSUFFICIENT_DATA": "info"}. Keys match the
+// provider's own severity (Datadog priority, CloudWatch state, Sentry level, ...) or the
+// mapped one, case-insensitively.
+func integrationSeverityMap(integration db.Integration) map[string]string {
+	raw, ok := integration.Config["severity_map"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	severityMap := make(map[string]string, len(raw))
+	for key, value := range raw {
+		severity, ok := value.(string)
+		if !ok || strings.TrimSpace(severity) == "" {
+			log.Printf("WARNING: Ignoring severity_map entry %q of integration %s: severity must be a string", key, integration.ID)
+			continue
+		}
+		severityMap[strings.ToLower(strings.TrimSpace(key))] = strings.ToLower(strings.TrimSpace(severity))
+	}
+	return severityMap
+}
+
+// applySeverityMap overrides an alert's severity from the integration's severity map. The
+// provider's raw severity is looked up first, then the built-in mapping's result. An
+// overridden alert drops its priority so it is derived again from the new severity.
+func applySeverityMap(alert ProcessedAlert, severityMap map[string]string) ProcessedAlert {
+	if len(severityMap) == 0 {
+		return alert
+	}
+
+	severity, ok := severityMap[strings.ToLower(alert.RawSeverity)]
+	if !ok || alert.RawSeverity == "" {
+		severity, ok = severityMap[strings.ToLower(alert.Severity)]
+	}
+	if !ok || severity == alert.Severity {
+		return alert
+	}
+
+	log.Printf("DEBUG: Severity map overrides %s severity %s (raw %q) with %s", alert.AlertName, alert.Severity, alert.RawSeverity, severity)
+	alert.Severity = severity
+	alert.Priority = ""
+	return alert
+}
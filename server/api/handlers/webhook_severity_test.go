@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntegrationSeverityMap(t *testing.T) {
+	assert.Nil(t, integrationSeverityMap(db.Integration{}))
+	assert.Nil(t, integrationSeverityMap(db.Integration{Config: map[string]interface{}{"severity_map": "P2=critical"}}))
+
+	severityMap := integrationSeverityMap(db.Integration{Config: map[string]interface{}{
+		"severity_map": map[string]interface{}{"P2": "Critical", " ALARM ": "high", "P5": 5, "P4": ""},
+	}})
+	assert.Equal(t, map[string]string{"p2": "critical", "alarm": "high"}, severityMap)
+}
+
+func TestApplySeverityMap(t *testing.T) {
+	severityMap := map[string]string{"p2": "critical", "warning": "info"}
+
+	tests := []struct {
+		name         string
+		alert        ProcessedAlert
+		wantSeverity string
+		wantPriority string
+	}{
+		{"RawSeverity", ProcessedAlert{Severity: "high", RawSeverity: "P2", Priority: "P2"}, "critical", ""},
+		{"MappedSeverity", ProcessedAlert{Severity: "warning", Priority: "P3"}, "info", ""},
+		{"RawWinsOverMapped", ProcessedAlert{Severity: "warning", RawSeverity: "p2", Priority: "P3"}, "critical", ""},
+		{"Unmapped", ProcessedAlert{Severity: "high", RawSeverity: "P1", Priority: "P1"}, "high", "P1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := applySeverityMap(tt.alert, severityMap)
+			assert.Equal(t, tt.wantSeverity, alert.Severity)
+			assert.Equal(t, tt.wantPriority, alert.Priority)
+		})
+	}
+
+	// Without a map the built-in mapping stands
+	alert := applySeverityMap(ProcessedAlert{Severity: "high", RawSeverity: "P2", Priority: "P2"}, nil)
+	assert.Equal(t, "high", alert.Severity)
+	assert.Equal(t, "P2", alert.Priority)
+}
+
+func TestProcessedAlertsKeepRawSeverity(t *testing.T) {
+	handler := &WebhookHandler{}
+
+	datadog := handler.processDatadogWebhook(map[string]interface{}{
+		"title": "CPU high", "alert_priority": "P2", "alert_transition": "Triggered", "id": "1",
+	})
+	assert.Len(t, datadog, 1)
+	assert.Equal(t, "high", datadog[0].Severity)
+	assert.Equal(t, "P2", datadog[0].RawSeverity)
+
+	aws := handler.processAWSWebhook(map[string]interface{}{
+		"AlarmName": "disk", "NewStateValue": "INSUFFICIENT_DATA",
+	})
+	assert.Len(t, aws, 1)
+	assert.Equal(t, "warning", aws[0].Severity)
+	assert.Equal(t, "INSUFFICIENT_DATA", aws[0].RawSeverity)
+}
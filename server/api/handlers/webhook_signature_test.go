@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+var verifySignature = map[string]interface{}{"verify_signature": true}
+
+func signBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"alert_name":"Database down","status":"firing"}`)
+	signature := signBody("s3cret", body)
+	integration := db.Integration{ID: "int-1", Type: "webhook", WebhookSecret: "s3cret", Config: verifySignature}
+
+	tests := []struct {
+		name        string
+		integration db.Integration
+		headers     map[string]string
+		want        error
+	}{
+		{"Valid", integration, map[string]string{WebhookSignatureHeader: signature}, nil},
+		{"SchemePrefix", integration, map[string]string{WebhookSignatureHeader: "sha256=" + signature}, nil},
+		{"Missing", integration, nil, ErrWebhookSignatureMissing},
+		{"WrongSecret", integration, map[string]string{WebhookSignatureHeader: signBody("other", body)}, ErrWebhookSignatureInvalid},
+		{"NotHex", integration, map[string]string{WebhookSignatureHeader: "not-a-signature"}, ErrWebhookSignatureInvalid},
+		{"NoSecret", db.Integration{Type: "webhook"}, nil, nil},
+		{"Disabled", db.Integration{Type: "webhook", WebhookSecret: "s3cret", Config: map[string]interface{}{"verify_signature": false}}, nil, nil},
+		{"NotOptedIn", db.Integration{Type: "webhook", WebhookSecret: "s3cret"}, nil, nil},
+		{"ProviderHeader", db.Integration{Type: "pagerduty", WebhookSecret: "s3cret", Config: verifySignature},
+			map[string]string{"X-PagerDuty-Signature": "v1=" + signBody("rotated", body) + ",v1=" + signature}, nil},
+		// Provider headers only count for their own integration type
+		{"OtherProviderHeader", integration, map[string]string{"X-PagerDuty-Signature": "v1=" + signature}, ErrWebhookSignatureMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for name, value := range tt.headers {
+				header.Set(name, value)
+			}
+			assert.Equal(t, tt.want, verifyWebhookSignature(tt.integration, header, body))
+		})
+	}
+}
+
+func TestVerifyWebhookSignature_TamperedBody(t *testing.T) {
+	integration := db.Integration{Type: "webhook", WebhookSecret: "s3cret", Config: verifySignature}
+	header := http.Header{}
+	header.Set(WebhookSignatureHeader, signBody("s3cret", []byte(`{"status":"firing"}`)))
+
+	err := verifyWebhookSignature(integration, header, []byte(`{"status":"resolved"}`))
+
+	assert.Equal(t, ErrWebhookSignatureInvalid, err)
+}
+
+func TestVerifyWebhookSignature_ProviderFormats(t *testing.T) {
+	body := []byte(`{"action":"created","alert":{"number":7}}`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	digest := mac.Sum(nil)
+
+	// GitHub has no integration type of its own; a generic integration opts into its format
+	github := db.Integration{Type: "webhook", WebhookSecret: "s3cret", Config: map[string]interface{}{"verify_signature": true, "signature_scheme": "github"}}
+	datadog := db.Integration{Type: "datadog", WebhookSecret: "s3cret", Config: verifySignature}
+
+	tests := []struct {
+		name        string
+		integration db.Integration
+		header      string
+		value       string
+		want        error
+	}{
+		{"GitHub", github, "X-Hub-Signature-256", "sha256=" + hex.EncodeToString(digest), nil},
+		{"GitHubWrongSecret", github, "X-Hub-Signature-256", "sha256=" + signBody("other", body), ErrWebhookSignatureInvalid},
+		{"GitHubHeaderWithoutScheme", db.Integration{Type: "webhook", WebhookSecret: "s3cret", Config: verifySignature}, "X-Hub-Signature-256", "sha256=" + hex.EncodeToString(digest), ErrWebhookSignatureMissing},
+		{"Datadog", datadog, "X-Datadog-Signature", base64.StdEncoding.EncodeToString(digest), nil},
+		{"DatadogHexRejected", datadog, "X-Datadog-Signature", hex.EncodeToString(digest), ErrWebhookSignatureInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set(tt.header, tt.value)
+			assert.Equal(t, tt.want, verifyWebhookSignature(tt.integration, header, body))
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// fieldTransform extracts one ProcessedAlert field from a webhook payload
+type fieldTransform struct {
+	Path    string            // dotted path into the payload, e.g. "alert.meta.sev" or "events.0.id"
+	Values  map[string]string // optional value mapping, e.g. {"2": "high"}
+	Default string            // used when the path is missing or empty
+}
+
+// payloadTransform maps ProcessedAlert fields (title, severity, status, fingerprint,
+// summary, description, resolution_note) to fields of an arbitrary webhook payload
+type payloadTransform map[string]fieldTransform
+
+// payloadTransformFields are the ProcessedAlert fields a transform can set
+var payloadTransformFields = map[string]bool{
+	"title":           true,
+	"severity":        true,
+	"status":          true,
+	"fingerprint":     true,
+	"summary":         true,
+	"description":     true,
+	"resolution_note": true,
+}
+
+// integrationPayloadTransform returns the payload transform of a custom or generic webhook integration.
+// Integrations created from an IntegrationTemplate carry its payload_transform in their config.
+func integrationPayloadTransform(integration db.Integration) (payloadTransform, bool) {
+	if integration.Type != "webhook" && integration.Type != "custom" {
+		return nil, false
+	}
+	spec, ok := integration.Config["payload_transform"].(map[string]interface{})
+	if !ok || len(spec) == 0 {
+		return nil, false
+	}
+	return parsePayloadTransform(spec), true
+}
+
+// parsePayloadTransform parses a transform spec. Each field is either a path string
+// or an object with "path", "map" and "default" keys. Unknown fields are ignored.
+func parsePayloadTransform(spec map[string]interface{}) payloadTransform {
+	transform := payloadTransform{}
+	for field, raw := range spec {
+		if !payloadTransformFields[field] {
+			log.Printf("WARNING: Ignoring unknown payload transform field %q", field)
+			continue
+		}
+
+		switch v := raw.(type) {
+		case string:
+			transform[field] = fieldTransform{Path: v}
+		case map[string]interface{}:
+			ft := fieldTransform{
+				Path:    getStringFromMap(v, "path", ""),
+				Default: getStringFromMap(v, "default", ""),
+			}
+			if values, ok := v["map"].(map[string]interface{}); ok {
+				ft.Values = make(map[string]string, len(values))
+				for from, to := range values {
+					ft.Values[from] = fmt.Sprint(to)
+				}
+			}
+			transform[field] = ft
+		}
+	}
+	return transform
+}
+
+// value extracts and maps the field from payload
+func (ft fieldTransform) value(payload map[string]interface{}) string {
+	value := ""
+	if ft.Path != "" {
+		if raw, ok := extractPath(payload, ft.Path); ok {
+			value = stringifyPayloadValue(raw)
+		}
+	}
+	if mapped, ok := ft.Values[value]; ok {
+		value = mapped
+	}
+	if value == "" {
+		return ft.Default
+	}
+	return value
+}
+
+// extractPath walks a dotted path through nested objects; numeric segments index into arrays
+func extractPath(payload map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = payload
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, current != nil
+}
+
+// stringifyPayloadValue renders scalar JSON values; objects and arrays yield ""
+func stringifyPayloadValue(raw interface{}) string {
+	switch v := raw.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+// processTransformedWebhook builds an alert from a payload using the integration's transform
+func (h *WebhookHandler) processTransformedWebhook(payload map[string]interface{}, transform payloadTransform) []ProcessedAlert {
+	field := func(name, defaultValue string) string {
+		ft, ok := transform[name]
+		if !ok {
+			return defaultValue
+		}
+		if value := ft.value(payload); value != "" {
+			return value
+		}
+		return defaultValue
+	}
+
+	alert := ProcessedAlert{
+		AlertName:      field("title", "generic-alert"),
+		Severity:       field("severity", "warning"),
+		Status:         field("status", "firing"),
+		Summary:        field("summary", ""),
+		Description:    field("description", ""),
+		Fingerprint:    field("fingerprint", ""),
+		ResolutionNote: field("resolution_note", ""),
+		Labels:         getMapFromMap(payload, "labels"),
+		Annotations:    getMapFromMap(payload, "annotations"),
+		StartsAt:       time.Now(),
+	}
+
+	log.Printf("INFO: Processed transformed alert: %s (Severity: %s, Status: %s)",
+		alert.AlertName, alert.Severity, alert.Status)
+	return []ProcessedAlert{alert}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessTransformedWebhook(t *testing.T) {
+	var config map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"payload_transform": {
+			"title": "incident.name",
+			"severity": {"path": "incident.sev", "map": {"1": "critical", "2": "high"}, "default": "warning"},
+			"status": {"path": "incident.state", "map": {"open": "firing", "closed": "resolved"}},
+			"fingerprint": "events.0.id",
+			"summary": "incident.details.summary",
+			"runbook": "incident.runbook"
+		}
+	}`), &config)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+	var payload map[string]interface{}
+	err = json.Unmarshal([]byte(`{
+		"incident": {"name": "Checkout latency", "sev": 2, "state": "closed", "details": {"summary": "p99 above 2s"}},
+		"events": [{"id": "evt-42"}, {"id": "evt-43"}]
+	}`), &payload)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal payload: %v", err)
+	}
+
+	transform, ok := integrationPayloadTransform(db.Integration{Type: "custom", Config: config})
+	assert.True(t, ok)
+
+	handler := &WebhookHandler{}
+	alerts := handler.processTransformedWebhook(payload, transform)
+
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, "Checkout latency", alerts[0].AlertName)
+	assert.Equal(t, "high", alerts[0].Severity)
+	assert.Equal(t, "resolved", alerts[0].Status)
+	assert.Equal(t, "evt-42", alerts[0].Fingerprint)
+	assert.Equal(t, "p99 above 2s", alerts[0].Summary)
+	assert.Empty(t, alerts[0].Description)
+}
+
+func TestProcessTransformedWebhook_Defaults(t *testing.T) {
+	transform := parsePayloadTransform(map[string]interface{}{
+		"title":    "alert.title",
+		"severity": map[string]interface{}{"path": "alert.level", "map": map[string]interface{}{"5": "critical"}, "default": "info"},
+	})
+
+	handler := &WebhookHandler{}
+	alerts := handler.processTransformedWebhook(map[string]interface{}{"alert": "not an object"}, transform)
+
+	// Missing paths fall back to the field default, then the generic webhook default
+	assert.Equal(t, "generic-alert", alerts[0].AlertName)
+	assert.Equal(t, "info", alerts[0].Severity)
+	assert.Equal(t, "firing", alerts[0].Status)
+	assert.Empty(t, alerts[0].Fingerprint)
+}
+
+func TestIntegrationPayloadTransform(t *testing.T) {
+	spec := map[string]interface{}{"payload_transform": map[string]interface{}{"title": "name"}}
+
+	_, ok := integrationPayloadTransform(db.Integration{Type: "webhook", Config: spec})
+	assert.True(t, ok)
+
+	// Built-in providers keep their own parsers
+	_, ok = integrationPayloadTransform(db.Integration{Type: "datadog", Config: spec})
+	assert.False(t, ok)
+
+	_, ok = integrationPayloadTransform(db.Integration{Type: "webhook", Config: map[string]interface{}{}})
+	assert.False(t, ok)
+}
+
+func TestExtractPath(t *testing.T) {
+	payload := map[string]interface{}{
+		"a": map[string]interface{}{"b": []interface{}{"x", map[string]interface{}{"c": true}}},
+	}
+
+	value, ok := extractPath(payload, "a.b.1.c")
+	assert.True(t, ok)
+	assert.Equal(t, true, value)
+
+	for _, path := range []string{"a.missing", "a.b.2", "a.b.x", "a.b.0.c"} {
+		_, ok := extractPath(payload, path)
+		assert.False(t, ok, path)
+	}
+}
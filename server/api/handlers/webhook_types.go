@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -65,25 +66,34 @@ type DatadogOrg struct {
 // Grafana webhook payload
 // Reference: https://grafana.com/docs/grafana/latest/alerting/configure-notifications/manage-contact-points/integrations/webhook-notifier/
 type GrafanaWebhook struct {
-	Receiver          string            `json:"receiver"`
-	Status            string            `json:"status"`
-	Alerts            []GrafanaAlert    `json:"alerts"`
-	GroupLabels       map[string]string `json:"groupLabels"`
-	CommonLabels      map[string]string `json:"commonLabels"`
-	CommonAnnotations map[string]string `json:"commonAnnotations"`
-	ExternalURL       string            `json:"externalURL"`
-	Version           string            `json:"version"`
-	GroupKey          string            `json:"groupKey"`
-	TruncatedAlerts   int               `json:"truncatedAlerts"`
-	OrgID             int64             `json:"orgId"`
-	Title             string            `json:"title"`
-	State             string            `json:"state"` // alerting, ok, pending
-	Message           string            `json:"message"`
-	RuleName          string            `json:"ruleName"`
-	RuleURL           string            `json:"ruleUrl"`
-	DashboardID       int64             `json:"dashboardId"`
-	PanelID           int64             `json:"panelId"`
-	ImageURL          string            `json:"imageUrl"`
+	Receiver          string             `json:"receiver"`
+	Status            string             `json:"status"`
+	Alerts            []GrafanaAlert     `json:"alerts"`
+	GroupLabels       map[string]string  `json:"groupLabels"`
+	CommonLabels      map[string]string  `json:"commonLabels"`
+	CommonAnnotations map[string]string  `json:"commonAnnotations"`
+	ExternalURL       string             `json:"externalURL"`
+	Version           string             `json:"version"`
+	GroupKey          string             `json:"groupKey"`
+	TruncatedAlerts   int                `json:"truncatedAlerts"`
+	OrgID             int64              `json:"orgId"`
+	Title             string             `json:"title"`
+	State             string             `json:"state"` // alerting, ok, pending
+	Message           string             `json:"message"`
+	RuleID            int64              `json:"ruleId"`
+	RuleName          string             `json:"ruleName"`
+	RuleURL           string             `json:"ruleUrl"`
+	DashboardID       int64              `json:"dashboardId"`
+	PanelID           int64              `json:"panelId"`
+	ImageURL          string             `json:"imageUrl"`
+	EvalMatches       []GrafanaEvalMatch `json:"evalMatches"` // Legacy alerting: one entry per series over threshold
+}
+
+// GrafanaEvalMatch is a series that matched a legacy Grafana alert rule
+type GrafanaEvalMatch struct {
+	Value  float64           `json:"value"`
+	Metric string            `json:"metric"`
+	Tags   map[string]string `json:"tags"`
 }
 
 type GrafanaAlert struct {
@@ -97,6 +107,7 @@ type GrafanaAlert struct {
 	SilenceURL   string             `json:"silenceURL"`
 	DashboardURL string             `json:"dashboardURL"`
 	PanelURL     string             `json:"panelURL"`
+	ImageURL     string             `json:"imageURL"` // Panel screenshot, when image rendering is enabled
 	Values       map[string]float64 `json:"values"`
 }
 
@@ -264,6 +275,54 @@ type CoralogixQueryResult struct {
 	Metadata    map[string]interface{} `json:"metadata"`
 }
 
+// Sentry webhook payload. Covers both the legacy plugin webhook (issue fields at the
+// top level, event under "event") and integration platform alerts (under "data").
+// Reference: https://docs.sentry.io/organization/integrations/integration-platform/webhooks/
+type SentryWebhook struct {
+	// Legacy plugin webhook
+	ID          string       `json:"id"` // issue ID
+	Project     string       `json:"project"`
+	ProjectName string       `json:"project_name"`
+	Culprit     string       `json:"culprit"`
+	Message     string       `json:"message"`
+	URL         string       `json:"url"`
+	Level       string       `json:"level"`
+	Event       *SentryEvent `json:"event"`
+
+	// Integration platform webhook
+	Action string             `json:"action"` // triggered, created, resolved, ...
+	Data   *SentryWebhookData `json:"data"`
+}
+
+type SentryWebhookData struct {
+	Event         *SentryEvent `json:"event"`
+	Issue         *SentryIssue `json:"issue"`
+	TriggeredRule string       `json:"triggered_rule"`
+}
+
+type SentryEvent struct {
+	EventID     string     `json:"event_id"`
+	IssueID     string     `json:"issue_id"`
+	Title       string     `json:"title"`
+	Level       string     `json:"level"` // fatal, error, warning, info, debug
+	Culprit     string     `json:"culprit"`
+	Transaction string     `json:"transaction"`
+	Environment string     `json:"environment"`
+	WebURL      string     `json:"web_url"`
+	Datetime    string     `json:"datetime"`
+	Tags        [][]string `json:"tags"` // [["key", "value"], ...]
+}
+
+type SentryIssue struct {
+	ID        string `json:"id"`
+	ShortID   string `json:"shortId"`
+	Title     string `json:"title"`
+	Level     string `json:"level"`
+	Culprit   string `json:"culprit"`
+	Status    string `json:"status"` // unresolved, resolved, ignored
+	Permalink string `json:"permalink"`
+}
+
 // Generic webhook payload (for custom integrations)
 type GenericWebhook struct {
 	AlertName   string                 `json:"alert_name"`
@@ -276,6 +335,12 @@ type GenericWebhook struct {
 	StartsAt    *time.Time             `json:"starts_at,omitempty"`
 	EndsAt      *time.Time             `json:"ends_at,omitempty"`
 	Fingerprint string                 `json:"fingerprint,omitempty"`
+
+	// Fingerprints lets one resolve close the incidents of several alerts
+	Fingerprints []string `json:"fingerprints,omitempty"`
+
+	// ResolutionNote summarises how a resolved alert was fixed
+	ResolutionNote string `json:"resolution_note,omitempty"`
 }
 
 // Helper functions to convert webhook structs to ProcessedAlert
@@ -291,6 +356,7 @@ func (p *PrometheusAlert) ToProcessedAlert() ProcessedAlert {
 		Annotations: convertStringMapToInterface(p.Annotations),
 		StartsAt:    p.StartsAt,
 		Fingerprint: p.Fingerprint,
+		SnapshotURL: snapshotURLFromAnnotations(p.Annotations),
 	}
 
 	// Set default severity if not provided
@@ -324,11 +390,12 @@ func (d *DatadogWebhook) ToProcessedAlert() ProcessedAlert {
 	alert := ProcessedAlert{
 		AlertName:   d.Title,
 		Severity:    severity,
+		RawSeverity: d.AlertPriority,
 		Status:      mapDatadogStatus(d.Transition),
 		Summary:     d.Title, // Summary is the body content
 		Description: d.Body,  // Description is the title
 		Priority:    d.AlertPriority,
-		Fingerprint: d.Aggregate,
+		Fingerprint: firstNonEmpty(d.Aggregate, d.AlertCycleKey),
 		Labels: map[string]interface{}{
 			"source":          "datadog",
 			"event_id":        d.ID,
@@ -346,8 +413,10 @@ func (d *DatadogWebhook) ToProcessedAlert() ProcessedAlert {
 			"org_name":     d.Org.Name,
 			"last_updated": d.LastUpdated,
 			"link":         d.Link,
+			"snapshot":     d.Snapshot,
 		},
-		StartsAt: parseDatadogTimestampFromString(d.Date, d.LastUpdated),
+		StartsAt:    parseDatadogTimestampFromString(d.Date, d.LastUpdated),
+		SnapshotURL: d.Snapshot,
 	}
 
 	// Add tags to labels
@@ -377,10 +446,64 @@ func parseDatadogTimestampFromString(date, lastUpdated string) time.Time {
 	return time.Now()
 }
 
+// ToProcessedAlerts emits one alert per legacy evaluation match when several series matched,
+// so distinct series don't collapse into one incident. Otherwise it yields the single alert
+// of ToProcessedAlert. Unified alerting payloads are handled by processGrafanaUnifiedWebhook.
+func (g *GrafanaWebhook) ToProcessedAlerts() []ProcessedAlert {
+	if len(g.EvalMatches) > 1 {
+		alerts := make([]ProcessedAlert, 0, len(g.EvalMatches))
+		for _, match := range g.EvalMatches {
+			alerts = append(alerts, g.evalMatchToProcessedAlert(match))
+		}
+		return alerts
+	}
+
+	return []ProcessedAlert{g.ToProcessedAlert()}
+}
+
+// evalMatchToProcessedAlert converts one series of a legacy alert that matched several series
+func (g *GrafanaWebhook) evalMatchToProcessedAlert(match GrafanaEvalMatch) ProcessedAlert {
+	alert := g.ToProcessedAlert()
+	alert.Labels["metric"] = match.Metric
+	alert.Labels["value"] = match.Value
+	for k, v := range match.Tags {
+		alert.Labels[k] = v
+	}
+
+	key := map[string]string{"metric": match.Metric}
+	for k, v := range match.Tags {
+		key["tag_"+k] = v
+	}
+	if g.RuleID != 0 {
+		key["rule_id"] = strconv.FormatInt(g.RuleID, 10)
+	} else {
+		key["rule_name"] = g.RuleName
+	}
+	alert.Fingerprint = grafanaFingerprint(key)
+
+	return alert
+}
+
+// grafanaFingerprint builds a stable fingerprint from labels
+func grafanaFingerprint(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+labels[k])
+	}
+	return "grafana-" + strings.Join(pairs, ",")
+}
+
 func (g *GrafanaWebhook) ToProcessedAlert() ProcessedAlert {
 	alert := ProcessedAlert{
 		AlertName:   g.RuleName,
 		Severity:    mapGrafanaSeverity(g.State),
+		RawSeverity: g.State,
 		Status:      mapGrafanaStatus(g.State),
 		Summary:     g.Message,
 		Description: g.Title,
@@ -406,13 +529,39 @@ func (g *GrafanaWebhook) ToProcessedAlert() ProcessedAlert {
 		alert.Annotations[k] = v
 	}
 
+	// Legacy alerts carry imageUrl at the top level, unified alerting per alert
+	alert.SnapshotURL = g.ImageURL
+	for _, a := range g.Alerts {
+		if alert.SnapshotURL != "" {
+			break
+		}
+		alert.SnapshotURL = a.ImageURL
+	}
+	if alert.SnapshotURL == "" {
+		alert.SnapshotURL = snapshotURLFromAnnotations(g.CommonAnnotations)
+	}
+
 	return alert
 }
 
+// snapshotAnnotationKeys are annotations alert rules commonly use to link a graph image
+var snapshotAnnotationKeys = []string{"image_url", "imageUrl", "graph_url", "snapshot_url"}
+
+// snapshotURLFromAnnotations returns the first graph/image URL found in the annotations
+func snapshotURLFromAnnotations(annotations map[string]string) string {
+	for _, key := range snapshotAnnotationKeys {
+		if url := annotations[key]; url != "" {
+			return url
+		}
+	}
+	return ""
+}
+
 func (a *AWSCloudWatchAlarm) ToProcessedAlert() ProcessedAlert {
 	alert := ProcessedAlert{
 		AlertName:   a.AlarmName,
 		Severity:    mapAWSSeverity(a.NewStateValue),
+		RawSeverity: a.NewStateValue,
 		Status:      mapAWSStatus(a.NewStateValue),
 		Summary:     a.AlarmDescription,
 		Description: a.NewStateReason,
@@ -448,6 +597,10 @@ func (g *GenericWebhook) ToProcessedAlert() ProcessedAlert {
 		Annotations: g.Annotations,
 		Fingerprint: g.Fingerprint,
 	}
+	if g.Status == "resolved" {
+		alert.Fingerprints = g.Fingerprints
+		alert.ResolutionNote = g.ResolutionNote
+	}
 
 	// Set defaults
 	if alert.AlertName == "" {
@@ -497,8 +650,10 @@ func (p *PagerDutyWebhook) ToProcessedAlert() ProcessedAlert {
 
 	// Map urgency/priority to severity
 	var severity string
+	rawSeverity := data.Urgency
 	if data.Priority != nil {
 		severity = mapPagerDutyPriority(data.Priority.Name)
+		rawSeverity = data.Priority.Name
 	} else {
 		severity = mapPagerDutyUrgency(data.Urgency)
 	}
@@ -526,6 +681,7 @@ func (p *PagerDutyWebhook) ToProcessedAlert() ProcessedAlert {
 	alert := ProcessedAlert{
 		AlertName:   data.Title,
 		Severity:    severity,
+		RawSeverity: rawSeverity,
 		Status:      status,
 		Summary:     data.Title,
 		Description: description,
@@ -609,6 +765,7 @@ func (c *CoralogixWebhook) ToProcessedAlert() ProcessedAlert {
 	alert := ProcessedAlert{
 		AlertName:   c.AlertName,
 		Severity:    severity,
+		RawSeverity: c.AlertSeverity,
 		Status:      status,
 		Summary:     c.AlertName,
 		Description: description,
@@ -641,6 +798,84 @@ func (c *CoralogixWebhook) ToProcessedAlert() ProcessedAlert {
 	return alert
 }
 
+func (s *SentryWebhook) ToProcessedAlert() ProcessedAlert {
+	event := s.Event
+	var issue *SentryIssue
+	triggeredRule := ""
+	if s.Data != nil {
+		if s.Data.Event != nil {
+			event = s.Data.Event
+		}
+		issue = s.Data.Issue
+		triggeredRule = s.Data.TriggeredRule
+	}
+	if event == nil {
+		event = &SentryEvent{}
+	}
+	if issue == nil {
+		issue = &SentryIssue{}
+	}
+
+	title := firstNonEmpty(event.Title, issue.Title, s.Message, "sentry-alert")
+	issueID := firstNonEmpty(event.IssueID, issue.ID, s.ID)
+	culprit := firstNonEmpty(event.Culprit, issue.Culprit, s.Culprit)
+	level := firstNonEmpty(event.Level, issue.Level, s.Level)
+	severity := mapSentryLevel(level)
+
+	status := "firing"
+	if strings.EqualFold(s.Action, "resolved") || strings.EqualFold(issue.Status, "resolved") {
+		status = "resolved"
+	}
+
+	startsAt := time.Now()
+	if event.Datetime != "" {
+		if t, err := time.Parse(time.RFC3339, event.Datetime); err == nil {
+			startsAt = t
+		}
+	}
+
+	// Every notification for a Sentry issue carries its ID, so they dedup into one incident
+	fingerprint := "sentry-" + issueID
+	if issueID == "" {
+		fingerprint = fmt.Sprintf("sentry-%s-%s", s.Project, title)
+	}
+
+	alert := ProcessedAlert{
+		AlertName:   title,
+		Severity:    severity,
+		RawSeverity: level,
+		Status:      status,
+		Summary:     title,
+		Description: culprit,
+		Fingerprint: fingerprint,
+		Priority:    mapSeverityToPriority(severity),
+		Labels: map[string]interface{}{
+			"source":      "sentry",
+			"issue_id":    issueID,
+			"project":     firstNonEmpty(s.Project, s.ProjectName),
+			"culprit":     culprit,
+			"transaction": event.Transaction,
+			"environment": event.Environment,
+		},
+		Annotations: map[string]interface{}{
+			"url":            firstNonEmpty(event.WebURL, issue.Permalink, s.URL),
+			"event_id":       event.EventID,
+			"short_id":       issue.ShortID,
+			"triggered_rule": triggeredRule,
+		},
+		StartsAt: startsAt,
+	}
+
+	// Add event tags
+	for _, tag := range event.Tags {
+		if len(tag) == 2 {
+			alert.Labels["tag_"+tag[0]] = tag[1]
+		}
+	}
+
+	return alert
+}
+
 // Helper functions for PagerDuty
 func mapPagerDutyPriority(priority string) string {
 	switch strings.ToUpper(priority) {
@@ -693,6 +928,33 @@ func mapCoralogixSeverity(severity string) string {
 	}
 }
 
+// Helper functions for Sentry
+func mapSentryLevel(level string) string {
+	switch strings.ToLower(level) {
+	case "fatal":
+		return "critical"
+	case "error":
+		return "high"
+	case "warning":
+		return "warning"
+	case "info":
+		return "info"
+	case "debug":
+		return "low"
+	default:
+		return "high" // Sentry alerts are errors unless told otherwise
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 // Helper function to convert map[string]string to map[string]interface{}
 func convertStringMapToInterface(m map[string]string) map[string]interface{} {
 	result := make(map[string]interface{})
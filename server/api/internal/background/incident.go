@@ -3,7 +3,6 @@ package background
 import (
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 	"time"
@@ -17,6 +16,19 @@ type IncidentWorker struct {
 	PG                 *sql.DB
 	IncidentService    *services.IncidentService
 	NotificationWorker *NotificationWorker
+
+	// ArchiveResolvedAfterDays archives incidents resolved longer ago than this, hourly (0 disables)
+	ArchiveResolvedAfterDays int
+	lastArchiveRun           time.Time
+
+	// SeverityUpgradeRules bump the severity of incidents left unresolved too long
+	SeverityUpgradeRules []services.SeverityUpgradeRule
+
+	// TicketChecker polls the vendor tickets of incidents on hold (nil disables)
+	TicketChecker services.ExternalTicketChecker
+
+	// EscalationService fires the alert escalation steps scheduled after a step's timeout (nil disables)
+	EscalationService *services.EscalationService
 }
 
 func NewIncidentWorker(pg *sql.DB, incidentService *services.IncidentService, notificationWorker *NotificationWorker) *IncidentWorker {
@@ -43,6 +55,33 @@ func (w *IncidentWorker) StartIncidentWorker() {
 func (w *IncidentWorker) processEscalations() {
 	log.Printf("DEBUG: Starting escalation check...")
 
+	// Resume escalation for incidents whose snooze has expired
+	w.resumeSnoozedIncidents()
+
+	// Log SLA breaches on open incidents
+	w.recordSLABreaches()
+
+	// Remind acknowledgers whose ETA passed with the incident unresolved
+	w.nudgeOverdueETAs()
+
+	// Send assignment notifications whose AssignmentNotificationDelay passed
+	w.sendHeldAssignmentNotifications()
+
+	// Bump the severity of long-unresolved incidents
+	w.upgradeSeverities()
+
+	// Archive old resolved incidents
+	w.archiveResolvedIncidents()
+
+	// Resolve incidents on hold whose vendor ticket was closed
+	w.pollExternalTickets()
+
+	// Fire alert escalation steps whose previous step timed out
+	w.fireScheduledEscalations()
+
+	// Page the next member of sequential and round robin groups whose member timed out
+	w.pageNextGroupMembers()
+
 	// Find incidents that need escalation
 	incidents, err := w.getIncidentsNeedingEscalation()
 	if err != nil {
@@ -64,6 +103,188 @@ func (w *IncidentWorker) processEscalations() {
 	}
 }
 
+// resumeSnoozedIncidents restores the escalation status of incidents whose snooze has passed
+func (w *IncidentWorker) resumeSnoozedIncidents() {
+	rows, err := w.PG.Query(`
+		UPDATE incidents
+		SET escalation_status = COALESCE(snoozed_escalation_status, 'pending'),
+		    snoozed_until = NULL,
+		    snoozed_escalation_status = NULL,
+		    updated_at = ` + services.SQLNowUTC + `
+		WHERE snoozed_until IS NOT NULL
+		AND snoozed_until <= NOW()
+		AND status != 'resolved'
+		RETURNING id, escalation_status
+	`)
+	if err != nil {
+		log.Printf("Worker: failed to resume snoozed incidents: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var incidentID, escalationStatus string
+		if err := rows.Scan(&incidentID, &escalationStatus); err != nil {
+			log.Printf("Worker: error scanning resumed incident: %v", err)
+			continue
+		}
+
+		log.Printf("Worker: snooze expired for incident %s, escalation status restored to %s", incidentID, escalationStatus)
+		if err := w.createIncidentEvent(incidentID, "unsnoozed", map[string]interface{}{
+			"reason":            "snooze_expired",
+			"escalation_status": escalationStatus,
+		}, "system"); err != nil {
+			log.Printf("Worker: failed to log unsnoozed event: %v", err)
+		}
+	}
+}
+
+// recordSLABreaches logs an sla_breached event the first time an open incident misses an SLA target
+func (w *IncidentWorker) recordSLABreaches() {
+	breaches, err := w.IncidentService.RecordSLABreaches()
+	if err != nil {
+		log.Printf("Worker: failed to record SLA breaches: %v", err)
+	}
+
+	for _, breach := range breaches {
+		log.Printf("Worker: incident %s breached %s SLA (%d min target, %d min elapsed)",
+			breach.IncidentID, breach.SLAType, breach.SLAMinutes, breach.ElapsedMinutes)
+	}
+}
+
+// nudgeOverdueETAs reminds acknowledgers once the ETA they gave passes with the incident unresolved
+func (w *IncidentWorker) nudgeOverdueETAs() {
+	overdue, err := w.IncidentService.NudgeOverdueETAs()
+	if err != nil {
+		log.Printf("Worker: failed to nudge overdue ETAs: %v", err)
+	}
+
+	for _, incident := range overdue {
+		log.Printf("Worker: ETA %s of incident %s passed, nudged %s", incident.ETA.Format(time.RFC3339), incident.IncidentID, incident.AcknowledgedBy)
+	}
+}
+
+// sendHeldAssignmentNotifications sends the assignment notifications held for new incidents
+func (w *IncidentWorker) sendHeldAssignmentNotifications() {
+	sent, err := w.IncidentService.SendHeldAssignmentNotifications()
+	if err != nil {
+		log.Printf("Worker: failed to send held assignment notifications: %v", err)
+	}
+	if sent > 0 {
+		log.Printf("Worker: handled %d held assignment notifications", sent)
+	}
+}
+
+// pollExternalTickets checks the vendor tickets of incidents on hold, each at most every ExternalTicketPollInterval
+func (w *IncidentWorker) pollExternalTickets() {
+	if w.TicketChecker == nil {
+		return
+	}
+
+	resolved, err := w.IncidentService.PollExternalTickets(w.TicketChecker, services.ExternalTicketPollInterval)
+	if err != nil {
+		log.Printf("Worker: failed to poll external tickets: %v", err)
+	}
+
+	for _, incidentID := range resolved {
+		log.Printf("Worker: resolved incident %s, its external ticket was closed", incidentID)
+	}
+}
+
+// fireScheduledEscalations runs the due escalation steps of alerts nobody acknowledged
+func (w *IncidentWorker) fireScheduledEscalations() {
+	if w.EscalationService == nil {
+		return
+	}
+
+	steps, err := w.EscalationService.ClaimDueEscalationSteps()
+	if err != nil {
+		log.Printf("Worker: failed to claim scheduled escalation steps: %v", err)
+		return
+	}
+
+	for _, step := range steps {
+		go w.fireScheduledEscalation(step)
+	}
+}
+
+func (w *IncidentWorker) fireScheduledEscalation(step services.ScheduledEscalation) {
+	fired, err := w.EscalationService.FireScheduledEscalation(step)
+	if err != nil {
+		log.Printf("Worker: escalation step %d for alert %s failed: %v", step.StepNumber, step.AlertID, err)
+		return
+	}
+	if !fired {
+		log.Printf("Worker: dropped escalation step %d for alert %s", step.StepNumber, step.AlertID)
+		return
+	}
+	log.Printf("Worker: fired escalation step %d for alert %s", step.StepNumber, step.AlertID)
+}
+
+// pageNextGroupMembers pages the next member of group escalations whose current member did
+// not acknowledge in time
+func (w *IncidentWorker) pageNextGroupMembers() {
+	if w.EscalationService == nil {
+		return
+	}
+
+	pending, err := w.EscalationService.ClaimDueGroupMembers()
+	if err != nil {
+		log.Printf("Worker: failed to claim due group members: %v", err)
+		return
+	}
+
+	for _, members := range pending {
+		go w.pageNextGroupMember(members)
+	}
+}
+
+func (w *IncidentWorker) pageNextGroupMember(members services.PendingGroupMember) {
+	paged, err := w.EscalationService.PageNextGroupMember(members)
+	if err != nil {
+		log.Printf("Worker: paging next member of group %s for alert %s failed: %v", members.GroupID, members.AlertID, err)
+		return
+	}
+	if !paged {
+		log.Printf("Worker: stopped paging group %s for alert %s", members.GroupID, members.AlertID)
+		return
+	}
+	log.Printf("Worker: paged next member of group %s for alert %s", members.GroupID, members.AlertID)
+}
+
+// upgradeSeverities applies the severity auto-upgrade rules to open incidents
+func (w *IncidentWorker) upgradeSeverities() {
+	if len(w.SeverityUpgradeRules) == 0 {
+		return
+	}
+
+	upgrades, err := w.IncidentService.AutoUpgradeSeverities(w.SeverityUpgradeRules)
+	if err != nil {
+		log.Printf("Worker: failed to upgrade incident severities: %v", err)
+	}
+
+	for _, upgrade := range upgrades {
+		log.Printf("Worker: upgraded incident %s severity from %s to %s",
+			upgrade.IncidentID, upgrade.PreviousSeverity, upgrade.Severity)
+	}
+}
+
+// archiveResolvedIncidents archives incidents resolved more than ArchiveResolvedAfterDays ago, at most hourly
+func (w *IncidentWorker) archiveResolvedIncidents() {
+	if w.ArchiveResolvedAfterDays <= 0 || time.Since(w.lastArchiveRun) < time.Hour {
+		return
+	}
+	w.lastArchiveRun = time.Now()
+
+	archived, err := w.IncidentService.ArchiveResolvedIncidents(w.ArchiveResolvedAfterDays)
+	if err != nil {
+		log.Printf("Worker: failed to archive resolved incidents: %v", err)
+	}
+	if archived > 0 {
+		log.Printf("Worker: archived %d incidents resolved more than %d days ago", archived, w.ArchiveResolvedAfterDays)
+	}
+}
+
 // getIncidentsNeedingEscalation finds incidents that need to be escalated
 func (w *IncidentWorker) getIncidentsNeedingEscalation() ([]db.Incident, error) {
 	// First, let's debug what incidents exist and check timezone issues
@@ -139,6 +360,7 @@ func (w *IncidentWorker) getIncidentsNeedingEscalation() ([]db.Incident, error)
 		WHERE i.status = 'triggered'
 		AND i.escalation_policy_id IS NOT NULL
 		AND i.escalation_status IN ('none', 'pending')
+		AND (i.snoozed_until IS NULL OR i.snoozed_until <= NOW())
 		AND (
 			-- Never escalated: check timeout for level 1
 			(i.last_escalated_at IS NULL
@@ -149,7 +371,8 @@ func (w *IncidentWorker) getIncidentsNeedingEscalation() ([]db.Incident, error)
 				AND i.created_at < NOW() - INTERVAL '1 minute' * el1.timeout_minutes
 			 ))
 			OR
-			-- Already escalated: check if current level has timed out and next level exists
+			-- Already escalated: check if current level has timed out and next level exists,
+			-- or the last level timed out and the policy repeats
 			(i.last_escalated_at IS NOT NULL
 			 AND i.current_escalation_level > 0
 			 AND EXISTS (
@@ -158,11 +381,15 @@ func (w *IncidentWorker) getIncidentsNeedingEscalation() ([]db.Incident, error)
 				AND el_current.level_number = i.current_escalation_level
 				AND i.last_escalated_at < NOW() - INTERVAL '1 minute' * el_current.timeout_minutes
 			 )
-			 AND EXISTS (
+			 AND (EXISTS (
 				SELECT 1 FROM escalation_levels el_next
 				WHERE el_next.policy_id = i.escalation_policy_id
 				AND el_next.level_number = i.current_escalation_level + 1
-			 ))
+			 ) OR EXISTS (
+				SELECT 1 FROM escalation_policies ep
+				WHERE ep.id = i.escalation_policy_id
+				AND i.escalation_repeat_count + 1 < ep.repeat_max_times
+			 )))
 		)
 		ORDER BY i.created_at ASC
 		LIMIT 50
@@ -220,393 +447,21 @@ func (w *IncidentWorker) getIncidentsNeedingEscalation() ([]db.Incident, error)
 	return incidents, nil
 }
 
-// processIncidentEscalation handles escalation for a single incident
+// processIncidentEscalation escalates an incident that stayed triggered past its current level's timeout.
+// Uses the same path as a manual escalation so both record and notify the same way.
 func (w *IncidentWorker) processIncidentEscalation(incident db.Incident) {
-	log.Printf("DEBUG: Starting escalation for incident %s (current level %d, status: %s, policy: %s)",
+	log.Printf("DEBUG: Starting timeout escalation for incident %s (current level %d, status: %s, policy: %s)",
 		incident.ID, incident.CurrentEscalationLevel, incident.EscalationStatus, incident.EscalationPolicyID)
-	log.Printf("DEBUG: Escalation state - Level %d means: %s",
-		incident.CurrentEscalationLevel,
-		func() string {
-			if incident.CurrentEscalationLevel == 0 {
-				return "not yet escalated"
-			}
-			return fmt.Sprintf("escalated to policy level %d", incident.CurrentEscalationLevel)
-		}())
-
-	// Get escalation policy details
-	escalationLevels, err := w.getEscalationLevels(incident.EscalationPolicyID)
-	if err != nil {
-		log.Printf("Worker: failed to get escalation levels for incident %s: %v", incident.ID, err)
-		return
-	}
-
-	log.Printf("DEBUG: Found %d escalation levels for policy %s", len(escalationLevels), incident.EscalationPolicyID)
-	for i, level := range escalationLevels {
-		log.Printf("DEBUG: Level %d - Number: %d, Type: %s, Target: %s", i+1, level.LevelNumber, level.TargetType, level.TargetID)
-	}
-
-	if len(escalationLevels) == 0 {
-		log.Printf("Worker: no escalation levels found for incident %s", incident.ID)
-		w.updateIncidentEscalation(incident.ID, 0, "completed")
-		return
-	}
-
-	// Determine next escalation level
-	// current_escalation_level: 0 = not escalated, 1 = level 1, 2 = level 2, etc.
-	nextLevel := incident.CurrentEscalationLevel + 1
-	log.Printf("DEBUG: Next escalation level should be %d (current: %d)", nextLevel, incident.CurrentEscalationLevel)
-
-	if nextLevel > len(escalationLevels) {
-		log.Printf("Worker: incident %s has reached maximum escalation level (next: %d, max: %d)",
-			incident.ID, nextLevel, len(escalationLevels))
-		w.updateIncidentEscalation(incident.ID, incident.CurrentEscalationLevel, "completed")
-		return
-	}
-
-	// Get the escalation level to process
-	var targetLevel db.EscalationLevel
-	for _, level := range escalationLevels {
-		if level.LevelNumber == nextLevel {
-			targetLevel = level
-			break
-		}
-	}
-
-	if targetLevel.ID == "" {
-		log.Printf("Worker: escalation level %d not found for incident %s (available levels: %v)",
-			nextLevel, incident.ID, func() []int {
-				var levels []int
-				for _, l := range escalationLevels {
-					levels = append(levels, l.LevelNumber)
-				}
-				return levels
-			}())
-		w.updateIncidentEscalation(incident.ID, incident.CurrentEscalationLevel, "completed")
-		return
-	}
-
-	log.Printf("DEBUG: Found target level %d - Type: %s, Target: %s",
-		targetLevel.LevelNumber, targetLevel.TargetType, targetLevel.TargetID)
 
-	// Process escalation based on target type
-	success := w.processEscalationTarget(incident, targetLevel)
-
-	// Update incident escalation status
-	if success {
-		// Log escalation event
-		eventData := map[string]interface{}{
-			"escalation_level": nextLevel,
-			"target_type":      targetLevel.TargetType,
-			"target_id":        targetLevel.TargetID,
-			"reason":           "escalation_policy",
-		}
-
-		// Get assignee info for the event
-		if assigneeID, err := w.getIncidentAssignee(incident.ID); err == nil && assigneeID != "" {
-			if assigneeName, err := w.getUserName(assigneeID); err == nil {
-				eventData["assigned_to"] = assigneeName
-				eventData["assigned_to_id"] = assigneeID
-			}
-		}
-
-		err := w.createIncidentEvent(incident.ID, "escalated", eventData, "system")
-		if err != nil {
-			log.Printf("Worker: failed to log escalation event: %v", err)
-		}
-
-		// Check if there are more levels to escalate after this one
-		// We need to check if there's a level after nextLevel (i.e., nextLevel + 1)
-		hasMoreLevels := false
-		for _, level := range escalationLevels {
-			if level.LevelNumber == nextLevel+1 {
-				hasMoreLevels = true
-				break
-			}
-		}
-
-		if hasMoreLevels {
-			// Set to pending for next escalation level
-			w.updateIncidentEscalation(incident.ID, nextLevel, "pending")
-			log.Printf("Worker: successfully escalated incident %s to level %d, ready for next level", incident.ID, nextLevel)
-		} else {
-			// This was the last level, update to final level and mark as completed
-			w.updateIncidentEscalation(incident.ID, nextLevel, "completed")
-
-			// Create escalation completion event
-			w.createEscalationCompletionEvent(incident.ID, nextLevel)
-
-			log.Printf("Worker: successfully escalated incident %s to final level %d", incident.ID, nextLevel)
-		}
-	} else {
-		log.Printf("Worker: failed to escalate incident %s to level %d", incident.ID, nextLevel)
-		// Keep status as 'pending' to allow retry later (no need to update since FOR UPDATE SKIP LOCKED handles concurrency)
-	}
-}
-
-// getEscalationLevels retrieves escalation levels for a policy
-func (w *IncidentWorker) getEscalationLevels(policyID string) ([]db.EscalationLevel, error) {
-	query := `
-		SELECT id, policy_id, level_number, target_type, target_id, timeout_minutes
-		FROM escalation_levels
-		WHERE policy_id = $1
-		ORDER BY level_number ASC
-	`
-
-	rows, err := w.PG.Query(query, policyID)
+	result, err := w.IncidentService.EscalateIncidentOnTimeout(incident.ID)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var levels []db.EscalationLevel
-	for rows.Next() {
-		var level db.EscalationLevel
-		err := rows.Scan(
-			&level.ID, &level.PolicyID, &level.LevelNumber,
-			&level.TargetType, &level.TargetID, &level.TimeoutMinutes,
-		)
-		if err != nil {
-			log.Printf("Worker: error scanning escalation level: %v", err)
-			continue
-		}
-		levels = append(levels, level)
-	}
-
-	return levels, nil
-}
-
-// processEscalationTarget handles escalation to a specific target
-func (w *IncidentWorker) processEscalationTarget(incident db.Incident, level db.EscalationLevel) bool {
-	switch level.TargetType {
-	case "user":
-		return w.escalateToUser(incident, level.TargetID)
-	case "scheduler":
-		return w.escalateToScheduler(incident, level.TargetID)
-	case "current_schedule":
-		// current_schedule uses the incident's group to find on-call user
-		return w.escalateToGroup(incident, incident.GroupID)
-	case "group":
-		return w.escalateToGroup(incident, level.TargetID)
-	case "external":
-		return w.escalateToExternal(incident, level.TargetID)
-	default:
-		log.Printf("Worker: unknown escalation target type: %s", level.TargetType)
-		return false
-	}
-}
-
-// escalateToUser assigns incident to a specific user
-func (w *IncidentWorker) escalateToUser(incident db.Incident, userID string) bool {
-	// Assign without sending assignment notification (we'll send escalation notification instead)
-	success := w.escalateToUserWithNotification(incident, userID, false)
-	if success && w.NotificationWorker != nil {
-		// Send escalation notification instead of assignment notification
-		if err := w.NotificationWorker.SendIncidentEscalatedNotification(userID, incident.ID); err != nil {
-			log.Printf("Failed to send incident escalation notification: %v", err)
-		} else {
-			log.Printf("  Sent incident escalation notification to user %s", userID)
-		}
-	}
-
-	return success
-}
-
-// escalateToUserWithNotification assigns incident to a specific user with optional notification
-func (w *IncidentWorker) escalateToUserWithNotification(incident db.Incident, userID string, sendNotification bool) bool {
-	log.Printf("DEBUG: Assigning incident %s to user %s (sendNotification: %v)", incident.ID, userID, sendNotification)
-
-	query := `
-		UPDATE incidents
-		SET assigned_to = $1
-		WHERE id = $2
-	`
-
-	result, err := w.PG.Exec(query, userID, incident.ID)
-	if err != nil {
-		log.Printf("Worker: failed to assign incident %s to user %s: %v", incident.ID, userID, err)
-		return false
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		log.Printf("DEBUG: Failed to get rows affected: %v", err)
-	} else {
-		log.Printf("DEBUG: Assignment query affected %d rows", rowsAffected)
-	}
-
-	log.Printf("Worker: assigned incident %s to user %s", incident.ID, userID)
-
-	// Send notification to user via PGMQ only if requested
-	if sendNotification && w.NotificationWorker != nil {
-		if err := w.NotificationWorker.SendIncidentAssignedNotification(userID, incident.ID); err != nil {
-			log.Printf("Failed to send incident assignment notification: %v", err)
-			// Don't fail the assignment if notification fails
-		} else {
-			log.Printf("  Sent incident assignment notification to user %s", userID)
-		}
-	} else if sendNotification {
-		log.Printf("NotificationWorker not available, skipping notification")
-	} else {
-		log.Printf("Skipping assignment notification (escalation context)")
-	}
-
-	return true
-}
-
-// escalateToScheduler finds current on-call user in scheduler and assigns
-// This uses the effective_shifts view which automatically handles schedule overrides
-func (w *IncidentWorker) escalateToScheduler(incident db.Incident, schedulerID string) bool {
-	log.Printf("DEBUG: Escalating to scheduler %s for incident %s (policy: %s, group: %s)",
-		schedulerID, incident.ID, incident.EscalationPolicyID, incident.GroupID)
-
-	// Find current on-call user using effective_shifts view
-	query := `
-		SELECT effective_user_id
-		FROM effective_shifts
-		WHERE scheduler_id = $1
-		AND group_id = $2
-		AND start_time <= NOW()
-		AND end_time >= NOW()
-		ORDER BY start_time ASC
-		LIMIT 1
-	`
-
-	var userID string
-	err := w.PG.QueryRow(query, schedulerID, incident.GroupID).Scan(&userID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Printf("Worker: no on-call user found for scheduler %s", schedulerID)
-			return false
-		}
-		log.Printf("Worker: failed to get on-call user for scheduler %s: %v", schedulerID, err)
-		return false
-	}
-
-	log.Printf("DEBUG: Found on-call user (effective) %s for scheduler %s", userID, schedulerID)
-
-	// Assign without sending assignment notification (we'll send escalation notification instead)
-	success := w.escalateToUserWithNotification(incident, userID, false)
-	if success && w.NotificationWorker != nil {
-		// Send escalation notification instead of assignment notification
-		if err := w.NotificationWorker.SendIncidentEscalatedNotification(userID, incident.ID); err != nil {
-			log.Printf("Failed to send incident escalation notification: %v", err)
-		} else {
-			log.Printf("  Sent incident escalation notification to user %s", userID)
-		}
-	}
-
-	return success
-}
-
-// escalateToGroup assigns to current on-call user in group
-// This uses the effective_shifts view which automatically handles schedule overrides
-func (w *IncidentWorker) escalateToGroup(incident db.Incident, groupID string) bool {
-	// Find current on-call user using effective_shifts view
-	query := `
-		SELECT effective_user_id
-		FROM effective_shifts
-		WHERE group_id = $1
-		AND start_time <= NOW()
-		AND end_time >= NOW()
-		ORDER BY start_time ASC
-		LIMIT 1
-	`
-
-	var userID string
-	err := w.PG.QueryRow(query, groupID).Scan(&userID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Printf("Worker: no on-call user found for group %s", groupID)
-			return false
-		}
-		log.Printf("Worker: failed to get on-call user for group %s: %v", groupID, err)
-		return false
-	}
-
-	// Assign without sending assignment notification (we'll send escalation notification instead)
-	success := w.escalateToUserWithNotification(incident, userID, false)
-	if success && w.NotificationWorker != nil {
-		// Send escalation notification instead of assignment notification
-		if err := w.NotificationWorker.SendIncidentEscalatedNotification(userID, incident.ID); err != nil {
-			log.Printf("Failed to send incident escalation notification: %v", err)
-		} else {
-			log.Printf("  Sent incident escalation notification to user %s", userID)
-		}
-	}
-
-	return success
-}
-
-// escalateToExternal handles external escalation (webhooks, etc.)
-func (w *IncidentWorker) escalateToExternal(incident db.Incident, targetID string) bool {
-	log.Printf("Worker: external escalation for incident %s to target %s", incident.ID, targetID)
-	// TODO: Implement external escalation (webhooks, API calls, etc.)
-	return true
-}
-
-// updateIncidentEscalation updates incident escalation status
-func (w *IncidentWorker) updateIncidentEscalation(incidentID string, level int, status string) {
-	log.Printf("DEBUG: Updating incident %s escalation - Level: %d, Status: %s", incidentID, level, status)
-
-	query := `
-		UPDATE incidents
-		SET current_escalation_level = $1,
-		    escalation_status = $2,
-		    last_escalated_at = NOW() AT TIME ZONE 'UTC'
-		WHERE id = $3
-	`
-
-	result, err := w.PG.Exec(query, level, status, incidentID)
-	if err != nil {
-		log.Printf("Worker: failed to update escalation for incident %s: %v", incidentID, err)
+		// e.g. acknowledged since it was picked up - nothing to do
+		log.Printf("Worker: did not escalate incident %s: %v", incident.ID, err)
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		log.Printf("DEBUG: Failed to get rows affected for escalation update: %v", err)
-	} else {
-		log.Printf("DEBUG: Escalation update affected %d rows", rowsAffected)
-	}
-}
-
-// createEscalationCompletionEvent creates an escalation completion event
-func (w *IncidentWorker) createEscalationCompletionEvent(incidentID string, finalLevel int) {
-	// Get current incident info to log final assignment
-	var assignedTo, assignedToName sql.NullString
-
-	query := `
-		SELECT assigned_to,
-		       COALESCE(u.name, u.email, 'Unknown') as assigned_to_name
-		FROM incidents i
-		LEFT JOIN users u ON i.assigned_to = u.id
-		WHERE i.id = $1
-	`
-
-	err := w.PG.QueryRow(query, incidentID).Scan(&assignedTo, &assignedToName)
-	if err != nil {
-		log.Printf("Worker: failed to get incident info for completion event: %v", err)
-	}
-
-	// Log escalation completion event
-	eventData := map[string]interface{}{
-		"escalation_status": "completed",
-		"final_level":       finalLevel,
-		"reason":            "escalation_policy_completed",
-	}
-
-	if assignedTo.Valid && assignedToName.Valid {
-		eventData["final_assignee"] = assignedToName.String
-		eventData["final_assignee_id"] = assignedTo.String
-	}
-
-	err = w.createIncidentEvent(incidentID, "escalation_completed", eventData, "")
-	if err != nil {
-		log.Printf("Worker: failed to log escalation completion event: %v", err)
-	}
-
-	log.Printf("Worker: created escalation completion event for incident %s (final level: %d, assigned to: %s)",
-		incidentID, finalLevel, assignedToName.String)
+	log.Printf("Worker: escalated incident %s to level %d (status: %s, assigned to: %s)",
+		incident.ID, result.NewLevel, result.EscalationStatus, result.AssignedUserID)
 }
 
 // createIncidentEvent creates an event for an incident
@@ -628,34 +483,6 @@ func (w *IncidentWorker) createIncidentEvent(incidentID, eventType string, event
 	return err
 }
 
-// getIncidentAssignee gets the current assignee of an incident
-func (w *IncidentWorker) getIncidentAssignee(incidentID string) (string, error) {
-	var assigneeID sql.NullString
-	query := `SELECT assigned_to FROM incidents WHERE id = $1`
-	err := w.PG.QueryRow(query, incidentID).Scan(&assigneeID)
-	if err != nil {
-		return "", err
-	}
-	if assigneeID.Valid {
-		return assigneeID.String, nil
-	}
-	return "", nil
-}
-
-// getUserName gets the name of a user by ID
-func (w *IncidentWorker) getUserName(userID string) (string, error) {
-	var name sql.NullString
-	query := `SELECT COALESCE(name, email, 'Unknown') FROM users WHERE id = $1`
-	err := w.PG.QueryRow(query, userID).Scan(&name)
-	if err != nil {
-		return "", err
-	}
-	if name.Valid {
-		return name.String, nil
-	}
-	return "Unknown", nil
-}
-
 // UptimeWorker handles uptime monitoring
 type UptimeWorker struct {
 	PG              *sql.DB
@@ -0,0 +1,101 @@
+package background
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/phonginreallife/inres/services"
+)
+
+// enrichmentMaxReads is how many times a queued enrichment is tried before it is dropped
+const enrichmentMaxReads = 5
+
+// EnrichmentProcessor completes an incident inserted by the async creation fast path
+type EnrichmentProcessor interface {
+	ProcessIncidentEnrichment(msg services.IncidentEnrichmentMessage) error
+}
+
+// EnrichmentWorker consumes the enrichment queue filled by IncidentService.CreateIncidentAsync
+type EnrichmentWorker struct {
+	PG        *sql.DB
+	Processor EnrichmentProcessor
+	BatchSize int
+}
+
+func NewEnrichmentWorker(pg *sql.DB, processor EnrichmentProcessor) *EnrichmentWorker {
+	return &EnrichmentWorker{
+		PG:        pg,
+		Processor: processor,
+		BatchSize: 20,
+	}
+}
+
+// StartEnrichmentWorker starts the worker to enrich queued incidents
+func (w *EnrichmentWorker) StartEnrichmentWorker() {
+	log.Println("Enrichment worker started, processing queued incidents...")
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for w.processEnrichmentQueue() == w.BatchSize {
+		}
+	}
+}
+
+// processEnrichmentQueue processes one batch of queued incidents and returns how many were read
+func (w *EnrichmentWorker) processEnrichmentQueue() int {
+	// Messages stay invisible for 60 seconds; a failed one is retried when that expires
+	rows, err := w.PG.Query(`SELECT msg_id, read_ct, message FROM pgmq.read($1, 60, $2)`, services.IncidentEnrichmentQueueName, w.BatchSize)
+	if err != nil {
+		log.Printf("Worker: failed to read from queue %s: %v", services.IncidentEnrichmentQueueName, err)
+		return 0
+	}
+
+	type queuedEnrichment struct {
+		msgID  int64
+		readCT int
+		raw    []byte
+	}
+	var batch []queuedEnrichment
+	for rows.Next() {
+		var m queuedEnrichment
+		if err := rows.Scan(&m.msgID, &m.readCT, &m.raw); err != nil {
+			log.Printf("Worker: failed to scan message from queue %s: %v", services.IncidentEnrichmentQueueName, err)
+			continue
+		}
+		batch = append(batch, m)
+	}
+	rows.Close()
+
+	for _, m := range batch {
+		var msg services.IncidentEnrichmentMessage
+		if err := json.Unmarshal(m.raw, &msg); err != nil {
+			log.Printf("Worker: dropping unreadable enrichment message %d: %v", m.msgID, err)
+			w.deleteMessage(m.msgID)
+			continue
+		}
+
+		if err := w.Processor.ProcessIncidentEnrichment(msg); err != nil {
+			if m.readCT >= enrichmentMaxReads {
+				log.Printf("Worker: giving up on enriching incident %s after %d attempts: %v", msg.Incident.ID, m.readCT, err)
+				w.deleteMessage(m.msgID)
+			} else {
+				log.Printf("Worker: enriching incident %s failed (attempt %d), will retry: %v", msg.Incident.ID, m.readCT, err)
+			}
+			continue
+		}
+		w.deleteMessage(m.msgID)
+	}
+
+	return len(batch)
+}
+
+// deleteMessage removes an enrichment message from the queue
+func (w *EnrichmentWorker) deleteMessage(msgID int64) {
+	if _, err := w.PG.Exec(`SELECT pgmq.delete($1, $2::bigint)`, services.IncidentEnrichmentQueueName, msgID); err != nil {
+		log.Printf("Worker: failed to delete message %d from queue %s: %v", msgID, services.IncidentEnrichmentQueueName, err)
+	}
+}
@@ -21,7 +21,7 @@ type NotificationWorker struct {
 type NotificationMessage struct {
 	UserID      string                 `json:"user_id"`
 	IncidentID  string                 `json:"incident_id"`
-	Type        string                 `json:"type"`           // "assigned", "escalated", "resolved", "acknowledged"
+	Type        string                 `json:"type"`           // "assigned", "escalated", "resolved", "acknowledged", "watcher_update"
 	Priority    string                 `json:"priority"`       // "high", "medium", "low"
 	Channels    []string               `json:"channels"`       // ["slack", "email", "push"]
 	Data        map[string]interface{} `json:"data,omitempty"` // Additional context data
@@ -143,13 +143,14 @@ func (w *NotificationWorker) SendIncidentAssignedNotification(userID, incidentID
 }
 
 // SendIncidentEscalatedNotification is a helper to send incident escalation notifications
-func (w *NotificationWorker) SendIncidentEscalatedNotification(userID, incidentID string) error {
+func (w *NotificationWorker) SendIncidentEscalatedNotification(userID, incidentID, text string) error {
 	message := &NotificationMessage{
 		UserID:     userID,
 		IncidentID: incidentID,
 		Type:       "escalated",
 		Priority:   "high",
 		Channels:   []string{"slack", "push"},
+		Data:       map[string]interface{}{"message": text},
 		RetryCount: 0,
 		CreatedAt:  time.Now(),
 	}
@@ -187,6 +188,22 @@ func (w *NotificationWorker) SendIncidentAcknowledgedNotification(userID, incide
 	return w.sendNotificationMessage("incident_notifications", message)
 }
 
+// SendIncidentWatcherNotification is a helper to notify an incident watcher about a change
+func (w *NotificationWorker) SendIncidentWatcherNotification(userID, incidentID, change string) error {
+	message := &NotificationMessage{
+		UserID:     userID,
+		IncidentID: incidentID,
+		Type:       "watcher_update",
+		Priority:   "medium",
+		Channels:   []string{"slack", "push"},
+		Data:       map[string]interface{}{"change": change},
+		RetryCount: 0,
+		CreatedAt:  time.Now(),
+	}
+
+	return w.sendNotificationMessage("incident_notifications", message)
+}
+
 // GetQueueStats returns statistics about notification queues
 func (w *NotificationWorker) GetQueueStats() (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
@@ -340,7 +357,7 @@ func (w *NotificationWorker) acknowledgeIncidentAPI(incidentID, userID string) b
 	log.Printf("Calling API to acknowledge incident %s by user %s", incidentID, userID)
 
 	// Simulate API call (replace with actual implementation)
-	query := `UPDATE incidents SET status = 'acknowledged', acknowledged_by = $1, acknowledged_at = NOW() WHERE id = $2`
+	query := `UPDATE incidents SET status = 'acknowledged', acknowledged_by = $1, acknowledged_at = ` + services.SQLNowUTC + ` WHERE id = $2`
 	_, err := w.PG.Exec(query, userID, incidentID)
 
 	if err != nil {
@@ -0,0 +1,126 @@
+package background
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/phonginreallife/inres/services"
+)
+
+// webhookMaxReads is how many times a queued webhook is tried before it is archived unprocessed
+const webhookMaxReads = 5
+
+// WebhookProcessor routes a queued webhook payload to incidents
+type WebhookProcessor interface {
+	ProcessQueuedWebhook(msg services.WebhookQueueMessage) error
+}
+
+// WebhookWorker consumes the webhook queue filled by the webhook endpoint. Processed messages
+// are archived rather than deleted, so payloads stay in pgmq.a_webhook_events for replay.
+type WebhookWorker struct {
+	PG                 *sql.DB
+	IntegrationService *services.IntegrationService
+	Processor          WebhookProcessor
+	BatchSize          int
+
+	// EventRetentionDays deletes recorded webhook events older than this, hourly (0 disables)
+	EventRetentionDays int
+	lastPruneRun       time.Time
+}
+
+func NewWebhookWorker(pg *sql.DB, processor WebhookProcessor) *WebhookWorker {
+	return &WebhookWorker{
+		PG:                 pg,
+		IntegrationService: services.NewIntegrationService(pg),
+		Processor:          processor,
+		BatchSize:          20,
+	}
+}
+
+// StartWebhookWorker starts the worker to process queued webhooks
+func (w *WebhookWorker) StartWebhookWorker() {
+	log.Println("Webhook worker started, processing queued webhooks...")
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		// Drain the queue during alert storms instead of waiting a tick per batch
+		for w.processWebhookQueue() == w.BatchSize {
+		}
+		w.pruneWebhookEvents()
+	}
+}
+
+// pruneWebhookEvents deletes webhook events older than EventRetentionDays, at most hourly
+func (w *WebhookWorker) pruneWebhookEvents() {
+	if w.EventRetentionDays <= 0 || time.Since(w.lastPruneRun) < time.Hour {
+		return
+	}
+	w.lastPruneRun = time.Now()
+
+	pruned, err := w.IntegrationService.PruneWebhookEvents(w.EventRetentionDays)
+	if err != nil {
+		log.Printf("Worker: failed to prune webhook events: %v", err)
+	}
+	if pruned > 0 {
+		log.Printf("Worker: pruned %d webhook events older than %d days", pruned, w.EventRetentionDays)
+	}
+}
+
+// processWebhookQueue processes one batch of queued webhooks and returns how many were read
+func (w *WebhookWorker) processWebhookQueue() int {
+	// Messages stay invisible for 60 seconds; a failed one is retried when that expires
+	rows, err := w.PG.Query(`SELECT msg_id, read_ct, message FROM pgmq.read($1, 60, $2)`, services.WebhookQueueName, w.BatchSize)
+	if err != nil {
+		log.Printf("Worker: failed to read from queue %s: %v", services.WebhookQueueName, err)
+		return 0
+	}
+
+	type queuedWebhook struct {
+		msgID  int64
+		readCT int
+		raw    []byte
+	}
+	var batch []queuedWebhook
+	for rows.Next() {
+		var m queuedWebhook
+		if err := rows.Scan(&m.msgID, &m.readCT, &m.raw); err != nil {
+			log.Printf("Worker: failed to scan message from queue %s: %v", services.WebhookQueueName, err)
+			continue
+		}
+		batch = append(batch, m)
+	}
+	rows.Close()
+
+	for _, m := range batch {
+		var msg services.WebhookQueueMessage
+		if err := json.Unmarshal(m.raw, &msg); err != nil {
+			log.Printf("Worker: archiving unreadable webhook message %d: %v", m.msgID, err)
+			w.archiveMessage(m.msgID)
+			continue
+		}
+
+		if err := w.Processor.ProcessQueuedWebhook(msg); err != nil {
+			if m.readCT >= webhookMaxReads {
+				log.Printf("Worker: giving up on webhook message %d for integration %s after %d attempts: %v", m.msgID, msg.IntegrationID, m.readCT, err)
+				w.archiveMessage(m.msgID)
+			} else {
+				log.Printf("Worker: webhook message %d for integration %s failed (attempt %d), will retry: %v", m.msgID, msg.IntegrationID, m.readCT, err)
+			}
+			continue
+		}
+		w.archiveMessage(m.msgID)
+	}
+
+	return len(batch)
+}
+
+// archiveMessage moves a webhook message to the queue's archive table
+func (w *WebhookWorker) archiveMessage(msgID int64) {
+	if _, err := w.PG.Exec(`SELECT pgmq.archive($1, $2::bigint)`, services.WebhookQueueName, msgID); err != nil {
+		log.Printf("Worker: failed to archive message %d from queue %s: %v", msgID, services.WebhookQueueName, err)
+	}
+}
@@ -18,6 +18,46 @@ type Config struct {
 	BackendURL        string `mapstructure:"backend_url"`
 	WebhookAPIBaseURL string `mapstructure:"webhook_api_base_url"`
 
+	// WebhookAsyncIncidents makes webhooks insert a minimal incident and resolve
+	// service/assignee in the background, keeping webhook responses fast under load
+	WebhookAsyncIncidents bool `mapstructure:"webhook_async_incidents"`
+
+	// DedupWindowMinutes re-opens an incident resolved within this many minutes when
+	// its alert fires again, instead of creating a new one (0 disables)
+	DedupWindowMinutes int `mapstructure:"dedup_window_minutes"`
+
+	// AssignmentNotificationDelaySeconds holds the assignment page for new incidents this
+	// long and drops it if the incident resolves meanwhile (0 pages immediately)
+	AssignmentNotificationDelaySeconds int `mapstructure:"assignment_notification_delay_seconds"`
+
+	// ArchiveResolvedAfterDays archives incidents resolved more than this many days ago,
+	// hiding them from default incident lists (0 disables)
+	ArchiveResolvedAfterDays int `mapstructure:"archive_resolved_after_days"`
+
+	// SeverityAutoUpgrade bumps the severity of incidents left unresolved, as comma-separated
+	// "from:to:minutes" rules, e.g. "warning:high:240,high:critical:60" (empty disables)
+	SeverityAutoUpgrade string `mapstructure:"severity_auto_upgrade"`
+
+	// WebhookEventRetentionDays deletes raw webhook payloads kept for audit and replay
+	// after this many days (0 keeps them forever)
+	WebhookEventRetentionDays int `mapstructure:"webhook_event_retention_days"`
+
+	// WebhookRateLimitPerMinute is the webhooks per minute an integration may send before
+	// they're rejected with 429, unless its config sets rate_limit_per_minute (0 disables)
+	WebhookRateLimitPerMinute int `mapstructure:"webhook_rate_limit_per_minute"`
+
+	// IncidentDailyQuota is the incidents alerts may create per organization per UTC day;
+	// past it they are counted on one "quota exceeded" incident instead (0 disables)
+	IncidentDailyQuota int `mapstructure:"incident_daily_quota"`
+
+	// IncidentClusterWindowMinutes groups an alert incident with the open incidents of the
+	// last this many minutes whose labels are highly similar under a cluster incident (0 disables)
+	IncidentClusterWindowMinutes int `mapstructure:"incident_cluster_window_minutes"`
+
+	// EscalationWebhookSecret signs the webhooks sent to external escalation targets with an
+	// X-InRes-Signature HMAC-SHA256 of the body (empty sends them unsigned)
+	EscalationWebhookSecret string `mapstructure:"escalation_webhook_secret"`
+
 	// Data storage
 	DataDir string `mapstructure:"data_dir"`
 
@@ -90,6 +130,8 @@ func LoadConfig(path string) error {
 	v.SetEnvPrefix("inres") // Legacy support
 	v.SetDefault("backend_url", "http://localhost:8080")
 	v.SetDefault("data_dir", "./data")
+	v.SetDefault("webhook_event_retention_days", 30)
+	v.SetDefault("webhook_rate_limit_per_minute", 600)
 
 	// Bind standard environment variables (Docker/deploy compatibility)
 	// This allows using standard keys like DATABASE_URL instead of inres_DATABASE_URL
@@ -115,6 +157,16 @@ func LoadConfig(path string) error {
 	_ = v.BindEnv("notification_gateway.api_token", "inres_CLOUD_TOKEN")
 	_ = v.BindEnv("notification_gateway.instance_id", "inres_INSTANCE_ID")
 	_ = v.BindEnv("webhook_api_base_url", "WEBHOOK_API_BASE_URL")
+	_ = v.BindEnv("webhook_async_incidents", "WEBHOOK_ASYNC_INCIDENTS")
+	_ = v.BindEnv("dedup_window_minutes", "DEDUP_WINDOW_MINUTES")
+	_ = v.BindEnv("assignment_notification_delay_seconds", "ASSIGNMENT_NOTIFICATION_DELAY_SECONDS")
+	_ = v.BindEnv("archive_resolved_after_days", "ARCHIVE_RESOLVED_AFTER_DAYS")
+	_ = v.BindEnv("severity_auto_upgrade", "SEVERITY_AUTO_UPGRADE")
+	_ = v.BindEnv("webhook_event_retention_days", "WEBHOOK_EVENT_RETENTION_DAYS")
+	_ = v.BindEnv("webhook_rate_limit_per_minute", "WEBHOOK_RATE_LIMIT_PER_MINUTE")
+	_ = v.BindEnv("incident_daily_quota", "INCIDENT_DAILY_QUOTA")
+	_ = v.BindEnv("incident_cluster_window_minutes", "INCIDENT_CLUSTER_WINDOW_MINUTES")
+	_ = v.BindEnv("escalation_webhook_secret", "ESCALATION_WEBHOOK_SECRET")
 
 	// Bind AI Incident Analytics Env Vars
 	_ = v.BindEnv("ai_incident_analytics.enabled", "AI_PILOT_ENABLED")
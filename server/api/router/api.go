@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
@@ -39,6 +40,10 @@ func NewGinRouter(pg *sql.DB, redis *redis.Client) *gin.Engine {
 	slackService, _ := services.NewSlackService(pg)
 	alertService := services.NewAlertService(pg, redis, fcmService)
 	incidentService := services.NewIncidentService(pg, redis, fcmService) // NEW: Incident service
+	incidentService.AssignmentNotificationDelay = time.Duration(config.App.AssignmentNotificationDelaySeconds) * time.Second
+	incidentService.PublicURL = config.App.PublicURL
+	incidentService.DailyIncidentQuota = config.App.IncidentDailyQuota
+	incidentService.ClusterWindow = time.Duration(config.App.IncidentClusterWindowMinutes) * time.Minute
 
 	// Create lightweight notification sender for API server
 	notificationSender := services.NewLightweightNotificationSender(pg)
@@ -53,16 +58,21 @@ func NewGinRouter(pg *sql.DB, redis *redis.Client) *gin.Engine {
 	apiKeyService := services.NewAPIKeyService(pg)
 	groupService := services.NewGroupService(pg)
 	escalationService := services.NewEscalationService(pg, redis, groupService, fcmService)
+	escalationService.WebhookSecret = config.App.EscalationWebhookSecret
 	onCallService := services.NewOnCallService(pg)
 	rotationService := services.NewRotationService(pg)
 	schedulerService := services.NewSchedulerService(pg)                                  // NEW: Service scheduling
 	serviceService := services.NewServiceService(pg)                                      // NEW: Service management
+	deployEventService := services.NewDeployEventService(pg)                              // Deploy events for incident correlation
 	integrationService := services.NewIntegrationService(pg)                              // NEW: Integration management
 	identityService, err := services.NewIdentityServiceWithDB(config.App.DataDir, pg, "") // Initialize IdentityService with DB for K8s persistence
 	if err != nil {
 		log.Printf("Warning: Failed to initialize identity service: %v", err)
 	}
 
+	// Webhook rate limits are shared through Redis across API replicas
+	integrationService.RateLimiter = services.NewWebhookRateLimiter(redis, config.App.WebhookRateLimitPerMinute)
+
 	// Initialize cloud relay and auto-register with cloud if configured
 	cloudRelayService := services.NewCloudRelayService(identityService)
 	if cloudRelayService.IsConfigured() {
@@ -95,7 +105,7 @@ func NewGinRouter(pg *sql.DB, redis *redis.Client) *gin.Engine {
 	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService, alertService, userService)
 	dashboardHandler := handlers.NewDashboardHandler(userService)
 	// testHandler := handlers.NewTestHandler(alertManagerHandler)
-	groupHandler := handlers.NewGroupHandler(groupService, escalationService)
+	groupHandler := handlers.NewGroupHandler(groupService, escalationService, incidentService)
 	onCallHandler := handlers.NewOnCallHandler(onCallService, schedulerService)
 	rotationHandler := handlers.NewRotationHandler(rotationService)
 	overrideHandler := handlers.NewOverrideHandler(onCallService.OverrideService)
@@ -103,13 +113,16 @@ func NewGinRouter(pg *sql.DB, redis *redis.Client) *gin.Engine {
 	serviceHandler := handlers.NewServiceHandler(serviceService)                                                    // NEW: Service management
 	integrationHandler := handlers.NewIntegrationHandler(integrationService)                                        // NEW: Integration handler
 	webhookHandler := handlers.NewWebhookHandler(integrationService, alertService, incidentService, serviceService) // NEW: Webhook handler
-	notificationHandler := handlers.NewNotificationHandler(slackService)                                            // NEW: Notification handler
+	notificationHandler := handlers.NewNotificationHandler(slackService, services.NewNotificationService(pg))       // NEW: Notification handler
 	mobileHandler := handlers.NewMobileHandler(pg, identityService)                                                 // Inject IdentityService
 	identityHandler := handlers.NewIdentityHandler(identityService)                                                 // Initialize IdentityHandler
 	agentHandler := handlers.NewAgentHandler(pg, identityService)                                                   // Initialize AgentHandler for Zero-Trust
 	orgHandler := handlers.NewOrgHandler(orgService)                                                                // Organization management
+	featureFlagHandler := handlers.NewFeatureFlagHandler(incidentService.FeatureFlags)                              // Per-org feature flags
 	projectHandler := handlers.NewProjectHandler(projectService)                                                    // Project management
 	conversationShareHandler := handlers.NewConversationShareHandler(pg)                                            // Conversation sharing
+	deployEventHandler := handlers.NewDeployEventHandler(deployEventService)                                        // Deploy (change) events
+	routingHandler := handlers.NewRoutingHandler(services.NewRoutingService(pg))                                    // Alert routing tables
 
 	// Initialize monitor handlers
 	monitorHandler := monitor.NewMonitorHandler(pg)
@@ -166,6 +179,8 @@ func NewGinRouter(pg *sql.DB, redis *redis.Client) *gin.Engine {
 		apiKeyWebhookRoutes.POST("/incident", incidentHandler.WebhookCreateIncident) // NEW: PagerDuty-style incident webhook
 		apiKeyWebhookRoutes.POST("/alert", apiKeyHandler.WebhookAlert)               // Legacy
 		apiKeyWebhookRoutes.POST("/alertmanager", alertManagerHandler.ReceiveWebhook)
+		apiKeyWebhookRoutes.POST("/incidents/:id/acknowledge", incidentHandler.APIKeyAcknowledgeIncident) // Machine ack for automation
+		apiKeyWebhookRoutes.POST("/deploys", deployEventHandler.WebhookCreateDeployEvent)                 // CI/CD deploy events
 	}
 
 	// PROTECTED ENDPOINTS (require Supabase authentication)
@@ -188,11 +203,20 @@ func NewGinRouter(pg *sql.DB, redis *redis.Client) *gin.Engine {
 			{
 				orgDetailRoutes.GET("", orgHandler.GetOrg)
 				orgDetailRoutes.GET("/members", orgHandler.GetOrgMembers)
+				orgDetailRoutes.GET("/feature-flags", featureFlagHandler.GetOrgFeatureFlags)
 
 				// Update requires ActionUpdate permission
 				orgDetailRoutes.PATCH("",
 					authzMiddleware.RequirePermission(authz.ActionUpdate, authz.ResourceOrg),
 					orgHandler.UpdateOrg)
+				orgDetailRoutes.PATCH("/feature-flags",
+					authzMiddleware.RequirePermission(authz.ActionUpdate, authz.ResourceOrg),
+					featureFlagHandler.UpdateOrgFeatureFlags)
+
+				// Dry-run of the org's alert routing rules, for admins validating them
+				orgDetailRoutes.POST("/routing/test",
+					authzMiddleware.RequirePermission(authz.ActionUpdate, authz.ResourceOrg),
+					routingHandler.TestRouting)
 
 				// Delete requires ActionDelete (only owner)
 				orgDetailRoutes.DELETE("",
@@ -263,18 +287,49 @@ func NewGinRouter(pg *sql.DB, redis *redis.Client) *gin.Engine {
 		{
 			incidentRoutes.GET("", incidentHandler.ListIncidents)
 			incidentRoutes.POST("", incidentHandler.CreateIncident)
+			incidentRoutes.POST("/bulk", incidentHandler.BulkUpdateIncidents)
 			incidentRoutes.GET("/stats", incidentHandler.GetIncidentStats)
 			incidentRoutes.GET("/trends", incidentHandler.GetIncidentTrends) // NEW: Incident trends for dashboard charts
+			incidentRoutes.GET("/sla-breaches", incidentHandler.GetSLABreaches)
+			incidentRoutes.GET("/response-metrics", incidentHandler.GetResponseMetrics)
+			incidentRoutes.GET("/business-impact", incidentHandler.GetBusinessImpactReport)
+
+			// Grafana SimpleJSON datasource backed by incident trends
+			incidentRoutes.GET("/grafana", incidentHandler.GrafanaTestDatasource)
+			incidentRoutes.POST("/grafana/search", incidentHandler.GrafanaSearch)
+			incidentRoutes.POST("/grafana/query", incidentHandler.GrafanaQuery)
+
 			incidentRoutes.GET("/:id", incidentHandler.GetIncident)
 			incidentRoutes.PUT("/:id", incidentHandler.UpdateIncident)
 			incidentRoutes.POST("/:id/acknowledge", incidentHandler.AcknowledgeIncident)
 			incidentRoutes.POST("/:id/resolve", incidentHandler.ResolveIncident)
+			incidentRoutes.POST("/:id/reopen", incidentHandler.ReopenIncident)
+			incidentRoutes.POST("/:id/archive", incidentHandler.ArchiveIncident)
 			incidentRoutes.POST("/:id/assign", incidentHandler.AssignIncident)
+			incidentRoutes.GET("/:id/assignments", incidentHandler.GetAssignmentHistory)
+			incidentRoutes.POST("/:id/merge", incidentHandler.MergeIncidents)
+			incidentRoutes.POST("/:id/snooze", incidentHandler.SnoozeIncident)
+			incidentRoutes.GET("/:id/hold", incidentHandler.GetIncidentHold)
+			incidentRoutes.POST("/:id/hold", incidentHandler.HoldIncident)
+			incidentRoutes.DELETE("/:id/hold", incidentHandler.ReleaseIncidentHold)
+			incidentRoutes.POST("/:id/watchers", incidentHandler.AddIncidentWatcher)
+			incidentRoutes.DELETE("/:id/watchers/:user_id", incidentHandler.RemoveIncidentWatcher)
+			incidentRoutes.POST("/:id/attachments", incidentHandler.AddIncidentAttachment)
 			incidentRoutes.POST("/:id/escalate", incidentHandler.EscalateIncident)
+			incidentRoutes.GET("/:id/escalate/preview", incidentHandler.PreviewEscalation)
+			incidentRoutes.PUT("/:id/escalation-policy", incidentHandler.SetIncidentEscalationPolicy)
 			incidentRoutes.POST("/:id/notes", incidentHandler.AddIncidentNote)
 			incidentRoutes.GET("/:id/events", incidentHandler.GetIncidentEvents)
 		}
 
+		// DEPLOY EVENTS - correlated with incidents created shortly after
+		deployRoutes := protected.Group("/deploys")
+		deployRoutes.Use(projectScopedMiddleware.InjectProjectContext())
+		{
+			deployRoutes.GET("", deployEventHandler.ListDeployEvents)
+			deployRoutes.POST("", deployEventHandler.CreateDeployEvent)
+		}
+
 		// =====================================================================
 		// PROJECT-SCOPED INCIDENTS (Defense in Depth)
 		// =====================================================================
@@ -292,6 +347,19 @@ func NewGinRouter(pg *sql.DB, redis *redis.Client) *gin.Engine {
 				incidentHandler.CreateIncident)
 		}
 
+		// Delivery/read receipts from notification channels (Slack, FCM)
+		protected.POST("/notifications/receipts", incidentHandler.RecordNotificationReceipt)
+
+		// Queued notifications that ran out of delivery attempts
+		protected.GET("/notifications/dead-letters", notificationHandler.ListDeadLetteredNotifications)
+		protected.POST("/notifications/dead-letters/retry", notificationHandler.RetryDeadLetteredNotifications)
+
+		// =====================================================================
+		// PLATFORM ADMIN (cross-organization, audited)
+		// =====================================================================
+		// The platform admin role is checked in the service, which bypasses tenant isolation
+		protected.GET("/admin/incidents", incidentHandler.ListIncidentsAllOrgs)
+
 		// ALERTS MANAGEMENT (Legacy - for backward compatibility)
 		alertRoutes := protected.Group("/alerts")
 		{
@@ -442,6 +510,7 @@ func NewGinRouter(pg *sql.DB, redis *redis.Client) *gin.Engine {
 
 			// Group member management
 			groupRoutes.GET("/:id/members", groupHandler.GetGroupMembers)
+			groupRoutes.GET("/:id/notification-preview", groupHandler.PreviewGroupNotification)
 			groupRoutes.POST("/:id/members", groupHandler.AddGroupMember)
 			groupRoutes.POST("/:id/members/bulk", groupHandler.AddMultipleGroupMembers)
 			groupRoutes.PUT("/:id/members/:user_id", groupHandler.UpdateGroupMember)
@@ -505,11 +574,18 @@ func NewGinRouter(pg *sql.DB, redis *redis.Client) *gin.Engine {
 			// Group escalation policies
 			groupRoutes.GET("/:id/escalation-policies", groupHandler.GetGroupEscalationPolicies)
 			groupRoutes.POST("/:id/escalation-policies", groupHandler.CreateEscalationPolicy)
+			groupRoutes.POST("/:id/escalation-policies/bulk-delete", groupHandler.BulkDeleteEscalationPolicies)
 			groupRoutes.GET("/:id/escalation-policies/:policy_id", groupHandler.GetEscalationPolicy)
 			groupRoutes.GET("/:id/escalation-policies/:policy_id/detail", groupHandler.GetEscalationPolicyDetail)
 			groupRoutes.PUT("/:id/escalation-policies/:policy_id", groupHandler.UpdateEscalationPolicy)
 			groupRoutes.DELETE("/:id/escalation-policies/:policy_id", groupHandler.DeleteEscalationPolicy)
 			groupRoutes.GET("/:id/escalation-policies/:policy_id/levels", groupHandler.GetEscalationLevels)
+			groupRoutes.PUT("/:id/escalation-policies/:policy_id/levels/order", groupHandler.ReorderEscalationLevels)
+			groupRoutes.GET("/:id/escalation-policies/:policy_id/versions", groupHandler.GetEscalationPolicyVersions)
+			groupRoutes.GET("/:id/escalation-policies/:policy_id/simulate", groupHandler.SimulateEscalationPolicy)
+
+			// Spread open incidents across the current on-call users
+			groupRoutes.POST("/:id/incidents/rebalance", groupHandler.RebalanceIncidents)
 
 		}
 
@@ -547,6 +623,14 @@ func NewGinRouter(pg *sql.DB, redis *redis.Client) *gin.Engine {
 			// Integration services
 			integrationRoutes.GET("/:id/services", integrationHandler.GetIntegrationServices)
 
+			// Alerts that could not be routed
+			integrationRoutes.GET("/:id/dead-letters", webhookHandler.ListDeadLetters)
+			integrationRoutes.POST("/dead-letters/:dead_letter_id/replay", webhookHandler.ReplayDeadLetter)
+
+			// Raw webhook payloads, for audit and replay
+			integrationRoutes.GET("/:id/webhook-events", integrationHandler.GetWebhookEvents)
+			integrationRoutes.POST("/webhook-events/:event_id/replay", integrationHandler.ReplayWebhookEvent)
+
 			// Integration templates
 			integrationRoutes.GET("/templates", integrationHandler.GetIntegrationTemplates)
 		}
@@ -563,6 +647,8 @@ func NewGinRouter(pg *sql.DB, redis *redis.Client) *gin.Engine {
 		{
 			// Alert escalation history
 			escalationRoutes.GET("/alerts/:alert_id/history", groupHandler.GetAlertEscalations)
+			// Escalations paging someone right now, org-wide
+			escalationRoutes.GET("/active", groupHandler.GetActiveEscalations)
 		}
 
 		// USER GROUP UTILITIES
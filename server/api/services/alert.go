@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -313,7 +314,11 @@ func (s *AlertService) GetAlert(id string) (db.AlertResponse, error) {
 func (s *AlertService) AckAlert(id string) error {
 	now := time.Now()
 	_, err := s.PG.Exec(`UPDATE alerts SET status = 'acked', acked_at = $1, updated_at = $2 WHERE id = $3`, now, now, id)
-	return err
+	if err != nil {
+		return err
+	}
+	s.stopEscalations(id, "", db.AlertEscalationStatusAcknowledged)
+	return nil
 }
 
 func (s *AlertService) UnackAlert(id string) error {
@@ -325,7 +330,19 @@ func (s *AlertService) UnackAlert(id string) error {
 func (s *AlertService) CloseAlert(id string) error {
 	now := time.Now()
 	_, err := s.PG.Exec(`UPDATE alerts SET status = 'closed', updated_at = $1 WHERE id = $2`, now, id)
-	return err
+	if err != nil {
+		return err
+	}
+	s.stopEscalations(id, "", db.AlertEscalationStatusStopped)
+	return nil
+}
+
+// stopEscalations ends the escalation chain of an alert that was handled. A step that fires
+// anyway finds the alert handled and is dropped, so failures are only logged.
+func (s *AlertService) stopEscalations(alertID, userID, status string) {
+	if err := StopEscalations(s.PG, alertID, userID, status); err != nil {
+		log.Printf("WARNING: Alert %s: %v", alertID, err)
+	}
 }
 
 func (s *AlertService) AssignAlertToUser(alertID, userID string) error {
@@ -415,8 +432,12 @@ func (s *AlertService) AckAlertByUser(alertID, userID string) error {
 		SET status = 'acked', acked_by = $1, acked_at = $2, updated_at = $3 
 		WHERE id = $4
 	`, userID, now, now, alertID)
+	if err != nil {
+		return err
+	}
 
-	return err
+	s.stopEscalations(alertID, userID, db.AlertEscalationStatusAcknowledged)
+	return nil
 }
 
 // TriggerEscalation can be called externally to start escalation for an alert
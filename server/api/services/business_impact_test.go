@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateIncident_BusinessImpact(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	impact := `{"customers_affected":1200,"revenue_per_minute":350.5,"services":["checkout","payments"]}`
+	mockDB.ExpectQuery(`UPDATE incidents SET updated_at = .*, business_impact = \$1 WHERE id = \$2 RETURNING .* business_impact, updated_at`).
+		WithArgs(impact, "inc-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "title", "description", "status", "urgency", "priority", "severity",
+			"labels", "custom_fields", "business_impact", "updated_at",
+		}).AddRow("inc-1", "Checkout down", "", "acknowledged", "high", "P1", "critical",
+			nil, nil, []byte(impact), time.Now()))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", db.IncidentEventUpdated, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	incident, err := service.UpdateIncident("inc-1", db.UpdateIncidentRequest{
+		BusinessImpact: &db.BusinessImpact{CustomersAffected: 1200, RevenuePerMinute: 350.5, Services: []string{"checkout", "payments"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &db.BusinessImpact{CustomersAffected: 1200, RevenuePerMinute: 350.5, Services: []string{"checkout", "payments"}}, incident.BusinessImpact)
+
+	// Negative figures are rejected before anything is written
+	_, err = service.UpdateIncident("inc-1", db.UpdateIncidentRequest{BusinessImpact: &db.BusinessImpact{CustomersAffected: -1}})
+	assert.ErrorIs(t, err, ErrInvalidBusinessImpact)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetBusinessImpactReport(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`SUM\(\(business_impact->>'customers_affected'\)::bigint\).*FROM incidents\s+WHERE created_at >= NOW\(\) - \$1::interval AND organization_id = \$2 AND business_impact IS NOT NULL`).
+		WithArgs("30 days", "org-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "customers_affected", "impact_minutes", "revenue_loss"}).
+			AddRow(3, 1700, 95.0, 21000.0))
+	mockDB.ExpectQuery(`jsonb_array_elements_text\(COALESCE\(business_impact->'services', '\[\]'::jsonb\)\) AS service\s+WHERE .* AND business_impact IS NOT NULL\s+GROUP BY service`).
+		WithArgs("30 days", "org-1").
+		WillReturnRows(sqlmock.NewRows([]string{"service", "count", "customers_affected"}).
+			AddRow("checkout", 2, 1500).
+			AddRow("search", 1, 200))
+
+	service := NewIncidentService(pg, nil, nil)
+	report, err := service.GetBusinessImpactReport("org-1", "30d")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &BusinessImpactReport{
+		TimeRange:            "30d",
+		IncidentCount:        3,
+		CustomersAffected:    1700,
+		ImpactMinutes:        95,
+		EstimatedRevenueLoss: 21000,
+		ByService: []ServiceBusinessImpact{
+			{Service: "checkout", IncidentCount: 2, CustomersAffected: 1500},
+			{Service: "search", IncidentCount: 1, CustomersAffected: 200},
+		},
+	}, report)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetBusinessImpactReport_NoOrg(t *testing.T) {
+	report, err := NewIncidentService(nil, nil, nil).GetBusinessImpactReport("", "90d")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "90d", report.TimeRange)
+	assert.Empty(t, report.ByService)
+}
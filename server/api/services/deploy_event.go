@@ -0,0 +1,191 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phonginreallife/inres/db"
+)
+
+// DefaultDeployCorrelationWindow is how long after a deploy new incidents on the
+// same service are attributed to it
+const DefaultDeployCorrelationWindow = 30 * time.Minute
+
+type DeployEventService struct {
+	PG *sql.DB
+}
+
+func NewDeployEventService(pg *sql.DB) *DeployEventService {
+	return &DeployEventService{PG: pg}
+}
+
+// CreateDeployEvent records a deploy of a service.
+// Organization and project are taken from the service; a request scoped to another
+// organization is rejected as if the service didn't exist.
+func (s *DeployEventService) CreateDeployEvent(req db.CreateDeployEventRequest, createdBy string) (*db.DeployEvent, error) {
+	var serviceOrgID, serviceProjectID sql.NullString
+	err := s.PG.QueryRow(`
+		SELECT organization_id, project_id FROM services WHERE id = $1
+	`, req.ServiceID).Scan(&serviceOrgID, &serviceProjectID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("service not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	if req.OrganizationID != "" && serviceOrgID.Valid && serviceOrgID.String != req.OrganizationID {
+		return nil, fmt.Errorf("service not found")
+	}
+
+	event := &db.DeployEvent{
+		ID:             uuid.New().String(),
+		OrganizationID: serviceOrgID.String,
+		ProjectID:      req.ProjectID,
+		ServiceID:      req.ServiceID,
+		Version:        req.Version,
+		Description:    req.Description,
+		Environment:    req.Environment,
+		Source:         req.Source,
+		DeployedBy:     req.DeployedBy,
+		URL:            req.URL,
+		Metadata:       req.Metadata,
+		DeployedAt:     time.Now(),
+		CreatedAt:      time.Now(),
+		CreatedBy:      createdBy,
+	}
+	if event.OrganizationID == "" {
+		event.OrganizationID = req.OrganizationID
+	}
+	if event.ProjectID == "" {
+		event.ProjectID = serviceProjectID.String
+	}
+	if req.DeployedAt != nil {
+		event.DeployedAt = *req.DeployedAt
+	}
+	if event.Source == "" {
+		event.Source = "api"
+	}
+	if event.Metadata == nil {
+		event.Metadata = map[string]interface{}{}
+	}
+
+	metadataJSON, err := json.Marshal(event.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	_, err = s.PG.Exec(`
+		INSERT INTO deploy_events (
+			id, organization_id, project_id, service_id, version, description, environment,
+			source, deployed_by, url, metadata, deployed_at, created_at, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`, event.ID, nullIfEmpty(event.OrganizationID), nullIfEmpty(event.ProjectID), event.ServiceID,
+		event.Version, nullIfEmpty(event.Description), nullIfEmpty(event.Environment),
+		event.Source, nullIfEmpty(event.DeployedBy), nullIfEmpty(event.URL), metadataJSON,
+		event.DeployedAt, event.CreatedAt, nullIfEmpty(event.CreatedBy))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create deploy event: %w", err)
+	}
+
+	return event, nil
+}
+
+// ListDeployEvents returns recent deploys in the current organization, newest first.
+// Supported filters: current_org_id (mandatory), service_id, project_id, limit.
+func (s *DeployEventService) ListDeployEvents(filters map[string]interface{}) ([]db.DeployEvent, error) {
+	orgID, _ := filters["current_org_id"].(string)
+	if orgID == "" {
+		return []db.DeployEvent{}, nil
+	}
+
+	query := deployEventSelect + ` WHERE organization_id = $1`
+	args := []interface{}{orgID}
+	argIndex := 2
+
+	if serviceID, ok := filters["service_id"].(string); ok && serviceID != "" {
+		query += fmt.Sprintf(" AND service_id = $%d", argIndex)
+		args = append(args, serviceID)
+		argIndex++
+	}
+	if projectID, ok := filters["project_id"].(string); ok && projectID != "" {
+		query += fmt.Sprintf(" AND project_id = $%d", argIndex)
+		args = append(args, projectID)
+		argIndex++
+	}
+
+	limit := 50
+	if l, ok := filters["limit"].(int); ok && l > 0 {
+		limit = l
+	}
+	query += fmt.Sprintf(" ORDER BY deployed_at DESC LIMIT $%d", argIndex)
+	args = append(args, limit)
+
+	rows, err := s.PG.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deploy events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []db.DeployEvent{}
+	for rows.Next() {
+		event, err := scanDeployEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan deploy event: %w", err)
+		}
+		events = append(events, *event)
+	}
+
+	return events, rows.Err()
+}
+
+// FindRecentDeploy returns the latest deploy of a service within window before now,
+// or nil when there is none
+func (s *DeployEventService) FindRecentDeploy(serviceID string, window time.Duration) (*db.DeployEvent, error) {
+	row := s.PG.QueryRow(deployEventSelect+`
+		WHERE service_id = $1
+		AND deployed_at <= NOW()
+		AND deployed_at >= NOW() - make_interval(secs => $2)
+		ORDER BY deployed_at DESC
+		LIMIT 1
+	`, serviceID, window.Seconds())
+
+	event, err := scanDeployEvent(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find recent deploy: %w", err)
+	}
+	return event, nil
+}
+
+const deployEventSelect = `
+	SELECT id, COALESCE(organization_id::text, ''), COALESCE(project_id::text, ''), service_id,
+	       version, COALESCE(description, ''), COALESCE(environment, ''), COALESCE(source, ''),
+	       COALESCE(deployed_by, ''), COALESCE(url, ''), COALESCE(metadata, '{}'),
+	       deployed_at, created_at, COALESCE(created_by::text, '')
+	FROM deploy_events`
+
+type deployEventScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDeployEvent(row deployEventScanner) (*db.DeployEvent, error) {
+	var event db.DeployEvent
+	var metadataJSON []byte
+	if err := row.Scan(
+		&event.ID, &event.OrganizationID, &event.ProjectID, &event.ServiceID,
+		&event.Version, &event.Description, &event.Environment, &event.Source,
+		&event.DeployedBy, &event.URL, &metadataJSON,
+		&event.DeployedAt, &event.CreatedAt, &event.CreatedBy,
+	); err != nil {
+		return nil, err
+	}
+	if len(metadataJSON) > 0 {
+		_ = json.Unmarshal(metadataJSON, &event.Metadata)
+	}
+	return &event, nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func deployEventColumns() []string {
+	return []string{
+		"id", "organization_id", "project_id", "service_id", "version", "description",
+		"environment", "source", "deployed_by", "url", "metadata", "deployed_at", "created_at", "created_by",
+	}
+}
+
+func TestCorrelateDeploy_WithinWindow(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	deployedAt := time.Now().Add(-10 * time.Minute)
+	mockDB.ExpectQuery(`FROM deploy_events\s+WHERE service_id = \$1`).
+		WithArgs("svc-1", float64(30*60)).
+		WillReturnRows(sqlmock.NewRows(deployEventColumns()).
+			AddRow("deploy-1", "org-1", "", "svc-1", "v1.4.2", "", "prod", "github", "ci-bot", "", []byte(`{}`), deployedAt, deployedAt, ""))
+
+	service := NewIncidentService(pg, nil, nil)
+	incident := &db.Incident{ServiceID: "svc-1"}
+	service.correlateDeploy(incident)
+
+	assert.Equal(t, "deploy-1", incident.RelatedDeployID)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCorrelateDeploy_NoRecentDeploy(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// The last deploy is older than the window, so the query finds nothing
+	mockDB.ExpectQuery(`FROM deploy_events\s+WHERE service_id = \$1`).
+		WithArgs("svc-1", float64(15*60)).
+		WillReturnError(sql.ErrNoRows)
+
+	service := NewIncidentService(pg, nil, nil)
+	service.DeployCorrelationWindow = 15 * time.Minute
+	incident := &db.Incident{ServiceID: "svc-1"}
+	service.correlateDeploy(incident)
+
+	assert.Empty(t, incident.RelatedDeployID)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCorrelateDeploy_Skipped(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewIncidentService(pg, nil, nil)
+
+	// No service to correlate by
+	noService := &db.Incident{}
+	service.correlateDeploy(noService)
+	assert.Empty(t, noService.RelatedDeployID)
+
+	// Correlation disabled
+	service.DeployCorrelationWindow = 0
+	disabled := &db.Incident{ServiceID: "svc-1"}
+	service.correlateDeploy(disabled)
+	assert.Empty(t, disabled.RelatedDeployID)
+
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCreateDeployEvent_RejectsOtherOrgService(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`SELECT organization_id, project_id FROM services`).
+		WithArgs("svc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"organization_id", "project_id"}).AddRow("org-2", nil))
+
+	service := NewDeployEventService(pg)
+	_, err = service.CreateDeployEvent(db.CreateDeployEventRequest{
+		ServiceID:      "svc-1",
+		Version:        "v1.4.2",
+		OrganizationID: "org-1",
+	}, "user-1")
+
+	assert.EqualError(t, err, "service not found")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
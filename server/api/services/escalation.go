@@ -3,8 +3,10 @@ package services
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
 	"time"
 
@@ -18,14 +20,23 @@ type EscalationService struct {
 	Redis        *redis.Client
 	GroupService *GroupService
 	FCMService   *FCMService
+
+	// HTTPClient sends the webhooks of external escalation targets, signed with WebhookSecret
+	// when it is set
+	HTTPClient    *http.Client
+	WebhookSecret string
+
+	webhookBackoff time.Duration // Delay before the first webhook retry, doubled for each retry
 }
 
 func NewEscalationService(pg *sql.DB, redis *redis.Client, groupService *GroupService, fcmService *FCMService) *EscalationService {
 	return &EscalationService{
-		PG:           pg,
-		Redis:        redis,
-		GroupService: groupService,
-		FCMService:   fcmService,
+		PG:             pg,
+		Redis:          redis,
+		GroupService:   groupService,
+		FCMService:     fcmService,
+		HTTPClient:     newExternalWebhookClient(),
+		webhookBackoff: time.Second,
 	}
 }
 
@@ -33,6 +44,105 @@ func NewEscalationService(pg *sql.DB, redis *redis.Client, groupService *GroupSe
 // DATADOG-STYLE ESCALATION POLICY MANAGEMENT
 // ==========================================
 
+// defaultNotificationChannels lists the notification methods an organization can use
+// unless overridden in organizations.settings->'notification_channels'.
+// SMS and phone calls need a provider, so they stay off until an org configures one.
+var defaultNotificationChannels = map[string]bool{
+	db.NotificationMethodEmail:   true,
+	db.NotificationMethodFCM:     true,
+	"push":                       true,
+	db.NotificationMethodWebhook: true,
+	"slack":                      true,
+	db.NotificationMethodSMS:     false,
+	"phone":                      false,
+}
+
+// GetGroupNotificationChannels returns the notification channels available to the
+// organization owning a group, with defaults filled in
+func (s *EscalationService) GetGroupNotificationChannels(groupID string) (map[string]bool, error) {
+	channels := make(map[string]bool, len(defaultNotificationChannels))
+	for method, enabled := range defaultNotificationChannels {
+		channels[method] = enabled
+	}
+
+	var raw sql.NullString
+	err := s.PG.QueryRow(`
+		SELECT o.settings->'notification_channels'
+		FROM groups g
+		JOIN organizations o ON o.id = g.organization_id
+		WHERE g.id = $1
+	`, groupID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return channels, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification channels: %w", err)
+	}
+
+	if raw.Valid && raw.String != "" {
+		var stored map[string]bool
+		if err := json.Unmarshal([]byte(raw.String), &stored); err != nil {
+			log.Printf("WARNING: Invalid notification_channels for group %s, using defaults: %v", groupID, err)
+			return channels, nil
+		}
+		for method, enabled := range stored {
+			if _, known := defaultNotificationChannels[method]; known {
+				channels[method] = enabled
+			}
+		}
+	}
+
+	return channels, nil
+}
+
+// validateNotificationMethods rejects levels using a notification method the
+// organization hasn't configured, so escalations don't silently fail to notify
+func validateNotificationMethods(levels []db.EscalationLevel, channels map[string]bool) error {
+	for _, level := range levels {
+		for _, method := range level.NotificationMethods {
+			enabled, known := channels[method]
+			if !known {
+				return fmt.Errorf("%w '%s' for level %d", ErrInvalidNotificationMethod, method, level.LevelNumber)
+			}
+			if !enabled {
+				return fmt.Errorf("%w: '%s' for level %d is not configured for this organization", ErrInvalidNotificationMethod, method, level.LevelNumber)
+			}
+		}
+	}
+	return nil
+}
+
+// ErrInvalidLevelNumbers is returned for policies whose level numbers skip or repeat a step
+var ErrInvalidLevelNumbers = errors.New("invalid escalation level numbers")
+
+// validateLevelNumbers requires level numbers to run 1, 2, 3... without gaps, since escalation
+// stops at the first missing level. Several targets may share a level to be paged in parallel,
+// but the same target listed twice on one level is a duplicate.
+func validateLevelNumbers(levels []db.EscalationLevel) error {
+	targets := make(map[int]map[string]bool)
+	for _, level := range levels {
+		if level.LevelNumber < 1 {
+			return fmt.Errorf("%w: level_number %d must be 1 or greater", ErrInvalidLevelNumbers, level.LevelNumber)
+		}
+		if targets[level.LevelNumber] == nil {
+			targets[level.LevelNumber] = make(map[string]bool)
+		}
+		target := level.TargetType + ":" + level.TargetID
+		if targets[level.LevelNumber][target] {
+			return fmt.Errorf("%w: level %d lists %s target '%s' more than once", ErrInvalidLevelNumbers,
+				level.LevelNumber, level.TargetType, level.TargetID)
+		}
+		targets[level.LevelNumber][target] = true
+	}
+
+	for number := 1; number <= len(targets); number++ {
+		if targets[number] == nil {
+			return fmt.Errorf("%w: level %d is missing, levels must be numbered from 1 without gaps", ErrInvalidLevelNumbers, number)
+		}
+	}
+	return nil
+}
+
 // EscalationPolicyWithUsage extends EscalationPolicy with usage statistics
 type EscalationPolicyWithUsage struct {
 	db.EscalationPolicy
@@ -81,6 +191,20 @@ func (s *EscalationService) CreateEscalationPolicy(groupID string, req db.Escala
 		policy.RepeatMaxTimes = 1
 	}
 
+	if err := validateLevelNumbers(req.Levels); err != nil {
+		return policy, err
+	}
+	channels, err := s.GetGroupNotificationChannels(groupID)
+	if err != nil {
+		return policy, err
+	}
+	if err := validateNotificationMethods(req.Levels, channels); err != nil {
+		return policy, err
+	}
+	if err := validateExternalTargets(req.Levels); err != nil {
+		return policy, err
+	}
+
 	// Start transaction
 	tx, err := s.PG.Begin()
 	if err != nil {
@@ -138,7 +262,7 @@ func (s *EscalationService) CreateEscalationPolicy(groupID string, req db.Escala
 			level.NotificationMethods = []string{"email"}
 		}
 		if level.MessageTemplate == "" {
-			level.MessageTemplate = "Alert: {{alert.title}} requires attention"
+			level.MessageTemplate = DefaultEscalationMessageTemplate
 		}
 
 		// Serialize notification methods to JSON
@@ -175,12 +299,21 @@ func (s *EscalationService) CreateEscalationPolicy(groupID string, req db.Escala
 
 // UpdateEscalationPolicy updates an existing escalation policy with levels
 func (s *EscalationService) UpdateEscalationPolicy(policyID string, req db.EscalationPolicy) (db.EscalationPolicy, error) {
+	if err := validateLevelNumbers(req.Levels); err != nil {
+		return db.EscalationPolicy{}, err
+	}
+
 	// First, get the existing policy to preserve some fields
 	existingPolicy, err := s.GetEscalationPolicy(policyID)
 	if err != nil {
 		return db.EscalationPolicy{}, fmt.Errorf("failed to get existing policy: %w", err)
 	}
 
+	existingLevels, err := s.GetEscalationLevels(policyID)
+	if err != nil {
+		return db.EscalationPolicy{}, err
+	}
+
 	// Update policy fields
 	policy := existingPolicy
 	policy.Name = req.Name
@@ -195,6 +328,17 @@ func (s *EscalationService) UpdateEscalationPolicy(policyID string, req db.Escal
 		policy.RepeatMaxTimes = 1
 	}
 
+	channels, err := s.GetGroupNotificationChannels(policy.GroupID)
+	if err != nil {
+		return policy, err
+	}
+	if err := validateNotificationMethods(req.Levels, channels); err != nil {
+		return policy, err
+	}
+	if err := validateExternalTargets(req.Levels); err != nil {
+		return policy, err
+	}
+
 	// Start transaction
 	tx, err := s.PG.Begin()
 	if err != nil {
@@ -202,6 +346,11 @@ func (s *EscalationService) UpdateEscalationPolicy(policyID string, req db.Escal
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	// Keep the version being replaced, so past escalations can still be explained
+	if _, err := snapshotEscalationPolicyTx(tx, db.EscalationPolicyWithLevels{EscalationPolicy: existingPolicy, Levels: existingLevels}); err != nil {
+		return policy, err
+	}
+
 	// Update escalation policy
 	updateQuery := `
 		UPDATE escalation_policies 
@@ -260,7 +409,7 @@ func (s *EscalationService) UpdateEscalationPolicy(policyID string, req db.Escal
 			level.NotificationMethods = []string{"email"}
 		}
 		if level.MessageTemplate == "" {
-			level.MessageTemplate = "Alert: {{alert.title}} requires attention"
+			level.MessageTemplate = DefaultEscalationMessageTemplate
 		}
 
 		// Serialize notification methods
@@ -295,8 +444,92 @@ func (s *EscalationService) UpdateEscalationPolicy(policyID string, req db.Escal
 	return policy, nil
 }
 
-// DeleteEscalationPolicy deletes an escalation policy and all its levels
-func (s *EscalationService) DeleteEscalationPolicy(policyID string) error {
+// ErrInvalidLevelOrder is returned when a new level order doesn't list each of the policy's levels once
+var ErrInvalidLevelOrder = errors.New("invalid escalation level order")
+
+// ReorderLevels renumbers a policy's levels in place, the level with ID order[0] becoming
+// level 1 and so on, and returns them in their new order. Unlike UpdateEscalationPolicy the
+// level rows, and so their IDs, are kept. order must list every level of the policy exactly once.
+func (s *EscalationService) ReorderLevels(policyID string, order []string) ([]db.EscalationLevel, error) {
+	existingPolicy, err := s.GetEscalationPolicy(policyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing policy: %w", err)
+	}
+
+	existingLevels, err := s.GetEscalationLevels(policyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(order) != len(existingLevels) {
+		return nil, fmt.Errorf("%w: expected %d level IDs, got %d", ErrInvalidLevelOrder, len(existingLevels), len(order))
+	}
+
+	byID := make(map[string]db.EscalationLevel, len(existingLevels))
+	maxLevelNumber := 0
+	for _, level := range existingLevels {
+		byID[level.ID] = level
+		if level.LevelNumber > maxLevelNumber {
+			maxLevelNumber = level.LevelNumber
+		}
+	}
+	levels := make([]db.EscalationLevel, 0, len(order))
+	for i, id := range order {
+		level, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: level %s is not in the policy or is listed more than once", ErrInvalidLevelOrder, id)
+		}
+		delete(byID, id)
+		level.LevelNumber = i + 1
+		levels = append(levels, level)
+	}
+
+	tx, err := s.PG.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := snapshotEscalationPolicyTx(tx, db.EscalationPolicyWithLevels{EscalationPolicy: existingPolicy, Levels: existingLevels}); err != nil {
+		return nil, err
+	}
+
+	// Move every level past the current numbers first, so no renumbered row can clash with
+	// one that hasn't been renumbered yet on the unique (policy, level, target) constraint
+	if _, err := tx.Exec(`UPDATE escalation_levels SET level_number = level_number + $2 WHERE policy_id = $1`,
+		policyID, maxLevelNumber); err != nil {
+		return nil, fmt.Errorf("failed to reorder escalation levels: %w", err)
+	}
+	for _, level := range levels {
+		result, err := tx.Exec(`UPDATE escalation_levels SET level_number = $3 WHERE id = $1 AND policy_id = $2`,
+			level.ID, policyID, level.LevelNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reorder escalation levels: %w", err)
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		} else if rowsAffected == 0 {
+			// Removed by an update since the levels were read
+			return nil, fmt.Errorf("%w: level %s is not in the policy", ErrInvalidLevelOrder, level.ID)
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE escalation_policies SET updated_at = NOW() WHERE id = $1`, policyID); err != nil {
+		return nil, fmt.Errorf("failed to update escalation policy: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Printf("Successfully reordered %d levels of escalation policy %s", len(levels), policyID)
+	return levels, nil
+}
+
+// DeleteEscalationPolicy deletes one of a group's escalation policies and all its levels;
+// policies of other groups are reported as not found. A policy that services or unresolved
+// incidents still use isn't deleted, the error lists them, unless force is set; forcing
+// unlinks them from the policy in the same transaction.
+func (s *EscalationService) DeleteEscalationPolicy(groupID, policyID string, force bool) error {
 	// Start transaction
 	tx, err := s.PG.Begin()
 	if err != nil {
@@ -304,17 +537,51 @@ func (s *EscalationService) DeleteEscalationPolicy(policyID string) error {
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	// Nothing is checked or unlinked for a policy outside the group
+	var lockedID string
+	err = tx.QueryRow(`SELECT id FROM escalation_policies WHERE id = $1 AND group_id::text = $2 FOR UPDATE`,
+		policyID, groupID).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("escalation policy not found: %s", policyID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get escalation policy: %w", err)
+	}
+
+	if force {
+		if err := unlinkEscalationPolicyTx(tx, policyID); err != nil {
+			return err
+		}
+	} else if err := checkEscalationPolicyNotInUse(tx, policyID); err != nil {
+		return err
+	}
+
+	if err := deleteEscalationPolicyTx(tx, groupID, policyID); err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Printf("Successfully deleted escalation policy %s of group %s (force: %t)", policyID, groupID, force)
+	return nil
+}
+
+// deleteEscalationPolicyTx deletes a group's policy and its levels within tx
+func deleteEscalationPolicyTx(tx *sql.Tx, groupID, policyID string) error {
 	// Delete escalation levels first (due to foreign key constraint)
 	deleteLevelsQuery := `DELETE FROM escalation_levels WHERE policy_id = $1`
-	_, err = tx.Exec(deleteLevelsQuery, policyID)
+	_, err := tx.Exec(deleteLevelsQuery, policyID)
 	if err != nil {
 		log.Println("Failed to delete escalation levels:", err)
 		return fmt.Errorf("failed to delete escalation levels: %w", err)
 	}
 
 	// Delete escalation policy
-	deletePolicyQuery := `DELETE FROM escalation_policies WHERE id = $1`
-	result, err := tx.Exec(deletePolicyQuery, policyID)
+	deletePolicyQuery := `DELETE FROM escalation_policies WHERE id = $1 AND group_id::text = $2`
+	result, err := tx.Exec(deletePolicyQuery, policyID, groupID)
 	if err != nil {
 		log.Println("Failed to delete escalation policy:", err)
 		return fmt.Errorf("failed to delete escalation policy: %w", err)
@@ -328,28 +595,113 @@ func (s *EscalationService) DeleteEscalationPolicy(policyID string) error {
 	if rowsAffected == 0 {
 		return fmt.Errorf("escalation policy not found: %s", policyID)
 	}
+	return nil
+}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+// ErrEscalationPolicyInUse is returned for policies still used by services or open incidents
+var ErrEscalationPolicyInUse = errors.New("escalation policy is in use")
+
+// EscalationPolicyDeleteResult is the outcome for one policy of BulkDeleteEscalationPolicies
+type EscalationPolicyDeleteResult struct {
+	ID      string `json:"id"`
+	Deleted bool   `json:"deleted"`
+	Error   string `json:"error,omitempty"`
+}
+
+// checkEscalationPolicyNotInUse returns ErrEscalationPolicyInUse, naming the services and
+// unresolved incidents, when any still use the policy
+func checkEscalationPolicyNotInUse(tx *sql.Tx, policyID string) error {
+	rows, err := tx.Query(`
+		SELECT 'service', COALESCE(name, id::text) FROM services WHERE escalation_policy_id = $1
+		UNION ALL
+		SELECT 'incident', COALESCE(title, id::text) FROM incidents WHERE escalation_policy_id = $1 AND status <> 'resolved'
+		ORDER BY 1 DESC, 2
+	`, policyID)
+	if err != nil {
+		return fmt.Errorf("failed to check escalation policy usage: %w", err)
+	}
+	defer rows.Close()
+
+	var services, incidents []string
+	for rows.Next() {
+		var kind, name string
+		if err := rows.Scan(&kind, &name); err != nil {
+			return fmt.Errorf("failed to scan escalation policy usage: %w", err)
+		}
+		if kind == "service" {
+			services = append(services, name)
+		} else {
+			incidents = append(incidents, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to check escalation policy usage: %w", err)
+	}
+
+	var users []string
+	if len(services) > 0 {
+		users = append(users, fmt.Sprintf("%d services (%s)", len(services), strings.Join(services, ", ")))
+	}
+	if len(incidents) > 0 {
+		users = append(users, fmt.Sprintf("%d open incidents (%s)", len(incidents), strings.Join(incidents, ", ")))
 	}
+	if len(users) > 0 {
+		return fmt.Errorf("%w by %s", ErrEscalationPolicyInUse, strings.Join(users, " and "))
+	}
+	return nil
+}
 
-	log.Printf("Successfully deleted escalation policy %s", policyID)
+// unlinkEscalationPolicyTx clears the policy from the services and unresolved incidents using it,
+// so they stop escalating through it
+func unlinkEscalationPolicyTx(tx *sql.Tx, policyID string) error {
+	if _, err := tx.Exec(`UPDATE services SET escalation_policy_id = NULL WHERE escalation_policy_id = $1`, policyID); err != nil {
+		return fmt.Errorf("failed to unlink escalation policy from services: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE incidents SET escalation_policy_id = NULL WHERE escalation_policy_id = $1 AND status <> 'resolved'`, policyID); err != nil {
+		return fmt.Errorf("failed to unlink escalation policy from incidents: %w", err)
+	}
 	return nil
 }
 
+// BulkDeleteEscalationPolicies deletes several of a group's policies, each in its own
+// transaction, and reports the outcome per ID. Policies of other groups are reported as not
+// found, and policies in use are skipped unless force is set, see DeleteEscalationPolicy.
+func (s *EscalationService) BulkDeleteEscalationPolicies(groupID string, ids []string, force bool) []EscalationPolicyDeleteResult {
+	results := []EscalationPolicyDeleteResult{}
+	seen := map[string]bool{}
+
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		result := EscalationPolicyDeleteResult{ID: id}
+		if err := s.DeleteEscalationPolicy(groupID, id, force); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Deleted = true
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
 // GetEscalationPolicy retrieves a single escalation policy by ID
 func (s *EscalationService) GetEscalationPolicy(id string) (db.EscalationPolicy, error) {
 	var policy db.EscalationPolicy
 	query := `
-		SELECT id, name, description, is_active, repeat_max_times, 
-			   created_at, updated_at, COALESCE(created_by, '') as created_by
+		SELECT id, name, description, is_active, repeat_max_times, COALESCE(escalate_after_minutes, 5),
+			   created_at, updated_at, COALESCE(created_by, '') as created_by, COALESCE(group_id::text, '')
 		FROM escalation_policies 
 		WHERE id = $1`
 
 	err := s.PG.QueryRow(query, id).Scan(
 		&policy.ID, &policy.Name, &policy.Description, &policy.IsActive,
-		&policy.RepeatMaxTimes, &policy.CreatedAt, &policy.UpdatedAt, &policy.CreatedBy)
+		&policy.RepeatMaxTimes, &policy.EscalateAfterMinutes, &policy.CreatedAt, &policy.UpdatedAt,
+		&policy.CreatedBy, &policy.GroupID)
 	if err != nil {
 		return policy, fmt.Errorf("failed to get escalation policy: %w", err)
 	}
@@ -827,9 +1179,27 @@ func (s *EscalationService) GetGroupEscalationPoliciesWithFilters(filters map[st
 		args = append(args, true)
 		argIndex++
 	}
+
+	if search, ok := filters["search"].(string); ok && search != "" {
+		query += fmt.Sprintf(" AND ep.name ILIKE $%d", argIndex)
+		args = append(args, "%"+search+"%")
+		argIndex++
+	}
 	_ = argIndex // silence ineffassign
 
-	query += " ORDER BY ep.created_at DESC"
+	// Sorting: name, usage (most services first), updated; newest first by default
+	sortBy := "ep.created_at DESC"
+	if sort, ok := filters["sort"].(string); ok && sort != "" {
+		switch sort {
+		case "name":
+			sortBy = "ep.name ASC"
+		case "usage":
+			sortBy = "services_count DESC, ep.name ASC"
+		case "updated":
+			sortBy = "ep.updated_at DESC"
+		}
+	}
+	query += " ORDER BY " + sortBy
 
 	rows, err := s.PG.Query(query, args...)
 	if err != nil {
@@ -1012,9 +1382,21 @@ func (s *EscalationService) executeEscalationStep(alert *db.Alert, policy *db.Es
 
 	// Execute all targets in parallel
 	var errors []string
-	var successCount int
+	var successCount, skippedCount int
 
 	for _, target := range stepTargets {
+		// Skip users who are in DND or on vacation rather than paging them
+		if target.TargetType == "user" {
+			reason, err := UserUnavailableReason(s.PG, target.TargetID)
+			if err != nil {
+				log.Printf("Failed to check availability of user %s, paging anyway: %v", target.TargetID, err)
+			} else if reason != "" {
+				s.skipEscalationLevel(alert, policy, &target, reason)
+				skippedCount++
+				continue
+			}
+		}
+
 		err := s.executeEscalationLevel(alert, policy, &target)
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("target %s (%s): %v", target.TargetID, target.TargetType, err))
@@ -1030,18 +1412,29 @@ func (s *EscalationService) executeEscalationStep(alert *db.Alert, policy *db.Es
 		log.Printf("Some targets failed in step %d: %v", stepNumber, errors)
 	}
 
-	// Schedule next step if current step has at least one success
-	if successCount > 0 {
-		nextStepNumber := stepNumber + 1
-		// Check if next step exists
-		hasNextStep := false
-		for _, level := range policy.Levels {
-			if level.LevelNumber == nextStepNumber {
-				hasNextStep = true
-				break
-			}
+	nextStepNumber := stepNumber + 1
+	hasNextStep := false
+	for _, level := range policy.Levels {
+		if level.LevelNumber == nextStepNumber {
+			hasNextStep = true
+			break
 		}
+	}
 
+	// Nobody in this step could be paged because they are unavailable,
+	// so move on to the next step immediately instead of waiting for the timeout
+	if successCount == 0 && skippedCount > 0 {
+		if hasNextStep {
+			log.Printf("No available targets in step %d, escalating to step %d", stepNumber, nextStepNumber)
+			return s.executeEscalationStep(alert, policy, nextStepNumber)
+		}
+		if len(errors) == 0 {
+			return fmt.Errorf("no available targets in step %d", stepNumber)
+		}
+	}
+
+	// Schedule next step if current step has at least one success
+	if successCount > 0 {
 		if hasNextStep {
 			// Get timeout from first target (they should all have the same timeout for the same step)
 			timeout := stepTargets[0].GetEffectiveTimeout(policy.EscalateAfterMinutes)
@@ -1075,24 +1468,32 @@ func (s *EscalationService) executeEscalationLevel(alert *db.Alert, policy *db.E
 		UpdatedAt:          time.Now(),
 	}
 
-	// Save escalation record
-	if err := s.saveEscalation(escalation); err != nil {
+	// Claim the target before sending, so a retried step doesn't notify it twice
+	key := escalationIdempotencyKey(alert.ID, level.LevelNumber, level.TargetType, level.TargetID)
+	id, claimed, err := s.claimEscalation(escalation, key)
+	if err != nil {
 		return fmt.Errorf("failed to save escalation: %w", err)
 	}
+	if !claimed {
+		log.Printf("Escalation level %d target %s (%s) already notified for alert %s, not sending again",
+			level.LevelNumber, level.TargetID, level.TargetType, alert.Title)
+		return nil
+	}
+	escalation.ID = id
 
 	// Execute notification based on target type
-	var err error
+	var deliveries []db.EscalationDelivery
 	switch level.TargetType {
 	case "current_schedule":
-		err = s.notifyCurrentSchedule(alert, level.NotificationMethods)
+		deliveries, err = s.notifyCurrentSchedule(alert, level.NotificationMethods)
 	case "scheduler":
-		err = s.notifyScheduler(alert, level.TargetID, level.NotificationMethods)
+		deliveries, err = s.notifyScheduler(alert, level.TargetID, level.NotificationMethods)
 	case "user":
-		err = s.notifyUser(alert, level.TargetID, level.NotificationMethods)
+		deliveries, err = s.notifyUser(alert, level.TargetID, level.NotificationMethods)
 	case "group":
-		err = s.notifyGroup(alert, level.TargetID, level.NotificationMethods)
+		deliveries, err = s.notifyGroup(alert, policy, level, escalation.ID)
 	case "external":
-		err = s.notifyExternal(alert, level.TargetID, level.NotificationMethods)
+		err = s.notifyExternal(alert, policy, level)
 	default:
 		err = fmt.Errorf("unknown target type: %s", level.TargetType)
 	}
@@ -1106,7 +1507,7 @@ func (s *EscalationService) executeEscalationLevel(alert *db.Alert, policy *db.E
 		log.Printf("Escalation level %d failed: %v", level.LevelNumber, err)
 	}
 
-	if err := s.updateEscalationStatus(escalation.ID, status, errorMessage); err != nil {
+	if err := s.updateEscalationStatus(escalation.ID, status, errorMessage, deliveries); err != nil {
 		log.Printf("Failed to update escalation status: %v", err)
 	}
 
@@ -1116,14 +1517,49 @@ func (s *EscalationService) executeEscalationLevel(alert *db.Alert, policy *db.E
 	return err
 }
 
-// Helper notification methods
-func (s *EscalationService) notifyCurrentSchedule(alert *db.Alert, methods []string) error {
-	// TODO: Implement current schedule notification
-	log.Printf("Notifying current schedule for alert %s via %v", alert.Title, methods)
-	return nil
+// skipEscalationLevel records a target that was not paged because the user is unavailable
+func (s *EscalationService) skipEscalationLevel(alert *db.Alert, policy *db.EscalationPolicyWithLevels, level *db.EscalationLevel, reason string) {
+	log.Printf("Skipping escalation level %d target %s for alert %s: user is unavailable (%s)",
+		level.LevelNumber, level.TargetID, alert.Title, reason)
+
+	escalation := db.AlertEscalation{
+		ID:                 uuid.New().String(),
+		AlertID:            alert.ID,
+		EscalationPolicyID: policy.ID,
+		EscalationLevel:    level.LevelNumber,
+		TargetType:         level.TargetType,
+		TargetID:           level.TargetID,
+		Status:             "skipped",
+		ErrorMessage:       fmt.Sprintf("user unavailable: %s", reason),
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
+	}
+
+	if err := s.saveEscalation(escalation); err != nil {
+		log.Printf("Failed to save skipped escalation: %v", err)
+	}
+}
+
+// UserUnavailableReason returns why a user should not be paged right now
+// ("dnd", "vacation"), or an empty string if the user is available
+func UserUnavailableReason(pg *sql.DB, userID string) (string, error) {
+	var reason string
+	err := pg.QueryRow(`
+		SELECT COALESCE(unavailable_reason, 'unavailable')
+		FROM users
+		WHERE id = $1 AND unavailable_until IS NOT NULL AND unavailable_until > NOW()`,
+		userID).Scan(&reason)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to check user availability: %w", err)
+	}
+	return reason, nil
 }
 
-func (s *EscalationService) notifyScheduler(alert *db.Alert, schedulerID string, methods []string) error {
+// notifyScheduler notifies the users currently on shift for a scheduler of the alert's group
+func (s *EscalationService) notifyScheduler(alert *db.Alert, schedulerID string, methods []string) ([]db.EscalationDelivery, error) {
 	log.Printf("Notifying scheduler %s for alert %s via %v", schedulerID, alert.Title, methods)
 
 	// Get current shifts for this scheduler
@@ -1140,12 +1576,13 @@ func (s *EscalationService) notifyScheduler(alert *db.Alert, schedulerID string,
 
 	rows, err := s.PG.Query(query, schedulerID, alert.GroupID)
 	if err != nil {
-		return fmt.Errorf("failed to query scheduler users: %w", err)
+		return nil, fmt.Errorf("failed to query scheduler users: %w", err)
 	}
 	defer rows.Close()
 
 	var notifiedUsers []string
 	var errors []string
+	var deliveries []db.EscalationDelivery
 
 	for rows.Next() {
 		var userID, userName, userEmail string
@@ -1155,7 +1592,9 @@ func (s *EscalationService) notifyScheduler(alert *db.Alert, schedulerID string,
 		}
 
 		// Notify each user currently on shift for this scheduler
-		if err := s.notifyUser(alert, userID, methods); err != nil {
+		userDeliveries, err := s.notifyUser(alert, userID, methods)
+		deliveries = append(deliveries, userDeliveries...)
+		if err != nil {
 			errors = append(errors, fmt.Sprintf("failed to notify user %s: %v", userName, err))
 		} else {
 			notifiedUsers = append(notifiedUsers, userName)
@@ -1163,7 +1602,7 @@ func (s *EscalationService) notifyScheduler(alert *db.Alert, schedulerID string,
 	}
 
 	if len(notifiedUsers) == 0 {
-		return fmt.Errorf("no users currently on-call for scheduler %s", schedulerID)
+		return deliveries, fmt.Errorf("no users currently on-call for scheduler %s", schedulerID)
 	}
 
 	log.Printf("Successfully notified %d users for scheduler %s: %v", len(notifiedUsers), schedulerID, notifiedUsers)
@@ -1172,61 +1611,100 @@ func (s *EscalationService) notifyScheduler(alert *db.Alert, schedulerID string,
 		log.Printf("Some notifications failed: %v", errors)
 		// Return error only if ALL notifications failed
 		if len(errors) == len(notifiedUsers)+len(errors) {
-			return fmt.Errorf("all notifications failed: %v", errors)
+			return deliveries, fmt.Errorf("all notifications failed: %v", errors)
 		}
 	}
 
-	return nil
+	return deliveries, nil
 }
 
-func (s *EscalationService) notifyUser(alert *db.Alert, userID string, methods []string) error {
-	// TODO: Implement user notification
-	log.Printf("Notifying user %s for alert %s via %v", userID, alert.Title, methods)
-	return nil
-}
+// saveEscalation saves an escalation record to the database
+func (s *EscalationService) saveEscalation(escalation db.AlertEscalation) error {
+	query := `
+		INSERT INTO alert_escalations (
+			id, alert_id, escalation_policy_id, escalation_level, target_type, target_id,
+			status, error_message, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
-func (s *EscalationService) notifyGroup(alert *db.Alert, groupID string, methods []string) error {
-	// TODO: Implement group notification
-	log.Printf("Notifying group %s for alert %s via %v", groupID, alert.Title, methods)
-	return nil
-}
+	_, err := s.PG.Exec(query,
+		escalation.ID, escalation.AlertID, escalation.EscalationPolicyID, escalation.EscalationLevel,
+		escalation.TargetType, escalation.TargetID, escalation.Status, escalation.ErrorMessage,
+		escalation.CreatedAt, escalation.UpdatedAt)
 
-func (s *EscalationService) notifyExternal(alert *db.Alert, target string, methods []string) error {
-	// TODO: Implement external notification (webhooks, etc.)
-	log.Printf("Notifying external target %s for alert %s via %v", target, alert.Title, methods)
-	return nil
+	return err
 }
 
-// scheduleNextEscalationStep schedules the next escalation step (all targets in parallel)
-func (s *EscalationService) scheduleNextEscalationStep(alert *db.Alert, policy *db.EscalationPolicyWithLevels, stepNumber int, delay time.Duration) {
-	// TODO: Implement escalation scheduling using Redis or background jobs
-	log.Printf("Scheduling next escalation step %d in %v for alert %s", stepNumber, delay, alert.Title)
+// escalationIdempotencyKey identifies the notification of one target at one escalation level
+// of an alert
+func escalationIdempotencyKey(alertID string, level int, targetType, targetID string) string {
+	return fmt.Sprintf("%s:%d:%s:%s", alertID, level, targetType, targetID)
 }
 
-// saveEscalation saves an escalation record to the database
-func (s *EscalationService) saveEscalation(escalation db.AlertEscalation) error {
+// claimEscalation saves an escalation record under its idempotency key and returns its id.
+// claimed is false when the key was already used by a record that didn't fail, i.e. the
+// target was notified or is being notified; a failed record is claimed again so retries
+// can re-send it.
+func (s *EscalationService) claimEscalation(escalation db.AlertEscalation, key string) (id string, claimed bool, err error) {
 	query := `
 		INSERT INTO alert_escalations (
 			id, alert_id, escalation_policy_id, escalation_level, target_type, target_id,
-			status, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
-
-	_, err := s.PG.Exec(query,
+			status, error_message, created_at, updated_at, idempotency_key
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (idempotency_key) DO UPDATE
+			SET status = EXCLUDED.status, error_message = EXCLUDED.error_message, updated_at = EXCLUDED.updated_at
+			WHERE alert_escalations.status = 'failed'
+		RETURNING id`
+
+	err = s.PG.QueryRow(query,
 		escalation.ID, escalation.AlertID, escalation.EscalationPolicyID, escalation.EscalationLevel,
-		escalation.TargetType, escalation.TargetID, escalation.Status, escalation.CreatedAt, escalation.UpdatedAt)
+		escalation.TargetType, escalation.TargetID, escalation.Status, escalation.ErrorMessage,
+		escalation.CreatedAt, escalation.UpdatedAt, key).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return id, true, nil
+}
+
+// updateEscalationStatus updates the status of an escalation and records its deliveries
+func (s *EscalationService) updateEscalationStatus(escalationID, status, errorMessage string, deliveries []db.EscalationDelivery) error {
+	var deliveriesJSON interface{}
+	if len(deliveries) > 0 {
+		data, err := json.Marshal(deliveries)
+		if err != nil {
+			return fmt.Errorf("failed to marshal escalation deliveries: %w", err)
+		}
+		deliveriesJSON = string(data)
+	}
 
+	query := `UPDATE alert_escalations SET status = $1, error_message = $2, deliveries = $3, updated_at = $4 WHERE id = $5`
+	_, err := s.PG.Exec(query, status, errorMessage, deliveriesJSON, time.Now(), escalationID)
 	return err
 }
 
-// updateEscalationStatus updates the status of an escalation
-func (s *EscalationService) updateEscalationStatus(escalationID, status, errorMessage string) error {
-	query := `UPDATE alert_escalations SET status = $1, error_message = $2, updated_at = $3 WHERE id = $4`
-	_, err := s.PG.Exec(query, status, errorMessage, time.Now(), escalationID)
-	return err
+// alertEscalationStatuses lists the statuses GetAlertEscalations can filter by
+var alertEscalationStatuses = map[string]bool{
+	"pending":      true,
+	"sent":         true,
+	"failed":       true,
+	"acknowledged": true,
+	"timeout":      true,
+	"executing":    true,
+	"completed":    true,
+	"skipped":      true,
+	"stopped":      true,
+}
+
+// IsValidAlertEscalationStatus reports whether status is a known alert escalation status
+func IsValidAlertEscalationStatus(status string) bool {
+	return alertEscalationStatuses[status]
 }
 
-// GetAlertEscalations retrieves escalation history for an alert
-func (s *EscalationService) GetAlertEscalations(alertID string) ([]db.AlertEscalation, error) {
+// GetAlertEscalations retrieves escalation history for an alert.
+// Supported filters: status (string), limit (int), offset (int).
+func (s *EscalationService) GetAlertEscalations(alertID string, filters map[string]interface{}) ([]db.AlertEscalation, error) {
 	var escalations []db.AlertEscalation
 
 	query := `
@@ -1234,45 +1712,121 @@ func (s *EscalationService) GetAlertEscalations(alertID string) ([]db.AlertEscal
 			   status, error_message, created_at, updated_at,
 			   COALESCE(acknowledged_at, '1970-01-01'::timestamp) as acknowledged_at,
 			   COALESCE(acknowledged_by, '') as acknowledged_by,
-			   response_time_seconds, notification_methods, target_name
+			   response_time_seconds, notification_methods, target_name,
+			   COALESCE(deliveries, '[]'::jsonb) AS deliveries
 		FROM alert_escalations 
-		WHERE alert_id = $1 
-		ORDER BY created_at ASC`
+		WHERE alert_id = $1`
+	args := []interface{}{alertID}
+	argIndex := 2
+
+	if status, ok := filters["status"].(string); ok && status != "" {
+		query += fmt.Sprintf(" AND status = $%d", argIndex)
+		args = append(args, status)
+		argIndex++
+	}
+
+	query += " ORDER BY created_at ASC"
 
-	rows, err := s.PG.Query(query, alertID)
+	if limit, ok := filters["limit"].(int); ok && limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argIndex)
+		args = append(args, limit)
+		argIndex++
+	}
+	if offset, ok := filters["offset"].(int); ok && offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argIndex)
+		args = append(args, offset)
+	}
+
+	rows, err := s.PG.Query(query, args...)
 	if err != nil {
 		return escalations, fmt.Errorf("failed to query alert escalations: %w", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var escalation db.AlertEscalation
-		var acknowledgedAtDummy time.Time
-		var notificationMethodsJSON []byte
-
-		err := rows.Scan(
-			&escalation.ID, &escalation.AlertID, &escalation.EscalationPolicyID, &escalation.EscalationLevel,
-			&escalation.TargetType, &escalation.TargetID, &escalation.Status, &escalation.ErrorMessage,
-			&escalation.CreatedAt, &escalation.UpdatedAt, &acknowledgedAtDummy, &escalation.AcknowledgedBy,
-			&escalation.ResponseTimeSeconds, &notificationMethodsJSON, &escalation.TargetName)
+		escalation, err := scanAlertEscalation(rows)
 		if err != nil {
-			return escalations, fmt.Errorf("failed to scan alert escalation: %w", err)
+			return escalations, err
 		}
+		escalations = append(escalations, escalation)
+	}
 
-		// Handle acknowledged_at
-		if !acknowledgedAtDummy.Equal(time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)) {
-			escalation.AcknowledgedAt = &acknowledgedAtDummy
-		}
+	return escalations, nil
+}
 
-		// Deserialize notification methods
-		if len(notificationMethodsJSON) > 0 {
-			if err := json.Unmarshal(notificationMethodsJSON, &escalation.NotificationMethods); err != nil {
-				escalation.NotificationMethods = []string{} // fallback
-			}
-		}
+// ListActiveEscalations returns the escalations across an organization that are paging their
+// target right now, oldest first, with target names resolved for display
+func (s *EscalationService) ListActiveEscalations(orgID string) ([]db.AlertEscalation, error) {
+	if orgID == "" {
+		return nil, fmt.Errorf("organization_id is required")
+	}
+
+	rows, err := s.PG.Query(`
+		SELECT ae.id, ae.alert_id, ae.escalation_policy_id, ae.escalation_level, ae.target_type, ae.target_id,
+			   ae.status, ae.error_message, ae.created_at, ae.updated_at,
+			   COALESCE(ae.acknowledged_at, '1970-01-01'::timestamp) as acknowledged_at,
+			   COALESCE(ae.acknowledged_by, '') as acknowledged_by,
+			   ae.response_time_seconds, ae.notification_methods,
+			   COALESCE(NULLIF(ae.target_name, ''), CASE ae.target_type
+			       WHEN 'user' THEN u.name
+			       WHEN 'group' THEN g.name
+			       WHEN 'scheduler' THEN sc.name
+			       WHEN 'current_schedule' THEN 'Current On-Call'
+			       WHEN 'external' THEN 'External Webhook'
+			   END, ae.target_type) AS target_name,
+			   COALESCE(ae.deliveries, '[]'::jsonb) AS deliveries
+		FROM alert_escalations ae
+		JOIN escalation_policies ep ON ep.id = ae.escalation_policy_id
+		LEFT JOIN users u ON ae.target_type = 'user' AND u.id::text = ae.target_id
+		LEFT JOIN groups g ON ae.target_type = 'group' AND g.id::text = ae.target_id
+		LEFT JOIN schedulers sc ON ae.target_type = 'scheduler' AND sc.id::text = ae.target_id
+		WHERE ep.organization_id::text = $1 AND ae.status = 'executing'
+		ORDER BY ae.created_at ASC
+	`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active escalations: %w", err)
+	}
+	defer rows.Close()
 
+	escalations := []db.AlertEscalation{}
+	for rows.Next() {
+		escalation, err := scanAlertEscalation(rows)
+		if err != nil {
+			return nil, err
+		}
 		escalations = append(escalations, escalation)
 	}
+	return escalations, rows.Err()
+}
 
-	return escalations, nil
+// scanAlertEscalation scans an alert escalation row as selected by GetAlertEscalations
+func scanAlertEscalation(rows *sql.Rows) (db.AlertEscalation, error) {
+	var escalation db.AlertEscalation
+	var acknowledgedAtDummy time.Time
+	var notificationMethodsJSON, deliveriesJSON []byte
+
+	err := rows.Scan(
+		&escalation.ID, &escalation.AlertID, &escalation.EscalationPolicyID, &escalation.EscalationLevel,
+		&escalation.TargetType, &escalation.TargetID, &escalation.Status, &escalation.ErrorMessage,
+		&escalation.CreatedAt, &escalation.UpdatedAt, &acknowledgedAtDummy, &escalation.AcknowledgedBy,
+		&escalation.ResponseTimeSeconds, &notificationMethodsJSON, &escalation.TargetName, &deliveriesJSON)
+	if err != nil {
+		return escalation, fmt.Errorf("failed to scan alert escalation: %w", err)
+	}
+
+	// Handle acknowledged_at
+	if !acknowledgedAtDummy.Equal(time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		escalation.AcknowledgedAt = &acknowledgedAtDummy
+	}
+
+	// Deserialize notification methods
+	if len(notificationMethodsJSON) > 0 {
+		if err := json.Unmarshal(notificationMethodsJSON, &escalation.NotificationMethods); err != nil {
+			escalation.NotificationMethods = []string{} // fallback
+		}
+	}
+	if err := json.Unmarshal(deliveriesJSON, &escalation.Deliveries); err != nil {
+		log.Printf("WARNING: Invalid deliveries on alert escalation %s: %v", escalation.ID, err)
+	}
+	return escalation, nil
 }
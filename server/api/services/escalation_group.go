@@ -0,0 +1,194 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/phonginreallife/inres/db"
+)
+
+// groupMemberBatch caps how many due group members one worker poll claims
+const groupMemberBatch = 50
+
+// PendingGroupMember is a sequential or round robin group escalation waiting for the current
+// member's timeout before paging the next member
+type PendingGroupMember struct {
+	ID                  string
+	AlertEscalationID   string
+	AlertID             string
+	GroupID             string
+	MemberIDs           []string
+	NextPosition        int
+	TimeoutMinutes      int
+	NotificationMethods []string
+}
+
+// rotateAfterLastPaged reorders a round robin group's members to start after the member paged
+// most recently for the group. Without a previous page the escalation order is kept.
+func (s *EscalationService) rotateAfterLastPaged(groupID string, userIDs []string) []string {
+	var lastPaged string
+	err := s.PG.QueryRow(`
+		SELECT user_id FROM oncall_pages
+		WHERE group_id = $1 AND user_id::text = ANY($2)
+		ORDER BY last_paged_at DESC
+		LIMIT 1
+	`, groupID, pq.Array(userIDs)).Scan(&lastPaged)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("WARNING: Failed to get last paged member of group %s: %v", groupID, err)
+		}
+		return userIDs
+	}
+
+	for i, userID := range userIDs {
+		if userID == lastPaged {
+			rotated := make([]string, 0, len(userIDs))
+			rotated = append(rotated, userIDs[i+1:]...)
+			return append(rotated, userIDs[:i+1]...)
+		}
+	}
+	return userIDs
+}
+
+// pageGroupMember pages the first available member of a group from position on and returns
+// the position after them. It fails when none of the remaining members could be notified.
+func (s *EscalationService) pageGroupMember(alert *db.Alert, groupID string, userIDs []string, position int, methods []string) ([]db.EscalationDelivery, int, error) {
+	var deliveries []db.EscalationDelivery
+	var errors []string
+
+	for i := position; i < len(userIDs); i++ {
+		userDeliveries, err := s.notifyAvailableUser(alert, userIDs[i], methods)
+		deliveries = append(deliveries, userDeliveries...)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		recordOncallPage(s.PG, groupID, userIDs[i])
+		return deliveries, i + 1, nil
+	}
+
+	return deliveries, len(userIDs), fmt.Errorf("no user could be notified: %s", strings.Join(errors, "; "))
+}
+
+// scheduleNextGroupMember stores the members still to page for a group escalation, the next
+// one due after timeoutMinutes
+func (s *EscalationService) scheduleNextGroupMember(escalationID string, alert *db.Alert, groupID string, userIDs []string, position, timeoutMinutes int, methods []string) {
+	_, err := s.PG.Exec(`
+		INSERT INTO group_escalation_members (alert_escalation_id, alert_id, group_id, member_ids, next_position,
+			timeout_minutes, notification_methods, next_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (alert_escalation_id) DO NOTHING
+	`, escalationID, alert.ID, groupID, pq.Array(userIDs), position, timeoutMinutes, pq.Array(methods),
+		time.Now().Add(time.Duration(timeoutMinutes)*time.Minute))
+	if err != nil {
+		log.Printf("WARNING: Failed to schedule next member of group %s for alert %s: %v", groupID, alert.Title, err)
+		return
+	}
+	log.Printf("Scheduled next member of group %s in %d minutes for alert %s", groupID, timeoutMinutes, alert.Title)
+}
+
+// ClaimDueGroupMembers marks the group escalations whose current member timed out as firing
+// and returns them, so each next member is paged once even with several workers polling
+func (s *EscalationService) ClaimDueGroupMembers() ([]PendingGroupMember, error) {
+	rows, err := s.PG.Query(`
+		UPDATE group_escalation_members
+		SET status = 'firing', updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM group_escalation_members
+			WHERE status = 'pending' AND next_at <= NOW()
+			ORDER BY next_at ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, alert_escalation_id, alert_id, group_id, member_ids, next_position, timeout_minutes, notification_methods
+	`, groupMemberBatch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due group members: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []PendingGroupMember
+	for rows.Next() {
+		var member PendingGroupMember
+		var memberIDs, methods pq.StringArray
+		if err := rows.Scan(&member.ID, &member.AlertEscalationID, &member.AlertID, &member.GroupID, &memberIDs,
+			&member.NextPosition, &member.TimeoutMinutes, &methods); err != nil {
+			return nil, fmt.Errorf("failed to scan group escalation member: %w", err)
+		}
+		member.MemberIDs = memberIDs
+		member.NotificationMethods = methods
+		pending = append(pending, member)
+	}
+	return pending, rows.Err()
+}
+
+// PageNextGroupMember pages the next member of a claimed group escalation if its alert is
+// still unacknowledged, and schedules the member after them. Returns whether anyone was paged.
+func (s *EscalationService) PageNextGroupMember(pending PendingGroupMember) (bool, error) {
+	alert, err := s.getEscalatingAlert(pending.AlertID)
+	if err != nil {
+		// Try again on the next poll
+		s.setGroupMembersStatus(pending.ID, "pending")
+		return false, err
+	}
+	if alert == nil {
+		s.setGroupMembersStatus(pending.ID, "cancelled")
+		return false, nil
+	}
+
+	deliveries, next, pageErr := s.pageGroupMember(alert, pending.GroupID, pending.MemberIDs, pending.NextPosition, pending.NotificationMethods)
+	if err := s.appendEscalationDeliveries(pending.AlertEscalationID, deliveries); err != nil {
+		log.Printf("Failed to record group escalation deliveries: %v", err)
+	}
+
+	if pageErr != nil || next >= len(pending.MemberIDs) {
+		s.setGroupMembersStatus(pending.ID, "done")
+		return pageErr == nil, pageErr
+	}
+
+	_, err = s.PG.Exec(`
+		UPDATE group_escalation_members
+		SET status = 'pending', next_position = $1, next_at = $2, updated_at = NOW()
+		WHERE id = $3
+	`, next, time.Now().Add(time.Duration(pending.TimeoutMinutes)*time.Minute), pending.ID)
+	if err != nil {
+		return true, fmt.Errorf("failed to schedule next group member: %w", err)
+	}
+	return true, nil
+}
+
+// setGroupMembersStatus updates the status of a group escalation's remaining members
+func (s *EscalationService) setGroupMembersStatus(id, status string) {
+	_, err := s.PG.Exec(`UPDATE group_escalation_members SET status = $1, updated_at = NOW() WHERE id = $2`, status, id)
+	if err != nil {
+		log.Printf("WARNING: Failed to mark group escalation members %s as %s: %v", id, status, err)
+	}
+}
+
+// appendEscalationDeliveries adds the deliveries of a later group member to the group's
+// escalation record
+func (s *EscalationService) appendEscalationDeliveries(escalationID string, deliveries []db.EscalationDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	deliveriesJSON, err := json.Marshal(deliveries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal deliveries: %w", err)
+	}
+
+	_, err = s.PG.Exec(`
+		UPDATE alert_escalations
+		SET deliveries = COALESCE(deliveries, '[]'::jsonb) || $1::jsonb, updated_at = NOW()
+		WHERE id = $2
+	`, string(deliveriesJSON), escalationID)
+	if err != nil {
+		return fmt.Errorf("failed to update escalation deliveries: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func expectEscalatingAlert(mockDB sqlmock.Sqlmock, alertID string) {
+	now := time.Now()
+	mockDB.ExpectQuery(`FROM alerts\s+WHERE id = \$1 AND status NOT IN \('acked', 'closed'\)`).
+		WithArgs(alertID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "status", "severity", "source", "group_id", "created_at", "updated_at"}).
+			AddRow(alertID, "disk full", "", "new", "critical", "webhook", "group-1", now, now))
+}
+
+func pendingSequentialGroup(position int) PendingGroupMember {
+	return PendingGroupMember{
+		ID:                  "pending-1",
+		AlertEscalationID:   "esc-1",
+		AlertID:             "alert-1",
+		GroupID:             "group-1",
+		MemberIDs:           []string{"user-a", "user-b", "user-c"},
+		NextPosition:        position,
+		TimeoutMinutes:      5,
+		NotificationMethods: []string{"email"},
+	}
+}
+
+func TestClaimDueGroupMembers(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`UPDATE group_escalation_members\s+SET status = 'firing'.*WHERE status = 'pending' AND next_at <= NOW\(\).*FOR UPDATE SKIP LOCKED`).
+		WithArgs(groupMemberBatch).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "alert_escalation_id", "alert_id", "group_id", "member_ids", "next_position", "timeout_minutes", "notification_methods"}).
+			AddRow("pending-1", "esc-1", "alert-1", "group-1", `{user-a,user-b,user-c}`, 1, 5, `{email}`))
+
+	pending, err := NewEscalationService(pg, nil, nil, nil).ClaimDueGroupMembers()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []PendingGroupMember{pendingSequentialGroup(1)}, pending)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestPageNextGroupMember_SchedulesMemberAfter(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// user-a timed out: user-b is paged, its delivery added to the group's escalation record,
+	// and user-c is due after another 5 minutes
+	expectEscalatingAlert(mockDB, "alert-1")
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-b").
+		WillReturnError(sql.ErrNoRows)
+	expectEscalationQueued(mockDB)
+	expectOncallPage(mockDB, "group-1", "user-b")
+	mockDB.ExpectExec(`UPDATE alert_escalations\s+SET deliveries = COALESCE\(deliveries, '\[\]'::jsonb\) \|\| \$1::jsonb`).
+		WithArgs(`[{"user_id":"user-b","channel":"email","status":"queued"}]`, "esc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`UPDATE group_escalation_members\s+SET status = 'pending', next_position = \$1, next_at = \$2`).
+		WithArgs(2, sqlmock.AnyArg(), "pending-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	paged, err := NewEscalationService(pg, nil, nil, nil).PageNextGroupMember(pendingSequentialGroup(1))
+
+	assert.NoError(t, err)
+	assert.True(t, paged)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestPageNextGroupMember_LastMemberIsDone(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectEscalatingAlert(mockDB, "alert-1")
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-c").
+		WillReturnError(sql.ErrNoRows)
+	expectEscalationQueued(mockDB)
+	expectOncallPage(mockDB, "group-1", "user-c")
+	mockDB.ExpectExec("UPDATE alert_escalations").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`UPDATE group_escalation_members SET status = \$1`).
+		WithArgs("done", "pending-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	paged, err := NewEscalationService(pg, nil, nil, nil).PageNextGroupMember(pendingSequentialGroup(2))
+
+	assert.NoError(t, err)
+	assert.True(t, paged)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestPageNextGroupMember_AlertAcknowledged(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// The alert was acknowledged while user-a was paged: nobody else is
+	mockDB.ExpectQuery("FROM alerts").
+		WithArgs("alert-1").
+		WillReturnError(sql.ErrNoRows)
+	mockDB.ExpectExec(`UPDATE group_escalation_members SET status = \$1`).
+		WithArgs("cancelled", "pending-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	paged, err := NewEscalationService(pg, nil, nil, nil).PageNextGroupMember(pendingSequentialGroup(1))
+
+	assert.NoError(t, err)
+	assert.False(t, paged)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
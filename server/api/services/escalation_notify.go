@@ -0,0 +1,278 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// escalationNotificationQueue is the PGMQ queue escalation notifications are sent on, shared
+// with IncidentService's notifications
+const escalationNotificationQueue = "incident_notifications"
+
+// defaultEscalationChannels are used for a level without notification methods, matching
+// incident escalation notifications
+var defaultEscalationChannels = []string{"push", "slack"}
+
+// EscalationDelivery statuses
+const (
+	escalationDeliverySent    = "sent"
+	escalationDeliveryQueued  = "queued"
+	escalationDeliveryFailed  = "failed"
+	escalationDeliverySkipped = "skipped"
+)
+
+// escalationChannels maps a level's notification methods to delivery channels: "fcm" and
+// "push" are FCM push notifications, the others go through the notification queue. Webhooks
+// only apply to external targets, so they are dropped here.
+func escalationChannels(methods []string) []string {
+	seen := make(map[string]bool)
+	var channels []string
+	for _, method := range methods {
+		channel := strings.ToLower(strings.TrimSpace(method))
+		if channel == db.NotificationMethodFCM {
+			channel = "push"
+		}
+		if channel == "" || channel == db.NotificationMethodWebhook || seen[channel] {
+			continue
+		}
+		seen[channel] = true
+		channels = append(channels, channel)
+	}
+	if len(channels) == 0 {
+		return defaultEscalationChannels
+	}
+	return channels
+}
+
+// notifyUser sends the alert to a user over each channel of the level's notification methods.
+// It fails when nothing could be sent or queued.
+func (s *EscalationService) notifyUser(alert *db.Alert, userID string, methods []string) ([]db.EscalationDelivery, error) {
+	log.Printf("Notifying user %s for alert %s via %v", userID, alert.Title, methods)
+
+	var deliveries []db.EscalationDelivery
+	var queued []string
+	for _, channel := range escalationChannels(methods) {
+		if channel == "push" {
+			deliveries = append(deliveries, s.sendEscalationPush(alert, userID))
+		} else {
+			queued = append(queued, channel)
+		}
+	}
+
+	if len(queued) > 0 {
+		status, errorMessage := escalationDeliveryQueued, ""
+		if err := s.enqueueEscalationNotification(alert, userID, queued); err != nil {
+			status, errorMessage = escalationDeliveryFailed, err.Error()
+		}
+		for _, channel := range queued {
+			deliveries = append(deliveries, db.EscalationDelivery{UserID: userID, Channel: channel, Status: status, Error: errorMessage})
+		}
+	}
+
+	var errors []string
+	for _, delivery := range deliveries {
+		if delivery.Status == escalationDeliverySent || delivery.Status == escalationDeliveryQueued {
+			return deliveries, nil
+		}
+		errors = append(errors, fmt.Sprintf("%s: %s", delivery.Channel, delivery.Error))
+	}
+	return deliveries, fmt.Errorf("no notification delivered to user %s: %s", userID, strings.Join(errors, "; "))
+}
+
+// sendEscalationPush sends the alert to a user's devices through FCM
+func (s *EscalationService) sendEscalationPush(alert *db.Alert, userID string) db.EscalationDelivery {
+	delivery := db.EscalationDelivery{UserID: userID, Channel: "push", Status: escalationDeliverySent}
+	if s.FCMService == nil {
+		delivery.Status = escalationDeliverySkipped
+		delivery.Error = "push notifications are not configured"
+		return delivery
+	}
+
+	// SendAlertNotification notifies the alert's assignee
+	userAlert := *alert
+	userAlert.AssignedTo = userID
+	if err := s.FCMService.SendAlertNotification(&userAlert); err != nil {
+		delivery.Status = escalationDeliveryFailed
+		delivery.Error = err.Error()
+	}
+	return delivery
+}
+
+// enqueueEscalationNotification queues an "escalated" notification for the notification
+// workers, in the format of LightweightNotificationSender. The alert ID rides in incident_id.
+func (s *EscalationService) enqueueEscalationNotification(alert *db.Alert, userID string, channels []string) error {
+	notification := map[string]interface{}{
+		"type":        "escalated",
+		"user_id":     userID,
+		"incident_id": alert.ID,
+		"channels":    channels,
+		"priority":    "high",
+		"data": map[string]interface{}{
+			"message":  fmt.Sprintf("[%s] %s", alert.Severity, alert.Title),
+			"alert_id": alert.ID,
+			"severity": alert.Severity,
+		},
+		"created_at":  time.Now(),
+		"retry_count": 0,
+	}
+
+	notificationJSON, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	_, err = s.PG.Exec(`SELECT pgmq.send($1, $2)`, escalationNotificationQueue, string(notificationJSON))
+	if err != nil {
+		return fmt.Errorf("failed to send notification to queue: %w", err)
+	}
+	return nil
+}
+
+// notifyGroup notifies the active members of a group according to its escalation method.
+// Parallel groups page everyone at once. Sequential groups page one member at a time in
+// escalation order, and round robin groups do the same starting after the member paged last
+// for the group; the next member is paged once the level's timeout passes without an
+// acknowledgement.
+func (s *EscalationService) notifyGroup(alert *db.Alert, policy *db.EscalationPolicyWithLevels, level *db.EscalationLevel, escalationID string) ([]db.EscalationDelivery, error) {
+	groupID, methods := level.TargetID, level.NotificationMethods
+	log.Printf("Notifying group %s for alert %s via %v", groupID, alert.Title, methods)
+
+	var escalationMethod string
+	err := s.PG.QueryRow(`SELECT COALESCE(escalation_method, '') FROM groups WHERE id = $1 AND is_active = true`, groupID).
+		Scan(&escalationMethod)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("group %s not found or inactive", groupID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+
+	// Memberships carry no escalation order; the legacy group_members rows still do
+	userIDs, err := s.queryUserIDs(`
+		SELECT m.user_id
+		FROM memberships m
+		JOIN users u ON u.id = m.user_id
+		LEFT JOIN group_members gm ON gm.group_id = m.resource_id AND gm.user_id = m.user_id
+		WHERE m.resource_type = 'group' AND m.resource_id = $1
+		AND COALESCE(u.is_active, true) AND COALESCE(gm.is_active, true)
+		ORDER BY gm.escalation_order ASC NULLS LAST, m.created_at ASC
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query group members: %w", err)
+	}
+	if len(userIDs) == 0 {
+		return nil, fmt.Errorf("group %s has no active members", groupID)
+	}
+
+	switch escalationMethod {
+	case db.EscalationMethodSequential, db.EscalationMethodRoundRobin:
+	default:
+		return s.notifyUsers(alert, userIDs, methods)
+	}
+
+	if escalationMethod == db.EscalationMethodRoundRobin {
+		userIDs = s.rotateAfterLastPaged(groupID, userIDs)
+	}
+
+	deliveries, next, err := s.pageGroupMember(alert, groupID, userIDs, 0, methods)
+	if err != nil {
+		return deliveries, err
+	}
+	if next < len(userIDs) {
+		timeout := level.GetEffectiveTimeout(policy.EscalateAfterMinutes)
+		s.scheduleNextGroupMember(escalationID, alert, groupID, userIDs, next, timeout, methods)
+	}
+	return deliveries, nil
+}
+
+// notifyCurrentSchedule notifies everyone on call right now for the alert's group, schedule
+// overrides included
+func (s *EscalationService) notifyCurrentSchedule(alert *db.Alert, methods []string) ([]db.EscalationDelivery, error) {
+	log.Printf("Notifying current schedule for alert %s via %v", alert.Title, methods)
+
+	if alert.GroupID == "" {
+		return nil, fmt.Errorf("alert %s has no group to find the current schedule of", alert.ID)
+	}
+
+	userIDs, err := s.queryUserIDs(`
+		SELECT DISTINCT es.effective_user_id
+		FROM effective_shifts es
+		WHERE es.group_id = $1
+		AND es.start_time <= NOW()
+		AND es.end_time >= NOW()
+	`, alert.GroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query on-call users: %w", err)
+	}
+	if len(userIDs) == 0 {
+		return nil, fmt.Errorf("no users currently on-call for group %s", alert.GroupID)
+	}
+
+	return s.notifyUsers(alert, userIDs, methods)
+}
+
+// notifyUsers notifies users in order, skipping those who are unavailable. It fails when
+// nobody was notified.
+func (s *EscalationService) notifyUsers(alert *db.Alert, userIDs []string, methods []string) ([]db.EscalationDelivery, error) {
+	var deliveries []db.EscalationDelivery
+	var errors []string
+	notified := 0
+
+	for _, userID := range userIDs {
+		userDeliveries, err := s.notifyAvailableUser(alert, userID, methods)
+		deliveries = append(deliveries, userDeliveries...)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		notified++
+	}
+
+	if notified == 0 {
+		return deliveries, fmt.Errorf("no user could be notified: %s", strings.Join(errors, "; "))
+	}
+	if len(errors) > 0 {
+		log.Printf("Some notifications failed: %v", errors)
+	}
+	return deliveries, nil
+}
+
+// notifyAvailableUser notifies a user unless they are unavailable, in which case a skipped
+// delivery is recorded
+func (s *EscalationService) notifyAvailableUser(alert *db.Alert, userID string, methods []string) ([]db.EscalationDelivery, error) {
+	reason, err := UserUnavailableReason(s.PG, userID)
+	if err != nil {
+		log.Printf("Failed to check availability of user %s, paging anyway: %v", userID, err)
+	} else if reason != "" {
+		return []db.EscalationDelivery{{
+			UserID: userID, Status: escalationDeliverySkipped, Error: fmt.Sprintf("user unavailable: %s", reason),
+		}}, fmt.Errorf("user %s unavailable: %s", userID, reason)
+	}
+
+	return s.notifyUser(alert, userID, methods)
+}
+
+// queryUserIDs returns the user IDs selected by a single-column query
+func (s *EscalationService) queryUserIDs(query string, args ...interface{}) ([]string, error) {
+	rows, err := s.PG.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscalationChannels(t *testing.T) {
+	assert.Equal(t, []string{"push", "email"}, escalationChannels([]string{"fcm", "Email", "push", "webhook"}))
+	assert.Equal(t, defaultEscalationChannels, escalationChannels(nil))
+}
+
+func groupLevel(methods ...string) *db.EscalationLevel {
+	return &db.EscalationLevel{ID: "level-1", LevelNumber: 1, TargetType: "group", TargetID: "group-1", NotificationMethods: methods}
+}
+
+func expectOncallPage(mockDB sqlmock.Sqlmock, groupID, userID string) {
+	mockDB.ExpectExec("INSERT INTO oncall_pages").
+		WithArgs(groupID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestNotifyGroup_ParallelPagesEveryMember(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectGroupMembers(mockDB, "group-1", db.EscalationMethodParallel, "user-a", "user-b")
+	for range []string{"user-a", "user-b"} {
+		mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+			WillReturnError(sql.ErrNoRows)
+		expectEscalationQueued(mockDB)
+	}
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	deliveries, err := service.notifyGroup(&db.Alert{ID: "alert-1", Title: "disk full"}, newTwoStepUserPolicy(), groupLevel("email"), "esc-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []db.EscalationDelivery{
+		{UserID: "user-a", Channel: "email", Status: "queued"},
+		{UserID: "user-b", Channel: "email", Status: "queued"},
+	}, deliveries)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestNotifyGroup_SequentialPagesOneMemberAtATime(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// user-a is first in escalation order but on vacation, so user-b is paged and user-c is
+	// left for after the level's 5 minute timeout
+	expectGroupMembers(mockDB, "group-1", db.EscalationMethodSequential, "user-a", "user-b", "user-c")
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-a").
+		WillReturnRows(sqlmock.NewRows([]string{"reason"}).AddRow("vacation"))
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-b").
+		WillReturnError(sql.ErrNoRows)
+	expectEscalationQueued(mockDB)
+	expectOncallPage(mockDB, "group-1", "user-b")
+	mockDB.ExpectExec(`INSERT INTO group_escalation_members .* ON CONFLICT \(alert_escalation_id\) DO NOTHING`).
+		WithArgs("esc-1", "alert-1", "group-1", `{"user-a","user-b","user-c"}`, 2, 5, `{"email"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	deliveries, err := service.notifyGroup(&db.Alert{ID: "alert-1", Title: "disk full"}, newTwoStepUserPolicy(), groupLevel("email"), "esc-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []db.EscalationDelivery{
+		{UserID: "user-a", Status: "skipped", Error: "user unavailable: vacation"},
+		{UserID: "user-b", Channel: "email", Status: "queued"},
+	}, deliveries)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestNotifyGroup_SequentialLastMemberSchedulesNothing(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectGroupMembers(mockDB, "group-1", db.EscalationMethodSequential, "user-a")
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-a").
+		WillReturnError(sql.ErrNoRows)
+	expectEscalationQueued(mockDB)
+	expectOncallPage(mockDB, "group-1", "user-a")
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	_, err = service.notifyGroup(&db.Alert{ID: "alert-1", Title: "disk full"}, newTwoStepUserPolicy(), groupLevel("email"), "esc-1")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestNotifyGroup_RoundRobinStartsAfterLastPagedMember(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// user-b took the previous page, so user-c goes first and user-a, user-b follow
+	expectGroupMembers(mockDB, "group-1", db.EscalationMethodRoundRobin, "user-a", "user-b", "user-c")
+	mockDB.ExpectQuery(`SELECT user_id FROM oncall_pages\s+WHERE group_id = \$1 AND user_id::text = ANY\(\$2\)\s+ORDER BY last_paged_at DESC`).
+		WithArgs("group-1", `{"user-a","user-b","user-c"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("user-b"))
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-c").
+		WillReturnError(sql.ErrNoRows)
+	expectEscalationQueued(mockDB)
+	expectOncallPage(mockDB, "group-1", "user-c")
+	mockDB.ExpectExec("INSERT INTO group_escalation_members").
+		WithArgs("esc-1", "alert-1", "group-1", `{"user-c","user-a","user-b"}`, 1, 10, `{"sms"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	level := groupLevel("sms")
+	level.TimeoutMinutes = 10
+	service := NewEscalationService(pg, nil, nil, nil)
+	deliveries, err := service.notifyGroup(&db.Alert{ID: "alert-1", Title: "disk full"}, newTwoStepUserPolicy(), level, "esc-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []db.EscalationDelivery{{UserID: "user-c", Channel: "sms", Status: "queued"}}, deliveries)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRotateAfterLastPaged_NoPreviousPage(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery("SELECT user_id FROM oncall_pages").
+		WillReturnError(sql.ErrNoRows)
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	assert.Equal(t, []string{"user-a", "user-b"}, service.rotateAfterLastPaged("group-1", []string{"user-a", "user-b"}))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestNotifyGroup_NoActiveMembers(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectGroupMembers(mockDB, "group-1", db.EscalationMethodParallel)
+
+	_, err = NewEscalationService(pg, nil, nil, nil).notifyGroup(&db.Alert{ID: "alert-1"}, newTwoStepUserPolicy(), groupLevel(), "esc-1")
+
+	assert.EqualError(t, err, "group group-1 has no active members")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestNotifyCurrentSchedule_RecordsFailedDeliveries(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Both on-call users are paged; the queue is down, so the escalation fails with both deliveries recorded
+	mockDB.ExpectQuery(`FROM effective_shifts es\s+WHERE es.group_id = \$1`).
+		WithArgs("group-1").
+		WillReturnRows(sqlmock.NewRows([]string{"effective_user_id"}).AddRow("user-1").AddRow("user-2"))
+	for _, userID := range []string{"user-1", "user-2"} {
+		mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+			WithArgs(userID).
+			WillReturnError(sql.ErrNoRows)
+		mockDB.ExpectExec(`SELECT pgmq.send`).
+			WillReturnError(errors.New("queue unavailable"))
+	}
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	deliveries, err := service.notifyCurrentSchedule(&db.Alert{ID: "alert-1", GroupID: "group-1"}, []string{"sms"})
+
+	assert.ErrorContains(t, err, "no user could be notified")
+	assert.Equal(t, []db.EscalationDelivery{
+		{UserID: "user-1", Channel: "sms", Status: "failed", Error: "failed to send notification to queue: queue unavailable"},
+		{UserID: "user-2", Channel: "sms", Status: "failed", Error: "failed to send notification to queue: queue unavailable"},
+	}, deliveries)
+
+	// Without a group there is no schedule to look at
+	_, err = service.notifyCurrentSchedule(&db.Alert{ID: "alert-2"}, nil)
+	assert.Error(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// scheduledEscalationBatch caps how many due steps one worker poll claims
+const scheduledEscalationBatch = 50
+
+// ScheduledEscalation is an escalation step waiting for the previous step's timeout
+type ScheduledEscalation struct {
+	ID                 string
+	AlertID            string
+	EscalationPolicyID string
+	StepNumber         int
+	FireAt             time.Time
+}
+
+// scheduleNextEscalationStep stores the next escalation step to fire after delay. Scheduling a
+// step that is already scheduled for the alert (e.g. a retried step) keeps the first schedule.
+func (s *EscalationService) scheduleNextEscalationStep(alert *db.Alert, policy *db.EscalationPolicyWithLevels, stepNumber int, delay time.Duration) {
+	_, err := s.PG.Exec(`
+		INSERT INTO scheduled_escalations (alert_id, escalation_policy_id, step_number, fire_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (alert_id, step_number) DO NOTHING
+	`, alert.ID, policy.ID, stepNumber, time.Now().Add(delay))
+	if err != nil {
+		log.Printf("WARNING: Failed to schedule escalation step %d for alert %s: %v", stepNumber, alert.Title, err)
+		return
+	}
+	log.Printf("Scheduled escalation step %d in %v for alert %s", stepNumber, delay, alert.Title)
+}
+
+// ClaimDueEscalationSteps marks the scheduled steps whose time has come as fired and returns
+// them, so each is fired once even with several workers polling
+func (s *EscalationService) ClaimDueEscalationSteps() ([]ScheduledEscalation, error) {
+	rows, err := s.PG.Query(`
+		UPDATE scheduled_escalations
+		SET status = 'fired', updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM scheduled_escalations
+			WHERE status = 'pending' AND fire_at <= NOW()
+			ORDER BY fire_at ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, alert_id, escalation_policy_id, step_number, fire_at
+	`, scheduledEscalationBatch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due escalation steps: %w", err)
+	}
+	defer rows.Close()
+
+	var steps []ScheduledEscalation
+	for rows.Next() {
+		var step ScheduledEscalation
+		if err := rows.Scan(&step.ID, &step.AlertID, &step.EscalationPolicyID, &step.StepNumber, &step.FireAt); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled escalation: %w", err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, rows.Err()
+}
+
+// FireScheduledEscalation runs a claimed step if its alert is still unacknowledged and its
+// policy still active; otherwise the step is dropped. Returns whether the step ran.
+func (s *EscalationService) FireScheduledEscalation(step ScheduledEscalation) (bool, error) {
+	alert, err := s.getEscalatingAlert(step.AlertID)
+	if err != nil {
+		return false, err
+	}
+	if alert == nil {
+		return false, nil
+	}
+
+	policy, err := s.GetEscalationPolicyWithLevels(step.EscalationPolicyID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get escalation policy: %w", err)
+	}
+	if !policy.IsActive {
+		log.Printf("Escalation policy %s is not active, dropping step %d for alert %s", policy.Name, step.StepNumber, alert.Title)
+		return false, nil
+	}
+
+	return true, s.executeEscalationStep(alert, &policy, step.StepNumber)
+}
+
+// getEscalatingAlert loads an alert for a scheduled step, or nil when it was acknowledged,
+// closed or deleted since the step was scheduled
+func (s *EscalationService) getEscalatingAlert(alertID string) (*db.Alert, error) {
+	var alert db.Alert
+	err := s.PG.QueryRow(`
+		SELECT id, title, COALESCE(description, ''), status, COALESCE(severity, ''), COALESCE(source, ''),
+		       COALESCE(group_id::text, ''), created_at, updated_at
+		FROM alerts
+		WHERE id = $1 AND status NOT IN ('acked', 'closed')
+	`, alertID).Scan(&alert.ID, &alert.Title, &alert.Description, &alert.Status, &alert.Severity, &alert.Source,
+		&alert.GroupID, &alert.CreatedAt, &alert.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert: %w", err)
+	}
+	return &alert, nil
+}
+
+// CancelScheduledEscalations drops the escalation steps and group members still waiting for
+// an alert, so nobody is paged after it was acknowledged or closed
+func CancelScheduledEscalations(exec sqlExecer, alertID string) error {
+	_, err := exec.Exec(`
+		UPDATE scheduled_escalations SET status = 'cancelled', updated_at = NOW()
+		WHERE alert_id = $1 AND status = 'pending'
+	`, alertID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel scheduled escalations: %w", err)
+	}
+
+	_, err = exec.Exec(`
+		UPDATE group_escalation_members SET status = 'cancelled', updated_at = NOW()
+		WHERE alert_id = $1 AND status = 'pending'
+	`, alertID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel pending group members: %w", err)
+	}
+	return nil
+}
+
+// StopEscalations ends the escalation chain of an alert or incident someone responded to.
+// Escalations still waiting for a response get status (acknowledged, or stopped when it was
+// resolved without an acknowledgement) along with the responder and how long they took to
+// respond, and the steps and group members still scheduled are cancelled.
+func StopEscalations(exec sqlExecer, alertID, userID, status string) error {
+	_, err := exec.Exec(`
+		UPDATE alert_escalations
+		SET status = $2, acknowledged_at = `+SQLNowUTC+`, acknowledged_by = $3,
+		    response_time_seconds = GREATEST(EXTRACT(EPOCH FROM NOW() - created_at), 0)::int,
+		    updated_at = NOW()
+		WHERE alert_id = $1 AND status IN ('pending', 'sent', 'executing', 'completed')
+	`, alertID, status, userID)
+	if err != nil {
+		return fmt.Errorf("failed to stop escalations: %w", err)
+	}
+
+	return CancelScheduledEscalations(exec, alertID)
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteEscalationStep_SchedulesNextStep(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-1").
+		WillReturnError(sql.ErrNoRows)
+	mockDB.ExpectQuery("INSERT INTO alert_escalations").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("esc-1"))
+	expectEscalationQueued(mockDB)
+	mockDB.ExpectExec("UPDATE alert_escalations SET status").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Step 2 fires after the policy's 5 minute timeout
+	mockDB.ExpectExec(`INSERT INTO scheduled_escalations .* ON CONFLICT \(alert_id, step_number\) DO NOTHING`).
+		WithArgs("alert-1", "policy-1", 2, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	err = service.executeEscalationStep(&db.Alert{ID: "alert-1", Title: "disk full"}, newTwoStepUserPolicy(), 1)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestClaimDueEscalationSteps(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	fireAt := time.Now().Add(-time.Minute)
+	mockDB.ExpectQuery(`UPDATE scheduled_escalations\s+SET status = 'fired'.*WHERE status = 'pending' AND fire_at <= NOW\(\).*FOR UPDATE SKIP LOCKED`).
+		WithArgs(scheduledEscalationBatch).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "alert_id", "escalation_policy_id", "step_number", "fire_at"}).
+			AddRow("sched-1", "alert-1", "policy-1", 2, fireAt))
+
+	steps, err := NewEscalationService(pg, nil, nil, nil).ClaimDueEscalationSteps()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []ScheduledEscalation{
+		{ID: "sched-1", AlertID: "alert-1", EscalationPolicyID: "policy-1", StepNumber: 2, FireAt: fireAt},
+	}, steps)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestFireScheduledEscalation(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	now := time.Now()
+	mockDB.ExpectQuery(`FROM alerts\s+WHERE id = \$1 AND status NOT IN \('acked', 'closed'\)`).
+		WithArgs("alert-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "status", "severity", "source", "group_id", "created_at", "updated_at"}).
+			AddRow("alert-1", "disk full", "", "new", "critical", "webhook", "group-1", now, now))
+	mockDB.ExpectQuery("FROM escalation_policies").
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "is_active", "repeat_max_times", "escalate_after_minutes", "created_at", "updated_at", "created_by", "group_id"}).
+			AddRow("policy-1", "Primary", "", true, 0, 5, now, now, "", "group-1"))
+	mockDB.ExpectQuery("FROM escalation_levels").
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "policy_id", "level_number", "target_type", "target_id", "timeout_minutes", "notification_methods", "message_template", "created_at"}).
+			AddRow("level-1", "policy-1", 1, "user", "user-1", 5, []byte(`["slack"]`), "", now).
+			AddRow("level-2", "policy-1", 2, "user", "user-2", 5, []byte(`["slack"]`), "", now))
+
+	// Only step 2 runs; it is the last step so nothing more is scheduled
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-2").
+		WillReturnError(sql.ErrNoRows)
+	mockDB.ExpectQuery("INSERT INTO alert_escalations").
+		WithArgs(sqlmock.AnyArg(), "alert-1", "policy-1", 2, "user", "user-2", "executing", "", sqlmock.AnyArg(), sqlmock.AnyArg(), "alert-1:2:user:user-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("esc-2"))
+	expectEscalationQueued(mockDB)
+	mockDB.ExpectExec("UPDATE alert_escalations SET status").
+		WithArgs("completed", "", `[{"user_id":"user-2","channel":"slack","status":"queued"}]`, sqlmock.AnyArg(), "esc-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	fired, err := service.FireScheduledEscalation(ScheduledEscalation{ID: "sched-1", AlertID: "alert-1", EscalationPolicyID: "policy-1", StepNumber: 2})
+
+	assert.NoError(t, err)
+	assert.True(t, fired)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestFireScheduledEscalation_AlertAcknowledged(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// The alert was acknowledged after the step was claimed: nobody is paged
+	mockDB.ExpectQuery("FROM alerts").
+		WithArgs("alert-1").
+		WillReturnError(sql.ErrNoRows)
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	fired, err := service.FireScheduledEscalation(ScheduledEscalation{ID: "sched-1", AlertID: "alert-1", EscalationPolicyID: "policy-1", StepNumber: 2})
+
+	assert.NoError(t, err)
+	assert.False(t, fired)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func expectEscalationsStopped(mockDB sqlmock.Sqlmock, alertID, userID, status string) {
+	mockDB.ExpectExec(`UPDATE alert_escalations\s+SET status = \$2, acknowledged_at = `+sqlNowUTCPattern+`, acknowledged_by = \$3,\s+response_time_seconds = .*WHERE alert_id = \$1 AND status IN \('pending', 'sent', 'executing', 'completed'\)`).
+		WithArgs(alertID, status, userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`UPDATE scheduled_escalations SET status = 'cancelled'.*WHERE alert_id = \$1 AND status = 'pending'`).
+		WithArgs(alertID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`UPDATE group_escalation_members SET status = 'cancelled'.*WHERE alert_id = \$1 AND status = 'pending'`).
+		WithArgs(alertID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestAckAlert_StopsEscalations(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec("UPDATE alerts SET status = 'acked'").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "alert-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEscalationsStopped(mockDB, "alert-1", "", db.AlertEscalationStatusAcknowledged)
+	mockDB.ExpectExec("UPDATE alerts SET status = 'closed'").
+		WithArgs(sqlmock.AnyArg(), "alert-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEscalationsStopped(mockDB, "alert-2", "", db.AlertEscalationStatusStopped)
+
+	service := NewAlertService(pg, nil, nil)
+	assert.NoError(t, service.AckAlert("alert-1"))
+	assert.NoError(t, service.CloseAlert("alert-2"))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestStopEscalations_CancelFails(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec("UPDATE alert_escalations").
+		WithArgs("inc-1", db.AlertEscalationStatusAcknowledged, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mockDB.ExpectExec("UPDATE scheduled_escalations").
+		WithArgs("inc-1").
+		WillReturnError(sql.ErrConnDone)
+
+	err = StopEscalations(pg, "inc-1", "user-1", db.AlertEscalationStatusAcknowledged)
+
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// StepResolution is who one level of a policy would page at a given time, worked out by
+// SimulatePolicy
+type StepResolution struct {
+	LevelNumber    int         `json:"level_number"`
+	TargetType     string      `json:"target_type"`
+	TargetID       string      `json:"target_id"`
+	Users          []PagedUser `json:"users"`
+	TimeoutMinutes int         `json:"timeout_minutes"`
+	Error          string      `json:"error,omitempty"` // why nobody would be paged
+}
+
+// PagedUser is a user a simulated escalation level resolves to
+type PagedUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// SimulatePolicy resolves each level of a policy to the users it would page at time at, so
+// on-call coverage can be checked before the policy is put to use. Scheduler, current
+// schedule and group targets are resolved through effective_shifts; groupID is the group
+// whose schedule current_schedule and scheduler targets use, the policy's own group when
+// empty. Schedule overrides are taken as they stand now. Nothing is sent or recorded.
+func (s *EscalationService) SimulatePolicy(policyID, groupID string, at time.Time) ([]StepResolution, error) {
+	policy, err := s.GetEscalationPolicyWithLevels(policyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("escalation policy not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if groupID == "" {
+		groupID = policy.GroupID
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	steps := make([]StepResolution, 0, len(policy.Levels))
+	for _, level := range policy.Levels {
+		step := StepResolution{
+			LevelNumber:    level.LevelNumber,
+			TargetType:     level.TargetType,
+			TargetID:       level.TargetID,
+			Users:          []PagedUser{},
+			TimeoutMinutes: level.GetEffectiveTimeout(policy.EscalateAfterMinutes),
+		}
+
+		switch level.TargetType {
+		case db.EscalationTargetUser:
+			step.Users, err = s.queryPagedUsers(`SELECT id, COALESCE(name, email, 'Unknown') FROM users WHERE id::text = $1`, level.TargetID)
+		case "scheduler":
+			step.Users, err = s.queryPagedUsers(`
+				SELECT DISTINCT es.effective_user_id, COALESCE(es.user_name, es.user_email, 'Unknown')
+				FROM effective_shifts es
+				WHERE es.scheduler_id::text = $1 AND es.group_id::text = $2
+				AND es.start_time <= $3 AND es.end_time >= $3
+			`, level.TargetID, groupID, at)
+		case "current_schedule", db.EscalationTargetGroup:
+			scheduleGroupID := groupID
+			if level.TargetType == db.EscalationTargetGroup {
+				scheduleGroupID = level.TargetID
+			}
+			step.Users, err = s.queryPagedUsers(`
+				SELECT DISTINCT es.effective_user_id, COALESCE(es.user_name, es.user_email, 'Unknown')
+				FROM effective_shifts es
+				WHERE es.group_id::text = $1
+				AND es.start_time <= $2 AND es.end_time >= $2
+			`, scheduleGroupID, at)
+		case db.EscalationTargetExternal:
+			step.Error = "external targets are not paged, the webhook is called instead"
+		default:
+			step.Error = fmt.Sprintf("unknown target type %s", level.TargetType)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve escalation level %d: %w", level.LevelNumber, err)
+		}
+		if step.Error == "" && len(step.Users) == 0 {
+			step.Error = "nobody would be paged"
+		}
+
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// queryPagedUsers returns the users selected by an (id, name) query
+func (s *EscalationService) queryPagedUsers(query string, args ...interface{}) ([]PagedUser, error) {
+	rows, err := s.PG.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []PagedUser{}
+	for rows.Next() {
+		var user PagedUser
+		var name sql.NullString
+		if err := rows.Scan(&user.ID, &name); err != nil {
+			return nil, err
+		}
+		user.Name = name.String
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulatePolicy_ResolvesEachLevel(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	createdAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	at := time.Date(2026, 10, 20, 3, 0, 0, 0, time.UTC)
+	expectGetEscalationPolicy(mockDB, "Primary", createdAt)
+	mockDB.ExpectQuery(`FROM escalation_levels`).
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "policy_id", "level_number", "target_type", "target_id",
+			"timeout_minutes", "notification_methods", "message_template", "created_at",
+		}).
+			AddRow("level-1", "policy-1", 1, "user", "user-1", 0, []byte(`["email"]`), "", createdAt).
+			AddRow("level-2", "policy-1", 2, "scheduler", "sched-1", 10, []byte(`["sms"]`), "", createdAt).
+			AddRow("level-3", "policy-1", 3, "group", "group-2", 15, []byte(`["sms"]`), "", createdAt).
+			AddRow("level-4", "policy-1", 4, "current_schedule", nil, 20, []byte(`["sms"]`), "", createdAt).
+			AddRow("level-5", "policy-1", 5, "external", "https://hooks.example.com", 5, []byte(`["webhook"]`), "", createdAt))
+
+	mockDB.ExpectQuery(`SELECT id, COALESCE\(name, email, 'Unknown'\) FROM users WHERE id::text = \$1`).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("user-1", "Alice"))
+	mockDB.ExpectQuery(`FROM effective_shifts es\s+WHERE es.scheduler_id::text = \$1 AND es.group_id::text = \$2\s+AND es.start_time <= \$3 AND es.end_time >= \$3`).
+		WithArgs("sched-1", "group-1", at).
+		WillReturnRows(sqlmock.NewRows([]string{"effective_user_id", "user_name"}).AddRow("user-2", "Bob"))
+	mockDB.ExpectQuery(`FROM effective_shifts es\s+WHERE es.group_id::text = \$1\s+AND es.start_time <= \$2 AND es.end_time >= \$2`).
+		WithArgs("group-2", at).
+		WillReturnRows(sqlmock.NewRows([]string{"effective_user_id", "user_name"}).AddRow("user-3", "Carol").AddRow("user-4", "Dan"))
+	// Nobody covers the policy's own group at 3am
+	mockDB.ExpectQuery(`FROM effective_shifts es\s+WHERE es.group_id::text = \$1`).
+		WithArgs("group-1", at).
+		WillReturnRows(sqlmock.NewRows([]string{"effective_user_id", "user_name"}))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	steps, err := service.SimulatePolicy("policy-1", "", at)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []StepResolution{
+		{LevelNumber: 1, TargetType: "user", TargetID: "user-1", Users: []PagedUser{{ID: "user-1", Name: "Alice"}}, TimeoutMinutes: 5},
+		{LevelNumber: 2, TargetType: "scheduler", TargetID: "sched-1", Users: []PagedUser{{ID: "user-2", Name: "Bob"}}, TimeoutMinutes: 10},
+		{LevelNumber: 3, TargetType: "group", TargetID: "group-2", Users: []PagedUser{{ID: "user-3", Name: "Carol"}, {ID: "user-4", Name: "Dan"}}, TimeoutMinutes: 15},
+		{LevelNumber: 4, TargetType: "current_schedule", Users: []PagedUser{}, TimeoutMinutes: 20, Error: "nobody would be paged"},
+		{LevelNumber: 5, TargetType: "external", TargetID: "https://hooks.example.com", Users: []PagedUser{}, TimeoutMinutes: 5,
+			Error: "external targets are not paged, the webhook is called instead"},
+	}, steps)
+	// Only reads: any notification or write would be an unexpected call
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestSimulatePolicy_PolicyNotFound(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`FROM escalation_policies`).
+		WithArgs("policy-1").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = NewEscalationService(pg, nil, nil, nil).SimulatePolicy("policy-1", "group-1", time.Now())
+
+	assert.EqualError(t, err, "escalation policy not found")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,846 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTwoStepUserPolicy() *db.EscalationPolicyWithLevels {
+	policy := &db.EscalationPolicyWithLevels{
+		Levels: []db.EscalationLevel{
+			{ID: "level-1", LevelNumber: 1, TargetType: "user", TargetID: "user-1"},
+			{ID: "level-2", LevelNumber: 2, TargetType: "user", TargetID: "user-2"},
+		},
+	}
+	policy.ID = "policy-1"
+	policy.EscalateAfterMinutes = 5
+	return policy
+}
+
+// userTwoDeliveries is recorded for user-2 paged over the default channels without FCM
+const userTwoDeliveries = `[{"user_id":"user-2","channel":"push","status":"skipped","error":"push notifications are not configured"},` +
+	`{"user_id":"user-2","channel":"slack","status":"queued"}]`
+
+func expectEscalationQueued(mockDB sqlmock.Sqlmock) {
+	mockDB.ExpectExec(`SELECT pgmq.send\(\$1, \$2\)`).
+		WithArgs(escalationNotificationQueue, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectGroupMembers(mockDB sqlmock.Sqlmock, groupID, escalationMethod string, userIDs ...string) {
+	mockDB.ExpectQuery(`SELECT COALESCE\(escalation_method, ''\) FROM groups WHERE id = \$1 AND is_active = true`).
+		WithArgs(groupID).
+		WillReturnRows(sqlmock.NewRows([]string{"escalation_method"}).AddRow(escalationMethod))
+	members := sqlmock.NewRows([]string{"user_id"})
+	for _, userID := range userIDs {
+		members.AddRow(userID)
+	}
+	mockDB.ExpectQuery(`FROM memberships m.*ORDER BY gm.escalation_order ASC NULLS LAST, m.created_at ASC`).
+		WithArgs(groupID).
+		WillReturnRows(members)
+}
+
+func TestExecuteEscalationStep_SkipsUnavailableUser(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Level 1: user-1 is on vacation, so it is recorded as skipped
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"reason"}).AddRow("vacation"))
+	mockDB.ExpectExec("INSERT INTO alert_escalations").
+		WithArgs(sqlmock.AnyArg(), "alert-1", "policy-1", 1, "user", "user-1", "skipped", "user unavailable: vacation", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Level 2 fires immediately for user-2
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-2").
+		WillReturnError(sql.ErrNoRows)
+	mockDB.ExpectQuery("INSERT INTO alert_escalations").
+		WithArgs(sqlmock.AnyArg(), "alert-1", "policy-1", 2, "user", "user-2", "executing", "", sqlmock.AnyArg(), sqlmock.AnyArg(), "alert-1:2:user:user-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("esc-2"))
+	expectEscalationQueued(mockDB)
+	mockDB.ExpectExec("UPDATE alert_escalations SET status").
+		WithArgs("completed", "", userTwoDeliveries, sqlmock.AnyArg(), "esc-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	err = service.executeEscalationStep(&db.Alert{ID: "alert-1", Title: "disk full"}, newTwoStepUserPolicy(), 1)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestExecuteEscalationStep_NoAvailableTargets(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-2").
+		WillReturnRows(sqlmock.NewRows([]string{"reason"}).AddRow("dnd"))
+	mockDB.ExpectExec("INSERT INTO alert_escalations").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	err = service.executeEscalationStep(&db.Alert{ID: "alert-1", Title: "disk full"}, newTwoStepUserPolicy(), 2)
+
+	assert.Error(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestExecuteEscalationStep_RetryDoesNotRenotify(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	policy := newTwoStepUserPolicy()
+	policy.Levels = append(policy.Levels, db.EscalationLevel{ID: "level-1b", LevelNumber: 1, TargetType: "group", TargetID: "group-1"})
+	claim := `INSERT INTO alert_escalations .* ON CONFLICT \(idempotency_key\) DO UPDATE .* WHERE alert_escalations.status = 'failed'\s+RETURNING id`
+
+	// The first run already notified user-1: the claim returns no row, so nothing is sent or updated
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-1").
+		WillReturnError(sql.ErrNoRows)
+	mockDB.ExpectQuery(claim).
+		WithArgs(sqlmock.AnyArg(), "alert-1", "policy-1", 1, "user", "user-1", "executing", "", sqlmock.AnyArg(), sqlmock.AnyArg(), "alert-1:1:user:user-1").
+		WillReturnError(sql.ErrNoRows)
+
+	// Sending to group-1 failed, so the retry claims its record again and re-sends
+	mockDB.ExpectQuery(claim).
+		WithArgs(sqlmock.AnyArg(), "alert-1", "policy-1", 1, "group", "group-1", "executing", "", sqlmock.AnyArg(), sqlmock.AnyArg(), "alert-1:1:group:group-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("esc-group"))
+	expectGroupMembers(mockDB, "group-1", db.EscalationMethodParallel, "user-3")
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-3").
+		WillReturnError(sql.ErrNoRows)
+	expectEscalationQueued(mockDB)
+	mockDB.ExpectExec("UPDATE alert_escalations SET status").
+		WithArgs("completed", "", sqlmock.AnyArg(), sqlmock.AnyArg(), "esc-group").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO scheduled_escalations").
+		WithArgs("alert-1", "policy-1", 2, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	err = service.executeEscalationStep(&db.Alert{ID: "alert-1", Title: "disk full"}, policy, 1)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestExecuteEscalationStep_RetriedStepAlreadyNotified(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Every target of the step was notified before the retry; the step still counts as
+	// done rather than failing
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-2").
+		WillReturnError(sql.ErrNoRows)
+	mockDB.ExpectQuery("INSERT INTO alert_escalations").
+		WithArgs(sqlmock.AnyArg(), "alert-1", "policy-1", 2, "user", "user-2", "executing", "", sqlmock.AnyArg(), sqlmock.AnyArg(), "alert-1:2:user:user-2").
+		WillReturnError(sql.ErrNoRows)
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	err = service.executeEscalationStep(&db.Alert{ID: "alert-1", Title: "disk full"}, newTwoStepUserPolicy(), 2)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func alertEscalationColumns() []string {
+	return []string{"id", "alert_id", "escalation_policy_id", "escalation_level", "target_type", "target_id",
+		"status", "error_message", "created_at", "updated_at", "acknowledged_at", "acknowledged_by",
+		"response_time_seconds", "notification_methods", "target_name", "deliveries"}
+}
+
+func TestGetAlertEscalations_StatusFilter(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	now := time.Now()
+	epoch := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockDB.ExpectQuery(`FROM alert_escalations\s+WHERE alert_id = \$1 AND status = \$2 ORDER BY created_at ASC LIMIT \$3 OFFSET \$4`).
+		WithArgs("alert-1", "failed", 10, 20).
+		WillReturnRows(sqlmock.NewRows(alertEscalationColumns()).
+			AddRow("esc-3", "alert-1", "policy-1", 2, "user", "user-2", "failed", "no devices", now, now, epoch, "", 0, []byte(`["push"]`), "Bob",
+				[]byte(`[{"user_id":"user-2","channel":"push","status":"failed","error":"no devices"}]`)))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	escalations, err := service.GetAlertEscalations("alert-1", map[string]interface{}{
+		"status": "failed",
+		"limit":  10,
+		"offset": 20,
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, escalations, 1) {
+		assert.Equal(t, "failed", escalations[0].Status)
+		assert.Nil(t, escalations[0].AcknowledgedAt)
+		assert.Equal(t, []string{"push"}, escalations[0].NotificationMethods)
+		assert.Equal(t, []db.EscalationDelivery{{UserID: "user-2", Channel: "push", Status: "failed", Error: "no devices"}}, escalations[0].Deliveries)
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetAlertEscalations_NoFilters(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`WHERE alert_id = \$1 ORDER BY created_at ASC$`).
+		WithArgs("alert-1").
+		WillReturnRows(sqlmock.NewRows(alertEscalationColumns()))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	escalations, err := service.GetAlertEscalations("alert-1", nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, escalations)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestListActiveEscalations_ExecutingInOrg(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	now := time.Now()
+	epoch := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockDB.ExpectQuery(`JOIN escalation_policies ep ON ep.id = ae.escalation_policy_id.*WHERE ep.organization_id::text = \$1 AND ae.status = 'executing'\s+ORDER BY ae.created_at ASC`).
+		WithArgs("org-1").
+		WillReturnRows(sqlmock.NewRows(alertEscalationColumns()).
+			AddRow("esc-1", "alert-1", "policy-1", 1, "user", "user-1", "executing", "", now, now, epoch, "", 0, []byte(`["sms"]`), "Alice", []byte(`[]`)).
+			AddRow("esc-2", "inc-2", "policy-2", 2, "group", "group-1", "executing", "", now, now, epoch, "", 0, nil, "Platform", []byte(`[]`)))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	escalations, err := service.ListActiveEscalations("org-1")
+
+	assert.NoError(t, err)
+	if assert.Len(t, escalations, 2) {
+		assert.Equal(t, "executing", escalations[0].Status)
+		assert.Equal(t, "Alice", escalations[0].TargetName)
+		assert.Equal(t, []string{"sms"}, escalations[0].NotificationMethods)
+		assert.Equal(t, "Platform", escalations[1].TargetName)
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestListActiveEscalations_RequiresOrg(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	_, err = NewEscalationService(pg, nil, nil, nil).ListActiveEscalations("")
+
+	assert.EqualError(t, err, "organization_id is required")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func expectTimeoutEscalationSetup(mockDB sqlmock.Sqlmock, status string) {
+	expectIncidentEscalationState(mockDB, status, 1, "pending", 0, 1)
+}
+
+func expectIncidentEscalationState(mockDB sqlmock.Sqlmock, status string, level int, escalationStatus string, repeatCount, repeatMaxTimes int) {
+	mockDB.ExpectQuery("SELECT id, title, severity, status, escalation_policy_id, current_escalation_level").
+		WithArgs("inc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "severity", "status", "escalation_policy_id", "current_escalation_level", "escalation_status", "group_id", "escalation_repeat_count", "repeat_max_times"}).
+			AddRow("inc-1", "Database down", "critical", status, "policy-1", level, escalationStatus, "group-1", repeatCount, repeatMaxTimes))
+}
+
+func expectTwoLevelPolicy(mockDB sqlmock.Sqlmock) {
+	mockDB.ExpectQuery("SELECT id, policy_id, level_number, target_type, target_id, timeout_minutes").
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "policy_id", "level_number", "target_type", "target_id", "timeout_minutes", "message_template"}).
+			AddRow("level-1", "policy-1", 1, "user", "user-1", 5, "").
+			AddRow("level-2", "policy-1", 2, "user", "user-2", 5, ""))
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-2").
+		WillReturnError(sql.ErrNoRows)
+}
+
+func TestEscalateIncidentOnTimeout(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectTimeoutEscalationSetup(mockDB, "triggered")
+	expectTwoLevelPolicy(mockDB)
+	mockDB.ExpectExec(`UPDATE incidents\s+SET current_escalation_level = \$1.*WHERE id = \$4 AND status = \$5`).
+		WithArgs(2, "completed", "user-2", "inc-1", "triggered").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery("SELECT COALESCE\\(name, email, 'Unknown'\\) FROM users").
+		WithArgs("user-2").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Bob"))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "escalated",
+			`{"assigned_to":"Bob","assigned_to_id":"user-2","escalation_level":2,"reason":"timeout_escalation","target_id":"user-2","target_type":"user"}`,
+			nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "escalation_completed", sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	result, err := service.EscalateIncidentOnTimeout("inc-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.NewLevel)
+	assert.Equal(t, "user-2", result.AssignedUserID)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestEscalateIncidentOnTimeout_RepeatsPolicy(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewIncidentService(pg, nil, nil)
+
+	// First of 2 passes: reaching the last level keeps the chain pending, with no completion event
+	expectIncidentEscalationState(mockDB, "triggered", 1, "pending", 0, 2)
+	expectTwoLevelPolicy(mockDB)
+	mockDB.ExpectExec(`UPDATE incidents\s+SET current_escalation_level = \$1.*WHERE id = \$4 AND status = \$5`).
+		WithArgs(2, "pending", "user-2", "inc-1", "triggered").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery("SELECT COALESCE\\(name, email, 'Unknown'\\) FROM users").
+		WithArgs("user-2").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Bob"))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "escalated", sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := service.EscalateIncidentOnTimeout("inc-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", result.EscalationStatus)
+	assert.False(t, result.HasMoreLevels)
+
+	// The last level timed out too: the second pass restarts from level 1 and records the repeat
+	expectIncidentEscalationState(mockDB, "triggered", 2, "pending", 0, 2)
+	mockDB.ExpectQuery("SELECT id, policy_id, level_number, target_type, target_id, timeout_minutes").
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "policy_id", "level_number", "target_type", "target_id", "timeout_minutes", "message_template"}).
+			AddRow("level-1", "policy-1", 1, "user", "user-1", 5, "").
+			AddRow("level-2", "policy-1", 2, "user", "user-2", 5, ""))
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-1").
+		WillReturnError(sql.ErrNoRows)
+	mockDB.ExpectExec(`UPDATE incidents\s+SET current_escalation_level = \$1.*, escalation_repeat_count = \$3.*WHERE id = \$5 AND status = \$6`).
+		WithArgs(1, "pending", 1, "user-1", "inc-1", "triggered").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery("SELECT COALESCE\\(name, email, 'Unknown'\\) FROM users").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Alice"))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "escalated",
+			`{"assigned_to":"Alice","assigned_to_id":"user-1","escalation_level":1,"reason":"timeout_escalation","repeat":1,"target_id":"user-1","target_type":"user"}`,
+			nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err = service.EscalateIncidentOnTimeout("inc-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.NewLevel)
+	assert.Equal(t, "pending", result.EscalationStatus)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestEscalateIncidentOnTimeout_LastPassCompletes(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// The second and last pass reached its last level: the chain completes instead of restarting
+	expectIncidentEscalationState(mockDB, "triggered", 2, "pending", 1, 2)
+	mockDB.ExpectQuery("SELECT id, policy_id, level_number, target_type, target_id, timeout_minutes").
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "policy_id", "level_number", "target_type", "target_id", "timeout_minutes", "message_template"}).
+			AddRow("level-1", "policy-1", 1, "user", "user-1", 5, "").
+			AddRow("level-2", "policy-1", 2, "user", "user-2", 5, ""))
+	mockDB.ExpectExec(`UPDATE incidents SET escalation_status = 'completed'`).
+		WithArgs("inc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := NewIncidentService(pg, nil, nil).EscalateIncidentOnTimeout("inc-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "completed", result.EscalationStatus)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestEscalateIncidentOnTimeout_AcknowledgedBeforeUpdate(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Picked up while triggered, acknowledged before the escalation lands
+	expectTimeoutEscalationSetup(mockDB, "triggered")
+	expectTwoLevelPolicy(mockDB)
+	mockDB.ExpectExec("UPDATE incidents").
+		WithArgs(2, "completed", "user-2", "inc-1", "triggered").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.EscalateIncidentOnTimeout("inc-1")
+
+	assert.EqualError(t, err, "incident is no longer triggered")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestEscalateIncidentOnTimeout_AlreadyAcknowledged(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectTimeoutEscalationSetup(mockDB, "acknowledged")
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.EscalateIncidentOnTimeout("inc-1")
+
+	assert.EqualError(t, err, "incident is no longer triggered")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCreateEscalationPolicy_RejectsSMSWithoutProvider(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Org has no notification_channels configured, so SMS stays disabled
+	mockDB.ExpectQuery(`settings->'notification_channels'`).
+		WithArgs("group-1").
+		WillReturnRows(sqlmock.NewRows([]string{"notification_channels"}).AddRow(nil))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	_, err = service.CreateEscalationPolicy("group-1", db.EscalationPolicy{
+		Name: "Primary",
+		Levels: []db.EscalationLevel{
+			{LevelNumber: 1, TargetType: "user", TargetID: "user-1", NotificationMethods: []string{"email", "sms"}},
+		},
+	})
+
+	assert.ErrorIs(t, err, ErrInvalidNotificationMethod)
+	assert.EqualError(t, err, "invalid notification method: 'sms' for level 1 is not configured for this organization")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCreateEscalationPolicy_RejectsPrivateExternalTarget(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`settings->'notification_channels'`).
+		WithArgs("group-1").
+		WillReturnRows(sqlmock.NewRows([]string{"notification_channels"}).AddRow(nil))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	_, err = service.CreateEscalationPolicy("group-1", db.EscalationPolicy{
+		Name: "Primary",
+		Levels: []db.EscalationLevel{
+			{LevelNumber: 1, TargetType: "external", TargetID: "http://169.254.169.254/latest/meta-data/"},
+		},
+	})
+
+	assert.ErrorIs(t, err, ErrInvalidExternalTarget)
+	assert.NoError(t, mockDB.ExpectationsWereMet(), "nothing should be written")
+}
+
+func TestGetGroupNotificationChannels_OrgEnablesSMS(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`settings->'notification_channels'`).
+		WithArgs("group-1").
+		WillReturnRows(sqlmock.NewRows([]string{"notification_channels"}).AddRow(`{"sms": true, "carrier_pigeon": true}`))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	channels, err := service.GetGroupNotificationChannels("group-1")
+
+	assert.NoError(t, err)
+	assert.True(t, channels["sms"])
+	assert.False(t, channels["phone"])
+	assert.NotContains(t, channels, "carrier_pigeon")
+	assert.NoError(t, validateNotificationMethods([]db.EscalationLevel{
+		{LevelNumber: 1, NotificationMethods: []string{"sms"}},
+	}, channels))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestValidateNotificationMethods_UnknownMethod(t *testing.T) {
+	err := validateNotificationMethods([]db.EscalationLevel{
+		{LevelNumber: 2, NotificationMethods: []string{"fax"}},
+	}, defaultNotificationChannels)
+
+	assert.ErrorIs(t, err, ErrInvalidNotificationMethod)
+	assert.EqualError(t, err, "invalid notification method 'fax' for level 2")
+}
+
+func TestCreateEscalationPolicy_RejectsLevelGap(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	_, err = service.CreateEscalationPolicy("group-1", db.EscalationPolicy{
+		Name: "Primary",
+		Levels: []db.EscalationLevel{
+			{LevelNumber: 1, TargetType: "user", TargetID: "user-1"},
+			{LevelNumber: 1, TargetType: "user", TargetID: "user-2"},
+			{LevelNumber: 3, TargetType: "user", TargetID: "user-3"},
+		},
+	})
+
+	assert.ErrorIs(t, err, ErrInvalidLevelNumbers)
+	assert.EqualError(t, err, "invalid escalation level numbers: level 2 is missing, levels must be numbered from 1 without gaps")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestUpdateEscalationPolicy_RejectsDuplicateTarget(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	_, err = service.UpdateEscalationPolicy("policy-1", db.EscalationPolicy{
+		Name: "Primary",
+		Levels: []db.EscalationLevel{
+			{LevelNumber: 1, TargetType: "user", TargetID: "user-1"},
+			{LevelNumber: 2, TargetType: "user", TargetID: "user-2"},
+			{LevelNumber: 2, TargetType: "user", TargetID: "user-2"},
+		},
+	})
+
+	assert.ErrorIs(t, err, ErrInvalidLevelNumbers)
+	assert.EqualError(t, err, "invalid escalation level numbers: level 2 lists user target 'user-2' more than once")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestValidateLevelNumbers(t *testing.T) {
+	// Several targets on one level are paged in parallel
+	assert.NoError(t, validateLevelNumbers([]db.EscalationLevel{
+		{LevelNumber: 2, TargetType: "user", TargetID: "user-3"},
+		{LevelNumber: 1, TargetType: "user", TargetID: "user-1"},
+		{LevelNumber: 1, TargetType: "group", TargetID: "group-1"},
+		{LevelNumber: 1, TargetType: "current_schedule"},
+	}))
+	assert.NoError(t, validateLevelNumbers(nil))
+
+	assert.EqualError(t, validateLevelNumbers([]db.EscalationLevel{{LevelNumber: 0, TargetType: "user", TargetID: "user-1"}}),
+		"invalid escalation level numbers: level_number 0 must be 1 or greater")
+	assert.EqualError(t, validateLevelNumbers([]db.EscalationLevel{{LevelNumber: 2, TargetType: "user", TargetID: "user-1"}}),
+		"invalid escalation level numbers: level 1 is missing, levels must be numbered from 1 without gaps")
+}
+
+func TestPreviewNextEscalation_MatchesManualEscalation(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewIncidentService(pg, nil, nil)
+
+	// Preview only reads: no UPDATE and no events
+	expectTimeoutEscalationSetup(mockDB, "acknowledged")
+	expectTwoLevelPolicy(mockDB)
+	mockDB.ExpectQuery("SELECT COALESCE\\(name, email, 'Unknown'\\) FROM users").
+		WithArgs("user-2").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Bob"))
+
+	preview, err := service.PreviewNextEscalation("inc-1")
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+
+	expectTimeoutEscalationSetup(mockDB, "acknowledged")
+	expectTwoLevelPolicy(mockDB)
+	mockDB.ExpectExec(`UPDATE incidents\s+SET current_escalation_level = \$1.*WHERE id = \$4$`).
+		WithArgs(2, "completed", "user-2", "inc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery("SELECT COALESCE\\(name, email, 'Unknown'\\) FROM users").
+		WithArgs("user-2").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Bob"))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "escalated", sqlmock.AnyArg(), "user-9").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "escalation_completed", sqlmock.AnyArg(), "user-9").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := service.ManualEscalateIncident("inc-1", "user-9")
+	assert.NoError(t, err)
+	assert.Equal(t, result, preview)
+	assert.Equal(t, "user-2", preview.AssignedUserID)
+	assert.Equal(t, "Bob", preview.AssignedToName)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestPreviewNextEscalation_AtMaximumLevel(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectIncidentEscalationState(mockDB, "triggered", 2, "completed", 0, 3)
+	mockDB.ExpectQuery("SELECT id, policy_id, level_number, target_type, target_id, timeout_minutes").
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "policy_id", "level_number", "target_type", "target_id", "timeout_minutes", "message_template"}).
+			AddRow("level-1", "policy-1", 1, "user", "user-1", 5, "").
+			AddRow("level-2", "policy-1", 2, "user", "user-2", 5, ""))
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.PreviewNextEscalation("inc-1")
+
+	assert.ErrorIs(t, err, ErrMaxEscalationLevel)
+	assert.EqualError(t, err, "already at maximum escalation level (2)")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func groupPolicyFilters(extra map[string]interface{}) map[string]interface{} {
+	filters := map[string]interface{}{
+		"current_user_id": "user-1",
+		"current_org_id":  "org-1",
+		"group_id":        "group-1",
+		"active_only":     true,
+	}
+	for k, v := range extra {
+		filters[k] = v
+	}
+	return filters
+}
+
+func groupPolicyColumns() []string {
+	return []string{"id", "name", "description", "is_active", "repeat_max_times", "created_at", "updated_at", "created_by", "services_count"}
+}
+
+func TestGetGroupEscalationPoliciesWithFilters_Search(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`AND ep\.is_active = \$4 AND ep\.name ILIKE \$5 ORDER BY ep\.created_at DESC$`).
+		WithArgs("group-1", "org-1", "user-1", true, "%primary%").
+		WillReturnRows(sqlmock.NewRows(groupPolicyColumns()).
+			AddRow("policy-1", "Primary on-call", "", true, 0, time.Now(), time.Now(), "", 2))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	policies, err := service.GetGroupEscalationPoliciesWithFilters(groupPolicyFilters(map[string]interface{}{"search": "primary"}))
+
+	assert.NoError(t, err)
+	assert.Len(t, policies, 1)
+	assert.Equal(t, "Primary on-call", policies[0].Name)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetGroupEscalationPoliciesWithFilters_SortByUsage(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`ORDER BY services_count DESC, ep\.name ASC$`).
+		WithArgs("group-1", "org-1", "user-1", true).
+		WillReturnRows(sqlmock.NewRows(groupPolicyColumns()).
+			AddRow("policy-2", "Database", "", true, 0, time.Now(), time.Now(), "", 5).
+			AddRow("policy-1", "Frontend", "", true, 0, time.Now(), time.Now(), "", 1))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	policies, err := service.GetGroupEscalationPoliciesWithFilters(groupPolicyFilters(map[string]interface{}{"sort": "usage"}))
+
+	assert.NoError(t, err)
+	assert.Len(t, policies, 2)
+	assert.Equal(t, []int{5, 1}, []int{policies[0].ServicesCount, policies[1].ServicesCount})
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func expectEscalationPolicyUsage(mockDB sqlmock.Sqlmock, policyID string, services, incidents []string) {
+	rows := sqlmock.NewRows([]string{"kind", "name"})
+	for _, name := range services {
+		rows.AddRow("service", name)
+	}
+	for _, title := range incidents {
+		rows.AddRow("incident", title)
+	}
+	mockDB.ExpectQuery(`FROM services WHERE escalation_policy_id = \$1\s+UNION ALL\s+.*FROM incidents WHERE escalation_policy_id = \$1 AND status <> 'resolved'`).
+		WithArgs(policyID).
+		WillReturnRows(rows)
+}
+
+func expectEscalationPolicyUnlinked(mockDB sqlmock.Sqlmock, policyID string) {
+	mockDB.ExpectExec(`UPDATE services SET escalation_policy_id = NULL WHERE escalation_policy_id = \$1`).
+		WithArgs(policyID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mockDB.ExpectExec(`UPDATE incidents SET escalation_policy_id = NULL WHERE escalation_policy_id = \$1 AND status <> 'resolved'`).
+		WithArgs(policyID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectEscalationPolicyLocked expects the policy to be looked up, and locked, within group-1
+func expectEscalationPolicyLocked(mockDB sqlmock.Sqlmock, policyID string, inGroup bool) {
+	rows := sqlmock.NewRows([]string{"id"})
+	if inGroup {
+		rows.AddRow(policyID)
+	}
+	mockDB.ExpectQuery(`SELECT id FROM escalation_policies WHERE id = \$1 AND group_id::text = \$2 FOR UPDATE`).
+		WithArgs(policyID, "group-1").
+		WillReturnRows(rows)
+}
+
+func expectEscalationPolicyDelete(mockDB sqlmock.Sqlmock, policyID string, rowsAffected int64) {
+	mockDB.ExpectExec(`DELETE FROM escalation_levels WHERE policy_id = \$1`).
+		WithArgs(policyID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mockDB.ExpectExec(`DELETE FROM escalation_policies WHERE id = \$1 AND group_id::text = \$2`).
+		WithArgs(policyID, "group-1").
+		WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+}
+
+func TestBulkDeleteEscalationPolicies_SkipsInUse(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectBegin()
+	expectEscalationPolicyLocked(mockDB, "policy-1", true)
+	expectEscalationPolicyUsage(mockDB, "policy-1", nil, nil)
+	expectEscalationPolicyDelete(mockDB, "policy-1", 1)
+	mockDB.ExpectCommit()
+
+	mockDB.ExpectBegin()
+	expectEscalationPolicyLocked(mockDB, "policy-2", true)
+	expectEscalationPolicyUsage(mockDB, "policy-2", []string{"Checkout", "Payments"}, []string{"Database down"})
+	mockDB.ExpectRollback()
+
+	// Another group's policy is left alone, usage included
+	mockDB.ExpectBegin()
+	expectEscalationPolicyLocked(mockDB, "policy-3", false)
+	mockDB.ExpectRollback()
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	results := service.BulkDeleteEscalationPolicies("group-1", []string{"policy-1", "policy-2", "policy-1", " ", "policy-3"}, false)
+
+	assert.Equal(t, []EscalationPolicyDeleteResult{
+		{ID: "policy-1", Deleted: true},
+		{ID: "policy-2", Error: "escalation policy is in use by 2 services (Checkout, Payments) and 1 open incidents (Database down)"},
+		{ID: "policy-3", Error: "escalation policy not found: policy-3"},
+	}, results)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestBulkDeleteEscalationPolicies_Force(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Forced deletes skip the usage check and unlink services and open incidents instead
+	mockDB.ExpectBegin()
+	expectEscalationPolicyLocked(mockDB, "policy-2", true)
+	expectEscalationPolicyUnlinked(mockDB, "policy-2")
+	expectEscalationPolicyDelete(mockDB, "policy-2", 1)
+	mockDB.ExpectCommit()
+
+	// Forcing doesn't reach past the group either
+	mockDB.ExpectBegin()
+	expectEscalationPolicyLocked(mockDB, "policy-9", false)
+	mockDB.ExpectRollback()
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	results := service.BulkDeleteEscalationPolicies("group-1", []string{"policy-2", "policy-9"}, true)
+
+	assert.Equal(t, []EscalationPolicyDeleteResult{
+		{ID: "policy-2", Deleted: true},
+		{ID: "policy-9", Error: "escalation policy not found: policy-9"},
+	}, results)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestDeleteEscalationPolicy_ListsUsers(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectBegin()
+	expectEscalationPolicyLocked(mockDB, "policy-1", true)
+	expectEscalationPolicyUsage(mockDB, "policy-1", []string{"Checkout"}, nil)
+	mockDB.ExpectRollback()
+
+	err = NewEscalationService(pg, nil, nil, nil).DeleteEscalationPolicy("group-1", "policy-1", false)
+
+	assert.ErrorIs(t, err, ErrEscalationPolicyInUse)
+	assert.EqualError(t, err, "escalation policy is in use by 1 services (Checkout)")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestDeleteEscalationPolicy_Force(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// The references are cleared in the same transaction as the delete
+	mockDB.ExpectBegin()
+	expectEscalationPolicyLocked(mockDB, "policy-1", true)
+	expectEscalationPolicyUnlinked(mockDB, "policy-1")
+	expectEscalationPolicyDelete(mockDB, "policy-1", 1)
+	mockDB.ExpectCommit()
+
+	err = NewEscalationService(pg, nil, nil, nil).DeleteEscalationPolicy("group-1", "policy-1", true)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// ErrPolicyVersionNotFound is returned when no version of a policy was in effect at a given time
+var ErrPolicyVersionNotFound = errors.New("no escalation policy version in effect at that time")
+
+// policyVersionStart is when the policy's current version took effect: the end of the last
+// snapshotted version, or the policy's creation
+const policyVersionStart = `
+	SELECT version,
+	       COALESCE((SELECT MAX(valid_to) FROM escalation_policy_versions WHERE policy_id = $1), created_at)
+	FROM escalation_policies
+	WHERE id = $1`
+
+// snapshotEscalationPolicyTx adds the policy as it is before an update to its version history
+// and moves it to the next version, which it returns
+func snapshotEscalationPolicyTx(tx *sql.Tx, current db.EscalationPolicyWithLevels) (int, error) {
+	var version int
+	var validFrom time.Time
+	if err := tx.QueryRow(policyVersionStart+" FOR UPDATE", current.ID).Scan(&version, &validFrom); err != nil {
+		return 0, fmt.Errorf("failed to get escalation policy version: %w", err)
+	}
+
+	snapshot, err := json.Marshal(current)
+	if err != nil {
+		return 0, fmt.Errorf("failed to serialize escalation policy version: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO escalation_policy_versions (policy_id, version, snapshot, valid_from, valid_to)
+		VALUES ($1, $2, $3, $4, NOW())
+	`, current.ID, version, string(snapshot), validFrom); err != nil {
+		return 0, fmt.Errorf("failed to record escalation policy version: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE escalation_policies SET version = $2 WHERE id = $1`, current.ID, version+1); err != nil {
+		return 0, fmt.Errorf("failed to update escalation policy version: %w", err)
+	}
+	return version + 1, nil
+}
+
+// GetPolicyVersions returns every version of an escalation policy, the one in effect first
+func (s *EscalationService) GetPolicyVersions(policyID string) ([]db.EscalationPolicyVersion, error) {
+	current, err := s.currentPolicyVersion(policyID)
+	if err != nil {
+		return nil, err
+	}
+	versions := []db.EscalationPolicyVersion{current}
+
+	rows, err := s.PG.Query(`
+		SELECT version, snapshot, valid_from, valid_to
+		FROM escalation_policy_versions
+		WHERE policy_id = $1
+		ORDER BY version DESC
+	`, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalation policy versions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		version, err := scanPolicyVersion(rows, policyID)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+// GetPolicyAtTime returns the version of an escalation policy that was in effect at the given time
+func (s *EscalationService) GetPolicyAtTime(policyID string, at time.Time) (db.EscalationPolicyVersion, error) {
+	row := s.PG.QueryRow(`
+		SELECT version, snapshot, valid_from, valid_to
+		FROM escalation_policy_versions
+		WHERE policy_id = $1 AND valid_from <= $2 AND valid_to > $2
+		ORDER BY version DESC
+		LIMIT 1
+	`, policyID, at)
+	version, err := scanPolicyVersion(row, policyID)
+	if err == nil {
+		return version, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return db.EscalationPolicyVersion{}, err
+	}
+
+	// Not in the history, so either the current version or before the policy existed
+	current, err := s.currentPolicyVersion(policyID)
+	if err != nil {
+		return db.EscalationPolicyVersion{}, err
+	}
+	if at.Before(current.ValidFrom) {
+		return db.EscalationPolicyVersion{}, ErrPolicyVersionNotFound
+	}
+	return current, nil
+}
+
+// currentPolicyVersion returns the version of an escalation policy in effect now
+func (s *EscalationService) currentPolicyVersion(policyID string) (db.EscalationPolicyVersion, error) {
+	version := db.EscalationPolicyVersion{PolicyID: policyID}
+	if err := s.PG.QueryRow(policyVersionStart, policyID).Scan(&version.Version, &version.ValidFrom); err != nil {
+		if err == sql.ErrNoRows {
+			return version, fmt.Errorf("escalation policy not found")
+		}
+		return version, fmt.Errorf("failed to get escalation policy version: %w", err)
+	}
+
+	policy, err := s.GetEscalationPolicyWithLevels(policyID)
+	if err != nil {
+		return version, err
+	}
+	version.Policy = policy
+	return version, nil
+}
+
+// scanPolicyVersion scans a version, snapshot, valid_from, valid_to row of the version history
+func scanPolicyVersion(row interface{ Scan(...interface{}) error }, policyID string) (db.EscalationPolicyVersion, error) {
+	version := db.EscalationPolicyVersion{PolicyID: policyID}
+	var snapshot []byte
+	var validTo time.Time
+	if err := row.Scan(&version.Version, &snapshot, &version.ValidFrom, &validTo); err != nil {
+		if err == sql.ErrNoRows {
+			return version, err
+		}
+		return version, fmt.Errorf("failed to scan escalation policy version: %w", err)
+	}
+	if err := json.Unmarshal(snapshot, &version.Policy); err != nil {
+		return version, fmt.Errorf("failed to parse escalation policy version %d: %w", version.Version, err)
+	}
+	version.ValidTo = &validTo
+	return version, nil
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// policySnapshotArg matches a serialized policy version with the given name and level targets
+type policySnapshotArg struct {
+	name    string
+	targets []string
+}
+
+func (a policySnapshotArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
+	var policy db.EscalationPolicyWithLevels
+	if err := json.Unmarshal([]byte(s), &policy); err != nil || policy.Name != a.name || len(policy.Levels) != len(a.targets) {
+		return false
+	}
+	for i, level := range policy.Levels {
+		if level.TargetID != a.targets[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func expectGetEscalationPolicy(mockDB sqlmock.Sqlmock, name string, createdAt time.Time) {
+	mockDB.ExpectQuery(`SELECT id, name, description, is_active, repeat_max_times, COALESCE\(escalate_after_minutes, 5\)`).
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "name", "description", "is_active", "repeat_max_times", "escalate_after_minutes",
+			"created_at", "updated_at", "created_by", "group_id",
+		}).AddRow("policy-1", name, "", true, 1, 5, createdAt, createdAt, "user-9", "group-1"))
+}
+
+func expectGetEscalationLevels(mockDB sqlmock.Sqlmock, targetID string, createdAt time.Time) {
+	mockDB.ExpectQuery(`FROM escalation_levels`).
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "policy_id", "level_number", "target_type", "target_id",
+			"timeout_minutes", "notification_methods", "message_template", "created_at",
+		}).AddRow("level-1", "policy-1", 1, "user", targetID, 5, []byte(`["email"]`), "", createdAt))
+}
+
+func TestUpdateEscalationPolicy_CreatesVersion(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	createdAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	expectGetEscalationPolicy(mockDB, "Primary", createdAt)
+	expectGetEscalationLevels(mockDB, "user-1", createdAt)
+	mockDB.ExpectQuery(`settings->'notification_channels'`).
+		WithArgs("group-1").
+		WillReturnRows(sqlmock.NewRows([]string{"notification_channels"}).AddRow(nil))
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery(`SELECT version, .* FROM escalation_policies WHERE id = \$1 FOR UPDATE`).
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "valid_from"}).AddRow(1, createdAt))
+	// The snapshot is the policy as it was before the update
+	mockDB.ExpectExec(`INSERT INTO escalation_policy_versions`).
+		WithArgs("policy-1", 1, policySnapshotArg{"Primary", []string{"user-1"}}, createdAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectExec(`UPDATE escalation_policies SET version = \$2 WHERE id = \$1`).
+		WithArgs("policy-1", 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`UPDATE escalation_policies SET name = \$2`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`DELETE FROM escalation_levels`).
+		WithArgs("policy-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`INSERT INTO escalation_levels`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectCommit()
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	_, err = service.UpdateEscalationPolicy("policy-1", db.EscalationPolicy{
+		Name: "Primary (new on-call)",
+		Levels: []db.EscalationLevel{
+			{LevelNumber: 1, TargetType: "user", TargetID: "user-2", NotificationMethods: []string{"email"}},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetPolicyVersions_IncludesPreviousVersion(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	createdAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	updatedAt := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+	snapshot := `{"id":"policy-1","name":"Primary","levels":[{"level_number":1,"target_type":"user","target_id":"user-1"}]}`
+
+	mockDB.ExpectQuery(`SELECT version, .* FROM escalation_policies WHERE id = \$1`).
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "valid_from"}).AddRow(2, updatedAt))
+	expectGetEscalationPolicy(mockDB, "Primary (new on-call)", createdAt)
+	expectGetEscalationLevels(mockDB, "user-2", updatedAt)
+	mockDB.ExpectQuery(`FROM escalation_policy_versions WHERE policy_id = \$1 ORDER BY version DESC`).
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "snapshot", "valid_from", "valid_to"}).
+			AddRow(1, []byte(snapshot), createdAt, updatedAt))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	versions, err := service.GetPolicyVersions("policy-1")
+
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 2) {
+		assert.Equal(t, 2, versions[0].Version)
+		assert.Nil(t, versions[0].ValidTo)
+		assert.Equal(t, "user-2", versions[0].Policy.Levels[0].TargetID)
+
+		assert.Equal(t, 1, versions[1].Version)
+		assert.Equal(t, "Primary", versions[1].Policy.Name)
+		assert.Equal(t, "user-1", versions[1].Policy.Levels[0].TargetID)
+		assert.Equal(t, updatedAt, *versions[1].ValidTo)
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetPolicyAtTime(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	createdAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	updatedAt := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+	versionColumns := []string{"version", "snapshot", "valid_from", "valid_to"}
+	service := NewEscalationService(pg, nil, nil, nil)
+
+	// Before the update, the snapshotted version was in effect
+	before := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+	mockDB.ExpectQuery(`FROM escalation_policy_versions WHERE policy_id = \$1 AND valid_from <= \$2 AND valid_to > \$2`).
+		WithArgs("policy-1", before).
+		WillReturnRows(sqlmock.NewRows(versionColumns).
+			AddRow(1, []byte(`{"id":"policy-1","name":"Primary"}`), createdAt, updatedAt))
+
+	version, err := service.GetPolicyAtTime("policy-1", before)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, version.Version)
+	assert.Equal(t, "Primary", version.Policy.Name)
+
+	// Since the update, the current version is in effect
+	after := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	mockDB.ExpectQuery(`FROM escalation_policy_versions`).
+		WithArgs("policy-1", after).
+		WillReturnRows(sqlmock.NewRows(versionColumns))
+	mockDB.ExpectQuery(`SELECT version, .* FROM escalation_policies WHERE id = \$1`).
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "valid_from"}).AddRow(2, updatedAt))
+	expectGetEscalationPolicy(mockDB, "Primary (new on-call)", createdAt)
+	expectGetEscalationLevels(mockDB, "user-2", updatedAt)
+
+	version, err = service.GetPolicyAtTime("policy-1", after)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version.Version)
+	assert.Nil(t, version.ValidTo)
+
+	// Before the policy existed there is no version
+	early := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	mockDB.ExpectQuery(`FROM escalation_policy_versions`).
+		WithArgs("policy-1", early).
+		WillReturnRows(sqlmock.NewRows(versionColumns))
+	mockDB.ExpectQuery(`SELECT version, .* FROM escalation_policies WHERE id = \$1`).
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "valid_from"}).AddRow(2, updatedAt))
+	expectGetEscalationPolicy(mockDB, "Primary (new on-call)", createdAt)
+	expectGetEscalationLevels(mockDB, "user-2", updatedAt)
+
+	_, err = service.GetPolicyAtTime("policy-1", early)
+	assert.ErrorIs(t, err, ErrPolicyVersionNotFound)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestReorderLevels_KeepsLevelIDs(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	createdAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	expectGetEscalationPolicy(mockDB, "Primary", createdAt)
+	mockDB.ExpectQuery(`FROM escalation_levels`).
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "policy_id", "level_number", "target_type", "target_id",
+			"timeout_minutes", "notification_methods", "message_template", "created_at",
+		}).
+			AddRow("level-1", "policy-1", 1, "user", "user-1", 5, []byte(`["email"]`), "", createdAt).
+			AddRow("level-2", "policy-1", 2, "user", "user-2", 5, []byte(`["email"]`), "", createdAt).
+			AddRow("level-3", "policy-1", 3, "user", "user-3", 5, []byte(`["email"]`), "", createdAt))
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery(`SELECT version, .* FROM escalation_policies WHERE id = \$1 FOR UPDATE`).
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "valid_from"}).AddRow(1, createdAt))
+	mockDB.ExpectExec(`INSERT INTO escalation_policy_versions`).
+		WithArgs("policy-1", 1, policySnapshotArg{"Primary", []string{"user-1", "user-2", "user-3"}}, createdAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectExec(`UPDATE escalation_policies SET version = \$2 WHERE id = \$1`).
+		WithArgs("policy-1", 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`UPDATE escalation_levels SET level_number = level_number \+ \$2 WHERE policy_id = \$1`).
+		WithArgs("policy-1", 3).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	for i, id := range []string{"level-3", "level-1", "level-2"} {
+		mockDB.ExpectExec(`UPDATE escalation_levels SET level_number = \$3 WHERE id = \$1 AND policy_id = \$2`).
+			WithArgs(id, "policy-1", i+1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mockDB.ExpectExec(`UPDATE escalation_policies SET updated_at = NOW\(\) WHERE id = \$1`).
+		WithArgs("policy-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectCommit()
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	levels, err := service.ReorderLevels("policy-1", []string{"level-3", "level-1", "level-2"})
+
+	assert.NoError(t, err)
+	if assert.Len(t, levels, 3) {
+		assert.Equal(t, "level-3", levels[0].ID)
+		assert.Equal(t, 1, levels[0].LevelNumber)
+		assert.Equal(t, "user-3", levels[0].TargetID)
+		assert.Equal(t, "level-2", levels[2].ID)
+		assert.Equal(t, 3, levels[2].LevelNumber)
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestReorderLevels_RejectsIncompleteOrder(t *testing.T) {
+	createdAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		order []string
+	}{
+		{"missing level", []string{"level-1"}},
+		{"duplicate level", []string{"level-1", "level-1"}},
+		{"unknown level", []string{"level-1", "level-9"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pg, mockDB, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer pg.Close()
+
+			expectGetEscalationPolicy(mockDB, "Primary", createdAt)
+			mockDB.ExpectQuery(`FROM escalation_levels`).
+				WithArgs("policy-1").
+				WillReturnRows(sqlmock.NewRows([]string{
+					"id", "policy_id", "level_number", "target_type", "target_id",
+					"timeout_minutes", "notification_methods", "message_template", "created_at",
+				}).
+					AddRow("level-1", "policy-1", 1, "user", "user-1", 5, []byte(`["email"]`), "", createdAt).
+					AddRow("level-2", "policy-1", 2, "user", "user-2", 5, []byte(`["email"]`), "", createdAt))
+
+			_, err = NewEscalationService(pg, nil, nil, nil).ReorderLevels("policy-1", tt.order)
+
+			// Nothing is written when the order doesn't cover the levels
+			assert.ErrorIs(t, err, ErrInvalidLevelOrder)
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
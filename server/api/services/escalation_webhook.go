@@ -0,0 +1,233 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// EscalationWebhookSignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body of
+// an external escalation webhook, the same scheme inbound webhooks are verified with
+const EscalationWebhookSignatureHeader = "X-InRes-Signature"
+
+const (
+	externalWebhookTimeout = 10 * time.Second
+	externalWebhookRetries = 3
+)
+
+// ErrInvalidExternalTarget is returned (wrapped) when an external target's webhook URL isn't an
+// absolute http(s) URL or points at a non-public address
+var ErrInvalidExternalTarget = errors.New("invalid external escalation target")
+
+// newExternalWebhookClient only dials public addresses: target URLs come from policy editors,
+// and the webhook must not reach the internal network or cloud metadata endpoints
+func newExternalWebhookClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: rejectNonPublicWebhookAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // A proxy would be dialed instead of the target
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: externalWebhookTimeout, Transport: transport}
+}
+
+// rejectNonPublicWebhookAddress runs after DNS resolution, so a public hostname that resolves
+// to a non-public address is refused as well
+func rejectNonPublicWebhookAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil || !isPublicIP(net.ParseIP(host)) {
+		return fmt.Errorf("%w: %s is not a public address", ErrInvalidExternalTarget, address)
+	}
+	return nil
+}
+
+// parseExternalTarget checks the webhook URL of an external target. IP literals and local
+// hostnames are rejected up front; hostnames are checked again when dialed.
+func parseExternalTarget(raw string) (*url.URL, error) {
+	target, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return nil, fmt.Errorf("%w: must be an absolute http(s) URL", ErrInvalidExternalTarget)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(target.Hostname(), "."))
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return nil, fmt.Errorf("%w: %s is not a public address", ErrInvalidExternalTarget, host)
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".local") ||
+		strings.HasSuffix(host, ".internal") {
+		return nil, fmt.Errorf("%w: %s is not a public host", ErrInvalidExternalTarget, host)
+	}
+	return target, nil
+}
+
+// validateExternalTargets rejects external levels whose webhook URL can't be delivered to,
+// when the policy is saved rather than when it first escalates
+func validateExternalTargets(levels []db.EscalationLevel) error {
+	for _, level := range levels {
+		if level.TargetType != "external" {
+			continue
+		}
+		if _, err := parseExternalTarget(level.TargetID); err != nil {
+			return fmt.Errorf("level %d: %w", level.LevelNumber, err)
+		}
+	}
+	return nil
+}
+
+// externalEscalationPayload is the JSON posted to an external target when its level's message
+// template is plain text; the rendered template is the message
+type externalEscalationPayload struct {
+	Event      string                      `json:"event"`
+	Message    string                      `json:"message"`
+	Alert      *db.Alert                   `json:"alert"`
+	Escalation externalEscalationReference `json:"escalation"`
+	SentAt     time.Time                   `json:"sent_at"`
+}
+
+type externalEscalationReference struct {
+	Level      int    `json:"level"`
+	PolicyID   string `json:"policy_id"`
+	PolicyName string `json:"policy_name"`
+}
+
+// notifyExternal posts the escalation to the external target's webhook URL (the level's
+// target_id). Failed deliveries are retried with backoff on network errors, 429 and 5xx.
+func (s *EscalationService) notifyExternal(alert *db.Alert, policy *db.EscalationPolicyWithLevels, level *db.EscalationLevel) error {
+	target, err := url.Parse(strings.TrimSpace(level.TargetID))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: must be an absolute http(s) URL", ErrInvalidExternalTarget)
+	}
+
+	body, err := externalEscalationBody(alert, policy, level, time.Now())
+	if err != nil {
+		return err
+	}
+
+	backoff := s.webhookBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.postEscalationWebhook(target.String(), body)
+		if err == nil {
+			log.Printf("Notified external target %s for alert %s", target.Host, alert.Title)
+			return nil
+		}
+		if !retry {
+			return fmt.Errorf("external webhook failed: %w", err)
+		}
+		if attempt == externalWebhookRetries {
+			return fmt.Errorf("external webhook failed after %d attempts: %w", attempt+1, err)
+		}
+
+		log.Printf("WARNING: External webhook to %s failed, retrying in %v: %v", target.Host, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// postEscalationWebhook sends one delivery and reports whether a failure is worth retrying
+func (s *EscalationService) postEscalationWebhook(target string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.WebhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(s.WebhookSecret))
+		mac.Write(body)
+		req.Header.Set(EscalationWebhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return !errors.Is(err, ErrInvalidExternalTarget), err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook returned %s", resp.Status)
+}
+
+// externalEscalationBody builds the webhook body. A message template that is a JSON object is
+// the payload itself, with {{variables}} JSON-escaped; any other template is rendered into the
+// message of the default payload.
+func externalEscalationBody(alert *db.Alert, policy *db.EscalationPolicyWithLevels, level *db.EscalationLevel, sentAt time.Time) ([]byte, error) {
+	vars := externalEscalationTemplateVars(alert, policy, level)
+	template := level.MessageTemplate
+	if template == "" {
+		template = DefaultEscalationMessageTemplate
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(template), "{") {
+		escaped := make(map[string]string, len(vars))
+		for name, value := range vars {
+			quoted, _ := json.Marshal(value)
+			escaped[name] = string(quoted[1 : len(quoted)-1])
+		}
+		body, unknown := RenderMessageTemplate(template, escaped)
+		warnUnknownTemplateVars(level, unknown)
+		if !json.Valid([]byte(body)) {
+			return nil, fmt.Errorf("message template of escalation level %d is not valid JSON", level.LevelNumber)
+		}
+		return []byte(body), nil
+	}
+
+	message, unknown := RenderMessageTemplate(template, vars)
+	warnUnknownTemplateVars(level, unknown)
+	body, err := json.Marshal(externalEscalationPayload{
+		Event:   "escalation",
+		Message: message,
+		Alert:   alert,
+		Escalation: externalEscalationReference{
+			Level:      level.LevelNumber,
+			PolicyID:   policy.ID,
+			PolicyName: policy.Name,
+		},
+		SentAt: sentAt.UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	return body, nil
+}
+
+// externalEscalationTemplateVars returns the variables an external target's message template
+// can use, including the incident.* names of incident escalations
+func externalEscalationTemplateVars(alert *db.Alert, policy *db.EscalationPolicyWithLevels, level *db.EscalationLevel) map[string]string {
+	return map[string]string{
+		"alert.id":               alert.ID,
+		"alert.title":            alert.Title,
+		"alert.description":      alert.Description,
+		"alert.severity":         alert.Severity,
+		"alert.status":           alert.Status,
+		"alert.source":           alert.Source,
+		"incident.id":            alert.ID,
+		"incident.title":         alert.Title,
+		"incident.severity":      alert.Severity,
+		"escalation.level":       strconv.Itoa(level.LevelNumber),
+		"escalation.policy_id":   policy.ID,
+		"escalation.policy_name": policy.Name,
+	}
+}
+
+func warnUnknownTemplateVars(level *db.EscalationLevel, unknown []string) {
+	if len(unknown) > 0 {
+		log.Printf("WARNING: Escalation level %s message template has unknown variables: %s",
+			level.ID, strings.Join(unknown, ", "))
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func newExternalPolicy(target, template string) (*db.EscalationPolicyWithLevels, *db.EscalationLevel) {
+	policy := &db.EscalationPolicyWithLevels{
+		Levels: []db.EscalationLevel{
+			{ID: "level-1", LevelNumber: 1, TargetType: "external", TargetID: target, MessageTemplate: template},
+		},
+	}
+	policy.ID = "policy-1"
+	policy.Name = "Primary"
+	return policy, &policy.Levels[0]
+}
+
+func TestNotifyExternal_SignsAndRetries(t *testing.T) {
+	attempts := 0
+	var body []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(EscalationWebhookSignatureHeader)
+	}))
+	defer server.Close()
+
+	service := NewEscalationService(nil, nil, nil, nil)
+	service.HTTPClient = server.Client() // The default client refuses loopback
+	service.WebhookSecret = "s3cret"
+	service.webhookBackoff = 0
+	policy, level := newExternalPolicy(server.URL, "")
+
+	err := service.notifyExternal(&db.Alert{ID: "alert-1", Title: "disk full", Severity: "critical"}, policy, level)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), signature)
+
+	var payload externalEscalationPayload
+	assert.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "escalation", payload.Event)
+	assert.Equal(t, "Alert: disk full requires attention", payload.Message)
+	assert.Equal(t, "alert-1", payload.Alert.ID)
+	assert.Equal(t, externalEscalationReference{Level: 1, PolicyID: "policy-1", PolicyName: "Primary"}, payload.Escalation)
+}
+
+func TestNotifyExternal_JSONTemplate(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		assert.Empty(t, r.Header.Get(EscalationWebhookSignatureHeader))
+	}))
+	defer server.Close()
+
+	service := NewEscalationService(nil, nil, nil, nil)
+	service.HTTPClient = server.Client() // The default client refuses loopback
+	policy, level := newExternalPolicy(server.URL, `{"text": "[{{alert.severity}}] {{alert.title}}", "level": {{escalation.level}}}`)
+
+	err := service.notifyExternal(&db.Alert{ID: "alert-1", Title: `disk "/var" full`, Severity: "critical"}, policy, level)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"text": `[critical] disk "/var" full`, "level": float64(1)}, body)
+
+	// A template that doesn't render to JSON is never sent
+	level.MessageTemplate = `{"text": {{alert.title}}}`
+	assert.Error(t, service.notifyExternal(&db.Alert{Title: "disk full"}, policy, level))
+	level.TargetID = "not a url"
+	assert.Error(t, service.notifyExternal(&db.Alert{Title: "disk full"}, policy, level))
+}
+
+func TestExecuteEscalationStep_ExternalWebhookFailureIsRecorded(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Client errors other than 429 aren't retried; the failure lands on the escalation record
+	mockDB.ExpectQuery("INSERT INTO alert_escalations").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("esc-1"))
+	mockDB.ExpectExec("UPDATE alert_escalations SET status").
+		WithArgs("failed", "external webhook failed: webhook returned 410 Gone", nil, sqlmock.AnyArg(), "esc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewEscalationService(pg, nil, nil, nil)
+	service.HTTPClient = server.Client() // The default client refuses loopback
+	service.webhookBackoff = 0
+	policy, _ := newExternalPolicy(server.URL, "")
+	err = service.executeEscalationStep(&db.Alert{ID: "alert-1", Title: "disk full"}, policy, 1)
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestNotifyExternal_GivesUpAfterRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	service := NewEscalationService(nil, nil, nil, nil)
+	service.HTTPClient = server.Client() // The default client refuses loopback
+	service.webhookBackoff = 0
+	policy, level := newExternalPolicy(server.URL, "")
+
+	err := service.notifyExternal(&db.Alert{Title: "disk full"}, policy, level)
+
+	assert.EqualError(t, err, "external webhook failed after 4 attempts: webhook returned 502 Bad Gateway")
+	assert.Equal(t, 1+externalWebhookRetries, attempts)
+}
+
+func TestNotifyExternal_RefusesNonPublicAddress(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+	}))
+	defer server.Close()
+
+	// A policy saved before targets were validated still can't reach the internal network
+	service := NewEscalationService(nil, nil, nil, nil)
+	service.webhookBackoff = 0
+	policy, level := newExternalPolicy(server.URL, "")
+
+	err := service.notifyExternal(&db.Alert{ID: "alert-1", Title: "disk full"}, policy, level)
+
+	assert.ErrorIs(t, err, ErrInvalidExternalTarget)
+	assert.Equal(t, 0, attempts)
+}
+
+func TestValidateExternalTargets(t *testing.T) {
+	for _, target := range []string{
+		"ftp://hooks.example.com/escalate",
+		"/escalate",
+		"http://127.0.0.1:8080/escalate",
+		"http://10.0.0.5/escalate",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]/escalate",
+		"http://localhost:9000/escalate",
+		"http://metadata.google.internal/computeMetadata/v1/",
+	} {
+		err := validateExternalTargets([]db.EscalationLevel{{LevelNumber: 1, TargetType: "external", TargetID: target}})
+		assert.ErrorIs(t, err, ErrInvalidExternalTarget, target)
+	}
+
+	assert.NoError(t, validateExternalTargets([]db.EscalationLevel{
+		{LevelNumber: 1, TargetType: "external", TargetID: "https://hooks.example.com/escalate"},
+		{LevelNumber: 2, TargetType: "user", TargetID: "user-1"},
+	}))
+}
+
+func TestRejectNonPublicWebhookAddress(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:80", "10.1.2.3:443", "169.254.169.254:80", "[fd00::1]:443"} {
+		assert.ErrorIs(t, rejectNonPublicWebhookAddress("tcp", address, nil), ErrInvalidExternalTarget, address)
+	}
+	assert.NoError(t, rejectNonPublicWebhookAddress("tcp", "140.82.112.6:443", nil))
+}
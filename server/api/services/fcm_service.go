@@ -405,6 +405,68 @@ func (s *FCMService) SendNotificationToUserViaRelay(userID, title, body string,
 	return s.sendToCloudRelay(payload)
 }
 
+// SendIncidentEscalatedNotification pushes an incident escalation to a user's devices, with
+// the escalation level's rendered message as the body
+func (s *FCMService) SendIncidentEscalatedNotification(userID, incidentID, severity, message string) error {
+	title := fmt.Sprintf("[%s] Incident escalated", strings.ToUpper(severity))
+	data := map[string]string{
+		"incident_id": incidentID,
+		"severity":    severity,
+		"type":        "incident_escalated",
+	}
+
+	if s.IsCloudRelayEnabled() {
+		return s.sendToCloudRelay(CloudRelayNotification{
+			InstanceID: s.instanceID,
+			UserID:     userID,
+			Notification: CloudRelayNotifPayload{
+				Title:    title,
+				Body:     message,
+				Priority: getPriorityBySeverity(severity),
+				Sound:    DefaultNotificationSound,
+				Data:     data,
+			},
+		})
+	}
+
+	if s.client == nil {
+		log.Println("FCM client not initialized and cloud relay not configured, skipping notification")
+		return nil
+	}
+
+	var fcmToken string
+	err := s.PG.QueryRow(
+		"SELECT fcm_token FROM users WHERE id = $1 AND fcm_token IS NOT NULL AND fcm_token != ''",
+		userID,
+	).Scan(&fcmToken)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			log.Printf("No FCM token found for user %s", userID)
+			return nil
+		}
+		return fmt.Errorf("error fetching user FCM token: %v", err)
+	}
+
+	_, err = s.client.Send(context.Background(), &messaging.Message{
+		Token:        fcmToken,
+		Notification: &messaging.Notification{Title: title, Body: message},
+		Data:         data,
+		Android: &messaging.AndroidConfig{
+			Priority: "high",
+			Notification: &messaging.AndroidNotification{
+				Color:     getColorBySeverity(severity),
+				Sound:     "default",
+				ChannelID: "high_importance_channel",
+				Priority:  messaging.PriorityHigh,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send FCM message: %w", err)
+	}
+	return nil
+}
+
 func getPriorityBySeverity(severity string) string {
 	switch severity {
 	case "critical", "high":
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFCMService_SendIncidentEscalatedNotification_UsesMessage(t *testing.T) {
+	var sent CloudRelayNotification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/gateway/notifications/send", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		_, _ = w.Write([]byte(`{"notification_id":"n-1","status":"sent","devices_count":1}`))
+	}))
+	defer server.Close()
+
+	service := &FCMService{cloudURL: server.URL, cloudToken: "token", instanceID: "instance-1"}
+	err := service.SendIncidentEscalatedNotification("user-1", "inc-1", "critical", "Checkout is down, Alice please take a look")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", sent.UserID)
+	assert.Equal(t, "[CRITICAL] Incident escalated", sent.Notification.Title)
+	assert.Equal(t, "Checkout is down, Alice please take a look", sent.Notification.Body)
+	assert.Equal(t, "inc-1", sent.Notification.Data["incident_id"])
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// Per-organization feature flags, stored in organizations.settings->'feature_flags'
+const (
+	// FeatureAutoAssignment assigns new incidents to the on-call user / escalation policy target
+	FeatureAutoAssignment = "auto_assignment"
+	// FeatureCrossIntegrationDedup lets an alert dedup against incidents opened by other integrations
+	FeatureCrossIntegrationDedup = "cross_integration_dedup"
+	// FeatureFlapSuppression re-opens an incident resolved within the dedup window when its
+	// alert fires again, instead of opening a new one
+	FeatureFlapSuppression = "flap_suppression"
+)
+
+// defaultFeatureFlags preserves the existing behavior for orgs that haven't set a flag
+var defaultFeatureFlags = map[string]bool{
+	FeatureAutoAssignment:        true,
+	FeatureCrossIntegrationDedup: true,
+	FeatureFlapSuppression:       true,
+}
+
+type FeatureFlagService struct {
+	PG *sql.DB
+}
+
+func NewFeatureFlagService(pg *sql.DB) *FeatureFlagService {
+	return &FeatureFlagService{PG: pg}
+}
+
+// IsKnownFeatureFlag reports whether name is a supported feature flag
+func IsKnownFeatureFlag(name string) bool {
+	_, ok := defaultFeatureFlags[name]
+	return ok
+}
+
+// GetOrgFeatureFlags returns every known flag for an organization, with defaults filled in
+func (s *FeatureFlagService) GetOrgFeatureFlags(orgID string) (map[string]bool, error) {
+	flags := make(map[string]bool, len(defaultFeatureFlags))
+	for name, enabled := range defaultFeatureFlags {
+		flags[name] = enabled
+	}
+
+	var raw sql.NullString
+	err := s.PG.QueryRow(`
+		SELECT settings->'feature_flags'
+		FROM organizations
+		WHERE id = $1
+	`, orgID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("organization not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feature flags: %w", err)
+	}
+
+	if raw.Valid && raw.String != "" {
+		var stored map[string]bool
+		if err := json.Unmarshal([]byte(raw.String), &stored); err != nil {
+			log.Printf("WARNING: Invalid feature_flags for org %s, using defaults: %v", orgID, err)
+			return flags, nil
+		}
+		for name, enabled := range stored {
+			if IsKnownFeatureFlag(name) {
+				flags[name] = enabled
+			}
+		}
+	}
+
+	return flags, nil
+}
+
+// IsEnabled reports whether a flag is on for an organization.
+// Falls back to the default when the org is unknown or the lookup fails.
+func (s *FeatureFlagService) IsEnabled(orgID, flag string) bool {
+	if s == nil || s.PG == nil || orgID == "" {
+		return defaultFeatureFlags[flag]
+	}
+
+	flags, err := s.GetOrgFeatureFlags(orgID)
+	if err != nil {
+		log.Printf("WARNING: Failed to load feature flags for org %s, using default for %s: %v", orgID, flag, err)
+		return defaultFeatureFlags[flag]
+	}
+
+	return flags[flag]
+}
+
+// UpdateOrgFeatureFlags merges the given flags into the organization's settings
+func (s *FeatureFlagService) UpdateOrgFeatureFlags(orgID string, updates map[string]bool) (map[string]bool, error) {
+	for name := range updates {
+		if !IsKnownFeatureFlag(name) {
+			return nil, fmt.Errorf("unknown feature flag '%s'", name)
+		}
+	}
+
+	updatesJSON, _ := json.Marshal(updates)
+	result, err := s.PG.Exec(`
+		UPDATE organizations
+		SET settings = jsonb_set(
+		        COALESCE(settings, '{}'::jsonb),
+		        '{feature_flags}',
+		        COALESCE(settings->'feature_flags', '{}'::jsonb) || $1::jsonb
+		    ),
+		    updated_at = NOW()
+		WHERE id = $2
+	`, string(updatesJSON), orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update feature flags: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, fmt.Errorf("organization not found")
+	}
+
+	return s.GetOrgFeatureFlags(orgID)
+}
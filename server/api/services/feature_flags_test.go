@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatureFlagService_GetOrgFeatureFlags(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`SELECT settings->'feature_flags'`).
+		WithArgs("org-1").
+		WillReturnRows(sqlmock.NewRows([]string{"feature_flags"}).
+			AddRow(`{"auto_assignment": false, "retired_flag": true}`))
+
+	service := NewFeatureFlagService(pg)
+	flags, err := service.GetOrgFeatureFlags("org-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{
+		FeatureAutoAssignment:        false,
+		FeatureCrossIntegrationDedup: true, // default when unset
+		FeatureFlapSuppression:       true,
+	}, flags)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestFeatureFlagService_IsEnabledDefaults(t *testing.T) {
+	var service *FeatureFlagService
+	assert.True(t, service.IsEnabled("org-1", FeatureAutoAssignment))
+
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Orgs without any flags stored keep the current behavior
+	mockDB.ExpectQuery(`SELECT settings->'feature_flags'`).
+		WithArgs("org-1").
+		WillReturnRows(sqlmock.NewRows([]string{"feature_flags"}).AddRow(nil))
+
+	service = NewFeatureFlagService(pg)
+	assert.True(t, service.IsEnabled("org-1", FeatureCrossIntegrationDedup))
+	assert.True(t, service.IsEnabled("", FeatureAutoAssignment))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestFeatureFlagService_UpdateRejectsUnknownFlag(t *testing.T) {
+	service := NewFeatureFlagService(nil)
+	_, err := service.UpdateOrgFeatureFlags("org-1", map[string]bool{"does_not_exist": true})
+	assert.Error(t, err)
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// HeldAssignmentQueueName is the PGMQ queue holding assignment notifications delayed by
+// AssignmentNotificationDelay. Messages are sent with the delay, so they only become
+// readable once it has passed.
+const HeldAssignmentQueueName = "held_assignment_notifications"
+
+// heldAssignmentBatch caps how many held notifications one call sends
+const heldAssignmentBatch = 50
+
+// HeldAssignmentNotification is an assignment notification waiting out AssignmentNotificationDelay
+type HeldAssignmentNotification struct {
+	Incident    db.Incident `json:"incident"`
+	NotifySlack bool        `json:"notify_slack"`
+	NotifyFCM   bool        `json:"notify_fcm"`
+	Delay       string      `json:"delay"` // For the notification_suppressed event
+}
+
+// holdAssignmentNotification queues an assignment notification that becomes readable after delay
+func (s *IncidentService) holdAssignmentNotification(incident *db.Incident, notifySlack, notifyFCM bool, delay time.Duration) error {
+	msgJSON, err := json.Marshal(HeldAssignmentNotification{
+		Incident:    *incident,
+		NotifySlack: notifySlack,
+		NotifyFCM:   notifyFCM,
+		Delay:       delay.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal held notification: %w", err)
+	}
+
+	delaySeconds := int((delay + time.Second - 1) / time.Second)
+	if _, err := s.PG.Exec(`SELECT pgmq.send($1, $2::jsonb, $3)`, HeldAssignmentQueueName, string(msgJSON), delaySeconds); err != nil {
+		return fmt.Errorf("failed to queue held notification: %w", err)
+	}
+	return nil
+}
+
+// SendHeldAssignmentNotifications sends the held assignment notifications whose delay has
+// passed, dropping those of incidents resolved meanwhile. Returns how many were handled.
+func (s *IncidentService) SendHeldAssignmentNotifications() (int, error) {
+	// A message not deleted (the worker died mid-send) is read again after 60 seconds
+	rows, err := s.PG.Query(`SELECT msg_id, message FROM pgmq.read($1, 60, $2)`, HeldAssignmentQueueName, heldAssignmentBatch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read held notifications: %w", err)
+	}
+
+	type heldMessage struct {
+		msgID int64
+		raw   []byte
+	}
+	var batch []heldMessage
+	for rows.Next() {
+		var m heldMessage
+		if err := rows.Scan(&m.msgID, &m.raw); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan held notification: %w", err)
+		}
+		batch = append(batch, m)
+	}
+	rows.Close()
+
+	for _, m := range batch {
+		var held HeldAssignmentNotification
+		if err := json.Unmarshal(m.raw, &held); err != nil {
+			log.Printf("WARNING: Dropping unreadable held notification %d: %v", m.msgID, err)
+		} else {
+			s.releaseAssignmentNotification(held)
+		}
+
+		if _, err := s.PG.Exec(`SELECT pgmq.delete($1, $2::bigint)`, HeldAssignmentQueueName, m.msgID); err != nil {
+			log.Printf("WARNING: Failed to delete held notification %d: %v", m.msgID, err)
+		}
+	}
+	return len(batch), nil
+}
+
+// releaseAssignmentNotification sends a held assignment notification unless its incident was resolved
+func (s *IncidentService) releaseAssignmentNotification(held HeldAssignmentNotification) {
+	incident := held.Incident
+
+	var status string
+	if err := s.PG.QueryRow(`SELECT status FROM incidents WHERE id = $1`, incident.ID).Scan(&status); err != nil {
+		// Better to page twice than not at all
+		log.Printf("WARNING: Failed to check status of incident %s, notifying anyway: %v", incident.ID, err)
+	} else if status == db.IncidentStatusResolved {
+		log.Printf("DEBUG: Incident %s resolved within %s, dropping assignment notification", incident.ID, held.Delay)
+		_ = s.createIncidentEvent(incident.ID, db.IncidentEventNotificationSuppressed, map[string]interface{}{
+			"reason": fmt.Sprintf("resolved within %s", held.Delay),
+		}, "")
+		return
+	}
+
+	// The process sending it may be configured with fewer channels than the one that held it
+	s.sendAssignmentNotification(&incident, held.NotifySlack && s.NotificationWorker != nil, held.NotifyFCM && s.FCMService != nil)
+}
@@ -3,14 +3,20 @@ package services
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/phonginreallife/inres/db"
+	"golang.org/x/sync/errgroup"
 )
 
 type IncidentService struct {
@@ -19,21 +25,46 @@ type IncidentService struct {
 	FCMService         *FCMService
 	NotificationWorker NotificationSender        // Interface for sending notifications
 	BroadcastService   *RealtimeBroadcastService // For real-time notifications
+	FeatureFlags       *FeatureFlagService       // Per-org behavior toggles
+	PriorityMatrix     PriorityMatrix            // Severity × urgency → priority; nil uses DefaultPriorityMatrix
+
+	// DeployCorrelationWindow links new incidents to a deploy of their service made
+	// within this long before creation (0 disables)
+	DeployCorrelationWindow time.Duration
+
+	// AssignmentNotificationDelay holds a new incident's assignment notification this long
+	// and drops it if the incident resolves meanwhile, so flapping alerts don't page (0 disables)
+	AssignmentNotificationDelay time.Duration
+
+	// PublicURL is the web app's base URL, used for {{incident.url}} in escalation messages
+	PublicURL string
+
+	// DailyIncidentQuota is the incidents alerts may create per organization per UTC day;
+	// past it they are counted on one quota incident instead (0 disables)
+	DailyIncidentQuota int
+
+	// ClusterWindow groups a new alert incident with open incidents created this long before it
+	// whose labels are highly similar, under one cluster incident (0 disables)
+	ClusterWindow time.Duration
 }
 
 // NotificationSender interface for sending incident notifications
 type NotificationSender interface {
 	SendIncidentAssignedNotification(userID, incidentID string) error
-	SendIncidentEscalatedNotification(userID, incidentID string) error
+	SendIncidentEscalatedNotification(userID, incidentID, message string) error
 	SendIncidentAcknowledgedNotification(userID, incidentID string) error
 	SendIncidentResolvedNotification(userID, incidentID string) error
+	SendIncidentWatcherNotification(userID, incidentID, change string) error
 }
 
 func NewIncidentService(pg *sql.DB, redis *redis.Client, fcmService *FCMService) *IncidentService {
 	return &IncidentService{
-		PG:         pg,
-		Redis:      redis,
-		FCMService: fcmService,
+		PG:           pg,
+		Redis:        redis,
+		FCMService:   fcmService,
+		FeatureFlags: NewFeatureFlagService(pg),
+
+		DeployCorrelationWindow: DefaultDeployCorrelationWindow,
 	}
 }
 
@@ -84,13 +115,14 @@ func (l *LightweightNotificationSender) SendIncidentAssignedNotification(userID,
 }
 
 // SendIncidentEscalatedNotification sends incident escalation notification to queue
-func (l *LightweightNotificationSender) SendIncidentEscalatedNotification(userID, incidentID string) error {
+func (l *LightweightNotificationSender) SendIncidentEscalatedNotification(userID, incidentID, message string) error {
 	notification := map[string]interface{}{
 		"type":        "escalated",
 		"user_id":     userID,
 		"incident_id": incidentID,
 		"channels":    []string{"slack", "push"},
 		"priority":    "high",
+		"data":        map[string]interface{}{"message": message},
 		"created_at":  time.Now(),
 		"retry_count": 0,
 	}
@@ -158,30 +190,76 @@ func (l *LightweightNotificationSender) SendIncidentResolvedNotification(userID,
 	return nil
 }
 
-// ListIncidents returns a paginated list of incidents with filters
-// ReBAC: Explicit OR Inherited access pattern with MANDATORY Tenant Isolation
-// - Direct: User has project membership
-// - Inherited: User is org member AND project is "Open" (no explicit members)
-// - Ad-hoc: Incident assigned directly to user
-// IMPORTANT: All queries MUST be scoped to current organization (Context-Aware)
-func (s *IncidentService) ListIncidents(filters map[string]interface{}) ([]db.IncidentResponse, error) {
-	// ReBAC: Get user context
-	currentUserID, hasCurrentUser := filters["current_user_id"].(string)
-	if !hasCurrentUser || currentUserID == "" {
-		return []db.IncidentResponse{}, nil
+// SendIncidentWatcherNotification sends an incident change notification for a watcher to queue
+func (l *LightweightNotificationSender) SendIncidentWatcherNotification(userID, incidentID, change string) error {
+	notification := map[string]interface{}{
+		"type":        "watcher_update",
+		"user_id":     userID,
+		"incident_id": incidentID,
+		"channels":    []string{"slack", "push"},
+		"priority":    "medium",
+		"data":        map[string]interface{}{"change": change},
+		"created_at":  time.Now(),
+		"retry_count": 0,
 	}
 
-	// ReBAC: Get organization context (MANDATORY for Tenant Isolation)
-	currentOrgID, hasOrgContext := filters["current_org_id"].(string)
-	if !hasOrgContext || currentOrgID == "" {
-		log.Printf("WARNING: ListIncidents called without organization context - returning empty")
-		return []db.IncidentResponse{}, nil
+	notificationJSON, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
 	}
 
-	// ReBAC: Explicit OR Inherited access with Tenant Isolation
-	// Uses single `memberships` table with resource_type = 'project' or 'org'
-	// $1 = currentUserID, $2 = currentOrgID
-	query := `
+	_, err = l.PG.Exec(`SELECT pgmq.send($1, $2)`, "incident_notifications", string(notificationJSON))
+	if err != nil {
+		return fmt.Errorf("failed to send notification to queue: %w", err)
+	}
+
+	return nil
+}
+
+// incidentAccessScope is the ReBAC visibility predicate shared by incident queries.
+// It expects $1 = user ID and $2 = organization ID, with incidents aliased as i.
+const incidentAccessScope = `(
+		-- Scope A: Direct project membership
+		EXISTS (
+			SELECT 1 FROM memberships m
+			WHERE m.user_id = $1
+			AND m.resource_type = 'project'
+			AND m.resource_id = i.project_id
+		)
+		OR
+		-- Scope B: Inherited access (org member + project is "Open")
+		-- Project is "Open" = no explicit project members exist
+		(
+			i.project_id IS NOT NULL
+			AND EXISTS (
+				SELECT 1 FROM memberships m
+				WHERE m.user_id = $1
+				AND m.resource_type = 'org'
+				AND m.resource_id = $2
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM memberships pm
+				WHERE pm.resource_type = 'project' AND pm.resource_id = i.project_id
+			)
+		)
+		OR
+		-- Scope C: Org-level incidents (no project_id) - accessible by org members
+		(
+			i.project_id IS NULL
+			AND EXISTS (
+				SELECT 1 FROM memberships m
+				WHERE m.user_id = $1
+				AND m.resource_type = 'org'
+				AND m.resource_id = $2
+			)
+		)
+		OR
+		-- Scope D: Ad-hoc access - incident assigned directly to user
+		i.assigned_to = $1
+	)`
+
+// incidentListSelect is the column list scanned by queryIncidentList
+const incidentListSelect = `
 		SELECT
 			i.id, i.title, i.description, i.status, i.urgency, i.priority,
 			i.created_at, i.updated_at, i.assigned_to, i.assigned_at,
@@ -189,81 +267,106 @@ func (s *IncidentService) ListIncidents(filters map[string]interface{}) ([]db.In
 			i.source, i.integration_id, i.service_id, i.external_id, i.external_url,
 			i.escalation_policy_id, i.current_escalation_level, i.last_escalated_at,
 			i.escalation_status, i.group_id, i.api_key_id, i.severity, i.incident_key,
-			i.alert_count, i.labels, i.custom_fields,
+			i.alert_count, i.labels, i.custom_fields, i.archived_at,
 			u_assigned.name as assigned_to_name, u_assigned.email as assigned_to_email,
 			u_acked.name as acknowledged_by_name, u_acked.email as acknowledged_by_email,
 			u_resolved.name as resolved_by_name, u_resolved.email as resolved_by_email,
 			g.name as group_name, s.name as service_name,
-			ep.name as escalation_policy_name
+			ep.name as escalation_policy_name`
+
+// incidentListJoins is the FROM clause of incident list queries
+const incidentListJoins = `
 		FROM incidents i
 		LEFT JOIN users u_assigned ON i.assigned_to = u_assigned.id
 		LEFT JOIN users u_acked ON i.acknowledged_by = u_acked.id
 		LEFT JOIN users u_resolved ON i.resolved_by = u_resolved.id
 		LEFT JOIN groups g ON i.group_id = g.id
 		LEFT JOIN services s ON i.service_id = s.id
-		LEFT JOIN escalation_policies ep ON i.escalation_policy_id = ep.id
+		LEFT JOIN escalation_policies ep ON i.escalation_policy_id = ep.id`
+
+// incidentListFrom is the FROM/WHERE shared by the incident list and count queries.
+// ReBAC: Explicit OR Inherited access with Tenant Isolation
+// Uses single `memberships` table with resource_type = 'project' or 'org'
+// $1 = currentUserID, $2 = currentOrgID
+const incidentListFrom = incidentListJoins + `
 		WHERE
 			-- TENANT ISOLATION (MANDATORY): Only incidents in current organization
 			i.organization_id = $2
-			AND (
-				-- Scope A: Direct project membership
-				EXISTS (
-					SELECT 1 FROM memberships m
-					WHERE m.user_id = $1
-					AND m.resource_type = 'project'
-					AND m.resource_id = i.project_id
-				)
-				OR
-				-- Scope B: Inherited access (org member + project is "Open")
-				-- Project is "Open" = no explicit project members exist
-				(
-					i.project_id IS NOT NULL
-					AND EXISTS (
-						SELECT 1 FROM memberships m
-						WHERE m.user_id = $1
-						AND m.resource_type = 'org'
-						AND m.resource_id = $2
-					)
-					AND NOT EXISTS (
-						SELECT 1 FROM memberships pm
-						WHERE pm.resource_type = 'project' AND pm.resource_id = i.project_id
-					)
-				)
-				OR
-				-- Scope C: Org-level incidents (no project_id) - accessible by org members
-				(
-					i.project_id IS NULL
-					AND EXISTS (
-						SELECT 1 FROM memberships m
-						WHERE m.user_id = $1
-						AND m.resource_type = 'org'
-						AND m.resource_id = $2
-					)
-				)
-				OR
-				-- Scope D: Ad-hoc access - incident assigned directly to user
-				i.assigned_to = $1
-			)
+			AND ` + incidentAccessScope + `
 	`
 
+// incidentListFilter holds the filter conditions of an incident list query
+type incidentListFilter struct {
+	conditions     string
+	args           []interface{}
+	nextArg        int
+	searchArgIndex int // 0 when no search term is set
+}
+
+// incidentListContext extracts the mandatory ReBAC user and organization context from filters
+func incidentListContext(filters map[string]interface{}) (string, string, bool) {
+	// ReBAC: Get user context
+	currentUserID, hasCurrentUser := filters["current_user_id"].(string)
+	if !hasCurrentUser || currentUserID == "" {
+		return "", "", false
+	}
+
+	// ReBAC: Get organization context (MANDATORY for Tenant Isolation)
+	currentOrgID, hasOrgContext := filters["current_org_id"].(string)
+	if !hasOrgContext || currentOrgID == "" {
+		log.Printf("WARNING: Incident list called without organization context - returning empty")
+		return "", "", false
+	}
+
+	return currentUserID, currentOrgID, true
+}
+
+// buildIncidentListFilter turns filters into conditions appended after incidentListFrom.
+// Shared by ListIncidents and CountIncidents so rows and totals always agree.
+func buildIncidentListFilter(filters map[string]interface{}, currentUserID, currentOrgID string) incidentListFilter {
+	query := ""
 	args := []interface{}{currentUserID, currentOrgID}
 	argIndex := 3
-	hasSearch := false
 	searchArgIndex := 0
 
+	// Archived incidents are only listed on request
+	if includeArchived, _ := filters["include_archived"].(bool); !includeArchived {
+		query += " AND i.archived_at IS NULL"
+	}
+
 	// Apply resource-specific filters (these are additive, not access control)
 	if search, ok := filters["search"].(string); ok && search != "" {
-		hasSearch = true
 		searchArgIndex = argIndex
-		query += fmt.Sprintf(" AND (i.search_vector @@ plainto_tsquery('english', $%d) OR i.title ILIKE $%d OR i.description ILIKE $%d)", argIndex, argIndex+1, argIndex+2)
+		searchCondition := fmt.Sprintf("i.search_vector @@ plainto_tsquery('english', $%d) OR i.title ILIKE $%d OR i.description ILIKE $%d", argIndex, argIndex+1, argIndex+2)
+		// search_notes=true also matches note_added comments. Notes aren't in search_vector,
+		// so incidents found only through a note rank after title/description matches.
+		if searchNotes, _ := filters["search_notes"].(bool); searchNotes {
+			searchCondition += fmt.Sprintf(` OR EXISTS (
+				SELECT 1 FROM incident_events ne
+				WHERE ne.incident_id = i.id
+				AND ne.event_type = '%s'
+				AND ne.event_data->>'note' ILIKE $%d
+			)`, db.IncidentEventNoteAdded, argIndex+1)
+		}
+		query += " AND (" + searchCondition + ")"
 		searchPattern := "%" + search + "%"
 		args = append(args, search, searchPattern, searchPattern)
 		argIndex += 3
 	}
 
-	if status, ok := filters["status"].(string); ok && status != "" {
+	// Status accepts a single value, a comma-separated list ("triggered,acknowledged") or a []string.
+	// Without one only open incidents are listed, unless resolved ones are asked for.
+	statuses := parseStatusFilter(filters["status"])
+	if includeResolved, _ := filters["include_resolved"].(bool); len(statuses) == 0 && !includeResolved {
+		statuses = db.OpenIncidentStatuses
+	}
+	if len(statuses) == 1 {
 		query += fmt.Sprintf(" AND i.status = $%d", argIndex)
-		args = append(args, status)
+		args = append(args, statuses[0])
+		argIndex++
+	} else if len(statuses) > 1 {
+		query += fmt.Sprintf(" AND i.status = ANY($%d)", argIndex)
+		args = append(args, pq.Array(statuses))
 		argIndex++
 	}
 
@@ -295,6 +398,13 @@ func (s *IncidentService) ListIncidents(filters map[string]interface{}) ([]db.In
 		}
 	}
 
+	// Assignee by email, for callers that don't know the user ID
+	if email, ok := filters["assigned_to_email"].(string); ok && strings.TrimSpace(email) != "" {
+		query += fmt.Sprintf(" AND LOWER(u_assigned.email) = LOWER($%d)", argIndex)
+		args = append(args, strings.TrimSpace(email))
+		argIndex++
+	}
+
 	if serviceID, ok := filters["service_id"].(string); ok && serviceID != "" {
 		query += fmt.Sprintf(" AND i.service_id = $%d", argIndex)
 		args = append(args, serviceID)
@@ -307,13 +417,33 @@ func (s *IncidentService) ListIncidents(filters map[string]interface{}) ([]db.In
 		argIndex++
 	}
 
+	// Incident clusters: list a cluster's members, or only show the cluster in their place
+	if clusterID, ok := filters["cluster_id"].(string); ok && clusterID != "" {
+		query += fmt.Sprintf(" AND i.cluster_id = $%d", argIndex)
+		args = append(args, clusterID)
+		argIndex++
+	} else if hideClustered, _ := filters["hide_clustered"].(bool); hideClustered {
+		query += " AND i.cluster_id IS NULL"
+	}
+
 	// Project filtering - additional scope filter (user must still have access via ReBAC)
 	if projectID, ok := filters["project_id"].(string); ok && projectID != "" {
 		query += fmt.Sprintf(" AND (i.project_id = $%d OR g.project_id = $%d OR s.project_id = $%d)", argIndex, argIndex, argIndex)
 		args = append(args, projectID)
 		argIndex++
 	}
-	_ = argIndex // silence ineffassign - last filter condition
+	// JSONB containment filters, e.g. labels {"team": "payments"}
+	if labels, ok := jsonContainmentFilter(filters["labels"]); ok {
+		query += fmt.Sprintf(" AND i.labels @> $%d::jsonb", argIndex)
+		args = append(args, labels)
+		argIndex++
+	}
+
+	if customFields, ok := jsonContainmentFilter(filters["custom_fields"]); ok {
+		query += fmt.Sprintf(" AND i.custom_fields @> $%d::jsonb", argIndex)
+		args = append(args, customFields)
+		argIndex++
+	}
 
 	// Time range filter
 	if timeRange, ok := filters["time_range"].(string); ok && timeRange != "" && timeRange != "all" {
@@ -329,6 +459,53 @@ func (s *IncidentService) ListIncidents(filters map[string]interface{}) ([]db.In
 		}
 	}
 
+	return incidentListFilter{
+		conditions:     query,
+		args:           args,
+		nextArg:        argIndex,
+		searchArgIndex: searchArgIndex,
+	}
+}
+
+// ListIncidents returns a paginated list of incidents with filters
+// ReBAC: Explicit OR Inherited access pattern with MANDATORY Tenant Isolation
+// - Direct: User has project membership
+// - Inherited: User is org member AND project is "Open" (no explicit members)
+// - Ad-hoc: Incident assigned directly to user
+// IMPORTANT: All queries MUST be scoped to current organization (Context-Aware)
+func (s *IncidentService) ListIncidents(filters map[string]interface{}) ([]db.IncidentResponse, error) {
+	currentUserID, currentOrgID, ok := incidentListContext(filters)
+	if !ok {
+		return []db.IncidentResponse{}, nil
+	}
+
+	// ReBAC: Explicit OR Inherited access with Tenant Isolation
+	filter := buildIncidentListFilter(filters, currentUserID, currentOrgID)
+	return s.queryIncidentList(incidentListSelect+incidentListFrom, filter, filters)
+}
+
+// queryIncidentList runs an incident list query (SELECT and FROM/WHERE) with the filter
+// conditions, then applies the cursor, sort order and pagination from filters
+func (s *IncidentService) queryIncidentList(query string, filter incidentListFilter, filters map[string]interface{}) ([]db.IncidentResponse, error) {
+	query += filter.conditions
+	args := filter.args
+	argIndex := filter.nextArg
+	hasSearch := filter.searchArgIndex > 0
+	searchArgIndex := filter.searchArgIndex
+
+	// Keyset pagination: a cursor takes precedence over page/OFFSET. An empty cursor
+	// starts a keyset walk from the newest incident.
+	cursor, hasCursor := filters["cursor"].(string)
+	if cursor != "" {
+		cursorCreatedAt, cursorID, err := DecodeIncidentCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		query += fmt.Sprintf(" AND (i.created_at, i.id) < ($%d::timestamptz, $%d::uuid)", argIndex, argIndex+1)
+		args = append(args, cursorCreatedAt, cursorID)
+		argIndex += 2
+	}
+
 	// Sorting
 	sortBy := "i.created_at DESC"
 
@@ -349,13 +526,20 @@ func (s *IncidentService) ListIncidents(filters map[string]interface{}) ([]db.In
 		case "urgency_desc":
 			sortBy = "CASE WHEN i.urgency = 'high' THEN 1 ELSE 2 END, i.created_at DESC"
 		case "status_asc":
-			sortBy = "CASE WHEN i.status = 'triggered' THEN 1 WHEN i.status = 'acknowledged' THEN 2 ELSE 3 END, i.created_at DESC"
+			sortBy = "CASE WHEN i.status = 'triggered' THEN 1 WHEN i.status = 'acknowledged' THEN 2 WHEN i.status = 'external_pending' THEN 3 ELSE 4 END, i.created_at DESC"
+		case "sla_due_asc":
+			// Closest to breach first, so breached incidents lead; no running SLA goes last
+			sortBy = incidentSLADueAt + " ASC NULLS LAST, i.created_at DESC"
 		case "relevance":
 			if hasSearch {
 				sortBy = fmt.Sprintf("ts_rank(i.search_vector, plainto_tsquery('english', $%d)) DESC, i.created_at DESC", searchArgIndex)
 			}
 		}
 	}
+	// The cursor only makes sense against the order it was taken from
+	if hasCursor {
+		sortBy = "i.created_at DESC, i.id DESC"
+	}
 	query += " ORDER BY " + sortBy
 
 	// Pagination
@@ -363,13 +547,19 @@ func (s *IncidentService) ListIncidents(filters map[string]interface{}) ([]db.In
 	if l, ok := filters["limit"].(int); ok && l > 0 && l <= 100 {
 		limit = l
 	}
-	offset := 0
-	if page, ok := filters["page"].(int); ok && page > 1 {
-		offset = (page - 1) * limit
-	}
 
-	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
-	args = append(args, limit, offset)
+	if hasCursor {
+		query += fmt.Sprintf(" LIMIT $%d", argIndex)
+		args = append(args, limit)
+	} else {
+		offset := 0
+		if page, ok := filters["page"].(int); ok && page > 1 {
+			offset = (page - 1) * limit
+		}
+
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+		args = append(args, limit, offset)
+	}
 
 	rows, err := s.PG.Query(query, args...)
 	if err != nil {
@@ -393,6 +583,7 @@ func (s *IncidentService) ListIncidents(filters map[string]interface{}) ([]db.In
 		var groupID, groupName, serviceName sql.NullString
 		var apiKeyID, incidentKey sql.NullString
 		var labels, customFields sql.NullString
+		var archivedAt sql.NullTime
 
 		err := rows.Scan(
 			&incident.ID, &incident.Title, &incident.Description, &incident.Status, &incident.Urgency, &incident.Priority,
@@ -401,7 +592,7 @@ func (s *IncidentService) ListIncidents(filters map[string]interface{}) ([]db.In
 			&incident.Source, &integrationID, &serviceID, &externalID, &externalURL,
 			&escalationPolicyID, &incident.CurrentEscalationLevel, &lastEscalatedAt,
 			&incident.EscalationStatus, &groupID, &apiKeyID, &incident.Severity, &incidentKey,
-			&incident.AlertCount, &labels, &customFields,
+			&incident.AlertCount, &labels, &customFields, &archivedAt,
 			&assignedToName, &assignedToEmail,
 			&acknowledgedByName, &acknowledgedByEmail,
 			&resolvedByName, &resolvedByEmail,
@@ -415,6 +606,9 @@ func (s *IncidentService) ListIncidents(filters map[string]interface{}) ([]db.In
 		if assignedTo.Valid {
 			incident.AssignedTo = assignedTo.String
 		}
+		if archivedAt.Valid {
+			incident.ArchivedAt = &archivedAt.Time
+		}
 		if assignedToName.Valid {
 			incident.AssignedToName = assignedToName.String
 		}
@@ -499,6 +693,127 @@ func (s *IncidentService) ListIncidents(filters map[string]interface{}) ([]db.In
 	return incidents, nil
 }
 
+// EncodeIncidentCursor builds an opaque keyset pagination cursor from an incident's created_at and id
+func EncodeIncidentCursor(createdAt time.Time, id string) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeIncidentCursor parses a cursor produced by EncodeIncidentCursor
+func DecodeIncidentCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("invalid cursor")
+	}
+
+	createdAtStr, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return time.Time{}, "", fmt.Errorf("invalid cursor")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return time.Time{}, "", fmt.Errorf("invalid cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtStr)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("invalid cursor")
+	}
+
+	return createdAt, id, nil
+}
+
+// NextIncidentCursor returns the cursor for the page after incidents, or "" when the page wasn't full
+func NextIncidentCursor(incidents []db.IncidentResponse, limit int) string {
+	if len(incidents) == 0 || len(incidents) < limit {
+		return ""
+	}
+	last := incidents[len(incidents)-1]
+	return EncodeIncidentCursor(last.CreatedAt, last.ID)
+}
+
+// CountIncidents returns how many incidents match filters, ignoring pagination.
+// Uses the same access scope and filter conditions as ListIncidents.
+func (s *IncidentService) CountIncidents(filters map[string]interface{}) (int, error) {
+	currentUserID, currentOrgID, ok := incidentListContext(filters)
+	if !ok {
+		return 0, nil
+	}
+
+	filter := buildIncidentListFilter(filters, currentUserID, currentOrgID)
+	query := "SELECT COUNT(*)" + incidentListFrom + filter.conditions
+
+	var total int
+	if err := s.PG.QueryRow(query, filter.args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count incidents: %w", err)
+	}
+	return total, nil
+}
+
+// ListIncidentsWithCount returns a page of incidents along with the total number of matches
+func (s *IncidentService) ListIncidentsWithCount(filters map[string]interface{}) ([]db.IncidentResponse, int, error) {
+	incidents, err := s.ListIncidents(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.CountIncidents(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return incidents, total, nil
+}
+
+// jsonContainmentFilter encodes a labels/custom_fields filter map as JSON for a @> match.
+// Accepts map[string]string or map[string]interface{}; empty maps are ignored.
+func jsonContainmentFilter(value interface{}) (string, bool) {
+	var fields map[string]interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		fields = v
+	case map[string]string:
+		fields = make(map[string]interface{}, len(v))
+		for key, val := range v {
+			fields[key] = val
+		}
+	}
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return "", false
+	}
+	return string(encoded), true
+}
+
+// parseStatusFilter normalizes a status filter value into a list of statuses.
+// Supports a plain string, a comma-separated string, or a []string.
+func parseStatusFilter(value interface{}) []string {
+	var raw []string
+	switch v := value.(type) {
+	case string:
+		raw = strings.Split(v, ",")
+	case []string:
+		raw = v
+	default:
+		return nil
+	}
+
+	statuses := make([]string, 0, len(raw))
+	for _, status := range raw {
+		status = strings.TrimSpace(status)
+		if status != "" {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// ErrIncidentNotFound is returned when no incident has the requested ID
+var ErrIncidentNotFound = errors.New("incident not found")
+
 // GetIncident returns a single incident with full details
 func (s *IncidentService) GetIncident(id string) (*db.IncidentResponse, error) {
 	query := `
@@ -510,12 +825,17 @@ func (s *IncidentService) GetIncident(id string) (*db.IncidentResponse, error) {
 			i.escalation_policy_id, i.current_escalation_level, i.last_escalated_at, 
 			i.escalation_status, i.group_id, i.api_key_id, i.severity, i.incident_key, 
 			i.alert_count, i.labels, i.custom_fields,
-			i.organization_id, i.project_id,
+			i.organization_id, i.project_id, i.snoozed_until, i.archived_at,
+			COALESCE(i.response_sla_minutes, s.response_sla_minutes),
+			COALESCE(i.resolution_sla_minutes, s.resolution_sla_minutes),
+			COALESCE(i.snapshot_url, ''), i.business_impact, i.cluster_id,
+			d.id, d.version, d.environment, d.deployed_at,
 			u_assigned.name as assigned_to_name, u_assigned.email as assigned_to_email,
 			u_acked.name as acknowledged_by_name, u_acked.email as acknowledged_by_email,
 			u_resolved.name as resolved_by_name, u_resolved.email as resolved_by_email,
 			g.name as group_name, s.name as service_name,
-			ep.name as escalation_policy_name
+			ep.name as escalation_policy_name,
+			o.name as organization_name, p.name as project_name
 		FROM incidents i
 		LEFT JOIN users u_assigned ON i.assigned_to = u_assigned.id
 		LEFT JOIN users u_acked ON i.acknowledged_by = u_acked.id
@@ -523,6 +843,9 @@ func (s *IncidentService) GetIncident(id string) (*db.IncidentResponse, error) {
 		LEFT JOIN groups g ON i.group_id = g.id
 		LEFT JOIN services s ON i.service_id = s.id
 		LEFT JOIN escalation_policies ep ON i.escalation_policy_id = ep.id
+		LEFT JOIN deploy_events d ON i.related_deploy_id = d.id
+		LEFT JOIN organizations o ON i.organization_id = o.id
+		LEFT JOIN projects p ON i.project_id = p.id
 		WHERE i.id = $1
 	`
 
@@ -538,8 +861,13 @@ func (s *IncidentService) GetIncident(id string) (*db.IncidentResponse, error) {
 	var lastEscalatedAt sql.NullTime
 	var groupID, groupName, serviceName sql.NullString
 	var apiKeyID, incidentKey sql.NullString
-	var labels, customFields sql.NullString
-	var organizationID, projectID sql.NullString
+	var labels, customFields, businessImpact sql.NullString
+	var organizationID, projectID, clusterID sql.NullString
+	var organizationName, projectName sql.NullString
+	var snoozedUntil, archivedAt sql.NullTime
+	var responseSLA, resolutionSLA sql.NullInt64
+	var deployID, deployVersion, deployEnvironment sql.NullString
+	var deployedAt sql.NullTime
 
 	err := s.PG.QueryRow(query, id).Scan(
 		&incident.ID, &incident.Title, &incident.Description, &incident.Status, &incident.Urgency, &incident.Priority,
@@ -549,16 +877,20 @@ func (s *IncidentService) GetIncident(id string) (*db.IncidentResponse, error) {
 		&escalationPolicyID, &incident.CurrentEscalationLevel, &lastEscalatedAt,
 		&incident.EscalationStatus, &groupID, &apiKeyID, &incident.Severity, &incidentKey,
 		&incident.AlertCount, &labels, &customFields,
-		&organizationID, &projectID,
+		&organizationID, &projectID, &snoozedUntil, &archivedAt,
+		&responseSLA, &resolutionSLA,
+		&incident.SnapshotURL, &businessImpact, &clusterID,
+		&deployID, &deployVersion, &deployEnvironment, &deployedAt,
 		&assignedToName, &assignedToEmail,
 		&acknowledgedByName, &acknowledgedByEmail,
 		&resolvedByName, &resolvedByEmail,
 		&groupName, &serviceName, &escalationPolicyName,
+		&organizationName, &projectName,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("incident not found")
+			return nil, ErrIncidentNotFound
 		}
 		return nil, fmt.Errorf("failed to get incident: %w", err)
 	}
@@ -567,6 +899,31 @@ func (s *IncidentService) GetIncident(id string) (*db.IncidentResponse, error) {
 	if assignedTo.Valid {
 		incident.AssignedTo = assignedTo.String
 	}
+	if snoozedUntil.Valid {
+		incident.SnoozedUntil = &snoozedUntil.Time
+	}
+	if archivedAt.Valid {
+		incident.ArchivedAt = &archivedAt.Time
+	}
+	if responseSLA.Valid {
+		minutes := int(responseSLA.Int64)
+		incident.ResponseSLAMinutes = &minutes
+	}
+	if resolutionSLA.Valid {
+		minutes := int(resolutionSLA.Int64)
+		incident.ResolutionSLAMinutes = &minutes
+	}
+	if deployID.Valid {
+		incident.RelatedDeployID = deployID.String
+		incident.RelatedDeploy = &db.DeployEvent{
+			ID:          deployID.String,
+			ServiceID:   incident.ServiceID,
+			Version:     deployVersion.String,
+			Environment: deployEnvironment.String,
+			DeployedAt:  deployedAt.Time,
+		}
+		incident.LikelyCause = fmt.Sprintf("Likely caused by deploy %s", deployVersion.String)
+	}
 	if assignedToName.Valid {
 		incident.AssignedToName = assignedToName.String
 	}
@@ -642,6 +999,9 @@ func (s *IncidentService) GetIncident(id string) (*db.IncidentResponse, error) {
 	if projectID.Valid {
 		incident.ProjectID = projectID.String
 	}
+	incident.OrganizationName = organizationName.String
+	incident.ProjectName = projectName.String
+	incident.SLAType, incident.SLARemainingSeconds = slaRemaining(&incident.Incident, time.Now())
 
 	// Parse JSON fields
 	if labels.Valid && labels.String != "" {
@@ -650,6 +1010,10 @@ func (s *IncidentService) GetIncident(id string) (*db.IncidentResponse, error) {
 	if customFields.Valid && customFields.String != "" {
 		_ = json.Unmarshal([]byte(customFields.String), &incident.CustomFields)
 	}
+	if businessImpact.Valid && businessImpact.String != "" {
+		_ = json.Unmarshal([]byte(businessImpact.String), &incident.BusinessImpact)
+	}
+	incident.ClusterID = clusterID.String
 
 	// Get recent events
 	events, err := s.GetIncidentEvents(id, 10)
@@ -657,11 +1021,99 @@ func (s *IncidentService) GetIncident(id string) (*db.IncidentResponse, error) {
 		incident.RecentEvents = events
 	}
 
+	watchers, err := s.GetWatchers(id)
+	if err == nil {
+		incident.Watchers = watchers
+	}
+
+	attachments, err := s.GetAttachments(id)
+	if err == nil {
+		incident.Attachments = attachments
+	}
+
 	return &incident, nil
 }
 
 // CreateIncident creates a new incident
 func (s *IncidentService) CreateIncident(incident *db.Incident) (*db.Incident, error) {
+	setIncidentDefaults(incident)
+	s.fillIncidentContext(incident)
+	s.applyAutoAssignment(incident)
+	s.prepareNewIncident(incident)
+
+	existing, err := s.insertOrAttachIncident(incident)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	s.publishIncidentCreated(incident)
+	s.clusterIncident(incident)
+	return incident, nil
+}
+
+// IncidentEnricher fills in incident details that are slow to resolve, such as
+// service routing and the assignee. It runs off the request path and may modify
+// the incident in place.
+type IncidentEnricher func(incident *db.Incident)
+
+// CreateIncidentAsync is the fast path for incident creation: it inserts the incident
+// as given and queues it on the enrichment queue, where the worker runs context lookup,
+// auto-assignment, events and notifications. The incident is returned along with an
+// ErrEnrichmentNotQueued error if it was inserted but could not be queued.
+func (s *IncidentService) CreateIncidentAsync(incident *db.Incident, enrichment IncidentEnrichmentMessage) (*db.Incident, error) {
+	setIncidentDefaults(incident)
+	s.prepareNewIncident(incident)
+
+	existing, err := s.insertOrAttachIncident(incident)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	enrichment.Incident = *incident
+	if err := s.EnqueueIncidentEnrichment(enrichment); err != nil {
+		return incident, err
+	}
+	return incident, nil
+}
+
+// EnrichIncident completes an incident inserted by CreateIncidentAsync
+func (s *IncidentService) EnrichIncident(incident *db.Incident, enrich IncidentEnricher) error {
+	start := time.Now()
+
+	if enrich != nil {
+		enrich(incident)
+	}
+	s.fillIncidentContext(incident)
+	s.applyAutoAssignment(incident)
+	// The service is only known now
+	s.correlateDeploy(incident)
+
+	_, err := s.PG.Exec(`
+		UPDATE incidents
+		SET service_id = $1, escalation_policy_id = $2, group_id = $3, assigned_to = $4,
+		    organization_id = $5, project_id = $6, related_deploy_id = $7
+		WHERE id = $8
+	`, nullIfEmpty(incident.ServiceID), nullIfEmpty(incident.EscalationPolicyID), nullIfEmpty(incident.GroupID),
+		nullIfEmpty(incident.AssignedTo), nullIfEmpty(incident.OrganizationID), nullIfEmpty(incident.ProjectID),
+		nullIfEmpty(incident.RelatedDeployID), incident.ID)
+	if err != nil {
+		return fmt.Errorf("failed to enrich incident %s: %w", incident.ID, err)
+	}
+
+	s.publishIncidentCreated(incident)
+	s.clusterIncident(incident)
+	log.Printf("DEBUG: Enriched incident %s in %v", incident.ID, time.Since(start))
+	return nil
+}
+
+// setIncidentDefaults fills in defaults for a new incident
+func setIncidentDefaults(incident *db.Incident) {
 	if incident.ID == "" {
 		incident.ID = uuid.New().String()
 	}
@@ -680,73 +1132,39 @@ func (s *IncidentService) CreateIncident(incident *db.Incident) (*db.Incident, e
 	if incident.AlertCount == 0 {
 		incident.AlertCount = 1
 	}
-
-	// Auto-assign to current on-call user if not assigned
-	if incident.AssignedTo == "" {
-		userService := NewUserService(s.PG, s.Redis)
-		onCallUser, err := userService.GetCurrentOnCallUser()
-		if err == nil {
-			incident.AssignedTo = onCallUser.ID
-			now := time.Now()
-			incident.AssignedAt = &now // Set AssignedAt so assignment event will be created
-			log.Printf("DEBUG: Auto-assigned incident to on-call user %s at %v", onCallUser.ID, now)
-		}
+	if incident.CurrentEscalationLevel == 0 {
+		incident.CurrentEscalationLevel = 1
 	}
+}
 
-	// Convert maps to JSON
-	var labelsJSON, customFieldsJSON interface{}
-	if incident.Labels != nil {
-		labelsBytes, _ := json.Marshal(incident.Labels)
-		labelsJSON = string(labelsBytes)
-	}
-	if incident.CustomFields != nil {
-		customFieldsBytes, _ := json.Marshal(incident.CustomFields)
-		customFieldsJSON = string(customFieldsBytes)
+// prepareNewIncident derives the fields a new incident is inserted with on both creation paths
+func (s *IncidentService) prepareNewIncident(incident *db.Incident) {
+	// Derive priority from severity × urgency unless the caller chose one
+	if incident.Priority == "" && incident.Severity != "" {
+		incident.Priority = s.computeOrgPriority(incident.OrganizationID, incident.Severity, incident.Urgency)
 	}
+	s.correlateDeploy(incident)
+}
 
-	// Handle UUID fields properly - convert empty strings to NULL
-	var assignedToParam, escalationPolicyIDParam, groupIDParam, integrationIDParam, serviceIDParam, apiKeyIDParam, organizationIDParam, projectIDParam interface{}
-
-	log.Printf("DEBUG: Incident UUID fields before processing - AssignedTo: '%s', EscalationPolicyID: '%s', GroupID: '%s', IntegrationID: '%s', ServiceID: '%s', APIKeyID: '%s', OrganizationID: '%s', ProjectID: '%s'",
-		incident.AssignedTo, incident.EscalationPolicyID, incident.GroupID, incident.IntegrationID, incident.ServiceID, incident.APIKeyID, incident.OrganizationID, incident.ProjectID)
-
-	if incident.AssignedTo != "" {
-		assignedToParam = incident.AssignedTo
-		log.Printf("DEBUG: Setting assignedToParam to: %s", incident.AssignedTo)
-	}
-	if incident.EscalationPolicyID != "" {
-		escalationPolicyIDParam = incident.EscalationPolicyID
-		log.Printf("DEBUG: Setting escalationPolicyIDParam to: %s", incident.EscalationPolicyID)
-	}
-	if incident.GroupID != "" {
-		groupIDParam = incident.GroupID
-		log.Printf("DEBUG: Setting groupIDParam to: %s", incident.GroupID)
-	}
-	if incident.IntegrationID != "" {
-		integrationIDParam = incident.IntegrationID
-		log.Printf("DEBUG: Setting integrationIDParam to: %s", incident.IntegrationID)
-	}
-	if incident.ServiceID != "" {
-		serviceIDParam = incident.ServiceID
-		log.Printf("DEBUG: Setting serviceIDParam to: %s", incident.ServiceID)
-	}
-	if incident.APIKeyID != "" {
-		apiKeyIDParam = incident.APIKeyID
-		log.Printf("DEBUG: Setting apiKeyIDParam to: %s", incident.APIKeyID)
-	}
-	if incident.OrganizationID != "" {
-		organizationIDParam = incident.OrganizationID
-		log.Printf("DEBUG: Setting organizationIDParam to: %s", incident.OrganizationID)
-	}
-	if incident.ProjectID != "" {
-		projectIDParam = incident.ProjectID
-		log.Printf("DEBUG: Setting projectIDParam to: %s", incident.ProjectID)
+// correlateDeploy links the incident to its service's most recent deploy within the correlation window
+func (s *IncidentService) correlateDeploy(incident *db.Incident) {
+	if incident.RelatedDeployID != "" || incident.ServiceID == "" || s.DeployCorrelationWindow <= 0 {
+		return
 	}
 
-	if incident.CurrentEscalationLevel == 0 {
-		incident.CurrentEscalationLevel = 1
+	deploy, err := NewDeployEventService(s.PG).FindRecentDeploy(incident.ServiceID, s.DeployCorrelationWindow)
+	if err != nil {
+		log.Printf("WARNING: Failed to correlate incident with deploys of service %s: %v", incident.ServiceID, err)
+		return
+	}
+	if deploy != nil {
+		incident.RelatedDeployID = deploy.ID
+		log.Printf("DEBUG: Incident likely caused by deploy %s (%s) of service %s", deploy.Version, deploy.ID, incident.ServiceID)
 	}
+}
 
+// fillIncidentContext auto-fills organization and project for incidents that don't have them
+func (s *IncidentService) fillIncidentContext(incident *db.Incident) {
 	// ==========================================================================
 	// AUTO-LOOKUP CONTEXT (Hidden Write Pattern)
 	// ==========================================================================
@@ -766,12 +1184,10 @@ func (s *IncidentService) CreateIncident(incident *db.Incident) (*db.Incident, e
 		if err == nil {
 			if serviceOrgID.Valid && serviceOrgID.String != "" {
 				incident.OrganizationID = serviceOrgID.String
-				organizationIDParam = serviceOrgID.String
 				log.Printf("DEBUG: Auto-filled OrganizationID from Service: %s", incident.OrganizationID)
 			}
 			if serviceProjectID.Valid && serviceProjectID.String != "" && incident.ProjectID == "" {
 				incident.ProjectID = serviceProjectID.String
-				projectIDParam = serviceProjectID.String
 				log.Printf("DEBUG: Auto-filled ProjectID from Service: %s", incident.ProjectID)
 			}
 		} else if err != sql.ErrNoRows {
@@ -791,12 +1207,10 @@ func (s *IncidentService) CreateIncident(incident *db.Incident) (*db.Incident, e
 		if err == nil {
 			if groupOrgID.Valid && groupOrgID.String != "" {
 				incident.OrganizationID = groupOrgID.String
-				organizationIDParam = groupOrgID.String
 				log.Printf("DEBUG: Auto-filled OrganizationID from Group: %s", incident.OrganizationID)
 			}
 			if groupProjectID.Valid && groupProjectID.String != "" && incident.ProjectID == "" {
 				incident.ProjectID = groupProjectID.String
-				projectIDParam = groupProjectID.String
 				log.Printf("DEBUG: Auto-filled ProjectID from Group: %s", incident.ProjectID)
 			}
 		} else if err != sql.ErrNoRows {
@@ -809,44 +1223,205 @@ func (s *IncidentService) CreateIncident(incident *db.Incident) (*db.Incident, e
 		log.Printf("WARNING: Incident created without organization_id - Source: %s, ServiceID: %s, GroupID: %s",
 			incident.Source, incident.ServiceID, incident.GroupID)
 	}
+}
 
-	log.Printf("DEBUG: Final params - assignedToParam: %v, escalationPolicyIDParam: %v, groupIDParam: %v, integrationIDParam: %v, serviceIDParam: %v, apiKeyIDParam: %v, organizationIDParam: %v, projectIDParam: %v",
-		assignedToParam, escalationPolicyIDParam, groupIDParam, integrationIDParam, serviceIDParam, apiKeyIDParam, organizationIDParam, projectIDParam)
+// applyAutoAssignment picks the assignee of a new incident. The first match wins:
+//  1. an explicit assignee set by the caller
+//  2. the level-1 target of the incident's escalation policy (callers that resolved it
+//     themselves set AssignmentMethodEscalationPolicy)
+//  3. the current on-call user
+//
+// The winner is kept in AssignmentMethod so the single assigned event records it.
+// Orgs that turned auto-assignment off keep explicit assignees but get no automatic one.
+func (s *IncidentService) applyAutoAssignment(incident *db.Incident) {
+	if incident.AssignedTo != "" && incident.AssignmentMethod == "" {
+		incident.AssignmentMethod = db.AssignmentMethodExplicit
+	}
+
+	if incident.AssignmentMethod != db.AssignmentMethodExplicit &&
+		!s.FeatureFlags.IsEnabled(incident.OrganizationID, FeatureAutoAssignment) {
+		if incident.AssignedTo != "" {
+			log.Printf("DEBUG: Auto-assignment disabled for org %s, leaving incident unassigned", incident.OrganizationID)
+		}
+		incident.AssignedTo = ""
+		incident.AssignedAt = nil
+		incident.AssignmentMethod = ""
+		return
+	}
 
-	_, err := s.PG.Exec(`
-		INSERT INTO incidents (
-			id, title, description, status, urgency, priority,
-			assigned_to, source, integration_id, service_id, external_id, external_url,
-			escalation_policy_id, current_escalation_level, escalation_status, group_id, api_key_id,
-			severity, incident_key, alert_count, labels, custom_fields, organization_id, project_id
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24)`,
-		incident.ID, incident.Title, incident.Description, incident.Status, incident.Urgency, incident.Priority,
-		assignedToParam, incident.Source, integrationIDParam, serviceIDParam, incident.ExternalID, incident.ExternalURL,
-		escalationPolicyIDParam, incident.CurrentEscalationLevel, incident.EscalationStatus,
-		groupIDParam, apiKeyIDParam, incident.Severity, incident.IncidentKey, incident.AlertCount,
-		labelsJSON, customFieldsJSON, organizationIDParam, projectIDParam,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create incident: %w", err)
+	if incident.AssignedTo == "" && incident.EscalationPolicyID != "" && incident.GroupID != "" {
+		assigneeID, err := s.GetAssigneeFromEscalationPolicy(incident.EscalationPolicyID, incident.GroupID)
+		if err != nil {
+			log.Printf("WARNING: Failed to get assignee from escalation policy %s: %v", incident.EscalationPolicyID, err)
+		} else if assigneeID != "" {
+			incident.AssignedTo = assigneeID
+			incident.AssignmentMethod = db.AssignmentMethodEscalationPolicy
+		}
 	}
 
-	// Create triggered event
-	_ = s.createIncidentEvent(incident.ID, db.IncidentEventTriggered, map[string]interface{}{
-		"source":   incident.Source,
-		"severity": incident.Severity,
-	}, "")
+	if incident.AssignedTo == "" {
+		userService := NewUserService(s.PG, s.Redis)
+		onCallUser, err := userService.GetCurrentOnCallUser()
+		if err == nil {
+			incident.AssignedTo = onCallUser.ID
+			incident.AssignmentMethod = db.AssignmentMethodOnCall
+		}
+	}
+
+	if incident.AssignedTo != "" {
+		if incident.AssignedAt == nil {
+			now := time.Now()
+			incident.AssignedAt = &now // Set AssignedAt so assignment event will be created
+		}
+		log.Printf("DEBUG: Assigned incident to %s (method: %s)", incident.AssignedTo, incident.AssignmentMethod)
+	}
+}
+
+// insertOrAttachIncident inserts a new incident unless an open incident of the same organization
+// already has its incident_key. In that case the alert is counted on the existing incident,
+// which is returned instead, and nothing is inserted. The same happens with the quota incident
+// when the organization is over its daily incident quota.
+func (s *IncidentService) insertOrAttachIncident(incident *db.Incident) (*db.Incident, error) {
+	existing, err := s.attachToOpenIncidentByKey(incident.OrganizationID, incident.IncidentKey)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+	if shed := s.shedOverQuota(incident); shed != nil {
+		return shed, nil
+	}
+
+	inserted, err := s.insertIncident(incident)
+	if err != nil || inserted {
+		return nil, err
+	}
+
+	// The upsert skipped the row: a concurrent alert with the same key opened the incident first
+	existing, err = s.attachToOpenIncidentByKey(incident.OrganizationID, incident.IncidentKey)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("failed to create incident: incident_key %s is taken but no open incident has it", incident.IncidentKey)
+	}
+	return existing, nil
+}
+
+// attachToOpenIncidentByKey counts another alert on the open incident with this
+// incident_key in the organization. Returns nil when there is no such incident.
+func (s *IncidentService) attachToOpenIncidentByKey(orgID, incidentKey string) (*db.Incident, error) {
+	if orgID == "" || incidentKey == "" {
+		return nil, nil
+	}
+
+	var incident db.Incident
+	var assignedTo, serviceID, projectID sql.NullString
+	err := s.PG.QueryRow(`
+		UPDATE incidents
+		SET alert_count = alert_count + 1,
+		    updated_at = `+SQLNowUTC+`
+		WHERE organization_id = $1
+		  AND incident_key = $2
+		  AND status = ANY($3)
+		RETURNING id, title, status, urgency, COALESCE(priority, ''), COALESCE(severity, ''),
+		          assigned_to, service_id, project_id, alert_count, created_at, updated_at
+	`, orgID, incidentKey, pq.Array(db.OpenIncidentStatuses)).Scan(
+		&incident.ID, &incident.Title, &incident.Status, &incident.Urgency, &incident.Priority, &incident.Severity,
+		&assignedTo, &serviceID, &projectID, &incident.AlertCount, &incident.CreatedAt, &incident.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach alert to incident: %w", err)
+	}
+
+	incident.OrganizationID = orgID
+	incident.IncidentKey = incidentKey
+	incident.AssignedTo = assignedTo.String
+	incident.ServiceID = serviceID.String
+	incident.ProjectID = projectID.String
+
+	log.Printf("DEBUG: Attached alert with incident_key %s to open incident %s (alert_count: %d)",
+		incidentKey, incident.ID, incident.AlertCount)
+	return &incident, nil
+}
+
+// insertIncident writes a new incident row. It reports false, without error, when an open
+// incident of the same organization already holds the incident_key.
+func (s *IncidentService) insertIncident(incident *db.Incident) (bool, error) {
+	// Convert maps to JSON
+	var labelsJSON, customFieldsJSON interface{}
+	if incident.Labels != nil {
+		labelsBytes, _ := json.Marshal(incident.Labels)
+		labelsJSON = string(labelsBytes)
+	}
+	if incident.CustomFields != nil {
+		customFieldsBytes, _ := json.Marshal(incident.CustomFields)
+		customFieldsJSON = string(customFieldsBytes)
+	}
+
+	log.Printf("DEBUG: Incident UUID fields - AssignedTo: '%s', EscalationPolicyID: '%s', GroupID: '%s', IntegrationID: '%s', ServiceID: '%s', APIKeyID: '%s', OrganizationID: '%s', ProjectID: '%s'",
+		incident.AssignedTo, incident.EscalationPolicyID, incident.GroupID, incident.IntegrationID, incident.ServiceID, incident.APIKeyID, incident.OrganizationID, incident.ProjectID)
 
-	// Create assignment event if incident was auto-assigned
+	// UUID fields: empty strings are stored as NULL
+	// The conflict target is the partial unique index on open incidents' incident_key
+	result, err := s.PG.Exec(`
+		INSERT INTO incidents (
+			id, title, description, status, urgency, priority,
+			assigned_to, source, integration_id, service_id, external_id, external_url,
+			escalation_policy_id, current_escalation_level, escalation_status, group_id, api_key_id,
+			severity, incident_key, alert_count, labels, custom_fields, organization_id, project_id,
+			response_sla_minutes, resolution_sla_minutes, related_deploy_id, snapshot_url
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28)
+		ON CONFLICT (organization_id, incident_key)
+			WHERE incident_key IS NOT NULL AND incident_key <> '' AND status IN ('triggered', 'acknowledged', 'external_pending')
+			DO NOTHING`,
+		incident.ID, incident.Title, incident.Description, incident.Status, incident.Urgency, incident.Priority,
+		nullIfEmpty(incident.AssignedTo), incident.Source, nullIfEmpty(incident.IntegrationID), nullIfEmpty(incident.ServiceID),
+		incident.ExternalID, incident.ExternalURL,
+		nullIfEmpty(incident.EscalationPolicyID), incident.CurrentEscalationLevel, incident.EscalationStatus,
+		nullIfEmpty(incident.GroupID), nullIfEmpty(incident.APIKeyID), incident.Severity, incident.IncidentKey, incident.AlertCount,
+		labelsJSON, customFieldsJSON, nullIfEmpty(incident.OrganizationID), nullIfEmpty(incident.ProjectID),
+		incident.ResponseSLAMinutes, incident.ResolutionSLAMinutes, nullIfEmpty(incident.RelatedDeployID),
+		nullIfEmpty(incident.SnapshotURL),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create incident: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// publishIncidentCreated records creation events and sends notifications for a new incident
+func (s *IncidentService) publishIncidentCreated(incident *db.Incident) {
+	// Create triggered event
+	triggeredData := map[string]interface{}{
+		"source":   incident.Source,
+		"severity": incident.Severity,
+	}
+	if incident.SnapshotURL != "" {
+		triggeredData["snapshot_url"] = incident.SnapshotURL
+	}
+	_ = s.createIncidentEvent(incident.ID, db.IncidentEventTriggered, triggeredData, "")
+
+	// Create assignment event reflecting how the assignee was picked
 	if incident.AssignedTo != "" && incident.AssignedAt != nil {
 		eventData := map[string]interface{}{
 			"assigned_to_id": incident.AssignedTo,
-			"method":         "auto_assignment",
-			"reason":         "escalation_policy",
+			"method":         "manual",
+		}
+		if incident.AssignmentMethod != db.AssignmentMethodExplicit {
+			eventData["method"] = "auto_assignment"
+			eventData["reason"] = incident.AssignmentMethod
 		}
 
 		// Get user name for display
 		var userName string
-		err = s.PG.QueryRow(`SELECT COALESCE(name, email, 'Unknown') FROM users WHERE id = $1`, incident.AssignedTo).Scan(&userName)
+		err := s.PG.QueryRow(`SELECT COALESCE(name, email, 'Unknown') FROM users WHERE id = $1`, incident.AssignedTo).Scan(&userName)
 		if err == nil {
 			eventData["assigned_to"] = userName
 		} else {
@@ -862,8 +1437,44 @@ func (s *IncidentService) CreateIncident(incident *db.Incident) (*db.Incident, e
 		s.Redis.RPush(context.Background(), "incidents:queue", b)
 	}
 
+	// Enforce the service's notification settings before notifying the assignee
+	notifySlack := s.NotificationWorker != nil && incident.AssignedTo != ""
+	notifyFCM := s.FCMService != nil && incident.AssignedTo != ""
+	if notifySlack || notifyFCM {
+		settings := s.getServiceNotificationSettings(incident.ServiceID)
+		if reason := NotificationSuppressedReason(settings, incident, time.Now()); reason != "" {
+			log.Printf("DEBUG: Notifications suppressed for incident %s: %s", incident.ID, reason)
+			_ = s.createIncidentEvent(incident.ID, db.IncidentEventNotificationSuppressed, map[string]interface{}{
+				"reason":     reason,
+				"service_id": incident.ServiceID,
+			}, "")
+			notifySlack, notifyFCM = false, false
+		}
+		notifySlack = notifySlack && ChannelEnabled(settings, ServiceChannelSlack)
+		notifyFCM = notifyFCM && ChannelEnabled(settings, ServiceChannelFCM)
+	}
+
+	if (notifySlack || notifyFCM) && s.AssignmentNotificationDelay > 0 {
+		s.debounceAssignmentNotification(incident, notifySlack, notifyFCM)
+	} else {
+		s.sendAssignmentNotification(incident, notifySlack, notifyFCM)
+	}
+
+	// Broadcast real-time notification to all connected clients in the organization
+	if s.BroadcastService != nil && incident.OrganizationID != "" {
+		s.BroadcastService.BroadcastIncidentAsync(incident.OrganizationID, incident, "INSERT")
+	}
+}
+
+// sendAssignmentNotification notifies the assignee of a new incident on the enabled channels
+func (s *IncidentService) sendAssignmentNotification(incident *db.Incident, notifySlack, notifyFCM bool) {
+	if notifySlack || notifyFCM {
+		s.recordPage(incident.GroupID, incident.AssignedTo)
+		s.notifyIfAssigneeUnreachable(incident, notifySlack, notifyFCM)
+	}
+
 	// Send incident assignment notification
-	if s.NotificationWorker != nil && incident.AssignedTo != "" {
+	if notifySlack {
 		go func() {
 			err := s.NotificationWorker.SendIncidentAssignedNotification(incident.AssignedTo, incident.ID)
 			if err != nil {
@@ -875,7 +1486,7 @@ func (s *IncidentService) CreateIncident(incident *db.Incident) (*db.Incident, e
 	}
 
 	// Send FCM notification (convert to alert format for now)
-	if s.FCMService != nil && incident.AssignedTo != "" {
+	if notifyFCM {
 		go func() {
 			// Convert incident to alert format for FCM compatibility
 			alert := &db.Alert{
@@ -892,19 +1503,32 @@ func (s *IncidentService) CreateIncident(incident *db.Incident) (*db.Incident, e
 			}
 		}()
 	}
+}
 
-	// Broadcast real-time notification to all connected clients in the organization
-	if s.BroadcastService != nil && incident.OrganizationID != "" {
-		s.BroadcastService.BroadcastIncidentAsync(incident.OrganizationID, incident, "INSERT")
-	}
-
-	return incident, nil
+// debounceAssignmentNotification holds the assignment notification on the held assignment
+// queue for AssignmentNotificationDelay; SendHeldAssignmentNotifications sends it afterwards
+// unless the incident has been resolved by then
+func (s *IncidentService) debounceAssignmentNotification(incident *db.Incident, notifySlack, notifyFCM bool) {
+	delay := s.AssignmentNotificationDelay
+	if err := s.holdAssignmentNotification(incident, notifySlack, notifyFCM, delay); err != nil {
+		// Better to page early than not at all
+		log.Printf("WARNING: Failed to hold assignment notification for incident %s, sending it now: %v", incident.ID, err)
+		s.sendAssignmentNotification(incident, notifySlack, notifyFCM)
+		return
+	}
+	log.Printf("DEBUG: Holding assignment notification for incident %s for %s", incident.ID, delay)
 }
 
 // UpdateIncident updates an incident's fields
 func (s *IncidentService) UpdateIncident(id string, req db.UpdateIncidentRequest) (*db.Incident, error) {
+	if req.BusinessImpact != nil {
+		if err := validateBusinessImpact(req.BusinessImpact); err != nil {
+			return nil, err
+		}
+	}
+
 	// Build dynamic update query
-	query := "UPDATE incidents SET updated_at = NOW()"
+	query := "UPDATE incidents SET updated_at = " + SQLNowUTC
 	args := []interface{}{}
 	argIndex := 1
 
@@ -950,17 +1574,23 @@ func (s *IncidentService) UpdateIncident(id string, req db.UpdateIncidentRequest
 		args = append(args, string(customFieldsJSON))
 		argIndex++
 	}
+	if req.BusinessImpact != nil {
+		businessImpactJSON, _ := json.Marshal(req.BusinessImpact)
+		query += fmt.Sprintf(", business_impact = $%d", argIndex)
+		args = append(args, string(businessImpactJSON))
+		argIndex++
+	}
 
-	query += fmt.Sprintf(" WHERE id = $%d RETURNING id, title, description, status, urgency, priority, severity, labels, custom_fields, updated_at", argIndex)
+	query += fmt.Sprintf(" WHERE id = $%d RETURNING id, title, description, status, urgency, priority, severity, labels, custom_fields, business_impact, updated_at", argIndex)
 	args = append(args, id)
 
 	var incident db.Incident
-	var labels, customFields sql.NullString
+	var labels, customFields, businessImpact sql.NullString
 
 	err := s.PG.QueryRow(query, args...).Scan(
 		&incident.ID, &incident.Title, &incident.Description, &incident.Status,
 		&incident.Urgency, &incident.Priority, &incident.Severity,
-		&labels, &customFields, &incident.UpdatedAt,
+		&labels, &customFields, &businessImpact, &incident.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update incident: %w", err)
@@ -972,36 +1602,155 @@ func (s *IncidentService) UpdateIncident(id string, req db.UpdateIncidentRequest
 	if customFields.Valid && customFields.String != "" {
 		_ = json.Unmarshal([]byte(customFields.String), &incident.CustomFields)
 	}
+	if businessImpact.Valid && businessImpact.String != "" {
+		_ = json.Unmarshal([]byte(businessImpact.String), &incident.BusinessImpact)
+	}
 
 	// Create update event
 	_ = s.createIncidentEvent(id, db.IncidentEventUpdated, map[string]interface{}{
 		"updated_fields": req,
 	}, "")
 
+	// The assignee has no other notification for field changes, so include them
+	s.notifyWatchers(id, db.IncidentEventUpdated, "", true)
+
 	return &incident, nil
 }
 
-// AcknowledgeIncident acknowledges an incident
-func (s *IncidentService) AcknowledgeIncident(id, userID, note string) error {
-	now := time.Now()
-	_, err := s.PG.Exec(`
+// sqlExecer is satisfied by both *sql.DB and *sql.Tx
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// ErrAckETAInPast is returned when acknowledging with an ETA that has already passed
+var ErrAckETAInPast = errors.New("eta must be in the future")
+
+// AcknowledgeIncident acknowledges an incident. A non-zero eta is when the responder expects
+// to resolve it; the incident worker nudges them if it passes first. Acknowledging an already
+// acknowledged incident with an eta replaces the ETA; otherwise it returns ErrIncidentNotTriggered.
+func (s *IncidentService) AcknowledgeIncident(id, userID, note string, eta time.Time) error {
+	if !eta.IsZero() && !eta.After(time.Now()) {
+		return ErrAckETAInPast
+	}
+
+	eventData := map[string]interface{}{}
+	if note != "" {
+		eventData["note"] = note
+	}
+	if !eta.IsZero() {
+		eventData["eta"] = eta.UTC().Format(time.RFC3339)
+	}
+	acknowledged, err := acknowledgeIncidentWithEvent(s.PG, id, userID, eta, eventData)
+	if err != nil {
+		return err
+	}
+	if !acknowledged {
+		if eta.IsZero() {
+			return ErrIncidentNotTriggered
+		}
+		return s.updateAckETA(id, userID, eta, eventData)
+	}
+
+	s.notifyIncidentAcknowledged(id, userID)
+	return nil
+}
+
+// updateAckETA replaces the ETA of an acknowledged incident and re-arms the ETA nudge.
+// Nobody is notified; the eta_updated event shows the change on the timeline.
+func (s *IncidentService) updateAckETA(id, userID string, eta time.Time, eventData map[string]interface{}) error {
+	result, err := s.PG.Exec(`
 		UPDATE incidents
-		SET status = $1, acknowledged_by = $2::uuid, acknowledged_at = $3, updated_at = $4
-		WHERE id = $5 AND status = $6
-	`, db.IncidentStatusAcknowledged, userID, now, now, id, db.IncidentStatusTriggered)
+		SET ack_eta = $1, eta_nudged_at = NULL, updated_at = `+SQLNowUTC+`
+		WHERE id = $2 AND status = $3
+	`, eta, id, db.IncidentStatusAcknowledged)
+	if err != nil {
+		return fmt.Errorf("failed to update incident eta: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrIncidentNotTriggered
+	}
 
+	if err := s.createIncidentEvent(id, db.IncidentEventETAUpdated, eventData, userID); err != nil {
+		log.Printf("WARNING: Failed to record eta_updated event for incident %s: %v", id, err)
+	}
+	return nil
+}
+
+// ErrIncidentNotTriggered is returned when acknowledging an incident that is no longer triggered
+var ErrIncidentNotTriggered = errors.New("incident is not in triggered state")
+
+// AcknowledgeIncidentByAPIKey acknowledges an incident on behalf of an automation holding an API key.
+// The API system user is recorded as the actor and the key is kept in the event for auditing.
+func (s *IncidentService) AcknowledgeIncidentByAPIKey(id string, apiKey *db.APIKey, note string) error {
+	eventData := map[string]interface{}{
+		"acknowledged_via": "api_key",
+		"api_key_id":       apiKey.ID,
+		"api_key_name":     apiKey.Name,
+	}
+	if note != "" {
+		eventData["note"] = note
+	}
+
+	acknowledged, err := acknowledgeIncidentWithEvent(s.PG, id, db.SystemUserAPI, time.Time{}, eventData)
 	if err != nil {
-		return fmt.Errorf("failed to acknowledge incident: %w", err)
+		return err
+	}
+	if !acknowledged {
+		return ErrIncidentNotTriggered
 	}
 
-	// Create acknowledged event
+	s.notifyIncidentAcknowledged(id, db.SystemUserAPI)
+	return nil
+}
+
+// acknowledgeIncidentWith moves a triggered incident to acknowledged and records the event.
+// Returns false if the incident was not in triggered state.
+func acknowledgeIncidentWith(exec sqlExecer, id, userID, note string) (bool, error) {
 	eventData := map[string]interface{}{}
 	if note != "" {
 		eventData["note"] = note
 	}
-	_ = s.createIncidentEvent(id, db.IncidentEventAcknowledged, eventData, userID)
+	return acknowledgeIncidentWithEvent(exec, id, userID, time.Time{}, eventData)
+}
+
+// acknowledgeIncidentWithEvent is acknowledgeIncidentWith with an ETA, zero for none, and
+// caller-supplied event data
+func acknowledgeIncidentWithEvent(exec sqlExecer, id, userID string, eta time.Time, eventData map[string]interface{}) (bool, error) {
+	result, err := exec.Exec(`
+		UPDATE incidents
+		SET status = $1, acknowledged_by = $2::uuid, acknowledged_at = `+SQLNowUTC+`, updated_at = `+SQLNowUTC+`,
+		    -- Cancel any snooze and any pending timeout escalation
+		    escalation_status = CASE
+		        WHEN COALESCE(snoozed_escalation_status, escalation_status) IN ('none', 'pending') THEN 'stopped'
+		        ELSE COALESCE(snoozed_escalation_status, escalation_status)
+		    END,
+		    snoozed_until = NULL, snoozed_escalation_status = NULL,
+		    ack_eta = $5, eta_nudged_at = NULL
+		WHERE id = $3 AND status = $4
+	`, db.IncidentStatusAcknowledged, userID, id, db.IncidentStatusTriggered, sql.NullTime{Time: eta, Valid: !eta.IsZero()})
+
+	if err != nil {
+		return false, fmt.Errorf("failed to acknowledge incident: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return false, nil
+	}
 
-	// Send notification about web acknowledgment to update Slack
+	if err := StopEscalations(exec, id, userID, db.AlertEscalationStatusAcknowledged); err != nil {
+		log.Printf("WARNING: Incident %s: %v", id, err)
+	}
+
+	// Create acknowledged event; in a transaction a failed insert must not be committed past
+	if err := createIncidentEventWith(exec, id, db.IncidentEventAcknowledged, eventData, userID); err != nil {
+		return true, fmt.Errorf("failed to record acknowledged event: %w", err)
+	}
+	return true, nil
+}
+
+// notifyIncidentAcknowledged sends notification about web acknowledgment to update Slack
+func (s *IncidentService) notifyIncidentAcknowledged(id, userID string) {
 	if s.NotificationWorker != nil {
 		go func() {
 			err := s.NotificationWorker.SendIncidentAcknowledgedNotification(userID, id)
@@ -1012,20 +1761,60 @@ func (s *IncidentService) AcknowledgeIncident(id, userID, note string) error {
 			}
 		}()
 	}
-
-	return nil
+	s.notifyWatchers(id, db.IncidentEventAcknowledged, userID, false)
 }
 
+// ErrAlreadyResolved is returned when resolving an incident that is already resolved
+var ErrAlreadyResolved = errors.New("incident is already resolved")
+
 // ResolveIncident resolves an incident
 func (s *IncidentService) ResolveIncident(id, userID, note, resolution string) error {
-	_, err := s.PG.Exec(`
+	return s.ResolveIncidentWithLabelDiff(id, userID, note, resolution, nil)
+}
+
+// ResolveIncidentWithLabelDiff resolves an incident and stores how the resolving
+// alert's labels differ from the firing alert's in the resolved event
+// Returns ErrAlreadyResolved, without recording an event or notifying, if the
+// incident was already resolved; the original resolver is kept.
+func (s *IncidentService) ResolveIncidentWithLabelDiff(id, userID, note, resolution string, labelDiff *db.IncidentLabelDiff) error {
+	resolved, err := resolveIncidentWith(s.PG, id, userID, note, resolution, labelDiff)
+	if err != nil {
+		return err
+	}
+	if !resolved {
+		return ErrAlreadyResolved
+	}
+
+	s.notifyIncidentResolved(id, userID)
+	return nil
+}
+
+// resolveIncidentWith resolves an incident and records the event.
+// Returns false, and records nothing, if the incident was already resolved.
+func resolveIncidentWith(exec sqlExecer, id, userID, note, resolution string, labelDiff *db.IncidentLabelDiff) (bool, error) {
+	result, err := exec.Exec(`
 		UPDATE incidents
-		SET status = $1, resolved_by = $2::uuid, resolved_at = NOW() AT TIME ZONE 'UTC'
+		SET status = $1, resolved_by = $2::uuid, resolved_at = `+SQLNowUTC+`, updated_at = `+SQLNowUTC+`,
+		    -- Cancel any snooze and any pending timeout escalation
+		    escalation_status = CASE
+		        WHEN COALESCE(snoozed_escalation_status, escalation_status) IN ('none', 'pending') THEN 'stopped'
+		        ELSE COALESCE(snoozed_escalation_status, escalation_status)
+		    END,
+		    snoozed_until = NULL, snoozed_escalation_status = NULL
 		WHERE id = $3 AND status != $1
 	`, db.IncidentStatusResolved, userID, id)
 
 	if err != nil {
-		return fmt.Errorf("failed to resolve incident: %w", err)
+		return false, fmt.Errorf("failed to resolve incident: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := StopEscalations(exec, id, userID, db.AlertEscalationStatusStopped); err != nil {
+		log.Printf("WARNING: Incident %s: %v", id, err)
 	}
 
 	// Create resolved event
@@ -1036,9 +1825,60 @@ func (s *IncidentService) ResolveIncident(id, userID, note, resolution string) e
 	if resolution != "" {
 		eventData["resolution"] = resolution
 	}
-	_ = s.createIncidentEvent(id, db.IncidentEventResolved, eventData, userID)
+	if !labelDiff.IsEmpty() {
+		eventData["label_diff"] = labelDiff
+	}
+	if err := createIncidentEventWith(exec, id, db.IncidentEventResolved, eventData, userID); err != nil {
+		return true, fmt.Errorf("failed to record resolved event: %w", err)
+	}
+	return true, nil
+}
+
+// DiffLabels compares the labels an incident fired with against the labels of
+// the alert that resolved it. Keys listed in ignore are not compared.
+func DiffLabels(fired, resolved map[string]interface{}, ignore ...string) *db.IncidentLabelDiff {
+	skip := make(map[string]bool, len(ignore))
+	for _, key := range ignore {
+		skip[key] = true
+	}
 
-	// Send notification about resolution to update Slack
+	diff := &db.IncidentLabelDiff{}
+	for key, firedValue := range fired {
+		if skip[key] {
+			continue
+		}
+		resolvedValue, ok := resolved[key]
+		if !ok {
+			if diff.Removed == nil {
+				diff.Removed = make(map[string]interface{})
+			}
+			diff.Removed[key] = firedValue
+			continue
+		}
+		if !reflect.DeepEqual(firedValue, resolvedValue) {
+			if diff.Changed == nil {
+				diff.Changed = make(map[string]db.IncidentLabelChange)
+			}
+			diff.Changed[key] = db.IncidentLabelChange{From: firedValue, To: resolvedValue}
+		}
+	}
+	for key, resolvedValue := range resolved {
+		if skip[key] {
+			continue
+		}
+		if _, ok := fired[key]; !ok {
+			if diff.Added == nil {
+				diff.Added = make(map[string]interface{})
+			}
+			diff.Added[key] = resolvedValue
+		}
+	}
+
+	return diff
+}
+
+// notifyIncidentResolved sends notification about resolution to update Slack
+func (s *IncidentService) notifyIncidentResolved(id, userID string) {
 	if s.NotificationWorker != nil {
 		go func() {
 			err := s.NotificationWorker.SendIncidentResolvedNotification(userID, id)
@@ -1049,10 +1889,607 @@ func (s *IncidentService) ResolveIncident(id, userID, note, resolution string) e
 			}
 		}()
 	}
+	s.notifyWatchers(id, db.IncidentEventResolved, userID, false)
+}
+
+// ErrIncidentNotResolved is returned when reopening an incident that isn't resolved
+var ErrIncidentNotResolved = errors.New("incident is not resolved")
+
+// ErrIncidentKeyInUse is returned (wrapped, naming the holder) when reopening an incident
+// whose incident_key another open incident of the organization holds
+var ErrIncidentKeyInUse = errors.New("incident key is held by another open incident")
+
+// ReopenIncident moves a prematurely resolved incident back to triggered.
+// Escalation resumes from the current level, with its timeout counted from now,
+// and the assignee is paged again.
+func (s *IncidentService) ReopenIncident(id, userID, reason string) error {
+	var assignedTo sql.NullString
+	var level int
+	err := s.PG.QueryRow(`
+		UPDATE incidents
+		SET status = $1,
+		    acknowledged_by = NULL,
+		    acknowledged_at = NULL,
+		    resolved_by = NULL,
+		    resolved_at = NULL,
+		    escalation_status = CASE WHEN escalation_policy_id IS NULL THEN escalation_status ELSE 'pending' END,
+		    current_escalation_level = GREATEST(current_escalation_level, 1),
+		    escalation_repeat_count = 0,
+		    last_escalated_at = `+SQLNowUTC+`,
+		    updated_at = `+SQLNowUTC+`
+		WHERE id = $2 AND status = $3
+		RETURNING assigned_to, current_escalation_level
+	`, db.IncidentStatusTriggered, id, db.IncidentStatusResolved).Scan(&assignedTo, &level)
+	if err == sql.ErrNoRows {
+		return ErrIncidentNotResolved
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_incidents_org_open_incident_key" {
+		return s.incidentKeyConflict(id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reopen incident: %w", err)
+	}
+
+	eventData := map[string]interface{}{
+		"escalation_level": level,
+	}
+	if reason != "" {
+		eventData["reason"] = reason
+	}
+	if err := s.createIncidentEvent(id, db.IncidentEventReopened, eventData, userID); err != nil {
+		log.Printf("WARNING: Failed to create reopened event for incident %s: %v", id, err)
+	}
+
+	// Page the assignee again so the on-call knows it's back
+	if assignedTo.Valid && assignedTo.String != "" && s.NotificationWorker != nil {
+		if err := s.NotificationWorker.SendIncidentAssignedNotification(assignedTo.String, id); err != nil {
+			log.Printf("WARNING: Failed to send notification for reopened incident %s: %v", id, err)
+		}
+	}
+	s.notifyWatchers(id, db.IncidentEventReopened, userID, false)
 
 	return nil
 }
 
+// incidentKeyConflict names the open incident holding the incident_key of id, which a new
+// alert with the same key opened while id was resolved
+func (s *IncidentService) incidentKeyConflict(id string) error {
+	var openID string
+	err := s.PG.QueryRow(`
+		SELECT o.id
+		FROM incidents r
+		JOIN incidents o ON o.organization_id = r.organization_id AND o.incident_key = r.incident_key
+		WHERE r.id = $1 AND o.id <> r.id AND o.status IN ($2, $3)
+		LIMIT 1
+	`, id, db.IncidentStatusTriggered, db.IncidentStatusAcknowledged).Scan(&openID)
+	if err != nil {
+		// The holder may have been resolved since; the reopen still failed on its key
+		log.Printf("WARNING: Failed to find the open incident holding the key of incident %s: %v", id, err)
+		return ErrIncidentKeyInUse
+	}
+	return fmt.Errorf("%w: %s", ErrIncidentKeyInUse, openID)
+}
+
+// ErrIncidentArchived is returned when archiving an incident that is already archived
+var ErrIncidentArchived = errors.New("incident is already archived")
+
+// ArchiveIncident hides a resolved incident from default incident lists.
+// It stays available through include_archived and in reporting.
+func (s *IncidentService) ArchiveIncident(id, userID string) error {
+	var status string
+	var archived bool
+	err := s.PG.QueryRow(`SELECT status, archived_at IS NOT NULL FROM incidents WHERE id = $1`, id).Scan(&status, &archived)
+	if err == sql.ErrNoRows {
+		return ErrIncidentNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get incident: %w", err)
+	}
+	if archived {
+		return ErrIncidentArchived
+	}
+	if status != db.IncidentStatusResolved {
+		return ErrIncidentNotResolved
+	}
+
+	result, err := s.PG.Exec(`
+		UPDATE incidents
+		SET archived_at = NOW()
+		WHERE id = $1 AND status = $2 AND archived_at IS NULL
+	`, id, db.IncidentStatusResolved)
+	if err != nil {
+		return fmt.Errorf("failed to archive incident: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		// Reopened or archived concurrently
+		return fmt.Errorf("incident changed while archiving")
+	}
+
+	_ = s.createIncidentEvent(id, db.IncidentEventArchived, map[string]interface{}{}, userID)
+	return nil
+}
+
+// ArchiveResolvedIncidents archives incidents resolved more than olderThanDays days ago
+// and returns how many were archived. Meant to run periodically; 0 or less does nothing.
+func (s *IncidentService) ArchiveResolvedIncidents(olderThanDays int) (int, error) {
+	if olderThanDays <= 0 {
+		return 0, nil
+	}
+
+	rows, err := s.PG.Query(`
+		UPDATE incidents
+		SET archived_at = NOW()
+		WHERE status = $1
+		  AND archived_at IS NULL
+		  AND resolved_at < (`+SQLNowUTC+`) - make_interval(days => $2)
+		RETURNING id
+	`, db.IncidentStatusResolved, olderThanDays)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive resolved incidents: %w", err)
+	}
+	defer rows.Close()
+
+	var archivedIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return len(archivedIDs), fmt.Errorf("failed to scan archived incident: %w", err)
+		}
+		archivedIDs = append(archivedIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return len(archivedIDs), fmt.Errorf("failed to archive resolved incidents: %w", err)
+	}
+
+	for _, id := range archivedIDs {
+		_ = s.createIncidentEvent(id, db.IncidentEventArchived, map[string]interface{}{
+			"reason":          "auto_archive",
+			"resolved_before": fmt.Sprintf("%d days", olderThanDays),
+		}, "")
+	}
+
+	return len(archivedIDs), nil
+}
+
+// BulkUpdateStatus acknowledges or resolves several incidents in a single transaction.
+// ReBAC: each incident must be visible to the user under the same scoping as ListIncidents,
+// otherwise it is reported as not found. Failures are reported per incident.
+func (s *IncidentService) BulkUpdateStatus(incidentIDs []string, userID, orgID, action, note string) (*db.BulkUpdateResult, error) {
+	if action != db.IncidentBulkActionAcknowledge && action != db.IncidentBulkActionResolve {
+		return nil, fmt.Errorf("invalid action '%s'. Must be one of: acknowledge, resolve", action)
+	}
+
+	result := &db.BulkUpdateResult{
+		SucceededIDs: []string{},
+		Errors:       map[string]string{},
+	}
+	if len(incidentIDs) == 0 {
+		return result, nil
+	}
+
+	// Drop duplicate IDs so each incident is processed once
+	uniqueIDs := make([]string, 0, len(incidentIDs))
+	seen := make(map[string]bool, len(incidentIDs))
+	for _, id := range incidentIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			uniqueIDs = append(uniqueIDs, id)
+		}
+	}
+
+	tx, err := s.PG.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Lock the incidents of the caller's organization the user can see, matching what
+	// ListIncidents returns in that org
+	rows, err := tx.Query(`
+		SELECT i.id, i.status
+		FROM incidents i
+		WHERE i.id::text = ANY($3)
+		AND i.organization_id = $2
+		AND `+incidentAccessScope+`
+		FOR UPDATE OF i
+	`, userID, orgID, pq.Array(uniqueIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load incidents: %w", err)
+	}
+
+	statuses := make(map[string]string)
+	for rows.Next() {
+		var id, status string
+		if err := rows.Scan(&id, &status); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan incident: %w", err)
+		}
+		statuses[id] = status
+	}
+	rows.Close()
+
+	for _, id := range uniqueIDs {
+		status, visible := statuses[id]
+		switch {
+		case !visible:
+			result.Errors[id] = "incident not found"
+			continue
+		case action == db.IncidentBulkActionAcknowledge && status != db.IncidentStatusTriggered:
+			result.Errors[id] = fmt.Sprintf("cannot acknowledge incident in status '%s'", status)
+			continue
+		case action == db.IncidentBulkActionResolve && status == db.IncidentStatusResolved:
+			result.Errors[id] = ErrAlreadyResolved.Error()
+			continue
+		}
+
+		// Savepoint keeps one failing incident from aborting the whole transaction
+		if _, err := tx.Exec("SAVEPOINT bulk_incident"); err != nil {
+			return nil, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		var updateErr error
+		if action == db.IncidentBulkActionAcknowledge {
+			_, updateErr = acknowledgeIncidentWith(tx, id, userID, note)
+		} else {
+			var resolved bool
+			resolved, updateErr = resolveIncidentWith(tx, id, userID, note, "", nil)
+			if updateErr == nil && !resolved {
+				// Resolved concurrently since the status check above
+				updateErr = ErrAlreadyResolved
+			}
+		}
+
+		if updateErr != nil {
+			if _, err := tx.Exec("ROLLBACK TO SAVEPOINT bulk_incident"); err != nil {
+				return nil, fmt.Errorf("failed to rollback savepoint: %w", err)
+			}
+			result.Errors[id] = updateErr.Error()
+			continue
+		}
+		if _, err := tx.Exec("RELEASE SAVEPOINT bulk_incident"); err != nil {
+			return nil, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+
+		result.SucceededIDs = append(result.SucceededIDs, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Notifications only go out once the transaction is committed
+	for _, id := range result.SucceededIDs {
+		if action == db.IncidentBulkActionAcknowledge {
+			s.notifyIncidentAcknowledged(id, userID)
+		} else {
+			s.notifyIncidentResolved(id, userID)
+		}
+	}
+
+	result.Succeeded = len(result.SucceededIDs)
+	result.Failed = len(result.Errors)
+
+	log.Printf("Bulk %s by user %s: %d succeeded, %d failed", action, userID, result.Succeeded, result.Failed)
+	return result, nil
+}
+
+// slaBreachQuery selects incidents whose time-to-acknowledge or time-to-resolve exceeded
+// their SLA target. Incident targets override the service defaults. Open incidents are
+// measured against the current UTC time, so a breach is reported as soon as the target passes.
+const slaBreachQuery = `
+	WITH targets AS (
+		SELECT i.id, i.title, i.status, i.created_at, i.acknowledged_at, i.resolved_at,
+		       COALESCE(i.response_sla_minutes, s.response_sla_minutes) AS response_sla,
+		       COALESCE(i.resolution_sla_minutes, s.resolution_sla_minutes) AS resolution_sla
+		FROM incidents i
+		LEFT JOIN services s ON i.service_id = s.id
+		WHERE ($1 = '' OR i.organization_id::text = $1)
+		AND ($2 = FALSE OR i.status != 'resolved')
+	), measured AS (
+		SELECT id, title, status, created_at, 'response' AS sla_type, response_sla AS sla_minutes,
+		       EXTRACT(EPOCH FROM (COALESCE(acknowledged_at, resolved_at, ` + SQLNowUTC + `) - created_at)) / 60 AS elapsed
+		FROM targets WHERE response_sla IS NOT NULL
+		UNION ALL
+		SELECT id, title, status, created_at, 'resolution', resolution_sla,
+		       EXTRACT(EPOCH FROM (COALESCE(resolved_at, ` + SQLNowUTC + `) - created_at)) / 60
+		FROM targets WHERE resolution_sla IS NOT NULL
+	)
+	SELECT m.id, m.title, m.status, m.sla_type, m.sla_minutes, FLOOR(m.elapsed)::int, m.created_at
+	FROM measured m
+	WHERE m.elapsed > m.sla_minutes
+	AND ($2 = FALSE OR NOT EXISTS (
+		SELECT 1 FROM incident_events e
+		WHERE e.incident_id = m.id
+		AND e.event_type = 'sla_breached'
+		AND e.event_data->>'sla_type' = m.sla_type
+	))
+	ORDER BY m.created_at DESC`
+
+// incidentSLADueAt is when the SLA currently running for an incident of a list query is
+// breached, like slaRemaining: the response SLA until acknowledged, then the resolution SLA.
+// NULL for resolved incidents and incidents without an SLA.
+const incidentSLADueAt = `CASE
+			WHEN i.status = 'resolved' THEN NULL
+			WHEN i.acknowledged_at IS NULL AND COALESCE(i.response_sla_minutes, s.response_sla_minutes) IS NOT NULL
+				THEN i.created_at + COALESCE(i.response_sla_minutes, s.response_sla_minutes) * INTERVAL '1 minute'
+			ELSE i.created_at + COALESCE(i.resolution_sla_minutes, s.resolution_sla_minutes) * INTERVAL '1 minute'
+		END`
+
+// slaRemaining returns the SLA currently running for an incident and the seconds left
+// until it is breached (negative once breached), or nil when no SLA applies
+func slaRemaining(incident *db.Incident, now time.Time) (string, *int64) {
+	if incident.Status == db.IncidentStatusResolved {
+		return "", nil
+	}
+
+	slaType, minutes := db.SLATypeResolution, incident.ResolutionSLAMinutes
+	if incident.AcknowledgedAt == nil && incident.ResponseSLAMinutes != nil {
+		slaType, minutes = db.SLATypeResponse, incident.ResponseSLAMinutes
+	}
+	if minutes == nil {
+		return "", nil
+	}
+
+	deadline := incident.CreatedAt.Add(time.Duration(*minutes) * time.Minute)
+	remaining := int64(deadline.Sub(now) / time.Second)
+	return slaType, &remaining
+}
+
+// GetSLABreaches returns the SLA breaches of an organization's incidents
+func (s *IncidentService) GetSLABreaches(orgID string) ([]db.SLABreach, error) {
+	if orgID == "" {
+		return nil, fmt.Errorf("organization_id is required")
+	}
+	return s.querySLABreaches(orgID, false)
+}
+
+// RecordSLABreaches logs an sla_breached event for each new breach on an open incident.
+// Each incident records at most one event per SLA type, so repeated runs are idempotent.
+func (s *IncidentService) RecordSLABreaches() ([]db.SLABreach, error) {
+	breaches, err := s.querySLABreaches("", true)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := make([]db.SLABreach, 0, len(breaches))
+	for _, breach := range breaches {
+		eventData, err := json.Marshal(map[string]interface{}{
+			"sla_type":        breach.SLAType,
+			"sla_minutes":     breach.SLAMinutes,
+			"elapsed_minutes": breach.ElapsedMinutes,
+		})
+		if err != nil {
+			return recorded, fmt.Errorf("failed to marshal sla breach event: %w", err)
+		}
+
+		result, err := s.PG.Exec(`
+			INSERT INTO incident_events (incident_id, event_type, event_data, created_at)
+			SELECT $1, $2, $3, `+SQLNowUTC+`
+			WHERE NOT EXISTS (
+				SELECT 1 FROM incident_events
+				WHERE incident_id = $1 AND event_type = $2 AND event_data->>'sla_type' = $4
+			)
+		`, breach.IncidentID, db.IncidentEventSLABreached, eventData, breach.SLAType)
+		if err != nil {
+			return recorded, fmt.Errorf("failed to record sla breach: %w", err)
+		}
+		if rows, _ := result.RowsAffected(); rows > 0 {
+			recorded = append(recorded, breach)
+		}
+	}
+
+	return recorded, nil
+}
+
+func (s *IncidentService) querySLABreaches(orgID string, onlyUnrecorded bool) ([]db.SLABreach, error) {
+	rows, err := s.PG.Query(slaBreachQuery, orgID, onlyUnrecorded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sla breaches: %w", err)
+	}
+	defer rows.Close()
+
+	breaches := []db.SLABreach{}
+	for rows.Next() {
+		var breach db.SLABreach
+		if err := rows.Scan(
+			&breach.IncidentID, &breach.Title, &breach.Status, &breach.SLAType,
+			&breach.SLAMinutes, &breach.ElapsedMinutes, &breach.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan sla breach: %w", err)
+		}
+		breaches = append(breaches, breach)
+	}
+
+	return breaches, rows.Err()
+}
+
+// SnoozeIncident pauses escalation of an incident until the given time.
+// The incident worker restores the previous escalation status once the snooze expires;
+// acknowledging or resolving the incident cancels the snooze.
+func (s *IncidentService) SnoozeIncident(id, userID string, until time.Time, reason string) error {
+	if !until.After(time.Now()) {
+		return fmt.Errorf("snooze time must be in the future")
+	}
+
+	// Re-snoozing keeps the escalation status saved by the first snooze
+	result, err := s.PG.Exec(`
+		UPDATE incidents
+		SET snoozed_escalation_status = CASE
+		        WHEN snoozed_until IS NULL THEN escalation_status
+		        ELSE snoozed_escalation_status
+		    END,
+		    escalation_status = 'stopped',
+		    snoozed_until = $1,
+		    updated_at = `+SQLNowUTC+`
+		WHERE id = $2 AND status != $3
+	`, until.UTC(), id, db.IncidentStatusResolved)
+	if err != nil {
+		return fmt.Errorf("failed to snooze incident: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("incident not found or already resolved")
+	}
+
+	eventData := map[string]interface{}{
+		"snoozed_until": until.UTC().Format(time.RFC3339),
+	}
+	if reason != "" {
+		eventData["reason"] = reason
+	}
+	_ = s.createIncidentEvent(id, db.IncidentEventSnoozed, eventData, userID)
+
+	return nil
+}
+
+// MergeIncidents consolidates duplicate incidents into a primary incident.
+// Secondaries are resolved and labelled with merged_into, their alert counts are
+// added to the primary and labels the primary doesn't have are copied over.
+// The primary's status is left untouched.
+func (s *IncidentService) MergeIncidents(primaryID string, secondaryIDs []string, userID string) error {
+	uniqueIDs := make([]string, 0, len(secondaryIDs))
+	seen := map[string]bool{primaryID: true}
+	for _, id := range secondaryIDs {
+		if id == primaryID {
+			return fmt.Errorf("cannot merge incident into itself")
+		}
+		if id != "" && !seen[id] {
+			seen[id] = true
+			uniqueIDs = append(uniqueIDs, id)
+		}
+	}
+	if len(uniqueIDs) == 0 {
+		return fmt.Errorf("no incidents to merge")
+	}
+
+	tx, err := s.PG.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	type mergeCandidate struct {
+		organizationID string
+		alertCount     int
+		labels         map[string]interface{}
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, COALESCE(organization_id::text, ''), alert_count, labels
+		FROM incidents
+		WHERE id::text = ANY($1)
+		FOR UPDATE
+	`, pq.Array(append([]string{primaryID}, uniqueIDs...)))
+	if err != nil {
+		return fmt.Errorf("failed to load incidents: %w", err)
+	}
+
+	candidates := make(map[string]*mergeCandidate)
+	for rows.Next() {
+		var id string
+		var labels sql.NullString
+		candidate := &mergeCandidate{}
+		if err := rows.Scan(&id, &candidate.organizationID, &candidate.alertCount, &labels); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan incident: %w", err)
+		}
+		candidate.labels = make(map[string]interface{})
+		if labels.Valid && labels.String != "" {
+			_ = json.Unmarshal([]byte(labels.String), &candidate.labels)
+		}
+		candidates[id] = candidate
+	}
+	rows.Close()
+
+	primary, ok := candidates[primaryID]
+	if !ok {
+		return ErrIncidentNotFound
+	}
+
+	// Validate everything before touching any rows
+	for _, id := range uniqueIDs {
+		secondary, ok := candidates[id]
+		if !ok {
+			return fmt.Errorf("incident %s not found", id)
+		}
+		if secondary.organizationID != primary.organizationID {
+			return fmt.Errorf("incident %s belongs to a different organization", id)
+		}
+		if mergedInto, ok := secondary.labels["merged_into"]; ok {
+			return fmt.Errorf("incident %s was already merged into %v", id, mergedInto)
+		}
+	}
+
+	var newlyResolved []string
+	for _, id := range uniqueIDs {
+		secondary := candidates[id]
+		primary.alertCount += secondary.alertCount
+
+		// Primary wins on conflicting labels; first secondary wins among secondaries
+		for key, value := range secondary.labels {
+			if _, exists := primary.labels[key]; !exists {
+				primary.labels[key] = value
+			}
+		}
+
+		// Resolved like any other resolve, so its escalation and snooze end too; an already
+		// resolved secondary keeps its resolver
+		resolved, err := resolveIncidentWith(tx, id, userID, fmt.Sprintf("merged into %s", primaryID), "", nil)
+		if err != nil {
+			return fmt.Errorf("failed to resolve merged incident %s: %w", id, err)
+		}
+		if resolved {
+			newlyResolved = append(newlyResolved, id)
+		}
+
+		secondary.labels["merged_into"] = primaryID
+		labelsJSON, _ := json.Marshal(secondary.labels)
+		if _, err := tx.Exec(`
+			UPDATE incidents
+			SET labels = $1, updated_at = `+SQLNowUTC+`
+			WHERE id = $2
+		`, string(labelsJSON), id); err != nil {
+			return fmt.Errorf("failed to merge incident %s: %w", id, err)
+		}
+
+		if err := createIncidentEventWith(tx, id, db.IncidentEventMerged, map[string]interface{}{
+			"note":        fmt.Sprintf("merged into %s", primaryID),
+			"merged_into": primaryID,
+		}, userID); err != nil {
+			return fmt.Errorf("failed to create merge event for incident %s: %w", id, err)
+		}
+	}
+
+	primaryLabelsJSON, _ := json.Marshal(primary.labels)
+	if _, err := tx.Exec(`
+		UPDATE incidents
+		SET alert_count = $1, labels = $2
+		WHERE id = $3
+	`, primary.alertCount, string(primaryLabelsJSON), primaryID); err != nil {
+		return fmt.Errorf("failed to update primary incident: %w", err)
+	}
+
+	if err := createIncidentEventWith(tx, primaryID, db.IncidentEventMerged, map[string]interface{}{
+		"merged_incident_ids": uniqueIDs,
+		"alert_count":         primary.alertCount,
+	}, userID); err != nil {
+		return fmt.Errorf("failed to create merge event: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, id := range newlyResolved {
+		s.notifyIncidentResolved(id, userID)
+	}
+
+	log.Printf("Merged %d incidents into %s by user %s", len(uniqueIDs), primaryID, userID)
+	return nil
+}
+
 // AssignIncident assigns an incident to a user
 func (s *IncidentService) AssignIncident(id, userID, assignedBy, note string) error {
 	_, err := s.PG.Exec(`
@@ -1068,6 +2505,7 @@ func (s *IncidentService) AssignIncident(id, userID, assignedBy, note string) er
 	// Create assigned event with user name resolution
 	eventData := map[string]interface{}{
 		"assigned_to_id": userID,
+		"method":         "manual",
 	}
 
 	// Get user name for display
@@ -1082,6 +2520,7 @@ func (s *IncidentService) AssignIncident(id, userID, assignedBy, note string) er
 	if note != "" {
 		eventData["note"] = note
 	}
+	_ = s.createIncidentEvent(id, db.IncidentEventAssigned, eventData, assignedBy)
 	return nil
 }
 
@@ -1099,22 +2538,85 @@ func (s *IncidentService) AddNote(id, userID, note string) error {
 		eventData["author_name"] = userName
 	}
 
-	return s.createIncidentEvent(id, db.IncidentEventNoteAdded, eventData, userID)
+	return s.createIncidentEvent(id, db.IncidentEventNoteAdded, eventData, userID)
+}
+
+// incidentEventSelect selects incident events with their actor.
+// Events recorded without a user are attributed to the system user of the incident's source,
+// and system users are given friendly names by queryIncidentEvents.
+const incidentEventSelect = `
+		SELECT ie.id, ie.incident_id, ie.event_type, ie.event_data, ie.created_at, ie.created_by,
+			   u.name as created_by_name, u.email as created_by_email, u.role as created_by_role,
+			   COALESCE(i.labels->>'source', i.source, '') as incident_source
+		FROM incident_events ie
+		LEFT JOIN users u ON ie.created_by = u.id
+		LEFT JOIN incidents i ON ie.incident_id = i.id
+		WHERE ie.incident_id = $1`
+
+// GetIncidentEvents returns the latest events for an incident
+func (s *IncidentService) GetIncidentEvents(incidentID string, limit int) ([]db.IncidentEvent, error) {
+	query := incidentEventSelect + `
+		ORDER BY ie.created_at DESC
+		LIMIT $2
+	`
+	return s.queryIncidentEvents(query, incidentID, limit)
+}
+
+// GetIncidentEventsPaged returns an incident's events newest first, one page at a time.
+// Supported filters: event_type (comma-separated string or []string), since, until (time.Time),
+// cursor (from NextIncidentEventCursor) and limit (default 50, max 100).
+func (s *IncidentService) GetIncidentEventsPaged(incidentID string, filters map[string]interface{}) ([]db.IncidentEvent, error) {
+	query := incidentEventSelect
+	args := []interface{}{incidentID}
+	argIndex := 2
+
+	if eventTypes := parseStatusFilter(filters["event_type"]); len(eventTypes) > 0 {
+		query += fmt.Sprintf(" AND ie.event_type = ANY($%d)", argIndex)
+		args = append(args, pq.Array(eventTypes))
+		argIndex++
+	}
+	if since, ok := filters["since"].(time.Time); ok && !since.IsZero() {
+		query += fmt.Sprintf(" AND ie.created_at >= $%d", argIndex)
+		args = append(args, since)
+		argIndex++
+	}
+	if until, ok := filters["until"].(time.Time); ok && !until.IsZero() {
+		query += fmt.Sprintf(" AND ie.created_at < $%d", argIndex)
+		args = append(args, until)
+		argIndex++
+	}
+	if cursor, ok := filters["cursor"].(string); ok && cursor != "" {
+		cursorCreatedAt, cursorID, err := DecodeIncidentCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		query += fmt.Sprintf(" AND (ie.created_at, ie.id) < ($%d::timestamptz, $%d::uuid)", argIndex, argIndex+1)
+		args = append(args, cursorCreatedAt, cursorID)
+		argIndex += 2
+	}
+
+	limit := 50
+	if l, ok := filters["limit"].(int); ok && l > 0 && l <= 100 {
+		limit = l
+	}
+	query += fmt.Sprintf(" ORDER BY ie.created_at DESC, ie.id DESC LIMIT $%d", argIndex)
+	args = append(args, limit)
+
+	return s.queryIncidentEvents(query, args...)
 }
 
-// GetIncidentEvents returns events for an incident
-func (s *IncidentService) GetIncidentEvents(incidentID string, limit int) ([]db.IncidentEvent, error) {
-	query := `
-		SELECT ie.id, ie.incident_id, ie.event_type, ie.event_data, ie.created_at, ie.created_by,
-			   u.name as created_by_name
-		FROM incident_events ie
-		LEFT JOIN users u ON ie.created_by = u.id
-		WHERE ie.incident_id = $1
-		ORDER BY ie.created_at DESC
-		LIMIT $2
-	`
+// NextIncidentEventCursor returns the cursor for the page after events, or "" when the page wasn't full
+func NextIncidentEventCursor(events []db.IncidentEvent, limit int) string {
+	if len(events) == 0 || len(events) < limit {
+		return ""
+	}
+	last := events[len(events)-1]
+	return EncodeIncidentCursor(last.CreatedAt, last.ID)
+}
 
-	rows, err := s.PG.Query(query, incidentID, limit)
+// queryIncidentEvents runs a query built on incidentEventSelect and scans the events
+func (s *IncidentService) queryIncidentEvents(query string, args ...interface{}) ([]db.IncidentEvent, error) {
+	rows, err := s.PG.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get incident events: %w", err)
 	}
@@ -1124,21 +2626,29 @@ func (s *IncidentService) GetIncidentEvents(incidentID string, limit int) ([]db.
 	for rows.Next() {
 		var event db.IncidentEvent
 		var eventDataJSON sql.NullString
-		var createdBy, createdByName sql.NullString
+		var createdBy, createdByName, createdByEmail, createdByRole sql.NullString
+		var source string
 
 		err := rows.Scan(
 			&event.ID, &event.IncidentID, &event.EventType, &eventDataJSON,
-			&event.CreatedAt, &createdBy, &createdByName,
+			&event.CreatedAt, &createdBy, &createdByName, &createdByEmail, &createdByRole, &source,
 		)
 		if err != nil {
 			continue
 		}
 
-		if createdBy.Valid {
-			event.CreatedBy = createdBy.String
+		event.CreatedBy = createdBy.String
+		if event.CreatedBy == "" {
+			event.CreatedBy = db.GetSystemUserBySource(source)
 		}
-		if createdByName.Valid {
+		// Synthetic system user IDs may have no users row, so name them here
+		if name, ok := db.SystemUserName(event.CreatedBy); ok {
+			event.CreatedByName = name
+			event.CreatedByRole = db.SystemActorRole
+		} else {
 			event.CreatedByName = createdByName.String
+			event.CreatedByEmail = createdByEmail.String
+			event.CreatedByRole = createdByRole.String
 		}
 		if eventDataJSON.Valid && eventDataJSON.String != "" {
 			_ = json.Unmarshal([]byte(eventDataJSON.String), &event.EventData)
@@ -1152,6 +2662,11 @@ func (s *IncidentService) GetIncidentEvents(incidentID string, limit int) ([]db.
 
 // createIncidentEvent creates an event for an incident
 func (s *IncidentService) createIncidentEvent(incidentID, eventType string, eventData map[string]interface{}, createdBy string) error {
+	return createIncidentEventWith(s.PG, incidentID, eventType, eventData, createdBy)
+}
+
+// createIncidentEventWith creates an event for an incident using the given executor (DB or transaction)
+func createIncidentEventWith(exec sqlExecer, incidentID, eventType string, eventData map[string]interface{}, createdBy string) error {
 	eventDataJSON, _ := json.Marshal(eventData)
 
 	var createdByParam interface{}
@@ -1159,16 +2674,50 @@ func (s *IncidentService) createIncidentEvent(incidentID, eventType string, even
 		createdByParam = createdBy
 	}
 
-	_, err := s.PG.Exec(`
-		INSERT INTO incident_events (incident_id, event_type, event_data, created_by)
-		VALUES ($1, $2, $3, $4)
+	_, err := exec.Exec(`
+		INSERT INTO incident_events (incident_id, event_type, event_data, created_by, created_at)
+		VALUES ($1, $2, $3, $4, `+SQLNowUTC+`)
 	`, incidentID, eventType, string(eventDataJSON), createdByParam)
 
 	return err
 }
 
-// GetIncidentStats returns incident statistics
-func (s *IncidentService) GetIncidentStats() (map[string]interface{}, error) {
+// statsTimeRanges maps the ListIncidents time_range values to GetIncidentStats intervals;
+// "all" is unbounded
+var statsTimeRanges = map[string]string{
+	"last_24_hours": "24 hours",
+	"last_7_days":   "7 days",
+	"last_30_days":  "30 days",
+	"last_90_days":  "90 days",
+	"all":           "",
+}
+
+// GetIncidentStats returns incident statistics scoped to an organization and optionally a project.
+// timeRange takes the ListIncidents values (last_24_hours ... all) and defaults to last_30_days.
+// An empty orgID returns empty stats.
+func (s *IncidentService) GetIncidentStats(orgID, projectID, timeRange string) (map[string]interface{}, error) {
+	interval, ok := statsTimeRanges[timeRange]
+	if !ok {
+		timeRange = "last_30_days"
+		interval = statsTimeRanges[timeRange]
+	}
+
+	byPriority := map[string]int{}
+	stats := map[string]interface{}{
+		"total":        0,
+		"triggered":    0,
+		"acknowledged": 0,
+		"resolved":     0,
+		"high_urgency": 0,
+		"by_priority":  byPriority,
+		"time_range":   timeRange,
+	}
+	if orgID == "" {
+		return stats, nil
+	}
+
+	whereClause, args := trendsFilter("", interval, orgID, projectID)
+
 	query := `
 		SELECT 
 			COUNT(*) as total,
@@ -1177,31 +2726,52 @@ func (s *IncidentService) GetIncidentStats() (map[string]interface{}, error) {
 			COUNT(CASE WHEN status = 'resolved' THEN 1 END) as resolved,
 			COUNT(CASE WHEN urgency = 'high' THEN 1 END) as high_urgency
 		FROM incidents
-		WHERE created_at >= NOW() - INTERVAL '30 days'
-	`
+		` + whereClause
 
 	var total, triggered, acknowledged, resolved, highUrgency int
-	err := s.PG.QueryRow(query).Scan(&total, &triggered, &acknowledged, &resolved, &highUrgency)
+	err := s.PG.QueryRow(query, args...).Scan(&total, &triggered, &acknowledged, &resolved, &highUrgency)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get incident stats: %w", err)
 	}
 
-	return map[string]interface{}{
-		"total":        total,
-		"triggered":    triggered,
-		"acknowledged": acknowledged,
-		"resolved":     resolved,
-		"high_urgency": highUrgency,
-	}, nil
+	stats["total"] = total
+	stats["triggered"] = triggered
+	stats["acknowledged"] = acknowledged
+	stats["resolved"] = resolved
+	stats["high_urgency"] = highUrgency
+
+	rows, err := s.PG.Query(`
+		SELECT COALESCE(NULLIF(priority, ''), 'none') as priority, COUNT(*) as count
+		FROM incidents
+		`+whereClause+`
+		GROUP BY 1
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incident stats by priority: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var priority string
+		var count int
+		if err := rows.Scan(&priority, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan incident stats by priority: %w", err)
+		}
+		byPriority[priority] = count
+	}
+
+	return stats, rows.Err()
 }
 
 // IncidentTrendDataPoint represents a single data point in the trends time series
 type IncidentTrendDataPoint struct {
-	Date         string `json:"date"`
-	Triggered    int    `json:"triggered"`
-	Acknowledged int    `json:"acknowledged"`
-	Resolved     int    `json:"resolved"`
-	Total        int    `json:"total"`
+	Date           string   `json:"date"`
+	Triggered      int      `json:"triggered"`
+	Acknowledged   int      `json:"acknowledged"`
+	Resolved       int      `json:"resolved"`
+	Total          int      `json:"total"`
+	AvgMTTAMinutes *float64 `json:"avg_mtta_minutes,omitempty"`
+	AvgMTTRMinutes *float64 `json:"avg_mttr_minutes,omitempty"`
 }
 
 // ServiceIncidentCount represents incident count per service
@@ -1222,21 +2792,13 @@ type IncidentTrendsResponse struct {
 	TotalIncidents int                      `json:"total_incidents"`
 }
 
+// trendsQueryConcurrency bounds how many GetIncidentTrends queries run at once
+const trendsQueryConcurrency = 3
+
 // GetIncidentTrends returns incident trends and analytics data
 func (s *IncidentService) GetIncidentTrends(orgID, projectID, timeRange string) (*IncidentTrendsResponse, error) {
 	// Determine the time interval based on timeRange
-	var intervalDays int
-	switch timeRange {
-	case "7d":
-		intervalDays = 7
-	case "30d":
-		intervalDays = 30
-	case "90d":
-		intervalDays = 90
-	default:
-		intervalDays = 7
-		timeRange = "7d"
-	}
+	intervalDays, timeRange := trendsInterval(timeRange)
 
 	response := &IncidentTrendsResponse{
 		DailyCounts: make([]IncidentTrendDataPoint, 0),
@@ -1247,180 +2809,243 @@ func (s *IncidentService) GetIncidentTrends(orgID, projectID, timeRange string)
 		TimeRange:   timeRange,
 	}
 
-	// Build WHERE clause for org/project filtering
-	// Note: incidents table uses 'organization_id' not 'org_id'
-	whereClause := "WHERE created_at >= NOW() - $1::interval"
-	args := []interface{}{fmt.Sprintf("%d days", intervalDays)}
-	argIndex := 2
-
-	if orgID != "" {
-		whereClause += fmt.Sprintf(" AND organization_id = $%d", argIndex)
-		args = append(args, orgID)
-		argIndex++
-	}
-	if projectID != "" {
-		whereClause += fmt.Sprintf(" AND project_id = $%d", argIndex)
-		args = append(args, projectID)
-		argIndex++
-	}
-	_ = argIndex // silence ineffassign
-
-	// 1. Get daily counts
-	dailyQuery := fmt.Sprintf(`
-		SELECT 
-			TO_CHAR(DATE(created_at), 'YYYY-MM-DD') as date,
-			COUNT(*) as total,
-			COUNT(CASE WHEN status = 'triggered' THEN 1 END) as triggered,
-			COUNT(CASE WHEN status = 'acknowledged' THEN 1 END) as acknowledged,
-			COUNT(CASE WHEN status = 'resolved' THEN 1 END) as resolved
-		FROM incidents
-		%s
-		GROUP BY DATE(created_at)
-		ORDER BY DATE(created_at) ASC
-	`, whereClause)
-
-	rows, err := s.PG.Query(dailyQuery, args...)
-	if err != nil {
-		log.Printf("ERROR: Failed to get daily counts: %v", err)
-		return nil, fmt.Errorf("failed to get daily counts: %w", err)
-	}
-	defer rows.Close()
-
-	totalIncidents := 0
-	for rows.Next() {
-		var dp IncidentTrendDataPoint
-		if err := rows.Scan(&dp.Date, &dp.Total, &dp.Triggered, &dp.Acknowledged, &dp.Resolved); err != nil {
-			log.Printf("WARNING: Failed to scan daily count row: %v", err)
-			continue
+	// WHERE clause for org/project filtering
+	whereClause, args := trendsFilter("", fmt.Sprintf("%d days", intervalDays), orgID, projectID)
+
+	// The aggregates are independent, so run them concurrently (bounded to spare the pool).
+	// Only the daily counts are required; the others degrade to empty sections on error.
+	var g errgroup.Group
+	g.SetLimit(trendsQueryConcurrency)
+
+	// 1. Get daily counts, with a zero-count point for each day without incidents so charts
+	// don't skip them ($1 is always the interval, see trendsFilter)
+	g.Go(func() error {
+		dailyQuery := fmt.Sprintf(`
+			WITH daily AS (
+				SELECT 
+					DATE(created_at) as day,
+					COUNT(*) as total,
+					COUNT(CASE WHEN status = 'triggered' THEN 1 END) as triggered,
+					COUNT(CASE WHEN status = 'acknowledged' THEN 1 END) as acknowledged,
+					COUNT(CASE WHEN status = 'resolved' THEN 1 END) as resolved,
+					AVG(`+sqlMinutesBetween("created_at", "acknowledged_at")+`) as avg_mtta_minutes,
+					AVG(`+sqlMinutesBetween("created_at", "resolved_at")+`) as avg_mttr_minutes
+				FROM incidents
+				%s
+				GROUP BY DATE(created_at)
+			)
+			SELECT 
+				TO_CHAR(days.day, 'YYYY-MM-DD') as date,
+				COALESCE(daily.total, 0),
+				COALESCE(daily.triggered, 0),
+				COALESCE(daily.acknowledged, 0),
+				COALESCE(daily.resolved, 0),
+				daily.avg_mtta_minutes,
+				daily.avg_mttr_minutes
+			FROM generate_series(DATE(NOW() - $1::interval), CURRENT_DATE, INTERVAL '1 day') AS days(day)
+			LEFT JOIN daily ON daily.day = days.day::date
+			ORDER BY days.day ASC
+		`, whereClause)
+
+		rows, err := s.PG.Query(dailyQuery, args...)
+		if err != nil {
+			log.Printf("ERROR: Failed to get daily counts: %v", err)
+			return fmt.Errorf("failed to get daily counts: %w", err)
 		}
-		response.DailyCounts = append(response.DailyCounts, dp)
-		totalIncidents += dp.Total
-	}
-	response.TotalIncidents = totalIncidents
+		defer rows.Close()
+
+		totalIncidents := 0
+		for rows.Next() {
+			var dp IncidentTrendDataPoint
+			var avgMTTA, avgMTTR sql.NullFloat64
+			if err := rows.Scan(&dp.Date, &dp.Total, &dp.Triggered, &dp.Acknowledged, &dp.Resolved, &avgMTTA, &avgMTTR); err != nil {
+				log.Printf("WARNING: Failed to scan daily count row: %v", err)
+				continue
+			}
+			if avgMTTA.Valid {
+				dp.AvgMTTAMinutes = &avgMTTA.Float64
+			}
+			if avgMTTR.Valid {
+				dp.AvgMTTRMinutes = &avgMTTR.Float64
+			}
+			response.DailyCounts = append(response.DailyCounts, dp)
+			totalIncidents += dp.Total
+		}
+		response.TotalIncidents = totalIncidents
+		return nil
+	})
 
 	// 2. Get counts by severity
-	severityQuery := fmt.Sprintf(`
-		SELECT 
-			COALESCE(severity, 'unknown') as severity,
-			COUNT(*) as count
-		FROM incidents
-		%s
-		GROUP BY severity
-		ORDER BY count DESC
-	`, whereClause)
-
-	severityRows, err := s.PG.Query(severityQuery, args...)
-	if err != nil {
-		log.Printf("Warning: failed to get severity counts: %v", err)
-	} else {
-		defer severityRows.Close()
-		for severityRows.Next() {
-			var severity string
-			var count int
-			if err := severityRows.Scan(&severity, &count); err == nil {
-				response.BySeverity[severity] = count
+	g.Go(func() error {
+		severityQuery := fmt.Sprintf(`
+			SELECT 
+				COALESCE(severity, 'unknown') as severity,
+				COUNT(*) as count
+			FROM incidents
+			%s
+			GROUP BY severity
+			ORDER BY count DESC
+		`, whereClause)
+
+		severityRows, err := s.PG.Query(severityQuery, args...)
+		if err != nil {
+			log.Printf("Warning: failed to get severity counts: %v", err)
+		} else {
+			defer severityRows.Close()
+			for severityRows.Next() {
+				var severity string
+				var count int
+				if err := severityRows.Scan(&severity, &count); err == nil {
+					response.BySeverity[severity] = count
+				}
 			}
 		}
-	}
+		return nil
+	})
 
 	// 3. Get counts by urgency
-	urgencyQuery := fmt.Sprintf(`
-		SELECT 
-			COALESCE(urgency, 'low') as urgency,
-			COUNT(*) as count
-		FROM incidents
-		%s
-		GROUP BY urgency
-		ORDER BY count DESC
-	`, whereClause)
-
-	urgencyRows, err := s.PG.Query(urgencyQuery, args...)
-	if err != nil {
-		log.Printf("Warning: failed to get urgency counts: %v", err)
-	} else {
-		defer urgencyRows.Close()
-		for urgencyRows.Next() {
-			var urgency string
-			var count int
-			if err := urgencyRows.Scan(&urgency, &count); err == nil {
-				response.ByUrgency[urgency] = count
+	g.Go(func() error {
+		urgencyQuery := fmt.Sprintf(`
+			SELECT 
+				COALESCE(urgency, 'low') as urgency,
+				COUNT(*) as count
+			FROM incidents
+			%s
+			GROUP BY urgency
+			ORDER BY count DESC
+		`, whereClause)
+
+		urgencyRows, err := s.PG.Query(urgencyQuery, args...)
+		if err != nil {
+			log.Printf("Warning: failed to get urgency counts: %v", err)
+		} else {
+			defer urgencyRows.Close()
+			for urgencyRows.Next() {
+				var urgency string
+				var count int
+				if err := urgencyRows.Scan(&urgency, &count); err == nil {
+					response.ByUrgency[urgency] = count
+				}
 			}
 		}
-	}
+		return nil
+	})
 
 	// 4. Get top services by incident count
-	// Build WHERE clause with table alias 'i' for the services join query
-	serviceWhereClause := "WHERE i.created_at >= NOW() - $1::interval"
-	serviceArgIndex := 2
-	if orgID != "" {
-		serviceWhereClause += fmt.Sprintf(" AND i.organization_id = $%d", serviceArgIndex)
-		serviceArgIndex++
-	}
-	if projectID != "" {
-		serviceWhereClause += fmt.Sprintf(" AND i.project_id = $%d", serviceArgIndex)
-	}
-
-	serviceQuery := fmt.Sprintf(`
-		SELECT 
-			i.service_id,
-			COALESCE(s.name, 'Unknown Service') as service_name,
-			COUNT(*) as count
-		FROM incidents i
-		LEFT JOIN services s ON i.service_id = s.id
-		%s
-		AND i.service_id IS NOT NULL
-		GROUP BY i.service_id, s.name
-		ORDER BY count DESC
-		LIMIT 10
-	`, serviceWhereClause)
-
-	serviceRows, err := s.PG.Query(serviceQuery, args...)
-	if err != nil {
-		log.Printf("Warning: failed to get service counts: %v", err)
-	} else {
-		defer serviceRows.Close()
-		for serviceRows.Next() {
-			var sc ServiceIncidentCount
-			if err := serviceRows.Scan(&sc.ServiceID, &sc.ServiceName, &sc.Count); err == nil {
-				response.ByService = append(response.ByService, sc)
+	g.Go(func() error {
+		// Same filter with table alias 'i' for the services join query
+		serviceWhereClause, serviceArgs := trendsFilter("i.", fmt.Sprintf("%d days", intervalDays), orgID, projectID)
+
+		serviceQuery := fmt.Sprintf(`
+			SELECT 
+				i.service_id,
+				COALESCE(s.name, 'Unknown Service') as service_name,
+				COUNT(*) as count
+			FROM incidents i
+			LEFT JOIN services s ON i.service_id = s.id
+			%s
+			AND i.service_id IS NOT NULL
+			GROUP BY i.service_id, s.name
+			ORDER BY count DESC
+			LIMIT 10
+		`, serviceWhereClause)
+
+		serviceRows, err := s.PG.Query(serviceQuery, serviceArgs...)
+		if err != nil {
+			log.Printf("Warning: failed to get service counts: %v", err)
+		} else {
+			defer serviceRows.Close()
+			for serviceRows.Next() {
+				var sc ServiceIncidentCount
+				if err := serviceRows.Scan(&sc.ServiceID, &sc.ServiceName, &sc.Count); err == nil {
+					response.ByService = append(response.ByService, sc)
+				}
 			}
 		}
-	}
+		return nil
+	})
 
 	// 5. Calculate MTTA (Mean Time To Acknowledge) and MTTR (Mean Time To Resolve)
-	metricsQuery := fmt.Sprintf(`
-		SELECT 
-			AVG(EXTRACT(EPOCH FROM (acknowledged_at - created_at))/60) as avg_mtta_minutes,
-			AVG(EXTRACT(EPOCH FROM (resolved_at - created_at))/60) as avg_mttr_minutes,
-			COUNT(CASE WHEN acknowledged_at IS NOT NULL THEN 1 END) as acknowledged_count,
-			COUNT(CASE WHEN resolved_at IS NOT NULL THEN 1 END) as resolved_count
-		FROM incidents
-		%s
-	`, whereClause)
-
-	var avgMTTA, avgMTTR sql.NullFloat64
-	var acknowledgedCount, resolvedCount int
-	err = s.PG.QueryRow(metricsQuery, args...).Scan(&avgMTTA, &avgMTTR, &acknowledgedCount, &resolvedCount)
-	if err != nil {
-		log.Printf("Warning: failed to get metrics: %v", err)
-	} else {
-		if avgMTTA.Valid {
-			response.Metrics["mtta_avg_minutes"] = fmt.Sprintf("%.1f", avgMTTA.Float64)
-		} else {
-			response.Metrics["mtta_avg_minutes"] = "N/A"
-		}
-		if avgMTTR.Valid {
-			response.Metrics["mttr_avg_minutes"] = fmt.Sprintf("%.1f", avgMTTR.Float64)
+	g.Go(func() error {
+		metricsQuery := fmt.Sprintf(`
+			SELECT 
+				AVG(`+sqlMinutesBetween("created_at", "acknowledged_at")+`) as avg_mtta_minutes,
+				AVG(`+sqlMinutesBetween("created_at", "resolved_at")+`) as avg_mttr_minutes,
+				COUNT(CASE WHEN acknowledged_at IS NOT NULL THEN 1 END) as acknowledged_count,
+				COUNT(CASE WHEN resolved_at IS NOT NULL THEN 1 END) as resolved_count
+			FROM incidents
+			%s
+		`, whereClause)
+
+		var avgMTTA, avgMTTR sql.NullFloat64
+		var acknowledgedCount, resolvedCount int
+		err := s.PG.QueryRow(metricsQuery, args...).Scan(&avgMTTA, &avgMTTR, &acknowledgedCount, &resolvedCount)
+		if err != nil {
+			log.Printf("Warning: failed to get metrics: %v", err)
 		} else {
-			response.Metrics["mttr_avg_minutes"] = "N/A"
+			if avgMTTA.Valid {
+				response.Metrics["mtta_avg_minutes"] = fmt.Sprintf("%.1f", avgMTTA.Float64)
+			} else {
+				response.Metrics["mtta_avg_minutes"] = "N/A"
+			}
+			if avgMTTR.Valid {
+				response.Metrics["mttr_avg_minutes"] = fmt.Sprintf("%.1f", avgMTTR.Float64)
+			} else {
+				response.Metrics["mttr_avg_minutes"] = "N/A"
+			}
+			response.Metrics["acknowledged_count"] = acknowledgedCount
+			response.Metrics["resolved_count"] = resolvedCount
 		}
-		response.Metrics["acknowledged_count"] = acknowledgedCount
-		response.Metrics["resolved_count"] = resolvedCount
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
 	}
 
 	return response, nil
 }
 
+// trendsInterval returns the number of days a trends time range (7d, 30d, 90d) covers
+// and the range itself, defaulting to 7d
+func trendsInterval(timeRange string) (int, string) {
+	switch timeRange {
+	case "30d":
+		return 30, timeRange
+	case "90d":
+		return 90, timeRange
+	default:
+		return 7, "7d"
+	}
+}
+
+// trendsFilter builds a GetIncidentTrends WHERE clause together with the args its placeholders
+// refer to, so each query gets a matching pair. prefix qualifies the incidents columns (e.g. "i.").
+// An empty interval (e.g. "30 days") leaves the time range unbounded.
+// Note: incidents table uses 'organization_id' not 'org_id'
+func trendsFilter(prefix, interval, orgID, projectID string) (string, []interface{}) {
+	var conditions []string
+	args := []interface{}{}
+
+	if interval != "" {
+		args = append(args, interval)
+		conditions = append(conditions, fmt.Sprintf("%screated_at >= NOW() - $%d::interval", prefix, len(args)))
+	}
+	if orgID != "" {
+		args = append(args, orgID)
+		conditions = append(conditions, fmt.Sprintf("%sorganization_id = $%d", prefix, len(args)))
+	}
+	if projectID != "" {
+		args = append(args, projectID)
+		conditions = append(conditions, fmt.Sprintf("%sproject_id = $%d", prefix, len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
 // GetAssigneeFromEscalationPolicy determines who should be assigned to an incident based on escalation policy
 func (s *IncidentService) GetAssigneeFromEscalationPolicy(escalationPolicyID, groupID string) (string, error) {
 	log.Printf("DEBUG: GetAssigneeFromEscalationPolicy called with escalationPolicyID='%s', groupID='%s'", escalationPolicyID, groupID)
@@ -1489,29 +3114,30 @@ func (s *IncidentService) GetAssigneeFromEscalationPolicy(escalationPolicyID, gr
 func (s *IncidentService) getCurrentOnCallUserFromScheduler(schedulerID, groupID string) (string, error) {
 	log.Printf("DEBUG: getCurrentOnCallUserFromScheduler called with schedulerID='%s', groupID='%s'", schedulerID, groupID)
 
+	// Everyone on call right now; the least recently paged of the first layer is picked
 	query := `
-		SELECT effective_user_id
-		FROM effective_shifts
-		WHERE scheduler_id = $1
-		AND group_id = $2
-		AND start_time <= NOW()
-		AND end_time >= NOW()
-		ORDER BY start_time ASC
-		LIMIT 1
+		SELECT es.effective_user_id, es.start_time, es.scheduler_id, es.rotation_cycle_id, p.last_paged_at
+		FROM effective_shifts es
+		LEFT JOIN oncall_pages p ON p.group_id = es.group_id AND p.user_id = es.effective_user_id
+		WHERE es.scheduler_id = $1
+		AND es.group_id = $2
+		AND es.start_time <= NOW()
+		AND es.end_time >= NOW()
+		ORDER BY es.start_time ASC
 	`
 
 	log.Printf("DEBUG: Querying effective_shifts view for current on-call user in scheduler")
 
-	var userID string
-	err := s.PG.QueryRow(query, schedulerID, groupID).Scan(&userID)
+	candidates, err := s.queryOnCallCandidates(query, schedulerID, groupID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Printf("DEBUG: No current on-call user found for scheduler '%s' in group '%s'", schedulerID, groupID)
-			return "", nil // No one currently on-call for this scheduler
-		}
 		log.Printf("DEBUG: Database error querying effective_shifts: %v", err)
 		return "", fmt.Errorf("failed to get current on-call user from scheduler: %w", err)
 	}
+	userID := pickLeastRecentlyPaged(candidates)
+	if userID == "" {
+		log.Printf("DEBUG: No current on-call user found for scheduler '%s' in group '%s'", schedulerID, groupID)
+		return "", nil // No one currently on-call for this scheduler
+	}
 
 	log.Printf("DEBUG: Found current on-call user (effective) '%s' for scheduler '%s'", userID, schedulerID)
 	return userID, nil
@@ -1522,72 +3148,165 @@ func (s *IncidentService) getCurrentOnCallUserFromScheduler(schedulerID, groupID
 func (s *IncidentService) getCurrentOnCallUserFromGroup(groupID string) (string, error) {
 	log.Printf("DEBUG: getCurrentOnCallUserFromGroup called with groupID='%s'", groupID)
 
+	// Everyone on call right now; the least recently paged of the first layer is picked
 	query := `
-		SELECT effective_user_id
-		FROM effective_shifts
-		WHERE group_id = $1
-		AND start_time <= NOW()
-		AND end_time >= NOW()
-		ORDER BY start_time ASC
-		LIMIT 1
+		SELECT es.effective_user_id, es.start_time, es.scheduler_id, es.rotation_cycle_id, p.last_paged_at
+		FROM effective_shifts es
+		LEFT JOIN oncall_pages p ON p.group_id = es.group_id AND p.user_id = es.effective_user_id
+		WHERE es.group_id = $1
+		AND es.start_time <= NOW()
+		AND es.end_time >= NOW()
+		ORDER BY es.start_time ASC, es.scheduler_id, es.rotation_cycle_id
 	`
 
 	log.Printf("DEBUG: Querying effective_shifts view for current on-call user in group")
 
-	var userID string
-	err := s.PG.QueryRow(query, groupID).Scan(&userID)
+	candidates, err := s.queryOnCallCandidates(query, groupID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Printf("DEBUG: No current on-call user found for group '%s'", groupID)
-			return "", nil // No one currently on-call for this group
-		}
 		log.Printf("DEBUG: Database error querying effective_shifts: %v", err)
 		return "", fmt.Errorf("failed to get current on-call user from group: %w", err)
 	}
+	userID := pickLeastRecentlyPaged(candidates)
+	if userID == "" {
+		log.Printf("DEBUG: No current on-call user found for group '%s'", groupID)
+		return "", nil // No one currently on-call for this group
+	}
 
 	log.Printf("DEBUG: Found current on-call user (effective) '%s' for group '%s'", userID, groupID)
 	return userID, nil
 }
 
+// Errors for incidents whose escalation policy can't take them any further
+var (
+	ErrCannotEscalateResolved = errors.New("cannot escalate resolved incident")
+	ErrNoEscalationPolicy     = errors.New("incident has no escalation policy")
+	ErrNoEscalationLevels     = errors.New("escalation policy has no levels defined")
+	ErrMaxEscalationLevel     = errors.New("already at maximum escalation level")
+)
+
 // ManualEscalateIncident handles manual escalation triggered by user action
 // Returns the new escalation level, assigned user ID, and any error
 func (s *IncidentService) ManualEscalateIncident(incidentID, userID string) (*db.EscalationResult, error) {
 	log.Printf("DEBUG: ManualEscalateIncident called for incident %s by user %s", incidentID, userID)
+	return s.escalateToNextLevel(incidentID, userID, "manual_escalation")
+}
+
+// EscalateIncidentOnTimeout advances a still-triggered incident to its next escalation level
+// once the current level's timeout_minutes passed without an acknowledgement.
+// Acknowledging the incident first cancels the escalation.
+func (s *IncidentService) EscalateIncidentOnTimeout(incidentID string) (*db.EscalationResult, error) {
+	log.Printf("DEBUG: EscalateIncidentOnTimeout called for incident %s", incidentID)
+	return s.escalateToNextLevel(incidentID, "", "timeout_escalation")
+}
+
+// PreviewNextEscalation computes what ManualEscalateIncident would do next - the level,
+// its target and the on-call user it resolves to - without changing the incident
+func (s *IncidentService) PreviewNextEscalation(incidentID string) (*db.EscalationResult, error) {
+	plan, err := s.planNextEscalation(incidentID, false)
+	if err != nil {
+		return nil, err
+	}
+	if plan.target == nil {
+		return nil, fmt.Errorf("%w (%d)", ErrMaxEscalationLevel, plan.currentLevel)
+	}
+
+	var assignedToName string
+	if plan.assignedUserID != "" {
+		_ = s.PG.QueryRow(`SELECT COALESCE(name, email, 'Unknown') FROM users WHERE id = $1`, plan.assignedUserID).Scan(&assignedToName)
+	}
+
+	return &db.EscalationResult{
+		NewLevel:         plan.nextLevel,
+		AssignedUserID:   plan.assignedUserID,
+		AssignedToName:   assignedToName,
+		EscalationStatus: plan.newStatus(),
+		TargetType:       plan.target.TargetType,
+		HasMoreLevels:    plan.hasMoreLevels,
+	}, nil
+}
+
+// escalationPlan is the next step of an incident's escalation policy, computed without side effects
+type escalationPlan struct {
+	currentLevel   int
+	nextLevel      int
+	target         *db.EscalationLevel // nil when no levels are left
+	title          string
+	severity       string
+	skipped        []skippedEscalationLevel
+	groupID        string // group the assignee is paged for
+	assignedUserID string
+	hasMoreLevels  bool
+
+	// repeatCount is the pass of the policy the plan is in, from 0; restarted is set when the
+	// plan starts a new pass from the first level, and canRepeat while passes are left after this one
+	repeatCount int
+	restarted   bool
+	canRepeat   bool
+}
 
+// skippedEscalationLevel is a level passed over because its user is unavailable
+type skippedEscalationLevel struct {
+	levelNumber int
+	target      db.EscalationLevel
+	reason      string
+}
+
+// newStatus returns the escalation_status the incident has once the plan is applied. The
+// chain stays pending past its last level while the policy repeats.
+func (p *escalationPlan) newStatus() string {
+	if p.hasMoreLevels || p.canRepeat {
+		return "pending"
+	}
+	return "completed"
+}
+
+// planNextEscalation finds the next level of an incident's escalation policy and resolves its target.
+// automatic plans only apply while the incident is triggered; past the last level they restart
+// from level 1 until the policy's repeat_max_times passes are done.
+func (s *IncidentService) planNextEscalation(incidentID string, automatic bool) (*escalationPlan, error) {
 	// Get current incident state
 	var incident struct {
 		ID                     string
+		Title                  string
+		Severity               sql.NullString
 		Status                 string
 		EscalationPolicyID     sql.NullString
 		CurrentEscalationLevel int
 		EscalationStatus       string
 		GroupID                sql.NullString
+		RepeatCount            int
+		RepeatMaxTimes         int
 	}
 
 	query := `
-		SELECT id, status, escalation_policy_id, current_escalation_level, 
-		       escalation_status, group_id
+		SELECT id, title, severity, status, escalation_policy_id, current_escalation_level, 
+		       escalation_status, group_id, escalation_repeat_count,
+		       COALESCE((SELECT repeat_max_times FROM escalation_policies WHERE id = incidents.escalation_policy_id), 1)
 		FROM incidents
 		WHERE id = $1
 	`
 	err := s.PG.QueryRow(query, incidentID).Scan(
-		&incident.ID, &incident.Status, &incident.EscalationPolicyID,
+		&incident.ID, &incident.Title, &incident.Severity, &incident.Status, &incident.EscalationPolicyID,
 		&incident.CurrentEscalationLevel, &incident.EscalationStatus, &incident.GroupID,
+		&incident.RepeatCount, &incident.RepeatMaxTimes,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("incident not found")
+			return nil, ErrIncidentNotFound
 		}
 		return nil, fmt.Errorf("failed to get incident: %w", err)
 	}
 
 	// Validate incident can be escalated
 	if incident.Status == db.IncidentStatusResolved {
-		return nil, fmt.Errorf("cannot escalate resolved incident")
+		return nil, ErrCannotEscalateResolved
+	}
+	if automatic && incident.Status != db.IncidentStatusTriggered {
+		return nil, fmt.Errorf("incident is no longer triggered")
 	}
 
 	if !incident.EscalationPolicyID.Valid || incident.EscalationPolicyID.String == "" {
-		return nil, fmt.Errorf("incident has no escalation policy")
+		return nil, ErrNoEscalationPolicy
 	}
 
 	// Get escalation levels
@@ -1597,87 +3316,168 @@ func (s *IncidentService) ManualEscalateIncident(incidentID, userID string) (*db
 	}
 
 	if len(escalationLevels) == 0 {
-		return nil, fmt.Errorf("escalation policy has no levels defined")
+		return nil, ErrNoEscalationLevels
 	}
 
 	// Determine next level
-	nextLevel := incident.CurrentEscalationLevel + 1
+	plan := &escalationPlan{
+		currentLevel: incident.CurrentEscalationLevel,
+		nextLevel:    incident.CurrentEscalationLevel + 1,
+		title:        incident.Title,
+		severity:     incident.Severity.String,
+		repeatCount:  incident.RepeatCount,
+	}
 	log.Printf("DEBUG: Current level %d, next level %d, total levels %d",
-		incident.CurrentEscalationLevel, nextLevel, len(escalationLevels))
+		plan.currentLevel, plan.nextLevel, len(escalationLevels))
+
+	// The policy runs repeat_max_times passes in total; 0 means a single one
+	passes := incident.RepeatMaxTimes
+	if passes < 1 {
+		passes = 1
+	}
+
+	for {
+		// Check if there's a next level available, skipping users in DND or on vacation
+		plan.target = findEscalationLevel(escalationLevels, plan.nextLevel)
+		for plan.target != nil && plan.target.TargetType == "user" {
+			unavailableReason, err := UserUnavailableReason(s.PG, plan.target.TargetID)
+			if err != nil {
+				log.Printf("WARNING: Failed to check availability of user %s, escalating anyway: %v", plan.target.TargetID, err)
+				break
+			}
+			if unavailableReason == "" {
+				break
+			}
+
+			log.Printf("DEBUG: Skipping escalation level %d for incident %s, user %s is unavailable (%s)",
+				plan.nextLevel, incidentID, plan.target.TargetID, unavailableReason)
+			plan.skipped = append(plan.skipped, skippedEscalationLevel{
+				levelNumber: plan.nextLevel,
+				target:      *plan.target,
+				reason:      unavailableReason,
+			})
+
+			plan.nextLevel++
+			plan.target = findEscalationLevel(escalationLevels, plan.nextLevel)
+		}
 
-	// Check if there's a next level available
-	var targetLevel *db.EscalationLevel
-	for _, level := range escalationLevels {
-		if level.LevelNumber == nextLevel {
-			targetLevel = &level
+		// Past the last level nobody acknowledged: start the next pass from level 1
+		if plan.target != nil || !automatic || plan.restarted || plan.repeatCount+1 >= passes {
 			break
 		}
+		plan.restarted = true
+		plan.repeatCount++
+		plan.nextLevel = 1
+		log.Printf("DEBUG: Restarting escalation of incident %s, pass %d of %d", incidentID, plan.repeatCount+1, passes)
 	}
+	plan.canRepeat = plan.repeatCount+1 < passes
 
-	if targetLevel == nil {
-		return nil, fmt.Errorf("already at maximum escalation level (%d)", incident.CurrentEscalationLevel)
+	if plan.target == nil {
+		return plan, nil
 	}
 
-	// Process escalation based on target type
-	var assignedUserID string
+	// Resolve the target to a user
 	groupID := ""
 	if incident.GroupID.Valid {
 		groupID = incident.GroupID.String
 	}
+	plan.groupID = groupID
 
-	switch targetLevel.TargetType {
+	switch plan.target.TargetType {
 	case "user":
-		assignedUserID = targetLevel.TargetID
+		plan.assignedUserID = plan.target.TargetID
 	case "scheduler":
-		assignedUserID, err = s.getCurrentOnCallUserFromScheduler(targetLevel.TargetID, groupID)
+		plan.assignedUserID, err = s.getCurrentOnCallUserFromScheduler(plan.target.TargetID, groupID)
 		if err != nil {
 			log.Printf("WARNING: Failed to get on-call user from scheduler: %v", err)
 		}
 	case "current_schedule", "group":
 		targetGroupID := groupID
-		if targetLevel.TargetType == "group" && targetLevel.TargetID != "" {
-			targetGroupID = targetLevel.TargetID
+		if plan.target.TargetType == "group" && plan.target.TargetID != "" {
+			targetGroupID = plan.target.TargetID
+			plan.groupID = targetGroupID
 		}
-		assignedUserID, err = s.getCurrentOnCallUserFromGroup(targetGroupID)
+		plan.assignedUserID, err = s.getCurrentOnCallUserFromGroup(targetGroupID)
 		if err != nil {
 			log.Printf("WARNING: Failed to get on-call user from group: %v", err)
 		}
 	case "external":
 		// External escalation doesn't assign to a user
-		log.Printf("DEBUG: External escalation to target %s", targetLevel.TargetID)
+		log.Printf("DEBUG: External escalation to target %s", plan.target.TargetID)
 	default:
-		log.Printf("WARNING: Unknown target type: %s", targetLevel.TargetType)
+		log.Printf("WARNING: Unknown target type: %s", plan.target.TargetType)
 	}
 
 	// Check if there are more levels after this one
-	hasMoreLevels := false
-	for _, level := range escalationLevels {
-		if level.LevelNumber == nextLevel+1 {
-			hasMoreLevels = true
-			break
-		}
+	plan.hasMoreLevels = findEscalationLevel(escalationLevels, plan.nextLevel+1) != nil
+
+	return plan, nil
+}
+
+// escalateToNextLevel moves an incident to the next level of its escalation policy.
+// userID is empty for automatic (timeout) escalation, which only applies while the incident is triggered.
+func (s *IncidentService) escalateToNextLevel(incidentID, userID, reason string) (*db.EscalationResult, error) {
+	automatic := userID == ""
+
+	plan, err := s.planNextEscalation(incidentID, automatic)
+	if err != nil {
+		return nil, err
 	}
 
-	// Determine new escalation status
-	newStatus := "pending"
-	if !hasMoreLevels {
-		newStatus = "completed"
+	for _, skipped := range plan.skipped {
+		_ = s.createIncidentEvent(incidentID, "escalation_skipped", map[string]interface{}{
+			"escalation_level": skipped.levelNumber,
+			"target_type":      skipped.target.TargetType,
+			"target_id":        skipped.target.TargetID,
+			"reason":           skipped.reason,
+		}, userID)
 	}
 
+	if plan.target == nil {
+		if !automatic {
+			return nil, fmt.Errorf("%w (%d)", ErrMaxEscalationLevel, plan.currentLevel)
+		}
+
+		// Nothing left to escalate to - stop the worker from picking the incident up again
+		if _, err := s.PG.Exec(`
+			UPDATE incidents SET escalation_status = 'completed', updated_at = `+SQLNowUTC+`
+			WHERE id = $1
+		`, incidentID); err != nil {
+			return nil, fmt.Errorf("failed to update incident: %w", err)
+		}
+		return &db.EscalationResult{
+			NewLevel:         plan.currentLevel,
+			EscalationStatus: "completed",
+		}, nil
+	}
+
+	nextLevel := plan.nextLevel
+	targetLevel := plan.target
+	assignedUserID := plan.assignedUserID
+	hasMoreLevels := plan.hasMoreLevels
+	newStatus := plan.newStatus()
+
 	// Update incident in database - use UTC time consistent with worker
 	updateQuery := `
 		UPDATE incidents
 		SET current_escalation_level = $1,
 		    escalation_status = $2,
-		    last_escalated_at = NOW() AT TIME ZONE 'UTC',
-		    updated_at = NOW() AT TIME ZONE 'UTC'
+		    last_escalated_at = ` + SQLNowUTC + `,
+		    escalation_policy_version = (SELECT version FROM escalation_policies WHERE id = incidents.escalation_policy_id),
+		    updated_at = ` + SQLNowUTC + `
 	`
 	args := []interface{}{nextLevel, newStatus}
 	argIndex := 3
 
+	if plan.restarted {
+		updateQuery += fmt.Sprintf(", escalation_repeat_count = $%d", argIndex)
+		args = append(args, plan.repeatCount)
+		argIndex++
+	}
+
 	// Also update assigned_to if we have a user
 	if assignedUserID != "" {
-		updateQuery += fmt.Sprintf(", assigned_to = $%d::uuid, assigned_at = NOW() AT TIME ZONE 'UTC'", argIndex)
+		updateQuery += fmt.Sprintf(", assigned_to = $%d::uuid, assigned_at = %s", argIndex, SQLNowUTC)
 		args = append(args, assignedUserID)
 		argIndex++
 	}
@@ -1685,10 +3485,19 @@ func (s *IncidentService) ManualEscalateIncident(incidentID, userID string) (*db
 	updateQuery += fmt.Sprintf(" WHERE id = $%d", argIndex)
 	args = append(args, incidentID)
 
-	_, err = s.PG.Exec(updateQuery, args...)
+	// An acknowledgement that lands before this update cancels the timeout escalation
+	if automatic {
+		updateQuery += fmt.Sprintf(" AND status = $%d", argIndex+1)
+		args = append(args, db.IncidentStatusTriggered)
+	}
+
+	result, err := s.PG.Exec(updateQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update incident: %w", err)
 	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil, fmt.Errorf("incident is no longer triggered")
+	}
 
 	// Get assignee name for event
 	var assignedToName string
@@ -1701,22 +3510,27 @@ func (s *IncidentService) ManualEscalateIncident(incidentID, userID string) (*db
 		"escalation_level": nextLevel,
 		"target_type":      targetLevel.TargetType,
 		"target_id":        targetLevel.TargetID,
-		"reason":           "manual_escalation",
-		"escalated_by":     userID,
+		"reason":           reason,
+	}
+	if userID != "" {
+		eventData["escalated_by"] = userID
 	}
 	if assignedUserID != "" {
 		eventData["assigned_to_id"] = assignedUserID
 		eventData["assigned_to"] = assignedToName
 	}
+	if plan.restarted {
+		eventData["repeat"] = plan.repeatCount
+	}
 
 	_ = s.createIncidentEvent(incidentID, db.IncidentEventEscalated, eventData, userID)
 
-	// Create escalation completion event if this was the last level
-	if !hasMoreLevels {
+	// Create escalation completion event if this was the last level of the last pass
+	if !hasMoreLevels && !plan.canRepeat {
 		completionEventData := map[string]interface{}{
 			"escalation_status": "completed",
 			"final_level":       nextLevel,
-			"reason":            "manual_escalation_completed",
+			"reason":            reason + "_completed",
 		}
 		if assignedUserID != "" {
 			completionEventData["final_assignee"] = assignedToName
@@ -1727,18 +3541,30 @@ func (s *IncidentService) ManualEscalateIncident(incidentID, userID string) (*db
 
 	// Send notification to assigned user
 	if s.NotificationWorker != nil && assignedUserID != "" {
+		s.recordPage(plan.groupID, assignedUserID)
+		message := s.renderEscalationMessage(plan, incidentID, assignedToName)
 		go func() {
-			err := s.NotificationWorker.SendIncidentEscalatedNotification(assignedUserID, incidentID)
+			err := s.NotificationWorker.SendIncidentEscalatedNotification(assignedUserID, incidentID, message)
 			if err != nil {
 				log.Printf("Failed to send escalation notification: %v", err)
 			} else {
 				log.Printf("  Sent escalation notification to user %s", assignedUserID)
 			}
 		}()
+
+		// The queue's consumers only post to Slack, so push is sent from here
+		if s.FCMService != nil {
+			go func() {
+				if err := s.FCMService.SendIncidentEscalatedNotification(assignedUserID, incidentID, plan.severity, message); err != nil {
+					log.Printf("Failed to send escalation push notification: %v", err)
+				}
+			}()
+		}
 	}
+	s.notifyWatchers(incidentID, db.IncidentEventEscalated, userID, false)
 
-	log.Printf("SUCCESS: Manually escalated incident %s to level %d (assigned to: %s, status: %s)",
-		incidentID, nextLevel, assignedUserID, newStatus)
+	log.Printf("SUCCESS: Escalated incident %s to level %d (reason: %s, assigned to: %s, status: %s)",
+		incidentID, nextLevel, reason, assignedUserID, newStatus)
 
 	return &db.EscalationResult{
 		NewLevel:         nextLevel,
@@ -1750,10 +3576,21 @@ func (s *IncidentService) ManualEscalateIncident(incidentID, userID string) (*db
 	}, nil
 }
 
+// findEscalationLevel returns the level with the given number, or nil if the policy doesn't have one
+func findEscalationLevel(levels []db.EscalationLevel, levelNumber int) *db.EscalationLevel {
+	for i := range levels {
+		if levels[i].LevelNumber == levelNumber {
+			return &levels[i]
+		}
+	}
+	return nil
+}
+
 // getEscalationLevels retrieves escalation levels for a policy
 func (s *IncidentService) getEscalationLevels(policyID string) ([]db.EscalationLevel, error) {
 	query := `
-		SELECT id, policy_id, level_number, target_type, target_id, timeout_minutes
+		SELECT id, policy_id, level_number, target_type, target_id, timeout_minutes,
+		       COALESCE(message_template, '')
 		FROM escalation_levels
 		WHERE policy_id = $1
 		ORDER BY level_number ASC
@@ -1771,6 +3608,7 @@ func (s *IncidentService) getEscalationLevels(policyID string) ([]db.EscalationL
 		err := rows.Scan(
 			&level.ID, &level.PolicyID, &level.LevelNumber,
 			&level.TargetType, &level.TargetID, &level.TimeoutMinutes,
+			&level.MessageTemplate,
 		)
 		if err != nil {
 			log.Printf("Error scanning escalation level: %v", err)
@@ -1782,9 +3620,18 @@ func (s *IncidentService) getEscalationLevels(policyID string) ([]db.EscalationL
 	return levels, nil
 }
 
-// FindIncidentByFingerprint finds an incident by fingerprint in labels
-func (s *IncidentService) FindIncidentByFingerprint(fingerprint string) (*db.Incident, error) {
-	log.Printf("DEBUG: Searching for incident with fingerprint: %s", fingerprint)
+// DedupScope is the incidents an alert fingerprint is matched against: always those of one
+// organization (incidents without one when empty), and only one integration's unless
+// IntegrationID is empty
+type DedupScope struct {
+	OrganizationID string
+	IntegrationID  string
+}
+
+// FindIncidentByFingerprintForIntegration finds an open incident by fingerprint within scope
+func (s *IncidentService) FindIncidentByFingerprintForIntegration(fingerprint string, scope DedupScope) (*db.Incident, error) {
+	log.Printf("DEBUG: Searching for incident with fingerprint: %s (organization: %s, integration: %s)",
+		fingerprint, scope.OrganizationID, scope.IntegrationID)
 
 	query := `
 		SELECT id, title, description, status, urgency, priority,
@@ -1796,7 +3643,9 @@ func (s *IncidentService) FindIncidentByFingerprint(fingerprint string) (*db.Inc
 			   alert_count, labels, custom_fields
 		FROM incidents
 		WHERE labels->>'fingerprint' = $1
-		AND status IN ('triggered', 'acknowledged')
+		AND status IN ('triggered', 'acknowledged', 'external_pending')
+		AND COALESCE(organization_id::text, '') = $2
+		AND ($3 = '' OR integration_id::text = $3)
 		ORDER BY created_at DESC
 		LIMIT 1
 	`
@@ -1810,7 +3659,7 @@ func (s *IncidentService) FindIncidentByFingerprint(fingerprint string) (*db.Inc
 	var groupID, apiKeyID, incidentKey sql.NullString
 	var labels, customFields sql.NullString
 
-	err := s.PG.QueryRow(query, fingerprint).Scan(
+	err := s.PG.QueryRow(query, fingerprint, scope.OrganizationID, scope.IntegrationID).Scan(
 		&incident.ID, &incident.Title, &incident.Description, &incident.Status,
 		&incident.Urgency, &incident.Priority, &incident.CreatedAt, &incident.UpdatedAt,
 		&assignedTo, &assignedAt, &acknowledgedBy, &acknowledgedAt,
@@ -1892,6 +3741,135 @@ func (s *IncidentService) FindIncidentByFingerprint(fingerprint string) (*db.Inc
 	return &incident, nil
 }
 
+// ReopenRecentlyResolvedIncident re-opens the newest incident with this fingerprint
+// that was resolved within window, so a flapping alert doesn't open a fresh incident
+// on every re-fire. Only incidents within scope are considered. Returns "" when there is
+// nothing to re-open.
+func (s *IncidentService) ReopenRecentlyResolvedIncident(fingerprint string, scope DedupScope, window time.Duration) (string, error) {
+	if fingerprint == "" || window <= 0 {
+		return "", nil
+	}
+
+	var incidentID string
+	var assignedTo sql.NullString
+	var alertCount int
+	err := s.PG.QueryRow(`
+		UPDATE incidents
+		SET status = $1,
+		    alert_count = alert_count + 1,
+		    acknowledged_by = NULL,
+		    acknowledged_at = NULL,
+		    resolved_by = NULL,
+		    resolved_at = NULL,
+		    escalation_status = 'none',
+		    current_escalation_level = 1,
+		    escalation_repeat_count = 0,
+		    last_escalated_at = NULL,
+		    updated_at = `+SQLNowUTC+`
+		WHERE id = (
+			SELECT id FROM incidents
+			WHERE labels->>'fingerprint' = $2
+			  AND status = $3
+			  AND resolved_at >= (`+SQLNowUTC+`) - make_interval(secs => $4)
+			  AND COALESCE(organization_id::text, '') = $5
+			  AND ($6 = '' OR integration_id::text = $6)
+			ORDER BY resolved_at DESC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, assigned_to, alert_count
+	`, db.IncidentStatusTriggered, fingerprint, db.IncidentStatusResolved, window.Seconds(),
+		scope.OrganizationID, scope.IntegrationID).Scan(&incidentID, &assignedTo, &alertCount)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to reopen incident: %w", err)
+	}
+
+	log.Printf("DEBUG: Re-opened incident %s for fingerprint %s (alert_count=%d)", incidentID, fingerprint, alertCount)
+
+	eventData := map[string]interface{}{
+		"fingerprint": fingerprint,
+		"alert_count": alertCount,
+		"note":        "alert fired again within dedup window",
+	}
+	if err := s.createIncidentEvent(incidentID, db.IncidentEventReopened, eventData, ""); err != nil {
+		log.Printf("WARNING: Failed to create reopened event for incident %s: %v", incidentID, err)
+	}
+
+	// Page the assignee again, the same way a new incident would
+	if assignedTo.Valid && assignedTo.String != "" && s.NotificationWorker != nil {
+		if err := s.NotificationWorker.SendIncidentAssignedNotification(assignedTo.String, incidentID); err != nil {
+			log.Printf("WARNING: Failed to send notification for reopened incident %s: %v", incidentID, err)
+		}
+	}
+
+	return incidentID, nil
+}
+
+// ResolveIncidentsByFingerprints resolves, in one transaction, every open incident within
+// scope whose fingerprint is listed - e.g. a single resolve sent once a shared root cause is
+// fixed. Each is resolved like ResolveIncident, escalations included. Returns the IDs of the
+// incidents it resolved.
+func (s *IncidentService) ResolveIncidentsByFingerprints(fingerprints []string, scope DedupScope, userID, note, resolution string) ([]string, error) {
+	if len(fingerprints) == 0 {
+		return nil, nil
+	}
+
+	tx, err := s.PG.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.Query(`
+		SELECT id FROM incidents
+		WHERE labels->>'fingerprint' = ANY($1)
+		AND status IN ('triggered', 'acknowledged', 'external_pending')
+		AND COALESCE(organization_id::text, '') = $2
+		AND ($3 = '' OR integration_id::text = $3)
+		ORDER BY created_at ASC
+		FOR UPDATE
+	`, pq.Array(fingerprints), scope.OrganizationID, scope.IntegrationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find incidents to resolve: %w", err)
+	}
+	var matched []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan incident to resolve: %w", err)
+		}
+		matched = append(matched, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find incidents to resolve: %w", err)
+	}
+
+	var ids []string
+	for _, id := range matched {
+		resolved, err := resolveIncidentWith(tx, id, userID, note, resolution, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resolved {
+			ids = append(ids, id)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, id := range ids {
+		s.notifyIncidentResolved(id, userID)
+	}
+	return ids, nil
+}
+
 // IncrementAlertCount increments the alert count for an existing incident (for deduplication)
 func (s *IncidentService) IncrementAlertCount(incidentID string) error {
 	log.Printf("DEBUG: Incrementing alert count for incident %s", incidentID)
@@ -1899,7 +3877,7 @@ func (s *IncidentService) IncrementAlertCount(incidentID string) error {
 	_, err := s.PG.Exec(`
 		UPDATE incidents 
 		SET alert_count = alert_count + 1,
-		    updated_at = NOW()
+		    updated_at = `+SQLNowUTC+`
 		WHERE id = $1
 	`, incidentID)
 
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"github.com/lib/pq"
+	"github.com/phonginreallife/inres/db"
+)
+
+// GetAssignmentHistory returns who held an incident when, oldest first.
+// It walks the assigned and escalated events; escalations that didn't reassign
+// (e.g. to an external target) and reassignments to the current assignee are skipped.
+func (s *IncidentService) GetAssignmentHistory(incidentID string) ([]db.IncidentAssignment, error) {
+	query := incidentEventSelect + `
+		AND ie.event_type = ANY($2)
+		ORDER BY ie.created_at ASC, ie.id ASC
+	`
+	events, err := s.queryIncidentEvents(query, incidentID, pq.Array([]string{db.IncidentEventAssigned, db.IncidentEventEscalated}))
+	if err != nil {
+		return nil, err
+	}
+
+	return assignmentHistory(events), nil
+}
+
+// assignmentHistory derives the assignment chain from assigned/escalated events in chronological order
+func assignmentHistory(events []db.IncidentEvent) []db.IncidentAssignment {
+	history := []db.IncidentAssignment{}
+	var current, currentName string
+
+	for _, event := range events {
+		to, _ := event.EventData["assigned_to_id"].(string)
+		if to == "" || to == current {
+			continue
+		}
+		toName, _ := event.EventData["assigned_to"].(string)
+
+		method, _ := event.EventData["method"].(string)
+		if event.EventType == db.IncidentEventEscalated {
+			method = "escalation"
+		} else if method == "" {
+			method = "manual"
+		}
+
+		history = append(history, db.IncidentAssignment{
+			From:     current,
+			FromName: currentName,
+			To:       to,
+			ToName:   toName,
+			At:       event.CreatedAt,
+			Method:   method,
+			By:       event.CreatedBy,
+		})
+		current, currentName = to, toName
+	}
+
+	return history
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAssignmentHistory(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	start := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	columns := []string{
+		"id", "incident_id", "event_type", "event_data", "created_at", "created_by",
+		"created_by_name", "created_by_email", "created_by_role", "incident_source",
+	}
+
+	mockDB.ExpectQuery(`WHERE ie.incident_id = \$1\s+AND ie.event_type = ANY\(\$2\)\s+ORDER BY ie.created_at ASC, ie.id ASC`).
+		WithArgs("inc-1", stringArrayArg{"assigned", "escalated"}).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("ev-1", "inc-1", "assigned", `{"assigned_to_id":"user-1","assigned_to":"Alice","method":"auto_assignment"}`, start, nil, nil, nil, nil, "webhook").
+			AddRow("ev-2", "inc-1", "escalated", `{"escalation_level":2,"assigned_to_id":"user-2","assigned_to":"Bob"}`, start.Add(5*time.Minute), nil, nil, nil, nil, "webhook").
+			AddRow("ev-3", "inc-1", "escalated", `{"escalation_level":3,"target_type":"external"}`, start.Add(10*time.Minute), nil, nil, nil, nil, "webhook").
+			AddRow("ev-4", "inc-1", "assigned", `{"assigned_to_id":"user-2","assigned_to":"Bob","method":"manual"}`, start.Add(12*time.Minute), "user-2", "Bob", "bob@example.com", "engineer", "webhook").
+			AddRow("ev-5", "inc-1", "assigned", `{"assigned_to_id":"user-3","assigned_to":"Carol"}`, start.Add(20*time.Minute), "user-2", "Bob", "bob@example.com", "engineer", "webhook"))
+
+	service := NewIncidentService(pg, nil, nil)
+	history, err := service.GetAssignmentHistory("inc-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []db.IncidentAssignment{
+		{To: "user-1", ToName: "Alice", At: start, Method: "auto_assignment", By: db.SystemUserWebhook},
+		{From: "user-1", FromName: "Alice", To: "user-2", ToName: "Bob", At: start.Add(5 * time.Minute), Method: "escalation", By: db.SystemUserWebhook},
+		// The external escalation and Bob reassigning to himself don't change hands
+		{From: "user-2", FromName: "Bob", To: "user-3", ToName: "Carol", At: start.Add(20 * time.Minute), Method: "manual", By: "user-2"},
+	}, history)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetAssignmentHistory_NeverAssigned(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`AND ie.event_type = ANY\(\$2\)`).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "incident_id", "event_type", "event_data", "created_at", "created_by",
+			"created_by_name", "created_by_email", "created_by_role", "incident_source",
+		}))
+
+	service := NewIncidentService(pg, nil, nil)
+	history, err := service.GetAssignmentHistory("inc-1")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, history)
+	assert.Empty(t, history)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// MaxAttachmentsPerIncident caps how many links a single incident can collect
+const MaxAttachmentsPerIncident = 50
+
+// maxAttachmentURLLength keeps attachment URLs within what browsers and chat tools handle
+const maxAttachmentURLLength = 2048
+
+var validAttachmentKinds = map[string]bool{
+	db.AttachmentKindPostmortem: true,
+	db.AttachmentKindRunbook:    true,
+	db.AttachmentKindScreenshot: true,
+	db.AttachmentKindLog:        true,
+}
+
+// ErrInvalidAttachment is returned (wrapped) when an attachment's URL or kind is rejected
+var ErrInvalidAttachment = errors.New("invalid attachment")
+
+// ErrAttachmentLimitReached is returned when an incident already has MaxAttachmentsPerIncident attachments
+var ErrAttachmentLimitReached = fmt.Errorf("incident already has the maximum of %d attachments", MaxAttachmentsPerIncident)
+
+// AddAttachment links a postmortem, runbook, screenshot or log URL to an incident and
+// records an attachment_added event. The title defaults to the URL.
+func (s *IncidentService) AddAttachment(incidentID, userID, rawURL, title, kind string) (*db.IncidentAttachment, error) {
+	attachmentURL, err := validateAttachment(rawURL, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &db.IncidentAttachment{
+		IncidentID: incidentID,
+		URL:        attachmentURL,
+		Title:      strings.TrimSpace(title),
+		Kind:       kind,
+		CreatedBy:  userID,
+	}
+	if attachment.Title == "" {
+		attachment.Title = attachment.URL
+	}
+
+	tx, err := s.PG.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Locking the incident serializes concurrent uploads, so the count below can't go stale
+	var lockedID string
+	err = tx.QueryRow(`SELECT id FROM incidents WHERE id = $1 FOR UPDATE`, incidentID).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		return nil, ErrIncidentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock incident: %w", err)
+	}
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM incident_attachments WHERE incident_id = $1`, incidentID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to count attachments: %w", err)
+	}
+	if count >= MaxAttachmentsPerIncident {
+		return nil, ErrAttachmentLimitReached
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO incident_attachments (incident_id, url, title, kind, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING id, created_at
+	`, incidentID, attachment.URL, attachment.Title, attachment.Kind, nullIfEmpty(userID),
+	).Scan(&attachment.ID, &attachment.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add attachment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit attachment: %w", err)
+	}
+
+	_ = s.createIncidentEvent(incidentID, db.IncidentEventAttachmentAdded, map[string]interface{}{
+		"attachment_id": attachment.ID,
+		"url":           attachment.URL,
+		"title":         attachment.Title,
+		"kind":          attachment.Kind,
+	}, userID)
+
+	return attachment, nil
+}
+
+// GetAttachments returns the attachments of an incident, oldest first
+func (s *IncidentService) GetAttachments(incidentID string) ([]db.IncidentAttachment, error) {
+	rows, err := s.PG.Query(`
+		SELECT a.id, a.incident_id, a.url, COALESCE(a.title, ''), a.kind,
+		       COALESCE(a.created_by::text, ''), COALESCE(u.name, ''), a.created_at
+		FROM incident_attachments a
+		LEFT JOIN users u ON a.created_by = u.id
+		WHERE a.incident_id = $1
+		ORDER BY a.created_at
+	`, incidentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []db.IncidentAttachment{}
+	for rows.Next() {
+		var attachment db.IncidentAttachment
+		if err := rows.Scan(
+			&attachment.ID, &attachment.IncidentID, &attachment.URL, &attachment.Title, &attachment.Kind,
+			&attachment.CreatedBy, &attachment.CreatedByName, &attachment.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+// validateAttachment checks the kind and returns the trimmed URL, which must be an absolute http(s) URL
+func validateAttachment(rawURL, kind string) (string, error) {
+	if !validAttachmentKinds[kind] {
+		return "", fmt.Errorf("%w: kind must be one of postmortem, runbook, screenshot, log", ErrInvalidAttachment)
+	}
+
+	rawURL = strings.TrimSpace(rawURL)
+	if len(rawURL) > maxAttachmentURLLength {
+		return "", fmt.Errorf("%w: url must be at most %d characters", ErrInvalidAttachment, maxAttachmentURLLength)
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidAttachment)
+	}
+
+	return rawURL, nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddAttachment(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery("SELECT id FROM incidents WHERE id = \\$1 FOR UPDATE").
+		WithArgs("inc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("inc-1"))
+	mockDB.ExpectQuery("SELECT COUNT\\(\\*\\) FROM incident_attachments").
+		WithArgs("inc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(MaxAttachmentsPerIncident - 1))
+	mockDB.ExpectQuery("INSERT INTO incident_attachments").
+		WithArgs("inc-1", "https://wiki.example.com/pm/42", "https://wiki.example.com/pm/42", "postmortem", "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("att-1", time.Now()))
+	mockDB.ExpectCommit()
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "attachment_added",
+			`{"attachment_id":"att-1","kind":"postmortem","title":"https://wiki.example.com/pm/42","url":"https://wiki.example.com/pm/42"}`,
+			"user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	attachment, err := service.AddAttachment("inc-1", "user-1", " https://wiki.example.com/pm/42 ", "", "postmortem")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "att-1", attachment.ID)
+	assert.Equal(t, "https://wiki.example.com/pm/42", attachment.Title)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestAddAttachment_Invalid(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewIncidentService(pg, nil, nil)
+
+	tests := []struct {
+		name string
+		url  string
+		kind string
+	}{
+		{"unknown kind", "https://example.com/runbook", "video"},
+		{"relative url", "/runbooks/db", "runbook"},
+		{"javascript url", "javascript:alert(1)", "runbook"},
+		{"missing host", "https://", "log"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.AddAttachment("inc-1", "user-1", tt.url, "", tt.kind)
+			assert.True(t, errors.Is(err, ErrInvalidAttachment), "got %v", err)
+		})
+	}
+
+	// Nothing is written for rejected attachments
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestAddAttachment_LimitReached(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery("FOR UPDATE").
+		WithArgs("inc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("inc-1"))
+	mockDB.ExpectQuery("SELECT COUNT").
+		WithArgs("inc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(MaxAttachmentsPerIncident))
+	mockDB.ExpectRollback()
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.AddAttachment("inc-1", "user-1", "https://logs.example.com/q?id=1", "Logs", "log")
+
+	assert.ErrorIs(t, err, ErrAttachmentLimitReached)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/phonginreallife/inres/db"
+)
+
+// IncidentSourceCluster is the source of the parent incident similar incidents are grouped under
+const IncidentSourceCluster = "cluster"
+
+// incidentClusterSimilarity is the share of label pairs two incidents must have in common
+// (of all the pairs either has) to be clustered: with 4 or more labels, all but one must match
+const incidentClusterSimilarity = 0.6
+
+// incidentClusterCandidates caps how many recent incidents a new incident is compared with
+const incidentClusterCandidates = 200
+
+// unclusteredLabels differ between alerts of the same outage by design, so they are left out
+// of the comparison
+var unclusteredLabels = map[string]bool{
+	"fingerprint": true,
+	"merged_into": true,
+}
+
+// labelPairs returns an incident's labels as "key=value" pairs for comparison
+func labelPairs(labels map[string]interface{}) map[string]bool {
+	pairs := make(map[string]bool, len(labels))
+	for key, value := range labels {
+		if !unclusteredLabels[key] {
+			pairs[fmt.Sprintf("%s=%v", key, value)] = true
+		}
+	}
+	return pairs
+}
+
+// labelSimilarity is the Jaccard index of two label sets: shared pairs over all pairs
+func labelSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for pair := range a {
+		if b[pair] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// clusterCandidate is a recent open incident a new incident may be clustered with
+type clusterCandidate struct {
+	id        string
+	title     string
+	labels    map[string]interface{}
+	clusterID string // Open cluster the candidate belongs to, if any
+}
+
+// clusterIncident groups a new alert incident with the most similar open incident created
+// within ClusterWindow, if their labels are at least incidentClusterSimilarity alike. It joins
+// that incident's cluster, or opens a cluster incident for the two. Failures only log: an
+// unclustered incident is still paged normally.
+func (s *IncidentService) clusterIncident(incident *db.Incident) {
+	if s.ClusterWindow <= 0 || incident.OrganizationID == "" || incident.Source == "manual" ||
+		incident.Source == IncidentSourceQuota || incident.Source == IncidentSourceCluster {
+		return
+	}
+	pairs := labelPairs(incident.Labels)
+	if len(pairs) == 0 {
+		return
+	}
+
+	candidates, err := s.clusterCandidates(incident)
+	if err != nil {
+		log.Printf("WARNING: Skipping clustering of incident %s: %v", incident.ID, err)
+		return
+	}
+
+	var match *clusterCandidate
+	best := 0.0
+	for i := range candidates {
+		if similarity := labelSimilarity(pairs, labelPairs(candidates[i].labels)); similarity >= incidentClusterSimilarity && similarity > best {
+			match, best = &candidates[i], similarity
+		}
+	}
+	if match == nil {
+		return
+	}
+
+	clusterID := match.clusterID
+	members := []string{incident.ID}
+	if clusterID == "" {
+		cluster, err := s.openIncidentCluster(incident, match)
+		if err != nil {
+			log.Printf("WARNING: Failed to open cluster for incident %s: %v", incident.ID, err)
+			return
+		}
+		clusterID = cluster.ID
+		members = append(members, match.id)
+	}
+
+	if err := s.addToIncidentCluster(clusterID, members); err != nil {
+		log.Printf("WARNING: Failed to cluster incident %s: %v", incident.ID, err)
+		return
+	}
+	incident.ClusterID = clusterID
+	log.Printf("INFO: Clustered incident %s with %s under %s (label similarity %.2f)", incident.ID, match.id, clusterID, best)
+}
+
+// clusterCandidates returns the open incidents of the organization created within
+// ClusterWindow before now, with the open cluster each belongs to
+func (s *IncidentService) clusterCandidates(incident *db.Incident) ([]clusterCandidate, error) {
+	rows, err := s.PG.Query(`
+		SELECT i.id, i.title, i.labels, CASE WHEN c.status = ANY($3) THEN c.id::text ELSE '' END
+		FROM incidents i
+		LEFT JOIN incidents c ON c.id = i.cluster_id
+		WHERE i.organization_id = $1
+		  AND i.id <> $2
+		  AND i.status = ANY($3)
+		  AND i.created_at >= $4
+		  AND i.source IS DISTINCT FROM $5
+		  AND i.labels IS NOT NULL
+		ORDER BY i.created_at DESC
+		LIMIT $6
+	`, incident.OrganizationID, incident.ID, pq.Array(db.OpenIncidentStatuses),
+		time.Now().Add(-s.ClusterWindow), IncidentSourceCluster, incidentClusterCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find incidents to cluster with: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []clusterCandidate
+	for rows.Next() {
+		var candidate clusterCandidate
+		var labels sql.NullString
+		if err := rows.Scan(&candidate.id, &candidate.title, &labels, &candidate.clusterID); err != nil {
+			return nil, fmt.Errorf("failed to scan incident: %w", err)
+		}
+		if labels.Valid && labels.String != "" {
+			_ = json.Unmarshal([]byte(labels.String), &candidate.labels)
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, rows.Err()
+}
+
+// openIncidentCluster creates the cluster incident of two similar incidents. It carries the
+// labels they share; its alert_count counts its member incidents.
+func (s *IncidentService) openIncidentCluster(incident *db.Incident, match *clusterCandidate) (*db.Incident, error) {
+	shared := make(map[string]interface{})
+	var summary []string
+	for key, value := range incident.Labels {
+		if other, ok := match.labels[key]; ok && !unclusteredLabels[key] && fmt.Sprint(other) == fmt.Sprint(value) {
+			shared[key] = value
+			summary = append(summary, fmt.Sprintf("%s=%v", key, value))
+		}
+	}
+	sort.Strings(summary)
+
+	cluster := &db.Incident{
+		Title: "Incident cluster: " + strings.Join(summary, ", "),
+		Description: fmt.Sprintf("Incidents with similar labels opened within %v of each other, starting with %q.",
+			s.ClusterWindow, match.title),
+		Source:         IncidentSourceCluster,
+		Severity:       incident.Severity,
+		Urgency:        incident.Urgency,
+		OrganizationID: incident.OrganizationID,
+		ProjectID:      incident.ProjectID,
+		Labels:         shared,
+	}
+	setIncidentDefaults(cluster)
+	cluster.AlertCount = 0 // Counted as members are added
+
+	if _, err := s.insertIncident(cluster); err != nil {
+		return nil, err
+	}
+	s.publishIncidentCreated(cluster)
+	return cluster, nil
+}
+
+// addToIncidentCluster points the member incidents at their cluster and counts them on it
+func (s *IncidentService) addToIncidentCluster(clusterID string, members []string) error {
+	result, err := s.PG.Exec(`UPDATE incidents SET cluster_id = $1 WHERE id = ANY($2)`, clusterID, pq.Array(members))
+	if err != nil {
+		return fmt.Errorf("failed to add incidents to cluster: %w", err)
+	}
+	added, _ := result.RowsAffected()
+
+	_, err = s.PG.Exec(`
+		UPDATE incidents SET alert_count = alert_count + $1, updated_at = `+SQLNowUTC+`
+		WHERE id = $2
+	`, added, clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to count incidents on cluster: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelSimilarity(t *testing.T) {
+	outage := labelPairs(map[string]interface{}{"env": "prod", "region": "eu-west-1", "service": "checkout", "cluster": "k8s-1", "fingerprint": "a1"})
+
+	// Only the pod differs: 4 shared pairs of 5, and fingerprints are ignored
+	similar := labelPairs(map[string]interface{}{"env": "prod", "region": "eu-west-1", "service": "checkout", "cluster": "k8s-1", "pod": "api-7", "fingerprint": "b2"})
+	assert.InDelta(t, 0.8, labelSimilarity(outage, similar), 0.001)
+
+	different := labelPairs(map[string]interface{}{"env": "prod", "service": "search"})
+	assert.InDelta(t, 0.2, labelSimilarity(outage, different), 0.001)
+
+	assert.Zero(t, labelSimilarity(outage, labelPairs(nil)))
+}
+
+func newClusteringService(t *testing.T) (*IncidentService, sqlmock.Sqlmock) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { pg.Close() })
+
+	service := NewIncidentService(pg, nil, nil)
+	service.ClusterWindow = 10 * time.Minute
+	return service, mockDB
+}
+
+func newClusterableIncident(labels map[string]interface{}) *db.Incident {
+	return &db.Incident{ID: "inc-new", Title: "checkout 5xx on api-7", Source: "webhook", Severity: "critical",
+		OrganizationID: "org-1", Labels: labels}
+}
+
+func expectClusterCandidates(mockDB sqlmock.Sqlmock, clusterID string, labels string) {
+	mockDB.ExpectQuery(`FROM incidents i\s+LEFT JOIN incidents c ON c.id = i.cluster_id\s+WHERE i.organization_id = \$1`).
+		WithArgs("org-1", "inc-new", stringArrayArg(db.OpenIncidentStatuses), sqlmock.AnyArg(), IncidentSourceCluster, incidentClusterCandidates).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "labels", "cluster_id"}).
+			AddRow("inc-old", "checkout 5xx on api-3", labels, clusterID))
+}
+
+var checkoutOutageLabels = map[string]interface{}{"env": "prod", "region": "eu-west-1", "service": "checkout", "cluster": "k8s-1", "pod": "api-7"}
+
+func TestClusterIncident_OpensCluster(t *testing.T) {
+	service, mockDB := newClusteringService(t)
+
+	// Same outage, different pod: the two incidents get a cluster incident with their shared labels
+	expectClusterCandidates(mockDB, "", `{"env": "prod", "region": "eu-west-1", "service": "checkout", "cluster": "k8s-1", "pod": "api-3"}`)
+	mockDB.ExpectExec("INSERT INTO incidents").
+		WithArgs(sqlmock.AnyArg(), "Incident cluster: cluster=k8s-1, env=prod, region=eu-west-1, service=checkout", sqlmock.AnyArg(),
+			db.IncidentStatusTriggered, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, IncidentSourceCluster,
+			nil, nil, "", "", nil, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil, "critical", "", 0,
+			`{"cluster":"k8s-1","env":"prod","region":"eu-west-1","service":"checkout"}`, nil, "org-1", nil, nil, nil, nil, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs(sqlmock.AnyArg(), db.IncidentEventTriggered, `{"severity":"critical","source":"cluster"}`, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`UPDATE incidents SET cluster_id = \$1 WHERE id = ANY\(\$2\)`).
+		WithArgs(sqlmock.AnyArg(), stringArrayArg([]string{"inc-new", "inc-old"})).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mockDB.ExpectExec(`UPDATE incidents SET alert_count = alert_count \+ \$1`).
+		WithArgs(int64(2), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	incident := newClusterableIncident(checkoutOutageLabels)
+	service.clusterIncident(incident)
+
+	assert.NotEmpty(t, incident.ClusterID)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestClusterIncident_JoinsCluster(t *testing.T) {
+	service, mockDB := newClusteringService(t)
+
+	expectClusterCandidates(mockDB, "cluster-1", `{"env": "prod", "region": "eu-west-1", "service": "checkout", "cluster": "k8s-1", "pod": "api-3"}`)
+	mockDB.ExpectExec(`UPDATE incidents SET cluster_id = \$1 WHERE id = ANY\(\$2\)`).
+		WithArgs("cluster-1", stringArrayArg([]string{"inc-new"})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`UPDATE incidents SET alert_count = alert_count \+ \$1`).
+		WithArgs(int64(1), "cluster-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	incident := newClusterableIncident(checkoutOutageLabels)
+	service.clusterIncident(incident)
+
+	assert.Equal(t, "cluster-1", incident.ClusterID)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestClusterIncident_DissimilarStaysAlone(t *testing.T) {
+	service, mockDB := newClusteringService(t)
+
+	// Only env matches: a different outage, so nothing is clustered
+	expectClusterCandidates(mockDB, "", `{"env": "prod", "service": "search", "region": "us-east-1"}`)
+
+	incident := newClusterableIncident(checkoutOutageLabels)
+	service.clusterIncident(incident)
+	assert.Empty(t, incident.ClusterID)
+
+	// Manual incidents and incidents without labels are never compared
+	manual := newClusterableIncident(checkoutOutageLabels)
+	manual.Source = "manual"
+	service.clusterIncident(manual)
+	service.clusterIncident(newClusterableIncident(nil))
+
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestListIncidents_ClusterFilters(t *testing.T) {
+	service, mockDB := newClusteringService(t)
+
+	// The rollup view hides clustered incidents behind their cluster...
+	mockDB.ExpectQuery(`AND i\.cluster_id IS NULL ORDER BY`).
+		WithArgs("user-1", "org-1", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	// ...and a cluster's members are listed by its id
+	mockDB.ExpectQuery(`AND i\.cluster_id = \$3 ORDER BY`).
+		WithArgs("user-1", "org-1", "cluster-1", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := service.ListIncidents(map[string]interface{}{
+		"current_user_id": "user-1", "current_org_id": "org-1", "include_resolved": true, "hide_clustered": true,
+	})
+	assert.NoError(t, err)
+	_, err = service.ListIncidents(map[string]interface{}{
+		"current_user_id": "user-1", "current_org_id": "org-1", "include_resolved": true, "hide_clustered": true,
+		"cluster_id": "cluster-1",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// IncidentEnrichmentQueueName is the PGMQ queue holding incidents inserted by
+// CreateIncidentAsync until the enrichment worker completes them
+const IncidentEnrichmentQueueName = "incident_enrichment"
+
+// ErrEnrichmentNotQueued is returned (wrapped) when an incident was inserted but its
+// enrichment could not be queued
+var ErrEnrichmentNotQueued = errors.New("incident enrichment not queued")
+
+// IncidentEnrichmentMessage is an incident waiting for the enrichment worker
+type IncidentEnrichmentMessage struct {
+	Incident      db.Incident     `json:"incident"`
+	IntegrationID string          `json:"integration_id,omitempty"`
+	Alert         json.RawMessage `json:"alert,omitempty"` // The alert the incident was created from
+}
+
+// EnqueueIncidentEnrichment persists an incident to the enrichment queue
+func (s *IncidentService) EnqueueIncidentEnrichment(msg IncidentEnrichmentMessage) error {
+	msgJSON, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("%w: failed to marshal enrichment message: %v", ErrEnrichmentNotQueued, err)
+	}
+
+	if _, err := s.PG.Exec(`SELECT pgmq.send($1, $2::jsonb)`, IncidentEnrichmentQueueName, string(msgJSON)); err != nil {
+		return fmt.Errorf("%w: %v", ErrEnrichmentNotQueued, err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func expectIncidentForPolicyOverride(mockDB sqlmock.Sqlmock, status string) {
+	mockDB.ExpectQuery("SELECT status, COALESCE\\(organization_id::text, ''\\), COALESCE\\(escalation_policy_id::text, ''\\)").
+		WithArgs("inc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "organization_id", "escalation_policy_id"}).
+			AddRow(status, "org-1", "policy-1"))
+}
+
+func TestSetIncidentEscalationPolicy_RestartsChainOnNewPolicy(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectIncidentForPolicyOverride(mockDB, db.IncidentStatusTriggered)
+	mockDB.ExpectQuery(`SELECT name, version FROM escalation_policies\s+WHERE id::text = \$1 AND organization_id::text = \$2 AND is_active = true`).
+		WithArgs("policy-exec", "org-1").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "version"}).AddRow("Executive", 3))
+	mockDB.ExpectExec(`UPDATE incidents\s+SET escalation_policy_id = \$1,\s+escalation_policy_version = \$2,\s+current_escalation_level = 0,\s+escalation_repeat_count = 0,\s+last_escalated_at = NULL,\s+escalation_status = CASE WHEN snoozed_until IS NULL THEN 'pending'.*WHERE id = \$3 AND status <> \$4`).
+		WithArgs("policy-exec", 3, "inc-1", db.IncidentStatusResolved).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`UPDATE scheduled_escalations SET status = 'cancelled'`).
+		WithArgs("inc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`UPDATE group_escalation_members SET status = 'cancelled'`).
+		WithArgs("inc-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", db.IncidentEventEscalationPolicyChanged,
+			`{"escalation_policy":"Executive","escalation_policy_id":"policy-exec","escalation_policy_version":3,"previous_escalation_policy_id":"policy-1"}`,
+			"user-9").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	assert.NoError(t, service.SetIncidentEscalationPolicy("inc-1", "policy-exec", "user-9"))
+
+	// The next timeout escalation pages level 1 of the executive policy
+	mockDB.ExpectQuery("SELECT id, title, severity, status, escalation_policy_id, current_escalation_level").
+		WithArgs("inc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "severity", "status", "escalation_policy_id", "current_escalation_level", "escalation_status", "group_id", "escalation_repeat_count", "repeat_max_times"}).
+			AddRow("inc-1", "Database down", "critical", db.IncidentStatusTriggered, "policy-exec", 0, "pending", "group-1", 0, 1))
+	mockDB.ExpectQuery("SELECT id, policy_id, level_number, target_type, target_id, timeout_minutes").
+		WithArgs("policy-exec").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "policy_id", "level_number", "target_type", "target_id", "timeout_minutes", "message_template"}).
+			AddRow("exec-level-1", "policy-exec", 1, "user", "user-cto", 5, "").
+			AddRow("exec-level-2", "policy-exec", 2, "user", "user-ceo", 10, ""))
+	mockDB.ExpectQuery("SELECT COALESCE\\(unavailable_reason, 'unavailable'\\)").
+		WithArgs("user-cto").
+		WillReturnError(sql.ErrNoRows)
+	mockDB.ExpectExec(`UPDATE incidents\s+SET current_escalation_level = \$1.*WHERE id = \$4 AND status = \$5`).
+		WithArgs(1, "pending", "user-cto", "inc-1", db.IncidentStatusTriggered).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery("SELECT COALESCE\\(name, email, 'Unknown'\\) FROM users").
+		WithArgs("user-cto").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Carol"))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "escalated", sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := service.EscalateIncidentOnTimeout("inc-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.NewLevel)
+	assert.Equal(t, "user-cto", result.AssignedUserID)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestSetIncidentEscalationPolicy_OtherOrganization(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectIncidentForPolicyOverride(mockDB, db.IncidentStatusAcknowledged)
+	mockDB.ExpectQuery("SELECT name, version FROM escalation_policies").
+		WithArgs("policy-other-org", "org-1").
+		WillReturnError(sql.ErrNoRows)
+
+	err = NewIncidentService(pg, nil, nil).SetIncidentEscalationPolicy("inc-1", "policy-other-org", "user-9")
+
+	assert.ErrorIs(t, err, ErrEscalationPolicyNotInOrg)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestSetIncidentEscalationPolicy_ResolvedIncident(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectIncidentForPolicyOverride(mockDB, db.IncidentStatusResolved)
+
+	err = NewIncidentService(pg, nil, nil).SetIncidentEscalationPolicy("inc-1", "policy-exec", "user-9")
+
+	assert.ErrorIs(t, err, ErrAlreadyResolved)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// etaNudgeBatch caps how many overdue ETAs one worker poll nudges
+const etaNudgeBatch = 50
+
+// OverdueETA is an acknowledged incident that wasn't resolved by the ETA given when acknowledging it
+type OverdueETA struct {
+	IncidentID     string
+	Title          string
+	AcknowledgedBy string
+	ETA            time.Time
+}
+
+// NudgeOverdueETAs reminds the acknowledger of each incident still acknowledged past its ETA and
+// records an eta_passed event. Each ETA is nudged once, even with several workers polling.
+// Returns the incidents nudged.
+func (s *IncidentService) NudgeOverdueETAs() ([]OverdueETA, error) {
+	rows, err := s.PG.Query(`
+		UPDATE incidents
+		SET eta_nudged_at = NOW()
+		WHERE id IN (
+			SELECT id FROM incidents
+			WHERE status = $1 AND ack_eta <= NOW() AND eta_nudged_at IS NULL
+			ORDER BY ack_eta ASC
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, title, COALESCE(acknowledged_by::text, ''), ack_eta
+	`, db.IncidentStatusAcknowledged, etaNudgeBatch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim overdue ETAs: %w", err)
+	}
+
+	var overdue []OverdueETA
+	for rows.Next() {
+		var incident OverdueETA
+		if err := rows.Scan(&incident.IncidentID, &incident.Title, &incident.AcknowledgedBy, &incident.ETA); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan overdue ETA: %w", err)
+		}
+		overdue = append(overdue, incident)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read overdue ETAs: %w", err)
+	}
+
+	for _, incident := range overdue {
+		eta := incident.ETA.UTC().Format(time.RFC3339)
+		if err := s.createIncidentEvent(incident.IncidentID, db.IncidentEventETAPassed, map[string]interface{}{
+			"eta":             eta,
+			"acknowledged_by": incident.AcknowledgedBy,
+		}, ""); err != nil {
+			log.Printf("WARNING: Failed to create eta_passed event for incident %s: %v", incident.IncidentID, err)
+		}
+
+		if s.NotificationWorker == nil || incident.AcknowledgedBy == "" {
+			continue
+		}
+		message := fmt.Sprintf("The ETA of %s you gave for incident %q has passed and it is still unresolved", eta, incident.Title)
+		go func(incident OverdueETA) {
+			if err := s.NotificationWorker.SendIncidentEscalatedNotification(incident.AcknowledgedBy, incident.IncidentID, message); err != nil {
+				log.Printf("Failed to nudge %s about the passed ETA of incident %s: %v", incident.AcknowledgedBy, incident.IncidentID, err)
+			}
+		}(incident)
+	}
+	return overdue, nil
+}
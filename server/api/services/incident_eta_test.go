@@ -0,0 +1,150 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcknowledgeIncident_StoresETA(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	eta := time.Now().Add(45 * time.Minute).UTC().Truncate(time.Second)
+	mockDB.ExpectExec(`UPDATE incidents\s+SET status = \$1.*ack_eta = \$5, eta_nudged_at = NULL\s+WHERE id = \$3 AND status = \$4`).
+		WithArgs(db.IncidentStatusAcknowledged, "user-1", "inc-1", db.IncidentStatusTriggered, eta).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEscalationsStopped(mockDB, "inc-1", "user-1", db.AlertEscalationStatusAcknowledged)
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", db.IncidentEventAcknowledged, `{"eta":"`+eta.Format(time.RFC3339)+`","note":"rolling back the deploy"}`, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	err = service.AcknowledgeIncident("inc-1", "user-1", "rolling back the deploy", eta)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestAcknowledgeIncident_ReplacesETA(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Already acknowledged: only the ETA changes and its nudge is re-armed, nobody is notified
+	eta := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+	mockDB.ExpectExec(`UPDATE incidents\s+SET status = \$1`).
+		WithArgs(db.IncidentStatusAcknowledged, "user-1", "inc-1", db.IncidentStatusTriggered, eta).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectExec(`UPDATE incidents\s+SET ack_eta = \$1, eta_nudged_at = NULL, updated_at = .*\s+WHERE id = \$2 AND status = \$3`).
+		WithArgs(eta, "inc-1", db.IncidentStatusAcknowledged).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", db.IncidentEventETAUpdated, `{"eta":"`+eta.Format(time.RFC3339)+`"}`, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	err = service.AcknowledgeIncident("inc-1", "user-1", "", eta)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestAcknowledgeIncident_NotTriggered(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Without an ETA to replace, acknowledging again changes nothing
+	mockDB.ExpectExec(`UPDATE incidents\s+SET status = \$1`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// A resolved incident has no ETA to replace either
+	eta := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	mockDB.ExpectExec(`UPDATE incidents\s+SET status = \$1`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectExec(`UPDATE incidents\s+SET ack_eta = \$1`).
+		WithArgs(eta, "inc-1", db.IncidentStatusAcknowledged).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	service := NewIncidentService(pg, nil, nil)
+	assert.ErrorIs(t, service.AcknowledgeIncident("inc-1", "user-1", "", time.Time{}), ErrIncidentNotTriggered)
+	assert.ErrorIs(t, service.AcknowledgeIncident("inc-1", "user-1", "", eta), ErrIncidentNotTriggered)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestAcknowledgeIncident_ETAInPast(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	err = NewIncidentService(pg, nil, nil).AcknowledgeIncident("inc-1", "user-1", "", time.Now().Add(-time.Minute))
+
+	assert.ErrorIs(t, err, ErrAckETAInPast)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestNudgeOverdueETAs_NudgesAcknowledger(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	eta := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	mockDB.ExpectQuery(`UPDATE incidents\s+SET eta_nudged_at = NOW\(\).*WHERE status = \$1 AND ack_eta <= NOW\(\) AND eta_nudged_at IS NULL.*FOR UPDATE SKIP LOCKED`).
+		WithArgs(db.IncidentStatusAcknowledged, etaNudgeBatch).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "acknowledged_by", "ack_eta"}).
+			AddRow("inc-1", "Database down", "user-1", eta))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", db.IncidentEventETAPassed, `{"acknowledged_by":"user-1","eta":"2026-10-17T09:30:00Z"}`, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	notifier := &escalatedNotifier{
+		assignedNotifier: assignedNotifier{assigned: make(chan string, 1)},
+		escalated:        make(chan string, 1),
+	}
+	service := NewIncidentService(pg, nil, nil)
+	service.SetNotificationWorker(notifier)
+
+	overdue, err := service.NudgeOverdueETAs()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []OverdueETA{{IncidentID: "inc-1", Title: "Database down", AcknowledgedBy: "user-1", ETA: eta}}, overdue)
+	select {
+	case nudge := <-notifier.escalated:
+		assert.Equal(t, `user-1: The ETA of 2026-10-17T09:30:00Z you gave for incident "Database down" has passed and it is still unresolved`, nudge)
+	case <-time.After(time.Second):
+		t.Fatal("the acknowledger should be nudged once the ETA passes")
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestNudgeOverdueETAs_NothingOverdue(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`SET eta_nudged_at = NOW\(\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "acknowledged_by", "ack_eta"}))
+
+	overdue, err := NewIncidentService(pg, nil, nil).NudgeOverdueETAs()
+
+	assert.NoError(t, err)
+	assert.Empty(t, overdue)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
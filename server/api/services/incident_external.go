@@ -0,0 +1,377 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// ExternalTicketPollInterval is how often the ticket of an incident on hold is checked
+const ExternalTicketPollInterval = 5 * time.Minute
+
+// externalTicketPollBatch caps how many tickets one poll checks
+const externalTicketPollBatch = 50
+
+// ErrInvalidExternalTicket is returned (wrapped) when a ticket's provider or URL is rejected
+var ErrInvalidExternalTicket = errors.New("invalid external ticket")
+
+// ErrIncidentNotOnHold is returned when releasing an incident that isn't external_pending
+var ErrIncidentNotOnHold = errors.New("incident is not on hold")
+
+// ExternalTicketState is what a vendor ticket looked like when it was checked
+type ExternalTicketState struct {
+	Status string // The provider's own status, e.g. "closed" or "Done"
+	Closed bool
+}
+
+// ExternalTicketChecker looks up the state of a vendor ticket
+type ExternalTicketChecker interface {
+	CheckTicket(ticket db.IncidentExternalTicket) (ExternalTicketState, error)
+}
+
+// ErrTicketHostNotAllowed is returned (wrapped) when a ticket URL resolves to a non-public address
+var ErrTicketHostNotAllowed = errors.New("ticket host is not a public address")
+
+// HTTPTicketChecker reads tickets from the providers' REST APIs without authentication.
+// Ticket URLs come from responders, so the default client only dials public addresses.
+type HTTPTicketChecker struct {
+	Client *http.Client
+}
+
+func NewHTTPTicketChecker() *HTTPTicketChecker {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: rejectNonPublicAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // A proxy would be dialed instead of the ticket host
+	transport.DialContext = dialer.DialContext
+	return &HTTPTicketChecker{Client: &http.Client{Timeout: 10 * time.Second, Transport: transport}}
+}
+
+// rejectNonPublicAddress runs after DNS resolution, so a public hostname that resolves to
+// loopback, link-local (cloud metadata) or private space is refused as well
+func rejectNonPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrTicketHostNotAllowed, address)
+	}
+	if !isPublicIP(net.ParseIP(host)) {
+		return fmt.Errorf("%w: %s", ErrTicketHostNotAllowed, host)
+	}
+	return nil
+}
+
+// isPublicIP rejects loopback, private, link-local (which covers cloud metadata endpoints
+// such as 169.254.169.254) and multicast addresses
+func isPublicIP(ip net.IP) bool {
+	return ip != nil && !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+// CheckTicket fetches the ticket's JSON from its provider's API
+func (c *HTTPTicketChecker) CheckTicket(ticket db.IncidentExternalTicket) (ExternalTicketState, error) {
+	apiURL, err := externalTicketAPIURL(ticket.Provider, ticket.URL)
+	if err != nil {
+		return ExternalTicketState{}, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return ExternalTicketState{}, fmt.Errorf("failed to build ticket request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return ExternalTicketState{}, fmt.Errorf("failed to fetch ticket: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ExternalTicketState{}, fmt.Errorf("ticket request returned %s", resp.Status)
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return ExternalTicketState{}, fmt.Errorf("failed to parse ticket: %w", err)
+	}
+	return parseExternalTicketState(ticket.Provider, body)
+}
+
+// externalTicketAPIURL turns the ticket URL a responder pastes into the API URL to poll.
+// GitHub issue and Jira browse links are rewritten; generic URLs are polled as they are.
+func externalTicketAPIURL(provider, ticketURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(ticketURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%w: ticket URL must be an absolute http(s) URL", ErrInvalidExternalTicket)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch provider {
+	case db.ExternalTicketProviderGitHub:
+		if u.Host == "api.github.com" {
+			return u.String(), nil
+		}
+		// github.com/{owner}/{repo}/issues/{number}, or pull/{number}
+		if u.Host != "github.com" || len(parts) != 4 || (parts[2] != "issues" && parts[2] != "pull") {
+			return "", fmt.Errorf("%w: expected a GitHub issue or pull request URL", ErrInvalidExternalTicket)
+		}
+		return fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/%s", parts[0], parts[1], parts[3]), nil
+	case db.ExternalTicketProviderJira:
+		if len(parts) >= 3 && parts[0] == "rest" {
+			return u.String(), nil
+		}
+		// {site}/browse/{KEY-123}
+		if len(parts) != 2 || parts[0] != "browse" {
+			return "", fmt.Errorf("%w: expected a Jira /browse/ URL", ErrInvalidExternalTicket)
+		}
+		return fmt.Sprintf("%s://%s/rest/api/2/issue/%s?fields=status", u.Scheme, u.Host, url.PathEscape(parts[1])), nil
+	case db.ExternalTicketProviderGeneric:
+		return u.String(), nil
+	default:
+		return "", fmt.Errorf("%w: unknown provider %q", ErrInvalidExternalTicket, provider)
+	}
+}
+
+// parseExternalTicketState reads a ticket's status from its provider's API response
+func parseExternalTicketState(provider string, body map[string]interface{}) (ExternalTicketState, error) {
+	switch provider {
+	case db.ExternalTicketProviderGitHub:
+		state, _ := body["state"].(string)
+		if state == "" {
+			return ExternalTicketState{}, fmt.Errorf("GitHub issue has no state")
+		}
+		return ExternalTicketState{Status: state, Closed: state == "closed"}, nil
+	case db.ExternalTicketProviderJira:
+		fields, _ := body["fields"].(map[string]interface{})
+		status, _ := fields["status"].(map[string]interface{})
+		name, _ := status["name"].(string)
+		category, _ := status["statusCategory"].(map[string]interface{})
+		key, _ := category["key"].(string)
+		if name == "" {
+			return ExternalTicketState{}, fmt.Errorf("Jira issue has no status")
+		}
+		return ExternalTicketState{Status: name, Closed: key == "done"}, nil
+	default:
+		status, _ := body["status"].(string)
+		if status == "" {
+			status, _ = body["state"].(string)
+		}
+		if status == "" {
+			return ExternalTicketState{}, fmt.Errorf("ticket has no status or state field")
+		}
+		switch strings.ToLower(status) {
+		case "closed", "resolved", "done", "completed":
+			return ExternalTicketState{Status: status, Closed: true}, nil
+		}
+		return ExternalTicketState{Status: status}, nil
+	}
+}
+
+// HoldIncidentForExternalTicket puts an open incident on hold until the vendor closes the
+// given ticket. Holding acknowledges a triggered incident, so it stops escalating; the
+// incident worker polls the ticket and resolves the incident once it is closed.
+func (s *IncidentService) HoldIncidentForExternalTicket(id, userID, provider, ticketURL, reason string) (*db.IncidentExternalTicket, error) {
+	if _, err := externalTicketAPIURL(provider, ticketURL); err != nil {
+		return nil, err
+	}
+	ticket := &db.IncidentExternalTicket{
+		IncidentID: id,
+		Provider:   provider,
+		URL:        strings.TrimSpace(ticketURL),
+		CreatedBy:  userID,
+	}
+
+	tx, err := s.PG.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec(`
+		UPDATE incidents
+		SET status = $1,
+		    acknowledged_by = COALESCE(acknowledged_by, $2::uuid),
+		    acknowledged_at = COALESCE(acknowledged_at, `+SQLNowUTC+`),
+		    -- Cancel any snooze
+		    escalation_status = COALESCE(snoozed_escalation_status, escalation_status),
+		    snoozed_until = NULL, snoozed_escalation_status = NULL,
+		    updated_at = `+SQLNowUTC+`
+		WHERE id = $3 AND status != $4
+	`, db.IncidentStatusExternalPending, userID, id, db.IncidentStatusResolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to put incident on hold: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil, fmt.Errorf("incident not found or already resolved")
+	}
+
+	// Holding again replaces the ticket
+	err = tx.QueryRow(`
+		INSERT INTO incident_external_tickets (incident_id, provider, url, created_by, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (incident_id) DO UPDATE
+		SET provider = EXCLUDED.provider, url = EXCLUDED.url, status = NULL, checked_at = NULL,
+		    created_by = EXCLUDED.created_by, created_at = EXCLUDED.created_at
+		RETURNING created_at
+	`, id, ticket.Provider, ticket.URL, nullIfEmpty(userID)).Scan(&ticket.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save external ticket: %w", err)
+	}
+
+	eventData := map[string]interface{}{
+		"provider":   ticket.Provider,
+		"ticket_url": ticket.URL,
+	}
+	if reason != "" {
+		eventData["reason"] = reason
+	}
+	_ = createIncidentEventWith(tx, id, db.IncidentEventOnHold, eventData, userID)
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return ticket, nil
+}
+
+// ReleaseIncidentHold takes an incident off hold, back to acknowledged, and stops polling its ticket
+func (s *IncidentService) ReleaseIncidentHold(id, userID string) error {
+	tx, err := s.PG.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec(`
+		UPDATE incidents
+		SET status = $1, updated_at = `+SQLNowUTC+`
+		WHERE id = $2 AND status = $3
+	`, db.IncidentStatusAcknowledged, id, db.IncidentStatusExternalPending)
+	if err != nil {
+		return fmt.Errorf("failed to release incident hold: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrIncidentNotOnHold
+	}
+
+	if _, err := tx.Exec(`DELETE FROM incident_external_tickets WHERE incident_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to remove external ticket: %w", err)
+	}
+	_ = createIncidentEventWith(tx, id, db.IncidentEventHoldReleased, map[string]interface{}{}, userID)
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetExternalTicket returns the ticket an incident is on hold for, or nil if there is none
+func (s *IncidentService) GetExternalTicket(incidentID string) (*db.IncidentExternalTicket, error) {
+	ticket, err := scanExternalTicket(s.PG.QueryRow(`
+		SELECT `+externalTicketColumns+`
+		FROM incident_external_tickets
+		WHERE incident_id = $1
+	`, incidentID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// PollExternalTickets checks the tickets of incidents on hold that weren't checked within
+// every, records status changes and resolves the incidents whose ticket was closed.
+// Returns the IDs of the resolved incidents.
+func (s *IncidentService) PollExternalTickets(checker ExternalTicketChecker, every time.Duration) ([]string, error) {
+	rows, err := s.PG.Query(`
+		SELECT t.incident_id, t.provider, t.url, COALESCE(t.status, ''), t.checked_at,
+		       COALESCE(t.created_by::text, ''), t.created_at
+		FROM incident_external_tickets t
+		JOIN incidents i ON i.id = t.incident_id
+		WHERE i.status = $1
+		  AND (t.checked_at IS NULL OR t.checked_at <= NOW() - make_interval(secs => $2))
+		ORDER BY t.checked_at NULLS FIRST
+		LIMIT $3
+	`, db.IncidentStatusExternalPending, every.Seconds(), externalTicketPollBatch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get external tickets to poll: %w", err)
+	}
+
+	var tickets []db.IncidentExternalTicket
+	for rows.Next() {
+		ticket, err := scanExternalTicket(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tickets = append(tickets, ticket)
+	}
+	rows.Close()
+
+	var resolved []string
+	for _, ticket := range tickets {
+		state, err := checker.CheckTicket(ticket)
+		if err != nil {
+			log.Printf("WARNING: Failed to check external ticket %s of incident %s: %v", ticket.URL, ticket.IncidentID, err)
+			// Wait a full interval before trying a failing ticket again
+			state = ExternalTicketState{Status: ticket.Status}
+		}
+
+		if _, err := s.PG.Exec(`
+			UPDATE incident_external_tickets SET status = NULLIF($2, ''), checked_at = NOW() WHERE incident_id = $1
+		`, ticket.IncidentID, state.Status); err != nil {
+			return resolved, fmt.Errorf("failed to update external ticket: %w", err)
+		}
+
+		if state.Status != ticket.Status {
+			_ = s.createIncidentEvent(ticket.IncidentID, db.IncidentEventExternalTicketUpdated, map[string]interface{}{
+				"ticket_url": ticket.URL,
+				"from":       ticket.Status,
+				"to":         state.Status,
+			}, db.SystemUserAPI)
+		}
+
+		if !state.Closed {
+			continue
+		}
+		err = s.ResolveIncident(ticket.IncidentID, db.SystemUserAPI, "External ticket closed",
+			fmt.Sprintf("Automatically resolved: external ticket %s was closed (%s)", ticket.URL, state.Status))
+		if err != nil && !errors.Is(err, ErrAlreadyResolved) {
+			log.Printf("WARNING: Failed to resolve incident %s after its external ticket closed: %v", ticket.IncidentID, err)
+			continue
+		}
+		resolved = append(resolved, ticket.IncidentID)
+	}
+	return resolved, nil
+}
+
+const externalTicketColumns = `incident_id, provider, url, COALESCE(status, ''), checked_at, COALESCE(created_by::text, ''), created_at`
+
+// scanExternalTicket scans a row selected with externalTicketColumns
+func scanExternalTicket(row interface{ Scan(...interface{}) error }) (db.IncidentExternalTicket, error) {
+	var ticket db.IncidentExternalTicket
+	var checkedAt sql.NullTime
+	err := row.Scan(&ticket.IncidentID, &ticket.Provider, &ticket.URL, &ticket.Status,
+		&checkedAt, &ticket.CreatedBy, &ticket.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ticket, err
+	}
+	if err != nil {
+		return ticket, fmt.Errorf("failed to scan external ticket: %w", err)
+	}
+	if checkedAt.Valid {
+		ticket.CheckedAt = &checkedAt.Time
+	}
+	return ticket, nil
+}
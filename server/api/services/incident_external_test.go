@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubTicketChecker returns a fixed state for every ticket
+type stubTicketChecker struct {
+	state ExternalTicketState
+	err   error
+}
+
+func (c stubTicketChecker) CheckTicket(db.IncidentExternalTicket) (ExternalTicketState, error) {
+	return c.state, c.err
+}
+
+var externalTicketRowColumns = []string{"incident_id", "provider", "url", "status", "checked_at", "created_by", "created_at"}
+
+func expectTicketsToPoll(mockDB sqlmock.Sqlmock, status string) {
+	mockDB.ExpectQuery(`FROM incident_external_tickets t JOIN incidents i ON i.id = t.incident_id WHERE i.status = \$1`).
+		WithArgs(db.IncidentStatusExternalPending, ExternalTicketPollInterval.Seconds(), externalTicketPollBatch).
+		WillReturnRows(sqlmock.NewRows(externalTicketRowColumns).
+			AddRow("inc-1", "github", "https://github.com/acme/db/issues/42", status, nil, "user-1", time.Now()))
+}
+
+func TestPollExternalTickets_ClosedTicketResolvesIncident(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectTicketsToPoll(mockDB, "open")
+	mockDB.ExpectExec(`UPDATE incident_external_tickets SET status = NULLIF\(\$2, ''\), checked_at = NOW\(\)`).
+		WithArgs("inc-1", "closed").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`INSERT INTO incident_events`).
+		WithArgs("inc-1", db.IncidentEventExternalTicketUpdated, sqlmock.AnyArg(), db.SystemUserAPI).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectExec(`UPDATE incidents SET status = \$1, resolved_by = \$2::uuid`).
+		WithArgs(db.IncidentStatusResolved, db.SystemUserAPI, "inc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEscalationsStopped(mockDB, "inc-1", db.SystemUserAPI, db.AlertEscalationStatusStopped)
+	mockDB.ExpectExec(`INSERT INTO incident_events`).
+		WithArgs("inc-1", db.IncidentEventResolved, sqlmock.AnyArg(), db.SystemUserAPI).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	resolved, err := service.PollExternalTickets(stubTicketChecker{state: ExternalTicketState{Status: "closed", Closed: true}}, ExternalTicketPollInterval)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"inc-1"}, resolved)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestPollExternalTickets_OpenTicketKeepsHold(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewIncidentService(pg, nil, nil)
+
+	// Unchanged status: only the check time moves
+	expectTicketsToPoll(mockDB, "open")
+	mockDB.ExpectExec(`UPDATE incident_external_tickets`).
+		WithArgs("inc-1", "open").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	resolved, err := service.PollExternalTickets(stubTicketChecker{state: ExternalTicketState{Status: "open"}}, ExternalTicketPollInterval)
+	assert.NoError(t, err)
+	assert.Empty(t, resolved)
+
+	// A failing check keeps the last known status until the next interval
+	expectTicketsToPoll(mockDB, "open")
+	mockDB.ExpectExec(`UPDATE incident_external_tickets`).
+		WithArgs("inc-1", "open").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	resolved, err = service.PollExternalTickets(stubTicketChecker{err: errors.New("503 Service Unavailable")}, ExternalTicketPollInterval)
+	assert.NoError(t, err)
+	assert.Empty(t, resolved)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestHoldIncidentForExternalTicket(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(`UPDATE incidents SET status = \$1, acknowledged_by = COALESCE\(acknowledged_by, \$2::uuid\)`).
+		WithArgs(db.IncidentStatusExternalPending, "user-1", "inc-1", db.IncidentStatusResolved).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery(`INSERT INTO incident_external_tickets .* ON CONFLICT \(incident_id\) DO UPDATE`).
+		WithArgs("inc-1", "jira", "https://acme.atlassian.net/browse/OPS-7", "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	mockDB.ExpectExec(`INSERT INTO incident_events`).
+		WithArgs("inc-1", db.IncidentEventOnHold, sqlmock.AnyArg(), "user-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectCommit()
+
+	service := NewIncidentService(pg, nil, nil)
+	ticket, err := service.HoldIncidentForExternalTicket("inc-1", "user-1", "jira", "https://acme.atlassian.net/browse/OPS-7", "Waiting on DB vendor")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "jira", ticket.Provider)
+
+	// Tickets that can't be polled are rejected before anything changes
+	_, err = service.HoldIncidentForExternalTicket("inc-1", "user-1", "github", "https://gitlab.com/acme/db/issues/1", "")
+	assert.ErrorIs(t, err, ErrInvalidExternalTicket)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestExternalTicketAPIURL(t *testing.T) {
+	tests := []struct {
+		provider, ticketURL, want string
+	}{
+		{"github", "https://github.com/acme/db/issues/42", "https://api.github.com/repos/acme/db/issues/42"},
+		{"github", "https://github.com/acme/db/pull/7", "https://api.github.com/repos/acme/db/issues/7"},
+		{"jira", "https://acme.atlassian.net/browse/OPS-7", "https://acme.atlassian.net/rest/api/2/issue/OPS-7?fields=status"},
+		{"generic", "https://vendor.example.com/api/cases/991", "https://vendor.example.com/api/cases/991"},
+	}
+	for _, tt := range tests {
+		got, err := externalTicketAPIURL(tt.provider, tt.ticketURL)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+
+	for _, bad := range [][2]string{{"github", "https://github.com/acme"}, {"jira", "ftp://acme/browse/OPS-1"}, {"zendesk", "https://acme.zendesk.com/1"}} {
+		_, err := externalTicketAPIURL(bad[0], bad[1])
+		assert.ErrorIs(t, err, ErrInvalidExternalTicket, bad[1])
+	}
+}
+
+func TestHTTPTicketChecker_Generic(t *testing.T) {
+	status := "In Progress"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id": 991, "status": "` + status + `"}`))
+	}))
+	defer server.Close()
+
+	checker := &HTTPTicketChecker{Client: server.Client()}
+	ticket := db.IncidentExternalTicket{Provider: "generic", URL: server.URL + "/api/cases/991"}
+
+	state, err := checker.CheckTicket(ticket)
+	assert.NoError(t, err)
+	assert.Equal(t, ExternalTicketState{Status: "In Progress"}, state)
+
+	status = "Resolved"
+	state, err = checker.CheckTicket(ticket)
+	assert.NoError(t, err)
+	assert.True(t, state.Closed)
+}
+
+func TestHTTPTicketChecker_RejectsNonPublicHosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the ticket checker should not reach a loopback address")
+	}))
+	defer server.Close()
+
+	checker := NewHTTPTicketChecker()
+	_, err := checker.CheckTicket(db.IncidentExternalTicket{Provider: "generic", URL: server.URL + "/api/cases/991"})
+	assert.ErrorIs(t, err, ErrTicketHostNotAllowed)
+
+	for _, address := range []string{"169.254.169.254:80", "10.0.0.5:443", "192.168.1.10:80", "[::1]:443", "[fd00::1]:443"} {
+		assert.ErrorIs(t, rejectNonPublicAddress("tcp", address, nil), ErrTicketHostNotAllowed, address)
+	}
+	assert.NoError(t, rejectNonPublicAddress("tcp", "140.82.112.6:443", nil))
+}
+
+func TestParseExternalTicketState_Jira(t *testing.T) {
+	state, err := parseExternalTicketState("jira", map[string]interface{}{
+		"fields": map[string]interface{}{
+			"status": map[string]interface{}{
+				"name":           "Won't Fix",
+				"statusCategory": map[string]interface{}{"key": "done"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ExternalTicketState{Status: "Won't Fix", Closed: true}, state)
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// ErrPlatformAdminRequired is returned when a cross-organization query is made by someone
+// who is not a platform admin
+var ErrPlatformAdminRequired = errors.New("platform admin access required")
+
+// AuditActionListIncidentsAllOrgs is the audit action recorded for cross-organization incident lists
+const AuditActionListIncidentsAllOrgs = "incidents.list_all_orgs"
+
+// incidentListAllOrgsFrom is incidentListFrom without tenant isolation or ReBAC scope.
+// $1 = the platform admin (unused, keeps buildIncidentListFilter's numbering), $2 = optional organization
+const incidentListAllOrgsFrom = incidentListJoins + `
+		WHERE
+			$1::text IS NOT NULL
+			AND ($2::text = '' OR i.organization_id::text = $2)
+	`
+
+// ListIncidentsAllOrgs lists incidents across every organization for platform support.
+// The caller (filters["current_user_id"]) must be a platform admin; each access is
+// written to platform_audit_logs first and refused if it can't be audited. filters take the
+// same keys as ListIncidents, plus an optional "organization_id" to narrow to one tenant.
+func (s *IncidentService) ListIncidentsAllOrgs(filters map[string]interface{}) ([]db.IncidentResponse, error) {
+	actorID, _ := filters["current_user_id"].(string)
+	if actorID == "" {
+		return nil, ErrPlatformAdminRequired
+	}
+
+	isAdmin, err := s.isPlatformAdmin(actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		log.Printf("WARNING: User %s denied cross-organization incident list", actorID)
+		return nil, ErrPlatformAdminRequired
+	}
+
+	orgID, _ := filters["organization_id"].(string)
+	if err := s.recordPlatformAudit(actorID, AuditActionListIncidentsAllOrgs, filters); err != nil {
+		return nil, err
+	}
+	log.Printf("AUDIT: Platform admin %s listed incidents across organizations (organization filter: %q)", actorID, orgID)
+
+	filter := buildIncidentListFilter(filters, actorID, orgID)
+	return s.queryIncidentList(incidentListSelect+incidentListAllOrgsFrom, filter, filters)
+}
+
+// isPlatformAdmin reports whether an active user is listed in platform_admins
+func (s *IncidentService) isPlatformAdmin(userID string) (bool, error) {
+	return isPlatformAdmin(s.PG, userID)
+}
+
+// isPlatformAdmin reports whether an active user is listed in platform_admins. The grant is
+// kept out of users.role, which users can edit through the user endpoints.
+func isPlatformAdmin(pg *sql.DB, userID string) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+	var isAdmin bool
+	err := pg.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM platform_admins pa
+			JOIN users u ON u.id = pa.user_id
+			WHERE pa.user_id = $1 AND u.is_active = true
+		)
+	`, userID).Scan(&isAdmin)
+	if err != nil {
+		return false, fmt.Errorf("failed to check platform admin access: %w", err)
+	}
+	return isAdmin, nil
+}
+
+// recordPlatformAudit logs a platform admin action along with the request details
+func (s *IncidentService) recordPlatformAudit(actorID, action string, details map[string]interface{}) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	if _, err := s.PG.Exec(`
+		INSERT INTO platform_audit_logs (actor_id, action, details, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`, actorID, action, detailsJSON); err != nil {
+		return fmt.Errorf("failed to record audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListIncidentsAllOrgs_RequiresPlatformAdmin(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`SELECT 1 FROM platform_admins pa\s+JOIN users u ON u.id = pa.user_id\s+WHERE pa.user_id = \$1 AND u.is_active = true`).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	service := NewIncidentService(pg, nil, nil)
+	incidents, err := service.ListIncidentsAllOrgs(map[string]interface{}{"current_user_id": "user-1"})
+
+	// Users not in platform_admins, whatever their role, get nothing audited or listed
+	assert.ErrorIs(t, err, ErrPlatformAdminRequired)
+	assert.Nil(t, incidents)
+
+	_, err = service.ListIncidentsAllOrgs(map[string]interface{}{})
+	assert.ErrorIs(t, err, ErrPlatformAdminRequired)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestListIncidentsAllOrgs_PlatformAdmin(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`FROM platform_admins`).
+		WithArgs("admin-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mockDB.ExpectExec(`INSERT INTO platform_audit_logs \(actor_id, action, details, created_at\)`).
+		WithArgs("admin-1", AuditActionListIncidentsAllOrgs, []byte(`{"current_user_id":"admin-1","organization_id":"org-2","status":"triggered"}`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// No tenant isolation or membership scope, just the optional organization filter
+	mockDB.ExpectQuery(`WHERE\s+\$1::text IS NOT NULL\s+AND \(\$2::text = '' OR i\.organization_id::text = \$2\)\s+AND i\.archived_at IS NULL AND i\.status = \$3 ORDER BY`).
+		WithArgs("admin-1", "org-2", "triggered", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.ListIncidentsAllOrgs(map[string]interface{}{
+		"current_user_id": "admin-1",
+		"organization_id": "org-2",
+		"status":          "triggered",
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestListIncidentsAllOrgs_AuditFailureDeniesAccess(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`FROM platform_admins`).
+		WithArgs("admin-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mockDB.ExpectExec(`INSERT INTO platform_audit_logs`).
+		WillReturnError(errors.New("connection reset"))
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.ListIncidentsAllOrgs(map[string]interface{}{"current_user_id": "admin-1"})
+
+	assert.Error(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// IncidentSourceQuota is the source of the incident alerts are counted on once an
+// organization is over its daily incident quota
+const IncidentSourceQuota = "quota"
+
+// quotaIncidentKey is the incident_key of an organization's quota incident for a UTC day, so
+// every alert shed that day lands on the same incident
+func quotaIncidentKey(day time.Time) string {
+	return "quota-exceeded:" + day.UTC().Format("2006-01-02")
+}
+
+// shedOverQuota returns the incident an alert is counted on instead of opening a new one when
+// the organization already created its daily quota of incidents today (UTC). The first alert
+// over the quota opens the day's quota incident and notifies the org's owners and admins; later
+// ones bump its alert_count. Returns nil when the incident may be created. Manual incidents are
+// never shed, and failures let the incident through: losing alerts is worse than a storm.
+func (s *IncidentService) shedOverQuota(incident *db.Incident) *db.Incident {
+	if s.DailyIncidentQuota <= 0 || incident.OrganizationID == "" ||
+		incident.Source == "manual" || incident.Source == IncidentSourceQuota {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	quota, created, err := s.dailyIncidentUsage(incident.OrganizationID, dayStart)
+	if err != nil {
+		log.Printf("WARNING: Skipping incident quota check for org %s: %v", incident.OrganizationID, err)
+		return nil
+	}
+	if quota <= 0 || created < quota {
+		return nil
+	}
+
+	key := quotaIncidentKey(now)
+	existing, err := s.attachToOpenIncidentByKey(incident.OrganizationID, key)
+	if err != nil {
+		log.Printf("WARNING: Failed to count alert on quota incident for org %s: %v", incident.OrganizationID, err)
+		return nil
+	}
+	if existing != nil {
+		return existing
+	}
+
+	quotaIncident := &db.Incident{
+		Title: "Incident quota exceeded",
+		Description: fmt.Sprintf("The organization created its daily quota of %d incidents. "+
+			"Alerts for the rest of the day (UTC) are counted on this incident instead of opening new ones.", quota),
+		Source:         IncidentSourceQuota,
+		Severity:       "critical",
+		IncidentKey:    key,
+		OrganizationID: incident.OrganizationID,
+		Labels:         map[string]interface{}{"daily_quota": quota, "first_shed_title": incident.Title},
+	}
+	setIncidentDefaults(quotaIncident)
+
+	inserted, err := s.insertIncident(quotaIncident)
+	if err != nil {
+		log.Printf("WARNING: Failed to open quota incident for org %s: %v", incident.OrganizationID, err)
+		return nil
+	}
+	if !inserted {
+		// A concurrent alert opened the quota incident first
+		existing, err = s.attachToOpenIncidentByKey(incident.OrganizationID, key)
+		if err != nil || existing == nil {
+			log.Printf("WARNING: Failed to count alert on quota incident for org %s: %v", incident.OrganizationID, err)
+			return nil
+		}
+		return existing
+	}
+
+	log.Printf("INFO: Org %s is over its daily quota of %d incidents; shedding alerts into incident %s",
+		incident.OrganizationID, quota, quotaIncident.ID)
+	s.publishIncidentCreated(quotaIncident)
+	s.notifyOrgAdminsOfQuota(quotaIncident)
+	return quotaIncident
+}
+
+// dailyIncidentUsage returns an organization's daily incident quota and how many incidents it
+// created since dayStart. The org's "incident_daily_quota" setting overrides DailyIncidentQuota;
+// 0 there disables the quota for the org.
+func (s *IncidentService) dailyIncidentUsage(orgID string, dayStart time.Time) (quota, created int, err error) {
+	var override sql.NullString
+	err = s.PG.QueryRow(`
+		SELECT (SELECT settings->>'incident_daily_quota' FROM organizations WHERE id = $1),
+		       (SELECT COUNT(*) FROM incidents
+		        WHERE organization_id = $1 AND created_at >= $2 AND source IS DISTINCT FROM $3)
+	`, orgID, dayStart, IncidentSourceQuota).Scan(&override, &created)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count today's incidents: %w", err)
+	}
+
+	quota = s.DailyIncidentQuota
+	if override.Valid && override.String != "" {
+		quota, err = strconv.Atoi(override.String)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid incident_daily_quota %q: %w", override.String, err)
+		}
+	}
+	return quota, created, nil
+}
+
+// notifyOrgAdminsOfQuota makes the organization's owners and admins watchers of its quota
+// incident and notifies them, so someone looks at the alert storm
+func (s *IncidentService) notifyOrgAdminsOfQuota(incident *db.Incident) {
+	_, err := s.PG.Exec(`
+		INSERT INTO incident_watchers (incident_id, user_id, created_at)
+		SELECT $1, user_id, NOW() FROM memberships
+		WHERE resource_type = 'org' AND resource_id = $2 AND role IN ('owner', 'admin')
+		ON CONFLICT (incident_id, user_id) DO NOTHING
+	`, incident.ID, incident.OrganizationID)
+	if err != nil {
+		log.Printf("WARNING: Failed to add org admins as watchers of quota incident %s: %v", incident.ID, err)
+		return
+	}
+	s.notifyWatchers(incident.ID, "quota_exceeded", "", true)
+}
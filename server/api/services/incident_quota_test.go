@@ -0,0 +1,140 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func expectDailyIncidentUsage(mockDB sqlmock.Sqlmock, override interface{}, created int) {
+	mockDB.ExpectQuery(`SELECT settings->>'incident_daily_quota' FROM organizations WHERE id = \$1`).
+		WithArgs("org-1", sqlmock.AnyArg(), IncidentSourceQuota).
+		WillReturnRows(sqlmock.NewRows([]string{"quota", "created"}).AddRow(override, created))
+}
+
+func expectQuotaIncidentAttach(mockDB sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	mockDB.ExpectQuery(`UPDATE incidents\s+SET alert_count = alert_count \+ 1`).
+		WithArgs("org-1", quotaIncidentKey(time.Now()), stringArrayArg(db.OpenIncidentStatuses)).
+		WillReturnRows(rows)
+}
+
+func newAlertIncident() *db.Incident {
+	incident := &db.Incident{Title: "CPU high", Source: "webhook", OrganizationID: "org-1"}
+	setIncidentDefaults(incident)
+	return incident
+}
+
+func TestInsertOrAttachIncident_UnderQuota(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewIncidentService(pg, nil, nil)
+	service.DailyIncidentQuota = 100
+
+	// The org raised its quota, so the 150th incident of the day is still created
+	expectDailyIncidentUsage(mockDB, "200", 150)
+	mockDB.ExpectExec("INSERT INTO incidents").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	existing, err := service.insertOrAttachIncident(newAlertIncident())
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// Manual incidents are never shed, and a disabled quota costs no query
+	mockDB.ExpectExec("INSERT INTO incidents").WillReturnResult(sqlmock.NewResult(0, 1))
+	manual := newAlertIncident()
+	manual.Source = "manual"
+	_, err = service.insertOrAttachIncident(manual)
+	assert.NoError(t, err)
+
+	service.DailyIncidentQuota = 0
+	mockDB.ExpectExec("INSERT INTO incidents").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = service.insertOrAttachIncident(newAlertIncident())
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestInsertOrAttachIncident_OverQuotaOpensQuotaIncident(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewIncidentService(pg, nil, nil)
+	service.DailyIncidentQuota = 100
+
+	// The first alert over the quota opens the day's quota incident and makes the org's admins watch it
+	expectDailyIncidentUsage(mockDB, nil, 100)
+	expectQuotaIncidentAttach(mockDB, sqlmock.NewRows([]string{"id"}))
+	mockDB.ExpectExec("INSERT INTO incidents").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs(sqlmock.AnyArg(), db.IncidentEventTriggered, `{"severity":"critical","source":"quota"}`, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`INSERT INTO incident_watchers .* role IN \('owner', 'admin'\)`).
+		WithArgs(sqlmock.AnyArg(), "org-1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	shed, err := service.insertOrAttachIncident(newAlertIncident())
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, shed) {
+		assert.Equal(t, IncidentSourceQuota, shed.Source)
+		assert.Equal(t, quotaIncidentKey(time.Now()), shed.IncidentKey)
+		assert.Equal(t, 1, shed.AlertCount)
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestInsertOrAttachIncident_OverQuotaCountsOnQuotaIncident(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewIncidentService(pg, nil, nil)
+	service.DailyIncidentQuota = 100
+
+	// Later alerts only bump the quota incident's alert_count; nothing is inserted
+	expectDailyIncidentUsage(mockDB, nil, 100)
+	expectQuotaIncidentAttach(mockDB, sqlmock.NewRows([]string{
+		"id", "title", "status", "urgency", "priority", "severity",
+		"assigned_to", "service_id", "project_id", "alert_count", "created_at", "updated_at",
+	}).AddRow("inc-quota", "Incident quota exceeded", "triggered", "high", "", "critical",
+		nil, nil, nil, 7, time.Now(), time.Now()))
+
+	shed, err := service.insertOrAttachIncident(newAlertIncident())
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, shed) {
+		assert.Equal(t, "inc-quota", shed.ID)
+		assert.Equal(t, 7, shed.AlertCount)
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestInsertOrAttachIncident_QuotaCheckFailsOpen(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewIncidentService(pg, nil, nil)
+	service.DailyIncidentQuota = 100
+
+	// A broken org setting must not drop the alert
+	expectDailyIncidentUsage(mockDB, "lots", 500)
+	mockDB.ExpectExec("INSERT INTO incidents").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	existing, err := service.insertOrAttachIncident(newAlertIncident())
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/lib/pq"
+	"github.com/phonginreallife/inres/db"
+)
+
+// ErrNoOnCallUsers is returned when a group has nobody on call to rebalance onto
+var ErrNoOnCallUsers = errors.New("no one is on call for this group")
+
+// rebalanceIncident is an open incident considered by RebalanceAssignments
+type rebalanceIncident struct {
+	ID         string
+	AssignedTo string
+}
+
+// RebalanceAssignments spreads a group's open, unacknowledged incidents evenly across
+// the users currently on call. Incidents keep their assignee where possible; unassigned
+// ones and those held by users no longer on call are handed out first.
+func (s *IncidentService) RebalanceAssignments(groupID string) (*db.RebalanceResult, error) {
+	onCallUsers, err := s.currentOnCallUsers(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(onCallUsers) == 0 {
+		return nil, ErrNoOnCallUsers
+	}
+
+	rows, err := s.PG.Query(`
+		SELECT id, COALESCE(assigned_to::text, '')
+		FROM incidents
+		WHERE group_id = $1 AND status = $2 AND archived_at IS NULL
+		ORDER BY created_at ASC, id ASC
+	`, groupID, db.IncidentStatusTriggered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open incidents: %w", err)
+	}
+	defer rows.Close()
+
+	var incidents []rebalanceIncident
+	for rows.Next() {
+		var incident rebalanceIncident
+		if err := rows.Scan(&incident.ID, &incident.AssignedTo); err != nil {
+			return nil, fmt.Errorf("failed to scan incident: %w", err)
+		}
+		incidents = append(incidents, incident)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get open incidents: %w", err)
+	}
+
+	result := &db.RebalanceResult{
+		GroupID:       groupID,
+		OnCallUsers:   onCallUsers,
+		Reassignments: []db.IncidentReassignment{},
+		Load:          make(map[string]int, len(onCallUsers)),
+	}
+	for _, userID := range onCallUsers {
+		result.Load[userID] = 0
+	}
+	for _, incident := range incidents {
+		if _, ok := result.Load[incident.AssignedTo]; ok {
+			result.Load[incident.AssignedTo]++
+		}
+	}
+
+	names := s.userDisplayNames(onCallUsers)
+	for _, move := range planRebalance(incidents, onCallUsers) {
+		applied, err := s.reassignForRebalance(move, names[move.To])
+		if err != nil {
+			log.Printf("WARNING: Failed to rebalance incident %s onto %s: %v", move.IncidentID, move.To, err)
+			continue
+		}
+		if !applied {
+			log.Printf("DEBUG: Incident %s changed during rebalance, leaving it", move.IncidentID)
+			continue
+		}
+		if _, ok := result.Load[move.From]; ok {
+			result.Load[move.From]--
+		}
+		result.Load[move.To]++
+		result.Reassignments = append(result.Reassignments, move)
+	}
+
+	log.Printf("Rebalanced %d of %d open incidents in group %s across %d on-call users",
+		len(result.Reassignments), len(incidents), groupID, len(onCallUsers))
+	return result, nil
+}
+
+// currentOnCallUsers lists everyone on call for a group right now, overrides applied
+func (s *IncidentService) currentOnCallUsers(groupID string) ([]string, error) {
+	rows, err := s.PG.Query(`
+		SELECT DISTINCT es.effective_user_id
+		FROM effective_shifts es
+		WHERE es.group_id = $1
+		AND es.start_time <= NOW()
+		AND es.end_time >= NOW()
+		ORDER BY es.effective_user_id
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get on-call users: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan on-call user: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// planRebalance decides which incidents move so every on-call user ends up within one
+// incident of the others. Users already holding the most keep the extra incidents, and
+// users over their share give up their newest incidents first.
+func planRebalance(incidents []rebalanceIncident, onCallUsers []string) []db.IncidentReassignment {
+	if len(onCallUsers) == 0 {
+		return nil
+	}
+
+	held := make(map[string][]rebalanceIncident, len(onCallUsers))
+	for _, userID := range onCallUsers {
+		held[userID] = nil
+	}
+	var pool []rebalanceIncident
+	for _, incident := range incidents {
+		if _, onCall := held[incident.AssignedTo]; onCall {
+			held[incident.AssignedTo] = append(held[incident.AssignedTo], incident)
+		} else {
+			pool = append(pool, incident)
+		}
+	}
+
+	users := append([]string(nil), onCallUsers...)
+	sort.SliceStable(users, func(i, j int) bool {
+		return len(held[users[i]]) > len(held[users[j]])
+	})
+
+	base, extra := len(incidents)/len(users), len(incidents)%len(users)
+	quota := make(map[string]int, len(users))
+	for i, userID := range users {
+		quota[userID] = base
+		if i < extra {
+			quota[userID]++
+		}
+		if len(held[userID]) > quota[userID] {
+			pool = append(pool, held[userID][quota[userID]:]...)
+			held[userID] = held[userID][:quota[userID]]
+		}
+	}
+
+	var moves []db.IncidentReassignment
+	for _, userID := range users {
+		for len(held[userID]) < quota[userID] && len(pool) > 0 {
+			incident := pool[0]
+			pool = pool[1:]
+			held[userID] = append(held[userID], incident)
+			moves = append(moves, db.IncidentReassignment{IncidentID: incident.ID, From: incident.AssignedTo, To: userID})
+		}
+	}
+	return moves
+}
+
+// reassignForRebalance moves one incident, provided it is still open and unchanged
+func (s *IncidentService) reassignForRebalance(move db.IncidentReassignment, toName string) (bool, error) {
+	result, err := s.PG.Exec(`
+		UPDATE incidents
+		SET assigned_to = $1::uuid, assigned_at = `+SQLNowUTC+`, updated_at = `+SQLNowUTC+`
+		WHERE id = $2 AND status = $3 AND assigned_to IS NOT DISTINCT FROM $4::uuid
+	`, move.To, move.IncidentID, db.IncidentStatusTriggered, nullIfEmpty(move.From))
+	if err != nil {
+		return false, fmt.Errorf("failed to reassign incident: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return false, nil
+	}
+
+	if toName == "" {
+		toName = move.To
+	}
+	eventData := map[string]interface{}{
+		"assigned_to_id": move.To,
+		"assigned_to":    toName,
+		"method":         "rebalance",
+	}
+	if move.From != "" {
+		eventData["previous_assigned_to_id"] = move.From
+	}
+	if err := s.createIncidentEvent(move.IncidentID, db.IncidentEventAssigned, eventData, ""); err != nil {
+		log.Printf("WARNING: Failed to create rebalance event for incident %s: %v", move.IncidentID, err)
+	}
+
+	if s.NotificationWorker != nil {
+		if err := s.NotificationWorker.SendIncidentAssignedNotification(move.To, move.IncidentID); err != nil {
+			log.Printf("WARNING: Failed to send notification for rebalanced incident %s: %v", move.IncidentID, err)
+		}
+	}
+	return true, nil
+}
+
+// userDisplayNames looks up display names for users; missing users are left out
+func (s *IncidentService) userDisplayNames(userIDs []string) map[string]string {
+	names := make(map[string]string, len(userIDs))
+	rows, err := s.PG.Query(`SELECT id, COALESCE(name, email, 'Unknown') FROM users WHERE id = ANY($1)`, pq.Array(userIDs))
+	if err != nil {
+		log.Printf("WARNING: Failed to look up user names: %v", err)
+		return names
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err == nil {
+			names[id] = name
+		}
+	}
+	return names
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanRebalance(t *testing.T) {
+	incidents := []rebalanceIncident{
+		{ID: "inc-1", AssignedTo: "alice"},
+		{ID: "inc-2", AssignedTo: "alice"},
+		{ID: "inc-3", AssignedTo: "alice"},
+		{ID: "inc-4", AssignedTo: "alice"},
+		{ID: "inc-5", AssignedTo: "alice"},
+		{ID: "inc-6", AssignedTo: "dave"}, // dave is off call now
+		{ID: "inc-7"},
+	}
+
+	moves := planRebalance(incidents, []string{"alice", "bob", "carol"})
+
+	// Unassigned and off-call incidents go first, then alice's newest
+	assert.Equal(t, []db.IncidentReassignment{
+		{IncidentID: "inc-6", From: "dave", To: "bob"},
+		{IncidentID: "inc-7", To: "bob"},
+		{IncidentID: "inc-4", From: "alice", To: "carol"},
+		{IncidentID: "inc-5", From: "alice", To: "carol"},
+	}, moves)
+
+	// Already balanced: nothing moves
+	assert.Empty(t, planRebalance([]rebalanceIncident{
+		{ID: "inc-1", AssignedTo: "alice"},
+		{ID: "inc-2", AssignedTo: "bob"},
+		{ID: "inc-3", AssignedTo: "alice"},
+	}, []string{"alice", "bob"}))
+}
+
+func TestRebalanceAssignments(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`SELECT DISTINCT es.effective_user_id\s+FROM effective_shifts es`).
+		WithArgs("group-1").
+		WillReturnRows(sqlmock.NewRows([]string{"effective_user_id"}).AddRow("user-1").AddRow("user-2"))
+	mockDB.ExpectQuery(`SELECT id, COALESCE\(assigned_to::text, ''\)\s+FROM incidents\s+WHERE group_id = \$1 AND status = \$2 AND archived_at IS NULL`).
+		WithArgs("group-1", db.IncidentStatusTriggered).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "assigned_to"}).
+			AddRow("inc-1", "user-1").
+			AddRow("inc-2", "user-1").
+			AddRow("inc-3", "user-1").
+			AddRow("inc-4", "user-1"))
+	mockDB.ExpectQuery(`SELECT id, COALESCE\(name, email, 'Unknown'\) FROM users WHERE id = ANY\(\$1\)`).
+		WithArgs(stringArrayArg{"user-1", "user-2"}).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("user-1", "Alice").AddRow("user-2", "Bob"))
+	for _, id := range []string{"inc-3", "inc-4"} {
+		mockDB.ExpectExec(`UPDATE incidents\s+SET assigned_to = \$1::uuid, assigned_at = NOW\(\) AT TIME ZONE 'UTC', updated_at = NOW\(\) AT TIME ZONE 'UTC'\s+WHERE id = \$2 AND status = \$3 AND assigned_to IS NOT DISTINCT FROM \$4::uuid`).
+			WithArgs("user-2", id, db.IncidentStatusTriggered, "user-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mockDB.ExpectExec("INSERT INTO incident_events").
+			WithArgs(id, "assigned", `{"assigned_to":"Bob","assigned_to_id":"user-2","method":"rebalance","previous_assigned_to_id":"user-1"}`, nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	notifier := &assignedNotifier{assigned: make(chan string, 2)}
+	service := NewIncidentService(pg, nil, nil)
+	service.SetNotificationWorker(notifier)
+	result, err := service.RebalanceAssignments("group-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"user-1": 2, "user-2": 2}, result.Load)
+	assert.Equal(t, []db.IncidentReassignment{
+		{IncidentID: "inc-3", From: "user-1", To: "user-2"},
+		{IncidentID: "inc-4", From: "user-1", To: "user-2"},
+	}, result.Reassignments)
+	assert.Equal(t, "user-2", <-notifier.assigned)
+	assert.Equal(t, "user-2", <-notifier.assigned)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRebalanceAssignments_NobodyOnCall(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery("FROM effective_shifts").
+		WithArgs("group-1").
+		WillReturnRows(sqlmock.NewRows([]string{"effective_user_id"}))
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.RebalanceAssignments("group-1")
+
+	assert.ErrorIs(t, err, ErrNoOnCallUsers)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// SeverityUpgradeRule bumps the severity of incidents left unresolved too long
+type SeverityUpgradeRule struct {
+	From         string
+	To           string
+	AfterMinutes int
+}
+
+// ParseSeverityUpgradeRules parses a comma-separated list of "from:to:minutes" rules,
+// e.g. "warning:high:240,high:critical:60". An empty spec yields no rules.
+func ParseSeverityUpgradeRules(spec string) ([]SeverityUpgradeRule, error) {
+	var rules []SeverityUpgradeRule
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid severity upgrade rule %q, expected from:to:minutes", entry)
+		}
+		from, to := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		minutes, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+		if err != nil || minutes <= 0 {
+			return nil, fmt.Errorf("invalid minutes in severity upgrade rule %q", entry)
+		}
+		if from == "" || to == "" || from == to {
+			return nil, fmt.Errorf("invalid severities in severity upgrade rule %q", entry)
+		}
+		if seen[from] {
+			return nil, fmt.Errorf("duplicate severity upgrade rule for %q", from)
+		}
+		seen[from] = true
+
+		rules = append(rules, SeverityUpgradeRule{From: from, To: to, AfterMinutes: minutes})
+	}
+	return rules, nil
+}
+
+// AutoUpgradeSeverities applies the rules to open incidents and returns the upgrades made.
+// Priority is recomputed for the new severity, an updated event is recorded and the
+// assignee and watchers are notified, so the upgrade is paged like a manual severity change.
+func (s *IncidentService) AutoUpgradeSeverities(rules []SeverityUpgradeRule) ([]db.SeverityUpgrade, error) {
+	upgrades := []db.SeverityUpgrade{}
+	for _, rule := range rules {
+		candidates, err := s.severityUpgradeCandidates(rule)
+		if err != nil {
+			return upgrades, err
+		}
+
+		for _, incident := range candidates {
+			priority := s.computeOrgPriority(incident.OrganizationID, rule.To, incident.Urgency)
+			result, err := s.PG.Exec(`
+				UPDATE incidents
+				SET severity = $1, priority = COALESCE(NULLIF($2, ''), priority), updated_at = `+SQLNowUTC+`
+				WHERE id = $3 AND severity = $4 AND status != $5
+			`, rule.To, priority, incident.ID, rule.From, db.IncidentStatusResolved)
+			if err != nil {
+				return upgrades, fmt.Errorf("failed to upgrade incident severity: %w", err)
+			}
+			if affected, _ := result.RowsAffected(); affected == 0 {
+				continue
+			}
+
+			if err := s.createIncidentEvent(incident.ID, db.IncidentEventUpdated, map[string]interface{}{
+				"reason":            "severity_auto_upgrade",
+				"severity":          rule.To,
+				"previous_severity": rule.From,
+				"after_minutes":     rule.AfterMinutes,
+			}, ""); err != nil {
+				log.Printf("WARNING: Failed to create severity upgrade event for incident %s: %v", incident.ID, err)
+			}
+			s.notifyWatchers(incident.ID, db.IncidentEventUpdated, "", true)
+
+			upgrades = append(upgrades, db.SeverityUpgrade{
+				IncidentID:       incident.ID,
+				PreviousSeverity: rule.From,
+				Severity:         rule.To,
+				Priority:         priority,
+			})
+		}
+	}
+	return upgrades, nil
+}
+
+// severityUpgradeCandidates lists open incidents at the rule's severity older than its threshold
+func (s *IncidentService) severityUpgradeCandidates(rule SeverityUpgradeRule) ([]db.Incident, error) {
+	rows, err := s.PG.Query(`
+		SELECT id, COALESCE(urgency, ''), COALESCE(organization_id::text, '')
+		FROM incidents
+		WHERE status IN ($1, $2)
+		  AND archived_at IS NULL
+		  AND severity = $3
+		  AND created_at < (`+SQLNowUTC+`) - make_interval(mins => $4)
+		ORDER BY created_at ASC
+	`, db.IncidentStatusTriggered, db.IncidentStatusAcknowledged, rule.From, rule.AfterMinutes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incidents for severity upgrade: %w", err)
+	}
+	defer rows.Close()
+
+	var incidents []db.Incident
+	for rows.Next() {
+		var incident db.Incident
+		if err := rows.Scan(&incident.ID, &incident.Urgency, &incident.OrganizationID); err != nil {
+			return nil, fmt.Errorf("failed to scan incident: %w", err)
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, rows.Err()
+}
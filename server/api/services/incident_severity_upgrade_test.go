@@ -0,0 +1,82 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSeverityUpgradeRules(t *testing.T) {
+	rules, err := ParseSeverityUpgradeRules(" warning:high:240, high:critical:60 ")
+	assert.NoError(t, err)
+	assert.Equal(t, []SeverityUpgradeRule{
+		{From: "warning", To: "high", AfterMinutes: 240},
+		{From: "high", To: "critical", AfterMinutes: 60},
+	}, rules)
+
+	rules, err = ParseSeverityUpgradeRules("")
+	assert.NoError(t, err)
+	assert.Empty(t, rules)
+
+	for _, spec := range []string{"warning:high", "warning:high:0", "warning:high:soon", "warning:warning:10", "warning:high:10,warning:critical:20"} {
+		_, err := ParseSeverityUpgradeRules(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestAutoUpgradeSeverities(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`SELECT id, COALESCE\(urgency, ''\), COALESCE\(organization_id::text, ''\)\s+FROM incidents\s+WHERE status IN \(\$1, \$2\)\s+AND archived_at IS NULL\s+AND severity = \$3\s+AND created_at < \(`+sqlNowUTCPattern+`\) - make_interval\(mins => \$4\)`).
+		WithArgs(db.IncidentStatusTriggered, db.IncidentStatusAcknowledged, "warning", 240).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "urgency", "organization_id"}).AddRow("inc-1", "high", ""))
+	mockDB.ExpectExec(`UPDATE incidents\s+SET severity = \$1, priority = COALESCE\(NULLIF\(\$2, ''\), priority\), updated_at = NOW\(\) AT TIME ZONE 'UTC'\s+WHERE id = \$3 AND severity = \$4 AND status != \$5`).
+		WithArgs("high", "", "inc-1", "warning", db.IncidentStatusResolved).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", db.IncidentEventUpdated, `{"after_minutes":240,"previous_severity":"warning","reason":"severity_auto_upgrade","severity":"high"}`, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	upgrades, err := service.AutoUpgradeSeverities([]SeverityUpgradeRule{{From: "warning", To: "high", AfterMinutes: 240}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []db.SeverityUpgrade{{IncidentID: "inc-1", PreviousSeverity: "warning", Severity: "high"}}, upgrades)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestAutoUpgradeSeverities_RecomputesPriority(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery("FROM incidents").
+		WithArgs(db.IncidentStatusTriggered, db.IncidentStatusAcknowledged, "warning", 30).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "urgency", "organization_id"}).
+			AddRow("inc-1", "high", "").
+			AddRow("inc-2", "low", ""))
+	mockDB.ExpectExec("UPDATE incidents").
+		WithArgs("critical", "P1", "inc-1", "warning", db.IncidentStatusResolved).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// inc-2 was resolved or changed since it was listed
+	mockDB.ExpectExec("UPDATE incidents").
+		WithArgs("critical", "P2", "inc-2", "warning", db.IncidentStatusResolved).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	service := NewIncidentService(pg, nil, nil)
+	upgrades, err := service.AutoUpgradeSeverities([]SeverityUpgradeRule{{From: "warning", To: "critical", AfterMinutes: 30}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []db.SeverityUpgrade{{IncidentID: "inc-1", PreviousSeverity: "warning", Severity: "critical", Priority: "P1"}}, upgrades)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
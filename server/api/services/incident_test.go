@@ -0,0 +1,1905 @@
+package services
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// stringArrayArg matches a pq.Array argument against the expected values
+type stringArrayArg []string
+
+func (a stringArrayArg) Match(v driver.Value) bool {
+	expected, _ := pq.Array([]string(a)).Value()
+	return v == expected
+}
+
+func TestParseStatusFilter(t *testing.T) {
+	assert.Nil(t, parseStatusFilter(nil))
+	assert.Empty(t, parseStatusFilter(""))
+	assert.Equal(t, []string{"triggered"}, parseStatusFilter("triggered"))
+	assert.Equal(t, []string{"triggered", "acknowledged"}, parseStatusFilter("triggered,acknowledged"))
+	assert.Equal(t, []string{"triggered", "acknowledged"}, parseStatusFilter(" triggered , acknowledged ,"))
+	assert.Equal(t, []string{"triggered", "acknowledged"}, parseStatusFilter([]string{"triggered", "acknowledged"}))
+}
+
+func TestListIncidents_StatusFilter(t *testing.T) {
+	t.Run("SingleStatus", func(t *testing.T) {
+		pg, mockDB, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer pg.Close()
+
+		mockDB.ExpectQuery(`AND i\.status = \$3 `).
+			WithArgs("user-1", "org-1", "triggered", 20, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		service := NewIncidentService(pg, nil, nil)
+		_, err = service.ListIncidents(map[string]interface{}{
+			"current_user_id": "user-1",
+			"current_org_id":  "org-1",
+			"status":          "triggered",
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("CommaSeparatedStatuses", func(t *testing.T) {
+		pg, mockDB, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer pg.Close()
+
+		mockDB.ExpectQuery(`AND i\.status = ANY\(\$3\)`).
+			WithArgs("user-1", "org-1", stringArrayArg{"triggered", "acknowledged"}, 50, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		service := NewIncidentService(pg, nil, nil)
+		_, err = service.ListIncidents(map[string]interface{}{
+			"current_user_id": "user-1",
+			"current_org_id":  "org-1",
+			"status":          "triggered,acknowledged",
+			"limit":           50,
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+}
+
+func TestBulkUpdateStatus(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery(`SELECT i\.id, i\.status\s+FROM incidents i\s+WHERE i\.id::text = ANY\(\$3\)\s+AND i\.organization_id = \$2`).
+		WithArgs("user-1", "org-1", stringArrayArg{"inc-1", "inc-2", "inc-3"}).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).
+			AddRow("inc-1", "triggered").
+			AddRow("inc-3", "acknowledged"))
+	mockDB.ExpectExec("SAVEPOINT bulk_incident").WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectExec("UPDATE incidents").
+		WithArgs("acknowledged", "user-1", "inc-1", "triggered", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEscalationsStopped(mockDB, "inc-1", "user-1", db.AlertEscalationStatusAcknowledged)
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "acknowledged", `{"note":"storm"}`, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("RELEASE SAVEPOINT bulk_incident").WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectCommit()
+
+	service := NewIncidentService(pg, nil, nil)
+	result, err := service.BulkUpdateStatus([]string{"inc-1", "inc-2", "inc-3", "inc-1"}, "user-1", "org-1", "acknowledge", "storm")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Succeeded)
+	assert.Equal(t, 2, result.Failed)
+	assert.Equal(t, []string{"inc-1"}, result.SucceededIDs)
+	assert.Equal(t, "incident not found", result.Errors["inc-2"])
+	assert.Contains(t, result.Errors["inc-3"], "cannot acknowledge")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestBulkUpdateStatus_EventInsertFails(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery(`SELECT i\.id, i\.status\s+FROM incidents i`).
+		WithArgs("user-1", "org-1", stringArrayArg{"inc-1"}).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("inc-1", "triggered"))
+	mockDB.ExpectExec("SAVEPOINT bulk_incident").WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectExec("UPDATE incidents").
+		WithArgs("resolved", "user-1", "inc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEscalationsStopped(mockDB, "inc-1", "user-1", db.AlertEscalationStatusStopped)
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WillReturnError(fmt.Errorf("connection reset"))
+	// The incident's resolve is rolled back with its event
+	mockDB.ExpectExec("ROLLBACK TO SAVEPOINT bulk_incident").WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectCommit()
+
+	result, err := NewIncidentService(pg, nil, nil).BulkUpdateStatus([]string{"inc-1"}, "user-1", "org-1", "resolve", "")
+
+	assert.NoError(t, err)
+	assert.Empty(t, result.SucceededIDs)
+	assert.Contains(t, result.Errors["inc-1"], "failed to record resolved event")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestBulkUpdateStatus_InvalidAction(t *testing.T) {
+	service := NewIncidentService(nil, nil, nil)
+	_, err := service.BulkUpdateStatus([]string{"inc-1"}, "user-1", "org-1", "snooze", "")
+	assert.Error(t, err)
+}
+
+func TestDiffLabels(t *testing.T) {
+	fired := map[string]interface{}{
+		"alertname":   "HighCPU",
+		"severity":    "critical",
+		"instance":    "web-1",
+		"fingerprint": "abc",
+	}
+	resolved := map[string]interface{}{
+		"alertname": "HighCPU",
+		"severity":  "warning",
+		"pod":       "web-1-7f9",
+	}
+
+	diff := DiffLabels(fired, resolved, "fingerprint")
+
+	assert.Equal(t, map[string]db.IncidentLabelChange{"severity": {From: "critical", To: "warning"}}, diff.Changed)
+	assert.Equal(t, map[string]interface{}{"instance": "web-1"}, diff.Removed)
+	assert.Equal(t, map[string]interface{}{"pod": "web-1-7f9"}, diff.Added)
+	assert.False(t, diff.IsEmpty())
+
+	assert.True(t, DiffLabels(fired, fired).IsEmpty())
+}
+
+func TestResolveIncidentWithLabelDiff(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	diff := DiffLabels(
+		map[string]interface{}{"alertname": "HighCPU", "severity": "critical"},
+		map[string]interface{}{"alertname": "HighCPU", "severity": "warning"},
+	)
+
+	mockDB.ExpectExec("UPDATE incidents").
+		WithArgs("resolved", "user-1", "inc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEscalationsStopped(mockDB, "inc-1", "user-1", db.AlertEscalationStatusStopped)
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "resolved",
+			`{"label_diff":{"changed":{"severity":{"from":"critical","to":"warning"}}},"note":"Alert resolved automatically"}`,
+			"user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	err = service.ResolveIncidentWithLabelDiff("inc-1", "user-1", "Alert resolved automatically", "", diff)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestResolveIncident_DoubleResolveSingleEvent(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec("UPDATE incidents").
+		WithArgs("resolved", "user-1", "inc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEscalationsStopped(mockDB, "inc-1", "user-1", db.AlertEscalationStatusStopped)
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "resolved", sqlmock.AnyArg(), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Second resolve matches no rows: no event, resolved_by stays user-1
+	mockDB.ExpectExec("UPDATE incidents").
+		WithArgs("resolved", "user-2", "inc-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	service := NewIncidentService(pg, nil, nil)
+
+	assert.NoError(t, service.ResolveIncident("inc-1", "user-1", "", ""))
+	err = service.ResolveIncident("inc-1", "user-2", "", "")
+	assert.True(t, errors.Is(err, ErrAlreadyResolved))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestMergeIncidents(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery(`SELECT id, COALESCE\(organization_id::text, ''\), alert_count, labels\s+FROM incidents`).
+		WithArgs(stringArrayArg{"inc-1", "inc-2"}).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "organization_id", "alert_count", "labels"}).
+			AddRow("inc-1", "org-1", 3, `{"alertname":"HighCPU","instance":"web-1"}`).
+			AddRow("inc-2", "org-1", 2, `{"alertname":"HighCPU","instance":"web-2","pod":"web-2-abc"}`))
+	// The secondary is resolved like any other resolve: escalation stopped, snooze cleared
+	mockDB.ExpectExec(`UPDATE incidents\s+SET status = \$1, resolved_by = \$2::uuid.*escalation_status = CASE.*snoozed_until = NULL, snoozed_escalation_status = NULL\s+WHERE id = \$3 AND status != \$1`).
+		WithArgs("resolved", "user-1", "inc-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEscalationsStopped(mockDB, "inc-2", "user-1", db.AlertEscalationStatusStopped)
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-2", "resolved", `{"note":"merged into inc-1"}`, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`UPDATE incidents\s+SET labels = \$1, updated_at = .*\s+WHERE id = \$2`).
+		WithArgs(`{"alertname":"HighCPU","instance":"web-2","merged_into":"inc-1","pod":"web-2-abc"}`, "inc-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-2", "merged", `{"merged_into":"inc-1","note":"merged into inc-1"}`, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Primary keeps its own instance label and status, gains the non-conflicting pod label
+	mockDB.ExpectExec("UPDATE incidents\\s+SET alert_count").
+		WithArgs(5, `{"alertname":"HighCPU","instance":"web-1","pod":"web-2-abc"}`, "inc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "merged", `{"alert_count":5,"merged_incident_ids":["inc-2"]}`, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectCommit()
+
+	service := NewIncidentService(pg, nil, nil)
+	err = service.MergeIncidents("inc-1", []string{"inc-2", "inc-2"}, "user-1")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestMergeIncidents_Validation(t *testing.T) {
+	service := NewIncidentService(nil, nil, nil)
+
+	assert.Error(t, service.MergeIncidents("inc-1", []string{"inc-1"}, "user-1"))
+	assert.Error(t, service.MergeIncidents("inc-1", nil, "user-1"))
+}
+
+func TestMergeIncidents_DifferentOrganization(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery("SELECT id, COALESCE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "organization_id", "alert_count", "labels"}).
+			AddRow("inc-1", "org-1", 1, nil).
+			AddRow("inc-2", "org-2", 1, nil))
+	mockDB.ExpectRollback()
+
+	service := NewIncidentService(pg, nil, nil)
+	err = service.MergeIncidents("inc-1", []string{"inc-2"}, "user-1")
+
+	assert.ErrorContains(t, err, "different organization")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCreateIncident_AutoAssignmentDisabled(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`SELECT settings->'feature_flags'`).
+		WithArgs("org-1").
+		WillReturnRows(sqlmock.NewRows([]string{"feature_flags"}).AddRow(`{"auto_assignment": false}`))
+
+	// assigned_to is the 7th column and must be NULL
+	insertArgs := make([]driver.Value, 28)
+	for i := range insertArgs {
+		insertArgs[i] = sqlmock.AnyArg()
+	}
+	insertArgs[6] = nil
+	mockDB.ExpectExec("INSERT INTO incidents").
+		WithArgs(insertArgs...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs(sqlmock.AnyArg(), "triggered", sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Assignee picked by the escalation policy before creation
+	now := time.Now()
+	service := NewIncidentService(pg, nil, nil)
+	incident, err := service.CreateIncident(&db.Incident{
+		Title:            "Disk full",
+		OrganizationID:   "org-1",
+		AssignedTo:       "user-1",
+		AssignedAt:       &now,
+		AssignmentMethod: db.AssignmentMethodEscalationPolicy,
+	})
+
+	assert.NoError(t, err)
+	assert.Empty(t, incident.AssignedTo)
+	assert.Nil(t, incident.AssignedAt)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func expectNoFeatureFlags(mockDB sqlmock.Sqlmock) {
+	mockDB.ExpectQuery(`SELECT settings->'feature_flags'`).
+		WithArgs("org-1").
+		WillReturnRows(sqlmock.NewRows([]string{"feature_flags"}).AddRow(nil))
+}
+
+func expectOnCallUser(mockDB sqlmock.Sqlmock, userID string) {
+	mockDB.ExpectQuery(`FROM effective_shifts es\s+JOIN users u`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "phone", "role", "team", "fcm_token", "is_active", "created_at", "updated_at"}).
+			AddRow(userID, "Carol", "carol@example.com", "", "engineer", "", "", true, time.Now(), time.Now()))
+}
+
+func TestApplyAutoAssignment_Precedence(t *testing.T) {
+	newPolicyIncident := func() *db.Incident {
+		return &db.Incident{OrganizationID: "org-1", EscalationPolicyID: "policy-1", GroupID: "group-1"}
+	}
+
+	t.Run("Explicit", func(t *testing.T) {
+		pg, mockDB, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer pg.Close()
+
+		// Feature flags, the escalation policy and on-call aren't consulted
+		incident := newPolicyIncident()
+		incident.AssignedTo = "user-1"
+		NewIncidentService(pg, nil, nil).applyAutoAssignment(incident)
+
+		assert.Equal(t, "user-1", incident.AssignedTo)
+		assert.Equal(t, db.AssignmentMethodExplicit, incident.AssignmentMethod)
+		assert.NotNil(t, incident.AssignedAt)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("EscalationPolicy", func(t *testing.T) {
+		pg, mockDB, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer pg.Close()
+
+		expectNoFeatureFlags(mockDB)
+		mockDB.ExpectQuery(`SELECT target_type, target_id\s+FROM escalation_levels\s+WHERE policy_id = \$1 AND level_number = 1`).
+			WithArgs("policy-1").
+			WillReturnRows(sqlmock.NewRows([]string{"target_type", "target_id"}).AddRow("user", "user-2"))
+
+		incident := newPolicyIncident()
+		NewIncidentService(pg, nil, nil).applyAutoAssignment(incident)
+
+		assert.Equal(t, "user-2", incident.AssignedTo)
+		assert.Equal(t, db.AssignmentMethodEscalationPolicy, incident.AssignmentMethod)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("PreResolvedEscalationPolicy", func(t *testing.T) {
+		pg, mockDB, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer pg.Close()
+
+		// The webhook resolved the level-1 assignee already; it isn't looked up again
+		expectNoFeatureFlags(mockDB)
+
+		incident := newPolicyIncident()
+		incident.AssignedTo = "user-2"
+		incident.AssignmentMethod = db.AssignmentMethodEscalationPolicy
+		NewIncidentService(pg, nil, nil).applyAutoAssignment(incident)
+
+		assert.Equal(t, "user-2", incident.AssignedTo)
+		assert.Equal(t, db.AssignmentMethodEscalationPolicy, incident.AssignmentMethod)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("OnCallFallback", func(t *testing.T) {
+		pg, mockDB, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer pg.Close()
+
+		expectNoFeatureFlags(mockDB)
+		mockDB.ExpectQuery(`FROM escalation_levels`).
+			WithArgs("policy-1").
+			WillReturnError(sql.ErrNoRows)
+		expectOnCallUser(mockDB, "user-3")
+
+		incident := newPolicyIncident()
+		NewIncidentService(pg, nil, nil).applyAutoAssignment(incident)
+
+		assert.Equal(t, "user-3", incident.AssignedTo)
+		assert.Equal(t, db.AssignmentMethodOnCall, incident.AssignmentMethod)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("NobodyAvailable", func(t *testing.T) {
+		pg, mockDB, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer pg.Close()
+
+		expectNoFeatureFlags(mockDB)
+		mockDB.ExpectQuery(`FROM effective_shifts es`).WillReturnError(sql.ErrNoRows)
+
+		incident := &db.Incident{OrganizationID: "org-1"}
+		NewIncidentService(pg, nil, nil).applyAutoAssignment(incident)
+
+		assert.Empty(t, incident.AssignedTo)
+		assert.Empty(t, incident.AssignmentMethod)
+		assert.Nil(t, incident.AssignedAt)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+}
+
+func TestCreateIncident_SingleAssignmentEvent(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectNoFeatureFlags(mockDB)
+	expectOnCallUser(mockDB, "user-3")
+	mockDB.ExpectExec("INSERT INTO incidents").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs(sqlmock.AnyArg(), "triggered", sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery("SELECT COALESCE\\(name, email, 'Unknown'\\) FROM users").
+		WithArgs("user-3").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Carol"))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs(sqlmock.AnyArg(), "assigned",
+			`{"assigned_to":"Carol","assigned_to_id":"user-3","method":"auto_assignment","reason":"on_call"}`, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	service.DeployCorrelationWindow = 0
+	incident, err := service.CreateIncident(&db.Incident{Title: "Disk full", OrganizationID: "org-1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user-3", incident.AssignedTo)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestSnoozeIncident(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	until := time.Now().Add(30 * time.Minute)
+
+	mockDB.ExpectExec(`UPDATE incidents\s+SET snoozed_escalation_status = CASE`).
+		WithArgs(until.UTC(), "inc-1", "resolved").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "snoozed",
+			`{"reason":"deploy in progress","snoozed_until":"`+until.UTC().Format(time.RFC3339)+`"}`,
+			"user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	err = service.SnoozeIncident("inc-1", "user-1", until, "deploy in progress")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestSnoozeIncident_Validation(t *testing.T) {
+	t.Run("PastTime", func(t *testing.T) {
+		service := NewIncidentService(nil, nil, nil)
+		err := service.SnoozeIncident("inc-1", "user-1", time.Now().Add(-time.Minute), "")
+		assert.Error(t, err)
+	})
+
+	t.Run("ResolvedIncident", func(t *testing.T) {
+		pg, mockDB, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer pg.Close()
+
+		mockDB.ExpectExec("UPDATE incidents").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		service := NewIncidentService(pg, nil, nil)
+		err = service.SnoozeIncident("inc-1", "user-1", time.Now().Add(time.Hour), "")
+
+		assert.ErrorContains(t, err, "already resolved")
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+}
+
+func TestAcknowledgeIncident_CancelsSnooze(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec(`WHEN COALESCE\(snoozed_escalation_status, escalation_status\) IN \('none', 'pending'\) THEN 'stopped'.*snoozed_until = NULL`).
+		WithArgs("acknowledged", "user-1", "inc-1", "triggered", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEscalationsStopped(mockDB, "inc-1", "user-1", db.AlertEscalationStatusAcknowledged)
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	err = service.AcknowledgeIncident("inc-1", "user-1", "", time.Time{})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCreateIncidentAsync(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec("INSERT INTO incidents").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`SELECT pgmq.send\(\$1, \$2::jsonb\)`).
+		WithArgs(IncidentEnrichmentQueueName, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	incident, err := service.CreateIncidentAsync(&db.Incident{
+		Title:          "High CPU",
+		OrganizationID: "org-1",
+	}, IncidentEnrichmentMessage{IntegrationID: "int-1", Alert: []byte(`{"alert_name":"HighCPU"}`)})
+
+	// The fast path returns once the incident is queued for enrichment
+	assert.NoError(t, err)
+	assert.NotEmpty(t, incident.ID)
+	assert.Empty(t, incident.ServiceID)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+
+	// The enrichment worker completes it
+	mockDB.ExpectQuery(`SELECT settings->'feature_flags'`).
+		WithArgs("org-1").
+		WillReturnRows(sqlmock.NewRows([]string{"feature_flags"}).AddRow(nil))
+	mockDB.ExpectExec(`UPDATE incidents\s+SET service_id = \$1`).
+		WithArgs("svc-1", nil, nil, "user-1", "org-1", nil, nil, incident.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs(incident.ID, "triggered", sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery("SELECT COALESCE\\(name, email, 'Unknown'\\) FROM users").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Alice"))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs(incident.ID, "assigned", sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = service.EnrichIncident(incident, func(incident *db.Incident) {
+		incident.ServiceID = "svc-1"
+		incident.AssignedTo = "user-1"
+		incident.AssignmentMethod = db.AssignmentMethodEscalationPolicy
+		now := time.Now()
+		incident.AssignedAt = &now
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCreateIncidentAsync_EnqueueFails(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec("INSERT INTO incidents").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`SELECT pgmq.send`).WillReturnError(fmt.Errorf("queue missing"))
+
+	incident, err := NewIncidentService(pg, nil, nil).CreateIncidentAsync(&db.Incident{Title: "High CPU"}, IncidentEnrichmentMessage{})
+
+	assert.ErrorIs(t, err, ErrEnrichmentNotQueued)
+	assert.NotNil(t, incident, "the inserted incident is returned so the caller can enrich it")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCreateIncidentAsync_DerivesPriorityBeforeInsert(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expected := DefaultPriorityMatrix.Lookup("critical", db.IncidentUrgencyHigh)
+	mockDB.ExpectExec("INSERT INTO incidents").
+		WithArgs(sqlmock.AnyArg(), "Database down", sqlmock.AnyArg(), db.IncidentStatusTriggered, db.IncidentUrgencyHigh, expected,
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`SELECT pgmq.send`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	incident, err := NewIncidentService(pg, nil, nil).CreateIncidentAsync(&db.Incident{
+		Title:    "Database down",
+		Severity: "critical",
+	}, IncidentEnrichmentMessage{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, incident.Priority)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestReopenRecentlyResolvedIncident_Flapping(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// The alert resolves...
+	mockDB.ExpectExec("UPDATE incidents").
+		WithArgs("resolved", "user-1", "inc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEscalationsStopped(mockDB, "inc-1", "user-1", db.AlertEscalationStatusStopped)
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "resolved", sqlmock.AnyArg(), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// ...and fires again within the window, re-opening the same incident (only ever one of
+	// the integration's organization)
+	mockDB.ExpectQuery(`UPDATE incidents\s+SET status = \$1,\s+alert_count = alert_count \+ 1.*AND COALESCE\(organization_id::text, ''\) = \$5`).
+		WithArgs("triggered", "fp-1", "resolved", float64(600), "org-1", "int-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "assigned_to", "alert_count"}).AddRow("inc-1", nil, 2))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "reopened",
+			`{"alert_count":2,"fingerprint":"fp-1","note":"alert fired again within dedup window"}`,
+			nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	assert.NoError(t, service.ResolveIncident("inc-1", "user-1", "Alert resolved automatically", ""))
+
+	reopenedID, err := service.ReopenRecentlyResolvedIncident("fp-1", DedupScope{OrganizationID: "org-1", IntegrationID: "int-1"}, 10*time.Minute)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "inc-1", reopenedID)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestReopenRecentlyResolvedIncident_OutsideWindow(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery("UPDATE incidents").
+		WithArgs("triggered", "fp-1", "resolved", float64(600), "org-1", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "assigned_to", "alert_count"}))
+
+	service := NewIncidentService(pg, nil, nil)
+
+	reopenedID, err := service.ReopenRecentlyResolvedIncident("fp-1", DedupScope{OrganizationID: "org-1"}, 10*time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, reopenedID)
+
+	// A zero window disables re-opening without touching the database
+	reopenedID, err = service.ReopenRecentlyResolvedIncident("fp-1", DedupScope{OrganizationID: "org-1"}, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, reopenedID)
+
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestReopenIncident(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`UPDATE incidents\s+SET status = \$1,\s+acknowledged_by = NULL`).
+		WithArgs("triggered", "inc-1", "resolved").
+		WillReturnRows(sqlmock.NewRows([]string{"assigned_to", "current_escalation_level"}).AddRow("oncall-1", 2))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "reopened", `{"escalation_level":2,"reason":"still failing"}`, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery(`SELECT COALESCE\(assigned_to::text, ''\) FROM incidents`).
+		WithArgs("inc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"assigned_to"}).AddRow("oncall-1"))
+	mockDB.ExpectQuery(`FROM incident_watchers`).
+		WithArgs("inc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "name", "email", "created_at"}).
+			AddRow("user-2", "Watcher", "w@example.com", time.Now()))
+
+	notifier := &assignedNotifier{
+		recordingNotifier: recordingNotifier{done: make(chan struct{}), expect: 1},
+		assigned:          make(chan string, 1),
+	}
+	service := NewIncidentService(pg, nil, nil)
+	service.SetNotificationWorker(notifier)
+
+	assert.NoError(t, service.ReopenIncident("inc-1", "user-1", "still failing"))
+	assert.Equal(t, "oncall-1", <-notifier.assigned)
+
+	select {
+	case <-notifier.done:
+	case <-time.After(time.Second):
+		t.Fatal("watcher notification was not sent")
+	}
+	assert.Equal(t, []string{"user-2:reopened"}, notifier.watched)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestReopenIncident_NotResolved(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery("UPDATE incidents").
+		WithArgs("triggered", "inc-1", "resolved").
+		WillReturnRows(sqlmock.NewRows([]string{"assigned_to", "current_escalation_level"}))
+
+	service := NewIncidentService(pg, nil, nil)
+	err = service.ReopenIncident("inc-1", "user-1", "")
+
+	assert.True(t, errors.Is(err, ErrIncidentNotResolved))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestReopenIncident_KeyHeldByOpenIncident(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// A new alert with the same key opened inc-2 while inc-1 was resolved
+	mockDB.ExpectQuery("UPDATE incidents").
+		WithArgs("triggered", "inc-1", "resolved").
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_incidents_org_open_incident_key"})
+	mockDB.ExpectQuery(`SELECT o.id\s+FROM incidents r\s+JOIN incidents o ON o.organization_id = r.organization_id AND o.incident_key = r.incident_key`).
+		WithArgs("inc-1", "triggered", "acknowledged").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("inc-2"))
+
+	service := NewIncidentService(pg, nil, nil)
+	err = service.ReopenIncident("inc-1", "user-1", "")
+
+	assert.ErrorIs(t, err, ErrIncidentKeyInUse)
+	assert.EqualError(t, err, "incident key is held by another open incident: inc-2")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestArchiveIncident(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`SELECT status, archived_at IS NOT NULL FROM incidents WHERE id = \$1`).
+		WithArgs("inc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "archived"}).AddRow("resolved", false))
+	mockDB.ExpectExec(`UPDATE incidents\s+SET archived_at = NOW\(\)\s+WHERE id = \$1 AND status = \$2 AND archived_at IS NULL`).
+		WithArgs("inc-1", "resolved").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "archived", `{}`, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	err = service.ArchiveIncident("inc-1", "user-1")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestArchiveIncident_Rejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		archived bool
+		want     error
+	}{
+		{"Open", "acknowledged", false, ErrIncidentNotResolved},
+		{"AlreadyArchived", "resolved", true, ErrIncidentArchived},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pg, mockDB, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer pg.Close()
+
+			mockDB.ExpectQuery("SELECT status, archived_at IS NOT NULL").
+				WithArgs("inc-1").
+				WillReturnRows(sqlmock.NewRows([]string{"status", "archived"}).AddRow(tt.status, tt.archived))
+
+			service := NewIncidentService(pg, nil, nil)
+			err = service.ArchiveIncident("inc-1", "user-1")
+
+			// Nothing is updated
+			assert.ErrorIs(t, err, tt.want)
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestArchiveResolvedIncidents(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`UPDATE incidents\s+SET archived_at = NOW\(\)\s+WHERE status = \$1\s+AND archived_at IS NULL\s+AND resolved_at < \(`+sqlNowUTCPattern+`\) - make_interval\(days => \$2\)\s+RETURNING id`).
+		WithArgs("resolved", 90).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("inc-1").AddRow("inc-2"))
+	for _, id := range []string{"inc-1", "inc-2"} {
+		mockDB.ExpectExec("INSERT INTO incident_events").
+			WithArgs(id, "archived", `{"reason":"auto_archive","resolved_before":"90 days"}`, nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	service := NewIncidentService(pg, nil, nil)
+	archived, err := service.ArchiveResolvedIncidents(90)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, archived)
+
+	// Disabled: nothing is queried
+	archived, err = service.ArchiveResolvedIncidents(0)
+	assert.NoError(t, err)
+	assert.Zero(t, archived)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestListIncidents_Archived(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Archived incidents are excluded by default
+	mockDB.ExpectQuery(`AND i\.archived_at IS NULL AND i\.status = \$3 ORDER BY`).
+		WithArgs("user-1", "org-1", "resolved", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	// and listed with include_archived
+	mockDB.ExpectQuery(`\)\s+AND i\.status = \$3 ORDER BY`).
+		WithArgs("user-1", "org-1", "resolved", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.ListIncidents(map[string]interface{}{
+		"current_user_id": "user-1",
+		"current_org_id":  "org-1",
+		"status":          "resolved",
+	})
+	assert.NoError(t, err)
+
+	_, err = service.ListIncidents(map[string]interface{}{
+		"current_user_id":  "user-1",
+		"current_org_id":   "org-1",
+		"status":           "resolved",
+		"include_archived": true,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestListIncidents_OpenOnlyByDefault(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Without a status filter resolved incidents are left out
+	mockDB.ExpectQuery(`AND i\.archived_at IS NULL AND i\.status = ANY\(\$3\) ORDER BY`).
+		WithArgs("user-1", "org-1", stringArrayArg{"triggered", "acknowledged", "external_pending"}, 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	// include_resolved lists every status
+	mockDB.ExpectQuery(`AND i\.archived_at IS NULL ORDER BY`).
+		WithArgs("user-1", "org-1", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	// and an explicit status filter wins over the default
+	mockDB.ExpectQuery(`AND i\.archived_at IS NULL AND i\.status = \$3 ORDER BY`).
+		WithArgs("user-1", "org-1", "resolved", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.ListIncidents(map[string]interface{}{
+		"current_user_id": "user-1",
+		"current_org_id":  "org-1",
+	})
+	assert.NoError(t, err)
+
+	_, err = service.ListIncidents(map[string]interface{}{
+		"current_user_id":  "user-1",
+		"current_org_id":   "org-1",
+		"include_resolved": true,
+	})
+	assert.NoError(t, err)
+
+	_, err = service.ListIncidents(map[string]interface{}{
+		"current_user_id": "user-1",
+		"current_org_id":  "org-1",
+		"status":          "resolved",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestListIncidentsWithCount(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	filterArgs := []driver.Value{"user-1", "org-1", "disk", "%disk%", "%disk%", "triggered", "high"}
+
+	mockDB.ExpectQuery(`SELECT\s+i\.id, i\.title.*AND i\.status = \$6 AND i\.urgency = \$7 ORDER BY ts_rank.* LIMIT \$8 OFFSET \$9`).
+		WithArgs(append(filterArgs, 10, 10)...).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mockDB.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM incidents i.*plainto_tsquery\('english', \$3\).*AND i\.status = \$6 AND i\.urgency = \$7$`).
+		WithArgs(filterArgs...).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(23))
+
+	service := NewIncidentService(pg, nil, nil)
+	_, total, err := service.ListIncidentsWithCount(map[string]interface{}{
+		"current_user_id": "user-1",
+		"current_org_id":  "org-1",
+		"search":          "disk",
+		"status":          "triggered",
+		"urgency":         "high",
+		"limit":           10,
+		"page":            2,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 23, total)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestListIncidentsWithCount_NoOrgContext(t *testing.T) {
+	service := NewIncidentService(nil, nil, nil)
+	incidents, total, err := service.ListIncidentsWithCount(map[string]interface{}{
+		"current_user_id": "user-1",
+	})
+
+	assert.NoError(t, err)
+	assert.Empty(t, incidents)
+	assert.Zero(t, total)
+}
+
+func TestListIncidents_SearchNotes(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`OR i\.description ILIKE \$5 OR EXISTS \(\s+SELECT 1 FROM incident_events ne\s+WHERE ne\.incident_id = i\.id\s+AND ne\.event_type = 'note_added'\s+AND ne\.event_data->>'note' ILIKE \$4\s+\)\) ORDER BY ts_rank\(i\.search_vector, plainto_tsquery\('english', \$3\)\) DESC`).
+		WithArgs("user-1", "org-1", "failover", "%failover%", "%failover%", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.ListIncidents(map[string]interface{}{
+		"current_user_id":  "user-1",
+		"current_org_id":   "org-1",
+		"search":           "failover",
+		"search_notes":     true,
+		"include_resolved": true,
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestListIncidents_SearchWithoutNotes(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`OR i\.description ILIKE \$5\) ORDER BY ts_rank`).
+		WithArgs("user-1", "org-1", "failover", "%failover%", "%failover%", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.ListIncidents(map[string]interface{}{
+		"current_user_id":  "user-1",
+		"current_org_id":   "org-1",
+		"search":           "failover",
+		"search_notes":     false,
+		"include_resolved": true,
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestListIncidents_AssignedToEmail(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	now := time.Now()
+	mockDB.ExpectQuery(`LEFT JOIN users u_assigned ON i\.assigned_to = u_assigned\.id.*AND LOWER\(u_assigned\.email\) = LOWER\(\$3\) ORDER BY`).
+		WithArgs("user-1", "org-1", "Alice@Example.com", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "title", "description", "status", "urgency", "priority",
+			"created_at", "updated_at", "assigned_to", "assigned_at",
+			"acknowledged_by", "acknowledged_at", "resolved_by", "resolved_at",
+			"source", "integration_id", "service_id", "external_id", "external_url",
+			"escalation_policy_id", "current_escalation_level", "last_escalated_at",
+			"escalation_status", "group_id", "api_key_id", "severity", "incident_key",
+			"alert_count", "labels", "custom_fields", "archived_at",
+			"assigned_to_name", "assigned_to_email",
+			"acknowledged_by_name", "acknowledged_by_email",
+			"resolved_by_name", "resolved_by_email",
+			"group_name", "service_name", "escalation_policy_name",
+		}).AddRow(
+			"inc-1", "Disk full", "", "triggered", "high", "P1",
+			now, now, "user-2", now,
+			nil, nil, nil, nil,
+			"webhook", nil, nil, nil, nil,
+			nil, 0, nil,
+			"none", nil, nil, "critical", nil,
+			1, nil, nil, nil,
+			"Alice", "alice@example.com",
+			nil, nil, nil, nil, nil, nil, nil,
+		))
+
+	service := NewIncidentService(pg, nil, nil)
+	incidents, err := service.ListIncidents(map[string]interface{}{
+		"current_user_id":   "user-1",
+		"current_org_id":    "org-1",
+		"assigned_to_email": " Alice@Example.com ",
+		"include_resolved":  true,
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, incidents, 1)
+	assert.Equal(t, "user-2", incidents[0].AssignedTo)
+	assert.Equal(t, "alice@example.com", incidents[0].AssignedToEmail)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestListIncidents_SortBySLADue(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Unacknowledged incidents are due at their response SLA, acknowledged ones at their
+	// resolution SLA; the earliest due time (a breach) comes first and no SLA comes last
+	rows := sqlmock.NewRows([]string{
+		"id", "title", "description", "status", "urgency", "priority",
+		"created_at", "updated_at", "assigned_to", "assigned_at",
+		"acknowledged_by", "acknowledged_at", "resolved_by", "resolved_at",
+		"source", "integration_id", "service_id", "external_id", "external_url",
+		"escalation_policy_id", "current_escalation_level", "last_escalated_at",
+		"escalation_status", "group_id", "api_key_id", "severity", "incident_key",
+		"alert_count", "labels", "custom_fields", "archived_at",
+		"assigned_to_name", "assigned_to_email",
+		"acknowledged_by_name", "acknowledged_by_email",
+		"resolved_by_name", "resolved_by_email",
+		"group_name", "service_name", "escalation_policy_name",
+	})
+	now := time.Now()
+	for _, id := range []string{"inc-breached", "inc-due-soon", "inc-no-sla"} {
+		rows.AddRow(
+			id, id, "", "triggered", "high", "P1",
+			now, now, nil, nil,
+			nil, nil, nil, nil,
+			"webhook", nil, nil, nil, nil,
+			nil, 0, nil,
+			"none", nil, nil, "critical", nil,
+			1, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		)
+	}
+	mockDB.ExpectQuery(`ORDER BY CASE\s+WHEN i\.status = 'resolved' THEN NULL\s+`+
+		`WHEN i\.acknowledged_at IS NULL AND COALESCE\(i\.response_sla_minutes, s\.response_sla_minutes\) IS NOT NULL\s+`+
+		`THEN i\.created_at \+ COALESCE\(i\.response_sla_minutes, s\.response_sla_minutes\) \* INTERVAL '1 minute'\s+`+
+		`ELSE i\.created_at \+ COALESCE\(i\.resolution_sla_minutes, s\.resolution_sla_minutes\) \* INTERVAL '1 minute'\s+`+
+		`END ASC NULLS LAST, i\.created_at DESC LIMIT \$3 OFFSET \$4$`).
+		WithArgs("user-1", "org-1", 20, 0).
+		WillReturnRows(rows)
+
+	service := NewIncidentService(pg, nil, nil)
+	incidents, err := service.ListIncidents(map[string]interface{}{
+		"current_user_id":  "user-1",
+		"current_org_id":   "org-1",
+		"include_resolved": true,
+		"sort":             "sla_due_asc",
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, incidents, 3) {
+		assert.Equal(t, "inc-breached", incidents[0].ID)
+		assert.Equal(t, "inc-no-sla", incidents[2].ID)
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCountIncidents_AssignedToEmail(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Combined with assigned_to both conditions apply
+	mockDB.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM incidents i.*AND i\.assigned_to = \$3::uuid AND LOWER\(u_assigned\.email\) = LOWER\(\$4\)$`).
+		WithArgs("user-1", "org-1", "user-2", "alice@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	service := NewIncidentService(pg, nil, nil)
+	total, err := service.CountIncidents(map[string]interface{}{
+		"current_user_id":   "user-1",
+		"current_org_id":    "org-1",
+		"assigned_to":       "user-2",
+		"assigned_to_email": "alice@example.com",
+		"include_resolved":  true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestListIncidents_LabelAndCustomFieldFilters(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`AND i\.status = \$3 AND i\.labels @> \$4::jsonb AND i\.custom_fields @> \$5::jsonb ORDER BY .* LIMIT \$6 OFFSET \$7`).
+		WithArgs("user-1", "org-1", "triggered", `{"team":"payments"}`, `{"region":"eu","tier":1}`, 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.ListIncidents(map[string]interface{}{
+		"current_user_id": "user-1",
+		"current_org_id":  "org-1",
+		"status":          "triggered",
+		"labels":          map[string]string{"team": "payments"},
+		"custom_fields":   map[string]interface{}{"region": "eu", "tier": 1},
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestJSONContainmentFilter(t *testing.T) {
+	_, ok := jsonContainmentFilter(nil)
+	assert.False(t, ok)
+	_, ok = jsonContainmentFilter(map[string]string{})
+	assert.False(t, ok)
+
+	encoded, ok := jsonContainmentFilter(map[string]string{"team": "payments"})
+	assert.True(t, ok)
+	assert.Equal(t, `{"team":"payments"}`, encoded)
+}
+
+func TestIncidentCursor_RoundTrip(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 12, 30, 45, 123456000, time.UTC)
+	id := "0b4c1e2a-5d6f-4a8b-9c0d-1e2f3a4b5c6d"
+
+	gotCreatedAt, gotID, err := DecodeIncidentCursor(EncodeIncidentCursor(createdAt, id))
+
+	assert.NoError(t, err)
+	assert.True(t, createdAt.Equal(gotCreatedAt))
+	assert.Equal(t, id, gotID)
+}
+
+func TestIncidentCursor_Invalid(t *testing.T) {
+	for _, cursor := range []string{
+		"not base64!",
+		EncodeIncidentCursor(time.Now(), ""),
+		"bm8tc2VwYXJhdG9y", // "no-separator"
+		EncodeIncidentCursor(time.Now(), "not-a-uuid"),
+	} {
+		_, _, err := DecodeIncidentCursor(cursor)
+		assert.Error(t, err, "cursor %q", cursor)
+	}
+}
+
+func TestListIncidents_Cursor(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Two incidents share the same created_at; the id tie-breaker keeps the next page exact
+	createdAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cursor := EncodeIncidentCursor(createdAt, "00000000-0000-0000-0000-00000000000b")
+
+	mockDB.ExpectQuery(`AND i\.status = \$3 AND \(i\.created_at, i\.id\) < \(\$4::timestamptz, \$5::uuid\) ORDER BY i\.created_at DESC, i\.id DESC LIMIT \$6$`).
+		WithArgs("user-1", "org-1", "triggered", createdAt, "00000000-0000-0000-0000-00000000000b", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.ListIncidents(map[string]interface{}{
+		"current_user_id": "user-1",
+		"current_org_id":  "org-1",
+		"status":          "triggered",
+		"sort":            "urgency_desc",
+		"page":            5,
+		"limit":           2,
+		"cursor":          cursor,
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestNextIncidentCursor(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	incidents := []db.IncidentResponse{}
+	incidents = append(incidents, db.IncidentResponse{}, db.IncidentResponse{})
+	incidents[0].ID, incidents[0].CreatedAt = "00000000-0000-0000-0000-00000000000c", createdAt
+	incidents[1].ID, incidents[1].CreatedAt = "00000000-0000-0000-0000-00000000000b", createdAt
+
+	// Partial page: nothing left to fetch
+	assert.Empty(t, NextIncidentCursor(incidents, 3))
+
+	// Full page: cursor points at the last row, even when created_at ties
+	next := NextIncidentCursor(incidents, 2)
+	gotCreatedAt, gotID, err := DecodeIncidentCursor(next)
+	assert.NoError(t, err)
+	assert.True(t, createdAt.Equal(gotCreatedAt))
+	assert.Equal(t, "00000000-0000-0000-0000-00000000000b", gotID)
+}
+
+func TestAcknowledgeIncidentByAPIKey(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec("UPDATE incidents").
+		WithArgs("acknowledged", db.SystemUserAPI, "inc-1", "triggered", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEscalationsStopped(mockDB, "inc-1", db.SystemUserAPI, db.AlertEscalationStatusAcknowledged)
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", "acknowledged",
+			`{"acknowledged_via":"api_key","api_key_id":"key-1","api_key_name":"auto-remediation","note":"restarting pod"}`,
+			db.SystemUserAPI).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	err = service.AcknowledgeIncidentByAPIKey("inc-1", &db.APIKey{ID: "key-1", Name: "auto-remediation"}, "restarting pod")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestAcknowledgeIncidentByAPIKey_NotTriggered(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec("UPDATE incidents").
+		WithArgs("acknowledged", db.SystemUserAPI, "inc-1", "triggered", nil).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	service := NewIncidentService(pg, nil, nil)
+	err = service.AcknowledgeIncidentByAPIKey("inc-1", &db.APIKey{ID: "key-1"}, "")
+
+	assert.ErrorIs(t, err, ErrIncidentNotTriggered)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func expectIncidentTrendQueries(mockDB sqlmock.Sqlmock, severityErr error) {
+	args := []driver.Value{"7 days", "org-1"}
+
+	mockDB.ExpectQuery(`GROUP BY DATE\(created_at\)`).WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"date", "total", "triggered", "acknowledged", "resolved", "avg_mtta_minutes", "avg_mttr_minutes"}).
+			AddRow("2026-03-01", 3, 1, 1, 1, 4.0, 30.0).
+			AddRow("2026-03-02", 2, 2, 0, 0, nil, nil))
+
+	severity := mockDB.ExpectQuery(`GROUP BY severity`).WithArgs(args...)
+	if severityErr != nil {
+		severity.WillReturnError(severityErr)
+	} else {
+		severity.WillReturnRows(sqlmock.NewRows([]string{"severity", "count"}).AddRow("critical", 4).AddRow("warning", 1))
+	}
+
+	mockDB.ExpectQuery(`GROUP BY urgency`).WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"urgency", "count"}).AddRow("high", 5))
+	mockDB.ExpectQuery(`LEFT JOIN services s ON i\.service_id = s\.id`).WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"service_id", "service_name", "count"}).AddRow("svc-1", "Checkout", 5))
+	mockDB.ExpectQuery(`as acknowledged_count`).WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"avg_mtta_minutes", "avg_mttr_minutes", "acknowledged_count", "resolved_count"}).
+			AddRow(4.0, 30.0, 1, 1))
+}
+
+func TestGetIncidentTrends(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+	mockDB.MatchExpectationsInOrder(false)
+
+	expectIncidentTrendQueries(mockDB, nil)
+
+	service := NewIncidentService(pg, nil, nil)
+	trends, err := service.GetIncidentTrends("org-1", "", "")
+
+	mtta, mttr := 4.0, 30.0
+	assert.NoError(t, err)
+	assert.Equal(t, &IncidentTrendsResponse{
+		DailyCounts: []IncidentTrendDataPoint{
+			{Date: "2026-03-01", Total: 3, Triggered: 1, Acknowledged: 1, Resolved: 1, AvgMTTAMinutes: &mtta, AvgMTTRMinutes: &mttr},
+			{Date: "2026-03-02", Total: 2, Triggered: 2},
+		},
+		BySeverity: map[string]int{"critical": 4, "warning": 1},
+		ByUrgency:  map[string]int{"high": 5},
+		ByService:  []ServiceIncidentCount{{ServiceID: "svc-1", ServiceName: "Checkout", Count: 5}},
+		Metrics: map[string]interface{}{
+			"mtta_avg_minutes":   "4.0",
+			"mttr_avg_minutes":   "30.0",
+			"acknowledged_count": 1,
+			"resolved_count":     1,
+		},
+		TimeRange:      "7d",
+		TotalIncidents: 5,
+	}, trends)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetIncidentTrends_IdleDayHasZeroPoint(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+	mockDB.MatchExpectationsInOrder(false)
+
+	args := []driver.Value{"7 days", "org-1"}
+	// The days come from a generated series joined to the counts, so a day without incidents
+	// comes back with zero counts
+	mockDB.ExpectQuery(`GROUP BY DATE\(created_at\).*FROM generate_series\(DATE\(NOW\(\) - \$1::interval\), CURRENT_DATE, INTERVAL '1 day'\)\s+AS days\(day\)\s+LEFT JOIN daily`).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"date", "total", "triggered", "acknowledged", "resolved", "avg_mtta_minutes", "avg_mttr_minutes"}).
+			AddRow("2026-03-01", 3, 1, 1, 1, 4.0, 30.0).
+			AddRow("2026-03-02", 0, 0, 0, 0, nil, nil).
+			AddRow("2026-03-03", 2, 2, 0, 0, nil, nil))
+	mockDB.ExpectQuery(`GROUP BY severity`).WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"severity", "count"}))
+	mockDB.ExpectQuery(`GROUP BY urgency`).WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"urgency", "count"}))
+	mockDB.ExpectQuery(`LEFT JOIN services s ON i\.service_id = s\.id`).WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"service_id", "service_name", "count"}))
+	mockDB.ExpectQuery(`as acknowledged_count`).WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"avg_mtta_minutes", "avg_mttr_minutes", "acknowledged_count", "resolved_count"}).
+			AddRow(4.0, 30.0, 1, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	trends, err := service.GetIncidentTrends("org-1", "", "7d")
+
+	mtta, mttr := 4.0, 30.0
+	assert.NoError(t, err)
+	assert.Equal(t, []IncidentTrendDataPoint{
+		{Date: "2026-03-01", Total: 3, Triggered: 1, Acknowledged: 1, Resolved: 1, AvgMTTAMinutes: &mtta, AvgMTTRMinutes: &mttr},
+		{Date: "2026-03-02"},
+		{Date: "2026-03-03", Total: 2, Triggered: 2},
+	}, trends.DailyCounts)
+	assert.Equal(t, 5, trends.TotalIncidents)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetIncidentTrends_OptionalQueryFails(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+	mockDB.MatchExpectationsInOrder(false)
+
+	expectIncidentTrendQueries(mockDB, fmt.Errorf("severity index missing"))
+
+	service := NewIncidentService(pg, nil, nil)
+	trends, err := service.GetIncidentTrends("org-1", "", "7d")
+
+	assert.NoError(t, err)
+	assert.Empty(t, trends.BySeverity)
+	assert.Equal(t, 5, trends.TotalIncidents)
+	assert.Equal(t, map[string]int{"high": 5}, trends.ByUrgency)
+}
+
+func TestGetIncidentTrends_Filters(t *testing.T) {
+	tests := []struct {
+		name          string
+		orgID         string
+		projectID     string
+		args          []driver.Value
+		serviceFilter string
+	}{
+		{"org only", "org-1", "", []driver.Value{"7 days", "org-1"}, `i\.organization_id = \$2\s+AND i\.service_id`},
+		{"project only", "", "proj-1", []driver.Value{"7 days", "proj-1"}, `i\.project_id = \$2\s+AND i\.service_id`},
+		{"org and project", "org-1", "proj-1", []driver.Value{"7 days", "org-1", "proj-1"}, `i\.organization_id = \$2 AND i\.project_id = \$3\s+AND i\.service_id`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pg, mockDB, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer pg.Close()
+			mockDB.MatchExpectationsInOrder(false)
+
+			mockDB.ExpectQuery(`GROUP BY DATE\(created_at\)`).WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"date", "total", "triggered", "acknowledged", "resolved", "avg_mtta_minutes", "avg_mttr_minutes"}))
+			mockDB.ExpectQuery(`GROUP BY severity`).WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"severity", "count"}))
+			mockDB.ExpectQuery(`GROUP BY urgency`).WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"urgency", "count"}))
+			mockDB.ExpectQuery(tt.serviceFilter).WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"service_id", "service_name", "count"}).AddRow("svc-1", "Checkout", 2))
+			mockDB.ExpectQuery(`as acknowledged_count`).WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"avg_mtta_minutes", "avg_mttr_minutes", "acknowledged_count", "resolved_count"}).
+					AddRow(nil, nil, 0, 0))
+
+			service := NewIncidentService(pg, nil, nil)
+			trends, err := service.GetIncidentTrends(tt.orgID, tt.projectID, "7d")
+
+			assert.NoError(t, err)
+			assert.Equal(t, []ServiceIncidentCount{{ServiceID: "svc-1", ServiceName: "Checkout", Count: 2}}, trends.ByService)
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetIncidentTrends_DailyQueryFails(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+	mockDB.MatchExpectationsInOrder(false)
+
+	mockDB.ExpectQuery(`GROUP BY DATE\(created_at\)`).WillReturnError(fmt.Errorf("connection reset"))
+	mockDB.ExpectQuery(`GROUP BY severity`).WillReturnRows(sqlmock.NewRows([]string{"severity", "count"}))
+	mockDB.ExpectQuery(`GROUP BY urgency`).WillReturnRows(sqlmock.NewRows([]string{"urgency", "count"}))
+	mockDB.ExpectQuery(`LEFT JOIN services`).WillReturnRows(sqlmock.NewRows([]string{"service_id", "service_name", "count"}))
+	mockDB.ExpectQuery(`as acknowledged_count`).WillReturnError(fmt.Errorf("connection reset"))
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.GetIncidentTrends("", "", "7d")
+
+	assert.Error(t, err)
+}
+
+func slaBreachColumns() []string {
+	return []string{"id", "title", "status", "sla_type", "sla_minutes", "elapsed", "created_at"}
+}
+
+func TestGetSLABreaches(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	createdAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	mockDB.ExpectQuery(`COALESCE\(i.response_sla_minutes, s.response_sla_minutes\)`).
+		WithArgs("org-1", false).
+		WillReturnRows(sqlmock.NewRows(slaBreachColumns()).
+			AddRow("inc-1", "DB down", "acknowledged", db.SLATypeResponse, 5, 12, createdAt).
+			AddRow("inc-2", "API slow", "triggered", db.SLATypeResolution, 60, 95, createdAt))
+
+	service := NewIncidentService(pg, nil, nil)
+	breaches, err := service.GetSLABreaches("org-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []db.SLABreach{
+		{IncidentID: "inc-1", Title: "DB down", Status: "acknowledged", SLAType: db.SLATypeResponse, SLAMinutes: 5, ElapsedMinutes: 12, CreatedAt: createdAt},
+		{IncidentID: "inc-2", Title: "API slow", Status: "triggered", SLAType: db.SLATypeResolution, SLAMinutes: 60, ElapsedMinutes: 95, CreatedAt: createdAt},
+	}, breaches)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetSLABreaches_RequiresOrg(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.GetSLABreaches("")
+
+	assert.EqualError(t, err, "organization_id is required")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRecordSLABreaches_Idempotent(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	createdAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	mockDB.ExpectQuery(`NOT EXISTS`).
+		WithArgs("", true).
+		WillReturnRows(sqlmock.NewRows(slaBreachColumns()).
+			AddRow("inc-1", "DB down", "triggered", db.SLATypeResponse, 5, 7, createdAt).
+			AddRow("inc-2", "API slow", "triggered", db.SLATypeResponse, 5, 9, createdAt))
+	mockDB.ExpectExec(`INSERT INTO incident_events`).
+		WithArgs("inc-1", db.IncidentEventSLABreached, sqlmock.AnyArg(), db.SLATypeResponse).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Already recorded by a concurrent run
+	mockDB.ExpectExec(`INSERT INTO incident_events`).
+		WithArgs("inc-2", db.IncidentEventSLABreached, sqlmock.AnyArg(), db.SLATypeResponse).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	service := NewIncidentService(pg, nil, nil)
+	recorded, err := service.RecordSLABreaches()
+
+	assert.NoError(t, err)
+	assert.Len(t, recorded, 1)
+	assert.Equal(t, "inc-1", recorded[0].IncidentID)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestSLARemaining(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	fifteen, sixty := 15, 60
+	ackedAt := now.Add(-5 * time.Minute)
+
+	// Within the response SLA: 5 of 15 minutes used
+	slaType, remaining := slaRemaining(&db.Incident{
+		Status: db.IncidentStatusTriggered, CreatedAt: now.Add(-5 * time.Minute),
+		ResponseSLAMinutes: &fifteen, ResolutionSLAMinutes: &sixty,
+	}, now)
+	assert.Equal(t, db.SLATypeResponse, slaType)
+	if assert.NotNil(t, remaining) {
+		assert.Equal(t, int64(600), *remaining)
+	}
+
+	// Acknowledged, resolution SLA breached 30 minutes ago
+	slaType, remaining = slaRemaining(&db.Incident{
+		Status: db.IncidentStatusAcknowledged, CreatedAt: now.Add(-90 * time.Minute), AcknowledgedAt: &ackedAt,
+		ResponseSLAMinutes: &fifteen, ResolutionSLAMinutes: &sixty,
+	}, now)
+	assert.Equal(t, db.SLATypeResolution, slaType)
+	if assert.NotNil(t, remaining) {
+		assert.Equal(t, int64(-1800), *remaining)
+	}
+
+	// Resolved or without SLA: no countdown
+	_, remaining = slaRemaining(&db.Incident{Status: db.IncidentStatusResolved, ResolutionSLAMinutes: &sixty}, now)
+	assert.Nil(t, remaining)
+	_, remaining = slaRemaining(&db.Incident{Status: db.IncidentStatusTriggered, CreatedAt: now}, now)
+	assert.Nil(t, remaining)
+}
+
+func TestGetIncidentEvents_ActorDetails(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	now := time.Now()
+	mockDB.ExpectQuery(`SELECT .* FROM incident_events ie\s+LEFT JOIN users u .*ORDER BY ie.created_at DESC\s+LIMIT \$2`).
+		WithArgs("inc-1", 10).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "incident_id", "event_type", "event_data", "created_at", "created_by",
+			"created_by_name", "created_by_email", "created_by_role", "incident_source",
+		}).
+			AddRow("ev-2", "inc-1", "acknowledged", `{}`, now, "user-1", "Alice", "alice@example.com", "engineer", "datadog").
+			AddRow("ev-1", "inc-1", "triggered", `{"source":"webhook"}`, now.Add(-time.Minute), nil, nil, nil, nil, "datadog"))
+
+	service := NewIncidentService(pg, nil, nil)
+	events, err := service.GetIncidentEvents("inc-1", 10)
+
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "Alice", events[0].CreatedByName)
+	assert.Equal(t, "alice@example.com", events[0].CreatedByEmail)
+	assert.Equal(t, "engineer", events[0].CreatedByRole)
+
+	// No creator: attributed to the integration's system user
+	assert.Equal(t, db.SystemUserDatadog, events[1].CreatedBy)
+	assert.Equal(t, "Datadog (automated)", events[1].CreatedByName)
+	assert.Equal(t, db.SystemActorRole, events[1].CreatedByRole)
+	assert.Empty(t, events[1].CreatedByEmail)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetIncidentEvents_SystemUserNames(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	now := time.Now()
+	mockDB.ExpectQuery(`FROM incident_events ie`).
+		WithArgs("inc-1", 10).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "incident_id", "event_type", "event_data", "created_at", "created_by",
+			"created_by_name", "created_by_email", "created_by_role", "incident_source",
+		}).
+			// Auto-resolved by the webhook system user, which has no users row
+			AddRow("ev-3", "inc-1", "resolved", `{"resolution":"Auto-resolved"}`, now, db.SystemUserWebhook, nil, nil, nil, "datadog").
+			// Seeded system users keep their friendly name too
+			AddRow("ev-2", "inc-1", "escalated", `{}`, now.Add(-time.Minute), db.SystemUserPrometheus, "Prometheus", "prometheus@system.local", "system", "prometheus").
+			AddRow("ev-1", "inc-1", "triggered", `{}`, now.Add(-2*time.Minute), nil, nil, nil, nil, "cloudwatch"))
+
+	service := NewIncidentService(pg, nil, nil)
+	events, err := service.GetIncidentEvents("inc-1", 10)
+
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+	assert.Equal(t, db.SystemUserWebhook, events[0].CreatedBy)
+	assert.Equal(t, "Webhook (automated)", events[0].CreatedByName)
+	assert.Equal(t, db.SystemActorRole, events[0].CreatedByRole)
+	assert.Equal(t, "Prometheus (automated)", events[1].CreatedByName)
+	assert.Empty(t, events[1].CreatedByEmail)
+	assert.Equal(t, db.SystemUserAWS, events[2].CreatedBy)
+	assert.Equal(t, "AWS CloudWatch (automated)", events[2].CreatedByName)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetIncidentEventsPaged(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	cursorAt := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	cursorID := "7f1c2a64-4b8e-4d3e-9a57-5d0f6c2b9e11"
+	columns := []string{
+		"id", "incident_id", "event_type", "event_data", "created_at", "created_by",
+		"created_by_name", "created_by_email", "created_by_role", "incident_source",
+	}
+
+	mockDB.ExpectQuery(`WHERE ie.incident_id = \$1 AND ie.event_type = ANY\(\$2\) AND ie.created_at >= \$3 AND \(ie.created_at, ie.id\) < \(\$4::timestamptz, \$5::uuid\) ORDER BY ie.created_at DESC, ie.id DESC LIMIT \$6`).
+		WithArgs("inc-1", stringArrayArg{"escalated", "acknowledged"}, since, cursorAt, cursorID, 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("3b0e8f4a-0000-4000-8000-000000000002", "inc-1", "acknowledged", `{}`, cursorAt.Add(-time.Minute), "user-1", "Alice", "alice@example.com", "engineer", "webhook").
+			AddRow("3b0e8f4a-0000-4000-8000-000000000001", "inc-1", "escalated", `{"level":2}`, cursorAt.Add(-time.Hour), nil, nil, nil, nil, "webhook"))
+
+	service := NewIncidentService(pg, nil, nil)
+	events, err := service.GetIncidentEventsPaged("inc-1", map[string]interface{}{
+		"event_type": "escalated, acknowledged",
+		"since":      since,
+		"cursor":     EncodeIncidentCursor(cursorAt, cursorID),
+		"limit":      2,
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "Alice", events[0].CreatedByName)
+	assert.Equal(t, "Webhook (automated)", events[1].CreatedByName)
+
+	// Full page: the next cursor points after the last event
+	next := NextIncidentEventCursor(events, 2)
+	nextAt, nextID, err := DecodeIncidentCursor(next)
+	assert.NoError(t, err)
+	assert.Equal(t, "3b0e8f4a-0000-4000-8000-000000000001", nextID)
+	assert.True(t, nextAt.Equal(cursorAt.Add(-time.Hour)))
+	assert.Empty(t, NextIncidentEventCursor(events, 3))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func expectIncidentKeyAttach(mockDB sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	mockDB.ExpectQuery(`UPDATE incidents\s+SET alert_count = alert_count \+ 1`).
+		WithArgs("org-1", "db-down", stringArrayArg(db.OpenIncidentStatuses)).
+		WillReturnRows(rows)
+}
+
+func attachedIncidentRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "title", "status", "urgency", "priority", "severity",
+		"assigned_to", "service_id", "project_id", "alert_count", "created_at", "updated_at",
+	}).AddRow("inc-1", "Database down", "triggered", "high", "P1", "critical",
+		"user-1", nil, "proj-1", 2, time.Now(), time.Now())
+}
+
+// expectExplicitAssignmentEvent expects the assigned event of a new incident created with assignee user-1
+func expectExplicitAssignmentEvent(mockDB sqlmock.Sqlmock) {
+	mockDB.ExpectQuery("SELECT COALESCE\\(name, email, 'Unknown'\\) FROM users").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Alice"))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs(sqlmock.AnyArg(), "assigned", `{"assigned_to":"Alice","assigned_to_id":"user-1","method":"manual"}`, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func newKeyedIncident() *db.Incident {
+	return &db.Incident{
+		Title:          "Database down",
+		Severity:       "critical",
+		Priority:       "P1",
+		AssignedTo:     "user-1",
+		OrganizationID: "org-1",
+		IncidentKey:    "db-down",
+	}
+}
+
+func TestCreateIncident_DedupsByIncidentKey(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewIncidentService(pg, nil, nil)
+	service.DeployCorrelationWindow = 0
+
+	// First alert opens the incident; its explicit assignee skips auto-assignment
+	expectIncidentKeyAttach(mockDB, sqlmock.NewRows([]string{"id"}))
+	mockDB.ExpectExec("INSERT INTO incidents").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs(sqlmock.AnyArg(), "triggered", sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectExplicitAssignmentEvent(mockDB)
+
+	first, err := service.CreateIncident(newKeyedIncident())
+	assert.NoError(t, err)
+
+	// Second alert with the same key is counted on it instead of opening another incident
+	expectIncidentKeyAttach(mockDB, attachedIncidentRows())
+
+	second, err := service.CreateIncident(newKeyedIncident())
+	assert.NoError(t, err)
+	assert.Equal(t, "inc-1", second.ID)
+	assert.NotEmpty(t, first.ID)
+	assert.Equal(t, 2, second.AlertCount)
+	assert.Equal(t, "db-down", second.IncidentKey)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCreateIncident_IncidentKeyRace(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// A concurrent alert inserts the keyed incident between the lookup and our insert
+	expectIncidentKeyAttach(mockDB, sqlmock.NewRows([]string{"id"}))
+	mockDB.ExpectExec(`INSERT INTO incidents .* ON CONFLICT \(organization_id, incident_key\)\s+WHERE .* DO NOTHING`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectIncidentKeyAttach(mockDB, attachedIncidentRows())
+
+	service := NewIncidentService(pg, nil, nil)
+	service.DeployCorrelationWindow = 0
+
+	incident, err := service.CreateIncident(newKeyedIncident())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "inc-1", incident.ID)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetIncidentStats_Scoped(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`FROM incidents\s+WHERE created_at >= NOW\(\) - \$1::interval AND organization_id = \$2 AND project_id = \$3$`).
+		WithArgs("30 days", "org-1", "proj-1").
+		WillReturnRows(sqlmock.NewRows([]string{"total", "triggered", "acknowledged", "resolved", "high_urgency"}).
+			AddRow(5, 2, 1, 2, 3))
+	mockDB.ExpectQuery(`organization_id = \$2 AND project_id = \$3\s+GROUP BY 1`).
+		WithArgs("30 days", "org-1", "proj-1").
+		WillReturnRows(sqlmock.NewRows([]string{"priority", "count"}).
+			AddRow("P1", 2).
+			AddRow("none", 3))
+
+	service := NewIncidentService(pg, nil, nil)
+	stats, err := service.GetIncidentStats("org-1", "proj-1", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, stats["total"])
+	assert.Equal(t, 3, stats["high_urgency"])
+	assert.Equal(t, map[string]int{"P1": 2, "none": 3}, stats["by_priority"])
+	assert.Equal(t, "last_30_days", stats["time_range"])
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetIncidentStats_NoOrg(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewIncidentService(pg, nil, nil)
+	stats, err := service.GetIncidentStats("", "proj-1", "last_7_days")
+
+	// Never falls back to global totals
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stats["total"])
+	assert.Equal(t, map[string]int{}, stats["by_priority"])
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetIncidentStats_TimeRange(t *testing.T) {
+	tests := []struct {
+		timeRange string
+		where     string
+		args      []driver.Value
+	}{
+		{"last_24_hours", `WHERE created_at >= NOW\(\) - \$1::interval AND organization_id = \$2$`, []driver.Value{"24 hours", "org-1"}},
+		{"last_90_days", `WHERE created_at >= NOW\(\) - \$1::interval AND organization_id = \$2$`, []driver.Value{"90 days", "org-1"}},
+		{"all", `WHERE organization_id = \$1$`, []driver.Value{"org-1"}},
+		{"bogus", `WHERE created_at >= NOW\(\) - \$1::interval AND organization_id = \$2$`, []driver.Value{"30 days", "org-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timeRange, func(t *testing.T) {
+			pg, mockDB, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer pg.Close()
+
+			mockDB.ExpectQuery(tt.where).WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"total", "triggered", "acknowledged", "resolved", "high_urgency"}).
+					AddRow(1, 1, 0, 0, 1))
+			mockDB.ExpectQuery(`GROUP BY 1`).WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"priority", "count"}))
+
+			service := NewIncidentService(pg, nil, nil)
+			_, err = service.GetIncidentStats("org-1", "", tt.timeRange)
+
+			assert.NoError(t, err)
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package services
+
+import "fmt"
+
+// SQLNowUTC is the current time as written to incident timestamp columns. Those columns are
+// "timestamp without time zone" holding UTC (see the incidents defaults), so every write goes
+// through this expression rather than a bare NOW() or a Go time.Time, whose wall clock depends
+// on the session or server time zone and would make created_at, acknowledged_at and
+// resolved_at incomparable.
+const SQLNowUTC = "NOW() AT TIME ZONE 'UTC'"
+
+// sqlMinutesBetween is the SQL for the minutes from one incident timestamp to a later one, as
+// used for MTTA and MTTR. Rows written before timestamps were consistently UTC can have an
+// acknowledged_at or resolved_at earlier than created_at, so the result is floored at zero.
+func sqlMinutesBetween(from, to string) string {
+	return fmt.Sprintf("GREATEST(EXTRACT(EPOCH FROM (%s - %s)), 0) / 60", to, from)
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+var sqlNowUTCPattern = regexp.QuoteMeta(SQLNowUTC)
+
+func TestSQLMinutesBetween(t *testing.T) {
+	assert.Equal(t,
+		"GREATEST(EXTRACT(EPOCH FROM (i.acknowledged_at - i.created_at)), 0) / 60",
+		sqlMinutesBetween("i.created_at", "i.acknowledged_at"))
+}
+
+func TestIncidentTransitionsWriteUTC(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec(`UPDATE incidents\s+SET status = \$1, acknowledged_by = \$2::uuid, acknowledged_at = `+sqlNowUTCPattern+`, updated_at = `+sqlNowUTCPattern).
+		WithArgs(db.IncidentStatusAcknowledged, "user-1", "inc-1", db.IncidentStatusTriggered, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEscalationsStopped(mockDB, "inc-1", "user-1", db.AlertEscalationStatusAcknowledged)
+	mockDB.ExpectExec(`INSERT INTO incident_events \(incident_id, event_type, event_data, created_by, created_at\)\s+VALUES \(\$1, \$2, \$3, \$4, `+sqlNowUTCPattern+`\)`).
+		WithArgs("inc-1", db.IncidentEventAcknowledged, `{}`, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`UPDATE incidents\s+SET status = \$1, resolved_by = \$2::uuid, resolved_at = `+sqlNowUTCPattern+`, updated_at = `+sqlNowUTCPattern).
+		WithArgs(db.IncidentStatusResolved, "user-1", "inc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEscalationsStopped(mockDB, "inc-1", "user-1", db.AlertEscalationStatusStopped)
+	mockDB.ExpectExec(`INSERT INTO incident_events .*`+sqlNowUTCPattern).
+		WithArgs("inc-1", db.IncidentEventResolved, `{}`, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	acknowledged, err := acknowledgeIncidentWith(pg, "inc-1", "user-1", "")
+	assert.NoError(t, err)
+	assert.True(t, acknowledged)
+
+	resolved, err := resolveIncidentWith(pg, "inc-1", "user-1", "", "", nil)
+	assert.NoError(t, err)
+	assert.True(t, resolved)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestResponseMetricsAreNonNegative(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// MTTA and MTTR are measured with the same floored expression over the UTC timestamps
+	mockDB.ExpectQuery(regexp.QuoteMeta("AVG("+sqlMinutesBetween("i.created_at", "i.acknowledged_at")+") as avg_mtta_minutes,")+
+		`\s+`+regexp.QuoteMeta("AVG("+sqlMinutesBetween("i.created_at", "i.resolved_at")+") as avg_mttr_minutes")).
+		WithArgs("7 days", "org-1").
+		WillReturnRows(sqlmock.NewRows(responseMetricsColumns()).
+			AddRow("svc-1", "API", 3, 3, 2, 0.0, 12.5))
+
+	service := NewIncidentService(pg, nil, nil)
+	metrics, err := service.GetResponseMetricsByService("org-1", "7d")
+
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.GreaterOrEqual(t, *metrics[0].AvgMTTAMinutes, 0.0)
+	assert.GreaterOrEqual(t, *metrics[0].AvgMTTRMinutes, *metrics[0].AvgMTTAMinutes)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
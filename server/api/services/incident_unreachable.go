@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// notifyIfAssigneeUnreachable falls back to the leaders of the incident's group when the
+// assignee has no channel the assignment goes out on: push needs a registered device and
+// Slack a linked Slack user, each enabled in the user's notification settings. Leaders get
+// an escalation noting the assignee is unreachable. A failed check assumes the assignee is
+// reachable, so the normal notification still goes out.
+func (s *IncidentService) notifyIfAssigneeUnreachable(incident *db.Incident, notifySlack, notifyFCM bool) {
+	var name string
+	var pushReachable, slackReachable bool
+	err := s.PG.QueryRow(`
+		SELECT COALESCE(u.name, u.email, 'Unknown'),
+		       COALESCE(u.fcm_token, '') <> '' AND COALESCE(unc.push_enabled, true),
+		       COALESCE(unc.slack_user_id, '') <> '' AND COALESCE(unc.slack_enabled, true)
+		FROM users u
+		LEFT JOIN user_notification_configs unc ON unc.user_id = u.id
+		WHERE u.id = $1
+	`, incident.AssignedTo).Scan(&name, &pushReachable, &slackReachable)
+	if err != nil {
+		log.Printf("WARNING: Failed to check notification channels of user %s: %v", incident.AssignedTo, err)
+		return
+	}
+	if (notifyFCM && pushReachable) || (notifySlack && slackReachable) {
+		return
+	}
+
+	leaders, err := s.groupLeaders(incident.GroupID, incident.AssignedTo)
+	if err != nil {
+		log.Printf("WARNING: Failed to get leaders of group %s: %v", incident.GroupID, err)
+	}
+	log.Printf("INFO: Assignee %s of incident %s has no notification channel, notifying %d group leaders",
+		incident.AssignedTo, incident.ID, len(leaders))
+
+	_ = s.createIncidentEvent(incident.ID, db.IncidentEventAssigneeUnreachable, map[string]interface{}{
+		"assigned_to_id": incident.AssignedTo,
+		"assigned_to":    name,
+		"notified":       leaders,
+	}, "")
+
+	if s.NotificationWorker == nil {
+		return
+	}
+	message := fmt.Sprintf("User %s is unreachable: they have no notification channel for incident %q", name, incident.Title)
+	for _, leaderID := range leaders {
+		go func(leaderID string) {
+			if err := s.NotificationWorker.SendIncidentEscalatedNotification(leaderID, incident.ID, message); err != nil {
+				log.Printf("Failed to notify group leader %s of unreachable assignee: %v", leaderID, err)
+			}
+		}(leaderID)
+	}
+}
+
+// groupLeaders returns the owners and admins of a group other than excludeUserID
+func (s *IncidentService) groupLeaders(groupID, excludeUserID string) ([]string, error) {
+	leaders := []string{}
+	if groupID == "" {
+		return leaders, nil
+	}
+
+	rows, err := s.PG.Query(`
+		SELECT user_id FROM memberships
+		WHERE resource_type = 'group' AND resource_id = $1
+		  AND role IN ('owner', 'admin') AND user_id::text <> $2
+		ORDER BY created_at ASC
+	`, groupID, excludeUserID)
+	if err != nil {
+		return leaders, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return leaders, err
+		}
+		leaders = append(leaders, userID)
+	}
+	return leaders, rows.Err()
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func expectAssigneeChannels(mockDB sqlmock.Sqlmock, userID string, push, slack bool) {
+	mockDB.ExpectQuery(`FROM users u\s+LEFT JOIN user_notification_configs unc ON unc.user_id = u.id\s+WHERE u.id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "push", "slack"}).AddRow("Alice", push, slack))
+}
+
+// escalatedNotifier captures escalation notifications sent by the service
+type escalatedNotifier struct {
+	assignedNotifier
+	escalated chan string
+}
+
+func (n *escalatedNotifier) SendIncidentEscalatedNotification(userID, incidentID, message string) error {
+	n.escalated <- userID + ": " + message
+	return nil
+}
+
+func TestSendAssignmentNotification_UnreachableAssigneeFallsBackToLeaders(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec("INSERT INTO oncall_pages").
+		WithArgs("group-1", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// A registered device doesn't help when only Slack goes out
+	expectAssigneeChannels(mockDB, "user-1", true, false)
+	mockDB.ExpectQuery(`SELECT user_id FROM memberships\s+WHERE resource_type = 'group' AND resource_id = \$1\s+AND role IN \('owner', 'admin'\)`).
+		WithArgs("group-1", "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("leader-1"))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", db.IncidentEventAssigneeUnreachable,
+			`{"assigned_to":"Alice","assigned_to_id":"user-1","notified":["leader-1"]}`, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	notifier := &escalatedNotifier{
+		assignedNotifier: assignedNotifier{assigned: make(chan string, 1)},
+		escalated:        make(chan string, 1),
+	}
+	service := NewIncidentService(pg, nil, nil)
+	service.SetNotificationWorker(notifier)
+
+	incident := newServiceIncident("critical")
+	incident.ID = "inc-1"
+	incident.GroupID = "group-1"
+	service.sendAssignmentNotification(incident, true, false)
+
+	select {
+	case escalated := <-notifier.escalated:
+		assert.Equal(t, `leader-1: User Alice is unreachable: they have no notification channel for incident "Disk filling up"`, escalated)
+	case <-time.After(time.Second):
+		t.Fatal("group leader should be told the assignee is unreachable")
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestSendAssignmentNotification_ReachableAssigneeHasNoFallback(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectAssigneeChannels(mockDB, "user-1", false, true)
+
+	notifier := &escalatedNotifier{
+		assignedNotifier: assignedNotifier{assigned: make(chan string, 1)},
+		escalated:        make(chan string, 1),
+	}
+	service := NewIncidentService(pg, nil, nil)
+	service.SetNotificationWorker(notifier)
+
+	incident := newServiceIncident("critical")
+	incident.ID = "inc-1"
+	service.sendAssignmentNotification(incident, true, false)
+
+	assert.Equal(t, "user-1", <-notifier.assigned)
+	assert.Empty(t, notifier.escalated)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// AddWatcher subscribes a user to every change of an incident. Adding an existing watcher is a no-op.
+func (s *IncidentService) AddWatcher(incidentID, userID string) error {
+	_, err := s.PG.Exec(`
+		INSERT INTO incident_watchers (incident_id, user_id, created_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (incident_id, user_id) DO NOTHING
+	`, incidentID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to add watcher: %w", err)
+	}
+	return nil
+}
+
+// ErrWatcherNotFound is returned when removing a user who isn't watching the incident
+var ErrWatcherNotFound = errors.New("watcher not found")
+
+// RemoveWatcher unsubscribes a user from an incident
+func (s *IncidentService) RemoveWatcher(incidentID, userID string) error {
+	result, err := s.PG.Exec(`
+		DELETE FROM incident_watchers WHERE incident_id = $1 AND user_id = $2
+	`, incidentID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove watcher: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrWatcherNotFound
+	}
+	return nil
+}
+
+// GetWatchers returns the users watching an incident, oldest subscription first
+func (s *IncidentService) GetWatchers(incidentID string) ([]db.IncidentWatcher, error) {
+	rows, err := s.PG.Query(`
+		SELECT w.user_id, COALESCE(u.name, ''), COALESCE(u.email, ''), w.created_at
+		FROM incident_watchers w
+		LEFT JOIN users u ON w.user_id = u.id
+		WHERE w.incident_id = $1
+		ORDER BY w.created_at
+	`, incidentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watchers: %w", err)
+	}
+	defer rows.Close()
+
+	watchers := []db.IncidentWatcher{}
+	for rows.Next() {
+		var watcher db.IncidentWatcher
+		if err := rows.Scan(&watcher.UserID, &watcher.Name, &watcher.Email, &watcher.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan watcher: %w", err)
+		}
+		watchers = append(watchers, watcher)
+	}
+
+	return watchers, rows.Err()
+}
+
+// notifyWatchers fans an incident change out to its watchers. The actor is skipped, and
+// so is the assignee unless includeAssignee is set: they already get their own notification.
+func (s *IncidentService) notifyWatchers(incidentID, change, actorID string, includeAssignee bool) {
+	if s.NotificationWorker == nil {
+		return
+	}
+
+	go func() {
+		var assignedTo string
+		err := s.PG.QueryRow(`
+			SELECT COALESCE(assigned_to::text, '') FROM incidents WHERE id = $1
+		`, incidentID).Scan(&assignedTo)
+		if err != nil {
+			log.Printf("Failed to load incident %s for watcher notifications: %v", incidentID, err)
+			return
+		}
+
+		watchers, err := s.GetWatchers(incidentID)
+		if err != nil {
+			log.Printf("Failed to load watchers of incident %s: %v", incidentID, err)
+			return
+		}
+
+		for _, userID := range watcherRecipients(watchers, assignedTo, actorID, includeAssignee) {
+			if err := s.NotificationWorker.SendIncidentWatcherNotification(userID, incidentID, change); err != nil {
+				log.Printf("Failed to send %s watcher notification to user %s: %v", change, userID, err)
+			}
+		}
+	}()
+}
+
+// watcherRecipients returns who to notify about an incident change: every watcher
+// plus (optionally) the assignee, each at most once, never the actor
+func watcherRecipients(watchers []db.IncidentWatcher, assignedTo, actorID string, includeAssignee bool) []string {
+	seen := map[string]bool{"": true, actorID: true}
+	recipients := []string{}
+
+	if !includeAssignee {
+		seen[assignedTo] = true
+	} else if !seen[assignedTo] {
+		seen[assignedTo] = true
+		recipients = append(recipients, assignedTo)
+	}
+
+	for _, watcher := range watchers {
+		if seen[watcher.UserID] {
+			continue
+		}
+		seen[watcher.UserID] = true
+		recipients = append(recipients, watcher.UserID)
+	}
+
+	return recipients
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingNotifier captures watcher notifications sent by the service
+type recordingNotifier struct {
+	mu      sync.Mutex
+	watched []string
+	done    chan struct{}
+	expect  int
+}
+
+func (n *recordingNotifier) SendIncidentAssignedNotification(userID, incidentID string) error {
+	return nil
+}
+func (n *recordingNotifier) SendIncidentEscalatedNotification(userID, incidentID, message string) error {
+	return nil
+}
+func (n *recordingNotifier) SendIncidentAcknowledgedNotification(userID, incidentID string) error {
+	return nil
+}
+func (n *recordingNotifier) SendIncidentResolvedNotification(userID, incidentID string) error {
+	return nil
+}
+func (n *recordingNotifier) SendIncidentWatcherNotification(userID, incidentID, change string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.watched = append(n.watched, userID+":"+change)
+	if len(n.watched) == n.expect {
+		close(n.done)
+	}
+	return nil
+}
+
+func TestWatcherRecipients_Dedup(t *testing.T) {
+	watchers := []db.IncidentWatcher{{UserID: "user-1"}, {UserID: "assignee"}, {UserID: "actor"}, {UserID: "user-2"}}
+
+	// The assignee and actor get their own notifications
+	assert.Equal(t, []string{"user-1", "user-2"}, watcherRecipients(watchers, "assignee", "actor", false))
+
+	// Assignee included once even though they also watch
+	assert.Equal(t, []string{"assignee", "user-1", "user-2"}, watcherRecipients(watchers, "assignee", "actor", true))
+
+	// Unassigned incident, no actor
+	assert.Equal(t, []string{"user-1", "assignee", "actor", "user-2"}, watcherRecipients(watchers, "", "", true))
+}
+
+func TestAcknowledgeIncident_NotifiesWatchers(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec(`UPDATE incidents`).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEscalationsStopped(mockDB, "inc-1", "user-3", db.AlertEscalationStatusAcknowledged)
+	mockDB.ExpectExec(`INSERT INTO incident_events`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery(`SELECT COALESCE\(assigned_to::text, ''\) FROM incidents`).
+		WithArgs("inc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"assigned_to"}).AddRow("user-1"))
+	mockDB.ExpectQuery(`FROM incident_watchers`).
+		WithArgs("inc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "name", "email", "created_at"}).
+			AddRow("user-1", "Assignee", "a@example.com", time.Now()).
+			AddRow("user-2", "Watcher", "w@example.com", time.Now()).
+			AddRow("user-3", "Acker", "k@example.com", time.Now()))
+
+	notifier := &recordingNotifier{done: make(chan struct{}), expect: 1}
+	service := NewIncidentService(pg, nil, nil)
+	service.SetNotificationWorker(notifier)
+
+	err = service.AcknowledgeIncident("inc-1", "user-3", "", time.Time{})
+	assert.NoError(t, err)
+
+	select {
+	case <-notifier.done:
+	case <-time.After(time.Second):
+		t.Fatal("watcher notification was not sent")
+	}
+	assert.Equal(t, []string{"user-2:acknowledged"}, notifier.watched)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRemoveWatcher_NotFound(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec(`DELETE FROM incident_watchers`).
+		WithArgs("inc-1", "user-2").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	service := NewIncidentService(pg, nil, nil)
+	err = service.RemoveWatcher("inc-1", "user-2")
+
+	assert.ErrorIs(t, err, ErrWatcherNotFound)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestAddWatcher_Idempotent(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec(`INSERT INTO incident_watchers .* ON CONFLICT \(incident_id, user_id\) DO NOTHING`).
+		WithArgs("inc-1", "user-2").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	service := NewIncidentService(pg, nil, nil)
+	assert.NoError(t, service.AddWatcher("inc-1", "user-2"))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
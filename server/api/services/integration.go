@@ -14,6 +14,9 @@ import (
 
 type IntegrationService struct {
 	PG *sql.DB
+
+	// RateLimiter limits webhooks per integration; nil leaves them unlimited
+	RateLimiter *WebhookRateLimiter
 }
 
 func NewIntegrationService(pg *sql.DB) *IntegrationService {
@@ -104,7 +107,8 @@ func (s *IntegrationService) GetIntegration(integrationID string) (db.Integratio
 		       i.created_at, i.updated_at, COALESCE(i.created_by, '') as created_by,
 		       i.organization_id, i.project_id,
 		       get_integration_health_status(i.id) as health_status,
-		       COALESCE(si_count.services_count, 0) as services_count
+		       COALESCE(si_count.services_count, 0) as services_count,
+		       i.dropped_alerts
 		FROM integrations i
 		LEFT JOIN (
 			SELECT integration_id, COUNT(*) as services_count
@@ -120,6 +124,7 @@ func (s *IntegrationService) GetIntegration(integrationID string) (db.Integratio
 		&integration.CreatedAt, &integration.UpdatedAt, &integration.CreatedBy,
 		&organizationID, &projectID,
 		&integration.HealthStatus, &integration.ServicesCount,
+		&integration.DroppedAlerts,
 	)
 
 	if err != nil {
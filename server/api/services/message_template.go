@@ -0,0 +1,60 @@
+package services
+
+import (
+	"log"
+	"regexp"
+	"strings"
+)
+
+// DefaultEscalationMessageTemplate is used for escalation levels without a message template
+const DefaultEscalationMessageTemplate = "Alert: {{alert.title}} requires attention"
+
+var templateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.]+)\s*\}\}`)
+
+// RenderMessageTemplate replaces {{variable}} placeholders with their values.
+// Unknown variables render blank and are returned so callers can flag them.
+func RenderMessageTemplate(template string, vars map[string]string) (string, []string) {
+	var unknown []string
+	rendered := templateVariable.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := templateVariable.FindStringSubmatch(placeholder)[1]
+		value, ok := vars[name]
+		if !ok {
+			unknown = append(unknown, name)
+		}
+		return value
+	})
+	return rendered, unknown
+}
+
+// escalationTemplateVars returns the variables escalation level message templates can use.
+// alert.* are kept for templates written before incidents replaced alerts.
+func (s *IncidentService) escalationTemplateVars(incidentID, title, severity, assigneeName string) map[string]string {
+	url := ""
+	if s.PublicURL != "" {
+		url = strings.TrimRight(s.PublicURL, "/") + "/incidents/" + incidentID
+	}
+
+	return map[string]string{
+		"incident.title":    title,
+		"incident.severity": severity,
+		"incident.url":      url,
+		"assignee.name":     assigneeName,
+		"alert.title":       title,
+		"alert.severity":    severity,
+	}
+}
+
+// renderEscalationMessage renders the message template of the level a plan escalates to
+func (s *IncidentService) renderEscalationMessage(plan *escalationPlan, incidentID, assigneeName string) string {
+	template := plan.target.MessageTemplate
+	if template == "" {
+		template = DefaultEscalationMessageTemplate
+	}
+
+	message, unknown := RenderMessageTemplate(template, s.escalationTemplateVars(incidentID, plan.title, plan.severity, assigneeName))
+	if len(unknown) > 0 {
+		log.Printf("WARNING: Escalation level %s message template has unknown variables: %s",
+			plan.target.ID, strings.Join(unknown, ", "))
+	}
+	return message
+}
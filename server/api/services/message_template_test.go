@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMessageTemplate(t *testing.T) {
+	vars := map[string]string{
+		"incident.title":    "Database down",
+		"incident.severity": "critical",
+		"incident.url":      "https://inres.example.com/incidents/inc-1",
+		"assignee.name":     "Alice",
+	}
+
+	message, unknown := RenderMessageTemplate(
+		"[{{incident.severity}}] {{ incident.title }} - {{assignee.name}}, see {{incident.url}}", vars)
+
+	assert.Equal(t, "[critical] Database down - Alice, see https://inres.example.com/incidents/inc-1", message)
+	assert.Empty(t, unknown)
+}
+
+func TestRenderMessageTemplate_UnknownVariables(t *testing.T) {
+	message, unknown := RenderMessageTemplate("{{incident.title}} in {{incident.region}}{{foo}}",
+		map[string]string{"incident.title": "Database down"})
+
+	// Unknown variables render blank and are flagged
+	assert.Equal(t, "Database down in ", message)
+	assert.Equal(t, []string{"incident.region", "foo"}, unknown)
+
+	// Text without placeholders passes through untouched
+	message, unknown = RenderMessageTemplate("Plain {text} {{ }}", nil)
+	assert.Equal(t, "Plain {text} {{ }}", message)
+	assert.Empty(t, unknown)
+}
+
+func TestRenderEscalationMessage(t *testing.T) {
+	service := NewIncidentService(nil, nil, nil)
+	service.PublicURL = "https://inres.example.com/"
+
+	plan := &escalationPlan{
+		target:   &db.EscalationLevel{ID: "level-2", MessageTemplate: "{{incident.title}} ({{incident.severity}}) for {{assignee.name}}: {{incident.url}}"},
+		title:    "Database down",
+		severity: "critical",
+	}
+	assert.Equal(t, "Database down (critical) for Alice: https://inres.example.com/incidents/inc-1",
+		service.renderEscalationMessage(plan, "inc-1", "Alice"))
+
+	// Levels without a template use the default, whose alert.* variables alias the incident
+	plan.target.MessageTemplate = ""
+	assert.Equal(t, "Alert: Database down requires attention", service.renderEscalationMessage(plan, "inc-1", "Alice"))
+
+	// Without a public URL the link is left blank
+	service.PublicURL = ""
+	plan.target.MessageTemplate = "Open {{incident.url}}"
+	assert.Equal(t, "Open ", service.renderEscalationMessage(plan, "inc-1", "Alice"))
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/lib/pq"
+	"github.com/phonginreallife/inres/db"
+)
+
+// ErrNoDeadLetterIDs is returned when a retry names no dead-lettered notifications
+var ErrNoDeadLetterIDs = errors.New("no dead-lettered notification IDs given")
+
+const notificationDeadLetterColumns = `id, queue_name, msg_id, message, error, attempts, created_at, replayed_at`
+
+// DeadLetterNotification moves a queued notification that ran out of delivery attempts off
+// its queue and into notification_dead_letters, with why its last attempt failed
+func (s *NotificationService) DeadLetterNotification(queueName string, msgID int64, message []byte, deliveryErr string, attempts int) error {
+	_, err := s.PG.Exec(`
+		WITH dead AS (
+			INSERT INTO notification_dead_letters (queue_name, msg_id, message, error, attempts)
+			VALUES ($1, $2, $3::jsonb, $4, $5)
+		)
+		SELECT pgmq.delete($1, $2::bigint)
+	`, queueName, msgID, string(message), deliveryErr, attempts)
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter notification %d: %w", msgID, err)
+	}
+	log.Printf("WARNING: Notification %d on queue %s dead-lettered after %d attempts: %s", msgID, queueName, attempts, deliveryErr)
+	return nil
+}
+
+// requirePlatformAdmin returns ErrPlatformAdminRequired unless actorID is a platform admin.
+// Dead letters hold the payloads of every organization, so only platform support sees them.
+func (s *NotificationService) requirePlatformAdmin(actorID string) error {
+	isAdmin, err := isPlatformAdmin(s.PG, actorID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		log.Printf("WARNING: User %s denied access to dead-lettered notifications", actorID)
+		return ErrPlatformAdminRequired
+	}
+	return nil
+}
+
+// ListDeadLettered returns the dead-lettered notifications, newest first, optionally only
+// those of one queue. The actor must be a platform admin.
+func (s *NotificationService) ListDeadLettered(actorID, queueName string, limit int) ([]db.NotificationDeadLetter, error) {
+	if err := s.requirePlatformAdmin(actorID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	rows, err := s.PG.Query(`
+		SELECT `+notificationDeadLetterColumns+`
+		FROM notification_dead_letters
+		WHERE ($1 = '' OR queue_name = $1)
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, queueName, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead-lettered notifications: %w", err)
+	}
+	defer rows.Close()
+
+	deadLetters := []db.NotificationDeadLetter{}
+	for rows.Next() {
+		deadLetter, err := scanNotificationDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	return deadLetters, rows.Err()
+}
+
+// RetryDeadLettered puts dead-lettered notifications back on their queues with a fresh retry
+// count and marks them replayed, all or none. IDs that are unknown or already replayed are
+// skipped. Returns the notifications that were requeued. The actor must be a platform admin.
+func (s *NotificationService) RetryDeadLettered(actorID string, ids []string) ([]db.NotificationDeadLetter, error) {
+	if len(ids) == 0 {
+		return nil, ErrNoDeadLetterIDs
+	}
+	if err := s.requirePlatformAdmin(actorID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.PG.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		UPDATE notification_dead_letters
+		SET replayed_at = NOW(), attempts = attempts + 1
+		WHERE id::text = ANY($1) AND replayed_at IS NULL
+		RETURNING `+notificationDeadLetterColumns, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim dead-lettered notifications: %w", err)
+	}
+
+	replayed := []db.NotificationDeadLetter{}
+	for rows.Next() {
+		deadLetter, err := scanNotificationDeadLetter(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		replayed = append(replayed, deadLetter)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dead-lettered notifications: %w", err)
+	}
+
+	for _, deadLetter := range replayed {
+		if _, err := tx.Exec(`SELECT pgmq.send($1, $2)`, deadLetter.QueueName, string(resetRetryCount(deadLetter.Message))); err != nil {
+			return nil, fmt.Errorf("failed to requeue notification %s: %w", deadLetter.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit retry: %w", err)
+	}
+	if len(replayed) > 0 {
+		log.Printf("INFO: Requeued %d dead-lettered notifications", len(replayed))
+	}
+	return replayed, nil
+}
+
+// resetRetryCount zeroes a queued notification's retry_count so it gets a full set of
+// attempts again. Messages that aren't JSON objects are requeued unchanged.
+func resetRetryCount(message []byte) []byte {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(message, &fields); err != nil || fields == nil {
+		return message
+	}
+	fields["retry_count"] = 0
+
+	reset, err := json.Marshal(fields)
+	if err != nil {
+		return message
+	}
+	return reset
+}
+
+// scanNotificationDeadLetter scans a row selected with notificationDeadLetterColumns
+func scanNotificationDeadLetter(row interface{ Scan(...interface{}) error }) (db.NotificationDeadLetter, error) {
+	var deadLetter db.NotificationDeadLetter
+	var message []byte
+	var replayedAt sql.NullTime
+	err := row.Scan(&deadLetter.ID, &deadLetter.QueueName, &deadLetter.MsgID, &message, &deadLetter.Error,
+		&deadLetter.Attempts, &deadLetter.CreatedAt, &replayedAt)
+	if err != nil {
+		return deadLetter, fmt.Errorf("failed to scan dead-lettered notification: %w", err)
+	}
+	deadLetter.Message = message
+	if replayedAt.Valid {
+		deadLetter.ReplayedAt = &replayedAt.Time
+	}
+	return deadLetter, nil
+}
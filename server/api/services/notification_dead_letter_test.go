@@ -0,0 +1,148 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+var notificationDeadLetterRowColumns = []string{"id", "queue_name", "msg_id", "message", "error", "attempts", "created_at", "replayed_at"}
+
+func TestDeadLetterNotification(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// The exhausted message is stored with why it failed and removed from its queue in one statement
+	message := `{"type":"escalated","user_id":"user-1","incident_id":"inc-1","retry_count":3}`
+	mockDB.ExpectExec(`WITH dead AS \(\s+INSERT INTO notification_dead_letters .*\)\s+SELECT pgmq.delete\(\$1, \$2::bigint\)`).
+		WithArgs("incident_notifications", int64(42), message, "slack: channel_not_found", 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewNotificationService(pg)
+	err = service.DeadLetterNotification("incident_notifications", 42, []byte(message), "slack: channel_not_found", 4)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestListDeadLettered(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`FROM platform_admins`).
+		WithArgs("admin-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	createdAt := time.Now()
+	mockDB.ExpectQuery(`FROM notification_dead_letters\s+WHERE \(\$1 = '' OR queue_name = \$1\)\s+ORDER BY created_at DESC\s+LIMIT \$2`).
+		WithArgs("incident_notifications", 50).
+		WillReturnRows(sqlmock.NewRows(notificationDeadLetterRowColumns).
+			AddRow("dl-1", "incident_notifications", int64(42), []byte(`{"type":"assigned"}`), "timeout", 4, createdAt, nil))
+
+	// Out of range limits fall back to the default
+	deadLetters, err := NewNotificationService(pg).ListDeadLettered("admin-1", "incident_notifications", 1000)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []db.NotificationDeadLetter{{
+		ID: "dl-1", QueueName: "incident_notifications", MsgID: 42, Message: json.RawMessage(`{"type":"assigned"}`),
+		Error: "timeout", Attempts: 4, CreatedAt: createdAt,
+	}}, deadLetters)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRetryDeadLettered(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// dl-2 was already replayed, so only dl-1 goes back on its queue with a fresh retry count
+	mockDB.ExpectQuery(`FROM platform_admins`).
+		WithArgs("admin-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	now := time.Now()
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery(`UPDATE notification_dead_letters\s+SET replayed_at = NOW\(\), attempts = attempts \+ 1\s+WHERE id::text = ANY\(\$1\) AND replayed_at IS NULL`).
+		WithArgs(`{"dl-1","dl-2"}`).
+		WillReturnRows(sqlmock.NewRows(notificationDeadLetterRowColumns).
+			AddRow("dl-1", "incident_notifications", int64(42), []byte(`{"type":"escalated","user_id":"user-1","retry_count":3}`), "timeout", 5, now, now))
+	mockDB.ExpectExec(`SELECT pgmq.send\(\$1, \$2\)`).
+		WithArgs("incident_notifications", `{"retry_count":0,"type":"escalated","user_id":"user-1"}`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectCommit()
+
+	replayed, err := NewNotificationService(pg).RetryDeadLettered("admin-1", []string{"dl-1", "dl-2"})
+
+	assert.NoError(t, err)
+	if assert.Len(t, replayed, 1) {
+		assert.Equal(t, "dl-1", replayed[0].ID)
+		assert.NotNil(t, replayed[0].ReplayedAt)
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRetryDeadLettered_RequeueFails(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// The claim is rolled back when a message can't be requeued, so it can be retried again
+	mockDB.ExpectQuery(`FROM platform_admins`).
+		WithArgs("admin-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	now := time.Now()
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery("UPDATE notification_dead_letters").
+		WillReturnRows(sqlmock.NewRows(notificationDeadLetterRowColumns).
+			AddRow("dl-1", "incident_notifications", int64(42), []byte(`{"type":"assigned"}`), "timeout", 5, now, now))
+	mockDB.ExpectExec(`SELECT pgmq.send`).
+		WillReturnError(errors.New("queue does not exist"))
+	mockDB.ExpectRollback()
+
+	service := NewNotificationService(pg)
+	_, err = service.RetryDeadLettered("admin-1", []string{"dl-1"})
+	assert.ErrorContains(t, err, "failed to requeue notification dl-1")
+
+	_, err = service.RetryDeadLettered("admin-1", nil)
+	assert.ErrorIs(t, err, ErrNoDeadLetterIDs)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestDeadLettered_RequiresPlatformAdmin(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Dead letters span every organization, so org members outside platform_admins see nothing
+	mockDB.ExpectQuery(`FROM platform_admins`).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mockDB.ExpectQuery(`FROM platform_admins`).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	service := NewNotificationService(pg)
+	deadLetters, err := service.ListDeadLettered("user-1", "", 50)
+	assert.ErrorIs(t, err, ErrPlatformAdminRequired)
+	assert.Nil(t, deadLetters)
+
+	replayed, err := service.RetryDeadLettered("user-1", []string{"dl-1"})
+	assert.ErrorIs(t, err, ErrPlatformAdminRequired)
+	assert.Nil(t, replayed)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Channels a group page can go out on
+const (
+	NotificationMethodSlack = "slack"
+	NotificationMethodPush  = "push"
+	NotificationMethodEmail = "email"
+)
+
+// defaultPageMethods are the channels group pages use when none are given
+var defaultPageMethods = []string{NotificationMethodSlack, NotificationMethodPush}
+
+// ErrInvalidNotificationMethod is returned for a channel notifications can't be sent on
+var ErrInvalidNotificationMethod = errors.New("invalid notification method")
+
+// NotificationService resolves who notifications reach, without sending them
+type NotificationService struct {
+	PG *sql.DB
+}
+
+func NewNotificationService(pg *sql.DB) *NotificationService {
+	return &NotificationService{PG: pg}
+}
+
+// RecipientPreview is a user a group page would reach and on which channels
+type RecipientPreview struct {
+	UserID   string   `json:"user_id"`
+	Name     string   `json:"name"`
+	Email    string   `json:"email,omitempty"`
+	Role     string   `json:"role,omitempty"` // group role; empty for on-call users who aren't members
+	OnCall   bool     `json:"on_call"`
+	Channels []string `json:"channels"`
+
+	// Unreachable maps requested channels the user won't get to why not
+	Unreachable map[string]string `json:"unreachable,omitempty"`
+}
+
+// PreviewGroupNotification lists who a page to the group would notify, on which of the
+// requested methods, without sending anything. Recipients are the group's members and whoever
+// is on call for it now, on-call users first. No methods means the default page channels.
+func (s *NotificationService) PreviewGroupNotification(groupID string, methods []string) ([]RecipientPreview, error) {
+	if len(methods) == 0 {
+		methods = defaultPageMethods
+	}
+	for _, method := range methods {
+		switch method {
+		case NotificationMethodSlack, NotificationMethodPush, NotificationMethodEmail:
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrInvalidNotificationMethod, method)
+		}
+	}
+
+	// Users without notification settings get the defaults: every channel on
+	rows, err := s.PG.Query(`
+		WITH members AS (
+			SELECT user_id, role FROM memberships
+			WHERE resource_type = 'group' AND resource_id = $1
+		), on_call AS (
+			SELECT DISTINCT user_id FROM shifts
+			WHERE group_id = $1 AND is_active = true
+			  AND NOW() BETWEEN start_time AND end_time
+		)
+		SELECT u.id, u.name, COALESCE(u.email, ''), COALESCE(m.role, ''),
+		       oc.user_id IS NOT NULL AS on_call,
+		       COALESCE(u.fcm_token, ''), COALESCE(unc.slack_user_id, ''),
+		       COALESCE(unc.slack_enabled, true), COALESCE(unc.push_enabled, true),
+		       COALESCE(unc.email_enabled, true)
+		FROM users u
+		LEFT JOIN members m ON m.user_id = u.id
+		LEFT JOIN on_call oc ON oc.user_id = u.id
+		LEFT JOIN user_notification_configs unc ON unc.user_id = u.id
+		WHERE u.is_active = true
+		  AND (m.user_id IS NOT NULL OR oc.user_id IS NOT NULL)
+		ORDER BY on_call DESC, u.name ASC
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group recipients: %w", err)
+	}
+	defer rows.Close()
+
+	recipients := []RecipientPreview{}
+	for rows.Next() {
+		var r RecipientPreview
+		var fcmToken, slackUserID string
+		var slackEnabled, pushEnabled, emailEnabled bool
+		if err := rows.Scan(&r.UserID, &r.Name, &r.Email, &r.Role, &r.OnCall,
+			&fcmToken, &slackUserID, &slackEnabled, &pushEnabled, &emailEnabled); err != nil {
+			return nil, fmt.Errorf("failed to scan group recipient: %w", err)
+		}
+
+		r.Channels = []string{}
+		for _, method := range methods {
+			var reason string
+			switch method {
+			case NotificationMethodSlack:
+				reason = unreachableReason(slackEnabled, slackUserID != "", "no Slack user linked")
+			case NotificationMethodPush:
+				reason = unreachableReason(pushEnabled, fcmToken != "", "no device registered")
+			case NotificationMethodEmail:
+				reason = unreachableReason(emailEnabled, r.Email != "", "no email address")
+			}
+			if reason == "" {
+				r.Channels = append(r.Channels, method)
+				continue
+			}
+			if r.Unreachable == nil {
+				r.Unreachable = map[string]string{}
+			}
+			r.Unreachable[method] = reason
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, rows.Err()
+}
+
+// unreachableReason returns why a channel won't reach a user, or "" if it will
+func unreachableReason(enabled, hasAddress bool, missing string) string {
+	switch {
+	case !enabled:
+		return "disabled in notification settings"
+	case !hasAddress:
+		return missing
+	}
+	return ""
+}
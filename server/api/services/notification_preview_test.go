@@ -0,0 +1,83 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var recipientRowColumns = []string{
+	"id", "name", "email", "role", "on_call", "fcm_token", "slack_user_id",
+	"slack_enabled", "push_enabled", "email_enabled",
+}
+
+func TestPreviewGroupNotification(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`WITH members AS .* FROM memberships .* on_call AS .* FROM shifts`).
+		WithArgs("group-1").
+		WillReturnRows(sqlmock.NewRows(recipientRowColumns).
+			// On call without being a member
+			AddRow("user-oncall", "Olive", "olive@example.com", "", true, "fcm-olive", "U01", true, true, true).
+			// Slack turned off, no phone registered
+			AddRow("user-2", "Bob", "bob@example.com", "leader", false, "", "U02", false, true, true).
+			// No notification settings yet: defaults, but no Slack user linked
+			AddRow("user-3", "Carol", "carol@example.com", "member", false, "fcm-carol", "", true, true, true))
+
+	service := NewNotificationService(pg)
+	recipients, err := service.PreviewGroupNotification("group-1", nil)
+
+	assert.NoError(t, err)
+	if assert.Len(t, recipients, 3) {
+		assert.Equal(t, RecipientPreview{
+			UserID: "user-oncall", Name: "Olive", Email: "olive@example.com", OnCall: true,
+			Channels: []string{"slack", "push"},
+		}, recipients[0])
+
+		assert.Equal(t, "leader", recipients[1].Role)
+		assert.Empty(t, recipients[1].Channels)
+		assert.Equal(t, map[string]string{
+			"slack": "disabled in notification settings",
+			"push":  "no device registered",
+		}, recipients[1].Unreachable)
+
+		assert.Equal(t, []string{"push"}, recipients[2].Channels)
+		assert.Equal(t, map[string]string{"slack": "no Slack user linked"}, recipients[2].Unreachable)
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestPreviewGroupNotification_Methods(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`FROM users u`).
+		WithArgs("group-1").
+		WillReturnRows(sqlmock.NewRows(recipientRowColumns).
+			AddRow("user-1", "Alice", "alice@example.com", "member", true, "", "", true, true, true).
+			AddRow("user-2", "Bob", "", "member", false, "fcm-bob", "U02", true, true, false))
+
+	service := NewNotificationService(pg)
+
+	// Only the requested channels are checked
+	recipients, err := service.PreviewGroupNotification("group-1", []string{"email"})
+	assert.NoError(t, err)
+	if assert.Len(t, recipients, 2) {
+		assert.Equal(t, []string{"email"}, recipients[0].Channels)
+		assert.Nil(t, recipients[0].Unreachable)
+		assert.Equal(t, map[string]string{"email": "disabled in notification settings"}, recipients[1].Unreachable)
+	}
+
+	// Unknown channels are rejected before anything is queried
+	_, err = service.PreviewGroupNotification("group-1", []string{"slack", "sms"})
+	assert.ErrorIs(t, err, ErrInvalidNotificationMethod)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// ErrNotificationNotFound is returned when a receipt matches no notification
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// ErrInvalidReceipt is returned for a receipt without a usable status or notification reference
+var ErrInvalidReceipt = errors.New("receipt needs a delivered/read status and a notification_id, or an external_message_id and channel")
+
+// notificationStatusRank orders delivery statuses; receipts never move a notification back
+var notificationStatusRank = map[string]int{
+	db.NotificationStatusPending:   0,
+	db.NotificationStatusFailed:    1,
+	db.NotificationStatusSent:      1,
+	db.NotificationStatusDelivered: 2,
+	db.NotificationStatusRead:      3,
+}
+
+// RecordDeliveryReceipt applies a channel's delivered/read receipt to a notification_logs record
+// and adds it to the incident timeline. userID limits the receipt to that user's notifications,
+// and orgID to notifications about that organization's incidents (channel workers, which pass
+// no user, are limited to their API key's org). Duplicate and out-of-order receipts leave the
+// record as it is.
+func (s *IncidentService) RecordDeliveryReceipt(receipt db.NotificationDeliveryReceipt, userID, orgID string) (*db.NotificationDelivery, error) {
+	if receipt.Status != db.NotificationStatusDelivered && receipt.Status != db.NotificationStatusRead {
+		return nil, ErrInvalidReceipt
+	}
+
+	query := `
+		SELECT id, user_id, COALESCE(incident_id::text, ''), channel, COALESCE(status, ''), delivered_at, read_at
+		FROM notification_logs
+	`
+	var args []interface{}
+	switch {
+	case receipt.NotificationID != "":
+		query += " WHERE id = $1"
+		args = append(args, receipt.NotificationID)
+	case receipt.ExternalMessageID != "" && receipt.Channel != "":
+		query += " WHERE external_message_id = $1 AND channel = $2"
+		args = append(args, receipt.ExternalMessageID, receipt.Channel)
+	default:
+		return nil, ErrInvalidReceipt
+	}
+	if userID != "" {
+		query += fmt.Sprintf(" AND user_id = $%d", len(args)+1)
+		args = append(args, userID)
+	}
+	if orgID != "" {
+		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM incidents i WHERE i.id = notification_logs.incident_id AND i.organization_id = $%d)", len(args)+1)
+		args = append(args, orgID)
+	}
+
+	var delivery db.NotificationDelivery
+	var deliveredAt, readAt sql.NullTime
+	err := s.PG.QueryRow(query, args...).Scan(
+		&delivery.ID, &delivery.UserID, &delivery.IncidentID, &delivery.Channel, &delivery.Status,
+		&deliveredAt, &readAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotificationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification: %w", err)
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	if readAt.Valid {
+		delivery.ReadAt = &readAt.Time
+	}
+
+	if notificationStatusRank[receipt.Status] <= notificationStatusRank[delivery.Status] {
+		log.Printf("DEBUG: Ignoring %s receipt for notification %s already %s", receipt.Status, delivery.ID, delivery.Status)
+		return &delivery, nil
+	}
+
+	at := time.Now().UTC()
+	if receipt.Timestamp != nil {
+		at = receipt.Timestamp.UTC()
+	}
+
+	// A read receipt implies delivery, so delivered_at is filled in either way
+	result, err := s.PG.Exec(`
+		UPDATE notification_logs
+		SET status = $1,
+		    delivered_at = COALESCE(delivered_at, $2),
+		    read_at = CASE WHEN $1 = 'read' THEN COALESCE(read_at, $2) ELSE read_at END,
+		    updated_at = NOW()
+		WHERE id = $3 AND COALESCE(status, '') = $4
+	`, receipt.Status, at, delivery.ID, delivery.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update notification status: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		// Another receipt got there first
+		return &delivery, nil
+	}
+
+	delivery.Status = receipt.Status
+	if delivery.DeliveredAt == nil {
+		delivery.DeliveredAt = &at
+	}
+	eventType := db.IncidentEventNotificationDelivered
+	if receipt.Status == db.NotificationStatusRead {
+		eventType = db.IncidentEventNotificationRead
+		if delivery.ReadAt == nil {
+			delivery.ReadAt = &at
+		}
+	}
+
+	if delivery.IncidentID != "" {
+		if err := s.createIncidentEvent(delivery.IncidentID, eventType, map[string]interface{}{
+			"notification_id": delivery.ID,
+			"channel":         delivery.Channel,
+			"user_id":         delivery.UserID,
+			"at":              at.Format(time.RFC3339),
+		}, ""); err != nil {
+			log.Printf("WARNING: Failed to record %s event for incident %s: %v", eventType, delivery.IncidentID, err)
+		}
+	}
+
+	return &delivery, nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+var notificationReceiptColumns = []string{"id", "user_id", "incident_id", "channel", "status", "delivered_at", "read_at"}
+
+func TestRecordDeliveryReceipt_Delivered(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	at := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+
+	mockDB.ExpectQuery(`FROM notification_logs\s+WHERE external_message_id = \$1 AND channel = \$2 AND EXISTS \(SELECT 1 FROM incidents i WHERE i\.id = notification_logs\.incident_id AND i\.organization_id = \$3\)$`).
+		WithArgs("1760693400.000100", "slack", "org-1").
+		WillReturnRows(sqlmock.NewRows(notificationReceiptColumns).
+			AddRow("notif-1", "user-1", "inc-1", "slack", "sent", nil, nil))
+	mockDB.ExpectExec(`UPDATE notification_logs\s+SET status = \$1,\s+delivered_at = COALESCE\(delivered_at, \$2\)`).
+		WithArgs("delivered", at, "notif-1", "sent").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", db.IncidentEventNotificationDelivered, `{"at":"2026-10-17T09:30:00Z","channel":"slack","notification_id":"notif-1","user_id":"user-1"}`, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	delivery, err := service.RecordDeliveryReceipt(db.NotificationDeliveryReceipt{
+		ExternalMessageID: "1760693400.000100",
+		Channel:           "slack",
+		Status:            "delivered",
+		Timestamp:         &at,
+	}, "", "org-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "delivered", delivery.Status)
+	assert.Equal(t, &at, delivery.DeliveredAt)
+	assert.Nil(t, delivery.ReadAt)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRecordDeliveryReceipt_ReadByRecipient(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`FROM notification_logs\s+WHERE id = \$1 AND user_id = \$2`).
+		WithArgs("notif-1", "user-1").
+		WillReturnRows(sqlmock.NewRows(notificationReceiptColumns).
+			AddRow("notif-1", "user-1", "inc-1", "fcm", "delivered", time.Now(), nil))
+	mockDB.ExpectExec("UPDATE notification_logs").
+		WithArgs("read", sqlmock.AnyArg(), "notif-1", "delivered").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs("inc-1", db.IncidentEventNotificationRead, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIncidentService(pg, nil, nil)
+	delivery, err := service.RecordDeliveryReceipt(db.NotificationDeliveryReceipt{NotificationID: "notif-1", Status: "read"}, "user-1", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "read", delivery.Status)
+	assert.NotNil(t, delivery.ReadAt)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRecordDeliveryReceipt_OutOfOrder(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// A late delivered receipt doesn't undo a read
+	mockDB.ExpectQuery("FROM notification_logs").
+		WithArgs("notif-1", "org-1").
+		WillReturnRows(sqlmock.NewRows(notificationReceiptColumns).
+			AddRow("notif-1", "user-1", "inc-1", "slack", "read", time.Now(), time.Now()))
+
+	service := NewIncidentService(pg, nil, nil)
+	delivery, err := service.RecordDeliveryReceipt(db.NotificationDeliveryReceipt{NotificationID: "notif-1", Status: "delivered"}, "", "org-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "read", delivery.Status)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRecordDeliveryReceipt_Errors(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Someone else's notification
+	mockDB.ExpectQuery("FROM notification_logs").
+		WithArgs("notif-1", "user-2").
+		WillReturnRows(sqlmock.NewRows(notificationReceiptColumns))
+
+	// Another organization's notification, reported with an API key
+	mockDB.ExpectQuery(`AND i\.organization_id = \$2\)$`).
+		WithArgs("notif-1", "org-2").
+		WillReturnRows(sqlmock.NewRows(notificationReceiptColumns))
+
+	service := NewIncidentService(pg, nil, nil)
+	_, err = service.RecordDeliveryReceipt(db.NotificationDeliveryReceipt{NotificationID: "notif-1", Status: "read"}, "user-2", "")
+	assert.ErrorIs(t, err, ErrNotificationNotFound)
+
+	_, err = service.RecordDeliveryReceipt(db.NotificationDeliveryReceipt{NotificationID: "notif-1", Status: "delivered"}, "", "org-2")
+	assert.ErrorIs(t, err, ErrNotificationNotFound)
+
+	_, err = service.RecordDeliveryReceipt(db.NotificationDeliveryReceipt{ExternalMessageID: "msg-1", Status: "read"}, "", "org-1")
+	assert.ErrorIs(t, err, ErrInvalidReceipt)
+
+	_, err = service.RecordDeliveryReceipt(db.NotificationDeliveryReceipt{NotificationID: "notif-1", Status: "sent"}, "", "org-1")
+	assert.ErrorIs(t, err, ErrInvalidReceipt)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
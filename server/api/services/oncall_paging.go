@@ -0,0 +1,94 @@
+package services
+
+import (
+	"database/sql"
+	"log"
+	"sort"
+	"time"
+)
+
+// onCallCandidate is a user currently on call, with when they were last paged for the group
+type onCallCandidate struct {
+	UserID      string
+	StartTime   time.Time
+	SchedulerID string
+	Layer       string // The shift's rotation cycle; empty for one-off shifts
+	LastPagedAt sql.NullTime
+}
+
+// pickLeastRecentlyPaged returns the candidate to page. Schedule precedence decides first:
+// candidates come ordered by shift start and only those sharing the first one's scheduler and
+// rotation layer are interchangeable, so a backup rotation is never paged ahead of the primary.
+// Among those, never-paged users go first, then the one paged longest ago; ties keep the
+// earliest shift start.
+func pickLeastRecentlyPaged(candidates []onCallCandidate) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	var equivalent []onCallCandidate
+	for _, candidate := range candidates {
+		if candidate.SchedulerID == candidates[0].SchedulerID && candidate.Layer == candidates[0].Layer {
+			equivalent = append(equivalent, candidate)
+		}
+	}
+
+	sort.SliceStable(equivalent, func(i, j int) bool {
+		a, b := equivalent[i], equivalent[j]
+		if a.LastPagedAt.Valid != b.LastPagedAt.Valid {
+			return !a.LastPagedAt.Valid
+		}
+		if a.LastPagedAt.Valid && !a.LastPagedAt.Time.Equal(b.LastPagedAt.Time) {
+			return a.LastPagedAt.Time.Before(b.LastPagedAt.Time)
+		}
+		return a.StartTime.Before(b.StartTime)
+	})
+
+	return equivalent[0].UserID
+}
+
+// queryOnCallCandidates runs a query returning
+// (user_id, start_time, scheduler_id, rotation_cycle_id, last_paged_at) rows
+func (s *IncidentService) queryOnCallCandidates(query string, args ...interface{}) ([]onCallCandidate, error) {
+	rows, err := s.PG.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []onCallCandidate
+	for rows.Next() {
+		var candidate onCallCandidate
+		var schedulerID, layer sql.NullString
+		if err := rows.Scan(&candidate.UserID, &candidate.StartTime, &schedulerID, &layer, &candidate.LastPagedAt); err != nil {
+			return nil, err
+		}
+		candidate.SchedulerID = schedulerID.String
+		candidate.Layer = layer.String
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, rows.Err()
+}
+
+// recordPage remembers that a user was just paged for a group
+func (s *IncidentService) recordPage(groupID, userID string) {
+	recordOncallPage(s.PG, groupID, userID)
+}
+
+// recordOncallPage remembers that a user was just paged for a group, for the least recently
+// paged pick and round robin groups
+func recordOncallPage(pg *sql.DB, groupID, userID string) {
+	if groupID == "" || userID == "" {
+		return
+	}
+
+	_, err := pg.Exec(`
+		INSERT INTO oncall_pages (group_id, user_id, last_paged_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (group_id, user_id) DO UPDATE SET last_paged_at = EXCLUDED.last_paged_at
+	`, groupID, userID)
+	if err != nil {
+		log.Printf("WARNING: Failed to record page of user %s in group %s: %v", userID, groupID, err)
+	}
+}
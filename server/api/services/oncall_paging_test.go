@@ -0,0 +1,92 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickLeastRecentlyPaged(t *testing.T) {
+	now := time.Now()
+	pagedAt := func(ago time.Duration) sql.NullTime {
+		return sql.NullTime{Time: now.Add(-ago), Valid: true}
+	}
+
+	// The user paged longer ago wins regardless of shift order
+	assert.Equal(t, "user-2", pickLeastRecentlyPaged([]onCallCandidate{
+		{UserID: "user-1", StartTime: now.Add(-2 * time.Hour), LastPagedAt: pagedAt(10 * time.Minute)},
+		{UserID: "user-2", StartTime: now.Add(-time.Hour), LastPagedAt: pagedAt(3 * time.Hour)},
+	}))
+
+	// Never paged beats paged
+	assert.Equal(t, "user-3", pickLeastRecentlyPaged([]onCallCandidate{
+		{UserID: "user-1", StartTime: now.Add(-2 * time.Hour), LastPagedAt: pagedAt(3 * time.Hour)},
+		{UserID: "user-3", StartTime: now.Add(-time.Hour)},
+	}))
+
+	// Without page history the earliest shift keeps precedence
+	assert.Equal(t, "user-1", pickLeastRecentlyPaged([]onCallCandidate{
+		{UserID: "user-1", StartTime: now.Add(-2 * time.Hour)},
+		{UserID: "user-2", StartTime: now.Add(-time.Hour)},
+	}))
+
+	// A backup layer is never picked over the primary, however recently the primary was paged
+	assert.Equal(t, "user-1", pickLeastRecentlyPaged([]onCallCandidate{
+		{UserID: "user-1", StartTime: now.Add(-2 * time.Hour), SchedulerID: "sched-1", Layer: "primary", LastPagedAt: pagedAt(time.Minute)},
+		{UserID: "user-2", StartTime: now.Add(-time.Hour), SchedulerID: "sched-1", Layer: "backup"},
+		{UserID: "user-3", StartTime: now.Add(-time.Hour), SchedulerID: "sched-2", Layer: "primary"},
+	}))
+
+	assert.Empty(t, pickLeastRecentlyPaged(nil))
+}
+
+func TestGetCurrentOnCallUserFromGroup_PrefersLeastRecentlyPaged(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	now := time.Now()
+	mockDB.ExpectQuery(`LEFT JOIN oncall_pages p ON p\.group_id = es\.group_id AND p\.user_id = es\.effective_user_id`).
+		WithArgs("group-1").
+		WillReturnRows(sqlmock.NewRows([]string{"effective_user_id", "start_time", "scheduler_id", "rotation_cycle_id", "last_paged_at"}).
+			AddRow("user-1", now.Add(-2*time.Hour), "sched-1", "rot-1", now.Add(-5*time.Minute)).
+			AddRow("user-2", now.Add(-time.Hour), "sched-1", "rot-1", now.Add(-2*time.Hour)).
+			AddRow("user-3", now.Add(-time.Hour), "sched-2", nil, nil))
+
+	service := NewIncidentService(pg, nil, nil)
+	userID, err := service.getCurrentOnCallUserFromGroup("group-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user-2", userID)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestSendAssignmentNotification_RecordsPage(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec(`INSERT INTO oncall_pages .* ON CONFLICT \(group_id, user_id\) DO UPDATE`).
+		WithArgs("group-1", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAssigneeChannels(mockDB, "user-1", false, true)
+
+	notifier := &assignedNotifier{assigned: make(chan string, 1)}
+	service := NewIncidentService(pg, nil, nil)
+	service.SetNotificationWorker(notifier)
+
+	incident := newServiceIncident("critical")
+	incident.ID = "inc-1"
+	incident.GroupID = "group-1"
+	service.sendAssignmentNotification(incident, true, false)
+
+	assert.Equal(t, "user-1", <-notifier.assigned)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+)
+
+// PriorityMatrix maps severity → urgency → priority (P1-P5)
+type PriorityMatrix map[string]map[string]string
+
+// DefaultPriorityMatrix is the PagerDuty-style severity × urgency matrix used
+// unless the service or the organization overrides it
+var DefaultPriorityMatrix = PriorityMatrix{
+	"critical": {"high": "P1", "low": "P2"},
+	"error":    {"high": "P2", "low": "P3"},
+	"warning":  {"high": "P3", "low": "P4"},
+	"info":     {"high": "P4", "low": "P5"},
+}
+
+var validPriority = regexp.MustCompile(`^P[1-5]$`)
+
+// Lookup returns the priority for a severity/urgency pair, or "" when the matrix has no entry
+func (m PriorityMatrix) Lookup(severity, urgency string) string {
+	return m[severity][urgency]
+}
+
+// merge returns a copy of m with the cells from overrides applied
+func (m PriorityMatrix) merge(overrides PriorityMatrix) PriorityMatrix {
+	merged := make(PriorityMatrix, len(m))
+	for severity, row := range m {
+		merged[severity] = make(map[string]string, len(row))
+		for urgency, priority := range row {
+			merged[severity][urgency] = priority
+		}
+	}
+	for severity, row := range overrides {
+		if merged[severity] == nil {
+			merged[severity] = make(map[string]string, len(row))
+		}
+		for urgency, priority := range row {
+			merged[severity][urgency] = priority
+		}
+	}
+	return merged
+}
+
+// ComputePriority derives an incident priority from its severity and urgency.
+// Returns "" when the combination isn't in the matrix.
+func (s *IncidentService) ComputePriority(severity, urgency string) string {
+	return s.priorityMatrix().Lookup(severity, urgency)
+}
+
+// priorityMatrix returns the service-wide matrix, falling back to the default
+func (s *IncidentService) priorityMatrix() PriorityMatrix {
+	if s.PriorityMatrix != nil {
+		return s.PriorityMatrix
+	}
+	return DefaultPriorityMatrix
+}
+
+// computeOrgPriority is ComputePriority with the organization's overrides from
+// organizations.settings->'priority_matrix' applied on top of the service-wide matrix
+func (s *IncidentService) computeOrgPriority(orgID, severity, urgency string) string {
+	matrix := s.priorityMatrix()
+	if orgID == "" {
+		return matrix.Lookup(severity, urgency)
+	}
+
+	overrides, err := s.getOrgPriorityMatrix(orgID)
+	if err != nil {
+		log.Printf("WARNING: Failed to load priority matrix for org %s, using default: %v", orgID, err)
+		return matrix.Lookup(severity, urgency)
+	}
+
+	return matrix.merge(overrides).Lookup(severity, urgency)
+}
+
+// getOrgPriorityMatrix returns the priority matrix cells an organization overrides
+func (s *IncidentService) getOrgPriorityMatrix(orgID string) (PriorityMatrix, error) {
+	var raw sql.NullString
+	err := s.PG.QueryRow(`
+		SELECT settings->'priority_matrix'
+		FROM organizations
+		WHERE id = $1
+	`, orgID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get priority matrix: %w", err)
+	}
+
+	if !raw.Valid || raw.String == "" {
+		return nil, nil
+	}
+
+	var overrides PriorityMatrix
+	if err := json.Unmarshal([]byte(raw.String), &overrides); err != nil {
+		return nil, fmt.Errorf("invalid priority_matrix: %w", err)
+	}
+	for severity, row := range overrides {
+		for urgency, priority := range row {
+			if !validPriority.MatchString(priority) {
+				return nil, fmt.Errorf("invalid priority '%s' for %s/%s", priority, severity, urgency)
+			}
+		}
+	}
+
+	return overrides, nil
+}
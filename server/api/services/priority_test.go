@@ -0,0 +1,81 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputePriority_DefaultMatrix(t *testing.T) {
+	service := NewIncidentService(nil, nil, nil)
+
+	cases := []struct {
+		severity string
+		urgency  string
+		want     string
+	}{
+		{"critical", "high", "P1"},
+		{"critical", "low", "P2"},
+		{"error", "high", "P2"},
+		{"error", "low", "P3"},
+		{"warning", "high", "P3"},
+		{"warning", "low", "P4"},
+		{"info", "high", "P4"},
+		{"info", "low", "P5"},
+		{"unknown", "high", ""},
+		{"critical", "", ""},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, service.ComputePriority(tc.severity, tc.urgency), "%s/%s", tc.severity, tc.urgency)
+	}
+}
+
+func TestComputePriority_ServiceMatrix(t *testing.T) {
+	service := NewIncidentService(nil, nil, nil)
+	service.PriorityMatrix = PriorityMatrix{"critical": {"low": "P1"}}
+
+	assert.Equal(t, "P1", service.ComputePriority("critical", "low"))
+	assert.Equal(t, "", service.ComputePriority("warning", "low"))
+}
+
+func TestComputeOrgPriority_OrgOverride(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`SELECT settings->'priority_matrix'`).
+		WithArgs("org-1").
+		WillReturnRows(sqlmock.NewRows([]string{"priority_matrix"}).AddRow(`{"warning": {"high": "P2"}}`))
+	mockDB.ExpectQuery(`SELECT settings->'priority_matrix'`).
+		WithArgs("org-1").
+		WillReturnRows(sqlmock.NewRows([]string{"priority_matrix"}).AddRow(`{"warning": {"high": "P2"}}`))
+
+	service := NewIncidentService(pg, nil, nil)
+
+	assert.Equal(t, "P2", service.computeOrgPriority("org-1", "warning", "high"))
+	// Cells the org doesn't override keep the default
+	assert.Equal(t, "P4", service.computeOrgPriority("org-1", "warning", "low"))
+	assert.Equal(t, "P3", DefaultPriorityMatrix.Lookup("warning", "high"))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestComputeOrgPriority_InvalidOverrideFallsBack(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`SELECT settings->'priority_matrix'`).
+		WithArgs("org-1").
+		WillReturnRows(sqlmock.NewRows([]string{"priority_matrix"}).AddRow(`{"critical": {"high": "urgent"}}`))
+
+	service := NewIncidentService(pg, nil, nil)
+
+	assert.Equal(t, "P1", service.computeOrgPriority("org-1", "critical", "high"))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
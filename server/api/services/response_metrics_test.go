@@ -0,0 +1,74 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func responseMetricsColumns() []string {
+	return []string{"id", "name", "incident_count", "acknowledged_count", "resolved_count", "avg_mtta_minutes", "avg_mttr_minutes"}
+}
+
+func TestGetResponseMetricsByGroup(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectQuery(`LEFT JOIN groups t ON i\.group_id = t\.id\s+WHERE i\.created_at >= NOW\(\) - \$1::interval AND i\.organization_id = \$2\s+AND i\.group_id IS NOT NULL\s+GROUP BY i\.group_id, t\.name\s+ORDER BY avg_mtta_minutes DESC NULLS LAST`).
+		WithArgs("30 days", "org-1").
+		WillReturnRows(sqlmock.NewRows(responseMetricsColumns()).
+			AddRow("group-2", "Database", 4, 4, 3, 42.5, 120.0).
+			AddRow("group-1", "Frontend", 2, 0, 0, nil, nil))
+
+	service := NewIncidentService(pg, nil, nil)
+	metrics, err := service.GetResponseMetricsByGroup("org-1", "30d")
+
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, "Database", metrics[0].Name)
+	assert.Equal(t, 42.5, *metrics[0].AvgMTTAMinutes)
+	assert.Equal(t, 3, metrics[0].ResolvedCount)
+	assert.Nil(t, metrics[1].AvgMTTAMinutes)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetResponseMetricsByService(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Unknown ranges fall back to 7 days like the trends
+	mockDB.ExpectQuery(`LEFT JOIN services t ON i\.service_id = t\.id`).
+		WithArgs("7 days", "org-1").
+		WillReturnRows(sqlmock.NewRows(responseMetricsColumns()).
+			AddRow("svc-1", "Checkout", 3, 2, 1, 5.0, 30.0))
+
+	service := NewIncidentService(pg, nil, nil)
+	metrics, err := service.GetResponseMetricsByService("org-1", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []ResponseMetrics{{
+		ID: "svc-1", Name: "Checkout", IncidentCount: 3, AcknowledgedCount: 2, ResolvedCount: 1,
+		AvgMTTAMinutes: floatPtr(5), AvgMTTRMinutes: floatPtr(30),
+	}}, metrics)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetResponseMetrics_NoOrg(t *testing.T) {
+	service := NewIncidentService(nil, nil, nil)
+
+	metrics, err := service.GetResponseMetricsByGroup("", "7d")
+
+	assert.NoError(t, err)
+	assert.Empty(t, metrics)
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
@@ -3,7 +3,9 @@ package services
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"regexp"
 	"strings"
 	"time"
@@ -12,12 +14,17 @@ import (
 	"github.com/phonginreallife/inres/db"
 )
 
+// ErrNoRouteMatched is returned when testing alert attributes no routing rule matches
+var ErrNoRouteMatched = errors.New("no routing rule would match for given attributes")
+
 type RoutingService struct {
 	PG *sql.DB
+
+	now func() time.Time
 }
 
 func NewRoutingService(pg *sql.DB) *RoutingService {
-	return &RoutingService{PG: pg}
+	return &RoutingService{PG: pg, now: time.Now}
 }
 
 // ROUTING TABLE MANAGEMENT
@@ -116,8 +123,8 @@ func (s *RoutingService) GetRoutingTableWithRules(id string) (*db.RoutingTableWi
 	}, nil
 }
 
-// CreateRoutingTable creates a new routing table
-func (s *RoutingService) CreateRoutingTable(req db.CreateRoutingTableRequest, createdBy string) (*db.AlertRoutingTable, error) {
+// CreateRoutingTable creates a new routing table for an organization's alerts
+func (s *RoutingService) CreateRoutingTable(orgID string, req db.CreateRoutingTableRequest, createdBy string) (*db.AlertRoutingTable, error) {
 	id := uuid.New().String()
 	now := time.Now()
 
@@ -134,9 +141,9 @@ func (s *RoutingService) CreateRoutingTable(req db.CreateRoutingTableRequest, cr
 	}
 
 	_, err := s.PG.Exec(`
-		INSERT INTO alert_routing_tables (id, name, description, priority, created_at, updated_at, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, id, req.Name, req.Description, priority, now, now, createdByParam)
+		INSERT INTO alert_routing_tables (id, name, description, priority, created_at, updated_at, created_by, organization_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, id, req.Name, req.Description, priority, now, now, createdByParam, nullIfEmpty(orgID))
 
 	if err != nil {
 		return nil, err
@@ -382,85 +389,101 @@ func (s *RoutingService) GetRoutingRule(id string) (*db.AlertRoutingRule, error)
 
 // ROUTING ENGINE - CORE LOGIC
 
-// RouteAlert evaluates routing tables and returns routing result
-func (s *RoutingService) RouteAlert(alert *db.Alert) (*db.RoutingResult, error) {
-	startTime := time.Now()
-
+// RouteAlert evaluates an organization's routing tables and returns routing result
+func (s *RoutingService) RouteAlert(orgID string, alert *db.Alert) (*db.RoutingResult, error) {
 	// Convert alert to attributes for evaluation
 	alertAttrs := s.convertAlertToAttributes(alert)
 
-	// Get all active routing tables (sorted by priority)
-	tables, err := s.getActiveRoutingTablesForEvaluation()
+	result, err := s.findRoute(orgID, alertAttrs, s.now(), "Matched")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get routing tables: %w", err)
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("no routing rule matched for alert: %s", alert.ID)
 	}
 
-	// Evaluate tables in priority order
-	for _, table := range tables {
-		rules, err := s.getActiveRulesForTable(table.ID)
-		if err != nil {
-			continue
-		}
+	// Log the match
+	s.logRouteMatch(alert.ID, result.MatchedTable, result.MatchedRule, alertAttrs, result.EvaluationTimeMs)
+	return result, nil
+}
 
-		// Evaluate rules in priority order
-		for _, rule := range rules {
-			if s.evaluateRule(alertAttrs, &rule) {
-				evaluationTime := int(time.Since(startTime).Milliseconds())
-
-				// Log the match
-				s.logRouteMatch(alert.ID, &table, &rule, alertAttrs, evaluationTime)
-
-				return &db.RoutingResult{
-					TargetGroupID:    rule.TargetGroupID,
-					EscalationRuleID: rule.EscalationRuleID,
-					MatchedRule:      &rule,
-					MatchedTable:     &table,
-					MatchedReason:    fmt.Sprintf("Matched rule '%s' in table '%s'", rule.Name, table.Name),
-					EvaluationTimeMs: evaluationTime,
-				}, nil
-			}
-		}
+// Evaluate walks the organization's active routing tables by priority and returns the first
+// rule the alert matches, logged under its fingerprint, or nil if none does
+func (s *RoutingService) Evaluate(orgID string, alert db.AlertAttributes) (*db.RoutingResult, error) {
+	result, err := s.findRoute(orgID, alert, s.now(), "Matched")
+	if err != nil || result == nil {
+		return nil, err
 	}
 
-	// No match found - return error or default
-	return nil, fmt.Errorf("no routing rule matched for alert: %s", alert.ID)
+	s.logRouteMatch(alert.Fingerprint, result.MatchedTable, result.MatchedRule, alert, result.EvaluationTimeMs)
+	return result, nil
 }
 
-// TestRouting tests routing for given alert attributes without creating logs
-func (s *RoutingService) TestRouting(attrs db.AlertAttributes) (*db.RoutingResult, error) {
+// TestRouting shows which of the organization's rules alert attributes would be routed by, and
+// why, as Evaluate would right now, without logging the match or creating anything. Returns
+// ErrNoRouteMatched when no rule matches. Other organizations' tables are never considered.
+func (s *RoutingService) TestRouting(orgID string, attrs db.AlertAttributes) (*db.RoutingResult, error) {
+	result, err := s.findRoute(orgID, attrs, s.now(), "Would match")
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, ErrNoRouteMatched
+	}
+	return result, nil
+}
+
+// findRoute evaluates the organization's active tables in priority order, then their rules in
+// priority order, and returns the first match or nil. reason prefixes MatchedReason.
+func (s *RoutingService) findRoute(orgID string, attrs db.AlertAttributes, now time.Time, reason string) (*db.RoutingResult, error) {
+	if orgID == "" {
+		return nil, nil // Routing tables belong to an organization
+	}
 	startTime := time.Now()
 
-	// Get all active routing tables (sorted by priority)
-	tables, err := s.getActiveRoutingTablesForEvaluation()
+	// Get the organization's active routing tables (sorted by priority)
+	tables, err := s.getActiveRoutingTablesForEvaluation(orgID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get routing tables: %w", err)
 	}
 
-	// Evaluate tables in priority order
 	for _, table := range tables {
 		rules, err := s.getActiveRulesForTable(table.ID)
 		if err != nil {
 			continue
 		}
 
-		// Evaluate rules in priority order
 		for _, rule := range rules {
-			if s.evaluateRule(attrs, &rule) {
-				evaluationTime := int(time.Since(startTime).Milliseconds())
-
-				return &db.RoutingResult{
-					TargetGroupID:    rule.TargetGroupID,
-					EscalationRuleID: rule.EscalationRuleID,
-					MatchedRule:      &rule,
-					MatchedTable:     &table,
-					MatchedReason:    fmt.Sprintf("Would match rule '%s' in table '%s'", rule.Name, table.Name),
-					EvaluationTimeMs: evaluationTime,
-				}, nil
+			if !s.evaluateRule(attrs, &rule, now) {
+				continue
 			}
+
+			return &db.RoutingResult{
+				TargetGroupID:    rule.TargetGroupID,
+				EscalationRuleID: rule.EscalationRuleID,
+				MatchedRule:      &rule,
+				MatchedTable:     &table,
+				MatchedReason:    matchedReason(reason, &table, &rule),
+				EvaluationTimeMs: int(time.Since(startTime).Milliseconds()),
+			}, nil
 		}
 	}
 
-	return nil, fmt.Errorf("no routing rule would match for given attributes")
+	return nil, nil
+}
+
+// matchedReason explains a match: which rule of which table, and the conditions it met
+func matchedReason(prefix string, table *db.AlertRoutingTable, rule *db.AlertRoutingRule) string {
+	reason := fmt.Sprintf("%s rule '%s' in table '%s' (priority %d)", prefix, rule.Name, table.Name, rule.Priority)
+	if len(rule.MatchConditions) > 0 {
+		conditions, _ := json.Marshal(rule.MatchConditions)
+		reason += fmt.Sprintf(": alert matches %s", conditions)
+	}
+	if len(rule.TimeConditions) > 0 {
+		conditions, _ := json.Marshal(rule.TimeConditions)
+		reason += fmt.Sprintf(", at a time matching %s", conditions)
+	}
+	return reason
 }
 
 // INTERNAL HELPER METHODS
@@ -479,16 +502,16 @@ func (s *RoutingService) convertAlertToAttributes(alert *db.Alert) db.AlertAttri
 	return attrs
 }
 
-// getActiveRoutingTablesForEvaluation gets active routing tables sorted by priority
-func (s *RoutingService) getActiveRoutingTablesForEvaluation() ([]db.AlertRoutingTable, error) {
+// getActiveRoutingTablesForEvaluation gets an organization's active routing tables sorted by priority
+func (s *RoutingService) getActiveRoutingTablesForEvaluation(orgID string) ([]db.AlertRoutingTable, error) {
 	query := `
 		SELECT id, name, description, is_active, priority, created_at, updated_at, created_by
 		FROM alert_routing_tables 
-		WHERE is_active = true 
+		WHERE is_active = true AND organization_id = $1
 		ORDER BY priority DESC, created_at ASC
 	`
 
-	rows, err := s.PG.Query(query)
+	rows, err := s.PG.Query(query, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -522,10 +545,10 @@ func (s *RoutingService) getActiveRulesForTable(tableID string) ([]db.AlertRouti
 	return s.ListRoutingRules(tableID, true)
 }
 
-// evaluateRule evaluates if alert attributes match a routing rule
-func (s *RoutingService) evaluateRule(attrs db.AlertAttributes, rule *db.AlertRoutingRule) bool {
+// evaluateRule evaluates if alert attributes match a routing rule at the given time
+func (s *RoutingService) evaluateRule(attrs db.AlertAttributes, rule *db.AlertRoutingRule, now time.Time) bool {
 	// First check time conditions
-	if !s.evaluateTimeConditions(rule.TimeConditions) {
+	if !timeConditionMatches(rule.TimeConditions, now) {
 		return false
 	}
 
@@ -533,30 +556,133 @@ func (s *RoutingService) evaluateRule(attrs db.AlertAttributes, rule *db.AlertRo
 	return s.evaluateMatchConditions(attrs, rule.MatchConditions)
 }
 
-// evaluateTimeConditions evaluates time-based conditions
-func (s *RoutingService) evaluateTimeConditions(timeConditions map[string]interface{}) bool {
-	if len(timeConditions) == 0 {
+// Business hours used by the business_hours shortcut, in the rule's timezone
+const (
+	businessDayStart = 9 * 60  // 09:00
+	businessDayEnd   = 17 * 60 // 17:00
+)
+
+// timeConditionMatches reports whether a rule's time conditions hold at now. Every condition
+// given must hold:
+//
+//	{"timezone": "Europe/Berlin", "business_hours": true}
+//	{"timezone": "America/New_York", "hours": {"start": "22:00", "end": "06:00"}}
+//
+// business_hours is 09:00-17:00 Monday to Friday, or {"start": ..., "end": ...} on weekdays;
+// weekdays and weekends restrict the day; hours is a window whose end is exclusive and which
+// wraps midnight when it ends before it starts; days lists weekday names. Times are an hour
+// (9) or "HH:MM" on the wall clock of timezone (UTC when unset), so windows follow DST.
+func timeConditionMatches(conditions map[string]interface{}, now time.Time) bool {
+	if len(conditions) == 0 {
 		return true // No time conditions means always match
 	}
 
-	now := time.Now()
+	loc := time.UTC
+	if tz, ok := conditions[db.TimeConditionTimezone].(string); ok && tz != "" {
+		var err error
+		loc, err = time.LoadLocation(tz)
+		if err != nil {
+			log.Printf("WARNING: Ignoring routing time conditions with invalid timezone %q: %v", tz, err)
+			return false
+		}
+	}
+	now = now.In(loc)
 
-	// Business hours check
-	if businessHours, ok := timeConditions[db.TimeConditionBusinessHours]; ok {
-		if bh, ok := businessHours.(bool); ok && bh {
-			hour := now.Hour()
-			weekday := now.Weekday()
-			// Simple business hours: 9-17, Mon-Fri
-			if weekday == time.Saturday || weekday == time.Sunday || hour < 9 || hour >= 17 {
-				return false
+	minute := now.Hour()*60 + now.Minute()
+	weekday := now.Weekday()
+	weekend := weekday == time.Saturday || weekday == time.Sunday
+
+	switch v := conditions[db.TimeConditionBusinessHours].(type) {
+	case bool:
+		if v && (weekend || !inDayWindow(minute, businessDayStart, businessDayEnd)) {
+			return false
+		}
+	case map[string]interface{}:
+		start, end, ok := dayWindow(v)
+		if !ok {
+			log.Printf("WARNING: Ignoring routing time conditions with invalid business_hours %v", v)
+			return false
+		}
+		if weekend || !inDayWindow(minute, start, end) {
+			return false
+		}
+	}
+	if enabled, _ := conditions[db.TimeConditionWeekdays].(bool); enabled && weekend {
+		return false
+	}
+	if enabled, _ := conditions[db.TimeConditionWeekends].(bool); enabled && !weekend {
+		return false
+	}
+
+	if hours, ok := conditions[db.TimeConditionHours].(map[string]interface{}); ok {
+		start, end, ok := dayWindow(hours)
+		if !ok {
+			log.Printf("WARNING: Ignoring routing time conditions with invalid hours %v", hours)
+			return false
+		}
+		if !inDayWindow(minute, start, end) {
+			return false
+		}
+	}
+
+	if days, ok := conditions[db.TimeConditionDays].([]interface{}); ok {
+		matched := false
+		for _, day := range days {
+			if name, ok := day.(string); ok && strings.EqualFold(name, weekday.String()) {
+				matched = true
+				break
 			}
 		}
+		if !matched {
+			return false
+		}
 	}
 
-	// Add more time condition evaluations as needed
 	return true
 }
 
+// dayWindow reads the start and end of a {"start": ..., "end": ...} window as minutes of the day
+func dayWindow(window map[string]interface{}) (start, end int, ok bool) {
+	start, okStart := dayMinute(window["start"])
+	end, okEnd := dayMinute(window["end"])
+	return start, end, okStart && okEnd
+}
+
+// dayMinute reads an hour (9, 9.5) or an "HH:MM" time as minutes since midnight
+func dayMinute(v interface{}) (int, bool) {
+	switch t := v.(type) {
+	case float64:
+		if t < 0 || t > 24 {
+			return 0, false
+		}
+		return int(t * 60), true
+	case int:
+		if t < 0 || t > 24 {
+			return 0, false
+		}
+		return t * 60, true
+	case string:
+		parsed, err := time.Parse("15:04", t)
+		if err != nil {
+			if t == "24:00" {
+				return 24 * 60, true
+			}
+			return 0, false
+		}
+		return parsed.Hour()*60 + parsed.Minute(), true
+	}
+	return 0, false
+}
+
+// inDayWindow reports whether a minute of the day falls in [start, end), wrapping midnight
+// when the window ends before it starts
+func inDayWindow(minute, start, end int) bool {
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
 // evaluateMatchConditions evaluates match conditions against alert attributes
 func (s *RoutingService) evaluateMatchConditions(attrs db.AlertAttributes, conditions map[string]interface{}) bool {
 	for key, value := range conditions {
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+var routingRuleRowColumns = []string{
+	"id", "routing_table_id", "name", "priority", "is_active",
+	"match_conditions", "target_group_id", "escalation_rule_id", "time_conditions",
+	"created_at", "updated_at", "created_by", "group_name", "escalation_rule_name",
+}
+
+func expectRoutingTables(mockDB sqlmock.Sqlmock, ids ...string) {
+	rows := sqlmock.NewRows([]string{"id", "name", "description", "is_active", "priority", "created_at", "updated_at", "created_by"})
+	for i, id := range ids {
+		rows.AddRow(id, "Table "+id, "", true, 100-i, time.Now(), time.Now(), nil)
+	}
+	mockDB.ExpectQuery(`FROM alert_routing_tables\s+WHERE is_active = true AND organization_id = \$1\s+ORDER BY priority DESC`).
+		WithArgs("org-1").
+		WillReturnRows(rows)
+}
+
+func TestEvaluate_FirstMatchingRuleWins(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	now := time.Now()
+	expectRoutingTables(mockDB, "table-high", "table-low")
+	mockDB.ExpectQuery(`FROM alert_routing_rules arr .* AND arr.is_active = true ORDER BY arr.priority DESC`).
+		WithArgs("table-high").
+		WillReturnRows(sqlmock.NewRows(routingRuleRowColumns).
+			AddRow("rule-staging", "table-high", "Staging", 90, true, []byte(`{"labels.env": "staging"}`), "group-staging", nil, nil, now, now, nil, "Staging", "").
+			AddRow("rule-db", "table-high", "Database prod", 80, true, []byte(`{"severity": ["critical", "high"], "labels.team": "db"}`), "group-dba", "er-1", nil, now, now, nil, "DBA", "Page DBAs"))
+	mockDB.ExpectExec(`INSERT INTO alert_route_logs`).
+		WithArgs(sqlmock.AnyArg(), "fp-123", "table-high", "rule-db", "group-dba", sqlmock.AnyArg(),
+			"Matched rule 'Database prod' in table 'Table table-high' (priority 80)", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	service := NewRoutingService(pg)
+	result, err := service.Evaluate("org-1", db.AlertAttributes{
+		Severity:    "critical",
+		Source:      "prometheus",
+		Labels:      map[string]interface{}{"env": "prod", "team": "db"},
+		Fingerprint: "fp-123",
+	})
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, result) {
+		assert.Equal(t, "group-dba", result.TargetGroupID)
+		assert.Equal(t, "er-1", result.EscalationRuleID)
+		assert.Equal(t, "rule-db", result.MatchedRule.ID)
+		assert.Equal(t, "table-high", result.MatchedTable.ID)
+		assert.GreaterOrEqual(t, result.EvaluationTimeMs, 0)
+	}
+	// The lower priority table isn't needed
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestEvaluate_NoMatch(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	now := time.Now()
+	expectRoutingTables(mockDB, "table-1")
+	mockDB.ExpectQuery(`FROM alert_routing_rules arr`).
+		WithArgs("table-1").
+		WillReturnRows(sqlmock.NewRows(routingRuleRowColumns).
+			AddRow("rule-1", "table-1", "Weekend DB", 50, true, []byte(`{"labels.team": "db"}`), "group-dba", nil, []byte(`{"weekends": true}`), now, now, nil, "DBA", "").
+			AddRow("rule-2", "table-1", "Web", 40, true, []byte(`{"labels.team": "web"}`), "group-web", nil, nil, now, now, nil, "Web", ""))
+
+	service := NewRoutingService(pg)
+	result, err := service.Evaluate("org-1", db.AlertAttributes{
+		Severity: "critical",
+		Labels:   map[string]interface{}{"team": "api"},
+	})
+
+	// Nothing is logged when no rule matches
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestTimeConditionMatches(t *testing.T) {
+	// Wednesday 2026-10-14 10:30 UTC and Saturday 2026-10-17 23:00 UTC
+	wednesday := time.Date(2026, 10, 14, 10, 30, 0, 0, time.UTC)
+	saturdayNight := time.Date(2026, 10, 17, 23, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		conditions map[string]interface{}
+		at         time.Time
+		want       bool
+	}{
+		{"none", nil, saturdayNight, true},
+		{"business hours", map[string]interface{}{"business_hours": true}, wednesday, true},
+		{"outside business hours", map[string]interface{}{"business_hours": true}, saturdayNight, false},
+		{"weekdays", map[string]interface{}{"weekdays": true}, wednesday, true},
+		{"weekdays on a weekend", map[string]interface{}{"weekdays": true}, saturdayNight, false},
+		{"weekends", map[string]interface{}{"weekends": true}, saturdayNight, true},
+		{"hours", map[string]interface{}{"hours": map[string]interface{}{"start": float64(9), "end": float64(12)}}, wednesday, true},
+		{"outside hours", map[string]interface{}{"hours": map[string]interface{}{"start": float64(13), "end": float64(17)}}, wednesday, false},
+		{"overnight hours", map[string]interface{}{"hours": map[string]interface{}{"start": float64(22), "end": float64(6)}}, saturdayNight, true},
+		{"outside overnight hours", map[string]interface{}{"hours": map[string]interface{}{"start": float64(22), "end": float64(6)}}, wednesday, false},
+		{"days", map[string]interface{}{"days": []interface{}{"Monday", "wednesday"}}, wednesday, true},
+		{"other days", map[string]interface{}{"days": []interface{}{"monday"}}, wednesday, false},
+		// 23:00 UTC Saturday is 08:00 Sunday in Tokyo
+		{"timezone", map[string]interface{}{"timezone": "Asia/Tokyo", "days": []interface{}{"sunday"}}, saturdayNight, true},
+		{"invalid timezone", map[string]interface{}{"timezone": "Mars/Olympus"}, wednesday, false},
+		{"clock hours", map[string]interface{}{"hours": map[string]interface{}{"start": "10:15", "end": "10:45"}}, wednesday, true},
+		{"clock hours end exclusive", map[string]interface{}{"hours": map[string]interface{}{"start": "09:00", "end": "10:30"}}, wednesday, false},
+		{"invalid hours", map[string]interface{}{"hours": map[string]interface{}{"start": "9am", "end": "5pm"}}, wednesday, false},
+		{"custom business hours", map[string]interface{}{"business_hours": map[string]interface{}{"start": "10:00", "end": "18:00"}}, wednesday, true},
+		{"custom business hours on a weekend", map[string]interface{}{"business_hours": map[string]interface{}{"start": 0, "end": 24}}, saturdayNight, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, timeConditionMatches(tt.conditions, tt.at))
+		})
+	}
+}
+
+func TestTimeConditionMatches_DST(t *testing.T) {
+	newYork := func(hours map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"timezone": "America/New_York", "hours": hours}
+	}
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		conditions map[string]interface{}
+		at         time.Time
+		want       bool
+	}{
+		// Clocks jump from 02:00 EST to 03:00 EDT on 2026-03-08: 07:30 UTC is 03:30 local
+		{"after spring forward", newYork(map[string]interface{}{"start": "03:00", "end": "04:00"}), utc(3, 8, 7, 30), true},
+		{"skipped hour never matches", newYork(map[string]interface{}{"start": "02:00", "end": "03:00"}), utc(3, 8, 7, 30), false},
+		{"before spring forward", newYork(map[string]interface{}{"start": "01:00", "end": "02:00"}), utc(3, 8, 6, 59), true},
+		// Clocks fall back from 02:00 EDT to 01:00 EST on 2026-11-01: 01:30 happens twice
+		{"first 01:30", newYork(map[string]interface{}{"start": "01:00", "end": "02:00"}), utc(11, 1, 5, 30), true},
+		{"repeated 01:30", newYork(map[string]interface{}{"start": "01:00", "end": "02:00"}), utc(11, 1, 6, 30), true},
+		{"after fall back", newYork(map[string]interface{}{"start": "01:00", "end": "02:00"}), utc(11, 1, 7, 0), false},
+		// Night window across the change still covers the whole local night
+		{"overnight across fall back", newYork(map[string]interface{}{"start": 22, "end": 6}), utc(11, 1, 10, 59), true},
+		{"overnight ends at local 06:00", newYork(map[string]interface{}{"start": 22, "end": 6}), utc(11, 1, 11, 0), false},
+		// Business hours follow the wall clock: 13:00 UTC is 08:00 EST the Friday before, 09:00 EDT the Monday after
+		{"business hours before DST", map[string]interface{}{"timezone": "America/New_York", "business_hours": true}, utc(3, 6, 13, 0), false},
+		{"business hours after DST", map[string]interface{}{"timezone": "America/New_York", "business_hours": true}, utc(3, 9, 13, 0), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, timeConditionMatches(tt.conditions, tt.at))
+		})
+	}
+}
+
+func TestTestRouting_ExplainsMatchWithoutLogging(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	now := time.Now()
+	expectRoutingTables(mockDB, "table-1")
+	mockDB.ExpectQuery(`FROM alert_routing_rules arr`).
+		WithArgs("table-1").
+		WillReturnRows(sqlmock.NewRows(routingRuleRowColumns).
+			AddRow("rule-1", "table-1", "Critical to SRE", 50, true, []byte(`{"severity": "critical"}`), "group-sre", nil, []byte(`{"weekdays": true}`), now, now, nil, "SRE", ""))
+
+	// No alert_route_logs insert is expected
+	service := NewRoutingService(pg)
+	service.now = func() time.Time { return time.Date(2026, 10, 12, 12, 0, 0, 0, time.UTC) } // a Monday
+	result, err := service.TestRouting("org-1", db.AlertAttributes{Severity: "critical"})
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, result) {
+		assert.Equal(t, `Would match rule 'Critical to SRE' in table 'Table table-1' (priority 50): alert matches {"severity":"critical"}, at a time matching {"weekdays":true}`, result.MatchedReason)
+		assert.Equal(t, "table-1", result.MatchedTable.ID)
+		assert.Equal(t, "rule-1", result.MatchedRule.ID)
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestTestRouting_NoMatch(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewRoutingService(pg)
+
+	// Only the organization's own tables are read; it has none
+	expectRoutingTables(mockDB)
+	_, err = service.TestRouting("org-1", db.AlertAttributes{Severity: "critical"})
+	assert.ErrorIs(t, err, ErrNoRouteMatched)
+
+	// Without an organization no table is considered at all
+	_, err = service.TestRouting("", db.AlertAttributes{Severity: "critical"})
+	assert.ErrorIs(t, err, ErrNoRouteMatched)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
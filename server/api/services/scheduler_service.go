@@ -479,16 +479,24 @@ func (s *SchedulerService) CreateSchedulerWithShifts(groupID string, schedulerRe
 	return scheduler, createdShifts, nil
 }
 
-// GetSchedulersByGroup gets all schedulers for a group
-func (s *SchedulerService) GetSchedulersByGroup(groupID string) ([]db.Scheduler, error) {
+// GetSchedulersByGroup gets all schedulers for a group within an organization.
+// It does no membership check, so it is only for internal callers that have already
+// authorized the request; handlers must use GetSchedulersByGroupWithFilters.
+func (s *SchedulerService) GetSchedulersByGroup(groupID, orgID string) ([]db.Scheduler, error) {
+	// Tenant isolation still applies to internal callers
+	if orgID == "" {
+		fmt.Printf("WARNING: GetSchedulersByGroup called without organization context - returning empty\n")
+		return []db.Scheduler{}, nil
+	}
+
 	query := `
 		SELECT id, name, display_name, group_id, description, is_active, rotation_type, created_at, updated_at, created_by, organization_id
 		FROM schedulers
-		WHERE group_id = $1 AND is_active = true
+		WHERE group_id = $1 AND organization_id = $2 AND is_active = true
 		ORDER BY name ASC
 	`
 
-	rows, err := s.PG.Query(query, groupID)
+	rows, err := s.PG.Query(query, groupID, orgID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query schedulers: %w", err)
 	}
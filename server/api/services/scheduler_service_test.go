@@ -0,0 +1,72 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var schedulerRowColumns = []string{
+	"id", "name", "display_name", "group_id", "description", "is_active", "rotation_type",
+	"created_at", "updated_at", "created_by", "organization_id",
+}
+
+func TestGetSchedulersByGroup_ScopedToOrganization(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	now := time.Now()
+	mockDB.ExpectQuery(`FROM schedulers\s+WHERE group_id = \$1 AND organization_id = \$2 AND is_active = true`).
+		WithArgs("group-1", "org-1").
+		WillReturnRows(sqlmock.NewRows(schedulerRowColumns).
+			AddRow("sched-1", "default", "Default", "group-1", "", true, "weekly", now, now, "user-1", "org-1"))
+
+	service := NewSchedulerService(pg)
+	schedulers, err := service.GetSchedulersByGroup("group-1", "org-1")
+	assert.NoError(t, err)
+	if assert.Len(t, schedulers, 1) {
+		assert.Equal(t, "org-1", schedulers[0].OrganizationID)
+	}
+
+	// Without an organization nothing is queried
+	schedulers, err = service.GetSchedulersByGroup("group-1", "")
+	assert.NoError(t, err)
+	assert.Empty(t, schedulers)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetSchedulersByGroupWithFilters_RequiresMembership(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	service := NewSchedulerService(pg)
+
+	// Missing user or organization context never reaches the database
+	for _, filters := range []map[string]interface{}{
+		{"current_org_id": "org-1", "group_id": "group-1"},
+		{"current_user_id": "user-1", "group_id": "group-1"},
+	} {
+		schedulers, err := service.GetSchedulersByGroupWithFilters(filters)
+		assert.NoError(t, err)
+		assert.Empty(t, schedulers)
+	}
+
+	mockDB.ExpectQuery(`AND s\.organization_id = \$2\s+-- ReBAC: User must have access to the group\s+AND EXISTS \(\s+SELECT 1 FROM memberships m\s+WHERE m\.user_id = \$3`).
+		WithArgs("group-1", "org-1", "user-2").
+		WillReturnRows(sqlmock.NewRows(schedulerRowColumns))
+
+	schedulers, err := service.GetSchedulersByGroupWithFilters(map[string]interface{}{
+		"current_user_id": "user-2", "current_org_id": "org-1", "group_id": "group-1",
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, schedulers)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
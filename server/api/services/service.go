@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -43,6 +45,9 @@ func (s *ServiceService) CreateService(groupID string, req db.CreateServiceReque
 	}
 
 	if req.NotificationSettings != nil {
+		if _, err := ParseServiceNotificationSettings(req.NotificationSettings); err != nil {
+			return service, err
+		}
 		service.NotificationSettings = req.NotificationSettings
 	} else {
 		service.NotificationSettings = map[string]interface{}{
@@ -77,7 +82,7 @@ func (s *ServiceService) CreateService(groupID string, req db.CreateServiceReque
 	}
 
 	// Populate computed webhook URLs
-	s.populateWebhookURLs(&service)
+	BuildServiceWebhookURLs(&service)
 
 	return service, nil
 }
@@ -95,12 +100,14 @@ func (s *ServiceService) GetService(serviceID string) (db.Service, error) {
 	var service db.Service
 	var integrationsJSON, notificationJSON []byte
 	var escalationPolicyID sql.NullString
+	var responseSLA, resolutionSLA sql.NullInt64
 
 	err := s.PG.QueryRow(`
 		SELECT s.id, s.group_id, s.name, s.description, s.routing_key, s.escalation_policy_id,
 		       s.is_active, s.created_at, s.updated_at, COALESCE(s.created_by, '') as created_by,
 		       COALESCE(s.integrations, '{}') as integrations,
 		       COALESCE(s.notification_settings, '{}') as notification_settings,
+		       s.response_sla_minutes, s.resolution_sla_minutes,
 		       g.name as group_name
 		FROM services s
 		LEFT JOIN groups g ON s.group_id = g.id
@@ -109,7 +116,7 @@ func (s *ServiceService) GetService(serviceID string) (db.Service, error) {
 		&service.ID, &service.GroupID, &service.Name, &service.Description,
 		&service.RoutingKey, &escalationPolicyID, &service.IsActive,
 		&service.CreatedAt, &service.UpdatedAt, &service.CreatedBy,
-		&integrationsJSON, &notificationJSON, &service.GroupName,
+		&integrationsJSON, &notificationJSON, &responseSLA, &resolutionSLA, &service.GroupName,
 	)
 
 	if err != nil {
@@ -131,9 +138,17 @@ func (s *ServiceService) GetService(serviceID string) (db.Service, error) {
 	if escalationPolicyID.Valid {
 		service.EscalationPolicyID = escalationPolicyID.String
 	}
+	if responseSLA.Valid {
+		minutes := int(responseSLA.Int64)
+		service.ResponseSLAMinutes = &minutes
+	}
+	if resolutionSLA.Valid {
+		minutes := int(resolutionSLA.Int64)
+		service.ResolutionSLAMinutes = &minutes
+	}
 
 	// Populate computed webhook URLs
-	s.populateWebhookURLs(&service)
+	BuildServiceWebhookURLs(&service)
 
 	return service, nil
 }
@@ -186,7 +201,7 @@ func (s *ServiceService) GetGroupServices(groupID string) ([]db.Service, error)
 		}
 
 		// Populate computed webhook URLs
-		s.populateWebhookURLs(&service)
+		BuildServiceWebhookURLs(&service)
 
 		services = append(services, service)
 	}
@@ -222,8 +237,17 @@ func (s *ServiceService) UpdateService(serviceID string, req db.UpdateServiceReq
 		service.Integrations = req.Integrations
 	}
 	if req.NotificationSettings != nil {
+		if _, err := ParseServiceNotificationSettings(req.NotificationSettings); err != nil {
+			return service, err
+		}
 		service.NotificationSettings = req.NotificationSettings
 	}
+	if req.ResponseSLAMinutes != nil {
+		service.ResponseSLAMinutes = req.ResponseSLAMinutes
+	}
+	if req.ResolutionSLAMinutes != nil {
+		service.ResolutionSLAMinutes = req.ResolutionSLAMinutes
+	}
 
 	service.UpdatedAt = time.Now()
 
@@ -235,18 +259,19 @@ func (s *ServiceService) UpdateService(serviceID string, req db.UpdateServiceReq
 	_, err = s.PG.Exec(`
 		UPDATE services 
 		SET name = $2, description = $3, routing_key = $4, escalation_policy_id = $5,
-		    is_active = $6, updated_at = $7, integrations = $8, notification_settings = $9
+		    is_active = $6, updated_at = $7, integrations = $8, notification_settings = $9,
+		    response_sla_minutes = $10, resolution_sla_minutes = $11
 		WHERE id = $1
 	`, serviceID, service.Name, service.Description, service.RoutingKey,
 		service.EscalationPolicyID, service.IsActive, service.UpdatedAt,
-		integrationsJSON, notificationJSON)
+		integrationsJSON, notificationJSON, service.ResponseSLAMinutes, service.ResolutionSLAMinutes)
 
 	if err != nil {
 		return service, fmt.Errorf("failed to update service: %w", err)
 	}
 
 	// Populate computed webhook URLs
-	s.populateWebhookURLs(&service)
+	BuildServiceWebhookURLs(&service)
 
 	return service, nil
 }
@@ -321,7 +346,7 @@ func (s *ServiceService) GetServiceByRoutingKey(routingKey string) (db.Service,
 	}
 
 	// Populate computed webhook URLs
-	s.populateWebhookURLs(&service)
+	BuildServiceWebhookURLs(&service)
 
 	return service, nil
 }
@@ -476,7 +501,7 @@ func (s *ServiceService) ListServices(filters map[string]interface{}) ([]db.Serv
 		}
 
 		// Populate computed webhook URLs
-		s.populateWebhookURLs(&service)
+		BuildServiceWebhookURLs(&service)
 
 		services = append(services, service)
 	}
@@ -543,7 +568,7 @@ func (s *ServiceService) ListAllServices(isActive *bool) ([]db.Service, error) {
 		}
 
 		// Populate computed webhook URLs
-		s.populateWebhookURLs(&service)
+		BuildServiceWebhookURLs(&service)
 
 		services = append(services, service)
 	}
@@ -551,13 +576,16 @@ func (s *ServiceService) ListAllServices(isActive *bool) ([]db.Service, error) {
 	return services, nil
 }
 
-// populateWebhookURLs computes and sets the webhook URLs for a service
-func (s *ServiceService) populateWebhookURLs(service *db.Service) {
-	baseURL := config.App.WebhookAPIBaseURL
+// BuildServiceWebhookURLs derives the generic and Prometheus webhook URLs for a
+// service from the configured webhook base URL and the service routing key.
+// It is used on every create/read path so the URLs are always consistent.
+func BuildServiceWebhookURLs(service *db.Service) {
+	baseURL := strings.TrimRight(config.App.WebhookAPIBaseURL, "/")
 	if baseURL == "" {
 		baseURL = "http://localhost:8080"
 	}
 
-	service.GenericWebhookURL = fmt.Sprintf("%s/webhook/generic/%s", baseURL, service.RoutingKey)
-	service.PrometheusWebhookURL = fmt.Sprintf("%s/webhook/prometheus/%s", baseURL, service.RoutingKey)
+	routingKey := url.PathEscape(service.RoutingKey)
+	service.GenericWebhookURL = fmt.Sprintf("%s/webhook/generic/%s", baseURL, routingKey)
+	service.PrometheusWebhookURL = fmt.Sprintf("%s/webhook/prometheus/%s", baseURL, routingKey)
 }
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+// Notification channels a service can toggle for new incidents
+const (
+	ServiceChannelSlack = "slack" // Queued assignment notification (Slack + push via the notification worker)
+	ServiceChannelFCM   = "fcm"   // Direct FCM push to the assignee
+)
+
+// severityRank orders incident severities for suppress_below_severity
+var severityRank = map[string]int{
+	"info":     1,
+	"warning":  2,
+	"error":    3,
+	"critical": 4,
+}
+
+// ParseServiceNotificationSettings decodes and validates Service.NotificationSettings
+func ParseServiceNotificationSettings(raw map[string]interface{}) (db.ServiceNotificationSettings, error) {
+	settings := db.ServiceNotificationSettings{Channels: map[string]bool{}}
+	if raw == nil {
+		return settings, nil
+	}
+
+	for key, value := range raw {
+		if enabled, ok := value.(bool); ok {
+			settings.Channels[key] = enabled
+		}
+	}
+
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return settings, fmt.Errorf("invalid notification_settings: %w", err)
+	}
+	if err := json.Unmarshal(b, &settings); err != nil {
+		return settings, fmt.Errorf("invalid notification_settings: %w", err)
+	}
+
+	if settings.SuppressBelowSeverity != "" {
+		if _, ok := severityRank[settings.SuppressBelowSeverity]; !ok {
+			return settings, fmt.Errorf("invalid notification_settings: unknown severity '%s' in suppress_below_severity", settings.SuppressBelowSeverity)
+		}
+	}
+	if settings.QuietHours != nil {
+		if _, _, _, err := parseQuietHours(settings.QuietHours); err != nil {
+			return settings, fmt.Errorf("invalid notification_settings: %w", err)
+		}
+	}
+
+	return settings, nil
+}
+
+// ChannelEnabled reports whether a channel is on; channels that aren't configured are enabled
+func ChannelEnabled(settings db.ServiceNotificationSettings, channel string) bool {
+	enabled, ok := settings.Channels[channel]
+	return !ok || enabled
+}
+
+// NotificationSuppressedReason returns why a new incident shouldn't notify under the
+// service's settings at the given time, or "" when notifications should go out
+func NotificationSuppressedReason(settings db.ServiceNotificationSettings, incident *db.Incident, now time.Time) string {
+	if settings.SuppressBelowSeverity != "" && incident.Severity != "" {
+		if severityRank[incident.Severity] < severityRank[settings.SuppressBelowSeverity] {
+			return fmt.Sprintf("severity %s is below %s", incident.Severity, settings.SuppressBelowSeverity)
+		}
+	}
+
+	if settings.QuietHours != nil && incident.Urgency != db.IncidentUrgencyHigh && inQuietHours(settings.QuietHours, now) {
+		return "quiet hours"
+	}
+
+	return ""
+}
+
+// parseQuietHours returns the window bounds as minutes since midnight and its location
+func parseQuietHours(q *db.QuietHours) (int, int, *time.Location, error) {
+	start, err := time.Parse("15:04", q.Start)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("quiet_hours.start must be HH:MM")
+	}
+	end, err := time.Parse("15:04", q.End)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("quiet_hours.end must be HH:MM")
+	}
+
+	loc := time.UTC
+	if q.Timezone != "" {
+		loc, err = time.LoadLocation(q.Timezone)
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("unknown quiet_hours.timezone '%s'", q.Timezone)
+		}
+	}
+
+	return start.Hour()*60 + start.Minute(), end.Hour()*60 + end.Minute(), loc, nil
+}
+
+// inQuietHours reports whether now falls inside the quiet window, which may wrap midnight
+func inQuietHours(q *db.QuietHours, now time.Time) bool {
+	start, end, loc, err := parseQuietHours(q)
+	if err != nil || start == end {
+		return false
+	}
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// getServiceNotificationSettings loads the notification settings of an incident's service.
+// Invalid or missing settings fall back to notifying on every channel.
+func (s *IncidentService) getServiceNotificationSettings(serviceID string) db.ServiceNotificationSettings {
+	defaults := db.ServiceNotificationSettings{Channels: map[string]bool{}}
+	if serviceID == "" {
+		return defaults
+	}
+
+	var raw sql.NullString
+	err := s.PG.QueryRow(`SELECT notification_settings FROM services WHERE id = $1`, serviceID).Scan(&raw)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("WARNING: Failed to load notification settings for service %s: %v", serviceID, err)
+		}
+		return defaults
+	}
+	if !raw.Valid || raw.String == "" {
+		return defaults
+	}
+
+	var stored map[string]interface{}
+	if err := json.Unmarshal([]byte(raw.String), &stored); err != nil {
+		log.Printf("WARNING: Invalid notification settings for service %s, notifying anyway: %v", serviceID, err)
+		return defaults
+	}
+
+	settings, err := ParseServiceNotificationSettings(stored)
+	if err != nil {
+		log.Printf("WARNING: %v (service %s), notifying anyway", err, serviceID)
+		return defaults
+	}
+	return settings
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// assignedNotifier records assignment notifications
+type assignedNotifier struct {
+	recordingNotifier
+	assigned chan string
+}
+
+func (n *assignedNotifier) SendIncidentAssignedNotification(userID, incidentID string) error {
+	n.assigned <- userID
+	return nil
+}
+
+// expectServiceIncidentCreate mocks CreateIncident for an assigned incident on svc-1 in org-1
+func expectServiceIncidentCreate(mockDB sqlmock.Sqlmock, notificationSettings string) {
+	mockDB.ExpectExec("INSERT INTO incidents").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs(sqlmock.AnyArg(), "triggered", sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectExplicitAssignmentEvent(mockDB)
+	mockDB.ExpectQuery(`SELECT notification_settings FROM services`).
+		WithArgs("svc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"notification_settings"}).AddRow(notificationSettings))
+}
+
+func newServiceIncident(severity string) *db.Incident {
+	return &db.Incident{
+		Title:          "Disk filling up",
+		Severity:       severity,
+		Priority:       "P3",
+		ServiceID:      "svc-1",
+		AssignedTo:     "user-1",
+		OrganizationID: "org-1",
+	}
+}
+
+func TestCreateIncident_ServiceSuppressesWarnings(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectServiceIncidentCreate(mockDB, `{"suppress_below_severity": "error"}`)
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs(sqlmock.AnyArg(), db.IncidentEventNotificationSuppressed, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	notifier := &assignedNotifier{assigned: make(chan string, 1)}
+	service := NewIncidentService(pg, nil, nil)
+	service.DeployCorrelationWindow = 0
+	service.SetNotificationWorker(notifier)
+
+	incident, err := service.CreateIncident(newServiceIncident("warning"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", incident.AssignedTo)
+	assert.Empty(t, notifier.assigned, "warning incident must not notify")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCreateIncident_ServiceNotifiesAboveThreshold(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectServiceIncidentCreate(mockDB, `{"suppress_below_severity": "error", "slack": true}`)
+	expectAssigneeChannels(mockDB, "user-1", false, true)
+
+	notifier := &assignedNotifier{assigned: make(chan string, 1)}
+	service := NewIncidentService(pg, nil, nil)
+	service.DeployCorrelationWindow = 0
+	service.SetNotificationWorker(notifier)
+
+	_, err = service.CreateIncident(newServiceIncident("critical"))
+	assert.NoError(t, err)
+
+	select {
+	case userID := <-notifier.assigned:
+		assert.Equal(t, "user-1", userID)
+	case <-time.After(time.Second):
+		t.Fatal("critical incident should notify the assignee")
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCreateIncident_DebouncedNotificationCanceledOnResolve(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectServiceIncidentCreate(mockDB, "")
+	mockDB.ExpectExec(`SELECT pgmq.send\(\$1, \$2::jsonb, \$3\)`).
+		WithArgs(HeldAssignmentQueueName, sqlmock.AnyArg(), 60).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	notifier := &assignedNotifier{assigned: make(chan string, 1)}
+	service := NewIncidentService(pg, nil, nil)
+	service.DeployCorrelationWindow = 0
+	service.AssignmentNotificationDelay = time.Minute
+	service.SetNotificationWorker(notifier)
+
+	incident, err := service.CreateIncident(newServiceIncident("critical"))
+	assert.NoError(t, err)
+	assert.Empty(t, notifier.assigned, "notification must be held during the delay")
+
+	// Resolved before the delay ran out: the page is dropped
+	mockDB.ExpectQuery(`SELECT msg_id, message FROM pgmq.read`).
+		WithArgs(HeldAssignmentQueueName, heldAssignmentBatch).
+		WillReturnRows(sqlmock.NewRows([]string{"msg_id", "message"}).
+			AddRow(7, heldAssignmentJSON(t, incident)))
+	mockDB.ExpectQuery(`SELECT status FROM incidents`).
+		WithArgs(incident.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(db.IncidentStatusResolved))
+	mockDB.ExpectExec("INSERT INTO incident_events").
+		WithArgs(incident.ID, db.IncidentEventNotificationSuppressed, `{"reason":"resolved within 1m0s"}`, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`SELECT pgmq.delete`).
+		WithArgs(HeldAssignmentQueueName, int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	handled, err := service.SendHeldAssignmentNotifications()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, handled)
+	assert.Empty(t, notifier.assigned, "resolved incident must not page")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCreateIncident_DebouncedNotificationSentWhenPersisting(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectServiceIncidentCreate(mockDB, "")
+	mockDB.ExpectExec(`SELECT pgmq.send\(\$1, \$2::jsonb, \$3\)`).
+		WithArgs(HeldAssignmentQueueName, sqlmock.AnyArg(), 60).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	notifier := &assignedNotifier{assigned: make(chan string, 1)}
+	service := NewIncidentService(pg, nil, nil)
+	service.DeployCorrelationWindow = 0
+	service.AssignmentNotificationDelay = time.Minute
+	service.SetNotificationWorker(notifier)
+
+	incident, err := service.CreateIncident(newServiceIncident("critical"))
+	assert.NoError(t, err)
+
+	// Another process (the worker) sends it once the delay passed
+	worker := NewIncidentService(pg, nil, nil)
+	worker.SetNotificationWorker(notifier)
+	mockDB.ExpectQuery(`SELECT msg_id, message FROM pgmq.read`).
+		WillReturnRows(sqlmock.NewRows([]string{"msg_id", "message"}).
+			AddRow(7, heldAssignmentJSON(t, incident)))
+	mockDB.ExpectQuery(`SELECT status FROM incidents`).
+		WithArgs(incident.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(db.IncidentStatusTriggered))
+	expectAssigneeChannels(mockDB, "user-1", false, true)
+	mockDB.ExpectExec(`SELECT pgmq.delete`).
+		WithArgs(HeldAssignmentQueueName, int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = worker.SendHeldAssignmentNotifications()
+	assert.NoError(t, err)
+	select {
+	case userID := <-notifier.assigned:
+		assert.Equal(t, "user-1", userID)
+	case <-time.After(time.Second):
+		t.Fatal("incident still open after the delay should page the assignee")
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCreateIncident_DebouncedNotificationSentNowWhenHoldFails(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	expectServiceIncidentCreate(mockDB, "")
+	mockDB.ExpectExec(`SELECT pgmq.send`).WillReturnError(fmt.Errorf("queue missing"))
+	expectAssigneeChannels(mockDB, "user-1", false, true)
+
+	notifier := &assignedNotifier{assigned: make(chan string, 1)}
+	service := NewIncidentService(pg, nil, nil)
+	service.DeployCorrelationWindow = 0
+	service.AssignmentNotificationDelay = time.Minute
+	service.SetNotificationWorker(notifier)
+
+	_, err = service.CreateIncident(newServiceIncident("critical"))
+	assert.NoError(t, err)
+	select {
+	case userID := <-notifier.assigned:
+		assert.Equal(t, "user-1", userID)
+	case <-time.After(time.Second):
+		t.Fatal("a notification that can't be held should be sent right away")
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+// heldAssignmentJSON is the held notification CreateIncident queues for an incident
+func heldAssignmentJSON(t *testing.T, incident *db.Incident) []byte {
+	raw, err := json.Marshal(HeldAssignmentNotification{Incident: *incident, NotifySlack: true, Delay: time.Minute.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestNotificationSuppressedReason_QuietHours(t *testing.T) {
+	settings, err := ParseServiceNotificationSettings(map[string]interface{}{
+		"quiet_hours": map[string]interface{}{"start": "22:00", "end": "07:00", "timezone": "UTC"},
+	})
+	assert.NoError(t, err)
+
+	night := time.Date(2026, 10, 17, 23, 30, 0, 0, time.UTC)
+	day := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	low := &db.Incident{Urgency: db.IncidentUrgencyLow}
+	high := &db.Incident{Urgency: db.IncidentUrgencyHigh}
+
+	assert.Equal(t, "quiet hours", NotificationSuppressedReason(settings, low, night))
+	assert.Equal(t, "", NotificationSuppressedReason(settings, high, night))
+	assert.Equal(t, "", NotificationSuppressedReason(settings, low, day))
+}
+
+func TestParseServiceNotificationSettings(t *testing.T) {
+	settings, err := ParseServiceNotificationSettings(map[string]interface{}{"email": true, "fcm": false, "sms": false})
+	assert.NoError(t, err)
+	assert.False(t, ChannelEnabled(settings, ServiceChannelFCM))
+	assert.True(t, ChannelEnabled(settings, ServiceChannelSlack)) // unset channels stay on
+
+	_, err = ParseServiceNotificationSettings(map[string]interface{}{"suppress_below_severity": "meh"})
+	assert.EqualError(t, err, "invalid notification_settings: unknown severity 'meh' in suppress_below_severity")
+
+	_, err = ParseServiceNotificationSettings(map[string]interface{}{
+		"quiet_hours": map[string]interface{}{"start": "10pm", "end": "07:00"},
+	})
+	assert.EqualError(t, err, "invalid notification_settings: quiet_hours.start must be HH:MM")
+}
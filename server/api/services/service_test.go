@@ -0,0 +1,77 @@
+package services
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/phonginreallife/inres/db"
+	"github.com/phonginreallife/inres/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildServiceWebhookURLs(t *testing.T) {
+	originalBaseURL := config.App.WebhookAPIBaseURL
+	defer func() { config.App.WebhookAPIBaseURL = originalBaseURL }()
+
+	t.Run("DerivedFromBaseURLAndRoutingKey", func(t *testing.T) {
+		config.App.WebhookAPIBaseURL = "https://api.inres.dev"
+		service := &db.Service{RoutingKey: "svc-routing-key"}
+
+		BuildServiceWebhookURLs(service)
+
+		assert.Equal(t, "https://api.inres.dev/webhook/generic/svc-routing-key", service.GenericWebhookURL)
+		assert.Equal(t, "https://api.inres.dev/webhook/prometheus/svc-routing-key", service.PrometheusWebhookURL)
+
+		for _, raw := range []string{service.GenericWebhookURL, service.PrometheusWebhookURL} {
+			parsed, err := url.Parse(raw)
+			assert.NoError(t, err)
+			assert.Equal(t, "https", parsed.Scheme)
+			assert.Equal(t, "api.inres.dev", parsed.Host)
+		}
+	})
+
+	t.Run("TrailingSlashInBaseURL", func(t *testing.T) {
+		config.App.WebhookAPIBaseURL = "https://api.inres.dev/"
+		service := &db.Service{RoutingKey: "abc"}
+
+		BuildServiceWebhookURLs(service)
+
+		assert.Equal(t, "https://api.inres.dev/webhook/generic/abc", service.GenericWebhookURL)
+		assert.Equal(t, "https://api.inres.dev/webhook/prometheus/abc", service.PrometheusWebhookURL)
+	})
+
+	t.Run("DefaultBaseURL", func(t *testing.T) {
+		config.App.WebhookAPIBaseURL = ""
+		service := &db.Service{RoutingKey: "abc"}
+
+		BuildServiceWebhookURLs(service)
+
+		assert.Equal(t, "http://localhost:8080/webhook/generic/abc", service.GenericWebhookURL)
+		assert.Equal(t, "http://localhost:8080/webhook/prometheus/abc", service.PrometheusWebhookURL)
+	})
+
+	t.Run("Stable", func(t *testing.T) {
+		config.App.WebhookAPIBaseURL = "https://api.inres.dev"
+		first := &db.Service{RoutingKey: "stable-key"}
+		second := &db.Service{RoutingKey: "stable-key"}
+
+		BuildServiceWebhookURLs(first)
+		BuildServiceWebhookURLs(second)
+		BuildServiceWebhookURLs(second)
+
+		assert.Equal(t, first.GenericWebhookURL, second.GenericWebhookURL)
+		assert.Equal(t, first.PrometheusWebhookURL, second.PrometheusWebhookURL)
+	})
+
+	t.Run("RoutingKeyIsPathEscaped", func(t *testing.T) {
+		config.App.WebhookAPIBaseURL = "https://api.inres.dev"
+		service := &db.Service{RoutingKey: "team a/b"}
+
+		BuildServiceWebhookURLs(service)
+
+		assert.Equal(t, "https://api.inres.dev/webhook/generic/team%20a%2Fb", service.GenericWebhookURL)
+		parsed, err := url.Parse(service.GenericWebhookURL)
+		assert.NoError(t, err)
+		assert.Equal(t, "/webhook/generic/team a/b", parsed.Path)
+	})
+}
@@ -84,8 +84,9 @@ func (s *UserService) UpdateUser(id string, c *gin.Context) (db.User, error) {
 
 	var err error
 
-	_, err = s.PG.Exec(`UPDATE users SET name=$2, email=$3, phone=$4, role=$5, team=$6, fcm_token=$7, updated_at=$8 WHERE id=$1`,
-		user.ID, user.Name, user.Email, user.Phone, user.Role, user.Team, user.FCMToken, user.UpdatedAt)
+	// role is not writable here, it would let users grant themselves roles; the stored one is returned
+	err = s.PG.QueryRow(`UPDATE users SET name=$2, email=$3, phone=$4, team=$5, fcm_token=$6, updated_at=$7 WHERE id=$1 RETURNING role`,
+		user.ID, user.Name, user.Email, user.Phone, user.Team, user.FCMToken, user.UpdatedAt).Scan(&user.Role)
 
 	return user, err
 }
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/phonginreallife/inres/db"
+)
+
+var (
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	ErrDeadLetterReplayed = errors.New("dead letter has already been replayed")
+)
+
+const deadLetterColumns = `id, integration_id, payload, error, attempts, created_at, replayed_at`
+
+// AddDeadLetter records an alert that could not be routed after the given number of attempts
+func (s *IntegrationService) AddDeadLetter(integrationID string, payload json.RawMessage, routeErr error, attempts int) (string, error) {
+	var id string
+	err := s.PG.QueryRow(`
+		INSERT INTO webhook_dead_letters (integration_id, payload, error, attempts)
+		VALUES ($1, $2::jsonb, $3, $4)
+		RETURNING id
+	`, integrationID, string(payload), routeErr.Error(), attempts).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to add dead letter: %w", err)
+	}
+	return id, nil
+}
+
+// ListDeadLetters returns an integration's dead letters, newest first
+func (s *IntegrationService) ListDeadLetters(integrationID string, limit int) ([]db.WebhookDeadLetter, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	rows, err := s.PG.Query(`
+		SELECT `+deadLetterColumns+`
+		FROM webhook_dead_letters
+		WHERE integration_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, integrationID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	deadLetters := []db.WebhookDeadLetter{}
+	for rows.Next() {
+		deadLetter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	return deadLetters, rows.Err()
+}
+
+// ReplayDeadLetter hands a dead letter to route again. The dead letter is claimed first so
+// concurrent replays can't route it twice; if route fails the claim is released and the
+// error recorded for the next attempt. Dead letters of integrations outside orgID are
+// reported as ErrDeadLetterNotFound.
+func (s *IntegrationService) ReplayDeadLetter(id, orgID string, route func(db.WebhookDeadLetter) error) (db.WebhookDeadLetter, error) {
+	deadLetter, err := scanDeadLetter(s.PG.QueryRow(`
+		UPDATE webhook_dead_letters
+		SET replayed_at = NOW(), attempts = attempts + 1
+		WHERE id = $1 AND replayed_at IS NULL
+		  AND integration_id IN (SELECT id FROM integrations WHERE organization_id = $2)
+		RETURNING `+deadLetterColumns, id, orgID))
+	if err == sql.ErrNoRows {
+		var exists bool
+		if err := s.PG.QueryRow(`
+			SELECT EXISTS(
+				SELECT 1 FROM webhook_dead_letters
+				WHERE id = $1 AND integration_id IN (SELECT id FROM integrations WHERE organization_id = $2)
+			)
+		`, id, orgID).Scan(&exists); err != nil {
+			return deadLetter, fmt.Errorf("failed to get dead letter: %w", err)
+		}
+		if exists {
+			return deadLetter, ErrDeadLetterReplayed
+		}
+		return deadLetter, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return deadLetter, err
+	}
+
+	if routeErr := route(deadLetter); routeErr != nil {
+		if _, err := s.PG.Exec(`
+			UPDATE webhook_dead_letters SET replayed_at = NULL, error = $2 WHERE id = $1
+		`, id, routeErr.Error()); err != nil {
+			return deadLetter, fmt.Errorf("failed to release dead letter after replay error %v: %w", routeErr, err)
+		}
+		return deadLetter, fmt.Errorf("failed to replay dead letter: %w", routeErr)
+	}
+	return deadLetter, nil
+}
+
+// scanDeadLetter scans a row selected with deadLetterColumns
+func scanDeadLetter(row interface{ Scan(...interface{}) error }) (db.WebhookDeadLetter, error) {
+	var deadLetter db.WebhookDeadLetter
+	var payload []byte
+	var replayedAt sql.NullTime
+	err := row.Scan(&deadLetter.ID, &deadLetter.IntegrationID, &payload, &deadLetter.Error,
+		&deadLetter.Attempts, &deadLetter.CreatedAt, &replayedAt)
+	if err == sql.ErrNoRows {
+		return deadLetter, err
+	}
+	if err != nil {
+		return deadLetter, fmt.Errorf("failed to scan dead letter: %w", err)
+	}
+	deadLetter.Payload = payload
+	if replayedAt.Valid {
+		deadLetter.ReplayedAt = &replayedAt.Time
+	}
+	return deadLetter, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+var deadLetterRowColumns = []string{"id", "integration_id", "payload", "error", "attempts", "created_at", "replayed_at"}
+
+func TestReplayDeadLetter_ReleasedWhenRoutingFails(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	now := time.Now()
+	mockDB.ExpectQuery(`UPDATE webhook_dead_letters SET replayed_at = NOW\(\), attempts = attempts \+ 1 WHERE id = \$1 AND replayed_at IS NULL AND integration_id IN \(SELECT id FROM integrations WHERE organization_id = \$2\)`).
+		WithArgs("dl-1", "org-1").
+		WillReturnRows(sqlmock.NewRows(deadLetterRowColumns).
+			AddRow("dl-1", "int-1", []byte(`{"alert_name":"Disk full"}`), "timeout", 4, now, now))
+	mockDB.ExpectExec(`UPDATE webhook_dead_letters SET replayed_at = NULL, error = \$2 WHERE id = \$1`).
+		WithArgs("dl-1", "still down").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIntegrationService(pg)
+	var routed db.WebhookDeadLetter
+	_, err = service.ReplayDeadLetter("dl-1", "org-1", func(deadLetter db.WebhookDeadLetter) error {
+		routed = deadLetter
+		return errors.New("still down")
+	})
+
+	assert.EqualError(t, err, "failed to replay dead letter: still down")
+	assert.Equal(t, "int-1", routed.IntegrationID)
+	assert.JSONEq(t, `{"alert_name":"Disk full"}`, string(routed.Payload))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestReplayDeadLetter_AlreadyReplayed(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	// Unknown dead letters and those of another organization's integrations look the same
+	for _, exists := range []bool{true, false} {
+		mockDB.ExpectQuery(`UPDATE webhook_dead_letters SET replayed_at = NOW\(\)`).
+			WithArgs("dl-1", "org-1").
+			WillReturnRows(sqlmock.NewRows(deadLetterRowColumns))
+		mockDB.ExpectQuery(`SELECT EXISTS\(\s*SELECT 1 FROM webhook_dead_letters\s+WHERE id = \$1 AND integration_id IN \(SELECT id FROM integrations WHERE organization_id = \$2\)`).
+			WithArgs("dl-1", "org-1").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(exists))
+	}
+
+	service := NewIntegrationService(pg)
+	route := func(db.WebhookDeadLetter) error {
+		t.Fatal("a dead letter that wasn't claimed must not be routed")
+		return nil
+	}
+
+	_, err = service.ReplayDeadLetter("dl-1", "org-1", route)
+	assert.ErrorIs(t, err, ErrDeadLetterReplayed)
+	_, err = service.ReplayDeadLetter("dl-1", "org-1", route)
+	assert.ErrorIs(t, err, ErrDeadLetterNotFound)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/phonginreallife/inres/db"
+)
+
+// ErrWebhookEventNotFound is returned when a recorded webhook event doesn't exist
+var ErrWebhookEventNotFound = errors.New("webhook event not found")
+
+const webhookEventColumns = `id, integration_id, integration_type, payload, received_at, processed_at, incident_ids, COALESCE(error, '')`
+
+// RecordWebhookEvent keeps a received webhook payload for audit and replay and returns its ID
+func (s *IntegrationService) RecordWebhookEvent(msg WebhookQueueMessage) (string, error) {
+	var id string
+	err := s.PG.QueryRow(`
+		INSERT INTO webhook_events (integration_id, integration_type, payload, received_at)
+		VALUES ($1, $2, $3::jsonb, $4)
+		RETURNING id
+	`, msg.IntegrationID, msg.IntegrationType, string(msg.Payload), msg.ReceivedAt).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to record webhook event: %w", err)
+	}
+	return id, nil
+}
+
+// CompleteWebhookEvent records what processing a webhook event produced. Incidents from a
+// replay are added to those of earlier runs; processErr replaces the previous error, if any.
+func (s *IntegrationService) CompleteWebhookEvent(eventID string, incidentIDs []string, processErr error) error {
+	var errText sql.NullString
+	if processErr != nil {
+		errText = sql.NullString{String: processErr.Error(), Valid: true}
+	}
+	if incidentIDs == nil {
+		incidentIDs = []string{}
+	}
+
+	_, err := s.PG.Exec(`
+		UPDATE webhook_events
+		SET processed_at = NOW(),
+		    incident_ids = ARRAY(SELECT DISTINCT unnest(incident_ids || $2::uuid[])),
+		    error = $3
+		WHERE id = $1
+	`, eventID, pq.Array(incidentIDs), errText)
+	if err != nil {
+		return fmt.Errorf("failed to complete webhook event: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookEvents returns an integration's most recent webhook events, newest first
+func (s *IntegrationService) GetWebhookEvents(integrationID string, limit int) ([]db.WebhookEvent, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	rows, err := s.PG.Query(`
+		SELECT `+webhookEventColumns+`
+		FROM webhook_events
+		WHERE integration_id = $1
+		ORDER BY received_at DESC
+		LIMIT $2
+	`, integrationID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []db.WebhookEvent{}
+	for rows.Next() {
+		event, err := scanWebhookEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// ReplayWebhookEvent queues a recorded webhook payload to be processed again and returns the
+// PGMQ message ID. Fingerprint dedup applies, so alerts with open incidents only count on them.
+// Events of integrations outside orgID are reported as ErrWebhookEventNotFound.
+func (s *IntegrationService) ReplayWebhookEvent(eventID, orgID string) (int64, error) {
+	event, err := scanWebhookEvent(s.PG.QueryRow(`
+		SELECT `+webhookEventColumns+`
+		FROM webhook_events
+		WHERE id = $1
+		  AND integration_id IN (SELECT id FROM integrations WHERE organization_id = $2)
+	`, eventID, orgID))
+	if err == sql.ErrNoRows {
+		return 0, ErrWebhookEventNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return s.EnqueueWebhook(WebhookQueueMessage{
+		IntegrationID:   event.IntegrationID,
+		IntegrationType: event.IntegrationType,
+		Payload:         event.Payload,
+		ReceivedAt:      event.ReceivedAt,
+		EventID:         event.ID,
+	})
+}
+
+// PruneWebhookEvents deletes webhook events received more than olderThanDays days ago and
+// returns how many were deleted. Meant to run periodically; 0 or less does nothing.
+func (s *IntegrationService) PruneWebhookEvents(olderThanDays int) (int64, error) {
+	if olderThanDays <= 0 {
+		return 0, nil
+	}
+
+	result, err := s.PG.Exec(`
+		DELETE FROM webhook_events
+		WHERE received_at < NOW() - make_interval(days => $1)
+	`, olderThanDays)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune webhook events: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// scanWebhookEvent scans a row selected with webhookEventColumns
+func scanWebhookEvent(row interface{ Scan(...interface{}) error }) (db.WebhookEvent, error) {
+	var event db.WebhookEvent
+	var payload []byte
+	var processedAt sql.NullTime
+	err := row.Scan(&event.ID, &event.IntegrationID, &event.IntegrationType, &payload,
+		&event.ReceivedAt, &processedAt, pq.Array(&event.IncidentIDs), &event.Error)
+	if err == sql.ErrNoRows {
+		return event, err
+	}
+	if err != nil {
+		return event, fmt.Errorf("failed to scan webhook event: %w", err)
+	}
+	event.Payload = payload
+	if processedAt.Valid {
+		event.ProcessedAt = &processedAt.Time
+	}
+	if event.IncidentIDs == nil {
+		event.IncidentIDs = []string{}
+	}
+	return event, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var webhookEventRowColumns = []string{
+	"id", "integration_id", "integration_type", "payload", "received_at", "processed_at", "incident_ids", "error",
+}
+
+func TestGetWebhookEvents(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	receivedAt := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	processedAt := receivedAt.Add(time.Second)
+	mockDB.ExpectQuery(`FROM webhook_events WHERE integration_id = \$1 ORDER BY received_at DESC LIMIT \$2`).
+		WithArgs("int-1", 50).
+		WillReturnRows(sqlmock.NewRows(webhookEventRowColumns).
+			AddRow("evt-2", "int-1", "webhook", []byte(`{"alert_name":"Watchdog"}`), receivedAt, nil, "{}", "").
+			AddRow("evt-1", "int-1", "webhook", []byte(`{"alert_name":"DiskFull"}`), receivedAt, processedAt, "{inc-1,inc-2}", ""))
+
+	service := NewIntegrationService(pg)
+	events, err := service.GetWebhookEvents("int-1", 0)
+
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		// Still waiting for the worker
+		assert.Nil(t, events[0].ProcessedAt)
+		assert.Empty(t, events[0].IncidentIDs)
+
+		assert.Equal(t, processedAt, *events[1].ProcessedAt)
+		assert.Equal(t, []string{"inc-1", "inc-2"}, events[1].IncidentIDs)
+		assert.JSONEq(t, `{"alert_name":"DiskFull"}`, string(events[1].Payload))
+	}
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestReplayWebhookEvent_QueuesRecordedPayload(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	receivedAt := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	mockDB.ExpectQuery(`FROM webhook_events\s+WHERE id = \$1\s+AND integration_id IN \(SELECT id FROM integrations WHERE organization_id = \$2\)`).
+		WithArgs("evt-1", "org-1").
+		WillReturnRows(sqlmock.NewRows(webhookEventRowColumns).
+			AddRow("evt-1", "int-1", "datadog", []byte(`{"title":"CPU high"}`), receivedAt, receivedAt, "{}", "failed to create incident"))
+	expected, _ := json.Marshal(WebhookQueueMessage{
+		IntegrationID:   "int-1",
+		IntegrationType: "datadog",
+		Payload:         json.RawMessage(`{"title":"CPU high"}`),
+		ReceivedAt:      receivedAt,
+		EventID:         "evt-1",
+	})
+	mockDB.ExpectQuery(`SELECT pgmq\.send\(\$1, \$2::jsonb\)`).
+		WithArgs(WebhookQueueName, string(expected)).
+		WillReturnRows(sqlmock.NewRows([]string{"send"}).AddRow(7))
+
+	// Unknown events, and other organizations' events, aren't queued
+	mockDB.ExpectQuery(`FROM webhook_events\s+WHERE id = \$1`).
+		WithArgs("evt-1", "org-2").
+		WillReturnRows(sqlmock.NewRows(webhookEventRowColumns))
+
+	service := NewIntegrationService(pg)
+	msgID, err := service.ReplayWebhookEvent("evt-1", "org-1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), msgID)
+
+	_, err = service.ReplayWebhookEvent("evt-1", "org-2")
+	assert.ErrorIs(t, err, ErrWebhookEventNotFound)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestPruneWebhookEvents(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec(`DELETE FROM webhook_events WHERE received_at < NOW\(\) - make_interval\(days => \$1\)`).
+		WithArgs(30).
+		WillReturnResult(sqlmock.NewResult(0, 12))
+
+	service := NewIntegrationService(pg)
+	pruned, err := service.PruneWebhookEvents(30)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), pruned)
+
+	// Retention disabled: nothing is deleted
+	pruned, err = service.PruneWebhookEvents(0)
+	assert.NoError(t, err)
+	assert.Zero(t, pruned)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
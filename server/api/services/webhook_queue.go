@@ -0,0 +1,33 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// WebhookQueueName is the PGMQ queue holding accepted webhook payloads until they are routed
+const WebhookQueueName = "webhook_events"
+
+// WebhookQueueMessage is a webhook accepted by the API and waiting for the webhook worker
+type WebhookQueueMessage struct {
+	IntegrationID   string          `json:"integration_id"`
+	IntegrationType string          `json:"integration_type"`
+	Payload         json.RawMessage `json:"payload"`
+	ReceivedAt      time.Time       `json:"received_at"`
+	EventID         string          `json:"event_id,omitempty"` // The webhook_events row recording this payload
+}
+
+// EnqueueWebhook persists a webhook payload to the webhook queue and returns its PGMQ message ID
+func (s *IntegrationService) EnqueueWebhook(msg WebhookQueueMessage) (int64, error) {
+	msgJSON, err := json.Marshal(msg)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal webhook message: %w", err)
+	}
+
+	var msgID int64
+	if err := s.PG.QueryRow(`SELECT pgmq.send($1, $2::jsonb)`, WebhookQueueName, string(msgJSON)).Scan(&msgID); err != nil {
+		return 0, fmt.Errorf("failed to queue webhook: %w", err)
+	}
+	return msgID, nil
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/phonginreallife/inres/db"
+)
+
+// webhookRateScript takes a token from an integration's bucket (KEYS[1]) if one is left and
+// counts the request in the current minute (KEYS[2]). Buckets refill at ARGV[1] tokens per
+// second up to ARGV[2]; ARGV[3] is now in milliseconds. Returns 1 if the request is allowed.
+var webhookRateScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or burst
+local ts = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+
+redis.call('INCR', KEYS[2])
+redis.call('EXPIRE', KEYS[2], 120)
+return allowed
+`)
+
+// WebhookRateLimiter is a token bucket per integration that keeps a misbehaving monitor from
+// flooding incidents. Buckets live in Redis so every API replica shares them; without Redis
+// they are kept in memory, which only limits each replica on its own.
+type WebhookRateLimiter struct {
+	Redis *redis.Client
+
+	// DefaultPerMinute applies to integrations without rate_limit_per_minute in their
+	// config; 0 or less leaves them unlimited
+	DefaultPerMinute int
+
+	now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*localRateBucket
+}
+
+// localRateBucket is the in-memory bucket used when Redis isn't available
+type localRateBucket struct {
+	tokens  float64
+	updated time.Time
+	counts  map[int64]int64 // requests per unix minute
+}
+
+func NewWebhookRateLimiter(redis *redis.Client, defaultPerMinute int) *WebhookRateLimiter {
+	return &WebhookRateLimiter{
+		Redis:            redis,
+		DefaultPerMinute: defaultPerMinute,
+		now:              time.Now,
+		buckets:          make(map[string]*localRateBucket),
+	}
+}
+
+// Limits returns the webhooks per minute and burst allowed for an integration. Its config can
+// set "rate_limit_per_minute" (0 disables limiting) and "rate_limit_burst", which defaults to
+// a minute's worth of webhooks.
+func (l *WebhookRateLimiter) Limits(integration db.Integration) (perMinute, burst int) {
+	perMinute = l.DefaultPerMinute
+	if v, ok := configInt(integration.Config, "rate_limit_per_minute"); ok {
+		perMinute = v
+	}
+	if perMinute <= 0 {
+		return 0, 0
+	}
+
+	burst = perMinute
+	if v, ok := configInt(integration.Config, "rate_limit_burst"); ok && v > 0 {
+		burst = v
+	}
+	return perMinute, burst
+}
+
+// Allow takes a token for one webhook from the integration's bucket and reports whether the
+// webhook may be processed. If Redis fails the webhook is allowed; dropping alerts because
+// the limiter is down would be worse than a storm.
+func (l *WebhookRateLimiter) Allow(integration db.Integration) bool {
+	perMinute, burst := l.Limits(integration)
+	if perMinute <= 0 {
+		return true
+	}
+
+	now := l.now()
+	if l.Redis == nil {
+		return l.allowLocal(integration.ID, perMinute, burst, now)
+	}
+
+	allowed, err := webhookRateScript.Run(context.Background(), l.Redis,
+		[]string{webhookRateBucketKey(integration.ID), webhookRateCountKey(integration.ID, now)},
+		float64(perMinute)/60, burst, now.UnixMilli(),
+	).Int()
+	if err != nil {
+		log.Printf("WARNING: Webhook rate limiter unavailable for integration %s, allowing webhook: %v", integration.ID, err)
+		return true
+	}
+	return allowed == 1
+}
+
+// Status returns an integration's rate limit and its current rate, or nil if it is unlimited
+func (l *WebhookRateLimiter) Status(integration db.Integration) *db.IntegrationRateLimit {
+	perMinute, burst := l.Limits(integration)
+	if perMinute <= 0 {
+		return nil
+	}
+
+	now := l.now()
+	var current, previous int64
+	if l.Redis == nil {
+		current, previous = l.localCounts(integration.ID, now)
+	} else {
+		counts, err := l.Redis.MGet(context.Background(),
+			webhookRateCountKey(integration.ID, now),
+			webhookRateCountKey(integration.ID, now.Add(-time.Minute)),
+		).Result()
+		if err != nil {
+			log.Printf("WARNING: Failed to get webhook rate for integration %s: %v", integration.ID, err)
+		} else {
+			current, previous = redisCount(counts[0]), redisCount(counts[1])
+		}
+	}
+
+	return &db.IntegrationRateLimit{
+		LimitPerMinute: perMinute,
+		Burst:          burst,
+		CurrentRate:    slidingMinuteRate(current, previous, now),
+	}
+}
+
+func (l *WebhookRateLimiter) allowLocal(integrationID string, perMinute, burst int, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[integrationID]
+	if !ok {
+		bucket = &localRateBucket{tokens: float64(burst), updated: now, counts: make(map[int64]int64)}
+		l.buckets[integrationID] = bucket
+	}
+
+	elapsed := now.Sub(bucket.updated).Seconds()
+	if elapsed > 0 {
+		bucket.tokens = math.Min(float64(burst), bucket.tokens+elapsed*float64(perMinute)/60)
+		bucket.updated = now
+	}
+
+	minute := now.Unix() / 60
+	bucket.counts[minute]++
+	for m := range bucket.counts {
+		if m < minute-1 {
+			delete(bucket.counts, m)
+		}
+	}
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+func (l *WebhookRateLimiter) localCounts(integrationID string, now time.Time) (current, previous int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[integrationID]
+	if !ok {
+		return 0, 0
+	}
+	minute := now.Unix() / 60
+	return bucket.counts[minute], bucket.counts[minute-1]
+}
+
+// slidingMinuteRate estimates the webhooks received over the last 60s from the counts of the
+// current and previous minutes, weighting the previous one by how much of it is still in range
+func slidingMinuteRate(current, previous int64, now time.Time) float64 {
+	elapsed := float64(now.Unix()%60) / 60
+	return float64(current) + float64(previous)*(1-elapsed)
+}
+
+func webhookRateBucketKey(integrationID string) string {
+	return "webhook_rate:" + integrationID + ":bucket"
+}
+
+func webhookRateCountKey(integrationID string, at time.Time) string {
+	return fmt.Sprintf("webhook_rate:%s:count:%d", integrationID, at.Unix()/60)
+}
+
+func redisCount(v interface{}) int64 {
+	s, ok := v.(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+// configInt reads a whole number from an integration's JSON config
+func configInt(config map[string]interface{}, key string) (int, bool) {
+	switch v := config[key].(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	case string:
+		n, err := strconv.Atoi(v)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// AllowWebhook reports whether a webhook for the integration is within its rate limit.
+// Always true when no rate limiter is set.
+func (s *IntegrationService) AllowWebhook(integration db.Integration) bool {
+	if s.RateLimiter == nil {
+		return true
+	}
+	return s.RateLimiter.Allow(integration)
+}
+
+// WebhookRateLimit returns an integration's webhook rate limit and current rate, or nil if
+// its webhooks aren't limited
+func (s *IntegrationService) WebhookRateLimit(integration db.Integration) *db.IntegrationRateLimit {
+	if s.RateLimiter == nil {
+		return nil
+	}
+	return s.RateLimiter.Status(integration)
+}
+
+// IncrementDroppedAlerts counts a webhook the rate limiter rejected against its integration
+func (s *IntegrationService) IncrementDroppedAlerts(integrationID string) error {
+	_, err := s.PG.Exec(`
+		UPDATE integrations SET dropped_alerts = dropped_alerts + 1 WHERE id = $1
+	`, integrationID)
+	if err != nil {
+		return fmt.Errorf("failed to increment dropped alerts: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/phonginreallife/inres/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRateLimiter(defaultPerMinute int, now *time.Time) *WebhookRateLimiter {
+	limiter := NewWebhookRateLimiter(nil, defaultPerMinute)
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestWebhookRateLimiter_TokenBucket(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	limiter := newTestRateLimiter(600, &now)
+	integration := db.Integration{ID: "int-1", Config: map[string]interface{}{
+		"rate_limit_per_minute": float64(60),
+		"rate_limit_burst":      float64(3),
+	}}
+
+	// The burst goes through, then the storm is cut off
+	for i := 0; i < 3; i++ {
+		assert.True(t, limiter.Allow(integration), "request %d", i)
+	}
+	assert.False(t, limiter.Allow(integration))
+
+	// One token a second refills
+	now = now.Add(time.Second)
+	assert.True(t, limiter.Allow(integration))
+	assert.False(t, limiter.Allow(integration))
+
+	// Other integrations have their own bucket
+	assert.True(t, limiter.Allow(db.Integration{ID: "int-2"}))
+
+	status := limiter.Status(integration)
+	if assert.NotNil(t, status) {
+		assert.Equal(t, 60, status.LimitPerMinute)
+		assert.Equal(t, 3, status.Burst)
+		assert.Equal(t, float64(6), status.CurrentRate)
+	}
+}
+
+func TestWebhookRateLimiter_Limits(t *testing.T) {
+	limiter := NewWebhookRateLimiter(nil, 600)
+
+	perMinute, burst := limiter.Limits(db.Integration{})
+	assert.Equal(t, 600, perMinute)
+	assert.Equal(t, 600, burst)
+
+	perMinute, burst = limiter.Limits(db.Integration{Config: map[string]interface{}{"rate_limit_per_minute": "30"}})
+	assert.Equal(t, 30, perMinute)
+	assert.Equal(t, 30, burst)
+
+	// 0 turns limiting off for the integration
+	unlimited := db.Integration{ID: "int-1", Config: map[string]interface{}{"rate_limit_per_minute": float64(0)}}
+	for i := 0; i < 1000; i++ {
+		assert.True(t, limiter.Allow(unlimited))
+	}
+	assert.Nil(t, limiter.Status(unlimited))
+}
+
+func TestSlidingMinuteRate(t *testing.T) {
+	// 15s into the minute, three quarters of the previous minute still counts
+	at := time.Date(2026, 10, 17, 9, 0, 15, 0, time.UTC)
+	assert.Equal(t, float64(10+30), slidingMinuteRate(10, 40, at))
+}
+
+func TestIncrementDroppedAlerts(t *testing.T) {
+	pg, mockDB, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer pg.Close()
+
+	mockDB.ExpectExec(`UPDATE integrations SET dropped_alerts = dropped_alerts \+ 1 WHERE id = \$1`).
+		WithArgs("int-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := NewIntegrationService(pg)
+	assert.NoError(t, service.IncrementDroppedAlerts("int-1"))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
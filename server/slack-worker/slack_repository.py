@@ -289,6 +289,26 @@ class SlackRepository:
         except Exception as e:
             logger.error(f"❌ Failed to delete message {msg_id}: {e}")
 
+    def dead_letter_message(self, queue_name: str, msg_id: int, message: Dict, reason: str, attempts: int) -> bool:
+        """Move a message that ran out of retries from its PGMQ queue to notification_dead_letters"""
+        try:
+            with self.db.cursor() as cursor:
+                cursor.execute(
+                    """
+                    WITH dead AS (
+                        INSERT INTO notification_dead_letters (queue_name, msg_id, message, error, attempts)
+                        VALUES (%s, %s, %s, %s, %s)
+                    )
+                    SELECT pgmq.delete(%s, %s::bigint)
+                    """,
+                    (queue_name, msg_id, json.dumps(message), reason, attempts, queue_name, msg_id)
+                )
+                logger.warning(f"☠️  Dead-lettered message {msg_id} from queue {queue_name}: {reason}")
+                return True
+        except Exception as e:
+            logger.error(f"❌ Failed to dead-letter message {msg_id}: {e}")
+            return False
+
     def read_queue_messages(self, queue_name: str, batch_size: int) -> List[Dict]:
         """Read messages from PGMQ"""
         try:
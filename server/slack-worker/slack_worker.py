@@ -347,6 +347,8 @@ class SlackWorker:
                 return self.send_incident_x_notification(user_data, incident_data, notification_msg, 'acknowledged')
             elif notification_type == 'resolved':
                 return self.send_incident_x_notification(user_data, incident_data, notification_msg, 'resolved')
+            elif notification_type == 'watcher_update':
+                return self.send_incident_watcher_notification(user_data, incident_data, notification_msg)
             else:
                 logger.warning(f"⚠️  Unknown notification type: {notification_type}")
                 return True
@@ -543,6 +545,44 @@ class SlackWorker:
             logger.error(f"❌ Failed to update Slack messages for {status} incident: {e}")
             return False
 
+    def send_incident_watcher_notification(self, user_data: Dict, incident_data: Dict, notification_msg: Dict) -> bool:
+        """Send a short Slack DM to a user watching an incident that changed"""
+        try:
+            slack_user_id = user_data['slack_user_id'].lstrip('@')
+            change = (notification_msg.get('data') or {}).get('change', 'updated')
+
+            incident_short_id = f"#{incident_data.get('id', '')[-8:]}"
+            incident_title = incident_data.get('title', 'Unknown Incident')
+            text = f"👀 Incident {incident_short_id} \"{incident_title}\" was {change}"
+
+            blocks = [{"type": "section", "text": {"type": "mrkdwn", "text": text}}]
+            if incident_data.get('id'):
+                blocks.append({
+                    "type": "actions",
+                    "elements": [
+                        {
+                            "type": "button",
+                            "text": {"type": "plain_text", "text": "View Incident"},
+                            "url": self.builder.get_incident_url(incident_data['id'])
+                        }
+                    ]
+                })
+
+            self.slack_client.chat_postMessage(
+                channel=f"@{slack_user_id}",
+                text=text,
+                blocks=blocks
+            )
+
+            notification_msg_with_recipient = notification_msg.copy()
+            notification_msg_with_recipient['recipient'] = f"@{slack_user_id}"
+            self.repo.log_notification(notification_msg_with_recipient, 'slack', True, None)
+            return True
+
+        except Exception as e:
+            logger.error(f"❌ Failed to send Slack watcher notification: {e}")
+            return False
+
     def send_new_acknowledgment_notification(self, user_data: Dict, incident_data: Dict, notification_msg: Dict) -> bool:
         """Send new Slack notification for incident acknowledgment (fallback)"""
         try:
@@ -646,6 +686,11 @@ class SlackWorker:
             routed_teams = self.repo.get_routed_teams(incident_data)
             blocks = self.builder.format_incident_blocks(incident_data, notification_msg, 'escalated', routed_teams)
             incident_message = SlackMessage(incident_data)
+
+            # The escalation level's rendered message template leads the notification
+            escalation_text = (notification_msg.get('data') or {}).get('message')
+            if escalation_text:
+                blocks.insert(0, {"type": "section", "text": {"type": "mrkdwn", "text": escalation_text}})
             
             # Add urgent action buttons
             if incident_data.get('id'):
@@ -669,7 +714,7 @@ class SlackWorker:
             
             response = self.slack_client.chat_postMessage(
                 channel=f"@{slack_user_id}",
-                text=f"🔄 [Escalated] {incident_message.get_title()}",
+                text=escalation_text or f"🔄 [Escalated] {incident_message.get_title()}",
                 blocks=blocks
             )
 
@@ -692,8 +737,10 @@ class SlackWorker:
         """Handle failed message processing with retry logic"""
         try:
             if read_ct > self.config['max_retries']:
-                logger.error(f"❌ Message {msg_id} exceeded max retries (read_ct={read_ct}), deleting")
-                self.repo.delete_message(queue_name, msg_id)
+                logger.error(f"❌ Message {msg_id} exceeded max retries (read_ct={read_ct}), dead-lettering")
+                # Left on the queue if this fails, so it is dead-lettered on a later read
+                reason = f"delivery failed after {read_ct} attempts"
+                self.repo.dead_letter_message(queue_name, msg_id, notification_msg, reason, read_ct)
             else:
                 logger.warning(f"⚠️  Message {msg_id} failed, read_ct: {read_ct}/{self.config['max_retries']}")
         except Exception as e:
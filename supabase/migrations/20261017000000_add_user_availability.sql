@@ -0,0 +1,21 @@
+-- Track when a user is temporarily unavailable for paging (DND, vacation)
+-- Escalation skips users whose unavailable_until is in the future and moves on
+-- to the next level instead of paging a silent phone
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS unavailable_until TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS unavailable_reason TEXT;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_unavailable_reason_valid;
+ALTER TABLE users ADD CONSTRAINT users_unavailable_reason_valid CHECK (
+    unavailable_reason IS NULL OR unavailable_reason = ANY (ARRAY['dnd', 'vacation']::text[])
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_unavailable_until ON users(unavailable_until)
+    WHERE unavailable_until IS NOT NULL;
+
+-- Escalation records for skipped targets use status 'skipped'
+-- (also allow the executing/completed statuses written by the escalation service)
+ALTER TABLE alert_escalations DROP CONSTRAINT IF EXISTS valid_escalation_status;
+ALTER TABLE alert_escalations ADD CONSTRAINT valid_escalation_status CHECK (
+    status = ANY (ARRAY['pending', 'sent', 'failed', 'acknowledged', 'timeout', 'executing', 'completed', 'skipped']::text[])
+);
@@ -0,0 +1,9 @@
+-- Snooze incidents: escalation pauses until snoozed_until, then resumes
+-- snoozed_escalation_status keeps the escalation_status to restore when the snooze ends
+
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMPTZ;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS snoozed_escalation_status TEXT;
+
+-- The incident worker polls for expired snoozes
+CREATE INDEX IF NOT EXISTS idx_incidents_snoozed_until ON incidents(snoozed_until)
+    WHERE snoozed_until IS NOT NULL;
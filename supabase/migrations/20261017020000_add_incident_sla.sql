@@ -0,0 +1,16 @@
+-- Per-incident SLA targets (minutes to acknowledge / to resolve)
+-- Incidents without their own targets fall back to their service's defaults
+
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS response_sla_minutes INTEGER
+    CHECK (response_sla_minutes IS NULL OR response_sla_minutes > 0);
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS resolution_sla_minutes INTEGER
+    CHECK (resolution_sla_minutes IS NULL OR resolution_sla_minutes > 0);
+
+ALTER TABLE services ADD COLUMN IF NOT EXISTS response_sla_minutes INTEGER
+    CHECK (response_sla_minutes IS NULL OR response_sla_minutes > 0);
+ALTER TABLE services ADD COLUMN IF NOT EXISTS resolution_sla_minutes INTEGER
+    CHECK (resolution_sla_minutes IS NULL OR resolution_sla_minutes > 0);
+
+-- The incident worker looks up already-recorded breaches per incident
+CREATE INDEX IF NOT EXISTS idx_incident_events_sla_breached ON incident_events(incident_id)
+    WHERE event_type = 'sla_breached';
@@ -0,0 +1,25 @@
+-- Deploy (change) events, ingested via webhook/API
+-- Incidents created shortly after a deploy to the same service are linked to it
+
+CREATE TABLE IF NOT EXISTS deploy_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
+    project_id UUID REFERENCES projects(id) ON DELETE SET NULL,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    version TEXT NOT NULL,
+    description TEXT,
+    environment TEXT,
+    source TEXT,        -- github, argocd, api, ...
+    deployed_by TEXT,
+    url TEXT,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    deployed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by UUID
+);
+
+CREATE INDEX IF NOT EXISTS idx_deploy_events_service_deployed ON deploy_events(service_id, deployed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_deploy_events_org_deployed ON deploy_events(organization_id, deployed_at DESC);
+
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS related_deploy_id UUID
+    REFERENCES deploy_events(id) ON DELETE SET NULL;
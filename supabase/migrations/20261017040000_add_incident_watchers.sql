@@ -0,0 +1,12 @@
+-- Users subscribed to an incident (beyond the assignee)
+-- Watchers are notified on acknowledge, resolve, update and escalation
+
+CREATE TABLE IF NOT EXISTS incident_watchers (
+    incident_id UUID NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (incident_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_watchers_user ON incident_watchers(user_id);
@@ -0,0 +1,2 @@
+-- Metric/graph snapshot captured from the alert (Grafana imageUrl, Datadog snapshot)
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS snapshot_url TEXT;
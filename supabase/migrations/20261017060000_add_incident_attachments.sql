@@ -0,0 +1,13 @@
+-- Links kept on an incident for remediation context (postmortems, runbooks, screenshots, logs)
+
+CREATE TABLE IF NOT EXISTS incident_attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    incident_id UUID NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title TEXT,
+    kind TEXT NOT NULL CHECK (kind IN ('postmortem', 'runbook', 'screenshot', 'log')),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_attachments_incident ON incident_attachments(incident_id, created_at);
@@ -0,0 +1,66 @@
+-- PagerDuty-style dedup: at most one open incident per incident_key in an organization
+
+-- Newer open duplicates are resolved so the index can be built; the oldest incident keeps the key
+CREATE TEMP TABLE duplicate_open_incidents AS
+SELECT id, survivor_id, alert_count
+FROM (
+    SELECT id, COALESCE(alert_count, 1) AS alert_count,
+           FIRST_VALUE(id) OVER w AS survivor_id,
+           ROW_NUMBER() OVER w AS rn
+    FROM incidents
+    WHERE organization_id IS NOT NULL
+      AND incident_key IS NOT NULL AND incident_key <> ''
+      AND status IN ('triggered', 'acknowledged')
+    WINDOW w AS (PARTITION BY organization_id, incident_key ORDER BY created_at, id)
+) ranked
+WHERE rn > 1;
+
+-- The survivor counts the duplicates' alerts, as if they had been attached to it
+UPDATE incidents i
+SET alert_count = COALESCE(i.alert_count, 1) + merged.alert_count,
+    updated_at = NOW()
+FROM (
+    SELECT survivor_id, SUM(alert_count) AS alert_count
+    FROM duplicate_open_incidents
+    GROUP BY survivor_id
+) merged
+WHERE i.id = merged.survivor_id;
+
+-- Resolve the duplicates and stop their escalation, as resolving them in the app would
+UPDATE incidents i
+SET status = 'resolved',
+    resolved_at = NOW(),
+    updated_at = NOW(),
+    escalation_status = CASE
+        WHEN COALESCE(i.snoozed_escalation_status, i.escalation_status) IN ('none', 'pending') THEN 'stopped'
+        ELSE COALESCE(i.snoozed_escalation_status, i.escalation_status)
+    END,
+    snoozed_until = NULL,
+    snoozed_escalation_status = NULL
+FROM duplicate_open_incidents d
+WHERE i.id = d.id;
+
+-- Escalations still waiting on a duplicate won't get a response
+UPDATE alert_escalations ae
+SET status = 'skipped'
+FROM duplicate_open_incidents d
+WHERE ae.alert_id = d.id::text
+  AND ae.status IN ('pending', 'sent', 'executing', 'completed');
+
+-- System event on each duplicate's timeline pointing to the incident that kept the key
+INSERT INTO incident_events (incident_id, event_type, event_data, created_by, created_at)
+SELECT d.id, 'resolved',
+       jsonb_build_object(
+           'resolution', 'duplicate',
+           'note', 'Resolved as a duplicate of an open incident with the same incident_key',
+           'duplicate_of', d.survivor_id
+       ),
+       NULL, NOW()
+FROM duplicate_open_incidents d;
+
+DROP TABLE duplicate_open_incidents;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_incidents_org_open_incident_key
+    ON incidents(organization_id, incident_key)
+    WHERE incident_key IS NOT NULL AND incident_key <> ''
+      AND status IN ('triggered', 'acknowledged');
@@ -0,0 +1,9 @@
+-- When each user was last paged for a group
+-- Used to prefer the least recently paged user when several are on call at once
+
+CREATE TABLE IF NOT EXISTS oncall_pages (
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_paged_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (group_id, user_id)
+);
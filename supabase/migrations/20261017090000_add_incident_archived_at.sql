@@ -0,0 +1,8 @@
+-- Archived incidents are hidden from default incident lists but kept for reporting
+
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+-- Default lists only read unarchived incidents
+CREATE INDEX IF NOT EXISTS idx_incidents_unarchived
+    ON incidents (organization_id, created_at DESC)
+    WHERE archived_at IS NULL;
@@ -0,0 +1,12 @@
+-- Audit trail of platform-admin actions that bypass tenant isolation
+-- e.g. cross-organization incident listing for support
+
+CREATE TABLE IF NOT EXISTS platform_audit_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    action TEXT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_platform_audit_logs_actor ON platform_audit_logs(actor_id, created_at DESC);
@@ -0,0 +1,11 @@
+-- Delivery and read receipts reported back by notification channels (Slack, FCM)
+-- notification_logs.status moves pending -> sent -> delivered -> read
+
+ALTER TABLE notification_logs
+    ADD COLUMN IF NOT EXISTS delivered_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS read_at TIMESTAMPTZ;
+
+-- Channels report receipts by their own message ID
+CREATE INDEX IF NOT EXISTS idx_notification_logs_external_message
+    ON notification_logs(channel, external_message_id)
+    WHERE external_message_id IS NOT NULL;
@@ -0,0 +1,5 @@
+-- Create webhook_events queue for asynchronous webhook processing
+-- The webhook endpoint persists the raw payload here and returns 202; the webhook worker
+-- routes it to incidents. Processed messages are archived (pgmq.a_webhook_events) for replay.
+
+SELECT pgmq.create('webhook_events');
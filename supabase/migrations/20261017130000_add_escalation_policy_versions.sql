@@ -0,0 +1,20 @@
+-- Escalation policy version history
+-- Each update of a policy snapshots the version it replaces (policy fields and levels), so
+-- past escalations can be explained. Incidents record the version they escalated under.
+
+ALTER TABLE escalation_policies ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS escalation_policy_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    policy_id UUID NOT NULL REFERENCES escalation_policies(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    snapshot JSONB NOT NULL,
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_to TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (policy_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_escalation_policy_versions_policy ON escalation_policy_versions(policy_id, valid_from);
+
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS escalation_policy_version INTEGER;
@@ -0,0 +1,15 @@
+-- Alerts the webhook worker could not route after retrying. Operators inspect them and
+-- replay them once the cause is fixed; replay goes through fingerprint dedup again.
+
+CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    integration_id UUID NOT NULL REFERENCES integrations(id) ON DELETE CASCADE,
+    payload JSONB NOT NULL,
+    error TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    replayed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_dead_letters_integration
+    ON webhook_dead_letters(integration_id, created_at DESC);
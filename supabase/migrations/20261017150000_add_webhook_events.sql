@@ -0,0 +1,19 @@
+-- Raw webhook payloads kept for audit and replay, with the incidents each one produced.
+-- Distinct from the webhook_events PGMQ queue (pgmq.q_webhook_events), which only holds
+-- payloads until the webhook worker routes them. Pruned by the webhook worker.
+
+CREATE TABLE IF NOT EXISTS webhook_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    integration_id UUID NOT NULL REFERENCES integrations(id) ON DELETE CASCADE,
+    integration_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMPTZ,
+    incident_ids UUID[] NOT NULL DEFAULT '{}',
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_events_integration
+    ON webhook_events(integration_id, received_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_events_received_at ON webhook_events(received_at);
@@ -0,0 +1,23 @@
+-- On hold pending external: an incident blocked on a vendor ticket. The incident worker polls
+-- the ticket and resolves the incident when the vendor closes it.
+
+ALTER TABLE incidents DROP CONSTRAINT IF EXISTS valid_status;
+ALTER TABLE incidents ADD CONSTRAINT valid_status
+    CHECK (status IN ('triggered', 'acknowledged', 'external_pending', 'resolved'));
+
+CREATE TABLE IF NOT EXISTS incident_external_tickets (
+    incident_id UUID PRIMARY KEY REFERENCES incidents(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL CHECK (provider IN ('github', 'jira', 'generic')),
+    url TEXT NOT NULL,
+    status TEXT,
+    checked_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- An incident on hold is still open, so incident_key dedup keeps covering it
+DROP INDEX IF EXISTS idx_incidents_org_open_incident_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_incidents_org_open_incident_key
+    ON incidents(organization_id, incident_key)
+    WHERE incident_key IS NOT NULL AND incident_key <> ''
+      AND status IN ('triggered', 'acknowledged', 'external_pending');
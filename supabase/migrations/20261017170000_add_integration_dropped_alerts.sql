@@ -0,0 +1,5 @@
+-- Webhooks rejected by the per-integration rate limiter (HTTP 429). The limiter itself
+-- lives in Redis; this only keeps the running count shown on the integration.
+
+ALTER TABLE integrations
+    ADD COLUMN IF NOT EXISTS dropped_alerts BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,36 @@
+-- Rules and decision log for alert routing tables. alert_routing_tables already exists; the
+-- webhook worker now evaluates active tables by priority before picking a service, and logs
+-- every match. alert_id holds the alert's fingerprint for webhook alerts.
+
+CREATE TABLE IF NOT EXISTS alert_routing_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    routing_table_id UUID NOT NULL REFERENCES alert_routing_tables(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 50,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    match_conditions JSONB NOT NULL DEFAULT '{}',
+    target_group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    escalation_rule_id UUID REFERENCES escalation_rules(id) ON DELETE SET NULL,
+    time_conditions JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_routing_rules_table
+    ON alert_routing_rules(routing_table_id, priority DESC) WHERE is_active = true;
+
+CREATE TABLE IF NOT EXISTS alert_route_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    alert_id TEXT NOT NULL DEFAULT '',
+    routing_table_id UUID REFERENCES alert_routing_tables(id) ON DELETE SET NULL,
+    routing_rule_id UUID REFERENCES alert_routing_rules(id) ON DELETE SET NULL,
+    target_group_id UUID REFERENCES groups(id) ON DELETE SET NULL,
+    matched_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    matched_reason TEXT NOT NULL DEFAULT '',
+    match_conditions JSONB,
+    alert_attributes JSONB,
+    evaluation_time_ms INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_route_logs_alert ON alert_route_logs(alert_id, matched_at DESC);
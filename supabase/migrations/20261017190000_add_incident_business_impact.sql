@@ -0,0 +1,7 @@
+-- Business impact of an incident: customers affected, revenue lost per minute and impacted
+-- services, e.g. {"customers_affected": 1200, "revenue_per_minute": 350.5, "services": ["checkout"]}
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS business_impact JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_incidents_business_impact
+    ON incidents (organization_id, created_at)
+    WHERE business_impact IS NOT NULL;
@@ -0,0 +1,7 @@
+-- Routing tables belong to an organization and only route its alerts. Tables without an
+-- organization are never evaluated.
+ALTER TABLE alert_routing_tables
+    ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_alert_routing_tables_org
+    ON alert_routing_tables(organization_id, priority DESC) WHERE is_active = true;
@@ -0,0 +1,7 @@
+-- One escalation record per (alert, level, target), so a retried escalation step does not
+-- notify a target twice. Skipped records have no key.
+ALTER TABLE alert_escalations
+    ADD COLUMN IF NOT EXISTS idempotency_key TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alert_escalations_idempotency_key
+    ON alert_escalations(idempotency_key);
@@ -0,0 +1,18 @@
+-- Escalation steps waiting for the previous step's timeout. The incident worker fires due
+-- steps; acknowledging or closing the alert cancels them.
+
+CREATE TABLE IF NOT EXISTS scheduled_escalations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    alert_id TEXT NOT NULL,
+    escalation_policy_id UUID NOT NULL REFERENCES escalation_policies(id) ON DELETE CASCADE,
+    step_number INT NOT NULL,
+    fire_at TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT valid_scheduled_escalation_status CHECK (status IN ('pending', 'fired', 'cancelled')),
+    UNIQUE (alert_id, step_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_escalations_due
+    ON scheduled_escalations(fire_at) WHERE status = 'pending';
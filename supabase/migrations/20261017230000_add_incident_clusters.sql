@@ -0,0 +1,7 @@
+-- Incidents with highly similar labels opened close together are grouped under a parent
+-- "cluster" incident (source 'cluster'), so responders see one rollup during an outage.
+ALTER TABLE incidents
+    ADD COLUMN IF NOT EXISTS cluster_id UUID REFERENCES incidents(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_incidents_cluster_id
+    ON incidents(cluster_id) WHERE cluster_id IS NOT NULL;
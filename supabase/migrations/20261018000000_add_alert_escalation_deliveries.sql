@@ -0,0 +1,4 @@
+-- Per-user, per-channel outcome of the notifications sent for an alert escalation
+-- ([{"user_id", "channel", "status", "error"}]), so a failed page can be traced to its channel
+ALTER TABLE alert_escalations
+    ADD COLUMN IF NOT EXISTS deliveries JSONB;
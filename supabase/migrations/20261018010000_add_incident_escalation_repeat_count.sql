@@ -0,0 +1,4 @@
+-- Passes of the escalation policy restarted for an incident, so the worker honors
+-- escalation_policies.repeat_max_times across restarts. Reset when the incident reopens.
+ALTER TABLE incidents
+    ADD COLUMN IF NOT EXISTS escalation_repeat_count INTEGER NOT NULL DEFAULT 0;
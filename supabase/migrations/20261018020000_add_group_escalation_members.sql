@@ -0,0 +1,23 @@
+-- Sequential and round robin groups page one member at a time. Each row tracks the members
+-- still to page for one group escalation; the incident worker pages the next member when the
+-- previous one's timeout passes, and acknowledging or closing the alert cancels the row.
+
+CREATE TABLE IF NOT EXISTS group_escalation_members (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    alert_escalation_id UUID NOT NULL REFERENCES alert_escalations(id) ON DELETE CASCADE,
+    alert_id TEXT NOT NULL,
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    member_ids TEXT[] NOT NULL,
+    next_position INT NOT NULL,
+    timeout_minutes INT NOT NULL,
+    notification_methods TEXT[] NOT NULL DEFAULT '{}',
+    next_at TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT valid_group_escalation_member_status CHECK (status IN ('pending', 'firing', 'done', 'cancelled')),
+    UNIQUE (alert_escalation_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_escalation_members_due
+    ON group_escalation_members(next_at) WHERE status = 'pending';
@@ -0,0 +1,16 @@
+-- Queued notifications that ran out of delivery attempts. Workers move a message here instead
+-- of deleting it, so operators can see why it failed and put it back on its queue.
+
+CREATE TABLE IF NOT EXISTS notification_dead_letters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    queue_name TEXT NOT NULL,
+    msg_id BIGINT NOT NULL,
+    message JSONB NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    replayed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_dead_letters_created_at
+    ON notification_dead_letters(created_at DESC);
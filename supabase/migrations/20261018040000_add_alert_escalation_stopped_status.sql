@@ -0,0 +1,7 @@
+-- Escalations still waiting for a response when their incident or alert is resolved without an
+-- acknowledgement are marked stopped
+
+ALTER TABLE alert_escalations DROP CONSTRAINT IF EXISTS valid_escalation_status;
+ALTER TABLE alert_escalations ADD CONSTRAINT valid_escalation_status CHECK (
+    status = ANY (ARRAY['pending', 'sent', 'failed', 'acknowledged', 'timeout', 'executing', 'completed', 'skipped', 'stopped']::text[])
+);
@@ -0,0 +1,9 @@
+-- Estimated resolution time given when acknowledging an incident. The incident worker nudges
+-- the acknowledger once it passes with the incident still acknowledged, recording when in
+-- eta_nudged_at so each ETA is nudged once.
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS ack_eta TIMESTAMPTZ;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS eta_nudged_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_incidents_ack_eta
+    ON incidents (ack_eta)
+    WHERE status = 'acknowledged' AND ack_eta IS NOT NULL AND eta_nudged_at IS NULL;
@@ -0,0 +1,19 @@
+-- Platform operators allowed to read across organizations (GET /admin/incidents).
+-- Kept out of users.role so no user-facing endpoint can grant it; rows are added by
+-- operators directly in the database.
+
+CREATE TABLE IF NOT EXISTS platform_admins (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    granted_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- No policies: the table is only reachable with the service role
+ALTER TABLE platform_admins ENABLE ROW LEVEL SECURITY;
+
+-- Carry over anyone granted the old role, then drop the grant from users.role
+INSERT INTO platform_admins (user_id, granted_by)
+SELECT id, 'users.role migration' FROM users WHERE role = 'platform_admin'
+ON CONFLICT (user_id) DO NOTHING;
+
+UPDATE users SET role = 'engineer' WHERE role = 'platform_admin';
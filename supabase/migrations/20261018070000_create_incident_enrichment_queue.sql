@@ -0,0 +1,5 @@
+-- Create incident_enrichment queue for the async incident creation fast path
+-- Webhook alerts insert a minimal incident and queue it here; the enrichment worker resolves
+-- its service and assignee and sends its events and notifications.
+
+SELECT pgmq.create('incident_enrichment');
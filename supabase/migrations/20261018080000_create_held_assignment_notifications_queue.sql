@@ -0,0 +1,5 @@
+-- Create held_assignment_notifications queue for ASSIGNMENT_NOTIFICATION_DELAY_SECONDS
+-- New incidents' assignment notifications are sent here with the delay, so they survive a
+-- restart; the incident worker sends them once readable unless the incident was resolved.
+
+SELECT pgmq.create('held_assignment_notifications');